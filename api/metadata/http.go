@@ -0,0 +1,52 @@
+package metadata
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cnsync/kratos/transport/http"
+)
+
+// OperationMetadataGetServiceDescriptor is the operation of downloading a service descriptor set.
+const OperationMetadataGetServiceDescriptor = "/kratos.api.Metadata/GetServiceDescriptor"
+
+// DescriptorContentType is the content type of the downloaded descriptor set.
+const DescriptorContentType = "application/x-protobuf"
+
+// RegisterHTTPServer registers the metadata service on the HTTP server, so that
+// non-gRPC tooling can inspect the services:
+//
+//	GET /services                     lists the services and methods.
+//	GET /services/{name}              returns the file descriptor set of the service as JSON.
+//	GET /services/{name}/descriptor   downloads the binary file descriptor set, e.g. for grpcurl -protoset.
+//
+// A server created by NewServer(nil) describes the services in the global proto registry.
+func RegisterHTTPServer(s *http.Server, srv *Server) {
+	RegisterMetadataHTTPServer(s, srv)
+	r := s.Route("/")
+	r.GET("/services/{name}/descriptor", _Metadata_GetServiceDescriptor_HTTP_Handler(srv))
+}
+
+func _Metadata_GetServiceDescriptor_HTTP_Handler(srv MetadataHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in GetServiceDescRequest
+		if err := ctx.BindVars(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationMetadataGetServiceDescriptor)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.GetServiceDesc(ctx, req.(*GetServiceDescRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		data, err := proto.Marshal(out.(*GetServiceDescReply).GetFileDescSet())
+		if err != nil {
+			return err
+		}
+		ctx.Response().Header().Set("Content-Disposition", `attachment; filename="`+in.Name+`.protoset"`)
+		return ctx.Blob(200, DescriptorContentType, data)
+	}
+}
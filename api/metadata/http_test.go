@@ -0,0 +1,67 @@
+package metadata
+
+import (
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/cnsync/kratos/transport/http"
+)
+
+func TestRegisterHTTPServer(t *testing.T) {
+	s := http.NewServer()
+	RegisterHTTPServer(s, NewServer(nil))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := nethttp.Get(srv.URL + "/services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Services []string `json:"services"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, name := range list.Services {
+		found = found || name == "kratos.api.Metadata"
+	}
+	if !found {
+		t.Fatalf("expected kratos.api.Metadata in %v", list.Services)
+	}
+
+	resp, err = nethttp.Get(srv.URL + "/services/kratos.api.Metadata/descriptor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK || resp.Header.Get("Content-Type") != DescriptorContentType {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var fds dpb.FileDescriptorSet
+	if err = proto.Unmarshal(data, &fds); err != nil {
+		t.Fatal(err)
+	}
+	if len(fds.File) == 0 || fds.File[len(fds.File)-1].GetName() != "metadata.proto" {
+		t.Errorf("unexpected descriptor set %v", fds.File)
+	}
+
+	resp, err = nethttp.Get(srv.URL + "/services/unknown.Service/descriptor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
@@ -3,6 +3,7 @@ package kratos
 import (
 	"context"
 	"errors"
+	"github.com/cnsync/kratos/internal/endpoint"
 	"github.com/cnsync/kratos/log"
 	"github.com/cnsync/kratos/registry"
 	"github.com/cnsync/kratos/transport"
@@ -32,6 +33,9 @@ type App struct {
 	cancel   context.CancelFunc
 	mu       sync.Mutex
 	instance *registry.ServiceInstance
+
+	observers     observers
+	stopHeartbeat func()
 }
 
 // New create an application lifecycle manager.
@@ -80,7 +84,10 @@ func (a *App) Endpoint() []string {
 }
 
 // Run executes all OnStart hooks registered with the application's Lifecycle.
-func (a *App) Run() error {
+func (a *App) Run() (err error) {
+	defer func() {
+		a.emit(LifecycleEvent{Type: EventStopped, Err: err})
+	}()
 	instance, err := a.buildInstance()
 	if err != nil {
 		return err
@@ -97,6 +104,7 @@ func (a *App) Run() error {
 			return err
 		}
 	}
+	a.emit(LifecycleEvent{Type: EventServersStarting})
 	for _, srv := range a.opts.servers {
 		server := srv
 		eg.Go(func() error {
@@ -115,15 +123,17 @@ func (a *App) Run() error {
 	if a.opts.registrar != nil {
 		rctx, rcancel := context.WithTimeout(ctx, a.opts.registrarTimeout)
 		defer rcancel()
-		if err = a.opts.registrar.Register(rctx, instance); err != nil {
+		if err = a.register(rctx, instance); err != nil {
 			return err
 		}
+		a.emit(LifecycleEvent{Type: EventRegistered})
 	}
 	for _, fn := range a.opts.afterStart {
 		if err = fn(sctx); err != nil {
 			return err
 		}
 	}
+	a.emit(LifecycleEvent{Type: EventStarted})
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, a.opts.sigs...)
@@ -131,7 +141,8 @@ func (a *App) Run() error {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-c:
+		case sig := <-c:
+			a.emit(LifecycleEvent{Type: EventSignalReceived, Signal: sig})
 			return a.Stop()
 		}
 	})
@@ -147,6 +158,7 @@ func (a *App) Run() error {
 
 // Stop gracefully stops the application.
 func (a *App) Stop() (err error) {
+	a.emit(LifecycleEvent{Type: EventStopping})
 	sctx := NewContext(a.ctx, a)
 	for _, fn := range a.opts.beforeStop {
 		err = fn(sctx)
@@ -154,9 +166,19 @@ func (a *App) Stop() (err error) {
 
 	a.mu.Lock()
 	instance := a.instance
+	stopHeartbeat := a.stopHeartbeat
+	a.stopHeartbeat = nil
 	a.mu.Unlock()
+	// stop renewing the lease first, so the instance is not registered again after it is deregistered
+	if stopHeartbeat != nil {
+		stopHeartbeat()
+	}
 	if a.opts.registrar != nil && instance != nil {
-		ctx, cancel := context.WithTimeout(NewContext(a.ctx, a), a.opts.registrarTimeout)
+		timeout := a.opts.deregisterTimeout
+		if timeout <= 0 {
+			timeout = a.opts.registrarTimeout
+		}
+		ctx, cancel := context.WithTimeout(NewContext(a.ctx, a), timeout)
 		defer cancel()
 		if err = a.opts.registrar.Deregister(ctx, instance); err != nil {
 			return err
@@ -180,6 +202,10 @@ func (a *App) buildInstance() (*registry.ServiceInstance, error) {
 				if err != nil {
 					return nil, err
 				}
+				// unix sockets are only reachable locally, so they are not registered
+				if endpoint.IsUnix(e) {
+					continue
+				}
 				endpoints = append(endpoints, e.String())
 			}
 		}
@@ -188,11 +214,34 @@ func (a *App) buildInstance() (*registry.ServiceInstance, error) {
 		ID:        a.opts.id,
 		Name:      a.opts.name,
 		Version:   a.opts.version,
-		Metadata:  a.opts.metadata,
+		Metadata:  a.instanceMetadata(),
 		Endpoints: endpoints,
 	}, nil
 }
 
+// instanceMetadata merges the instance metadata of the servers with the app metadata,
+// the app metadata takes precedence.
+func (a *App) instanceMetadata() map[string]string {
+	var md map[string]string
+	for _, srv := range a.opts.servers {
+		if p, ok := srv.(transport.InstanceMetadataProvider); ok {
+			for k, v := range p.InstanceMetadata() {
+				if md == nil {
+					md = make(map[string]string)
+				}
+				md[k] = v
+			}
+		}
+	}
+	if md == nil {
+		return a.opts.metadata
+	}
+	for k, v := range a.opts.metadata {
+		md[k] = v
+	}
+	return md
+}
+
 type appKey struct{}
 
 // NewContext returns a new Context that carries value.
@@ -182,6 +182,25 @@ func TestApp_buildInstance(t *testing.T) {
 	}
 }
 
+func TestApp_buildInstanceMetadata(t *testing.T) {
+	app := New(
+		Metadata(map[string]string{"zone": "us-east-1a"}),
+		Endpoint(&url.URL{Scheme: "http", Host: "127.0.0.1:8000"}),
+		Server(
+			http.NewServer(http.Weight(10), http.InstanceMetadata(map[string]string{"zone": "ap-east-1a", "canary": "true"})),
+			grpc.NewServer(grpc.InstanceMetadata(map[string]string{"protocol": "grpc"})),
+		),
+	)
+	got, err := app.buildInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"zone": "us-east-1a", "weight": "10", "canary": "true", "protocol": "grpc"}
+	if !reflect.DeepEqual(got.Metadata, want) {
+		t.Errorf("Metadata() = %v, want %v", got.Metadata, want)
+	}
+}
+
 func TestApp_Context(t *testing.T) {
 	type fields struct {
 		id       string
@@ -283,3 +302,144 @@ func TestApp_Context(t *testing.T) {
 		})
 	}
 }
+
+func TestApp_Subscribe(t *testing.T) {
+	app := New(
+		Name("kratos"),
+		Server(http.NewServer()),
+		Registrar(&mockRegistry{service: make(map[string]*registry.ServiceInstance)}),
+	)
+	var (
+		mu     sync.Mutex
+		events []LifecycleEventType
+	)
+	app.Subscribe(func(e LifecycleEvent) {
+		mu.Lock()
+		events = append(events, e.Type)
+		mu.Unlock()
+		if e.Type == EventStarted {
+			go func() { _ = app.Stop() }()
+		}
+	})
+	unsubscribe := app.Subscribe(func(LifecycleEvent) {
+		t.Error("unexpected event after unsubscribe")
+	})
+	unsubscribe()
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []LifecycleEventType{EventServersStarting, EventRegistered, EventStarted, EventStopping, EventStopped}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+}
+
+func TestApp_SubscribeError(t *testing.T) {
+	wantErr := errors.New("before start failed")
+	app := New(BeforeStart(func(context.Context) error { return wantErr }))
+	var stopped LifecycleEvent
+	app.Subscribe(func(e LifecycleEvent) { stopped = e })
+	if err := app.Run(); !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if stopped.Type != EventStopped || !errors.Is(stopped.Err, wantErr) {
+		t.Errorf("expected stopped event with error, got %+v", stopped)
+	}
+}
+
+type mockLease struct {
+	r   *mockTTLRegistry
+	ttl time.Duration
+	id  int
+}
+
+func (l *mockLease) TTL() time.Duration { return l.ttl }
+
+func (l *mockLease) Heartbeat(context.Context) error {
+	l.r.lk.Lock()
+	defer l.r.lk.Unlock()
+	l.r.heartbeats++
+	if l.r.lost || l.id != l.r.leases {
+		return registry.ErrLeaseLost
+	}
+	return nil
+}
+
+type mockTTLRegistry struct {
+	mockRegistry
+	ttl        time.Duration
+	leases     int
+	heartbeats int
+	lost       bool
+}
+
+func (r *mockTTLRegistry) RegisterLease(ctx context.Context, service *registry.ServiceInstance) (registry.Lease, error) {
+	if err := r.Register(ctx, service); err != nil {
+		return nil, err
+	}
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.leases++
+	r.lost = false
+	return &mockLease{r: r, ttl: r.ttl, id: r.leases}, nil
+}
+
+// expire drops the lease as the registry does after missed heartbeats.
+func (r *mockTTLRegistry) expire() {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.lost = true
+}
+
+func (r *mockTTLRegistry) stats() (int, int, int) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return r.leases, r.heartbeats, len(r.service)
+}
+
+func TestApp_Heartbeat(t *testing.T) {
+	r := &mockTTLRegistry{mockRegistry: mockRegistry{service: make(map[string]*registry.ServiceInstance)}, ttl: 30 * time.Millisecond}
+	app := New(Name("kratos"), Server(http.NewServer()), Registrar(r))
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+
+	waitFor := func(cond func(leases, heartbeats, services int) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond(r.stats()) {
+			if time.Now().After(deadline) {
+				leases, heartbeats, services := r.stats()
+				t.Fatalf("timeout: leases=%d heartbeats=%d services=%d", leases, heartbeats, services)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	// the lease is renewed at intervals of about a third of its TTL
+	waitFor(func(leases, heartbeats, _ int) bool { return leases == 1 && heartbeats >= 3 })
+	// the instance is registered again once the lease is lost
+	r.expire()
+	waitFor(func(leases, _, _ int) bool { return leases == 2 })
+
+	if err := app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// the heartbeat is stopped before deregistration, so the instance stays deregistered
+	r.expire()
+	time.Sleep(50 * time.Millisecond)
+	if leases, _, services := r.stats(); leases != 2 || services != 0 {
+		t.Errorf("expected deregistered instance, got leases=%d services=%d", leases, services)
+	}
+}
+
+func TestHeartbeatInterval(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := heartbeatInterval(30 * time.Second); d < 9*time.Second || d > 11*time.Second {
+			t.Fatalf("expected about 10s, got %v", d)
+		}
+	}
+}
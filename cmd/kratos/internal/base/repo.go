@@ -2,6 +2,7 @@ package base
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net"
 	"os"
@@ -15,9 +16,29 @@ var unExpandVarPath = []string{"~", ".", ".."}
 
 // Repo is git repository manager.
 type Repo struct {
-	url    string
-	home   string
-	branch string
+	url     string
+	home    string
+	branch  string
+	token   string
+	offline bool
+	local   bool
+}
+
+// RepoOption is repository option.
+type RepoOption func(*Repo)
+
+// WithToken with the access token used to clone private repositories over http(s).
+func WithToken(token string) RepoOption {
+	return func(r *Repo) {
+		r.token = token
+	}
+}
+
+// WithOffline with offline mode, the cached repository is used without pulling from remote.
+func WithOffline(offline bool) RepoOption {
+	return func(r *Repo) {
+		r.offline = offline
+	}
 }
 
 func repoDir(url string) string {
@@ -38,17 +59,25 @@ func repoDir(url string) string {
 	return url
 }
 
-// NewRepo new a repository manager.
-func NewRepo(url string, branch string) *Repo {
-	return &Repo{
-		url:    url,
-		home:   kratosHomeWithDir("repo/" + repoDir(url)),
-		branch: branch,
+// NewRepo new a repository manager, url could also be a local template directory.
+func NewRepo(url string, branch string, opts ...RepoOption) *Repo {
+	r := &Repo{url: url, branch: branch}
+	for _, o := range opts {
+		o(r)
 	}
+	if info, err := os.Stat(url); err == nil && info.IsDir() {
+		r.local = true
+		return r
+	}
+	r.home = kratosHomeWithDir("repo/" + repoDir(url))
+	return r
 }
 
 // Path returns the repository cache path.
 func (r *Repo) Path() string {
+	if r.local {
+		return r.url
+	}
 	start := strings.LastIndex(r.url, "/")
 	end := strings.LastIndex(r.url, ".git")
 	if end == -1 {
@@ -71,7 +100,7 @@ func (r *Repo) Pull(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	cmd = exec.CommandContext(ctx, "git", "pull")
+	cmd = exec.CommandContext(ctx, "git", r.args("pull")...)
 	cmd.Dir = r.Path()
 	out, err := cmd.CombinedOutput()
 	fmt.Println(string(out))
@@ -83,14 +112,23 @@ func (r *Repo) Pull(ctx context.Context) error {
 
 // Clone clones the repository to cache path.
 func (r *Repo) Clone(ctx context.Context) error {
+	if r.local {
+		return nil
+	}
 	if _, err := os.Stat(r.Path()); !os.IsNotExist(err) {
+		if r.offline {
+			return nil
+		}
 		return r.Pull(ctx)
 	}
+	if r.offline {
+		return fmt.Errorf("repository %s is not cached, run without --offline first", r.url)
+	}
 	var cmd *exec.Cmd
 	if r.branch == "" {
-		cmd = exec.CommandContext(ctx, "git", "clone", r.url, r.Path())
+		cmd = exec.CommandContext(ctx, "git", r.args("clone", r.url, r.Path())...)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "clone", "-b", r.branch, r.url, r.Path())
+		cmd = exec.CommandContext(ctx, "git", r.args("clone", "-b", r.branch, r.url, r.Path())...)
 	}
 	out, err := cmd.CombinedOutput()
 	fmt.Println(string(out))
@@ -100,6 +138,16 @@ func (r *Repo) Clone(ctx context.Context) error {
 	return nil
 }
 
+// args returns the git arguments, the access token is passed as an http header
+// so that it is not persisted in the cached repository config.
+func (r *Repo) args(args ...string) []string {
+	if r.token == "" || !strings.HasPrefix(r.url, "http") {
+		return args
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + r.token))
+	return append([]string{"-c", "http.extraHeader=Authorization: Basic " + auth}, args...)
+}
+
 // CopyTo copies the repository to project path.
 func (r *Repo) CopyTo(ctx context.Context, to string, modPath string, ignores []string) error {
 	if err := r.Clone(ctx); err != nil {
@@ -3,6 +3,8 @@ package base
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -49,3 +51,48 @@ func TestRepoClone(t *testing.T) {
 		os.RemoveAll("/tmp/test_repo")
 	})
 }
+
+func TestRepoLocal(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/go-kratos/kratos-layout\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // github.com/go-kratos/kratos-layout\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRepo(dir, "")
+	if r.Path() != dir {
+		t.Fatalf("expected %s, got %s", dir, r.Path())
+	}
+	to := filepath.Join(t.TempDir(), "helloworld")
+	if err := r.CopyTo(context.Background(), to, "helloworld", nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(to, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package main // helloworld\n" {
+		t.Errorf("unexpected content: %s", data)
+	}
+}
+
+func TestRepoOffline(t *testing.T) {
+	r := NewRepo("https://example.com/not-cached/layout.git", "", WithOffline(true))
+	if err := r.Clone(context.Background()); err == nil {
+		t.Fatal("expected error for an uncached repo in offline mode")
+	}
+}
+
+func TestRepoArgs(t *testing.T) {
+	r := NewRepo("https://example.com/private/layout.git", "", WithToken("secret"))
+	args := r.args("clone")
+	if len(args) != 3 || args[0] != "-c" || !strings.HasPrefix(args[1], "http.extraHeader=Authorization: Basic ") {
+		t.Errorf("unexpected args: %v", args)
+	}
+	// the token is not used for ssh urls
+	r = NewRepo("git@example.com:private/layout.git", "", WithToken("secret"))
+	if args = r.args("clone"); len(args) != 1 {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
@@ -0,0 +1,225 @@
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+)
+
+// CmdDoctor represents the doctor command.
+var CmdDoctor = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the environment and project",
+	Long:  "Check the toolchain, proto plugins and project configuration, and print the fixes. Example: kratos doctor",
+	Run:   run,
+}
+
+// kratosModules are the module paths of kratos.
+var kratosModules = []string{"github.com/cnsync/kratos", "github.com/go-kratos/kratos/v2"}
+
+// plugins are the protoc plugins used by kratos proto commands.
+var plugins = []string{"protoc-gen-go", "protoc-gen-go-grpc", "protoc-gen-go-http", "protoc-gen-go-errors", "protoc-gen-openapi"}
+
+// result is the result of a check.
+type result struct {
+	name string
+	ok   bool
+	warn bool
+	msg  string
+	fix  string
+}
+
+func run(cmd *cobra.Command, _ []string) {
+	var results []result
+	results = append(results, checkCommand("go", "version"))
+	results = append(results, checkCommand("protoc", "--version"))
+	results = append(results, checkPlugins()...)
+	results = append(results, checkModule("go.mod", cmd.Root().Version))
+	results = append(results, checkProtos(".")...)
+
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.ok:
+			fmt.Printf("%s %s: %s\n", color.GreenString("✔"), r.name, r.msg)
+		case r.warn:
+			fmt.Printf("%s %s: %s\n", color.YellowString("!"), r.name, r.msg)
+		default:
+			failed++
+			fmt.Printf("%s %s: %s\n", color.RedString("✘"), r.name, r.msg)
+		}
+		if !r.ok && r.fix != "" {
+			fmt.Printf("    👉 %s\n", r.fix)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d problem(s) found\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("\nNo problems found")
+}
+
+// checkCommand checks the command is installed and prints its version.
+func checkCommand(name string, args ...string) result {
+	r := result{name: name}
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		r.msg = "not found"
+		switch name {
+		case "go":
+			r.fix = "install Go from https://go.dev/dl/"
+		case "protoc":
+			r.fix = "install protoc from https://github.com/protocolbuffers/protobuf/releases"
+		}
+		return r
+	}
+	r.ok = true
+	r.msg = firstLine(string(out))
+	return r
+}
+
+// checkPlugins checks the protoc plugins are installed.
+func checkPlugins() []result {
+	res := make([]result, 0, len(plugins))
+	for _, name := range plugins {
+		r := result{name: name}
+		if _, err := exec.LookPath(name); err != nil {
+			r.msg = "not found"
+			r.fix = "run `kratos upgrade` to install the proto plugins"
+			res = append(res, r)
+			continue
+		}
+		r.ok = true
+		r.msg = "installed"
+		if out, err := exec.Command(name, "--version").CombinedOutput(); err == nil {
+			r.msg = firstLine(string(out))
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// checkModule checks the kratos version required by the project matches the CLI version.
+func checkModule(filename string, release string) result {
+	r := result{name: "go.mod"}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		r.warn = true
+		r.msg = "not in a Go module, skip the project checks"
+		return r
+	}
+	f, err := modfile.Parse(filename, data, nil)
+	if err != nil {
+		r.msg = err.Error()
+		r.fix = "fix the syntax error of go.mod"
+		return r
+	}
+	for _, req := range f.Require {
+		for _, mod := range kratosModules {
+			if req.Mod.Path != mod {
+				continue
+			}
+			// the module has no major version suffix, so v2 releases are required as +incompatible
+			if release == "" || strings.TrimSuffix(req.Mod.Version, "+incompatible") == release {
+				r.ok = true
+				r.msg = fmt.Sprintf("%s %s", mod, req.Mod.Version)
+				return r
+			}
+			r.warn = true
+			r.msg = fmt.Sprintf("%s %s does not match the kratos CLI %s", mod, req.Mod.Version, release)
+			r.fix = fmt.Sprintf("run `go get %s@%s` or `go install github.com/cnsync/kratos/cmd/kratos@%s`", mod, release, req.Mod.Version)
+			return r
+		}
+	}
+	r.warn = true
+	r.msg = "kratos is not required by the module"
+	return r
+}
+
+var (
+	packageRe = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*;`)
+	declRe    = regexp.MustCompile(`^(message|service|enum)\s+(\w+)`)
+)
+
+// checkProtos checks the proto declarations are not registered more than once,
+// which panics at startup with "proto: duplicate proto type registered".
+func checkProtos(root string) []result {
+	decls := make(map[string][]string)
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); path != root && (name == "third_party" || name == "vendor" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".proto" {
+			return nil
+		}
+		for _, name := range protoDecls(path) {
+			decls[name] = append(decls[name], path)
+		}
+		return nil
+	})
+	var res []result
+	for name, files := range decls {
+		if len(files) > 1 {
+			res = append(res, result{
+				name: "proto",
+				msg:  fmt.Sprintf("%s is declared in %s", name, strings.Join(files, ", ")),
+				fix:  "rename the declaration or the proto package so that each full name is registered once",
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].msg < res[j].msg })
+	return res
+}
+
+// protoDecls returns the full names of the top-level declarations of the proto file.
+func protoDecls(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var (
+		pkg   string
+		names []string
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := packageRe.FindStringSubmatch(line); m != nil {
+			pkg = m[1]
+			continue
+		}
+		if m := declRe.FindStringSubmatch(line); m != nil {
+			names = append(names, m[2])
+		}
+	}
+	for i, name := range names {
+		if pkg != "" {
+			names[i] = pkg + "." + name
+		}
+	}
+	return names
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckModule(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "go.mod")
+	writeFile(t, filename, "module helloworld\n\ngo 1.23\n\nrequire github.com/cnsync/kratos v2.8.1+incompatible\n")
+
+	if r := checkModule(filename, "v2.8.1"); !r.ok {
+		t.Errorf("expected ok, got %+v", r)
+	}
+	if r := checkModule(filename, "v2.8.2"); r.ok || !r.warn || r.fix == "" {
+		t.Errorf("expected version mismatch warning, got %+v", r)
+	}
+	if r := checkModule(filepath.Join(dir, "missing.mod"), "v2.8.2"); !r.warn {
+		t.Errorf("expected warning, got %+v", r)
+	}
+}
+
+func TestCheckProtos(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "api/helloworld/v1/greeter.proto"), `syntax = "proto3";
+package helloworld.v1;
+service Greeter {}
+message HelloRequest {
+  message Inner {}
+}
+`)
+	writeFile(t, filepath.Join(dir, "api/helloworld/v1/copy.proto"), `syntax = "proto3";
+package helloworld.v1;
+message HelloRequest {}
+message Inner {}
+`)
+	writeFile(t, filepath.Join(dir, "third_party/helloworld.proto"), `package helloworld.v1;
+message HelloRequest {}
+`)
+	res := checkProtos(dir)
+	if len(res) != 1 || !strings.Contains(res[0].msg, "helloworld.v1.HelloRequest") {
+		t.Errorf("unexpected results: %+v", res)
+	}
+}
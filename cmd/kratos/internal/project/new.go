@@ -19,7 +19,7 @@ type Project struct {
 }
 
 // New new a project from remote repo.
-func (p *Project) New(ctx context.Context, dir string, layout string, branch string) error {
+func (p *Project) New(ctx context.Context, dir string, layout string, branch string, opts ...base.RepoOption) error {
 	to := filepath.Join(dir, p.Name)
 	if _, err := os.Stat(to); !os.IsNotExist(err) {
 		fmt.Printf("🚫 %s already exists\n", p.Name)
@@ -38,7 +38,7 @@ func (p *Project) New(ctx context.Context, dir string, layout string, branch str
 		os.RemoveAll(to)
 	}
 	fmt.Printf("🚀 Creating service %s, layout repo is %s, please wait a moment.\n\n", p.Name, layout)
-	repo := base.NewRepo(layout, branch)
+	repo := base.NewRepo(layout, branch, opts...)
 	if err := repo.CopyTo(ctx, to, p.Name, []string{".git", ".github"}); err != nil {
 		return err
 	}
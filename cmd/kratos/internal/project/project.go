@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -24,21 +25,32 @@ var CmdNew = &cobra.Command{
 }
 
 var (
-	repoURL string
-	branch  string
-	timeout string
-	nomod   bool
+	repoURL  string
+	template string
+	branch   string
+	timeout  string
+	nomod    bool
+	gitToken string
+	offline  bool
+	tidy     bool
+	hooks    []string
 )
 
 func init() {
 	if repoURL = os.Getenv("KRATOS_LAYOUT_REPO"); repoURL == "" {
 		repoURL = "https://github.com/go-kratos/kratos-layout.git"
 	}
+	gitToken = os.Getenv("KRATOS_GIT_TOKEN")
 	timeout = "60s"
 	CmdNew.Flags().StringVarP(&repoURL, "repo-url", "r", repoURL, "layout repo")
+	CmdNew.Flags().StringVarP(&template, "template", "", template, "layout template, a git/https repo url or a local directory, overrides --repo-url")
 	CmdNew.Flags().StringVarP(&branch, "branch", "b", branch, "repo branch")
 	CmdNew.Flags().StringVarP(&timeout, "timeout", "t", timeout, "time out")
 	CmdNew.Flags().BoolVarP(&nomod, "nomod", "", nomod, "retain go mod")
+	CmdNew.Flags().StringVarP(&gitToken, "token", "", gitToken, "access token of the private template repo over https, default is $KRATOS_GIT_TOKEN")
+	CmdNew.Flags().BoolVarP(&offline, "offline", "", offline, "use the cached layout without pulling from remote")
+	CmdNew.Flags().BoolVarP(&tidy, "tidy", "", tidy, "run go mod tidy after the project is created")
+	CmdNew.Flags().StringArrayVarP(&hooks, "hook", "", hooks, "command run in the project directory after it is created, can be repeated")
 }
 
 func run(_ *cobra.Command, args []string) {
@@ -67,10 +79,15 @@ func run(_ *cobra.Command, args []string) {
 	}
 	projectName, workingDir := processProjectParams(name, wd)
 	p := &Project{Name: projectName}
+	layout := repoURL
+	if template != "" {
+		layout = template
+	}
+	opts := []base.RepoOption{base.WithToken(gitToken), base.WithOffline(offline)}
 	done := make(chan error, 1)
 	go func() {
 		if !nomod {
-			done <- p.New(ctx, workingDir, repoURL, branch)
+			done <- p.New(ctx, workingDir, layout, branch, opts...)
 			return
 		}
 		projectRoot := getgomodProjectRoot(workingDir)
@@ -93,7 +110,7 @@ func run(_ *cobra.Command, args []string) {
 		}
 		// Get the relative path for adding a project based on Go modules
 		p.Path = filepath.Join(strings.TrimPrefix(workingDir, projectRoot+"/"), p.Name)
-		done <- p.Add(ctx, workingDir, repoURL, branch, mod, packagePath)
+		done <- p.Add(ctx, workingDir, layout, branch, mod, packagePath, opts...)
 	}()
 	select {
 	case <-ctx.Done():
@@ -105,8 +122,40 @@ func run(_ *cobra.Command, args []string) {
 	case err = <-done:
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "\033[31mERROR: Failed to create project(%s)\033[m\n", err.Error())
+			return
+		}
+		if err = runHooks(ctx, filepath.Join(workingDir, projectName), postHooks()); err != nil {
+			fmt.Fprintf(os.Stderr, "\033[31mERROR: Failed to run post-generation hook(%s)\033[m\n", err.Error())
+		}
+	}
+}
+
+// postHooks returns the commands run after the project is created.
+func postHooks() []string {
+	var res []string
+	if tidy {
+		res = append(res, "go mod tidy")
+	}
+	return append(res, hooks...)
+}
+
+// runHooks runs the commands in the project directory in order, stopping at the first failure.
+func runHooks(ctx context.Context, dir string, hooks []string) error {
+	for _, hook := range hooks {
+		args := strings.Fields(hook)
+		if len(args) == 0 {
+			continue
+		}
+		fmt.Printf("🔧 %s\n", hook)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", hook, err)
 		}
 	}
+	return nil
 }
 
 func processProjectParams(projectName string, workingDir string) (projectNameResult, workingDirResult string) {
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"fmt"
 	"go/parser"
 	"go/token"
@@ -142,3 +143,24 @@ func changeCurrentDir(t *testing.T) string {
 
 	return tmp
 }
+
+func TestRunHooks(t *testing.T) {
+	dir := t.TempDir()
+	if err := runHooks(context.Background(), dir, []string{"", "go mod init example.com/helloworld"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		t.Error(err)
+	}
+	if err := runHooks(context.Background(), dir, []string{"go not-a-command"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPostHooks(t *testing.T) {
+	tidy, hooks = true, []string{"git init"}
+	defer func() { tidy, hooks = false, nil }()
+	if got := postHooks(); len(got) != 2 || got[0] != "go mod tidy" || got[1] != "git init" {
+		t.Errorf("unexpected hooks: %v", got)
+	}
+}
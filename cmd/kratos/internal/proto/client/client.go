@@ -17,17 +17,76 @@ import (
 var CmdClient = &cobra.Command{
 	Use:   "client",
 	Short: "Generate the proto client code",
-	Long:  "Generate the proto client code. Example: kratos proto client helloworld.proto",
+	Long:  "Generate the proto client code. Example: kratos proto client helloworld.proto --target=go-http,go-grpc,ts",
 	Run:   run,
 }
 
-var protoPath string
+var (
+	protoPath string
+	targets   []string
+	tsOut     string
+)
+
+// target is a code generation target of the proto command.
+type target struct {
+	plugin  string // protoc plugin binary
+	out     string // protoc output flag
+	install string // go install path of the plugin, empty if installed by kratos upgrade
+}
+
+// defaultTargets are generated if no --target is specified.
+var defaultTargets = []string{"go-grpc", "go-http", "go-errors", "openapi"}
+
+var knownTargets = map[string]target{
+	"go":        {plugin: "protoc-gen-go", out: "--go_out=paths=source_relative:."},
+	"go-grpc":   {plugin: "protoc-gen-go-grpc", out: "--go-grpc_out=paths=source_relative:."},
+	"go-http":   {plugin: "protoc-gen-go-http", out: "--go-http_out=paths=source_relative:."},
+	"go-errors": {plugin: "protoc-gen-go-errors", out: "--go-errors_out=paths=source_relative:."},
+	"openapi":   {plugin: "protoc-gen-openapi", out: "--openapi_out=paths=source_relative:."},
+	"ts": {
+		plugin:  "protoc-gen-typescript-http",
+		out:     "--typescript-http_out=%s",
+		install: "github.com/einride/protoc-gen-typescript-http@latest",
+	},
+}
 
 func init() {
 	if protoPath = os.Getenv("KRATOS_PROTO_PATH"); protoPath == "" {
 		protoPath = "./third_party"
 	}
 	CmdClient.Flags().StringVarP(&protoPath, "proto_path", "p", protoPath, "proto path")
+	CmdClient.Flags().StringSliceVarP(&targets, "target", "t", defaultTargets, "generation targets: go-grpc, go-http, go-errors, openapi, ts")
+	CmdClient.Flags().StringVar(&tsOut, "ts_out", ".", "output directory of the TypeScript clients")
+}
+
+// resolve returns the protoc plugins and output flags of the targets.
+// Go messages are always generated along with the Go targets.
+func resolve(names []string) ([]target, error) {
+	var (
+		res  []target
+		seen = make(map[string]bool)
+	)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			t := knownTargets[name]
+			if strings.Contains(t.out, "%s") {
+				t.out = fmt.Sprintf(t.out, tsOut)
+			}
+			res = append(res, t)
+		}
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if _, ok := knownTargets[name]; !ok {
+			return nil, fmt.Errorf("unknown target %q, supported targets: go, go-grpc, go-http, go-errors, openapi, ts", name)
+		}
+		if strings.HasPrefix(name, "go") {
+			add("go")
+		}
+		add(name)
+	}
+	return res, nil
 }
 
 func run(_ *cobra.Command, args []string) {
@@ -39,26 +98,56 @@ func run(_ *cobra.Command, args []string) {
 		err   error
 		proto = strings.TrimSpace(args[0])
 	)
-	if err = look("protoc-gen-go", "protoc-gen-go-grpc", "protoc-gen-go-http", "protoc-gen-go-errors", "protoc-gen-openapi"); err != nil {
-		// update the kratos plugins
-		cmd := exec.Command("kratos", "upgrade")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err = cmd.Run(); err != nil {
-			fmt.Println(err)
-			return
-		}
+	plugins, err := resolve(targets)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err = install(plugins); err != nil {
+		fmt.Println(err)
+		return
 	}
 	if strings.HasSuffix(proto, ".proto") {
-		err = generate(proto, args)
+		err = generate(proto, plugins, args)
 	} else {
-		err = walk(proto, args)
+		err = walk(proto, plugins, args)
 	}
 	if err != nil {
 		fmt.Println(err)
 	}
 }
 
+// install installs the missing plugins of the targets.
+func install(plugins []target) error {
+	var (
+		upgrade  bool
+		installs []string
+	)
+	for _, p := range plugins {
+		if err := look(p.plugin); err == nil {
+			continue
+		}
+		if p.install != "" {
+			installs = append(installs, p.install)
+		} else {
+			upgrade = true
+		}
+	}
+	if upgrade {
+		// update the kratos plugins
+		cmd := exec.Command("kratos", "upgrade")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	if len(installs) > 0 {
+		return base.GoInstall(installs...)
+	}
+	return nil
+}
+
 func look(name ...string) error {
 	for _, n := range name {
 		if _, err := exec.LookPath(n); err != nil {
@@ -68,7 +157,7 @@ func look(name ...string) error {
 	return nil
 }
 
-func walk(dir string, args []string) error {
+func walk(dir string, plugins []target, args []string) error {
 	if dir == "" {
 		dir = "."
 	}
@@ -76,30 +165,27 @@ func walk(dir string, args []string) error {
 		if ext := filepath.Ext(path); ext != ".proto" || strings.HasPrefix(path, "third_party") {
 			return nil
 		}
-		return generate(path, args)
+		return generate(path, plugins, args)
 	})
 }
 
 // generate is used to execute the generate command for the specified proto file
-func generate(proto string, args []string) error {
+func generate(proto string, plugins []target, args []string) error {
 	input := []string{
 		"--proto_path=.",
 	}
 	if pathExists(protoPath) {
 		input = append(input, "--proto_path="+protoPath)
 	}
-	inputExt := []string{
-		"--proto_path=" + base.KratosMod(),
-		"--proto_path=" + filepath.Join(base.KratosMod(), "third_party"),
-		"--go_out=paths=source_relative:.",
-		"--go-grpc_out=paths=source_relative:.",
-		"--go-http_out=paths=source_relative:.",
-		"--go-errors_out=paths=source_relative:.",
-		"--openapi_out=paths=source_relative:.",
+	input = append(input,
+		"--proto_path="+base.KratosMod(),
+		"--proto_path="+filepath.Join(base.KratosMod(), "third_party"),
+	)
+	for _, p := range plugins {
+		input = append(input, p.out)
 	}
-	input = append(input, inputExt...)
 	protoBytes, err := os.ReadFile(proto)
-	if err == nil && len(protoBytes) > 0 {
+	if err == nil && len(protoBytes) > 0 && hasGoTarget(plugins) {
 		if ok, _ := regexp.Match(`\n[^/]*(import)\s+"validate/validate.proto"`, protoBytes); ok {
 			input = append(input, "--validate_out=lang=go,paths=source_relative:.")
 		}
@@ -121,6 +207,16 @@ func generate(proto string, args []string) error {
 	return nil
 }
 
+// hasGoTarget reports whether Go code is generated.
+func hasGoTarget(plugins []target) bool {
+	for _, p := range plugins {
+		if p.plugin == "protoc-gen-go" {
+			return true
+		}
+	}
+	return false
+}
+
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
 	if err != nil {
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		targets []string
+		plugins []string
+		wantErr bool
+	}{
+		{defaultTargets, []string{"protoc-gen-go", "protoc-gen-go-grpc", "protoc-gen-go-http", "protoc-gen-go-errors", "protoc-gen-openapi"}, false},
+		{[]string{"go-http", "ts"}, []string{"protoc-gen-go", "protoc-gen-go-http", "protoc-gen-typescript-http"}, false},
+		{[]string{"ts"}, []string{"protoc-gen-typescript-http"}, false},
+		{[]string{"java"}, nil, true},
+	}
+	for _, test := range tests {
+		res, err := resolve(test.targets)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("resolve(%v) error = %v, wantErr %v", test.targets, err, test.wantErr)
+		}
+		var plugins []string
+		for _, p := range res {
+			plugins = append(plugins, p.plugin)
+		}
+		if !reflect.DeepEqual(plugins, test.plugins) {
+			t.Errorf("resolve(%v) = %v, want %v", test.targets, plugins, test.plugins)
+		}
+	}
+
+	tsOut = "web/src/api"
+	defer func() { tsOut = "." }()
+	res, _ := resolve([]string{"ts"})
+	if res[0].out != "--typescript-http_out=web/src/api" {
+		t.Errorf("unexpected ts output: %s", res[0].out)
+	}
+}
@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cnsync/kratos/cmd/kratos/internal/base"
+)
+
+// CmdOpenAPI represents the openapi command.
+var CmdOpenAPI = &cobra.Command{
+	Use:   "openapi",
+	Short: "Generate the OpenAPI document",
+	Long:  "Generate the OpenAPI document from google.api.http annotations. Example: kratos proto openapi api --out=internal/server",
+	Run:   run,
+}
+
+var (
+	protoPath string
+	out       string
+	title     string
+	version   string
+	naming    string
+)
+
+func init() {
+	if protoPath = os.Getenv("KRATOS_PROTO_PATH"); protoPath == "" {
+		protoPath = "./third_party"
+	}
+	CmdOpenAPI.Flags().StringVarP(&protoPath, "proto_path", "p", protoPath, "proto path")
+	CmdOpenAPI.Flags().StringVarP(&out, "out", "o", ".", "output directory of openapi.yaml")
+	CmdOpenAPI.Flags().StringVar(&title, "title", "", "title of the API")
+	CmdOpenAPI.Flags().StringVar(&version, "version", "", "version of the API")
+	CmdOpenAPI.Flags().StringVar(&naming, "naming", "json", "naming convention of the fields: json or proto")
+}
+
+func run(_ *cobra.Command, args []string) {
+	dir := "."
+	if len(args) > 0 {
+		dir = strings.TrimSpace(args[0])
+	}
+	if _, err := exec.LookPath("protoc-gen-openapi"); err != nil {
+		if err = base.GoInstall("github.com/google/gnostic/cmd/protoc-gen-openapi@latest"); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	protos, err := find(dir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(protos) == 0 {
+		fmt.Printf("No proto files found in %s\n", dir)
+		return
+	}
+	if err = generate(protos); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// find returns the proto files of the path, third_party protos are ignored.
+func find(path string) ([]string, error) {
+	if strings.HasSuffix(path, ".proto") {
+		return []string{path}, nil
+	}
+	var protos []string
+	err := filepath.Walk(path, func(p string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(p) == ".proto" && !strings.HasPrefix(p, "third_party") {
+			protos = append(protos, p)
+		}
+		return nil
+	})
+	return protos, err
+}
+
+// args returns the protoc arguments generating a single document for all the protos.
+func args(protos []string) []string {
+	input := []string{"--proto_path=."}
+	if _, err := os.Stat(protoPath); err == nil {
+		input = append(input, "--proto_path="+protoPath)
+	}
+	input = append(input,
+		"--proto_path="+base.KratosMod(),
+		"--proto_path="+filepath.Join(base.KratosMod(), "third_party"),
+		"--openapi_out="+out,
+		"--openapi_opt=naming="+naming,
+	)
+	if title != "" {
+		input = append(input, "--openapi_opt=title="+title)
+	}
+	if version != "" {
+		input = append(input, "--openapi_opt=version="+version)
+	}
+	return append(input, protos...)
+}
+
+func generate(protos []string) error {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	fd := exec.Command("protoc", args(protos)...)
+	fd.Stdout = os.Stdout
+	fd.Stderr = os.Stderr
+	if err := fd.Run(); err != nil {
+		return err
+	}
+	fmt.Printf("openapi: %s\n", filepath.Join(out, "openapi.yaml"))
+	fmt.Println("Serve it with http.ServeOpenAPI by embedding the document into the HTTP server.")
+	return nil
+}
@@ -0,0 +1,52 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api/helloworld/v1/greeter.proto", "api/helloworld/v1/greeter.pb.go", "api/user/v1/user.proto"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	protos, err := find(filepath.Join(dir, "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "api/helloworld/v1/greeter.proto"),
+		filepath.Join(dir, "api/user/v1/user.proto"),
+	}
+	if !reflect.DeepEqual(protos, want) {
+		t.Errorf("find() = %v, want %v", protos, want)
+	}
+	if protos, _ = find("greeter.proto"); !reflect.DeepEqual(protos, []string{"greeter.proto"}) {
+		t.Errorf("find() = %v", protos)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	out, title, naming = "docs", "Greeter API", "json"
+	defer func() { out, title = ".", "" }()
+	got := args([]string{"greeter.proto"})
+	for _, want := range []string{"--openapi_out=docs", "--openapi_opt=naming=json", "--openapi_opt=title=Greeter API", "greeter.proto"} {
+		found := false
+		for _, a := range got {
+			if a == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %q in %v", want, got)
+		}
+	}
+}
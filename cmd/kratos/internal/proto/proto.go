@@ -5,6 +5,7 @@ import (
 
 	"github.com/cnsync/kratos/cmd/kratos/internal/proto/add"
 	"github.com/cnsync/kratos/cmd/kratos/internal/proto/client"
+	"github.com/cnsync/kratos/cmd/kratos/internal/proto/openapi"
 	"github.com/cnsync/kratos/cmd/kratos/internal/proto/server"
 )
 
@@ -18,5 +19,6 @@ var CmdProto = &cobra.Command{
 func init() {
 	CmdProto.AddCommand(add.CmdAdd)
 	CmdProto.AddCommand(client.CmdClient)
+	CmdProto.AddCommand(openapi.CmdOpenAPI)
 	CmdProto.AddCommand(server.CmdServer)
 }
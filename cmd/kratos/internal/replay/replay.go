@@ -0,0 +1,212 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// CmdReplay represents the replay command.
+var CmdReplay = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay the recorded requests against an instance",
+	Long:  "Send the requests recorded by the record middleware again and compare the replies. Example: kratos replay -f records.jsonl -t http://127.0.0.1:8000 -H 'Authorization: Bearer dev-token'",
+	Run:   run,
+}
+
+// redactedValue mirrors config.RedactedValue, the record middleware writes it in place of sensitive headers.
+const redactedValue = "******"
+
+var (
+	file    string
+	target  string
+	timeout time.Duration
+	headers []string
+)
+
+func init() {
+	CmdReplay.Flags().StringVarP(&file, "file", "f", "records.jsonl", "the records file written by the record middleware")
+	CmdReplay.Flags().StringVarP(&target, "target", "t", "http://127.0.0.1:8000", "the base URL of the instance to replay against")
+	CmdReplay.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "the timeout of every request")
+	CmdReplay.Flags().StringArrayVarP(&headers, "header", "H", nil, "the header added to every request, e.g. 'Authorization: Bearer dev-token', it replaces the recorded one")
+}
+
+// Record is a recorded request and its reply, it mirrors the record middleware format.
+type Record struct {
+	Kind      string              `json:"kind"`
+	Operation string              `json:"operation"`
+	Method    string              `json:"method"`
+	Path      string              `json:"path"`
+	Header    map[string][]string `json:"header"`
+	Request   json.RawMessage     `json:"request"`
+	Reply     json.RawMessage     `json:"reply"`
+	Code      int                 `json:"code"`
+}
+
+// Result is the result of a replayed record.
+type Result struct {
+	Record  *Record
+	Code    int
+	Reply   []byte
+	Skipped bool
+	Err     error
+}
+
+// Match reports whether the replayed reply matches the recorded one.
+func (r *Result) Match() bool {
+	if r.Err != nil || r.Code != r.Record.Code {
+		return false
+	}
+	if r.Record.Reply == nil {
+		return true
+	}
+	return jsonEqual(r.Record.Reply, r.Reply)
+}
+
+func run(_ *cobra.Command, _ []string) {
+	header, err := parseHeaders(headers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+		os.Exit(1)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	client := &http.Client{Timeout: timeout}
+	var matched, mismatched, skipped int
+	err = Replay(f, client, target, header, func(r *Result) {
+		switch {
+		case r.Skipped:
+			skipped++
+		case r.Match():
+			matched++
+			fmt.Printf("%s %s %s\n", color.GreenString("✔"), r.Record.Method, r.Record.Path)
+		default:
+			mismatched++
+			msg := fmt.Sprintf("code %d, recorded %d", r.Code, r.Record.Code)
+			if r.Err != nil {
+				msg = r.Err.Error()
+			}
+			fmt.Printf("%s %s %s: %s\n", color.RedString("✘"), r.Record.Method, r.Record.Path, msg)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n%d matched, %d mismatched, %d skipped\n", matched, mismatched, skipped)
+	if mismatched > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseHeaders parses the "Key: Value" headers of the command line.
+func parseHeaders(values []string) (http.Header, error) {
+	header := make(http.Header, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, ":")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid header %q, expected 'Key: Value'", v)
+		}
+		header.Add(key, strings.TrimSpace(value))
+	}
+	return header, nil
+}
+
+// Replay sends the HTTP records read from r to the target, the gRPC records are skipped.
+// The header is added to every request in place of the recorded one, it carries the credentials
+// of the target since the record middleware redacts the recorded ones.
+func Replay(r io.Reader, client *http.Client, target string, header http.Header, fn func(*Result)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		fn(send(client, target, header, &record))
+	}
+	return scanner.Err()
+}
+
+// send sends the record to the target, the redacted headers are not sent.
+func send(client *http.Client, target string, header http.Header, record *Record) *Result {
+	result := &Result{Record: record}
+	if record.Kind != "http" || record.Method == "" {
+		result.Skipped = true
+		return result
+	}
+	var body io.Reader
+	if record.Method != http.MethodGet && record.Method != http.MethodDelete && len(record.Request) > 0 {
+		body = bytes.NewReader(record.Request)
+	}
+	req, err := http.NewRequest(record.Method, strings.TrimSuffix(target, "/")+record.Path, body)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	for key, values := range record.Header {
+		switch http.CanonicalHeaderKey(key) {
+		case "Content-Length", "Host", "Accept-Encoding":
+			continue
+		}
+		if redacted(values) {
+			continue
+		}
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		// the recorded message is JSON whatever the original content type is
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer res.Body.Close()
+	result.Code = res.StatusCode
+	result.Reply, result.Err = io.ReadAll(res.Body)
+	return result
+}
+
+// redacted reports whether the recorded header values were redacted by the record middleware.
+func redacted(values []string) bool {
+	for _, v := range values {
+		if v == redactedValue {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual reports whether the JSON documents are equal.
+func jsonEqual(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
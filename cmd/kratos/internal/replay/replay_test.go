@@ -0,0 +1,86 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `","body":` + string(body) + `}`))
+	}))
+	defer srv.Close()
+
+	records := strings.Join([]string{
+		`{"kind":"http","method":"POST","path":"/hello","request":{"name":"kratos"},"reply":{"body":{"name":"kratos"},"path":"/hello"},"code":200}`,
+		`{"kind":"http","method":"POST","path":"/changed","request":{"name":"kratos"},"reply":{"path":"/other"},"code":200}`,
+		`{"kind":"http","method":"GET","path":"/missing","code":404}`,
+		`{"kind":"grpc","operation":"/helloworld.Greeter/SayHello","code":200}`,
+	}, "\n")
+	var results []*Result
+	if err := Replay(strings.NewReader(records), srv.Client(), srv.URL, nil, func(r *Result) {
+		results = append(results, r)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if !results[0].Match() {
+		t.Errorf("expected the reply to match: %s", results[0].Reply)
+	}
+	if results[1].Match() {
+		t.Error("expected the reply to mismatch")
+	}
+	if !results[2].Match() {
+		t.Errorf("expected the code to match, got %d", results[2].Code)
+	}
+	if !results[3].Skipped {
+		t.Error("expected the grpc record to be skipped")
+	}
+}
+
+func TestReplay_Header(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"authorization":"` + r.Header.Get("Authorization") + `","cookie":"` + r.Header.Get("Cookie") + `","tenant":"` + r.Header.Get("X-Tenant") + `"}`))
+	}))
+	defer srv.Close()
+
+	// the redacted headers are not sent, the command line headers replace them
+	records := strings.Join([]string{
+		`{"kind":"http","method":"GET","path":"/","header":{"Authorization":["******"],"Cookie":["******"],"X-Tenant":["t1"]},"reply":{"authorization":"","cookie":"","tenant":"t1"},"code":200}`,
+		`{"kind":"http","method":"GET","path":"/","header":{"Authorization":["******"],"X-Tenant":["t1"]},"reply":{"authorization":"Bearer dev","cookie":"","tenant":"t1"},"code":200}`,
+	}, "\n")
+	header, err := parseHeaders([]string{"Authorization: Bearer dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []*Result
+	if err := Replay(strings.NewReader(records), srv.Client(), srv.URL, nil, func(r *Result) {
+		results = append(results, r)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replay(strings.NewReader(records), srv.Client(), srv.URL, header, func(r *Result) {
+		results = append(results, r)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i, match := range []bool{true, false, false, true} {
+		if results[i].Match() != match {
+			t.Errorf("result %d: expected match %v, got reply %s", i, match, results[i].Reply)
+		}
+	}
+
+	if _, err := parseHeaders([]string{"Authorization"}); err == nil {
+		t.Error("expected an error for the header without a value")
+	}
+}
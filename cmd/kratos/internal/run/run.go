@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -15,13 +16,19 @@ import (
 var CmdRun = &cobra.Command{
 	Use:   "run",
 	Short: "Run project",
-	Long:  "Run project. Example: kratos run",
+	Long:  "Run project. Example: kratos run, kratos run --watch",
 	Run:   Run,
 }
-var targetDir string
+var (
+	targetDir string
+	watch     bool
+	grace     time.Duration
+)
 
 func init() {
 	CmdRun.Flags().StringVarP(&targetDir, "work", "w", "", "target working directory")
+	CmdRun.Flags().BoolVar(&watch, "watch", false, "rebuild and restart the service when Go, proto or config files change")
+	CmdRun.Flags().DurationVar(&grace, "grace", 10*time.Second, "time to wait for the service to exit after SIGTERM in watch mode")
 }
 
 // Run run project.
@@ -68,6 +75,13 @@ func Run(cmd *cobra.Command, args []string) {
 			dir = cmdPath[dir]
 		}
 	}
+	if watch {
+		w := &watcher{root: base, dir: dir, programArgs: programArgs, interval: 500 * time.Millisecond, grace: grace}
+		if err := w.run(); err != nil {
+			fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err.Error())
+		}
+		return
+	}
 	fd := exec.Command("go", append([]string{"run", dir}, programArgs...)...)
 	fd.Stdout = os.Stdout
 	fd.Stderr = os.Stderr
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package run
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminate asks the process to exit gracefully.
+func terminate(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
+
+func isWindows() bool {
+	return false
+}
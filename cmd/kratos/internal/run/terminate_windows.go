@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package run
+
+import "os"
+
+// terminate kills the process, signals other than kill are not supported on Windows.
+func terminate(p *os.Process) error {
+	return p.Kill()
+}
+
+func isWindows() bool {
+	return true
+}
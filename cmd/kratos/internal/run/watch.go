@@ -0,0 +1,182 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// watchExts are the extensions of the files triggering a rebuild.
+var watchExts = map[string]bool{
+	".go":    true,
+	".proto": true,
+	".yaml":  true,
+	".yml":   true,
+	".json":  true,
+	".toml":  true,
+}
+
+// snapshot returns the modification times of the watched files under root.
+func snapshot(root string) (map[string]time.Time, error) {
+	files := make(map[string]time.Time)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "bin") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if watchExts[filepath.Ext(path)] {
+			files[path] = info.ModTime()
+		}
+		return nil
+	})
+	return files, err
+}
+
+// changed reports whether any watched file is added, removed or modified.
+func changed(old, cur map[string]time.Time) bool {
+	if len(old) != len(cur) {
+		return true
+	}
+	for path, mod := range cur {
+		if t, ok := old[path]; !ok || !t.Equal(mod) {
+			return true
+		}
+	}
+	return false
+}
+
+// watcher rebuilds and restarts the service when the watched files change.
+type watcher struct {
+	root        string
+	dir         string
+	programArgs []string
+	interval    time.Duration
+	grace       time.Duration
+
+	bin  string // the binary of the running service
+	next string // the binary being built, renamed to bin once the service is stopped
+	proc *exec.Cmd
+	done chan struct{}
+}
+
+// build builds the service binary to out.
+func (w *watcher) build(out string) error {
+	fd := exec.Command("go", "build", "-o", out, ".")
+	fd.Stdout = os.Stdout
+	fd.Stderr = os.Stderr
+	fd.Dir = w.dir
+	return fd.Run()
+}
+
+// start starts the service binary.
+func (w *watcher) start() error {
+	fd := exec.Command(w.bin, w.programArgs...)
+	fd.Stdout = os.Stdout
+	fd.Stderr = os.Stderr
+	fd.Dir = w.dir
+	changeWorkingDirectory(fd, targetDir)
+	if err := fd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = fd.Wait()
+		close(done)
+	}()
+	w.proc, w.done = fd, done
+	return nil
+}
+
+// stop sends SIGTERM to the service so the graceful shutdown of kratos executes,
+// the service is killed if it does not exit within the grace period.
+func (w *watcher) stop() {
+	if w.proc == nil {
+		return
+	}
+	select {
+	case <-w.done:
+	default:
+		if err := terminate(w.proc.Process); err != nil {
+			_ = w.proc.Process.Kill()
+		}
+		select {
+		case <-w.done:
+		case <-time.After(w.grace):
+			fmt.Fprintf(os.Stderr, "\033[33mWARN: the service did not exit in %s, killing it\033[m\n", w.grace)
+			_ = w.proc.Process.Kill()
+			<-w.done
+		}
+	}
+	w.proc = nil
+}
+
+// run builds and runs the service, and restarts it on changes until interrupted.
+func (w *watcher) run() error {
+	tmp, err := os.MkdirTemp("", "kratos-run")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	w.bin, w.next = filepath.Join(tmp, "app"), filepath.Join(tmp, "app-next")
+	if isWindows() {
+		w.bin += ".exe"
+		w.next += ".exe"
+	}
+
+	files, err := snapshot(w.root)
+	if err != nil {
+		return err
+	}
+	if err = w.build(w.bin); err == nil {
+		if err = w.start(); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "\033[31mERROR: build failed: %s\033[m\n", err)
+	}
+	defer w.stop()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sig:
+			return nil
+		case <-ticker.C:
+			cur, err := snapshot(w.root)
+			if err != nil || !changed(files, cur) {
+				continue
+			}
+			files = cur
+			fmt.Println("\033[32mFiles changed, rebuilding...\033[m")
+			// build beside the running binary, which could not be overwritten on Windows,
+			// and keep the running service if the build fails
+			if err = w.build(w.next); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mERROR: build failed: %s\033[m\n", err)
+				continue
+			}
+			w.stop()
+			if err = os.Rename(w.next, w.bin); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+				continue
+			}
+			if err = w.start(); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mERROR: %s\033[m\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go")
+	write("api/helloworld.proto")
+	write("configs/config.yaml")
+	write("README.md")
+	write(".git/HEAD.go")
+	write("vendor/lib/lib.go")
+
+	files, err := snapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 watched files, got %v", files)
+	}
+	cur, _ := snapshot(dir)
+	if changed(files, cur) {
+		t.Error("expected no change")
+	}
+
+	// 修改文件
+	later := time.Now().Add(time.Second)
+	if err = os.Chtimes(filepath.Join(dir, "main.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	cur, _ = snapshot(dir)
+	if !changed(files, cur) {
+		t.Error("expected modified file to be detected")
+	}
+
+	// 删除文件
+	files = cur
+	if err = os.Remove(filepath.Join(dir, "configs/config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	cur, _ = snapshot(dir)
+	if !changed(files, cur) {
+		t.Error("expected removed file to be detected")
+	}
+}
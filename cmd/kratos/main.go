@@ -6,8 +6,10 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cnsync/kratos/cmd/kratos/internal/change"
+	"github.com/cnsync/kratos/cmd/kratos/internal/doctor"
 	"github.com/cnsync/kratos/cmd/kratos/internal/project"
 	"github.com/cnsync/kratos/cmd/kratos/internal/proto"
+	"github.com/cnsync/kratos/cmd/kratos/internal/replay"
 	"github.com/cnsync/kratos/cmd/kratos/internal/run"
 	"github.com/cnsync/kratos/cmd/kratos/internal/upgrade"
 )
@@ -25,6 +27,8 @@ func init() {
 	rootCmd.AddCommand(upgrade.CmdUpgrade)
 	rootCmd.AddCommand(change.CmdChange)
 	rootCmd.AddCommand(run.CmdRun)
+	rootCmd.AddCommand(doctor.CmdDoctor)
+	rootCmd.AddCommand(replay.CmdReplay)
 }
 
 func main() {
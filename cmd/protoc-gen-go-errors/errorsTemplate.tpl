@@ -15,3 +15,9 @@ func Error{{ .CamelValue }}(format string, args ...interface{}) *errors.Error {
 }
 
 {{- end }}
+
+func init() {
+{{- range .Errors }}
+	errors.Register({{ .Name }}_{{ .Value }}.String(), {{ .HTTPCode }})
+{{- end }}
+}
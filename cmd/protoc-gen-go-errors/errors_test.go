@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func Test_case2Camel(t *testing.T) {
 	type args struct {
@@ -60,3 +63,19 @@ func Test_case2Camel(t *testing.T) {
 		})
 	}
 }
+
+func Test_errorWrapper_execute(t *testing.T) {
+	ew := &errorWrapper{Errors: []*errorInfo{
+		{Name: "ErrorReason", Value: "USER_NOT_FOUND", CamelValue: "UserNotFound", HTTPCode: 404},
+	}}
+	got := ew.execute()
+	for _, want := range []string{
+		"func IsUserNotFound(err error) bool",
+		"func ErrorUserNotFound(format string, args ...interface{}) *errors.Error",
+		"errors.Register(ErrorReason_USER_NOT_FOUND.String(), 404)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected generated code to contain %q, got:\n%s", want, got)
+		}
+	}
+}
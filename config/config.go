@@ -1,8 +1,11 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 	"time"
@@ -23,24 +26,30 @@ var _ Config = (*config)(nil)
 // ErrNotFound 表示在配置中未找到指定键。
 var ErrNotFound = errors.New("key not found")
 
+// ErrSetNotSupported 表示没有配置源支持写回配置。
+var ErrSetNotSupported = errors.New("config source does not support set")
+
 // Observer 是配置观察者的类型定义。
 type Observer func(string, Value)
 
 // Config 是配置接口。
 type Config interface {
-	Load() error                        // 加载配置
-	Scan(v interface{}) error           // 将配置解析到目标结构体
-	Value(key string) Value             // 获取指定键的配置值
-	Watch(key string, o Observer) error // 监听指定键的变化
-	Close() error                       // 关闭配置监听器
+	Load() error                               // 加载配置
+	Scan(v interface{}) error                  // 将配置解析到目标结构体
+	Value(key string) Value                    // 获取指定键的配置值
+	Watch(key string, o Observer) error        // 监听指定键的变化
+	Set(key string, value []byte) error        // 将配置写回支持 Setter 的配置源
+	Snapshot() (map[string]interface{}, error) // 返回合并并解析占位符后的完整配置
+	Close() error                              // 关闭配置监听器
 }
 
 type config struct {
-	opts      options   // 配置选项
-	reader    Reader    // 配置读取器
-	cached    sync.Map  // 缓存的配置键值对
-	observers sync.Map  // 监听器（键 -> Observer）
-	watchers  []Watcher // 配置源的监听器列表
+	opts      options    // 配置选项
+	reader    Reader     // 配置读取器
+	cached    sync.Map   // 缓存的配置键值对
+	observers sync.Map   // 监听器（键 -> Observer）
+	watchers  []Watcher  // 配置源的监听器列表
+	mu        sync.Mutex // 保护配置变更的应用
 }
 
 // New 创建一个配置实例并应用选项。
@@ -62,7 +71,7 @@ func New(opts ...Option) Config {
 }
 
 // watch 启动对配置源的监听，处理变更。
-func (c *config) watch(w Watcher) {
+func (c *config) watch(i int, w Watcher) {
 	for {
 		kvs, err := w.Next() // 获取下一个变更
 		if err != nil {
@@ -74,12 +83,11 @@ func (c *config) watch(w Watcher) {
 			log.Errorf("failed to watch next config: %v", err)
 			continue
 		}
-		if err := c.reader.Merge(kvs...); err != nil {
-			log.Errorf("failed to merge next config: %v", err)
-			continue
-		}
-		if err := c.reader.Resolve(); err != nil {
-			log.Errorf("failed to resolve next config: %v", err)
+		if err := c.apply(withSource(i, kvs)); err != nil {
+			log.Errorf("failed to apply next config: %v", err)
+			if c.opts.errorHandler != nil {
+				c.opts.errorHandler(err)
+			}
 			continue
 		}
 		// 遍历缓存并更新值
@@ -97,9 +105,40 @@ func (c *config) watch(w Watcher) {
 	}
 }
 
+// apply 合并、解析并校验变更的配置，失败时回滚到上一份有效的配置。
+func (c *config) apply(kvs []*KeyValue) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.reader.(*reader)
+	if !ok {
+		if err := c.reader.Merge(kvs...); err != nil {
+			return fmt.Errorf("merge: %w", err)
+		}
+		if err := c.reader.Resolve(); err != nil {
+			return fmt.Errorf("resolve: %w", err)
+		}
+		return nil
+	}
+	prev, prevRaw, prevKVs := r.snapshot()
+	if err := r.Merge(kvs...); err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	if err := r.Resolve(); err != nil {
+		r.restore(prev, prevRaw, prevKVs)
+		return fmt.Errorf("resolve: %w", err)
+	}
+	if c.opts.validator != nil {
+		if err := r.validate(c.opts.validator); err != nil {
+			r.restore(prev, prevRaw, prevKVs)
+			return fmt.Errorf("validate: %w", err)
+		}
+	}
+	return nil
+}
+
 // Load 加载配置并启动监听。
 func (c *config) Load() error {
-	for _, src := range c.opts.sources {
+	for i, src := range c.opts.sources {
 		kvs, err := src.Load()
 		if err != nil {
 			return err
@@ -107,7 +146,7 @@ func (c *config) Load() error {
 		for _, v := range kvs {
 			log.Debugf("config loaded: %s format: %s", v.Key, v.Format)
 		}
-		if err = c.reader.Merge(kvs...); err != nil {
+		if err = c.reader.Merge(withSource(i, kvs)...); err != nil {
 			log.Errorf("failed to merge config source: %v", err)
 			return err
 		}
@@ -117,12 +156,18 @@ func (c *config) Load() error {
 			return err
 		}
 		c.watchers = append(c.watchers, w)
-		go c.watch(w) // 异步启动监听
+		go c.watch(i, w) // 异步启动监听
 	}
 	if err := c.reader.Resolve(); err != nil {
 		log.Errorf("failed to resolve config source: %v", err)
 		return err
 	}
+	if r, ok := c.reader.(*reader); ok && c.opts.validator != nil {
+		if err := r.validate(c.opts.validator); err != nil {
+			log.Errorf("failed to validate config source: %v", err)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -144,7 +189,10 @@ func (c *config) Scan(v interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalJSON(data, v) // 使用 JSON 解码
+	if err = unmarshalJSON(data, v); err != nil { // 使用 JSON 解码
+		return err
+	}
+	return applyTags(data, v) // 应用默认值并检查必填键
 }
 
 // Watch 监听指定键的配置变化。
@@ -156,6 +204,31 @@ func (c *config) Watch(key string, o Observer) error {
 	return nil
 }
 
+// Set 将配置写入第一个实现了 Setter 的配置源，新值在配置源的 Watch 通知后生效。
+func (c *config) Set(key string, value []byte) error {
+	for _, src := range c.opts.sources {
+		if s, ok := src.(Setter); ok {
+			return s.Set(key, value)
+		}
+	}
+	return ErrSetNotSupported
+}
+
+// Snapshot 返回合并并解析占位符后的完整配置的副本，修改副本不会影响配置。
+func (c *config) Snapshot() (map[string]interface{}, error) {
+	data, err := c.reader.Source()
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber() // 保留整数的原始精度
+	values := make(map[string]interface{})
+	if err = d.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 // Close 关闭所有监听器。
 func (c *config) Close() error {
 	for _, w := range c.watchers {
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"dario.cat/mergo"
 )
@@ -215,3 +218,172 @@ func TestConfig(t *testing.T) {
 		t.Error("len(testConf.Endpoints) is not equal to 2")
 	}
 }
+
+// testSetterSource 定义了一个支持写回配置的测试数据源
+type testSetterSource struct {
+	*testJSONSource
+	kvs map[string][]byte
+}
+
+// Set 方法记录写入的配置
+func (p *testSetterSource) Set(key string, value []byte) error {
+	p.kvs[key] = value
+	return nil
+}
+
+// TestConfigSet 测试配置写回
+func TestConfigSet(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(_testJSON)))
+	if err := c.Set("feature.enabled", []byte("true")); !errors.Is(err, ErrSetNotSupported) {
+		t.Errorf("expected %v, got %v", ErrSetNotSupported, err)
+	}
+
+	s := &testSetterSource{testJSONSource: newTestJSONSource(_testJSON), kvs: map[string][]byte{}}
+	c = New(WithSource(newTestJSONSource(_testJSON), s))
+	if err := c.Set("feature.enabled", []byte("true")); err != nil {
+		t.Fatal(err)
+	}
+	if v := string(s.kvs["feature.enabled"]); v != "true" {
+		t.Errorf("expected %q, got %q", "true", v)
+	}
+}
+
+// testChanSource 定义了一个通过通道推送配置变化的测试数据源
+type testChanSource struct {
+	kv *KeyValue
+	ch chan *KeyValue
+}
+
+// Load 方法返回初始配置
+func (p *testChanSource) Load() ([]*KeyValue, error) {
+	return []*KeyValue{p.kv}, nil
+}
+
+// Watch 方法返回从通道读取配置变化的监听器
+func (p *testChanSource) Watch() (Watcher, error) {
+	return &testChanWatcher{ch: p.ch, exit: make(chan struct{})}, nil
+}
+
+// testChanWatcher 定义了一个从通道读取配置变化的监听器
+type testChanWatcher struct {
+	ch   chan *KeyValue
+	exit chan struct{}
+}
+
+// Next 方法等待下一个配置变化
+func (w *testChanWatcher) Next() ([]*KeyValue, error) {
+	select {
+	case kv := <-w.ch:
+		return []*KeyValue{kv}, nil
+	case <-w.exit:
+		return nil, context.Canceled
+	}
+}
+
+// Stop 方法停止监听器
+func (w *testChanWatcher) Stop() error {
+	close(w.exit)
+	return nil
+}
+
+// TestConfigValidator 测试配置校验
+func TestConfigValidator(t *testing.T) {
+	validator := func(values map[string]interface{}) error {
+		if port, _ := values["port"].(float64); port <= 0 {
+			return errors.New("port must be positive")
+		}
+		return nil
+	}
+	c := New(
+		WithSource(&testChanSource{kv: &KeyValue{Key: "app", Value: []byte(`{"port":0}`), Format: "json"}}),
+		WithValidator(validator),
+	)
+	if err := c.Load(); err == nil {
+		t.Fatal("expected validation error on load")
+	}
+	_ = c.Close()
+
+	source := &testChanSource{
+		kv: &KeyValue{Key: "app", Value: []byte(`{"port":8000}`), Format: "json"},
+		ch: make(chan *KeyValue),
+	}
+	errs := make(chan error, 1)
+	c = New(
+		WithSource(source),
+		WithValidator(validator),
+		WithErrorHandler(func(err error) { errs <- err }),
+	)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// 校验失败时继续使用上一份有效的配置
+	source.ch <- &KeyValue{Key: "app", Value: []byte(`{"port":-1}`), Format: "json"}
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "port must be positive") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected error event")
+	}
+	if port, err := c.Value("port").Int(); err != nil || port != 8000 {
+		t.Errorf("expected 8000, got %v %v", port, err)
+	}
+
+	// 校验通过时采用新配置
+	source.ch <- &KeyValue{Key: "app", Value: []byte(`{"port":9000}`), Format: "json"}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if port, _ := c.Value("port").Int(); port == 9000 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected new config to be applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestConfigSameKeySources 测试不同配置源中相同的键分别合并
+func TestConfigSameKeySources(t *testing.T) {
+	first := &testChanSource{
+		kv: &KeyValue{Key: "config.yaml", Value: []byte(`{"server":{"addr":"0.0.0.0","port":80}}`), Format: "json"},
+		ch: make(chan *KeyValue),
+	}
+	second := &testChanSource{
+		kv: &KeyValue{Key: "config.yaml", Value: []byte(`{"server":{"port":8000},"name":"app"}`), Format: "json"},
+		ch: make(chan *KeyValue),
+	}
+	c := New(WithSource(first, second))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if addr, err := c.Value("server.addr").String(); err != nil || addr != "0.0.0.0" {
+		t.Errorf("expected 0.0.0.0, got %v %v", addr, err)
+	}
+	if port, err := c.Value("server.port").Int(); err != nil || port != 8000 {
+		t.Errorf("expected 8000, got %v %v", port, err)
+	}
+
+	// 重新加载第一个配置源不会丢弃第二个配置源的配置
+	first.ch <- &KeyValue{Key: "config.yaml", Value: []byte(`{"server":{"addr":"127.0.0.1","port":80}}`), Format: "json"}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if addr, _ := c.Value("server.addr").String(); addr == "127.0.0.1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected new config to be applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if port, err := c.Value("server.port").Int(); err != nil || port != 8000 {
+		t.Errorf("expected 8000, got %v %v", port, err)
+	}
+	if name, err := c.Value("name").String(); err != nil || name != "app" {
+		t.Errorf("expected app, got %v %v", name, err)
+	}
+}
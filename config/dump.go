@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// RedactedValue 是被脱敏的配置值的替代内容。
+const RedactedValue = "******"
+
+// DefaultRedactPatterns 是默认的脱敏模式，匹配常见的保存密钥的配置键。
+var DefaultRedactPatterns = []string{
+	"*password*", "*passwd*", "*secret*", "*token*", "*credential*",
+	"*private_key*", "*privatekey*", "*access_key*", "*accesskey*", "*api_key*", "*apikey*",
+}
+
+// DumpOption 是配置导出的选项。
+type DumpOption func(*dumpOptions)
+
+type dumpOptions struct {
+	patterns []string
+}
+
+// WithRedactPatterns 设置脱敏模式，替换默认的脱敏模式。
+// 模式使用 path.Match 的语法，不区分大小写，
+// 与配置键的名称或以 "." 分隔的完整路径匹配时，该键的值被替换为 RedactedValue。
+func WithRedactPatterns(patterns ...string) DumpOption {
+	return func(o *dumpOptions) {
+		o.patterns = patterns
+	}
+}
+
+// Redact 返回脱敏后的配置副本，不修改 values。
+func Redact(values map[string]interface{}, opts ...DumpOption) map[string]interface{} {
+	o := dumpOptions{patterns: DefaultRedactPatterns}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	patterns := make([]string, 0, len(o.patterns))
+	for _, p := range o.patterns {
+		patterns = append(patterns, strings.ToLower(p))
+	}
+	return redactMap(values, "", patterns)
+}
+
+// redactMap 递归脱敏 map 中的配置值。
+func redactMap(values map[string]interface{}, prefix string, patterns []string) map[string]interface{} {
+	dst := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		full := key
+		if prefix != "" {
+			full = prefix + "." + key
+		}
+		if redactKey(key, full, patterns) {
+			dst[key] = RedactedValue
+			continue
+		}
+		dst[key] = redactValue(value, full, patterns)
+	}
+	return dst
+}
+
+// redactValue 脱敏嵌套的 map 与数组，数组元素的路径使用下标。
+func redactValue(value interface{}, full string, patterns []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactMap(v, full, patterns)
+	case []interface{}:
+		dst := make([]interface{}, len(v))
+		for i, e := range v {
+			dst[i] = redactValue(e, full, patterns)
+		}
+		return dst
+	}
+	return value
+}
+
+// redactKey 判断配置键是否需要脱敏。
+func redactKey(key, full string, patterns []string) bool {
+	key, full = strings.ToLower(key), strings.ToLower(full)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, full); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler 返回导出当前生效配置的 HTTP 处理器，响应为脱敏后的 JSON，
+// 用于排查实例实际运行的配置。处理器可能暴露内部配置，应只注册在内部端口上。
+func Handler(c Config, opts ...DumpOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		values, err := c.Snapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data, err := json.MarshalIndent(Redact(values, opts...), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const _dumpJSON = `{
+	"server": {"addr": "0.0.0.0:8000", "port": 8000},
+	"data": {
+		"database": {"source": "root:${DB_PASSWORD:pass}@tcp(127.0.0.1:3306)/test", "password": "pass"},
+		"clients": [{"name": "a", "api_key": "k1"}]
+	},
+	"jwt": {"secret": "s"}
+}`
+
+// TestConfig_Snapshot 测试导出合并后的完整配置
+func TestConfig_Snapshot(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(_dumpJSON)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	values, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := values["server"].(map[string]interface{})
+	if server["port"] != json.Number("8000") {
+		t.Errorf("unexpected port: %v", server["port"])
+	}
+	// 修改副本不影响配置
+	server["addr"] = "changed"
+	if addr, _ := c.Value("server.addr").String(); addr != "0.0.0.0:8000" {
+		t.Errorf("snapshot should be a copy, got %s", addr)
+	}
+	// 占位符已被解析
+	source, _ := c.Value("data.database.source").String()
+	if !strings.HasPrefix(source, "root:pass@") {
+		t.Errorf("expected resolved placeholder, got %s", source)
+	}
+}
+
+// TestRedact 测试按模式脱敏配置
+func TestRedact(t *testing.T) {
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(_dumpJSON), &values); err != nil {
+		t.Fatal(err)
+	}
+	redacted := Redact(values)
+	data, _ := json.Marshal(redacted)
+	for _, secret := range []string{`"pass"`, `"k1"`, `"s"`} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %s to be redacted: %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), `"name":"a"`) {
+		t.Errorf("unexpected redaction: %s", data)
+	}
+	// 原配置不被修改
+	if values["jwt"].(map[string]interface{})["secret"] != "s" {
+		t.Error("values should not be modified")
+	}
+
+	redacted = Redact(values, WithRedactPatterns("data.database.*"))
+	database := redacted["data"].(map[string]interface{})["database"].(map[string]interface{})
+	if database["source"] != RedactedValue || redacted["jwt"].(map[string]interface{})["secret"] != "s" {
+		t.Errorf("unexpected redaction: %v", redacted)
+	}
+}
+
+// TestHandler 测试导出配置的 HTTP 处理器
+func TestHandler(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(_dumpJSON)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	Handler(c).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if strings.Contains(w.Body.String(), `"pass"`) || !strings.Contains(w.Body.String(), RedactedValue) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	Handler(c).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/config", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration 是可以从配置中解析的 time.Duration，支持 "1.5s" 形式的字符串以及纳秒数。
+type Duration time.Duration
+
+// UnmarshalJSON 实现 json.Unmarshaler。
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case float64:
+		*d = Duration(value)
+	case string:
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(dur)
+	default:
+		return fmt.Errorf("config: invalid duration %s", b)
+	}
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler。
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
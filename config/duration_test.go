@@ -0,0 +1,31 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	var v struct {
+		A Duration `json:"a"`
+		B Duration `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a":"1.5s","b":1000}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(v.A) != 1500*time.Millisecond || time.Duration(v.B) != time.Microsecond {
+		t.Errorf("unexpected durations: %v %v", v.A, v.B)
+	}
+	// 无效的时长返回错误
+	if err := json.Unmarshal([]byte(`{"a":true}`), &v); err == nil {
+		t.Error("expect error for invalid duration")
+	}
+	b, err := json.Marshal(v.A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"1.5s"` {
+		t.Errorf("unexpected json: %s", b)
+	}
+}
@@ -3,23 +3,55 @@ package env
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cnsync/kratos/config"
 )
 
+// DefaultPollInterval 是 NewSource 创建的配置源轮询进程环境变量的间隔。
+const DefaultPollInterval = 10 * time.Second
+
 // env 结构体表示一个环境变量源，它包含一个前缀列表。
 type env struct {
 	prefixes []string
+	interval time.Duration // 轮询进程环境变量的间隔，小于等于 0 时不轮询
+	path     string        // envfile 或目录的路径，为空时读取进程环境变量
 }
 
 // NewSource 函数创建一个新的 env 源实例，该实例可以从环境变量中加载配置。
+// 进程环境变量按照 DefaultPollInterval 轮询，变化时 Watch 返回新的配置。
 func NewSource(prefixes ...string) config.Source {
-	return &env{prefixes: prefixes}
+	return &env{prefixes: prefixes, interval: DefaultPollInterval}
+}
+
+// NewPollingSource 创建按照指定间隔轮询进程环境变量的 env 源，间隔小于等于 0 时 Watch 不会返回变化。
+func NewPollingSource(interval time.Duration, prefixes ...string) config.Source {
+	return &env{prefixes: prefixes, interval: interval}
+}
+
+// NewFileSource 创建从 envfile 或目录中加载环境变量的 env 源，文件变化时 Watch 返回新的配置。
+// envfile 每行是一个 KEY=VALUE，支持 # 注释、export 前缀以及引号包围的值；
+// 目录中每个文件是一个环境变量，文件名为键，内容为值，例如 Kubernetes downward API 或 Secret 挂载的目录，
+// 以 . 开头的文件与目录会被忽略。
+func NewFileSource(path string, prefixes ...string) config.Source {
+	return &env{prefixes: prefixes, path: path}
 }
 
 // Load 方法从环境变量中加载配置，并将其作为键值对列表返回。
 func (e *env) Load() (kv []*config.KeyValue, err error) {
-	return e.load(os.Environ()), nil
+	envs, err := e.environ()
+	if err != nil {
+		return nil, err
+	}
+	return e.load(envs), nil
+}
+
+// environ 返回 KEY=VALUE 形式的环境变量列表。
+func (e *env) environ() ([]string, error) {
+	if e.path == "" {
+		return os.Environ(), nil
+	}
+	return readPath(e.path)
 }
 
 // load 方法从给定的环境变量列表中加载配置，并将其作为键值对列表返回。
@@ -61,14 +93,15 @@ func (e *env) load(envs []string) []*config.KeyValue {
 
 // Watch 方法创建一个新的 Watcher 实例，用于监视环境变量的变化
 func (e *env) Watch() (config.Watcher, error) {
-	// 创建一个新的 watcher 实例
-	w, err := NewWatcher()
-	// 如果创建实例失败，返回错误
-	if err != nil {
-		return nil, err
+	switch {
+	case e.path != "":
+		return newFileWatcher(e)
+	case e.interval > 0:
+		return newPollWatcher(e)
+	default:
+		// 不轮询时返回一个只会在停止时返回的 watcher
+		return NewWatcher()
 	}
-	// 返回 watcher 实例
-	return w, nil
 }
 
 // matchPrefix 函数检查给定的字符串是否以任何一个前缀开头，并返回匹配的前缀。
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cnsync/kratos/config"
 	"github.com/cnsync/kratos/config/file"
@@ -510,3 +511,67 @@ func Test_env_watch(t *testing.T) {
 	// 调用 Stop 方法停止监控
 	_ = w.Stop()
 }
+
+// TestEnvPlaceholderReload 测试进程环境变量变化后占位符的值随之更新
+func TestEnvPlaceholderReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(path, []byte(`{"server":{"name":"${NAME}"}}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RELOAD_NAME", "kratos_app")
+
+	c := config.New(config.WithSource(
+		file.NewSource(path),
+		NewPollingSource(10*time.Millisecond, "RELOAD_"),
+	))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	v := c.Value("server.name")
+	if s, _ := v.String(); s != "kratos_app" {
+		t.Fatalf("expect kratos_app, got %s", s)
+	}
+
+	t.Setenv("RELOAD_NAME", "kratos_next")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if s, _ := v.String(); s == "kratos_next" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expect the placeholder to be resolved again")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Test_env_fileSource 测试从 envfile 与目录中加载环境变量
+func Test_env_fileSource(t *testing.T) {
+	dir := t.TempDir()
+	envfile := filepath.Join(dir, ".env")
+	data := "# comment\nexport APP_NAME=kratos\nAPP_ADDR=\"127.0.0.1:8000\"\nAPP_TOKEN='a b'\ninvalid\n"
+	if err := os.WriteFile(envfile, []byte(data), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	kvs, err := NewFileSource(envfile, "APP_").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"NAME": "kratos", "ADDR": "127.0.0.1:8000", "TOKEN": "a b"}
+	if got := snapshot(kvs); !reflect.DeepEqual(got, want) {
+		t.Errorf("expect %v, got %v", want, got)
+	}
+
+	// 目录中的文件名为键，忽略以 . 开头的文件
+	if err = os.WriteFile(filepath.Join(dir, "POD_NAME"), []byte("pod-1\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if kvs, err = NewFileSource(dir).Load(); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{"POD_NAME": "pod-1"}
+	if got := snapshot(kvs); !reflect.DeepEqual(got, want) {
+		t.Errorf("expect %v, got %v", want, got)
+	}
+}
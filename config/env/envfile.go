@@ -0,0 +1,84 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readPath 从 envfile 或目录中读取 KEY=VALUE 形式的环境变量列表。
+func readPath(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return readDir(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseEnvFile(string(data)), nil
+}
+
+// readDir 读取目录中的文件，文件名为键，内容为值。
+// 以 . 开头的文件会被忽略，例如 Kubernetes 挂载目录中的 ..data 等目录。
+func readDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var envs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		// 使用 Stat 跟随符号链接，Kubernetes 挂载的文件是指向 ..data 目录的符号链接
+		fi, err := os.Stat(path)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, name+"="+strings.TrimRight(string(data), "\r\n"))
+	}
+	return envs, nil
+}
+
+// parseEnvFile 解析 envfile 的内容，忽略空行、注释以及格式错误的行。
+func parseEnvFile(data string) []string {
+	var envs []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if n := len(v); n >= 2 { //nolint:mnd
+			switch {
+			case v[0] == '"' && v[n-1] == '"':
+				// 双引号包围的值支持转义字符
+				if s, err := strconv.Unquote(v); err == nil {
+					v = s
+				} else {
+					v = v[1 : n-1]
+				}
+			case v[0] == '\'' && v[n-1] == '\'':
+				v = v[1 : n-1]
+			}
+		}
+		envs = append(envs, k+"="+v)
+	}
+	return envs
+}
@@ -2,6 +2,13 @@ package env
 
 import (
 	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
 	"github.com/cnsync/kratos/config"
 )
 
@@ -13,7 +20,7 @@ type watcher struct {
 	cancel context.CancelFunc
 }
 
-// NewWatcher 函数创建一个新的 watcher 实例，用于监视环境变量的变化
+// NewWatcher 函数创建一个新的 watcher 实例，它不会监视环境变量的变化，Next 会一直阻塞到 Stop 被调用
 func NewWatcher() (config.Watcher, error) {
 	// 创建一个带有取消功能的上下文对象
 	ctx, cancel := context.WithCancel(context.Background())
@@ -36,3 +43,113 @@ func (w *watcher) Stop() error {
 	// 返回 nil 表示没有错误
 	return nil
 }
+
+var _ config.Watcher = (*changeWatcher)(nil)
+
+// changeWatcher 在定时轮询或文件变化时重新加载环境变量，只在环境变量变化时返回新的配置
+type changeWatcher struct {
+	e    *env
+	last map[string]string // 上一次返回的环境变量
+
+	ticker *time.Ticker
+	fw     *fsnotify.Watcher
+	tick   <-chan time.Time
+	events <-chan fsnotify.Event
+	errs   <-chan error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newChangeWatcher 创建 changeWatcher，并记录当前的环境变量用于比较变化
+func newChangeWatcher(e *env) (*changeWatcher, error) {
+	kvs, err := e.Load()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &changeWatcher{e: e, last: snapshot(kvs), ctx: ctx, cancel: cancel}, nil
+}
+
+// newPollWatcher 创建按照间隔轮询进程环境变量的 watcher
+func newPollWatcher(e *env) (config.Watcher, error) {
+	w, err := newChangeWatcher(e)
+	if err != nil {
+		return nil, err
+	}
+	w.ticker = time.NewTicker(e.interval)
+	w.tick = w.ticker.C
+	return w, nil
+}
+
+// newFileWatcher 创建监视 envfile 或目录的 watcher，envfile 通过所在的目录监视，
+// 使原子替换文件以及 Kubernetes 切换 ..data 符号链接的更新都可以被发现
+func newFileWatcher(e *env) (config.Watcher, error) {
+	fi, err := os.Stat(e.path)
+	if err != nil {
+		return nil, err
+	}
+	dir := e.path
+	if !fi.IsDir() {
+		dir = filepath.Dir(e.path)
+	}
+	w, err := newChangeWatcher(e)
+	if err != nil {
+		return nil, err
+	}
+	if w.fw, err = fsnotify.NewWatcher(); err != nil {
+		return nil, err
+	}
+	if err = w.fw.Add(dir); err != nil {
+		_ = w.fw.Close()
+		return nil, err
+	}
+	w.events, w.errs = w.fw.Events, w.fw.Errors
+	return w, nil
+}
+
+// Next 方法等待环境变量变化，并返回变化后的全部环境变量
+func (w *changeWatcher) Next() ([]*config.KeyValue, error) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.tick:
+		case <-w.events:
+		case err := <-w.errs:
+			return nil, err
+		}
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
+		}
+		kvs, err := w.e.Load()
+		if err != nil {
+			return nil, err
+		}
+		if current := snapshot(kvs); !maps.Equal(current, w.last) {
+			w.last = current
+			return kvs, nil
+		}
+	}
+}
+
+// Stop 方法停止监视，并释放定时器与文件监视器
+func (w *changeWatcher) Stop() error {
+	w.cancel()
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	if w.fw != nil {
+		return w.fw.Close()
+	}
+	return nil
+}
+
+// snapshot 将键值对列表转换为用于比较的 map
+func snapshot(kvs []*config.KeyValue) map[string]string {
+	m := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = string(kv.Value)
+	}
+	return m
+}
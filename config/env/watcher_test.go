@@ -1,7 +1,10 @@
 package env
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 // Test_watcher_next 测试 watcher 的 Next 方法
@@ -46,3 +49,59 @@ func Test_watcher_stop(t *testing.T) {
 		_ = w.Stop()
 	})
 }
+
+// Test_pollWatcher 测试轮询进程环境变量的 watcher 只在变化时返回
+func Test_pollWatcher(t *testing.T) {
+	t.Setenv("POLL_KEY", "1")
+	w, err := NewPollingSource(10*time.Millisecond, "POLL_").Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.Setenv("POLL_KEY", "2")
+	}()
+	kvs, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot(kvs); got["KEY"] != "2" {
+		t.Errorf("expect 2, got %v", got)
+	}
+}
+
+// Test_fileWatcher 测试 envfile 变化时 watcher 返回新的环境变量
+func Test_fileWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("KEY=1\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewFileSource(path).Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// 原子替换文件
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, []byte("KEY=2\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	kvs, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot(kvs); got["KEY"] != "2" {
+		t.Errorf("expect 2, got %v", got)
+	}
+
+	_ = w.Stop()
+	if _, err = w.Next(); err == nil {
+		t.Error("expect error after stop")
+	}
+}
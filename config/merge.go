@@ -0,0 +1,160 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Strategy 是配置键的合并策略。
+type Strategy int
+
+const (
+	// StrategyDefault 使用 WithMergeFunc 设置的合并函数，默认为 mergo 的覆盖合并。
+	StrategyDefault Strategy = iota
+	// StrategyReplace 使用后加载的配置整体替换该键的值，适用于列表等不应与之前的值混合的配置。
+	StrategyReplace
+	// StrategyDeepMerge 递归合并映射，映射中的列表与标量值被后加载的配置替换。
+	StrategyDeepMerge
+	// StrategyAppend 将后加载的列表追加到之前的列表之后，非列表的值被替换。
+	StrategyAppend
+)
+
+// String 返回合并策略的名称。
+func (s Strategy) String() string {
+	switch s {
+	case StrategyReplace:
+		return "replace"
+	case StrategyDeepMerge:
+		return "deep-merge"
+	case StrategyAppend:
+		return "append"
+	default:
+		return "default"
+	}
+}
+
+// WithMergeStrategy 设置指定路径的合并策略，路径使用 "." 分隔，例如 "server.http.cors.origins"。
+// 多个配置源都提供该键时，按策略合并它们的值，其余的键仍使用合并函数合并。
+// 设置了策略的路径之间存在嵌套关系时，较短路径的策略先应用。
+func WithMergeStrategy(path string, strategy Strategy) Option {
+	return func(o *options) {
+		if o.strategies == nil {
+			o.strategies = make(map[string]Strategy)
+		}
+		o.strategies[path] = strategy
+	}
+}
+
+// strategyValue 是从待合并配置中取出的设置了合并策略的值。
+type strategyValue struct {
+	path     []string
+	strategy Strategy
+	value    interface{}
+}
+
+// mergeWithStrategies 将 src 合并到 dst 中，设置了合并策略的路径按策略合并，其余的键使用 merge 合并。
+// src 会被修改。
+func mergeWithStrategies(dst *map[string]interface{}, src map[string]interface{}, strategies map[string]Strategy, merge Merge) error {
+	// 较深的路径先从 src 中取出，保证较短路径取出的值不包含较深路径的值
+	paths := make([]string, 0, len(strategies))
+	for path, s := range strategies {
+		if s != StrategyDefault {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Count(paths[i], ".") > strings.Count(paths[j], ".")
+	})
+	taken := make([]strategyValue, 0, len(paths))
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		if v, ok := takeValue(src, keys); ok {
+			taken = append(taken, strategyValue{path: keys, strategy: strategies[path], value: v})
+		}
+	}
+	if err := merge(dst, src); err != nil {
+		return err
+	}
+	for i := len(taken) - 1; i >= 0; i-- {
+		sv := taken[i]
+		old, _ := lookupValue(*dst, sv.path)
+		setValue(*dst, sv.path, mergeValue(old, sv.value, sv.strategy))
+	}
+	return nil
+}
+
+// mergeValue 按策略合并同一个键的新旧值。
+func mergeValue(old, value interface{}, strategy Strategy) interface{} {
+	switch strategy {
+	case StrategyAppend:
+		ol, ok1 := old.([]interface{})
+		nl, ok2 := value.([]interface{})
+		if ok1 && ok2 {
+			merged := make([]interface{}, 0, len(ol)+len(nl))
+			merged = append(merged, ol...)
+			return append(merged, nl...)
+		}
+	case StrategyDeepMerge:
+		om, ok1 := old.(map[string]interface{})
+		nm, ok2 := value.(map[string]interface{})
+		if ok1 && ok2 {
+			merged := make(map[string]interface{}, len(om)+len(nm))
+			for k, v := range om {
+				merged[k] = v
+			}
+			for k, v := range nm {
+				merged[k] = mergeValue(merged[k], v, StrategyDeepMerge)
+			}
+			return merged
+		}
+	}
+	return value
+}
+
+// takeValue 从 values 中取出并删除路径对应的值。
+func takeValue(values map[string]interface{}, keys []string) (interface{}, bool) {
+	for i, key := range keys {
+		v, ok := values[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(keys)-1 {
+			delete(values, key)
+			return v, true
+		}
+		if values, ok = v.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// lookupValue 返回路径对应的值。
+func lookupValue(values map[string]interface{}, keys []string) (interface{}, bool) {
+	for i, key := range keys {
+		v, ok := values[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(keys)-1 {
+			return v, true
+		}
+		if values, ok = v.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// setValue 设置路径对应的值，路径上缺失或不是映射的中间键会被替换为映射。
+func setValue(values map[string]interface{}, keys []string, value interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := values[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			values[key] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = value
+}
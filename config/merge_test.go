@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"dario.cat/mergo"
+
+	"github.com/cnsync/kratos/encoding"
+	_ "github.com/cnsync/kratos/encoding/json"
+)
+
+// TestMergeStrategy 测试按路径设置的合并策略
+func TestMergeStrategy(t *testing.T) {
+	opts := options{
+		decoder: func(kv *KeyValue, v map[string]interface{}) error {
+			if codec := encoding.GetCodec(kv.Format); codec != nil {
+				return codec.Unmarshal(kv.Value, &v)
+			}
+			return fmt.Errorf("不支持的键: %s 格式: %s", kv.Key, kv.Format)
+		},
+		resolver: defaultResolver,
+		merge: func(dst, src interface{}) error {
+			return mergo.Map(dst, src, mergo.WithOverride)
+		},
+	}
+	WithMergeStrategy("cors.origins", StrategyReplace)(&opts)
+	WithMergeStrategy("plugins", StrategyAppend)(&opts)
+	WithMergeStrategy("labels", StrategyDeepMerge)(&opts)
+	r := newReader(opts)
+
+	err := r.Merge(
+		&KeyValue{Key: "a", Format: "json", Value: []byte(`{
+			"cors": {"origins": ["a", "b", "c"], "max_age": 10},
+			"plugins": ["auth"],
+			"labels": {"team": "x", "env": {"name": "dev", "zone": "z1"}}
+		}`)},
+		&KeyValue{Key: "b", Format: "json", Value: []byte(`{
+			"cors": {"origins": ["d"]},
+			"plugins": ["trace"],
+			"labels": {"env": {"name": "prod"}}
+		}`)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]interface{}{
+		"cors.origins":    []interface{}{"d"},
+		"cors.max_age":    float64(10),
+		"plugins":         []interface{}{"auth", "trace"},
+		"labels.team":     "x",
+		"labels.env.name": "prod",
+		"labels.env.zone": "z1",
+	}
+	for path, want := range tests {
+		v, ok := r.Value(path)
+		if !ok {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		if got := v.Load(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", path, want, got)
+		}
+	}
+}
+
+// TestMergeStrategyReload 测试配置重复加载时追加合并的结果不变
+func TestMergeStrategyReload(t *testing.T) {
+	opts := options{
+		decoder: func(kv *KeyValue, v map[string]interface{}) error {
+			return encoding.GetCodec(kv.Format).Unmarshal(kv.Value, &v)
+		},
+		resolver: defaultResolver,
+		merge: func(dst, src interface{}) error {
+			return mergo.Map(dst, src, mergo.WithOverride)
+		},
+	}
+	WithMergeStrategy("plugins", StrategyAppend)(&opts)
+	r := newReader(opts)
+	a := &KeyValue{Key: "a", Format: "json", Value: []byte(`{"plugins": ["auth"]}`)}
+	if err := r.Merge(a, &KeyValue{Key: "b", Format: "json", Value: []byte(`{"plugins": ["trace"]}`)}); err != nil {
+		t.Fatal(err)
+	}
+	// 监听到变更时只重新加载变化的配置键
+	for _, plugins := range []string{`["trace", "metrics"]`, `["trace", "metrics"]`} {
+		if err := r.Merge(&KeyValue{Key: "b", Format: "json", Value: []byte(`{"plugins": ` + plugins + `}`)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Merge(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, _ := r.Value("plugins")
+	want := []interface{}{"auth", "trace", "metrics"}
+	if got := v.Load(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestMergeValue 测试各合并策略对不同类型值的处理
+func TestMergeValue(t *testing.T) {
+	if got := mergeValue([]interface{}{1}, "x", StrategyAppend); got != "x" {
+		t.Errorf("expected non-list value replaced, got %v", got)
+	}
+	if got := mergeValue(nil, []interface{}{1}, StrategyAppend); !reflect.DeepEqual(got, []interface{}{1}) {
+		t.Errorf("expected new list, got %v", got)
+	}
+	got := mergeValue(map[string]interface{}{"a": []interface{}{1}}, map[string]interface{}{"a": []interface{}{2}}, StrategyDeepMerge)
+	if !reflect.DeepEqual(got, map[string]interface{}{"a": []interface{}{2}}) {
+		t.Errorf("expected list in map replaced, got %v", got)
+	}
+}
@@ -18,6 +18,9 @@ type Resolver func(map[string]interface{}) error
 // Merge 是配置的合并函数类型，用于将源配置合并到目标配置中。
 type Merge func(dst, src interface{}) error
 
+// Validator 是配置校验函数类型，在合并与解析占位符之后对完整的配置进行校验，不应修改配置。
+type Validator func(map[string]interface{}) error
+
 // Option 是配置的选项函数，用于设置 options。
 type Option func(*options)
 
@@ -26,6 +29,11 @@ type options struct {
 	decoder  Decoder  // 配置解码器
 	resolver Resolver // 占位符解析器
 	merge    Merge    // 合并函数
+
+	strategies map[string]Strategy // 按路径设置的合并策略
+
+	validator    Validator   // 配置校验函数
+	errorHandler func(error) // 监听配置变化出错时的回调
 }
 
 // WithSource 设置配置来源。
@@ -66,6 +74,21 @@ func WithMergeFunc(m Merge) Option {
 	}
 }
 
+// WithValidator 设置配置校验函数。加载配置时校验失败会使 Load 返回错误；
+// 监听到配置变化时校验失败，会继续使用上一份有效的配置，并通过 WithErrorHandler 通知错误。
+func WithValidator(v Validator) Option {
+	return func(o *options) {
+		o.validator = v
+	}
+}
+
+// WithErrorHandler 设置监听配置变化出错时的回调，例如配置合并、解析或校验失败。
+func WithErrorHandler(h func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = h
+	}
+}
+
 // defaultDecoder 函数用于将源 KeyValue 配置解码到目标 map[string]interface{} 中，使用 src.Format 编码格式。
 func defaultDecoder(src *KeyValue, target map[string]interface{}) error {
 	// 如果 src.Format 为空，则将 src.Key 展开为嵌套的 map 结构，并将 src.Value 作为最终键的值。
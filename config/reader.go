@@ -25,7 +25,9 @@ type Reader interface {
 // 内部的配置读取器实现
 type reader struct {
 	opts   options                // 配置选项
-	values map[string]interface{} // 配置键值存储
+	values map[string]interface{} // 解析占位符后的配置键值存储
+	raw    map[string]interface{} // 合并后尚未解析占位符的配置，每次解析都从中重新解析
+	kvs    []*KeyValue            // 每个配置源中每个配置键最新的配置数据，按首次加载的顺序排列
 	lock   sync.Mutex             // 用于保护并发访问的锁
 }
 
@@ -34,17 +36,32 @@ func newReader(opts options) Reader {
 	return &reader{
 		opts:   opts,
 		values: make(map[string]interface{}),
+		raw:    make(map[string]interface{}),
 		lock:   sync.Mutex{},
 	}
 }
 
-// Merge 将多个 KeyValue 合并到当前配置中
+// Merge 将多个 KeyValue 合并到当前配置中。
+// 每个配置源中的每个配置键只保留最新的配置数据，合并时从各个配置键的数据重新合并，
+// 使重复加载同一份配置的结果不变（例如追加合并的列表不会重复追加）。
 func (r *reader) Merge(kvs ...*KeyValue) error {
-	merged, err := r.cloneMap() // 克隆当前配置
-	if err != nil {
-		return err
-	}
+	r.lock.Lock()
+	all := append([]*KeyValue(nil), r.kvs...)
+	r.lock.Unlock()
 	for _, kv := range kvs {
+		replaced := false
+		for i, prev := range all {
+			if prev.source == kv.source && prev.Key == kv.Key {
+				all[i], replaced = kv, true
+				break
+			}
+		}
+		if !replaced {
+			all = append(all, kv)
+		}
+	}
+	merged := make(map[string]interface{})
+	for _, kv := range all {
 		next := make(map[string]interface{})
 		// 使用解码器解码 KeyValue
 		if err := r.opts.decoder(kv, next); err != nil {
@@ -52,7 +69,7 @@ func (r *reader) Merge(kvs ...*KeyValue) error {
 			return err
 		}
 		// 使用合并器合并配置
-		if err := r.opts.merge(&merged, convertMap(next)); err != nil {
+		if err := mergeWithStrategies(&merged, convertMap(next).(map[string]interface{}), r.opts.strategies, r.opts.merge); err != nil {
 			log.Errorf("配置合并失败: %v 键: %s 值: %s", err, kv.Key, string(kv.Value))
 			return err
 		}
@@ -60,6 +77,8 @@ func (r *reader) Merge(kvs ...*KeyValue) error {
 	// 更新配置存储
 	r.lock.Lock()
 	r.values = merged
+	r.raw = merged
+	r.kvs = all
 	r.lock.Unlock()
 	return nil
 }
@@ -78,18 +97,40 @@ func (r *reader) Source() ([]byte, error) {
 	return marshalJSON(convertMap(r.values))
 }
 
-// Resolve 调用解析器处理配置
+// Resolve 调用解析器处理配置，占位符从合并后的原始配置重新解析，使引用的配置（例如环境变量）变化后占位符的值随之更新。
 func (r *reader) Resolve() error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	return r.opts.resolver(r.values)
+	resolved, err := cloneMap(r.raw)
+	if err != nil {
+		return err
+	}
+	if err = r.opts.resolver(resolved); err != nil {
+		return err
+	}
+	r.values = resolved
+	return nil
+}
+
+// snapshot 返回当前配置、尚未解析的配置与各个配置键的数据，Merge 与 Resolve 会替换而不是修改它们，因此可以用于回滚。
+func (r *reader) snapshot() (values, raw map[string]interface{}, kvs []*KeyValue) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.values, r.raw, r.kvs
+}
+
+// restore 将配置回滚到 snapshot 返回的配置。
+func (r *reader) restore(values, raw map[string]interface{}, kvs []*KeyValue) {
+	r.lock.Lock()
+	r.values, r.raw, r.kvs = values, raw, kvs
+	r.lock.Unlock()
 }
 
-// 克隆当前配置
-func (r *reader) cloneMap() (map[string]interface{}, error) {
+// validate 使用校验函数校验当前配置
+func (r *reader) validate(v Validator) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	return cloneMap(r.values)
+	return v(r.values)
 }
 
 // cloneMap 克隆一个 map[string]interface{} 的深拷贝
@@ -8,6 +8,20 @@ type KeyValue struct {
 	Value []byte
 	// Format 是配置值的格式，例如 JSON、YAML 等。
 	Format string
+
+	// source 是加载该配置的配置源序号加一，用于区分不同配置源中相同的键，0 表示直接合并的配置。
+	source int
+}
+
+// withSource 返回标记了配置源序号的 KeyValue 副本，不修改配置源返回的 KeyValue。
+func withSource(i int, kvs []*KeyValue) []*KeyValue {
+	tagged := make([]*KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		c := *kv
+		c.source = i + 1
+		tagged = append(tagged, &c)
+	}
+	return tagged
 }
 
 // Source 是配置源的接口。
@@ -18,6 +32,13 @@ type Source interface {
 	Watch() (Watcher, error)
 }
 
+// Setter 是支持写回配置的配置源实现的可选接口，例如 etcd、consul。
+// key 与该配置源 Load 返回的 KeyValue.Key 含义一致，写入后的变化通过 Watch 通知。
+type Setter interface {
+	// Set 方法将配置值写入配置源。
+	Set(key string, value []byte) error
+}
+
 // Watcher 是监控器的接口，用于监控配置源的变化。
 type Watcher interface {
 	// Next 方法等待并返回配置源的下一次变化。
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrRequired 表示配置中缺少 `required:"true"` 标签声明的必填键。
+var ErrRequired = errors.New("missing required config keys")
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// applyTags 根据结构体标签处理配置中未设置的键：
+// 带有 `default:"..."` 标签的字段被设置为默认值，带有 `required:"true"` 标签的字段被记录为缺失，
+// 所有缺失的键会在一个错误中列出。data 为解码 v 时使用的 JSON 数据。
+func applyTags(data []byte, v interface{}) error {
+	if _, ok := v.(proto.Message); ok {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	var raw map[string]interface{}
+	_ = json.Unmarshal(data, &raw)
+	var missing []string
+	if err := walkTags(rv.Elem(), raw, "", &missing); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrRequired, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// walkTags 遍历结构体字段，为 raw 中不存在的键应用默认值或记录缺失的必填键。
+func walkTags(rv reflect.Value, raw map[string]interface{}, prefix string, missing *[]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, skip := jsonFieldName(sf)
+		if skip {
+			continue
+		}
+		fv := rv.Field(i)
+		// 未命名的嵌入结构体的字段与外层结构体位于同一层级
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			if err := walkTags(fv, raw, prefix, missing); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		key := prefix + name
+		val, ok := lookupKey(raw, name)
+		if !ok {
+			if def, has := sf.Tag.Lookup("default"); has {
+				if err := setDefault(fv, def); err != nil {
+					return fmt.Errorf("config: invalid default value %q for %s: %w", def, key, err)
+				}
+				continue
+			}
+			if sf.Tag.Get("required") == "true" {
+				*missing = append(*missing, key)
+				continue
+			}
+		}
+		// 非指针的嵌套结构体即使未设置也会应用其中的默认值
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			sub, _ := val.(map[string]interface{})
+			if err := walkTags(fv, sub, key+".", missing); err != nil {
+				return err
+			}
+		} else if ok && fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+			sub, _ := val.(map[string]interface{})
+			if err := walkTags(fv.Elem(), sub, key+".", missing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFieldName 返回字段在 JSON 中的名称，skip 表示该字段被忽略。
+func jsonFieldName(sf reflect.StructField) (name string, skip bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	return name, false
+}
+
+// lookupKey 查找键，与 encoding/json 一致，优先精确匹配，其次不区分大小写匹配。
+func lookupKey(raw map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := raw[name]; ok {
+		return v, v != nil
+	}
+	for k, v := range raw {
+		if strings.EqualFold(k, name) {
+			return v, v != nil
+		}
+	}
+	return nil, false
+}
+
+// setDefault 将字符串形式的默认值设置到字段上。
+func setDefault(fv reflect.Value, def string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(def, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(def, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(def, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		// 切片、映射等其他类型的默认值使用 JSON 表示
+		return json.Unmarshal([]byte(def), fv.Addr().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestScanTags 测试通过结构体标签设置默认值和检查必填键
+func TestScanTags(t *testing.T) {
+	type Database struct {
+		Driver string `json:"driver" default:"mysql"`
+		Source string `json:"source" required:"true"`
+	}
+	type Server struct {
+		Addr    string        `json:"addr" default:"0.0.0.0:8000"`
+		Timeout time.Duration `json:"timeout" default:"1s"`
+		Debug   bool          `json:"debug" default:"true"`
+		Tags    []string      `json:"tags" default:"[\"a\",\"b\"]"`
+		Weight  *int          `json:"weight" default:"10"`
+	}
+	type Bootstrap struct {
+		Server   Server    `json:"server"`
+		Database *Database `json:"database"`
+		Name     string    `json:"name" required:"true"`
+	}
+
+	c := New(WithSource(newTestJSONSource(`{"name":"demo","server":{"addr":":9000","debug":false},"database":{"source":"dsn"}}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var bc Bootstrap
+	if err := c.Scan(&bc); err != nil {
+		t.Fatal(err)
+	}
+	if bc.Server.Addr != ":9000" || bc.Server.Debug {
+		t.Errorf("expected configured values to be kept, got %+v", bc.Server)
+	}
+	if bc.Server.Timeout != time.Second || len(bc.Server.Tags) != 2 || bc.Server.Weight == nil || *bc.Server.Weight != 10 {
+		t.Errorf("expected default values, got %+v", bc.Server)
+	}
+	if bc.Database.Driver != "mysql" || bc.Database.Source != "dsn" {
+		t.Errorf("unexpected database: %+v", bc.Database)
+	}
+
+	// 列出所有缺失的必填键
+	c = New(WithSource(newTestJSONSource(`{"database":{}}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Scan(&Bootstrap{})
+	if !errors.Is(err, ErrRequired) || !strings.Contains(err.Error(), "database.source, name") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Value.Scan 同样支持标签
+	var srv Server
+	if err = c.Value("database").Scan(&srv); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Addr != "0.0.0.0:8000" || srv.Timeout != time.Second {
+		t.Errorf("unexpected server: %+v", srv)
+	}
+}
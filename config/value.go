@@ -175,8 +175,13 @@ func (v *atomicValue) String() (string, error) {
 	return "", v.typeAssertError()
 }
 
-// Duration 返回值的持续时间表示
+// Duration 返回值的持续时间表示，整数表示纳秒数，字符串还支持 "5s"、"1m30s" 等格式
 func (v *atomicValue) Duration() (time.Duration, error) {
+	if s, ok := v.Load().(string); ok {
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return time.ParseDuration(s)
+		}
+	}
 	val, err := v.Int()
 	if err != nil {
 		return 0, err
@@ -193,7 +198,10 @@ func (v *atomicValue) Scan(obj interface{}) error {
 	if pb, ok := obj.(proto.Message); ok {
 		return kratosjson.UnmarshalOptions.Unmarshal(data, pb)
 	}
-	return json.Unmarshal(data, obj)
+	if err = json.Unmarshal(data, obj); err != nil {
+		return err
+	}
+	return applyTags(data, obj)
 }
 
 // errValue 是一个错误值，它实现了 Value 接口
@@ -230,3 +238,42 @@ func (v errValue) Slice() ([]Value, error) { return nil, v.err }
 
 // Map 返回错误
 func (v errValue) Map() (map[string]Value, error) { return nil, v.err }
+
+// Get 获取指定键的配置值并转换为类型 T。基础类型与 time.Duration 使用 Value 对应的方法转换，
+// 其他类型通过 Value.Scan 解码。
+func Get[T any](c Config, key string) (T, error) {
+	var t T
+	v := c.Value(key)
+	var err error
+	switch p := any(&t).(type) {
+	case *bool:
+		*p, err = v.Bool()
+	case *string:
+		*p, err = v.String()
+	case *time.Duration:
+		*p, err = v.Duration()
+	case *int:
+		var n int64
+		n, err = v.Int()
+		*p = int(n)
+	case *int32:
+		var n int64
+		n, err = v.Int()
+		*p = int32(n)
+	case *int64:
+		*p, err = v.Int()
+	case *float32:
+		var f float64
+		f, err = v.Float()
+		*p = float32(f)
+	case *float64:
+		*p, err = v.Float()
+	default:
+		err = v.Scan(&t)
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return t, nil
+}
@@ -262,6 +262,56 @@ func TestAtomicValue_Slice(t *testing.T) {
 	}
 }
 
+// TestAtomicValue_DurationString 测试 atomicValue 类型的 Duration 方法解析字符串格式
+func TestAtomicValue_DurationString(t *testing.T) {
+	tests := map[string]time.Duration{"5s": 5 * time.Second, "1m30s": 90 * time.Second, "5": 5}
+	for s, want := range tests {
+		v := atomicValue{}
+		v.Store(s)
+		d, err := v.Duration()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != want {
+			t.Errorf("%q: expected %v, got %v", s, want, d)
+		}
+	}
+	v := atomicValue{}
+	v.Store("5x")
+	if _, err := v.Duration(); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestGet 测试泛型的配置值访问
+func TestGet(t *testing.T) {
+	c := New(WithSource(newTestJSONSource(`{"name":"demo","port":8000,"timeout":"1m30s","debug":true,"ratio":0.5,"tags":["a","b"]}`)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := Get[string](c, "name"); err != nil || v != "demo" {
+		t.Errorf("unexpected name: %v %v", v, err)
+	}
+	if v, err := Get[int](c, "port"); err != nil || v != 8000 {
+		t.Errorf("unexpected port: %v %v", v, err)
+	}
+	if v, err := Get[time.Duration](c, "timeout"); err != nil || v != 90*time.Second {
+		t.Errorf("unexpected timeout: %v %v", v, err)
+	}
+	if v, err := Get[bool](c, "debug"); err != nil || !v {
+		t.Errorf("unexpected debug: %v %v", v, err)
+	}
+	if v, err := Get[float64](c, "ratio"); err != nil || v != 0.5 {
+		t.Errorf("unexpected ratio: %v %v", v, err)
+	}
+	if v, err := Get[[]string](c, "tags"); err != nil || len(v) != 2 || v[1] != "b" {
+		t.Errorf("unexpected tags: %v %v", v, err)
+	}
+	if _, err := Get[int](c, "missing"); err != ErrNotFound {
+		t.Errorf("expected %v, got %v", ErrNotFound, err)
+	}
+}
+
 // TestAtomicValue_Map 测试 atomicValue 类型的 Map 方法
 func TestAtomicValue_Map(t *testing.T) {
 	// 定义一个包含多种类型的映射，这些类型都应该被正确地转换为映射
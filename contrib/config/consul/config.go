@@ -15,8 +15,10 @@ import (
 type Option func(o *options)
 
 type options struct {
-	ctx  context.Context
-	path string
+	ctx        context.Context
+	path       string
+	datacenter string
+	token      string
 }
 
 // WithContext with registry context.
@@ -33,6 +35,22 @@ func WithPath(p string) Option {
 	}
 }
 
+// WithDatacenter with the datacenter to read the config from, default is the datacenter of the agent.
+func WithDatacenter(dc string) Option {
+	return func(o *options) {
+		o.datacenter = dc
+	}
+}
+
+// WithToken with the ACL token used to read and write the config, default is the token of the client.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+var _ config.Setter = (*source)(nil)
+
 type source struct {
 	client  *api.Client
 	options *options
@@ -60,31 +78,63 @@ func New(client *api.Client, opts ...Option) (config.Source, error) {
 
 // Load return the config values
 func (s *source) Load() ([]*config.KeyValue, error) {
-	kv, _, err := s.client.KV().List(s.options.path, nil)
+	kv, _, err := s.client.KV().List(s.options.path, s.queryOptions(0))
 	if err != nil {
 		return nil, err
 	}
 
-	pathPrefix := s.options.path
-	if !strings.HasSuffix(s.options.path, "/") {
-		pathPrefix = pathPrefix + "/"
-	}
-	kvs := make([]*config.KeyValue, 0)
+	kvs := make([]*config.KeyValue, 0, len(kv))
 	for _, item := range kv {
-		k := strings.TrimPrefix(item.Key, pathPrefix)
-		if k == "" {
-			continue
+		if v, ok := s.keyValue(item); ok {
+			kvs = append(kvs, v)
 		}
-		kvs = append(kvs, &config.KeyValue{
-			Key:    k,
-			Value:  item.Value,
-			Format: strings.TrimPrefix(filepath.Ext(k), "."),
-		})
 	}
 	return kvs, nil
 }
 
+// keyValue converts the KV pair to the config, the format is inferred from the key suffix,
+// e.g. the format of "app.yaml" is yaml. The pair of the path itself is skipped.
+func (s *source) keyValue(item *api.KVPair) (*config.KeyValue, bool) {
+	k := strings.TrimPrefix(item.Key, s.pathPrefix())
+	if k == "" {
+		return nil, false
+	}
+	return &config.KeyValue{
+		Key:    k,
+		Value:  item.Value,
+		Format: strings.TrimPrefix(filepath.Ext(k), "."),
+	}, true
+}
+
+// queryOptions returns the query options, a positive index makes it a blocking query
+// that waits until the index of the prefix changes.
+func (s *source) queryOptions(index uint64) *api.QueryOptions {
+	return (&api.QueryOptions{
+		Datacenter: s.options.datacenter,
+		Token:      s.options.token,
+		WaitIndex:  index,
+	}).WithContext(s.options.ctx)
+}
+
 // Watch return the watcher
 func (s *source) Watch() (config.Watcher, error) {
 	return newWatcher(s)
 }
+
+// Set writes the value to consul KV. The key is relative to the config path,
+// the same as the Key returned by Load.
+func (s *source) Set(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key invalid")
+	}
+	wo := &api.WriteOptions{Datacenter: s.options.datacenter, Token: s.options.token}
+	_, err := s.client.KV().Put(&api.KVPair{Key: s.pathPrefix() + key, Value: value}, wo.WithContext(s.options.ctx))
+	return err
+}
+
+func (s *source) pathPrefix() string {
+	if !strings.HasSuffix(s.options.path, "/") {
+		return s.options.path + "/"
+	}
+	return s.options.path
+}
@@ -1,7 +1,11 @@
 package consul
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -257,3 +261,78 @@ func Test_source_Load(t *testing.T) {
 		})
 	}
 }
+
+func TestWatcherBlockingQuery(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []*http.Request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r)
+		mu.Unlock()
+		// index 10 is the state read by Load, the value changed at index 11 before Watch
+		kv := api.KVPairs{
+			{Key: testPath + "/a.json", Value: []byte(`{"a":1}`), ModifyIndex: 5},
+			{Key: testPath + "/b.yaml", Value: []byte("b: 2"), ModifyIndex: 11},
+		}
+		index := "11"
+		if r.URL.Query().Get("index") == "11" {
+			kv[1].Value, kv[1].ModifyIndex = []byte("b: 3"), 12
+			index = "12"
+		}
+		w.Header().Set("X-Consul-Index", index)
+		_ = json.NewEncoder(w).Encode(kv)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := New(client, WithPath(testPath), WithDatacenter("dc2"), WithToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := source.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = w.Stop()
+	}()
+
+	// the first query returns the current values, including the change made after Load
+	kvs, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 2 || kvs[0].Key != "a.json" || kvs[1].Key != "b.yaml" || string(kvs[1].Value) != "b: 2" {
+		t.Fatalf("unexpected first values: %v", kvs)
+	}
+	// the blocking query returns only the changed value
+	if kvs, err = w.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || kvs[0].Key != "b.yaml" || string(kvs[0].Value) != "b: 3" || kvs[0].Format != "yaml" {
+		t.Fatalf("unexpected changed values: %v", kvs)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(queries) != 2 {
+		t.Fatalf("queries = %d, want 2", len(queries))
+	}
+	for i, want := range []string{"", "11"} {
+		q := queries[i]
+		if got := q.URL.Query().Get("index"); got != want {
+			t.Errorf("query %d index = %q, want %q", i, got, want)
+		}
+		if got := q.URL.Query().Get("dc"); got != "dc2" {
+			t.Errorf("query %d dc = %q, want dc2", i, got)
+		}
+		if got := q.Header.Get("X-Consul-Token"); got != "secret" {
+			t.Errorf("query %d token = %q, want secret", i, got)
+		}
+	}
+}
@@ -2,102 +2,76 @@ package consul
 
 import (
 	"context"
-	"path/filepath"
-	"strings"
 
 	"github.com/hashicorp/consul/api"
-	"github.com/hashicorp/consul/api/watch"
 
 	"github.com/cnsync/kratos/config"
 )
 
+// watcher watches the KV prefix with blocking queries, tracking the index of the prefix
+// and the modify index of every key to return only the changed values.
 type watcher struct {
 	source          *source
-	ch              chan []*config.KeyValue
-	wp              *watch.Plan
+	index           uint64
 	fileModifyIndex map[string]uint64
 	ctx             context.Context
 	cancel          context.CancelFunc
 }
 
-func (w *watcher) handle(_ uint64, data interface{}) {
-	if data == nil {
-		return
-	}
-
-	kv, ok := data.(api.KVPairs)
-	if !ok {
-		return
-	}
-
-	pathPrefix := w.source.options.path
-	if !strings.HasSuffix(w.source.options.path, "/") {
-		pathPrefix = pathPrefix + "/"
-	}
-	kvs := make([]*config.KeyValue, 0, len(kv))
-	for _, item := range kv {
-		if index, ok := w.fileModifyIndex[item.Key]; ok && item.ModifyIndex == index {
-			continue
-		}
-		k := strings.TrimPrefix(item.Key, pathPrefix)
-		if k == "" {
-			continue
-		}
-		kvs = append(kvs, &config.KeyValue{
-			Key:    k,
-			Value:  item.Value,
-			Format: strings.TrimPrefix(filepath.Ext(k), "."),
-		})
-		w.fileModifyIndex[item.Key] = item.ModifyIndex
-	}
-
-	if len(kvs) == 0 {
-		return
-	}
-
-	w.ch <- kvs
-}
-
 func newWatcher(s *source) (*watcher, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	w := &watcher{
+	ctx, cancel := context.WithCancel(s.options.ctx)
+	// the watcher starts without a baseline, so the first query returns the current values
+	// immediately. A change committed between Load and Watch is delivered instead of being
+	// taken as the baseline.
+	return &watcher{
 		source:          s,
-		ch:              make(chan []*config.KeyValue),
 		fileModifyIndex: make(map[string]uint64),
 		ctx:             ctx,
 		cancel:          cancel,
-	}
-
-	wp, err := watch.Parse(map[string]interface{}{"type": "keyprefix", "prefix": s.options.path})
-	if err != nil {
-		return nil, err
-	}
-
-	wp.Handler = w.handle
-	w.wp = wp
+	}, nil
+}
 
-	// wp.Run is a blocking call and will prevent newWatcher from returning
-	go func() {
-		err := wp.RunWithClientAndHclog(s.client, nil)
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	for {
+		kv, meta, err := w.source.client.KV().List(w.source.options.path, w.source.queryOptions(w.index).WithContext(w.ctx))
 		if err != nil {
-			panic(err)
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			return nil, err
 		}
-	}()
-
-	return w, nil
+		switch {
+		case meta.LastIndex < w.index:
+			// the index went backwards, e.g. the servers are restored from a snapshot, start over
+			w.index = 0
+		case meta.LastIndex == w.index:
+			// the blocking query timed out without changes
+			continue
+		default:
+			w.index = meta.LastIndex
+		}
+		if kvs := w.changed(kv); len(kvs) > 0 {
+			return kvs, nil
+		}
+	}
 }
 
-func (w *watcher) Next() ([]*config.KeyValue, error) {
-	select {
-	case kv := <-w.ch:
-		return kv, nil
-	case <-w.ctx.Done():
-		return nil, w.ctx.Err()
+// changed returns the values whose modify index changed since the last query.
+func (w *watcher) changed(kv api.KVPairs) []*config.KeyValue {
+	kvs := make([]*config.KeyValue, 0, len(kv))
+	for _, item := range kv {
+		if index, ok := w.fileModifyIndex[item.Key]; ok && item.ModifyIndex == index {
+			continue
+		}
+		w.fileModifyIndex[item.Key] = item.ModifyIndex
+		if v, ok := w.source.keyValue(item); ok {
+			kvs = append(kvs, v)
+		}
 	}
+	return kvs
 }
 
 func (w *watcher) Stop() error {
-	w.wp.Stop()
 	w.cancel()
 	return nil
 }
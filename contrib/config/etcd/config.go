@@ -41,6 +41,8 @@ func WithPrefix(prefix bool) Option {
 	}
 }
 
+var _ config.Setter = (*source)(nil)
+
 type source struct {
 	client  *clientv3.Client
 	options *options
@@ -94,3 +96,13 @@ func (s *source) Load() ([]*config.KeyValue, error) {
 func (s *source) Watch() (config.Watcher, error) {
 	return newWatcher(s), nil
 }
+
+// Set writes the value to etcd. The key is the full etcd key, the same as the
+// Key returned by Load, and must be the config path or under it with prefix enabled.
+func (s *source) Set(key string, value []byte) error {
+	if key != s.options.path && !(s.options.prefix && strings.HasPrefix(key, s.options.path)) {
+		return errors.New("key is not under config path")
+	}
+	_, err := s.client.Put(s.options.ctx, key, string(value))
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/go-zookeeper/zk"
@@ -39,12 +40,21 @@ func WithDigestACL(user string, password string) Option {
 	}
 }
 
-// Registry is consul registry
+// Registry is zookeeper registry, the instances are registered as protected ephemeral sequential nodes.
 type Registry struct {
 	opts *options
 	conn *zk.Conn
 
 	group singleflight.Group
+
+	mu    sync.Mutex
+	nodes map[string]*node
+}
+
+// node is a registered instance node.
+type node struct {
+	path   string
+	cancel context.CancelFunc
 }
 
 func New(conn *zk.Conn, opts ...Option) *Registry {
@@ -55,11 +65,14 @@ func New(conn *zk.Conn, opts ...Option) *Registry {
 		o(options)
 	}
 	return &Registry{
-		opts: options,
-		conn: conn,
+		opts:  options,
+		conn:  conn,
+		nodes: make(map[string]*node),
 	}
 }
 
+// Register registers the service instance as a protected ephemeral sequential node,
+// the node is created again when the session is re-established after it expired.
 func (r *Registry) Register(_ context.Context, service *registry.ServiceInstance) error {
 	var (
 		data []byte
@@ -75,18 +88,36 @@ func (r *Registry) Register(_ context.Context, service *registry.ServiceInstance
 	if data, err = marshal(service); err != nil {
 		return err
 	}
-	servicePath := path.Join(serviceNamePath, service.ID)
-	if err = r.ensureName(servicePath, data, zk.FlagEphemeral); err != nil {
+	nodePath, err := r.createNode(serviceNamePath, service.ID, data)
+	if err != nil {
 		return err
 	}
-	go r.reRegister(servicePath, data)
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &node{path: nodePath, cancel: cancel}
+	key := path.Join(service.Name, service.ID)
+	r.mu.Lock()
+	if prev, ok := r.nodes[key]; ok {
+		prev.cancel()
+	}
+	r.nodes[key] = n
+	r.mu.Unlock()
+	go r.reRegister(ctx, n, serviceNamePath, service.ID, data)
 	return nil
 }
 
 // Deregister registry service to zookeeper.
 func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
 	ch := make(chan error, 1)
-	servicePath := path.Join(r.opts.namespace, service.Name, service.ID)
+	key := path.Join(service.Name, service.ID)
+	// the node registered by an older version is named by the instance id
+	servicePath := path.Join(r.opts.namespace, key)
+	r.mu.Lock()
+	if n, ok := r.nodes[key]; ok {
+		n.cancel()
+		servicePath = n.path
+		delete(r.nodes, key)
+	}
+	r.mu.Unlock()
 	go func() {
 		err := r.conn.Delete(servicePath, -1)
 		ch <- err
@@ -150,32 +181,73 @@ func (r *Registry) ensureName(path string, data []byte, flags int32) error {
 		exists = false
 	}
 	if !exists {
-		if len(r.opts.user) > 0 && len(r.opts.password) > 0 {
-			_, err = r.conn.Create(path, data, flags, zk.DigestACL(zk.PermAll, r.opts.user, r.opts.password))
-		} else {
-			_, err = r.conn.Create(path, data, flags, zk.WorldACL(zk.PermAll))
-		}
-		if err != nil {
+		if _, err = r.conn.Create(path, data, flags, r.acl()); err != nil && !errors.Is(err, zk.ErrNodeExists) {
 			return err
 		}
 	}
 	return nil
 }
 
-// reRegister re-register data node info when bad connection recovered
-func (r *Registry) reRegister(path string, data []byte) {
+// acl returns the ACL of the created nodes.
+func (r *Registry) acl() []zk.ACL {
+	if len(r.opts.user) > 0 && len(r.opts.password) > 0 {
+		return zk.DigestACL(zk.PermAll, r.opts.user, r.opts.password)
+	}
+	return zk.WorldACL(zk.PermAll)
+}
+
+// createNode deletes the nodes of the instance left by the previous sessions and creates
+// a protected ephemeral sequential node, the protected name lets the client find the node
+// it created if the connection is lost during the creation.
+func (r *Registry) createNode(dir, id string, data []byte) (string, error) {
+	children, _, err := r.conn.Children(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, child := range children {
+		childPath := path.Join(dir, child)
+		b, _, err := r.conn.Get(childPath)
+		if err != nil {
+			continue
+		}
+		if si, err := unmarshal(b); err == nil && si.ID == id {
+			if err = r.conn.Delete(childPath, -1); err != nil && !errors.Is(err, zk.ErrNoNode) {
+				return "", err
+			}
+		}
+	}
+	return r.conn.CreateProtectedEphemeralSequential(path.Join(dir, id+"-"), data, r.acl())
+}
+
+// reRegister creates the node again after the session is re-established,
+// zookeeper deletes the ephemeral nodes of the expired session.
+func (r *Registry) reRegister(ctx context.Context, n *node, dir, id string, data []byte) {
 	sessionID := r.conn.SessionID()
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 		cur := r.conn.SessionID()
-		// sessionID changed
-		if cur > 0 && sessionID != cur {
-			// re-ensureName
-			if err := r.ensureName(path, data, zk.FlagEphemeral); err != nil {
-				return
-			}
-			sessionID = cur
+		if cur <= 0 || cur == sessionID {
+			continue
+		}
+		// retry on the next tick if the creation failed
+		nodePath, err := r.createNode(dir, id, data)
+		if err != nil {
+			continue
+		}
+		r.mu.Lock()
+		if ctx.Err() != nil {
+			// deregistered during the creation
+			_ = r.conn.Delete(nodePath, -1)
+		} else {
+			n.path = nodePath
 		}
+		r.mu.Unlock()
+		sessionID = cur
 	}
 }
@@ -2,7 +2,11 @@ package zookeeper
 
 import (
 	"context"
+	"io"
+	"net"
+	"path"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -418,3 +422,220 @@ func TestRegistry_Watch(t *testing.T) {
 		})
 	}
 }
+
+// instanceNodes returns the nodes of the service instance under the service path.
+func instanceNodes(t *testing.T, conn *zk.Conn, dir, id string) map[string]*registry.ServiceInstance {
+	t.Helper()
+	children, _, err := conn.Children(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := make(map[string]*registry.ServiceInstance)
+	for _, child := range children {
+		b, _, err := conn.Get(path.Join(dir, child))
+		if err != nil {
+			continue
+		}
+		if si, err := unmarshal(b); err == nil && si.ID == id {
+			nodes[path.Join(dir, child)] = si
+		}
+	}
+	return nodes
+}
+
+func TestRegistry_RegisterReplace(t *testing.T) {
+	conn, _, err := zk.Connect([]string{"127.0.0.1:2181"}, time.Second*15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := New(conn)
+
+	svr := &registry.ServiceInstance{
+		ID:        "1",
+		Name:      "hello-replace",
+		Version:   "v1.0.0",
+		Endpoints: []string{"127.0.0.1:8080"},
+	}
+	if err = r.Register(context.Background(), svr); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = r.Deregister(context.Background(), svr)
+	}()
+	// a node left by the previous process of the instance
+	dir := path.Join("/microservices", svr.Name)
+	data, _ := marshal(svr)
+	if _, err = conn.Create(path.Join(dir, "stale"), data, 0, zk.WorldACL(zk.PermAll)); err != nil {
+		t.Fatal(err)
+	}
+
+	svr.Endpoints = []string{"127.0.0.1:8081"}
+	if err = r.Register(context.Background(), svr); err != nil {
+		t.Fatal(err)
+	}
+	nodes := instanceNodes(t, conn, dir, svr.ID)
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %v", nodes)
+	}
+	for _, si := range nodes {
+		if !reflect.DeepEqual(si.Endpoints, svr.Endpoints) {
+			t.Errorf("expected endpoints %v, got %v", svr.Endpoints, si.Endpoints)
+		}
+	}
+}
+
+func TestRegistry_DeregisterNode(t *testing.T) {
+	conn, _, err := zk.Connect([]string{"127.0.0.1:2181"}, time.Second*15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := New(conn)
+
+	svr := &registry.ServiceInstance{
+		ID:        "1",
+		Name:      "hello-deregister",
+		Version:   "v1.0.0",
+		Endpoints: []string{"127.0.0.1:8080"},
+	}
+	if err = r.Register(context.Background(), svr); err != nil {
+		t.Fatal(err)
+	}
+	dir := path.Join("/microservices", svr.Name)
+	nodes := instanceNodes(t, conn, dir, svr.ID)
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %v", nodes)
+	}
+	if err = r.Deregister(context.Background(), svr); err != nil {
+		t.Fatal(err)
+	}
+	for nodePath := range nodes {
+		if exists, _, err := conn.Exists(nodePath); err != nil || exists {
+			t.Errorf("expected the sequential node %s to be deleted, err %v", nodePath, err)
+		}
+	}
+}
+
+// proxy forwards the connections to zookeeper, it drops them while paused to let the session expire.
+type proxy struct {
+	ln     net.Listener
+	mu     sync.Mutex
+	paused bool
+	conns  []net.Conn
+}
+
+func newProxy(t *testing.T, addr string) *proxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proxy{ln: ln}
+	go p.serve(addr)
+	t.Cleanup(func() {
+		_ = ln.Close()
+		p.drop()
+	})
+	return p
+}
+
+func (p *proxy) serve(addr string) {
+	for {
+		c, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		p.mu.Lock()
+		if p.paused {
+			p.mu.Unlock()
+			_ = c.Close()
+			continue
+		}
+		up, err := net.Dial("tcp", addr)
+		if err != nil {
+			p.mu.Unlock()
+			_ = c.Close()
+			continue
+		}
+		p.conns = append(p.conns, c, up)
+		p.mu.Unlock()
+		go func() {
+			_, _ = io.Copy(up, c)
+			_ = up.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(c, up)
+			_ = c.Close()
+		}()
+	}
+}
+
+// drop closes the forwarded connections.
+func (p *proxy) drop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.conns {
+		_ = c.Close()
+	}
+	p.conns = nil
+}
+
+// pause drops the connections and refuses the new ones for d.
+func (p *proxy) pause(d time.Duration) {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	p.drop()
+	time.Sleep(d)
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+}
+
+func TestRegistry_ReRegister(t *testing.T) {
+	p := newProxy(t, "127.0.0.1:2181")
+	// the session expires while the proxy is paused for longer than the session timeout
+	conn, _, err := zk.Connect([]string{p.ln.Addr().String()}, time.Second*4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	observer, _, err := zk.Connect([]string{"127.0.0.1:2181"}, time.Second*15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer observer.Close()
+	r := New(conn)
+
+	svr := &registry.ServiceInstance{
+		ID:        "1",
+		Name:      "hello-reregister",
+		Version:   "v1.0.0",
+		Endpoints: []string{"127.0.0.1:8080"},
+	}
+	if err = r.Register(context.Background(), svr); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = r.Deregister(context.Background(), svr)
+	}()
+	dir := path.Join("/microservices", svr.Name)
+	if nodes := instanceNodes(t, observer, dir, svr.ID); len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %v", nodes)
+	}
+	sessionID := conn.SessionID()
+
+	p.pause(time.Second * 10)
+	deadline := time.Now().Add(time.Second * 20)
+	for {
+		nodes := instanceNodes(t, observer, dir, svr.ID)
+		if len(nodes) == 1 && conn.SessionID() != sessionID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the node to be created again in the new session, got %v", nodes)
+		}
+		time.Sleep(time.Millisecond * 200)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"path"
 	"sync/atomic"
+	"time"
 
 	"github.com/go-zookeeper/zk"
 
@@ -36,6 +37,8 @@ func newWatcher(ctx context.Context, prefix, serviceName string, conn *zk.Conn)
 }
 
 func (w *watcher) watch(ctx context.Context) {
+	// 连接断开后重新 watch 时，需要重新获取一次服务列表，断开期间的变化不会收到通知
+	resync := false
 	for {
 		// 每次 watch 只有一次有效期 所以循环 watch
 		_, _, ch, err := w.conn.ChildrenW(w.prefix)
@@ -46,7 +49,22 @@ func (w *watcher) watch(ctx context.Context) {
 				_, _, ch, err = w.conn.ExistsW(w.prefix)
 			}
 			if err != nil {
-				w.event <- zk.Event{Err: err}
+				if !w.send(ctx, zk.Event{Err: err}) {
+					return
+				}
+				// 等待会话重新建立后重试
+				resync = true
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+		}
+		if resync {
+			resync = false
+			if !w.send(ctx, zk.Event{Type: zk.EventNodeChildrenChanged, Path: w.prefix}) {
 				return
 			}
 		}
@@ -54,11 +72,26 @@ func (w *watcher) watch(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case ev := <-ch:
-			w.event <- ev
+			if ev.Type == zk.EventNotWatching {
+				resync = true
+			}
+			if !w.send(ctx, ev) {
+				return
+			}
 		}
 	}
 }
 
+// send 发送事件，watcher 停止时返回 false
+func (w *watcher) send(ctx context.Context, ev zk.Event) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case w.event <- ev:
+		return true
+	}
+}
+
 func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
 	// todo 如果多处调用 next 可能会导致多实例信息不同步
 	if atomic.CompareAndSwapUint32(&w.first, 0, 1) {
@@ -0,0 +1,158 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	khttp "github.com/cnsync/kratos/transport/http"
+)
+
+var _ khttp.RouterEngine = (*Router)(nil)
+
+// routeKey 是请求上下文中匹配的路由的键。
+type routeKey struct{}
+
+// route 是请求匹配的路由。
+type route struct {
+	template string // 路径模板
+	wildcard string // 通配参数 {name:.*} 的名称，chi 中对应参数 *
+}
+
+// Router 是基于 go-chi/chi 的路由引擎，通过 khttp.Engine 选项替换服务器默认的 gorilla/mux。
+// 带有正则表达式的参数 {name:regexp} 由 chi 校验，位于模板末尾的 {name:.*} 转换为 chi 的通配符 *；
+// chi 的正则表达式只匹配单个路径段，其他匹配多个路径段的参数无法注册，错误通过 Err 返回。
+type Router struct {
+	mux    *chi.Mux
+	routes []khttp.RouteInfo
+	err    error // 第一个注册失败的错误
+}
+
+// NewRouter 创建一个基于 chi 的路由引擎。
+func NewRouter() *Router {
+	return &Router{mux: chi.NewRouter()}
+}
+
+// Mux 返回 chi 的路由器，用于配置 NotFound、MethodNotAllowed 等选项。
+func (r *Router) Mux() *chi.Mux {
+	return r.mux
+}
+
+// ServeHTTP 分发请求。
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
+
+// Handle 注册匹配请求方法与路径模板的路由。
+func (r *Router) Handle(method, pattern string, h http.Handler) {
+	std, wildcard, err := chiPattern(pattern)
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	h = withRoute(h, &route{template: pattern, wildcard: wildcard})
+	if !r.register(func() {
+		if method == "" {
+			r.mux.Handle(std, h)
+		} else {
+			r.mux.Method(method, std, h)
+		}
+	}) {
+		return
+	}
+	if method != "" {
+		r.routes = append(r.routes, khttp.RouteInfo{Method: method, Path: pattern})
+	}
+}
+
+// HandlePrefix 注册匹配路径前缀的路由。
+func (r *Router) HandlePrefix(prefix string, h http.Handler) {
+	h = withRoute(h, &route{template: prefix})
+	r.register(func() {
+		r.mux.Handle(strings.TrimSuffix(prefix, "/")+"/*", h)
+	})
+}
+
+// Err 返回第一个注册失败的错误，例如模式不合法或与已注册的模式冲突。
+func (r *Router) Err() error {
+	return r.err
+}
+
+// register 注册路由，将 chi 的 panic 转换为错误，chi 的 panic 信息已经带有 "chi: " 前缀，返回是否注册成功。
+func (r *Router) register(fn func()) (ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.setErr(fmt.Errorf("%v", v))
+		}
+	}()
+	fn()
+	return true
+}
+
+func (r *Router) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Walk 遍历已注册的路由，只包含指定了请求方法的路由。
+func (r *Router) Walk(fn khttp.WalkRouteFunc) error {
+	for _, info := range r.routes {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Vars 返回请求匹配的路径参数。
+func (r *Router) Vars(req *http.Request) map[string]string {
+	rctx := chi.RouteContext(req.Context())
+	if rctx == nil || len(rctx.URLParams.Keys) == 0 {
+		return nil
+	}
+	rt, _ := req.Context().Value(routeKey{}).(*route)
+	vars := make(map[string]string, len(rctx.URLParams.Keys))
+	for i, k := range rctx.URLParams.Keys {
+		if k == "*" {
+			if rt == nil || rt.wildcard == "" {
+				continue
+			}
+			k = rt.wildcard
+		}
+		vars[k] = rctx.URLParams.Values[i]
+	}
+	return vars
+}
+
+// Template 返回请求匹配的路径模板。
+func (r *Router) Template(req *http.Request) string {
+	if rt, ok := req.Context().Value(routeKey{}).(*route); ok {
+		return rt.template
+	}
+	return ""
+}
+
+// withRoute 返回在请求上下文中记录匹配的路由的处理器。
+func withRoute(h http.Handler, rt *route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), routeKey{}, rt)))
+	})
+}
+
+// chiPattern 将 gorilla/mux 的路径模板转换为 chi 的模式，并返回通配参数的名称。
+// 两者的参数语法相同，只需要将末尾的 {name:.*} 转换为通配符，正则表达式匹配多个路径段时返回错误。
+func chiPattern(pattern string) (string, string, error) {
+	for _, p := range khttp.PathParams(pattern) {
+		if p.Wildcard(pattern) {
+			return pattern[:p.Start] + "*", p.Name, nil
+		}
+		if re := strings.ReplaceAll(p.Regexp, "[^/]", ""); strings.Contains(re, "/") || strings.Contains(re, ".*") {
+			return "", "", fmt.Errorf("chi: pattern %q: parameter %s matches multiple path segments", pattern, pattern[p.Start:p.End])
+		}
+	}
+	return pattern, "", nil
+}
@@ -0,0 +1,10 @@
+module github.com/cnsync/kratos/contrib/router/chi
+
+go 1.23.3
+
+require (
+	github.com/cnsync/kratos v0.0.0-00010101000000-000000000000
+	github.com/go-chi/chi/v5 v5.2.1
+)
+
+replace github.com/cnsync/kratos => ../../../
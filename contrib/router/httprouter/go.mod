@@ -0,0 +1,10 @@
+module github.com/cnsync/kratos/contrib/router/httprouter
+
+go 1.23.3
+
+require (
+	github.com/cnsync/kratos v0.0.0-00010101000000-000000000000
+	github.com/julienschmidt/httprouter v1.3.0
+)
+
+replace github.com/cnsync/kratos => ../../../
@@ -0,0 +1,195 @@
+package httprouter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	khttp "github.com/cnsync/kratos/transport/http"
+)
+
+var _ khttp.RouterEngine = (*Router)(nil)
+
+// methods 是不指定请求方法的路由注册的方法，httprouter 的路由必须指定请求方法。
+var methods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// routeKey 是请求上下文中匹配的路由的键。
+type routeKey struct{}
+
+// route 是请求匹配的路由。
+type route struct {
+	template string // 路径模板
+	wildcard string // 通配参数 {name:.*} 的名称
+	prefix   bool   // 是否是前缀路由
+}
+
+// Router 是基于 julienschmidt/httprouter 的路由引擎，通过 khttp.Engine 选项替换服务器默认的 gorilla/mux。
+// 参数 {name} 转换为 :name，位于模板末尾的 {name:.*} 转换为 *name；httprouter 不支持正则表达式，
+// 参数必须是完整的路径段，其他带有正则表达式的参数或包含 ":"、"*" 的路径无法注册，错误通过 Err 返回。
+type Router struct {
+	router *httprouter.Router
+	routes []khttp.RouteInfo
+	err    error // 第一个注册失败的错误
+}
+
+// NewRouter 创建一个基于 httprouter 的路由引擎。
+func NewRouter() *Router {
+	return &Router{router: httprouter.New()}
+}
+
+// HTTPRouter 返回 httprouter 的路由器，用于配置 NotFound、RedirectTrailingSlash 等选项。
+func (r *Router) HTTPRouter() *httprouter.Router {
+	return r.router
+}
+
+// ServeHTTP 分发请求。
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.router.ServeHTTP(w, req)
+}
+
+// Handle 注册匹配请求方法与路径模板的路由，method 为空时注册所有请求方法。
+func (r *Router) Handle(method, pattern string, h http.Handler) {
+	std, wildcard, err := routerPattern(pattern)
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	h = withRoute(h, &route{template: pattern, wildcard: wildcard})
+	if method != "" {
+		if r.register(method, std, h) {
+			r.routes = append(r.routes, khttp.RouteInfo{Method: method, Path: pattern})
+		}
+		return
+	}
+	for _, m := range methods {
+		if !r.register(m, std, h) {
+			return
+		}
+	}
+}
+
+// HandlePrefix 注册匹配路径前缀的路由。
+func (r *Router) HandlePrefix(prefix string, h http.Handler) {
+	std := strings.TrimSuffix(prefix, "/") + "/*path"
+	h = withRoute(h, &route{template: prefix, prefix: true})
+	for _, m := range methods {
+		if !r.register(m, std, h) {
+			return
+		}
+	}
+}
+
+// Err 返回第一个注册失败的错误，例如模式不合法或与已注册的模式冲突。
+func (r *Router) Err() error {
+	return r.err
+}
+
+// register 注册路由，将 httprouter 的 panic 转换为错误，返回是否注册成功。
+func (r *Router) register(method, path string, h http.Handler) (ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.setErr(fmt.Errorf("httprouter: %v", v))
+		}
+	}()
+	r.router.Handler(method, path, h)
+	return true
+}
+
+func (r *Router) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Walk 遍历已注册的路由，只包含指定了请求方法的路由。
+func (r *Router) Walk(fn khttp.WalkRouteFunc) error {
+	for _, info := range r.routes {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Vars 返回请求匹配的路径参数，通配参数的值不包含开头的 "/"，与 gorilla/mux 一致。
+func (r *Router) Vars(req *http.Request) map[string]string {
+	rt, ok := req.Context().Value(routeKey{}).(*route)
+	if !ok || rt.prefix {
+		return nil
+	}
+	params := httprouter.ParamsFromContext(req.Context())
+	if len(params) == 0 {
+		return nil
+	}
+	vars := make(map[string]string, len(params))
+	for _, p := range params {
+		if p.Key == rt.wildcard {
+			p.Value = strings.TrimPrefix(p.Value, "/")
+		}
+		vars[p.Key] = p.Value
+	}
+	return vars
+}
+
+// Template 返回请求匹配的路径模板。
+func (r *Router) Template(req *http.Request) string {
+	if rt, ok := req.Context().Value(routeKey{}).(*route); ok {
+		return rt.template
+	}
+	return ""
+}
+
+// withRoute 返回在请求上下文中记录匹配的路由的处理器。
+func withRoute(h http.Handler, rt *route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), routeKey{}, rt)))
+	})
+}
+
+// routerPattern 将 gorilla/mux 的路径模板转换为 httprouter 的路径，并返回通配参数的名称。
+func routerPattern(pattern string) (string, string, error) {
+	var (
+		b        strings.Builder
+		wildcard string
+		last     int
+	)
+	for _, p := range khttp.PathParams(pattern) {
+		param := pattern[p.Start:p.End]
+		if p.Start == 0 || pattern[p.Start-1] != '/' || (p.End < len(pattern) && pattern[p.End] != '/') {
+			return "", "", fmt.Errorf("httprouter: pattern %q: parameter %s must be a full path segment", pattern, param)
+		}
+		if err := literal(pattern, pattern[last:p.Start]); err != nil {
+			return "", "", err
+		}
+		b.WriteString(pattern[last:p.Start])
+		switch {
+		case p.Regexp == "":
+			b.WriteString(":" + p.Name)
+		case p.Wildcard(pattern):
+			b.WriteString("*" + p.Name)
+			wildcard = p.Name
+		default:
+			return "", "", fmt.Errorf("httprouter: pattern %q: parameter %s has a regexp that httprouter cannot enforce", pattern, param)
+		}
+		last = p.End
+	}
+	if err := literal(pattern, pattern[last:]); err != nil {
+		return "", "", err
+	}
+	b.WriteString(pattern[last:])
+	return b.String(), wildcard, nil
+}
+
+// literal 检查路径模板中参数以外的部分，":" 与 "*" 在 httprouter 中表示参数。
+func literal(pattern, s string) error {
+	if strings.ContainsAny(s, ":*") {
+		return fmt.Errorf("httprouter: pattern %q: \":\" and \"*\" are reserved for parameters", pattern)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	khttp "github.com/cnsync/kratos/transport/http"
+)
+
+func TestRouter(t *testing.T) {
+	srv := khttp.NewServer(khttp.Engine(NewRouter()))
+	r := srv.Route("/v1")
+	r.GET("/users/{id}", func(ctx khttp.Context) error {
+		return ctx.String(200, ctx.Vars().Get("id"))
+	})
+	r.POST("/messages/{name:.*}", func(ctx khttp.Context) error {
+		return ctx.String(200, ctx.Vars().Get("name"))
+	})
+	srv.HandlePrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("static"))
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/v1/users/7", 200, "7"},
+		{http.MethodGet, "/v1/users/7/books", 404, ""},
+		{http.MethodDelete, "/v1/users/7", 405, ""},
+		{http.MethodPost, "/v1/messages/messages/1/2", 200, "messages/1/2"},
+		{http.MethodGet, "/static/app.js", 200, "static"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code || (test.body != "" && w.Body.String() != test.body) {
+			t.Errorf("%s %s: unexpected response: %d %s", test.method, test.path, w.Code, w.Body.String())
+		}
+	}
+
+	var routes []khttp.RouteInfo
+	if err := srv.WalkRoute(func(r khttp.RouteInfo) error {
+		routes = append(routes, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []khttp.RouteInfo{{Path: "/v1/users/{id}", Method: http.MethodGet}, {Path: "/v1/messages/{name:.*}", Method: http.MethodPost}}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expect %v, got %v", expected, routes)
+	}
+	if _, err := srv.Endpoint(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouter_InvalidPattern(t *testing.T) {
+	// httprouter 不支持正则表达式，参数必须是完整的路径段
+	for _, pattern := range []string{"/users/{id:[0-9]+}", "/v1/{name:messages/.*}", "/files/{name}.{ext}", "/v1/users:batchGet"} {
+		srv := khttp.NewServer(khttp.Engine(NewRouter()))
+		srv.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+		if _, err := srv.Endpoint(); err == nil {
+			t.Errorf("%s: expect error", pattern)
+		}
+	}
+
+	// httprouter 注册冲突的路由时返回错误而不是 panic
+	router := NewRouter()
+	router.Handle(http.MethodGet, "/users/{id}", http.NotFoundHandler())
+	router.Handle(http.MethodGet, "/users/{name}", http.NotFoundHandler())
+	if router.Err() == nil {
+		t.Error("expect error for the conflicting pattern")
+	}
+}
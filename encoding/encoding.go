@@ -32,8 +32,16 @@ func RegisterCodec(codec Codec) {
 
 // GetCodec 根据内容子类型获取已注册的 Codec，
 // 如果该内容子类型没有注册对应的 Codec，则返回 nil。
+// 带有结构化语法后缀（RFC 6839）的子类型，如厂商类型 vnd.myapp.v2+json 与 problem+json，
+// 没有单独注册 Codec 时使用后缀 json 对应的 Codec。
 //
 // 内容子类型预期为小写格式。
 func GetCodec(contentSubtype string) Codec {
-	return registeredCodecs[contentSubtype]
+	if codec, ok := registeredCodecs[contentSubtype]; ok {
+		return codec
+	}
+	if i := strings.LastIndexByte(contentSubtype, '+'); i >= 0 {
+		return registeredCodecs[contentSubtype[i+1:]]
+	}
+	return nil
 }
@@ -75,6 +75,20 @@ func TestRegisterCodec(t *testing.T) {
 	}
 }
 
+// TestGetCodec_Suffix 测试带有结构化语法后缀的子类型使用后缀对应的编解码器。
+func TestGetCodec_Suffix(t *testing.T) {
+	codec := codec2{}
+	RegisterCodec(codec)
+	for _, subtype := range []string{"vnd.myapp.v2+xml", "problem+xml"} {
+		if got := GetCodec(subtype); got != codec {
+			t.Errorf("GetCodec(%s) want %v got %v", subtype, codec, got)
+		}
+	}
+	if got := GetCodec("vnd.myapp.v2+unknown"); got != nil {
+		t.Errorf("GetCodec() want nil got %v", got)
+	}
+}
+
 // PanicTestFunc 定义了一个应该传递给 assert.Panics 和 assert.NotPanics 方法的函数，它表示一个不带参数且不返回任何内容的简单函数。
 type PanicTestFunc func()
 
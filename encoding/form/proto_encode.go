@@ -105,6 +105,10 @@ func encodeByField(u url.Values, path string, m protoreflect.Message) (finalErr
 				}
 			}
 		case (fd.Kind() == protoreflect.MessageKind) || (fd.Kind() == protoreflect.GroupKind):
+			// Empty 消息没有任何字段，无需编码
+			if fd.Message().FullName() == emptyMessageFullname {
+				return true
+			}
 			// 如果字段是一个消息或组，则递归编码该消息
 			value, err := encodeMessage(fd.Message(), v)
 			if err == nil {
@@ -248,6 +252,12 @@ func encodeMessage(msgDescriptor protoreflect.MessageDescriptor, value protorefl
 		fd := msgDescriptor.Fields()
 		v := value.Message().Get(fd.ByName("value"))
 		return fmt.Sprint(v.Interface()), nil
+	case emptyMessageFullname:
+		// 如果是空消息，则编码为空字符串
+		return "", nil
+	case anyMessageFullname:
+		// 如果是 Any 消息，则调用 marshalAny 函数进行编码
+		return marshalAny(value.Message())
 	case fieldMaskFullName:
 		// 如果是字段掩码消息，则将其路径转换为驼峰命名法并返回逗号分隔的字符串
 		m, ok := value.Message().Interface().(*fieldmaskpb.FieldMask)
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -47,6 +48,12 @@ const (
 
 	// 定义了 google.protobuf.FieldMask 消息的全名
 	fieldMaskFullName protoreflect.FullName = "google.protobuf.FieldMask"
+
+	// 定义了 google.protobuf.Empty 消息的全名
+	emptyMessageFullname protoreflect.FullName = "google.protobuf.Empty"
+
+	// 定义了 google.protobuf.Any 消息的全名
+	anyMessageFullname protoreflect.FullName = "google.protobuf.Any"
 )
 
 // marshalTimestamp 函数用于将一个 protobuf 消息中的时间戳字段编码为 URL 查询字符串格式。
@@ -161,3 +168,20 @@ func marshalBytes(m protoreflect.Message) (string, error) {
 	// 将字节字段的值编码为 base64 字符串
 	return base64.StdEncoding.EncodeToString(val), nil
 }
+
+// marshalAny 函数用于将一个 google.protobuf.Any 消息编码为 URL 查询字符串格式。
+// 编码结果为带有 "@type" 类型 URL 的 JSON 字符串，其中的消息类型必须已注册。
+// 参数：
+//   - m：要编码的 Any 消息。
+//
+// 返回值：
+//   - string：编码后的 URL 查询字符串。
+//   - error：如果编码过程中发生错误，返回该错误。
+func marshalAny(m protoreflect.Message) (string, error) {
+	// 使用 protojson 编码，保证与 JSON 编码器的 Any 格式一致
+	data, err := protojson.Marshal(m.Interface())
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", anyMessageFullname, err)
+	}
+	return string(data), nil
+}
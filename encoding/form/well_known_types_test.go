@@ -5,8 +5,11 @@ import (
 	"testing"
 	"time"
 
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
@@ -93,3 +96,42 @@ func TestMarshalBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalAny(t *testing.T) {
+	in, err := anypb.New(wrapperspb.String("kratos"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := marshalAny(in.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := parseMessage(in.ProtoReflect().Descriptor(), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(in, v.Message().Interface()) {
+		t.Errorf("expect %v, got %v", in, v.Message().Interface())
+	}
+	if _, err = parseMessage(in.ProtoReflect().Descriptor(), "kratos"); err == nil {
+		t.Error("expect error for invalid any payload")
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	md := (&emptypb.Empty{}).ProtoReflect().Descriptor()
+	v, err := parseMessage(md, "ignored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&emptypb.Empty{}, v.Message().Interface()) {
+		t.Errorf("expect empty, got %v", v.Message().Interface())
+	}
+	got, err := encodeMessage(md, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("expect empty string, got %q", got)
+	}
+}
@@ -1,12 +1,15 @@
 // Package proto 定义了 protobuf 编解码器。导入该包时会自动注册该编解码器。
+// 消息实现了 vtprotobuf 生成的 MarshalVT/UnmarshalVT 方法时，编解码器会优先使用这些方法，
+// 可以通过 Register(WithStandard()) 强制使用标准 protobuf 库。
 package proto
 
 import (
 	"errors"
-	"github.com/cnsync/kratos/encoding"
 	"reflect"
 
 	"google.golang.org/protobuf/proto"
+
+	"github.com/cnsync/kratos/encoding"
 )
 
 // Name 是为 proto 编解码器注册的名称。
@@ -17,17 +20,60 @@ func init() {
 	encoding.RegisterCodec(codec{})
 }
 
+// vtMarshaler 是 vtprotobuf 生成的序列化方法
+type vtMarshaler interface {
+	MarshalVT() ([]byte, error)
+}
+
+// vtUnmarshaler 是 vtprotobuf 生成的反序列化方法
+type vtUnmarshaler interface {
+	UnmarshalVT([]byte) error
+}
+
+// Option 是 proto 编解码器的配置选项
+type Option func(*codec)
+
+// WithStandard 强制使用标准 protobuf 库编解码，忽略 vtprotobuf 生成的方法
+func WithStandard() Option {
+	return func(c *codec) {
+		c.standard = true
+	}
+}
+
+// NewCodec 使用给定的选项创建一个 proto 编解码器
+func NewCodec(opts ...Option) encoding.Codec {
+	c := codec{}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// Register 使用给定的选项重新注册 proto 编解码器，覆盖导入时注册的默认编解码器
+func Register(opts ...Option) {
+	encoding.RegisterCodec(NewCodec(opts...))
+}
+
 // codec 是基于 protobuf 的 Codec 实现。它是 Transport 的默认编解码器。
-type codec struct{}
+type codec struct {
+	// standard 为 true 时不使用 vtprotobuf 生成的方法
+	standard bool
+}
 
 // Marshal 方法将一个 Go 语言的值序列化为 Protocol Buffers 格式的字节切片
-func (codec) Marshal(v interface{}) ([]byte, error) {
+func (c codec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(vtMarshaler); ok && !c.standard {
+		return m.MarshalVT()
+	}
 	// 使用 protobuf 包中的 Marshal 函数将值 v 序列化为 Protocol Buffers 格式
 	return proto.Marshal(v.(proto.Message))
 }
 
 // Unmarshal 方法将一个 Protocol Buffers 格式的字节切片反序列化为 Go 语言中的值
-func (codec) Unmarshal(data []byte, v interface{}) error {
+func (c codec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(vtUnmarshaler); ok && !c.standard {
+		return m.UnmarshalVT(data)
+	}
 	// 获取 protobuf 消息对象
 	pm, err := getProtoMessage(v)
 	if err != nil {
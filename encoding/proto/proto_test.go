@@ -4,6 +4,9 @@ import (
 	"reflect"
 	"testing"
 
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cnsync/kratos/encoding"
 	testData "github.com/cnsync/kratos/internal/testdata/encoding"
 )
 
@@ -117,3 +120,72 @@ func Test_getProtoMessage(t *testing.T) {
 		})
 	}
 }
+
+// vtModel 模拟 vtprotobuf 生成的消息，记录 vtprotobuf 方法是否被调用
+type vtModel struct {
+	*testData.TestModel
+	marshaled   bool
+	unmarshaled bool
+}
+
+func (m *vtModel) MarshalVT() ([]byte, error) {
+	m.marshaled = true
+	return proto.Marshal(m.TestModel)
+}
+
+func (m *vtModel) UnmarshalVT(data []byte) error {
+	m.unmarshaled = true
+	return proto.Unmarshal(data, m.TestModel)
+}
+
+// TestCodec_VT 测试实现了 vtprotobuf 方法的消息优先使用 vtprotobuf 编解码
+func TestCodec_VT(t *testing.T) {
+	c := NewCodec()
+	in := &vtModel{TestModel: &testData.TestModel{Id: 1, Name: "kratos"}}
+	data, err := c.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !in.marshaled {
+		t.Error("expect MarshalVT to be used")
+	}
+	out := &vtModel{TestModel: &testData.TestModel{}}
+	if err = c.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.unmarshaled {
+		t.Error("expect UnmarshalVT to be used")
+	}
+	if out.Id != 1 || out.Name != "kratos" {
+		t.Errorf("unexpected message: %v", out.TestModel)
+	}
+}
+
+// TestCodec_Standard 测试 WithStandard 强制使用标准 protobuf 库
+func TestCodec_Standard(t *testing.T) {
+	c := NewCodec(WithStandard())
+	in := &vtModel{TestModel: &testData.TestModel{Id: 1, Name: "kratos"}}
+	data, err := c.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &vtModel{TestModel: &testData.TestModel{}}
+	if err = c.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if in.marshaled || out.unmarshaled {
+		t.Error("expect vtprotobuf methods not to be used")
+	}
+	if out.Id != 1 || out.Name != "kratos" {
+		t.Errorf("unexpected message: %v", out.TestModel)
+	}
+}
+
+// TestRegister 测试使用选项重新注册编解码器
+func TestRegister(t *testing.T) {
+	defer Register()
+	Register(WithStandard())
+	if c, ok := encoding.GetCodec(Name).(codec); !ok || !c.standard {
+		t.Errorf("expect standard codec to be registered, got %#v", encoding.GetCodec(Name))
+	}
+}
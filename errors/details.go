@@ -0,0 +1,63 @@
+package errors
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// WithLocalizedMessage 设置面向用户的本地化错误消息并返回新的错误对象。
+func (e *Error) WithLocalizedMessage(locale, message string) *Error {
+	err := Clone(e)
+	err.localized = &errdetails.LocalizedMessage{Locale: locale, Message: message}
+	return err
+}
+
+// WithFieldViolations 设置请求字段的校验错误并返回新的错误对象。
+func (e *Error) WithFieldViolations(violations ...*errdetails.BadRequest_FieldViolation) *Error {
+	err := Clone(e)
+	err.violations = violations
+	return err
+}
+
+// LocalizedMessage 返回本地化错误消息，未设置时返回 nil。
+func (e *Error) LocalizedMessage() *errdetails.LocalizedMessage {
+	return e.localized
+}
+
+// FieldViolations 返回请求字段的校验错误。
+func (e *Error) FieldViolations() []*errdetails.BadRequest_FieldViolation {
+	return e.violations
+}
+
+// FieldViolation 创建一个请求字段的校验错误。
+func FieldViolation(field, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+// Causes 返回错误根因链中的结构化错误，按从外到内的顺序排列。
+// 非 *Error 类型的根因可能包含内部实现细节，不会出现在结果中，也不会通过传输层传递。
+func (e *Error) Causes() []*Error {
+	var causes []*Error
+	for err := e.cause; err != nil; err = errors.Unwrap(err) {
+		if se := new(Error); errors.As(err, &se) {
+			causes = append(causes, se)
+			err = se
+		} else {
+			break
+		}
+	}
+	return causes
+}
+
+// withCauses 将结构化的根因链设置为错误的根因。
+func (e *Error) withCauses(causes []*Error) *Error {
+	if len(causes) == 0 {
+		return e
+	}
+	for i := len(causes) - 1; i > 0; i-- {
+		causes[i-1].cause = causes[i]
+	}
+	e.cause = causes[0]
+	return e
+}
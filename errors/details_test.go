@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetails(t *testing.T) {
+	inner := New(503, "DB_UNAVAILABLE", "database unavailable").WithMetadata(map[string]string{"db": "users"})
+	middle := New(500, "REPO", "query failed").WithCause(fmt.Errorf("wrap: %w", inner))
+	err := BadRequest("INVALID", "invalid argument").
+		WithLocalizedMessage("zh-CN", "参数错误").
+		WithFieldViolations(FieldViolation("name", "must not be empty")).
+		WithCause(middle)
+
+	causes := err.Causes()
+	if len(causes) != 2 || causes[0].Reason != "REPO" || causes[1].Reason != "DB_UNAVAILABLE" {
+		t.Fatalf("unexpected causes: %v", causes)
+	}
+	// 非结构化的根因不会出现在根因链中
+	if c := New(500, "", "").WithCause(fmt.Errorf("internal")).Causes(); len(c) != 0 {
+		t.Errorf("expected no causes, got %v", c)
+	}
+
+	// 默认不通过 gRPC 状态传递根因链
+	if c := FromError(err.GRPCStatus().Err()).Causes(); len(c) != 0 {
+		t.Errorf("expected no causes by default, got %v", c)
+	}
+
+	// 通过 gRPC 状态传递后还原
+	se := FromError(err.DetailedGRPCStatus().Err())
+	if se.Code != 400 || se.Reason != "INVALID" {
+		t.Errorf("unexpected error: %v", se)
+	}
+	if l := se.LocalizedMessage(); l == nil || l.Locale != "zh-CN" || l.Message != "参数错误" {
+		t.Errorf("unexpected localized message: %v", l)
+	}
+	if v := se.FieldViolations(); len(v) != 1 || v[0].Field != "name" {
+		t.Errorf("unexpected field violations: %v", v)
+	}
+	causes = se.Causes()
+	if len(causes) != 2 || causes[0].Code != 500 || causes[1].Code != 503 || causes[1].Metadata["db"] != "users" {
+		t.Errorf("unexpected causes: %v", causes)
+	}
+	if !Is(se, inner) {
+		t.Errorf("expected cause chain to contain %v", inner)
+	}
+	if c := Clone(err); c.LocalizedMessage() != err.LocalizedMessage() || len(c.FieldViolations()) != 1 {
+		t.Errorf("expected clone to keep details")
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
 
 	httpstatus "github.com/cnsync/kratos/transport/http/status"
 )
@@ -23,6 +24,10 @@ const (
 type Error struct {
 	Status       // 嵌入的状态信息，包括错误码、原因、消息等。
 	cause  error // 错误的实际根因。
+
+	localized  *errdetails.LocalizedMessage            // 面向用户的本地化错误消息。
+	violations []*errdetails.BadRequest_FieldViolation // 请求字段的校验错误。
+	retryInfo  *errdetails.RetryInfo                   // 服务端建议的重试等待时间。
 }
 
 // Error 实现 `error` 接口，返回错误的字符串表示。
@@ -56,13 +61,49 @@ func (e *Error) WithMetadata(md map[string]string) *Error {
 }
 
 // GRPCStatus 将错误转换为 gRPC 的 `status.Status` 对象。
+// 本地化消息、字段校验错误以及重试信息会作为附加的详细信息传递，根因链可能包含内部实现细节，
+// 不会被传递，需要传递时使用 DetailedGRPCStatus。
 func (e *Error) GRPCStatus() *status.Status {
-	s, _ := status.New(httpstatus.ToGRPCCode(int(e.Code)), e.Message).WithDetails(
+	return e.grpcStatus(false)
+}
+
+// DetailedGRPCStatus 将错误转换为 gRPC 的 `status.Status` 对象，在 GRPCStatus 的基础上
+// 额外将结构化的根因链作为附加的详细信息传递。
+func (e *Error) DetailedGRPCStatus() *status.Status {
+	return e.grpcStatus(true)
+}
+
+func (e *Error) grpcStatus(withCauses bool) *status.Status {
+	details := []protoadapt.MessageV1{
 		&errdetails.ErrorInfo{
 			Reason:   e.Reason,
 			Metadata: e.Metadata,
 		},
-	)
+	}
+	if e.localized != nil {
+		details = append(details, e.localized)
+	}
+	if len(e.violations) > 0 {
+		details = append(details, &errdetails.BadRequest{FieldViolations: e.violations})
+	}
+	if e.retryInfo != nil {
+		details = append(details, e.retryInfo)
+	}
+	if withCauses {
+		for _, c := range e.Causes() {
+			details = append(details, &Status{
+				Code:     c.Code,
+				Reason:   c.Reason,
+				Message:  c.Message,
+				Metadata: c.Metadata,
+			})
+		}
+	}
+	code := httpstatus.ToGRPCCode(int(e.Code))
+	if _, c, ok := LookupCodeMapping(e.Reason); ok {
+		code = c // 使用错误原因注册的 gRPC 状态码
+	}
+	s, _ := status.New(code, e.Message).WithDetails(details...)
 	return s
 }
 
@@ -119,6 +160,10 @@ func Clone(err *Error) *Error {
 	return &Error{
 		// 复制 cause 字段，如果传入的 err 对象的 cause 字段为 nil，则新对象的 cause 字段也为 nil
 		cause: err.cause,
+		// 复制本地化消息、字段校验错误与重试信息
+		localized:  err.localized,
+		violations: err.violations,
+		retryInfo:  err.retryInfo,
 		// 复制 Status 结构体中的各个字段
 		Status: Status{
 			// 复制 Code 字段
@@ -153,12 +198,27 @@ func FromError(err error) *Error {
 		gs.Message(),
 	)
 	// 提取 gRPC 错误的详细信息（如 `ErrorInfo`）。
+	var causes []*Error
 	for _, detail := range gs.Details() {
 		switch d := detail.(type) {
 		case *errdetails.ErrorInfo:
 			ret.Reason = d.Reason
-			return ret.WithMetadata(d.Metadata) // 将元数据附加到错误对象。
+			ret.Metadata = d.Metadata // 将元数据附加到错误对象。
+		case *errdetails.LocalizedMessage:
+			ret.localized = d
+		case *errdetails.BadRequest:
+			ret.violations = d.FieldViolations
+		case *errdetails.RetryInfo:
+			ret.retryInfo = d
+		case *Status:
+			causes = append(causes, New(int(d.Code), d.Reason, d.Message).WithMetadata(d.Metadata))
 		}
 	}
-	return ret
+	// 使用注册的 HTTP 状态码还原 gRPC 状态码映射时丢失的精度
+	if code, grpcCode, ok := LookupCodeMapping(ret.Reason); ok && grpcCode == gs.Code() {
+		ret.Code = int32(code)
+	} else if code, ok := Lookup(ret.Reason); ok && httpstatus.ToGRPCCode(code) == gs.Code() {
+		ret.Code = int32(code)
+	}
+	return ret.withCauses(causes)
 }
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	// reasons 保存错误原因与 HTTP 状态码的对应关系。
+	reasons sync.Map
+	// mappings 保存错误原因自定义的 HTTP 状态码与 gRPC 状态码。
+	mappings sync.Map
+)
+
+// codeMapping 是错误原因自定义的状态码映射。
+type codeMapping struct {
+	http int
+	grpc codes.Code
+}
+
+// Register 注册错误原因对应的 HTTP 状态码，通常由 protoc-gen-go-errors 生成的代码在 init 中调用。
+// 注册后，通过 gRPC 传递的错误可以还原为准确的 HTTP 状态码，使 HTTP 与 gRPC 的错误判断保持一致。
+// 重复注册同一原因时，以最后一次注册为准。
+func Register(reason string, code int) {
+	reasons.Store(reason, code)
+}
+
+// Lookup 返回错误原因注册的 HTTP 状态码。
+func Lookup(reason string) (int, bool) {
+	v, ok := reasons.Load(reason)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+// FromReason 使用错误原因注册的 HTTP 状态码创建一个错误，未注册时使用 UnknownCode。
+func FromReason(reason, message string) *Error {
+	code, ok := Lookup(reason)
+	if !ok {
+		code = UnknownCode
+	}
+	return New(code, reason, message)
+}
+
+// RegisterCodeMapping 注册错误原因对应的 HTTP 状态码与 gRPC 状态码，覆盖按错误码换算的默认映射，
+// 适用于业务错误需要使用非默认状态码的场景（例如 402、423）。
+// 注册后 GRPCStatus 使用注册的 gRPC 状态码，FromError 将收到的 gRPC 错误还原为注册的 HTTP 状态码，
+// HTTP 的错误编码器使用注册的 HTTP 状态码作为响应的状态码。
+func RegisterCodeMapping(reason string, httpCode int, grpcCode codes.Code) {
+	mappings.Store(reason, codeMapping{http: httpCode, grpc: grpcCode})
+	Register(reason, httpCode)
+}
+
+// LookupCodeMapping 返回错误原因注册的 HTTP 状态码与 gRPC 状态码。
+func LookupCodeMapping(reason string) (int, codes.Code, bool) {
+	v, ok := mappings.Load(reason)
+	if !ok {
+		return 0, codes.Unknown, false
+	}
+	m := v.(codeMapping)
+	return m.http, m.grpc, true
+}
+
+// HTTPStatus 返回错误作为 HTTP 响应时的状态码，错误原因注册了状态码映射时使用注册的 HTTP 状态码。
+// 如果错误为 `nil`，则返回 200。
+func HTTPStatus(err error) int {
+	se := FromError(err)
+	if se == nil {
+		return 200 //nolint:mnd
+	}
+	if code, _, ok := LookupCodeMapping(se.Reason); ok {
+		return code
+	}
+	return int(se.Code)
+}
@@ -0,0 +1,70 @@
+package errors
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRegister(t *testing.T) {
+	Register("PRECONDITION", 412)
+	if code, ok := Lookup("PRECONDITION"); !ok || code != 412 {
+		t.Errorf("expected 412, got %d", code)
+	}
+	if _, ok := Lookup("NOT_REGISTERED"); ok {
+		t.Error("expected reason not to be registered")
+	}
+	if e := FromReason("PRECONDITION", "precondition failed"); e.Code != 412 || e.Message != "precondition failed" {
+		t.Errorf("unexpected error: %v", e)
+	}
+	if e := FromReason("NOT_REGISTERED", ""); e.Code != UnknownCode {
+		t.Errorf("expected %d, got %d", UnknownCode, e.Code)
+	}
+
+	// 通过 gRPC 传递后还原注册的状态码
+	if e := FromError(New(412, "PRECONDITION", "").GRPCStatus().Err()); e.Code != 412 {
+		t.Errorf("expected 412, got %d", e.Code)
+	}
+	// 状态码与注册不一致时保留 gRPC 映射的状态码
+	if e := FromError(New(503, "PRECONDITION", "").GRPCStatus().Err()); e.Code != 503 {
+		t.Errorf("expected 503, got %d", e.Code)
+	}
+}
+
+func TestRegisterCodeMapping(t *testing.T) {
+	RegisterCodeMapping("PAYMENT_REQUIRED", 402, codes.FailedPrecondition)
+	if code, grpcCode, ok := LookupCodeMapping("PAYMENT_REQUIRED"); !ok || code != 402 || grpcCode != codes.FailedPrecondition {
+		t.Errorf("unexpected mapping: %d %v %v", code, grpcCode, ok)
+	}
+	if _, _, ok := LookupCodeMapping("NOT_REGISTERED"); ok {
+		t.Error("expected reason not to be registered")
+	}
+	if e := FromReason("PAYMENT_REQUIRED", ""); e.Code != 402 {
+		t.Errorf("expected 402, got %d", e.Code)
+	}
+
+	// 使用注册的 gRPC 状态码，而不是按照错误码换算
+	s := New(500, "PAYMENT_REQUIRED", "payment required").GRPCStatus()
+	if s.Code() != codes.FailedPrecondition {
+		t.Errorf("expected %v, got %v", codes.FailedPrecondition, s.Code())
+	}
+	// 通过 gRPC 传递后还原注册的 HTTP 状态码
+	if e := FromError(s.Err()); e.Code != 402 || e.Reason != "PAYMENT_REQUIRED" {
+		t.Errorf("unexpected error: %v", e)
+	}
+	// gRPC 状态码与注册不一致时保留默认的映射
+	if e := FromError(status.New(codes.Unavailable, "").Err()); e.Code != 503 {
+		t.Errorf("expected 503, got %d", e.Code)
+	}
+
+	if code := HTTPStatus(New(500, "PAYMENT_REQUIRED", "")); code != 402 {
+		t.Errorf("expected 402, got %d", code)
+	}
+	if code := HTTPStatus(New(409, "NOT_REGISTERED", "")); code != 409 {
+		t.Errorf("expected 409, got %d", code)
+	}
+	if code := HTTPStatus(nil); code != 200 {
+		t.Errorf("expected 200, got %d", code)
+	}
+}
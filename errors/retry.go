@@ -0,0 +1,44 @@
+package errors
+
+import (
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// WithRetryInfo 设置客户端重试前应等待的时间并返回新的错误对象，表示该错误可以重试。
+func (e *Error) WithRetryInfo(delay time.Duration) *Error {
+	err := Clone(e)
+	err.retryInfo = &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}
+	return err
+}
+
+// RetryInfo 返回服务端建议的重试等待时间，未设置时返回 false。
+func (e *Error) RetryInfo() (time.Duration, bool) {
+	if e.retryInfo == nil {
+		return 0, false
+	}
+	return e.retryInfo.GetRetryDelay().AsDuration(), true
+}
+
+// RetryDelay 返回错误中服务端建议的重试等待时间，未设置时返回 false。
+func RetryDelay(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	return FromError(err).RetryInfo()
+}
+
+// IsRetryable 判断错误是否可以重试。
+// 携带重试信息的错误，以及“服务不可用”和“网关超时”类型的错误都被认为是可以重试的。
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	se := FromError(err)
+	if _, ok := se.RetryInfo(); ok {
+		return true
+	}
+	return se.Code == 503 || se.Code == 504
+}
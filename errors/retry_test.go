@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryInfo(t *testing.T) {
+	err := New(429, "RATE_LIMITED", "too many requests").WithRetryInfo(3 * time.Second)
+	if d, ok := err.RetryInfo(); !ok || d != 3*time.Second {
+		t.Errorf("expected 3s, got %v", d)
+	}
+	if !IsRetryable(err) || !IsRetryable(fmt.Errorf("wrap: %w", err)) {
+		t.Error("expected error to be retryable")
+	}
+	if _, ok := New(429, "", "").RetryInfo(); ok {
+		t.Error("expected no retry info")
+	}
+	if c := Clone(err); c.retryInfo != err.retryInfo {
+		t.Error("expected clone to keep retry info")
+	}
+
+	// 通过 gRPC 状态传递后还原
+	if d, ok := RetryDelay(err.GRPCStatus().Err()); !ok || d != 3*time.Second {
+		t.Errorf("expected 3s, got %v", d)
+	}
+	if _, ok := RetryDelay(nil); ok {
+		t.Error("expected no retry info")
+	}
+
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{ServiceUnavailable("", ""), true},
+		{GatewayTimeout("", ""), true},
+		{InternalServer("", ""), false},
+		{BadRequest("", ""), false},
+	}
+	for _, test := range tests {
+		if got := IsRetryable(test.err); got != test.retryable {
+			t.Errorf("IsRetryable(%v) = %v, want %v", test.err, got, test.retryable)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package kratos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/cnsync/kratos/log"
+	"github.com/cnsync/kratos/registry"
+)
+
+// register registers the instance, keeping its lease alive if the registrar supports leases.
+func (a *App) register(ctx context.Context, instance *registry.ServiceInstance) error {
+	r, ok := a.opts.registrar.(registry.RegistrarTTL)
+	if !ok {
+		return a.opts.registrar.Register(ctx, instance)
+	}
+	lease, err := r.RegisterLease(ctx, instance)
+	if err != nil {
+		return err
+	}
+	if lease == nil || lease.TTL() <= 0 {
+		return nil
+	}
+	hctx, cancel := context.WithCancel(NewContext(a.ctx, a))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.heartbeat(hctx, r, instance, lease)
+	}()
+	a.mu.Lock()
+	a.stopHeartbeat = func() {
+		cancel()
+		<-done
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+// heartbeat renews the lease at jittered intervals of about a third of its TTL,
+// and registers the instance again once the lease is lost.
+func (a *App) heartbeat(ctx context.Context, r registry.RegistrarTTL, instance *registry.ServiceInstance, lease registry.Lease) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(heartbeatInterval(lease.TTL())):
+		}
+		hctx, cancel := context.WithTimeout(ctx, a.opts.registrarTimeout)
+		err := lease.Heartbeat(hctx)
+		if errors.Is(err, registry.ErrLeaseLost) {
+			var next registry.Lease
+			if next, err = r.RegisterLease(hctx, instance); err == nil && next != nil && next.TTL() > 0 {
+				lease = next
+				log.Infof("[kratos] service instance %s registered again after the lease was lost", instance)
+			}
+		}
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Warnf("[kratos] failed to keep the lease of service instance %s alive: %v", instance, err)
+		}
+	}
+}
+
+// heartbeatInterval returns a third of the TTL with ±10% jitter, so the instances do not renew at the same time.
+func heartbeatInterval(ttl time.Duration) time.Duration {
+	d := ttl / 3
+	if d <= 0 {
+		return ttl
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1)) - d/10 //nolint:gosec
+}
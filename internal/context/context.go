@@ -27,6 +27,8 @@ type mergeCtx struct {
 	cancelCh chan struct{}
 	// 确保 cancel 函数只被调用一次的 Once 对象
 	cancelOnce sync.Once
+	// 注销父 context 完成时的回调函数
+	stop func()
 }
 
 // Merge 函数用于合并两个 context.Context 对象
@@ -38,8 +40,14 @@ func Merge(parent1, parent2 context.Context) (context.Context, context.CancelFun
 		done:     make(chan struct{}),
 		cancelCh: make(chan struct{}),
 	}
-	// 启动一个 goroutine 等待两个父 context 完成
-	go mc.wait()
+	// 在两个父 context 完成时结束合并后的 context。标准库的 context 通过注册回调实现，
+	// 不需要为每个合并后的 context 启动一个等待的 goroutine
+	stop1 := mc.afterFunc(parent1)
+	stop2 := mc.afterFunc(parent2)
+	mc.stop = func() {
+		stop1()
+		stop2()
+	}
 	// 检查两个父 context 是否已经完成
 	select {
 	case <-parent1.Done():
@@ -56,6 +64,15 @@ func Merge(parent1, parent2 context.Context) (context.Context, context.CancelFun
 	return mc, mc.cancel
 }
 
+// afterFunc 函数用于在父 context 完成时结束合并后的 context，返回注销回调的函数，
+// 父 context 永远不会完成时不注册回调
+func (mc *mergeCtx) afterFunc(parent context.Context) func() bool {
+	if parent.Done() == nil {
+		return func() bool { return true }
+	}
+	return context.AfterFunc(parent, func() { _ = mc.finish(parent.Err()) })
+}
+
 // finish 函数用于标记合并后的 context 已完成，并设置错误信息
 func (mc *mergeCtx) finish(err error) error {
 	// 使用 doneOnce 确保 finish 函数只被调用一次
@@ -97,6 +114,11 @@ func (mc *mergeCtx) cancel() {
 	mc.cancelOnce.Do(func() {
 		// 关闭 cancelCh 通道，通知所有等待的 goroutine
 		close(mc.cancelCh)
+		// 注销父 context 的回调并结束合并后的 context
+		if mc.stop != nil {
+			mc.stop()
+			_ = mc.finish(context.Canceled)
+		}
 	})
 }
 
@@ -2,6 +2,8 @@ package endpoint
 
 import (
 	"net/url"
+	"path/filepath"
+	"strings"
 )
 
 // NewEndpoint 函数用于创建一个新的 URL 端点。
@@ -50,3 +52,26 @@ func Scheme(scheme string, isSecure bool) string {
 	}
 	return scheme
 }
+
+// UnixScheme 是 Unix 域套接字端点的协议。
+const UnixScheme = "unix"
+
+// NewUnixEndpoint 函数用于为 Unix 域套接字创建端点，例如 unix:///run/app.sock。
+// 参数：
+//   - path：套接字的路径，相对路径会被转换为绝对路径，以 "@" 开头的抽象套接字保持不变。
+//
+// 返回值：
+//   - *url.URL：新创建的 URL 端点。
+func NewUnixEndpoint(path string) *url.URL {
+	if !strings.HasPrefix(path, "@") {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	return &url.URL{Scheme: UnixScheme, Path: path}
+}
+
+// IsUnix 函数用于判断端点是否为 Unix 域套接字端点，这类端点只能在本机访问，不应注册到服务发现中。
+func IsUnix(u *url.URL) bool {
+	return u != nil && u.Scheme == UnixScheme
+}
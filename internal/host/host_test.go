@@ -3,6 +3,7 @@ package host
 import (
 	"net"
 	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -147,3 +148,61 @@ func TestIpIsUp(t *testing.T) {
 		println(interfaces[i].Name, interfaces[i].Flags&net.FlagUp)
 	}
 }
+
+func TestSelectIP(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.0.0/16")
+	cands := []candidate{
+		{iface: "eth0", index: 2, ip: net.ParseIP("10.0.0.2")},
+		{iface: "eth0", index: 2, ip: net.ParseIP("2001:db8::2")},
+		{iface: "eth1", index: 3, ip: net.ParseIP("192.168.1.3")},
+		{iface: "eth1", index: 3, ip: net.ParseIP("2001:db8::3")},
+		{iface: "lo", index: 1, ip: net.ParseIP("127.0.0.1")},
+		{iface: "tun0", index: 4, ip: net.ParseIP("fe80::1")},
+	}
+	tests := []struct {
+		name   string
+		opts   ExtractOptions
+		expect string
+	}{
+		{"default", ExtractOptions{}, "10.0.0.2"},
+		{"prefer ipv6", ExtractOptions{PreferIPv6: true}, "2001:db8::2"},
+		{"interface", ExtractOptions{Interface: "eth1"}, "192.168.1.3"},
+		{"interface ipv6", ExtractOptions{Interface: "eth1", PreferIPv6: true}, "2001:db8::3"},
+		{"subnet", ExtractOptions{Subnet: subnet}, "192.168.1.3"},
+		{"fallback family", ExtractOptions{Subnet: subnet, PreferIPv6: true}, "192.168.1.3"},
+		{"no match", ExtractOptions{Interface: "tun0"}, "<nil>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if ip := selectIP(cands, test.opts); ip.String() != test.expect {
+				t.Errorf("expected %s got %s", test.expect, ip)
+			}
+		})
+	}
+}
+
+func TestExtractWithOptions(t *testing.T) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	port, _ := Port(lis)
+	tests := []struct {
+		opts   ExtractOptions
+		expect string
+	}{
+		{ExtractOptions{Advertise: "203.0.113.1"}, net.JoinHostPort("203.0.113.1", strconv.Itoa(port))},
+		{ExtractOptions{Advertise: "203.0.113.1:443"}, "203.0.113.1:443"},
+		{ExtractOptions{Advertise: "2001:db8::1"}, net.JoinHostPort("2001:db8::1", strconv.Itoa(port))},
+	}
+	for _, test := range tests {
+		res, err := ExtractWithOptions(":0", lis, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != test.expect {
+			t.Errorf("expected %s got %s", test.expect, res)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package host
+
+import (
+	"net"
+	"strconv"
+)
+
+// ExtractOptions 是选择服务端点地址的选项。
+type ExtractOptions struct {
+	// Advertise 是直接使用的对外地址（例如 NAT 之后的公网地址），可以是 host 或 host:port，
+	// 未指定端口时使用监听的端口。
+	Advertise string
+	// Interface 是选择地址的网络接口名称，为空时选择所有已启用的网络接口。
+	Interface string
+	// Subnet 是地址必须属于的子网，为 nil 时不限制。
+	Subnet *net.IPNet
+	// PreferIPv6 表示优先选择 IPv6 地址，默认优先选择 IPv4 地址。
+	PreferIPv6 bool
+}
+
+// isZero 判断是否没有设置任何选项。
+func (o ExtractOptions) isZero() bool {
+	return o.Advertise == "" && o.Interface == "" && o.Subnet == nil && !o.PreferIPv6
+}
+
+// candidate 是一个网络接口上的候选地址。
+type candidate struct {
+	iface string
+	index int
+	ip    net.IP
+}
+
+// ExtractWithOptions 与 Extract 相同，但按照选项选择服务端点的地址，用于多网卡、IPv6 或 NAT 场景。
+func ExtractWithOptions(hostPort string, lis net.Listener, opts ExtractOptions) (string, error) {
+	if opts.isZero() {
+		return Extract(hostPort, lis)
+	}
+	addr, port, err := net.SplitHostPort(hostPort)
+	if err != nil && lis == nil {
+		return "", err
+	}
+	if lis != nil {
+		p, ok := Port(lis)
+		if !ok {
+			return Extract(hostPort, lis)
+		}
+		port = strconv.Itoa(p)
+	}
+	if opts.Advertise != "" {
+		if _, _, err := net.SplitHostPort(opts.Advertise); err == nil {
+			return opts.Advertise, nil
+		}
+		return net.JoinHostPort(opts.Advertise, port), nil
+	}
+	if len(addr) > 0 && (addr != "0.0.0.0" && addr != "[::]" && addr != "::") {
+		return net.JoinHostPort(addr, port), nil
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	var cands []candidate
+	for _, iface := range ifaces {
+		if (iface.Flags & net.FlagUp) == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, rawAddr := range addrs {
+			switch a := rawAddr.(type) {
+			case *net.IPAddr:
+				cands = append(cands, candidate{iface: iface.Name, index: iface.Index, ip: a.IP})
+			case *net.IPNet:
+				cands = append(cands, candidate{iface: iface.Name, index: iface.Index, ip: a.IP})
+			}
+		}
+	}
+	if ip := selectIP(cands, opts); ip != nil {
+		return net.JoinHostPort(ip.String(), port), nil
+	}
+	return "", nil
+}
+
+// selectIP 从候选地址中选择一个满足选项的有效地址，优先选择索引较小的网络接口上首选地址族的地址，
+// 没有首选地址族的地址时选择另一地址族的地址。
+func selectIP(cands []candidate, opts ExtractOptions) net.IP {
+	var preferred, fallback *candidate
+	for i := range cands {
+		c := &cands[i]
+		if opts.Interface != "" && c.iface != opts.Interface {
+			continue
+		}
+		if opts.Subnet != nil && !opts.Subnet.Contains(c.ip) {
+			continue
+		}
+		if !isValidIP(c.ip.String()) {
+			continue
+		}
+		isV6 := c.ip.To4() == nil
+		if isV6 == opts.PreferIPv6 {
+			if preferred == nil || c.index < preferred.index {
+				preferred = c
+			}
+		} else if fallback == nil || c.index < fallback.index {
+			fallback = c
+		}
+	}
+	if preferred != nil {
+		return preferred.ip
+	}
+	if fallback != nil {
+		return fallback.ip
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package httputil
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseCacheControl 将 Cache-Control 头解析为小写的指令与值。
+func ParseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, d := range strings.Split(header, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(d, "=")
+		directives[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return directives
+}
+
+// DeltaSeconds 解析指令中以秒为单位的时长，无效的值视为 0。
+func DeltaSeconds(v string) time.Duration {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
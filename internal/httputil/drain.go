@@ -0,0 +1,42 @@
+package httputil
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// MaxDrainBytes 是关闭响应体前最多丢弃的字节数。
+// 超过该大小时直接关闭响应体，放弃连接复用，避免为了复用连接读取过多数据。
+const MaxDrainBytes = 256 << 10
+
+// abortedReuse 统计因响应体未能完全读取而放弃连接复用的次数。
+var abortedReuse atomic.Int64
+
+// DrainAndClose 函数用于读取并丢弃响应体中剩余的数据后关闭响应体，使底层连接能够被复用。
+// 参数：
+//   - body：需要关闭的响应体。
+//
+// 返回值：
+//   - error：关闭响应体时发生的错误。
+func DrainAndClose(body io.ReadCloser) error {
+	if body == nil {
+		return nil
+	}
+	if !Drain(body) {
+		abortedReuse.Add(1)
+	}
+	return body.Close()
+}
+
+// Drain 函数用于读取并丢弃数据流中剩余的数据，最多读取 MaxDrainBytes 字节。
+// 返回值表示数据流是否被完全读取。
+func Drain(r io.Reader) bool {
+	n, err := io.CopyN(io.Discard, r, MaxDrainBytes+1)
+	return n <= MaxDrainBytes && (err == nil || errors.Is(err, io.EOF))
+}
+
+// AbortedReuse 函数返回因响应体未能完全读取而放弃连接复用的次数。
+func AbortedReuse() int64 {
+	return abortedReuse.Load()
+}
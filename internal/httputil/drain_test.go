@@ -0,0 +1,52 @@
+package httputil
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type testBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *testBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestDrainAndClose(t *testing.T) {
+	tests := []struct {
+		reader  io.Reader
+		aborted int64
+	}{
+		{strings.NewReader("small body"), 0},
+		{strings.NewReader(strings.Repeat("a", MaxDrainBytes)), 0},
+		{strings.NewReader(strings.Repeat("a", MaxDrainBytes+1)), 1},
+		{errReader{}, 1},
+	}
+	for _, test := range tests {
+		before := AbortedReuse()
+		body := &testBody{Reader: test.reader}
+		if err := DrainAndClose(body); err != nil {
+			t.Fatal(err)
+		}
+		if !body.closed {
+			t.Error("expected body to be closed")
+		}
+		if got := AbortedReuse() - before; got != test.aborted {
+			t.Errorf("expected %d aborted, got %d", test.aborted, got)
+		}
+	}
+	if err := DrainAndClose(nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -20,6 +20,8 @@ func ContentType(subtype string) string {
 }
 
 // ContentSubtype 函数用于从给定的内容类型中提取内容子类型。
+// 参数（如 charset）会被忽略，返回的子类型为去除空白的小写格式，
+// 厂商类型（如 application/vnd.myapp.v2+json）返回完整的子类型 vnd.myapp.v2+json。
 // 参数：
 //   - contentType：有效的内容类型字符串，必须以 application/ 开头。
 //
@@ -43,5 +45,26 @@ func ContentSubtype(contentType string) string {
 		return ""
 	}
 	// 返回 contentType 中从 left+1 到 right 的子串，即内容子类型
-	return contentType[left+1 : right]
+	return strings.ToLower(strings.TrimSpace(contentType[left+1 : right]))
+}
+
+// MediaTypeParam 函数用于返回内容类型中指定参数的值，参数名不区分大小写。
+// 参数：
+//   - contentType：内容类型字符串，例如 application/json; charset=utf-8。
+//   - name：参数名称。
+//
+// 返回值：
+//   - string：参数的值，去除了引号；参数不存在时返回空字符串。
+func MediaTypeParam(contentType, name string) string {
+	_, params, ok := strings.Cut(contentType, ";")
+	if !ok {
+		return ""
+	}
+	for _, p := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.EqualFold(strings.TrimSpace(k), name) {
+			return strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return ""
 }
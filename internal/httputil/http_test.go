@@ -17,6 +17,8 @@ func TestContentSubtype(t *testing.T) {
 		{"text/xml", "xml"},
 		{";text/xml", ""},
 		{"application", ""},
+		{"application/JSON ; charset=utf-8", "json"},
+		{"application/vnd.myapp.v2+json; charset=utf-8", "vnd.myapp.v2+json"},
 	}
 	for _, test := range tests {
 		t.Run(test.contentType, func(t *testing.T) {
@@ -45,3 +47,23 @@ func TestContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestMediaTypeParam(t *testing.T) {
+	tests := []struct {
+		contentType string
+		name        string
+		want        string
+	}{
+		{"application/json; charset=utf-8", "charset", "utf-8"},
+		{"application/json; Version=\"2\"; q=0.9", "version", "2"},
+		{"application/json", "charset", ""},
+		{"application/json; q=0.9", "charset", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := MediaTypeParam(tt.contentType, tt.name); got != tt.want {
+				t.Errorf("MediaTypeParam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
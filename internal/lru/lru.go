@@ -0,0 +1,108 @@
+// Package lru provides an in-memory cache of expiring keys that evicts the least recently used keys,
+// shared by the in-memory stores of the middlewares.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a size-bounded cache of expiring keys, it is safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	evicts  *list.List
+	nowFunc func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// New returns a cache that holds at most size keys, 0 is unlimited,
+// the least recently used keys are evicted when it is full.
+// nowFunc returns the current time the keys expire against, e.g. time.Now.
+func New(size int, nowFunc func() time.Time) *Cache {
+	return &Cache{
+		size:    size,
+		items:   make(map[string]*list.Element),
+		evicts:  list.New(),
+		nowFunc: nowFunc,
+	}
+}
+
+// Get returns the value of the key, false if the key does not exist or has expired.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.get(key)
+	if !ok {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set sets the value of the key.
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+// SetNX sets the value of the key only if it does not exist or has expired, and reports whether it is set.
+func (c *Cache) SetNX(key string, value []byte, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.get(key); ok {
+		return false
+	}
+	c.set(key, value, ttl)
+	return true
+}
+
+// Delete deletes the key.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.evicts.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// get returns the unexpired entry of the key, the caller must hold the lock.
+func (c *Cache) get(key string) (*entry, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.nowFunc().Before(e.expires) {
+		c.evicts.Remove(el)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.evicts.MoveToFront(el)
+	return e, true
+}
+
+// set sets the entry of the key, the caller must hold the lock.
+func (c *Cache) set(key string, value []byte, ttl time.Duration) {
+	expires := c.nowFunc().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.evicts.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.evicts.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.size > 0 && c.evicts.Len() > c.size {
+		el := c.evicts.Back()
+		c.evicts.Remove(el)
+		delete(c.items, el.Value.(*entry).key)
+	}
+}
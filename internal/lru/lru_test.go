@@ -0,0 +1,45 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := New(2, func() time.Time { return now })
+
+	if !c.SetNX("a", []byte("1"), time.Minute) {
+		t.Fatal("expected a to be set")
+	}
+	if c.SetNX("a", []byte("2"), time.Minute) {
+		t.Fatal("expected a to exist")
+	}
+	if v, ok := c.Get("a"); !ok || string(v) != "1" {
+		t.Fatalf("unexpected value %q", v)
+	}
+
+	// the least recently used key is evicted when the cache is full
+	c.Set("b", []byte("2"), time.Minute)
+	_, _ = c.Get("a")
+	c.Set("c", []byte("3"), time.Minute)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to exist")
+	}
+
+	// the expired keys do not exist
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to expire")
+	}
+	if !c.SetNX("c", nil, time.Minute) {
+		t.Error("expected expired c to be set")
+	}
+	c.Delete("c")
+	if _, ok := c.Get("c"); ok {
+		t.Error("expected c to be deleted")
+	}
+}
@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// Rules 是按选择器匹配操作的规则，选择器的语法与 Matcher 相同：
+// 完整的操作，如 /helloworld.Greeter/SayHello，或者以 * 结尾的前缀，如 /helloworld.Greeter/*，
+// 完整的操作优先于前缀，较长的前缀优先于较短的前缀，"*" 匹配所有操作。
+type Rules[T any] struct {
+	prefix []string
+	rules  map[string]T
+}
+
+// NewRules 使用选择器到规则的映射创建 Rules。
+func NewRules[T any](rules map[string]T) *Rules[T] {
+	r := &Rules[T]{rules: make(map[string]T, len(rules))}
+	for selector, rule := range rules {
+		if strings.HasSuffix(selector, "*") {
+			selector = strings.TrimSuffix(selector, "*")
+			r.prefix = append(r.prefix, selector)
+		}
+		r.rules[selector] = rule
+	}
+	sort.Slice(r.prefix, func(i, j int) bool {
+		return r.prefix[i] > r.prefix[j]
+	})
+	return r
+}
+
+// Match 返回操作匹配的规则以及匹配的选择器，没有匹配的规则时返回 false。
+func (r *Rules[T]) Match(operation string) (T, string, bool) {
+	if rule, ok := r.rules[operation]; ok {
+		return rule, operation, true
+	}
+	for _, prefix := range r.prefix {
+		if strings.HasPrefix(operation, prefix) {
+			return r.rules[prefix], prefix + "*", true
+		}
+	}
+	var zero T
+	return zero, "", false
+}
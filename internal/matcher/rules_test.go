@@ -0,0 +1,32 @@
+package matcher
+
+import "testing"
+
+func TestRules(t *testing.T) {
+	r := NewRules(map[string]int{
+		"*":                          1,
+		"/helloworld.Greeter/*":      2,
+		"/helloworld.Greeter/Say*":   3,
+		"/helloworld.Greeter/Search": 4,
+	})
+	tests := []struct {
+		operation string
+		want      int
+		selector  string
+	}{
+		{"/helloworld.Greeter/Search", 4, "/helloworld.Greeter/Search"},
+		{"/helloworld.Greeter/SayHello", 3, "/helloworld.Greeter/Say*"},
+		{"/helloworld.Greeter/Get", 2, "/helloworld.Greeter/*"},
+		{"/foo", 1, "*"},
+	}
+	for _, tt := range tests {
+		got, selector, ok := r.Match(tt.operation)
+		if !ok || got != tt.want || selector != tt.selector {
+			t.Errorf("%s: expect %d %s, got %d %s %v", tt.operation, tt.want, tt.selector, got, selector, ok)
+		}
+	}
+	// 没有默认规则时不匹配
+	if _, _, ok := NewRules(map[string]int{"/foo": 1}).Match("/bar"); ok {
+		t.Error("expect no match")
+	}
+}
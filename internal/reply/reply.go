@@ -0,0 +1,35 @@
+package reply
+
+import (
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// New 返回与 reply 类型相同的新响应对象，reply 不是非空指针时原样返回。
+func New(reply interface{}) interface{} {
+	v := reflect.ValueOf(reply)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reply
+	}
+	return reflect.New(v.Type().Elem()).Interface()
+}
+
+// Copy 将 src 的内容复制到 dst，两者需是由 New 创建的同类型响应对象。
+func Copy(dst, src interface{}) {
+	if dst == src {
+		return
+	}
+	if dm, ok := dst.(proto.Message); ok {
+		if sm, ok := src.(proto.Message); ok {
+			proto.Reset(dm)
+			proto.Merge(dm, sm)
+			return
+		}
+	}
+	dv, sv := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || !sv.IsValid() || sv.Type() != dv.Type() || sv.IsNil() {
+		return
+	}
+	dv.Elem().Set(sv.Elem())
+}
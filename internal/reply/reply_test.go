@@ -0,0 +1,33 @@
+package reply
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCopy(t *testing.T) {
+	dst := wrapperspb.String("old")
+	src := New(dst).(*wrapperspb.StringValue)
+	src.Value = "new"
+	Copy(dst, src)
+	if dst.Value != "new" {
+		t.Errorf("expected new, got %s", dst.Value)
+	}
+
+	type reply struct{ Message string }
+	r := &reply{}
+	v := New(r).(*reply)
+	v.Message = "hello"
+	Copy(r, v)
+	if r.Message != "hello" {
+		t.Errorf("expected hello, got %s", r.Message)
+	}
+
+	// 非指针类型原样返回，且不会复制
+	if v := New(nil); v != nil {
+		t.Errorf("expected nil, got %v", v)
+	}
+	Copy(nil, nil)
+	Copy(r, nil)
+}
@@ -0,0 +1,98 @@
+package kratos
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// LifecycleEventType is the type of an application lifecycle event.
+type LifecycleEventType int
+
+const (
+	// EventServersStarting is emitted after the BeforeStart hooks, before the servers start.
+	EventServersStarting LifecycleEventType = iota + 1
+	// EventRegistered is emitted after the instance is registered to the registrar.
+	EventRegistered
+	// EventStarted is emitted after the AfterStart hooks, when the application is running.
+	EventStarted
+	// EventSignalReceived is emitted when a stop signal is received.
+	EventSignalReceived
+	// EventStopping is emitted when the application starts to stop.
+	EventStopping
+	// EventStopped is emitted when Run returns, with the error returned by Run.
+	EventStopped
+)
+
+// String returns the name of the event type.
+func (t LifecycleEventType) String() string {
+	switch t {
+	case EventServersStarting:
+		return "ServersStarting"
+	case EventRegistered:
+		return "Registered"
+	case EventStarted:
+		return "Started"
+	case EventSignalReceived:
+		return "SignalReceived"
+	case EventStopping:
+		return "Stopping"
+	case EventStopped:
+		return "Stopped"
+	default:
+		return "Unknown"
+	}
+}
+
+// LifecycleEvent is an application lifecycle event.
+type LifecycleEvent struct {
+	Type LifecycleEventType
+	Time time.Time
+	// Signal is the received signal of EventSignalReceived.
+	Signal os.Signal
+	// Err is the error returned by Run of EventStopped.
+	Err error
+}
+
+// observers holds the lifecycle event subscribers.
+type observers struct {
+	mu   sync.Mutex
+	next int
+	subs []subscriber
+}
+
+type subscriber struct {
+	id int
+	fn func(LifecycleEvent)
+}
+
+// Subscribe registers fn to receive the lifecycle events, and returns a function
+// to unsubscribe. Events are delivered synchronously in order, so fn should return quickly.
+func (a *App) Subscribe(fn func(event LifecycleEvent)) (unsubscribe func()) {
+	a.observers.mu.Lock()
+	defer a.observers.mu.Unlock()
+	id := a.observers.next
+	a.observers.next++
+	a.observers.subs = append(a.observers.subs, subscriber{id: id, fn: fn})
+	return func() {
+		a.observers.mu.Lock()
+		defer a.observers.mu.Unlock()
+		for i, s := range a.observers.subs {
+			if s.id == id {
+				a.observers.subs = append(a.observers.subs[:i:i], a.observers.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit delivers the event to the subscribers in the order of subscription.
+func (a *App) emit(event LifecycleEvent) {
+	event.Time = time.Now()
+	a.observers.mu.Lock()
+	subs := a.observers.subs
+	a.observers.mu.Unlock()
+	for _, s := range subs {
+		s.fn(event)
+	}
+}
@@ -0,0 +1,135 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ Logger = (*AsyncLogger)(nil)
+
+// asyncSampleRate 是 PolicySample 策略下缓冲区已满时保留日志的比例，即每 asyncSampleRate 条保留一条。
+const asyncSampleRate = 10
+
+// Policy 是异步日志记录器缓冲区已满时的处理策略。
+type Policy int
+
+const (
+	// PolicyDrop 缓冲区已满时丢弃新的日志。
+	PolicyDrop Policy = iota
+	// PolicyBlock 缓冲区已满时阻塞调用方，直到缓冲区有空闲位置。
+	PolicyBlock
+	// PolicySample 缓冲区已满时按比例保留日志，保留的日志会阻塞调用方，其余日志被丢弃。
+	PolicySample
+)
+
+// asyncRecord 是一条等待写入的日志记录。
+type asyncRecord struct {
+	level   Level
+	keyvals []interface{}
+}
+
+// AsyncLogger 是一个异步日志记录器，它将日志放入缓冲队列，由后台 goroutine 写入底层日志记录器，
+// 适用于同步写日志成为延迟瓶颈的高吞吐服务。
+type AsyncLogger struct {
+	logger   Logger
+	policy   Policy
+	interval time.Duration
+	records  chan asyncRecord
+	mu       sync.RWMutex
+	closed   bool
+	done     chan struct{}
+	overflow atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+// NewAsyncLogger 创建一个异步日志记录器。
+// bufferSize 是缓冲队列的长度，flushInterval 是调用底层日志记录器 Sync 方法的间隔，为 0 时不定期刷新，
+// overflow 是缓冲区已满时的处理策略。使用完毕后需要调用 Close 写入缓冲区中剩余的日志。
+func NewAsyncLogger(logger Logger, bufferSize int, flushInterval time.Duration, overflow Policy) *AsyncLogger {
+	l := &AsyncLogger{
+		logger:   logger,
+		policy:   overflow,
+		interval: flushInterval,
+		records:  make(chan asyncRecord, max(bufferSize, 0)),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Log 将日志放入缓冲队列，关闭后的日志记录器会直接同步写入底层日志记录器。
+func (l *AsyncLogger) Log(level Level, keyvals ...interface{}) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return l.logger.Log(level, keyvals...)
+	}
+	r := asyncRecord{level: level, keyvals: keyvals}
+	select {
+	case l.records <- r:
+		return nil
+	default:
+	}
+	switch l.policy {
+	case PolicyBlock:
+		l.records <- r
+		return nil
+	case PolicySample:
+		if l.overflow.Add(1)%asyncSampleRate == 1 {
+			l.records <- r
+			return nil
+		}
+	}
+	l.dropped.Add(1)
+	return nil
+}
+
+// Dropped 返回因缓冲区已满而被丢弃的日志数量。
+func (l *AsyncLogger) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// Close 停止接收新的日志，并等待缓冲区中剩余的日志写入完成。
+func (l *AsyncLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.records)
+	l.mu.Unlock()
+	<-l.done
+	return l.sync()
+}
+
+// run 在后台 goroutine 中将缓冲区中的日志写入底层日志记录器。
+func (l *AsyncLogger) run() {
+	defer close(l.done)
+	var tick <-chan time.Time
+	if l.interval > 0 {
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case r, ok := <-l.records:
+			if !ok {
+				return
+			}
+			_ = l.logger.Log(r.level, r.keyvals...)
+		case <-tick:
+			_ = l.sync()
+		}
+	}
+}
+
+// sync 刷新底层日志记录器的缓冲区。
+func (l *AsyncLogger) sync() error {
+	if s, ok := l.logger.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingLogger 在 gate 关闭前阻塞写入，用于模拟缓慢的底层日志记录器。
+type blockingLogger struct {
+	gate   chan struct{}
+	mu     sync.Mutex
+	count  int
+	synced int
+}
+
+func (l *blockingLogger) Log(Level, ...interface{}) error {
+	<-l.gate
+	l.mu.Lock()
+	l.count++
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *blockingLogger) Sync() error {
+	l.mu.Lock()
+	l.synced++
+	l.mu.Unlock()
+	return nil
+}
+
+func TestAsyncLogger(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewAsyncLogger(NewStdLogger(&b), 16, 0, PolicyBlock)
+	for i := 0; i < 100; i++ {
+		_ = logger.Log(LevelInfo, "msg", "a")
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "msg=a"); n != 100 {
+		t.Errorf("expected 100 logs, got %d", n)
+	}
+	if logger.Dropped() != 0 {
+		t.Errorf("expected no dropped logs, got %d", logger.Dropped())
+	}
+	// 关闭后同步写入
+	_ = logger.Log(LevelInfo, "msg", "b")
+	if !strings.Contains(b.String(), "msg=b") {
+		t.Errorf("expected log after close, got %q", b.String())
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAsyncLoggerDrop(t *testing.T) {
+	inner := &blockingLogger{gate: make(chan struct{})}
+	logger := NewAsyncLogger(inner, 1, 0, PolicyDrop)
+	// 第一条被后台 goroutine 取出并阻塞，第二条进入缓冲区，其余被丢弃
+	_ = logger.Log(LevelInfo, "msg", "a")
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		_ = logger.Log(LevelInfo, "msg", "a")
+	}
+	close(inner.gate)
+	_ = logger.Close()
+	if inner.count != 2 {
+		t.Errorf("expected 2 logs, got %d", inner.count)
+	}
+	if logger.Dropped() != 9 {
+		t.Errorf("expected 9 dropped logs, got %d", logger.Dropped())
+	}
+}
+
+func TestAsyncLoggerSample(t *testing.T) {
+	inner := &blockingLogger{gate: make(chan struct{})}
+	logger := NewAsyncLogger(inner, 1, 0, PolicySample)
+	_ = logger.Log(LevelInfo, "msg", "a")
+	time.Sleep(20 * time.Millisecond)
+	_ = logger.Log(LevelInfo, "msg", "a")
+	// 缓冲区已满，第 1、11 条溢出的日志会阻塞等待写入，其余被丢弃
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(inner.gate)
+	}()
+	for i := 0; i < 20; i++ {
+		_ = logger.Log(LevelInfo, "msg", "a")
+	}
+	_ = logger.Close()
+	if got := uint64(inner.count) + logger.Dropped(); got != 22 {
+		t.Errorf("expected 22 logs in total, got %d", got)
+	}
+	if inner.count < 4 {
+		t.Errorf("expected at least 4 logs, got %d", inner.count)
+	}
+}
+
+func TestAsyncLoggerFlush(t *testing.T) {
+	inner := &blockingLogger{gate: make(chan struct{})}
+	close(inner.gate)
+	logger := NewAsyncLogger(inner, 8, 5*time.Millisecond, PolicyDrop)
+	_ = logger.Log(LevelInfo, "msg", "a")
+	time.Sleep(30 * time.Millisecond)
+	_ = logger.Close()
+	inner.mu.Lock()
+	defer inner.mu.Unlock()
+	if inner.synced < 2 {
+		t.Errorf("expected periodic sync, got %d", inner.synced)
+	}
+}
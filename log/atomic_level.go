@@ -0,0 +1,86 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+var _ http.Handler = (*AtomicLevel)(nil)
+
+// AtomicLevel 是一个可以在运行时并发修改的日志级别，配合 FilterLevelVar 使用。
+// AtomicLevel 实现了 http.Handler，可以注册到管理端口上（如 /debug/log/level），
+// 通过 GET 查询当前级别，通过 PUT 修改级别，无需重启即可开启调试日志。
+type AtomicLevel struct {
+	level atomic.Int32
+}
+
+// NewAtomicLevel 使用初始级别创建一个 AtomicLevel。
+func NewAtomicLevel(level Level) *AtomicLevel {
+	l := &AtomicLevel{}
+	l.SetLevel(level)
+	return l
+}
+
+// Level 返回当前的日志级别。
+func (l *AtomicLevel) Level() Level {
+	return Level(l.level.Load())
+}
+
+// SetLevel 修改日志级别。
+func (l *AtomicLevel) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// String 返回当前日志级别的字符串表示。
+func (l *AtomicLevel) String() string {
+	return l.Level().String()
+}
+
+// levelPayload 是日志级别接口的请求与响应内容。
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// ServeHTTP 处理日志级别的查询与修改。
+//
+//	GET 返回当前级别，如 {"level":"INFO"}
+//	PUT 修改级别，请求体为 {"level":"debug"}，也可以使用查询参数 ?level=debug
+func (l *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var p levelPayload
+		if p.Level = r.URL.Query().Get("level"); p.Level == "" {
+			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+				writeLevelError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+		}
+		level, ok := parseLevel(p.Level)
+		if !ok {
+			writeLevelError(w, http.StatusBadRequest, "unrecognized level: "+p.Level)
+			return
+		}
+		l.SetLevel(level)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, "only GET and PUT are supported")
+		return
+	}
+	_ = json.NewEncoder(w).Encode(levelPayload{Level: l.String()})
+}
+
+// parseLevel 严格解析级别字符串，无法识别时返回 false。
+func parseLevel(s string) (Level, bool) {
+	level := ParseLevel(s)
+	return level, level.String() == strings.ToUpper(s)
+}
+
+// writeLevelError 以 JSON 格式写入错误信息。
+func writeLevelError(w http.ResponseWriter, code int, msg string) {
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAtomicLevelFilter(t *testing.T) {
+	var b bytes.Buffer
+	level := NewAtomicLevel(LevelInfo)
+	logger := NewFilter(NewStdLogger(&b), FilterLevel(LevelError), FilterLevelVar(level))
+	_ = logger.Log(LevelDebug, "msg", "a")
+	_ = logger.Log(LevelInfo, "msg", "b")
+	level.SetLevel(LevelDebug)
+	_ = logger.Log(LevelDebug, "msg", "c")
+	if s := b.String(); s != "INFO msg=b\nDEBUG msg=c\n" {
+		t.Errorf("log not match: %q", s)
+	}
+}
+
+func TestAtomicLevelHandler(t *testing.T) {
+	level := NewAtomicLevel(LevelInfo)
+	tests := []struct {
+		method string
+		target string
+		body   string
+		code   int
+		want   string
+		level  Level
+	}{
+		{http.MethodGet, "/debug/log/level", "", http.StatusOK, `{"level":"INFO"}`, LevelInfo},
+		{http.MethodPut, "/debug/log/level", `{"level":"debug"}`, http.StatusOK, `{"level":"DEBUG"}`, LevelDebug},
+		{http.MethodPut, "/debug/log/level?level=warn", "", http.StatusOK, `{"level":"WARN"}`, LevelWarn},
+		{http.MethodPut, "/debug/log/level", `{"level":"verbose"}`, http.StatusBadRequest, `{"error":"unrecognized level: verbose"}`, LevelWarn},
+		{http.MethodPut, "/debug/log/level", `{`, http.StatusBadRequest, "", LevelWarn},
+		{http.MethodPost, "/debug/log/level", "", http.StatusMethodNotAllowed, "", LevelWarn},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		level.ServeHTTP(w, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if w.Code != test.code {
+			t.Errorf("%s %s: expected code %d, got %d", test.method, test.target, test.code, w.Code)
+		}
+		if got := strings.TrimSpace(w.Body.String()); test.want != "" && got != test.want {
+			t.Errorf("%s %s: expected body %s, got %s", test.method, test.target, test.want, got)
+		}
+		if level.Level() != test.level {
+			t.Errorf("%s %s: expected level %s, got %s", test.method, test.target, test.level, level)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package log
+
+import "context"
+
+// contextKey 是上下文中日志字段的键。
+type contextKey struct{}
+
+// NewContext 返回携带日志字段的新上下文，字段追加在 ctx 中已有的字段之后。
+// 通过 WithContext 绑定该上下文的日志记录器会自动在每条日志中添加这些字段，
+// 例如在中间件中设置一次用户 ID，下游的所有日志都会带有该字段，无需传递 Helper。
+func NewContext(ctx context.Context, kvs ...interface{}) context.Context {
+	if len(kvs) == 0 {
+		return ctx
+	}
+	prev := FromContext(ctx)
+	fields := make([]interface{}, 0, len(prev)+len(kvs))
+	fields = append(fields, prev...)
+	fields = append(fields, kvs...)
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+// FromContext 返回上下文中累积的日志字段。
+func FromContext(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextKey{}).([]interface{})
+	return fields
+}
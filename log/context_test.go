@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestNewContext 测试上下文中累积的日志字段
+func TestNewContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := With(NewStdLogger(buf), "service", "user")
+
+	ctx := NewContext(context.Background(), "uid", 1)
+	ctx = NewContext(ctx, "shard", Valuer(func(context.Context) interface{} { return "s1" }))
+	if got := len(FromContext(ctx)); got != 4 {
+		t.Fatalf("expected 4 fields, got %d", got)
+	}
+
+	NewHelper(WithContext(ctx, logger)).Info("hello")
+	want := "INFO service=user uid=1 shard=s1 msg=hello\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	// 父上下文不受子上下文添加的字段影响
+	buf.Reset()
+	_ = WithContext(context.Background(), NewStdLogger(buf)).Log(LevelInfo, "msg", "plain")
+	if strings.Contains(buf.String(), "uid") {
+		t.Errorf("unexpected context fields: %q", buf.String())
+	}
+}
+
+// TestNewContextFilter 测试过滤器对上下文中日志字段的过滤
+func TestNewContextFilter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	filter := NewFilter(NewStdLogger(buf), FilterFunc(func(_ Level, keyvals ...interface{}) bool {
+		for i := 0; i < len(keyvals); i += 2 {
+			if keyvals[i] == "internal" {
+				return true
+			}
+		}
+		return false
+	}))
+	ctx := NewContext(context.Background(), "internal", true)
+	_ = WithContext(ctx, filter).Log(LevelInfo, "msg", "hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected log filtered, got %q", buf.String())
+	}
+}
@@ -12,6 +12,28 @@ func FilterLevel(level Level) FilterOption {
 	}
 }
 
+// FilterLevelVar 用于设置可在运行时修改的过滤级别，设置后优先于 FilterLevel。
+func FilterLevelVar(level *AtomicLevel) FilterOption {
+	return func(opts *Filter) {
+		opts.levelVar = level
+	}
+}
+
+// FilterModuleLevel 用于按模块设置过滤级别，模块取自日志的 module 字段（如 With(logger, "module", "kafka")），
+// 设置了级别的模块使用自己的级别，其他日志仍然使用 FilterLevel 或 FilterLevelVar 的级别。
+func FilterModuleLevel(levels map[string]Level) FilterOption {
+	return func(opts *Filter) {
+		opts.modules = NewModuleLevels(levels)
+	}
+}
+
+// FilterModuleLevelVar 用于设置可在运行时修改的模块级别，设置后优先于 FilterModuleLevel。
+func FilterModuleLevelVar(levels *ModuleLevels) FilterOption {
+	return func(opts *Filter) {
+		opts.modules = levels
+	}
+}
+
 // FilterKey 用于设置过滤键。
 func FilterKey(key ...string) FilterOption {
 	return func(o *Filter) {
@@ -39,11 +61,13 @@ func FilterFunc(f func(level Level, keyvals ...interface{}) bool) FilterOption {
 
 // Filter 是一个日志过滤器。
 type Filter struct {
-	logger Logger
-	level  Level
-	key    map[interface{}]struct{}
-	value  map[interface{}]struct{}
-	filter func(level Level, keyvals ...interface{}) bool
+	logger   Logger
+	level    Level
+	levelVar *AtomicLevel
+	modules  *ModuleLevels
+	key      map[interface{}]struct{}
+	value    map[interface{}]struct{}
+	filter   func(level Level, keyvals ...interface{}) bool
 }
 
 // NewFilter 新建一个日志过滤器。
@@ -59,20 +83,44 @@ func NewFilter(logger Logger, opts ...FilterOption) *Filter {
 	return &options
 }
 
+// enabled 判断指定级别的日志是否需要记录。
+func (f *Filter) enabled(level Level) bool {
+	if f.levelVar != nil {
+		return level >= f.levelVar.Level()
+	}
+	return level >= f.level
+}
+
 // Log 根据级别和键值对打印日志。
 func (f *Filter) Log(level Level, keyvals ...interface{}) error {
-	// 如果日志级别低于过滤器设置的级别，则不记录日志
-	if level < f.level {
+	// 如果日志级别低于过滤器设置的级别，则不记录日志，设置了模块级别时需要先取得日志所属的模块
+	if f.modules == nil && !f.enabled(level) {
 		return nil
 	}
 
 	// prefixkv 包含在日志初始化期间定义为前缀的参数切片
 	var prefixkv []interface{}
 	// 如果日志记录器实现了 logger 接口，并且有前缀，则将前缀添加到 prefixkv 中
+	// 上下文中累积的日志字段同样视为前缀
 	l, ok := f.logger.(*logger)
-	if ok && len(l.prefix) > 0 {
-		prefixkv = make([]interface{}, 0, len(l.prefix))
-		prefixkv = append(prefixkv, l.prefix...)
+	if ok {
+		fields := FromContext(l.ctx)
+		if len(l.prefix)+len(fields) > 0 {
+			prefixkv = make([]interface{}, 0, len(l.prefix)+len(fields))
+			prefixkv = append(prefixkv, l.prefix...)
+			prefixkv = append(prefixkv, fields...)
+		}
+	}
+
+	// 模块设置了级别时使用模块的级别
+	if f.modules != nil {
+		if moduleLevel, ok := f.modules.Level(moduleOf(prefixkv, keyvals)); ok {
+			if level < moduleLevel {
+				return nil
+			}
+		} else if !f.enabled(level) {
+			return nil
+		}
 	}
 
 	// 如果过滤器函数存在，并且它对前缀或键值对返回 true，则不记录日志
@@ -99,3 +147,18 @@ func (f *Filter) Log(level Level, keyvals ...interface{}) error {
 	// 记录过滤后的日志
 	return f.logger.Log(level, keyvals...)
 }
+
+// moduleOf 返回键值对中 module 字段的值，后出现的值优先。
+func moduleOf(prefixkv, keyvals []interface{}) string {
+	var module string
+	for _, kvs := range [][]interface{}{prefixkv, keyvals} {
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if k, ok := kvs[i].(string); ok && k == ModuleKey {
+				if v, ok := kvs[i+1].(string); ok {
+					module = v
+				}
+			}
+		}
+	}
+	return module
+}
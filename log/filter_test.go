@@ -243,3 +243,32 @@ func TestFilterWithContextConcurrent(t *testing.T) {
 		t.Errorf("got: %#v", got)
 	}
 }
+
+// 测试按模块设置日志级别
+func TestFilterModuleLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	levels := NewModuleLevels(map[string]Level{"kafka": LevelWarn, "db": LevelDebug})
+	filter := NewFilter(NewStdLogger(buf), FilterLevel(LevelInfo), FilterModuleLevelVar(levels))
+
+	NewHelper(With(filter, ModuleKey, "kafka")).Info("kafka info")
+	NewHelper(With(filter, ModuleKey, "kafka")).Warn("kafka warn")
+	NewHelper(With(filter, ModuleKey, "db")).Debug("db debug")
+	NewHelper(With(filter, ModuleKey, "http")).Debug("http debug")
+	NewHelper(filter).Info("app info")
+	// 模块字段也可以位于过滤器内部日志记录器的前缀中
+	NewHelper(NewFilter(With(NewStdLogger(buf), ModuleKey, "kafka"), FilterModuleLevelVar(levels))).Info("prefix info")
+
+	want := "WARN module=kafka msg=kafka warn\nDEBUG module=db msg=db debug\nINFO msg=app info\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// 运行时修改模块级别
+	buf.Reset()
+	levels.SetLevels(map[string]Level{"kafka": LevelDebug})
+	NewHelper(With(filter, ModuleKey, "kafka")).Debug("kafka debug")
+	NewHelper(With(filter, ModuleKey, "db")).Debug("db debug")
+	if got, want := buf.String(), "DEBUG module=kafka msg=kafka debug\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ Logger = (*jsonLogger)(nil)
+
+// JSONOption 是 JSON 日志记录器的选项。
+type JSONOption func(*jsonLogger)
+
+// JSONTimeKey 设置时间戳的键名，默认为 "ts"。
+func JSONTimeKey(key string) JSONOption {
+	return func(l *jsonLogger) {
+		l.timeKey = key
+	}
+}
+
+// JSONTimeFormat 设置时间戳的格式，默认为 time.RFC3339，为空时不输出时间戳。
+func JSONTimeFormat(layout string) JSONOption {
+	return func(l *jsonLogger) {
+		l.timeFormat = layout
+	}
+}
+
+// JSONLevelKey 设置日志级别的键名，默认为 LevelKey。
+func JSONLevelKey(key string) JSONOption {
+	return func(l *jsonLogger) {
+		l.levelKey = key
+	}
+}
+
+// JSONFlatten 设置是否展开嵌套字段，开启后 map 类型的值会以 "key.subkey" 的形式输出。
+func JSONFlatten(flatten bool) JSONOption {
+	return func(l *jsonLogger) {
+		l.flatten = flatten
+	}
+}
+
+// jsonLogger 将每条日志输出为一行 JSON 对象，可以被多个 goroutine 同时使用。
+type jsonLogger struct {
+	w          io.Writer
+	isDiscard  bool
+	timeKey    string
+	timeFormat string
+	levelKey   string
+	flatten    bool
+	mu         sync.Mutex
+	pool       *sync.Pool
+}
+
+// NewJSONLogger 使用指定的写入器创建一个 JSON 日志记录器。
+func NewJSONLogger(w io.Writer, opts ...JSONOption) Logger {
+	l := &jsonLogger{
+		w:          w,
+		isDiscard:  w == io.Discard,
+		timeKey:    "ts",
+		timeFormat: time.RFC3339,
+		levelKey:   LevelKey,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		},
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Log 将键值对编码为一行 JSON 并写入。
+func (l *jsonLogger) Log(level Level, keyvals ...interface{}) error {
+	if l.isDiscard || len(keyvals) == 0 {
+		return nil
+	}
+	if (len(keyvals) & 1) == 1 {
+		keyvals = append(keyvals, "KEYVALS UNPAIRED")
+	}
+
+	buf := l.pool.Get().(*bytes.Buffer)
+	defer l.pool.Put(buf)
+	defer buf.Reset()
+
+	buf.WriteByte('{')
+	first := true
+	if l.levelKey != "" {
+		l.writeField(buf, &first, l.levelKey, level.String())
+	}
+	if l.timeKey != "" && l.timeFormat != "" {
+		l.writeField(buf, &first, l.timeKey, time.Now().Format(l.timeFormat))
+	}
+	for i := 0; i < len(keyvals); i += 2 {
+		l.writeValue(buf, &first, fmt.Sprint(keyvals[i]), keyvals[i+1])
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(buf.Bytes())
+	return err
+}
+
+// writeValue 写入一个字段，开启展开时递归写入 map 类型的值。
+func (l *jsonLogger) writeValue(buf *bytes.Buffer, first *bool, key string, value interface{}) {
+	if l.flatten {
+		switch m := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				l.writeValue(buf, first, key+"."+k, m[k])
+			}
+			return
+		case map[string]string:
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				l.writeField(buf, first, key+"."+k, m[k])
+			}
+			return
+		}
+	}
+	l.writeField(buf, first, key, value)
+}
+
+// writeField 写入一个 JSON 字段，无法编码的值以字符串形式输出。
+func (l *jsonLogger) writeField(buf *bytes.Buffer, first *bool, key string, value interface{}) {
+	if !*first {
+		buf.WriteByte(',')
+	}
+	*first = false
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case fmt.Stringer:
+		if _, ok := v.(json.Marshaler); !ok {
+			value = v.String()
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+// Close 关闭日志记录器。
+func (l *jsonLogger) Close() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewJSONLogger(&b, JSONTimeFormat(""))
+	_ = logger.Log(LevelInfo, "msg", "hello", "err", errors.New("boom"), "n", 1, "m", map[string]string{"a": "b"})
+	_ = logger.Log(LevelWarn, "single")
+	want := `{"level":"INFO","msg":"hello","err":"boom","n":1,"m":{"a":"b"}}` + "\n" +
+		`{"level":"WARN","single":"KEYVALS UNPAIRED"}` + "\n"
+	if s := b.String(); s != want {
+		t.Fatalf("log not match: %q", s)
+	}
+}
+
+func TestJSONLoggerOptions(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewJSONLogger(&b, JSONTimeKey("time"), JSONTimeFormat("2006"), JSONLevelKey("severity"), JSONFlatten(true))
+	_ = logger.Log(LevelError, "req", map[string]interface{}{"id": 1, "user": map[string]interface{}{"name": "kratos"}})
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["severity"] != "ERROR" {
+		t.Errorf("expected severity ERROR, got %v", m["severity"])
+	}
+	if s, ok := m["time"].(string); !ok || len(s) != 4 {
+		t.Errorf("unexpected time: %v", m["time"])
+	}
+	if m["req.id"] != float64(1) || m["req.user.name"] != "kratos" {
+		t.Errorf("unexpected flatten fields: %v", m)
+	}
+}
+
+func TestJSONLogger_Concurrent(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewJSONLogger(&b, JSONTimeFormat(""))
+	var eg errgroup.Group
+	eg.Go(func() error { return logger.Log(LevelInfo, "msg", "a") })
+	eg.Go(func() error { return logger.Log(LevelInfo, "msg", "a") })
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("log error: %v", err)
+	}
+	if s := b.String(); s != "{\"level\":\"INFO\",\"msg\":\"a\"}\n{\"level\":\"INFO\",\"msg\":\"a\"}\n" {
+		t.Fatalf("log not match: %q", s)
+	}
+}
@@ -1,6 +1,9 @@
 package log
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // Level 是日志记录器的级别。
 type Level int8
@@ -60,3 +63,19 @@ func ParseLevel(s string) Level {
 	}
 	return LevelInfo
 }
+
+// MarshalText 实现 encoding.TextMarshaler，返回级别的字符串表示。
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，不区分大小写地解析级别字符串，
+// 使级别可以直接从配置中解析，无法识别的级别返回错误。
+func (l *Level) UnmarshalText(text []byte) error {
+	level, ok := parseLevel(string(text))
+	if !ok {
+		return fmt.Errorf("log: unrecognized level: %q", text)
+	}
+	*l = level
+	return nil
+}
@@ -1,6 +1,9 @@
 package log
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 // TestLevel_Key 测试日志记录器级别的 Key 方法
 func TestLevel_Key(t *testing.T) {
@@ -150,3 +153,25 @@ func TestParseLevel(t *testing.T) {
 		})
 	}
 }
+
+// 测试从 JSON 中解析日志级别
+func TestLevel_UnmarshalText(t *testing.T) {
+	levels := make(map[string]Level)
+	if err := json.Unmarshal([]byte(`{"kafka":"warn","db":"DEBUG"}`), &levels); err != nil {
+		t.Fatal(err)
+	}
+	if levels["kafka"] != LevelWarn || levels["db"] != LevelDebug {
+		t.Errorf("unexpected levels: %v", levels)
+	}
+	// 无法识别的级别返回错误
+	if err := json.Unmarshal([]byte(`{"kafka":"verbose"}`), &levels); err == nil {
+		t.Error("expect error for unrecognized level")
+	}
+	b, err := json.Marshal(map[string]Level{"kafka": LevelError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"kafka":"ERROR"}` {
+		t.Errorf("unexpected json: %s", b)
+	}
+}
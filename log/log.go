@@ -27,14 +27,22 @@ type logger struct {
 
 // Log 方法记录日志。
 func (c *logger) Log(level Level, keyvals ...interface{}) error {
+	// 上下文中通过 NewContext 累积的日志字段。
+	fields := FromContext(c.ctx)
 	// 创建一个新的切片来存储所有的键值对。
-	kvs := make([]interface{}, 0, len(c.prefix)+len(keyvals))
+	kvs := make([]interface{}, 0, len(c.prefix)+len(fields)+len(keyvals))
 	// 将前缀添加到键值对切片中。
 	kvs = append(kvs, c.prefix...)
 	// 如果有值函数（Valuer），则将其绑定到上下文中。
 	if c.hasValuer {
 		bindValues(c.ctx, kvs)
 	}
+	// 将上下文中的日志字段添加到前缀之后。
+	if len(fields) > 0 {
+		n := len(kvs)
+		kvs = append(kvs, fields...)
+		bindValues(c.ctx, kvs[n:])
+	}
 	// 将传入的键值对添加到切片中。
 	kvs = append(kvs, keyvals...)
 	// 使用底层日志记录器记录日志。
@@ -81,5 +89,10 @@ func WithContext(ctx context.Context, l Logger) Logger {
 		fv := *v
 		fv.logger = WithContext(ctx, fv.logger)
 		return &fv
+	case *Sampler:
+		// 如果是 Sampler 类型，递归绑定上下文到其内部的 logger，采样计数器保持共享。
+		sv := *v
+		sv.logger = WithContext(ctx, sv.logger)
+		return &sv
 	}
 }
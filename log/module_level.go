@@ -0,0 +1,45 @@
+package log
+
+import "sync/atomic"
+
+// ModuleKey 是日志中模块字段的键。
+const ModuleKey = "module"
+
+// ModuleLevels 是可以在运行时并发修改的模块日志级别，配合 FilterModuleLevelVar 使用。
+// Level 实现了 encoding.TextUnmarshaler，可以直接从配置中解析，并在配置变化时调用 SetLevels：
+//
+//	levels := log.NewModuleLevels(nil)
+//	_ = c.Watch("log.modules", func(_ string, v config.Value) {
+//		m := make(map[string]log.Level)
+//		if err := v.Scan(&m); err == nil {
+//			levels.SetLevels(m)
+//		}
+//	})
+type ModuleLevels struct {
+	levels atomic.Pointer[map[string]Level]
+}
+
+// NewModuleLevels 使用初始的模块级别创建一个 ModuleLevels。
+func NewModuleLevels(levels map[string]Level) *ModuleLevels {
+	m := &ModuleLevels{}
+	m.SetLevels(levels)
+	return m
+}
+
+// Level 返回模块的日志级别，模块没有设置级别时返回 false。
+func (m *ModuleLevels) Level(module string) (Level, bool) {
+	if module == "" {
+		return 0, false
+	}
+	level, ok := (*m.levels.Load())[module]
+	return level, ok
+}
+
+// SetLevels 替换所有模块的日志级别。
+func (m *ModuleLevels) SetLevels(levels map[string]Level) {
+	cp := make(map[string]Level, len(levels))
+	for k, v := range levels {
+		cp[k] = v
+	}
+	m.levels.Store(&cp)
+}
@@ -0,0 +1,327 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ io.WriteCloser = (*RotateWriter)(nil)
+
+const (
+	// rotateTimeFormat 是备份文件名中时间戳的格式。
+	rotateTimeFormat = "2006-01-02T15-04-05.000"
+	// rotateCompressSuffix 是压缩后的备份文件后缀。
+	rotateCompressSuffix = ".gz"
+	// defaultRotateMaxSize 是默认的单个日志文件最大大小，单位为 MB。
+	defaultRotateMaxSize = 100
+	megabyte             = 1024 * 1024
+)
+
+// RotateWriter 是一个按大小滚动的日志文件写入器，可以配合 NewStdLogger 或 NewJSONLogger 使用。
+// 当文件大小超过限制时，当前文件会被重命名为带时间戳的备份文件，并创建新的日志文件，
+// 过期或超出数量的备份文件会在后台被清理。
+type RotateWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+
+	mu     sync.Mutex
+	file   *os.File // 滚动失败后可能为 nil，下次写入时重新打开
+	size   int64
+	closed bool
+
+	millCh chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewRotateWriter 创建一个滚动日志文件写入器。
+// maxSize 是单个日志文件的最大大小，单位为 MB，为 0 时默认为 100MB；
+// maxBackups 是保留的备份文件数量，为 0 时不限制；maxAge 是备份文件的保留时间，为 0 时不限制；
+// compress 设置是否使用 gzip 压缩备份文件。
+func NewRotateWriter(path string, maxSize, maxBackups int, maxAge time.Duration, compress bool) (*RotateWriter, error) {
+	if maxSize <= 0 {
+		maxSize = defaultRotateMaxSize
+	}
+	w := &RotateWriter{
+		path:       path,
+		maxSize:    int64(maxSize) * megabyte,
+		maxBackups: maxBackups,
+		maxAge:     maxAge,
+		compress:   compress,
+		millCh:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.wg.Add(1)
+	go w.mill()
+	w.notify()
+	return w, nil
+}
+
+// Write 写入日志，写入后文件大小超过限制时会先进行滚动。
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即滚动日志文件。
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.rotate()
+}
+
+// Sync 将文件内容刷新到磁盘。
+func (w *RotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭当前日志文件，并等待后台清理完成。
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+	}
+	w.file = nil
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
+
+// open 以追加模式打开日志文件。
+func (w *RotateWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+// rotate 将当前文件重命名为备份文件并打开新的日志文件，调用方需要持有锁。
+// 重命名失败时继续写入原文件，打开失败时下次写入会重新打开。
+func (w *RotateWriter) rotate() error {
+	err := w.file.Close()
+	w.file, w.size = nil, 0
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(w.path, w.backupName(time.Now())); err != nil && !os.IsNotExist(err) {
+		_ = w.open()
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.notify()
+	return nil
+}
+
+// backupName 返回指定时间对应的备份文件名，如 app-2006-01-02T15-04-05.000.log。
+func (w *RotateWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.fileParts()
+	return filepath.Join(dir, prefix+t.Format(rotateTimeFormat)+ext)
+}
+
+// fileParts 返回日志文件所在目录、备份文件名前缀和扩展名。
+func (w *RotateWriter) fileParts() (dir, prefix, ext string) {
+	name := filepath.Base(w.path)
+	ext = filepath.Ext(name)
+	return filepath.Dir(w.path), strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// notify 通知后台 goroutine 清理备份文件。
+func (w *RotateWriter) notify() {
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// mill 在后台压缩和清理备份文件。
+func (w *RotateWriter) mill() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.millCh:
+			if err := w.millRun(); err != nil {
+				Errorf("failed to clean up rotated log files: %v", err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// rotateBackup 是一个备份文件。
+type rotateBackup struct {
+	name      string
+	timestamp time.Time
+}
+
+// millRun 删除超出数量或过期的备份文件，并压缩剩余的备份文件。
+func (w *RotateWriter) millRun() error {
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+	var (
+		remove   []rotateBackup
+		compress []rotateBackup
+	)
+	if w.maxBackups > 0 && len(backups) > w.maxBackups {
+		remove = append(remove, backups[w.maxBackups:]...)
+		backups = backups[:w.maxBackups]
+	}
+	if w.maxAge > 0 {
+		cutoff := time.Now().Add(-w.maxAge)
+		remaining := backups[:0]
+		for _, b := range backups {
+			if b.timestamp.Before(cutoff) {
+				remove = append(remove, b)
+				continue
+			}
+			remaining = append(remaining, b)
+		}
+		backups = remaining
+	}
+	if w.compress {
+		for _, b := range backups {
+			if !strings.HasSuffix(b.name, rotateCompressSuffix) {
+				compress = append(compress, b)
+			}
+		}
+	}
+	dir, _, _ := w.fileParts()
+	for _, b := range remove {
+		if e := os.Remove(filepath.Join(dir, b.name)); e != nil && !os.IsNotExist(e) {
+			err = e
+		}
+	}
+	for _, b := range compress {
+		if e := compressFile(filepath.Join(dir, b.name)); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// backups 返回所有备份文件，按时间从新到旧排序。
+func (w *RotateWriter) backups() ([]rotateBackup, error) {
+	dir, prefix, ext := w.fileParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []rotateBackup
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), rotateCompressSuffix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(rotateTimeFormat, strings.TrimSuffix(ts, ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, rotateBackup{name: name, timestamp: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups, nil
+}
+
+// compressFile 使用 gzip 压缩文件，成功后删除原文件。
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+rotateCompressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(name + rotateCompressSuffix)
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotateWriter(path, 1, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调小文件大小限制以便触发滚动
+	w.maxSize = 32
+	logger := NewStdLogger(w)
+	_ = logger.Log(LevelInfo, "msg", "first message")
+	time.Sleep(2 * time.Millisecond)
+	_ = logger.Log(LevelInfo, "msg", "second message")
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != "INFO msg=second message\n" {
+		t.Errorf("log not match: %q", s)
+	}
+	backups, err := w.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || !strings.HasPrefix(backups[0].name, "app-") || !strings.HasSuffix(backups[0].name, ".log") {
+		t.Errorf("unexpected backups: %v", backups)
+	}
+	if _, err = w.Write([]byte("closed")); err == nil {
+		t.Error("expected error after close")
+	}
+}
+
+func TestRotateWriterRotateFailed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the directory of an open file cannot be removed on windows")
+	}
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotateWriter(path, 1, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// 删除日志目录使滚动后无法打开新的日志文件
+	if err = os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Rotate(); err == nil {
+		t.Fatal("expected rotate error")
+	}
+	if _, err = w.Write([]byte("lost\n")); err == nil {
+		t.Error("expected write error")
+	}
+	// 目录恢复后写入会重新打开日志文件
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("recovered\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != "recovered\n" {
+		t.Errorf("log not match: %q", s)
+	}
+}
+
+func TestRotateWriterCleanup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotateWriter(path, 1, 2, 24*time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := w.backupName(time.Now().Add(-48 * time.Hour))
+	if err = os.WriteFile(old, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		if err = w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 关闭后台清理后再同步执行一次，保证结果确定
+	_ = w.Close()
+	if err = w.millRun(); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := w.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v", backups)
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b.name, ".log.gz") {
+			t.Errorf("expected compressed backup, got %s", b.name)
+		}
+	}
+	if _, err = os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected expired backup to be removed")
+	}
+}
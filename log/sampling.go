@@ -0,0 +1,122 @@
+package log
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+var _ Logger = (*Sampler)(nil)
+
+// samplingSlots 是采样计数器的槽位数量，不同的采样键通过哈希映射到槽位上。
+const samplingSlots = 4096
+
+// SamplingOption 是采样日志记录器的选项。
+type SamplingOption func(*Sampler)
+
+// SamplingKey 设置采样键的计算函数，默认使用日志级别与 DefaultMessageKey 对应的消息。
+func SamplingKey(fn func(level Level, keyvals ...interface{}) string) SamplingOption {
+	return func(s *Sampler) {
+		s.key = fn
+	}
+}
+
+// SamplingHook 设置采样结果的回调函数，可用于统计被丢弃的日志数量。
+func SamplingHook(fn func(level Level, key string, dropped bool)) SamplingOption {
+	return func(s *Sampler) {
+		s.hook = fn
+	}
+}
+
+// Sampler 是一个采样日志记录器，它按采样键统计每个时间窗口内的日志数量，
+// 超出限制的日志会被丢弃，以避免热点错误路径刷屏。
+type Sampler struct {
+	logger     Logger
+	initial    uint64
+	thereafter uint64
+	tick       time.Duration
+	key        func(level Level, keyvals ...interface{}) string
+	hook       func(level Level, key string, dropped bool)
+	counters   *[samplingSlots]samplingCounter
+}
+
+// NewSamplingLogger 创建一个采样日志记录器。
+// 在每个 tick 时间窗口内，相同采样键的前 initial 条日志会被记录，
+// 之后每 thereafter 条记录一条，thereafter 为 0 时丢弃其余日志。
+func NewSamplingLogger(logger Logger, initial, thereafter int, tick time.Duration, opts ...SamplingOption) *Sampler {
+	s := &Sampler{
+		logger:     logger,
+		initial:    uint64(max(initial, 0)),
+		thereafter: uint64(max(thereafter, 0)),
+		tick:       tick,
+		key:        defaultSamplingKey,
+		counters:   new([samplingSlots]samplingCounter),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// NewRateLimitLogger 创建一个按采样键限流的日志记录器，
+// 每个 interval 时间窗口内相同采样键最多记录 limit 条日志。
+func NewRateLimitLogger(logger Logger, limit int, interval time.Duration, opts ...SamplingOption) *Sampler {
+	return NewSamplingLogger(logger, limit, 0, interval, opts...)
+}
+
+// Log 根据采样结果决定是否记录日志。
+func (s *Sampler) Log(level Level, keyvals ...interface{}) error {
+	key := s.key(level, keyvals...)
+	dropped := !s.allow(key)
+	if s.hook != nil {
+		s.hook(level, key, dropped)
+	}
+	if dropped {
+		return nil
+	}
+	return s.logger.Log(level, keyvals...)
+}
+
+// allow 判断采样键对应的日志是否应该被记录。
+func (s *Sampler) allow(key string) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	c := &s.counters[h.Sum32()%samplingSlots]
+	n := c.incCheckReset(time.Now(), s.tick)
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+// defaultSamplingKey 使用日志级别和消息作为采样键。
+func defaultSamplingKey(level Level, keyvals ...interface{}) string {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == DefaultMessageKey {
+			return level.String() + ":" + fmt.Sprint(keyvals[i+1])
+		}
+	}
+	return level.String()
+}
+
+// samplingCounter 是一个按时间窗口重置的计数器。
+type samplingCounter struct {
+	resetAt atomic.Int64
+	counter atomic.Uint64
+}
+
+// incCheckReset 增加计数，如果当前时间窗口已经结束则重置计数。
+func (c *samplingCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.counter.Add(1)
+	}
+	c.counter.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick.Nanoseconds()) {
+		// 其他 goroutine 已经重置了计数器
+		return c.counter.Add(1)
+	}
+	return 1
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingLogger(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewSamplingLogger(NewStdLogger(&b), 2, 3, time.Minute)
+	for i := 0; i < 10; i++ {
+		_ = logger.Log(LevelError, "msg", "hot")
+	}
+	_ = logger.Log(LevelError, "msg", "cold")
+	// 1, 2 为 initial，之后记录第 5、8 条
+	if n := strings.Count(b.String(), "msg=hot"); n != 4 {
+		t.Errorf("expected 4 hot logs, got %d: %q", n, b.String())
+	}
+	if n := strings.Count(b.String(), "msg=cold"); n != 1 {
+		t.Errorf("expected 1 cold log, got %d", n)
+	}
+}
+
+func TestSamplingLoggerTick(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewRateLimitLogger(NewStdLogger(&b), 1, 10*time.Millisecond)
+	_ = logger.Log(LevelInfo, "msg", "a")
+	_ = logger.Log(LevelInfo, "msg", "a")
+	time.Sleep(20 * time.Millisecond)
+	_ = logger.Log(LevelInfo, "msg", "a")
+	if n := strings.Count(b.String(), "msg=a"); n != 2 {
+		t.Errorf("expected 2 logs, got %d: %q", n, b.String())
+	}
+}
+
+func TestSamplingLoggerCompose(t *testing.T) {
+	var (
+		b       bytes.Buffer
+		dropped int
+	)
+	hook := SamplingHook(func(_ Level, _ string, d bool) {
+		if d {
+			dropped++
+		}
+	})
+	key := SamplingKey(func(Level, ...interface{}) string { return "all" })
+	logger := NewFilter(With(NewRateLimitLogger(NewStdLogger(&b), 1, time.Minute, hook, key), "service", "kratos"), FilterLevel(LevelInfo))
+	_ = logger.Log(LevelDebug, "msg", "ignored")
+	_ = logger.Log(LevelInfo, "msg", "first")
+	_ = logger.Log(LevelWarn, "msg", "second")
+	if s := b.String(); s != "INFO service=kratos msg=first\n" {
+		t.Errorf("log not match: %q", s)
+	}
+	if dropped != 1 {
+		t.Errorf("expected 1 dropped log, got %d", dropped)
+	}
+}
+
+func TestSamplingLoggerWithContext(t *testing.T) {
+	var b bytes.Buffer
+	inner := With(NewStdLogger(&b), "trace", Valuer(func(ctx context.Context) interface{} {
+		return ctx.Value(ctxKey{})
+	}))
+	logger := WithContext(context.WithValue(context.Background(), ctxKey{}, "abc"), NewSamplingLogger(inner, 1, 0, time.Minute))
+	_ = logger.Log(LevelInfo, "msg", "a")
+	if s := b.String(); s != "INFO trace=abc msg=a\n" {
+		t.Errorf("log not match: %q", s)
+	}
+}
@@ -0,0 +1,171 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	_ Logger       = (*slogLogger)(nil)
+	_ slog.Handler = (*slogHandler)(nil)
+)
+
+// slogLevelFatal 是 LevelFatal 在 slog 中对应的级别，slog 没有内置的致命级别。
+const slogLevelFatal = slog.LevelError + 4
+
+// ToSlogLevel 将日志级别转换为 slog 的日志级别。
+func ToSlogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return slogLevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// FromSlogLevel 将 slog 的日志级别转换为日志级别，介于两个级别之间的值向下取整。
+func FromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	case level < slogLevelFatal:
+		return LevelError
+	default:
+		return LevelFatal
+	}
+}
+
+// slogLogger 将日志写入标准库的 slog.Logger。
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 使用 slog.Logger 创建一个日志记录器。
+// 键值对中 DefaultMessageKey 对应的值会作为 slog 记录的消息。
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+// Log 将键值对转换为 slog 属性并记录日志。
+func (l *slogLogger) Log(level Level, keyvals ...interface{}) error {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	if (len(keyvals) & 1) == 1 {
+		keyvals = append(keyvals, "KEYVALS UNPAIRED")
+	}
+	ctx := context.Background()
+	lvl := ToSlogLevel(level)
+	if !l.logger.Enabled(ctx, lvl) {
+		return nil
+	}
+	var msg string
+	attrs := make([]slog.Attr, 0, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if key == DefaultMessageKey && msg == "" {
+			msg = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, keyvals[i+1]))
+	}
+	l.logger.LogAttrs(ctx, lvl, msg, attrs...)
+	return nil
+}
+
+// slogHandler 将 slog 记录转发到日志记录器。
+type slogHandler struct {
+	logger Logger
+	attrs  []interface{}
+	group  string
+}
+
+// NewSlogHandler 使用日志记录器创建一个 slog.Handler。
+// slog 的分组会以 "group.key" 的形式展开；记录的上下文会绑定到日志记录器上，
+// 因此属性中以及通过 With 添加的 Valuer 都会基于该上下文求值。
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// Enabled 判断指定的 slog 级别是否启用。
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if f, ok := h.logger.(*Filter); ok {
+		return f.enabled(FromSlogLevel(level))
+	}
+	return true
+}
+
+// Handle 将 slog 记录转换为键值对并记录日志。
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	kvs := make([]interface{}, 0, len(h.attrs)+2*r.NumAttrs()+2)
+	kvs = append(kvs, h.attrs...)
+	if r.Message != "" {
+		kvs = append(kvs, DefaultMessageKey, r.Message)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = appendAttr(kvs, h.group, a)
+		return true
+	})
+	logger := h.logger
+	if ctx != nil {
+		bindValues(ctx, kvs)
+		logger = WithContext(ctx, logger)
+	}
+	return logger.Log(FromSlogLevel(r.Level), kvs...)
+}
+
+// WithAttrs 返回一个附带了指定属性的 slog.Handler。
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	kvs := make([]interface{}, 0, len(h.attrs)+2*len(attrs))
+	kvs = append(kvs, h.attrs...)
+	for _, a := range attrs {
+		kvs = appendAttr(kvs, h.group, a)
+	}
+	return &slogHandler{logger: h.logger, attrs: kvs, group: h.group}
+}
+
+// WithGroup 返回一个在后续属性键前添加分组名的 slog.Handler。
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, attrs: h.attrs, group: h.group + name + "."}
+}
+
+// appendAttr 将 slog 属性展开为键值对，分组属性的键使用 "." 连接。
+func appendAttr(kvs []interface{}, prefix string, a slog.Attr) []interface{} {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return kvs
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range attrs {
+			kvs = appendAttr(kvs, prefix, ga)
+		}
+		return kvs
+	}
+	if a.Key == "" {
+		return kvs
+	}
+	return append(kvs, prefix+a.Key, v.Any())
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestSlogLogger(t *testing.T) {
+	var b bytes.Buffer
+	sl := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger := NewSlogLogger(sl)
+	_ = logger.Log(LevelDebug, "msg", "debug")
+	_ = logger.Log(LevelInfo, "msg", "hello", "k", "v")
+	_ = logger.Log(LevelWarn, "k", "v", "single")
+	want := "level=INFO msg=hello k=v\nlevel=WARN msg=\"\" k=v single=\"KEYVALS UNPAIRED\"\n"
+	if s := b.String(); s != want {
+		t.Fatalf("log not match: %q", s)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var b bytes.Buffer
+	logger := With(NewStdLogger(&b), "trace", Valuer(func(ctx context.Context) interface{} {
+		return ctx.Value(ctxKey{})
+	}))
+	sl := slog.New(NewSlogHandler(NewFilter(logger, FilterLevel(LevelInfo))))
+	ctx := context.WithValue(context.Background(), ctxKey{}, "abc")
+
+	sl.DebugContext(ctx, "ignored")
+	sl.With("a", 1).WithGroup("g").InfoContext(ctx, "hello", "b", 2, slog.Group("sub", "c", 3))
+	sl.Log(ctx, slog.LevelError+4, "fatal")
+
+	want := "INFO trace=abc a=1 msg=hello g.b=2 g.sub.c=3\nFATAL trace=abc msg=fatal\n"
+	if s := b.String(); s != want {
+		t.Fatalf("log not match: %q", s)
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal} {
+		if got := FromSlogLevel(ToSlogLevel(level)); got != level {
+			t.Errorf("expected %s, got %s", level, got)
+		}
+	}
+	if got := FromSlogLevel(slog.LevelWarn + 1); got != LevelWarn {
+		t.Errorf("expected %s, got %s", LevelWarn, got)
+	}
+	if !strings.HasPrefix(ToSlogLevel(LevelFatal).String(), "ERROR") {
+		t.Errorf("unexpected fatal level: %s", ToSlogLevel(LevelFatal))
+	}
+}
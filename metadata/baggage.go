@@ -0,0 +1,82 @@
+package metadata
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Propagation 是元数据键在服务调用链中的传播策略。
+type Propagation int
+
+const (
+	// PropagateGlobal 表示元数据沿调用链传递到所有下游服务。
+	PropagateGlobal Propagation = iota
+	// PropagateOneHop 表示元数据只传递给直接调用的下游服务，下游服务不再继续传递。
+	PropagateOneHop
+	// PropagateLocal 表示元数据只在当前服务内使用，不通过传输层发送，也不接收调用方发送的同名元数据。
+	PropagateLocal
+)
+
+// String 返回传播策略的名称。
+func (p Propagation) String() string {
+	switch p {
+	case PropagateGlobal:
+		return "global"
+	case PropagateOneHop:
+		return "one-hop"
+	case PropagateLocal:
+		return "local"
+	}
+	return "Propagation(" + strconv.Itoa(int(p)) + ")"
+}
+
+// lookup 查找键对应的值，键不区分大小写。
+func (m Metadata) lookup(key string) []string {
+	k := strings.ToLower(key)
+	if v, ok := m[k]; ok {
+		return v
+	}
+	// 兼容直接以字面量构造、未统一为小写的键
+	for mk, v := range m {
+		if strings.EqualFold(mk, k) {
+			return v
+		}
+	}
+	return nil
+}
+
+// GetInt 返回与指定键关联的整数值。
+func (m Metadata) GetInt(key string) (int64, bool) {
+	n, err := strconv.ParseInt(m.Get(key), 10, 64)
+	return n, err == nil
+}
+
+// GetFloat 返回与指定键关联的浮点数值。
+func (m Metadata) GetFloat(key string) (float64, bool) {
+	f, err := strconv.ParseFloat(m.Get(key), 64)
+	return f, err == nil
+}
+
+// GetBool 返回与指定键关联的布尔值。
+func (m Metadata) GetBool(key string) (bool, bool) {
+	b, err := strconv.ParseBool(m.Get(key))
+	return b, err == nil
+}
+
+// GetDuration 返回与指定键关联的时间间隔，值的格式与 time.ParseDuration 一致。
+func (m Metadata) GetDuration(key string) (time.Duration, bool) {
+	d, err := time.ParseDuration(m.Get(key))
+	return d, err == nil
+}
+
+// Size 返回所有键和值的总字节数，用于限制元数据的大小。
+func (m Metadata) Size() int {
+	var n int
+	for k, vList := range m {
+		for _, v := range vList {
+			n += len(k) + len(v)
+		}
+	}
+	return n
+}
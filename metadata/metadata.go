@@ -34,7 +34,7 @@ func (m Metadata) Add(key, value string) {
 
 // Get 返回与指定键关联的值。
 func (m Metadata) Get(key string) string {
-	v := m[strings.ToLower(key)]
+	v := m.lookup(key)
 	if len(v) == 0 {
 		return ""
 	}
@@ -60,7 +60,7 @@ func (m Metadata) Range(f func(k string, v []string) bool) {
 
 // Values 返回与指定键关联的所有值的切片。
 func (m Metadata) Values(key string) []string {
-	return m[strings.ToLower(key)]
+	return m.lookup(key)
 }
 
 // Clone 返回 Metadata 的一个深拷贝。
@@ -116,7 +116,7 @@ func MergeToClientContext(ctx context.Context, cmd Metadata) context.Context {
 	md, _ := FromClientContext(ctx)
 	md = md.Clone()
 	for k, v := range cmd {
-		md[k] = v
+		md[strings.ToLower(k)] = v
 	}
 	return NewClientContext(ctx, md)
 }
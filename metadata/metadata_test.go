@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -387,3 +388,25 @@ func TestMetadata_Clone(t *testing.T) {
 		})
 	}
 }
+
+func TestMetadata_Typed(t *testing.T) {
+	md := Metadata{"x-retry": {"3"}, "X-Ratio": {"0.5"}, "x-debug": {"true"}, "x-timeout": {"1s"}}
+	if v, ok := md.GetInt("X-Retry"); !ok || v != 3 {
+		t.Errorf("GetInt() = %v, %v", v, ok)
+	}
+	if v, ok := md.GetFloat("x-ratio"); !ok || v != 0.5 {
+		t.Errorf("GetFloat() = %v, %v", v, ok)
+	}
+	if v, ok := md.GetBool("x-debug"); !ok || !v {
+		t.Errorf("GetBool() = %v, %v", v, ok)
+	}
+	if v, ok := md.GetDuration("x-timeout"); !ok || v != time.Second {
+		t.Errorf("GetDuration() = %v, %v", v, ok)
+	}
+	if _, ok := md.GetInt("x-missing"); ok {
+		t.Errorf("GetInt() ok = %v, want false", ok)
+	}
+	if s := (Metadata{"ab": {"cd", "e"}}).Size(); s != 7 {
+		t.Errorf("Size() = %v, want 7", s)
+	}
+}
@@ -0,0 +1,160 @@
+// Package authz authorizes requests against a policy engine after they have been authenticated.
+package authz
+
+import (
+	"context"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/log"
+	"github.com/cnsync/kratos/middleware"
+	jwtauth "github.com/cnsync/kratos/middleware/auth/jwt"
+	"github.com/cnsync/kratos/transport"
+	thttp "github.com/cnsync/kratos/transport/http"
+)
+
+const reason = "FORBIDDEN"
+
+var (
+	// ErrForbidden is returned when the policy denies the request.
+	ErrForbidden = errors.Forbidden(reason, "permission denied")
+	// ErrMissingSubject is returned when there is no authenticated subject in the context.
+	ErrMissingSubject = errors.Unauthorized("UNAUTHORIZED", "subject is missing")
+	// ErrEngine is returned when the policy engine fails to evaluate the request.
+	ErrEngine = errors.InternalServer("AUTHZ_ENGINE", "authorization engine failed")
+	// ErrWrongContext is returned when there is no transport in the context.
+	ErrWrongContext = errors.Forbidden(reason, "wrong context for middleware")
+)
+
+// Authorizer decides whether the subject may perform the operation on the resource.
+// It returns nil to allow the request, ErrForbidden (or any error that is not a
+// *errors.Error) to deny it, and a *errors.Error to fail with that error as is.
+type Authorizer interface {
+	Allow(ctx context.Context, subject, operation, resource string) error
+}
+
+// AuthorizerFunc is an adapter to use ordinary functions as Authorizer.
+type AuthorizerFunc func(ctx context.Context, subject, operation, resource string) error
+
+// Allow calls f(ctx, subject, operation, resource).
+func (f AuthorizerFunc) Allow(ctx context.Context, subject, operation, resource string) error {
+	return f(ctx, subject, operation, resource)
+}
+
+// SubjectFunc extracts the authenticated subject from the context.
+type SubjectFunc func(ctx context.Context) (string, bool)
+
+// ResourceFunc returns the resource of the request.
+type ResourceFunc func(ctx context.Context, req interface{}) string
+
+// Option is authz option.
+type Option func(*options)
+
+type options struct {
+	subject  SubjectFunc
+	resource ResourceFunc
+	audit    log.Logger
+}
+
+// WithSubject with the subject extractor. Default is JWTSubject.
+func WithSubject(f SubjectFunc) Option {
+	return func(o *options) {
+		if f != nil {
+			o.subject = f
+		}
+	}
+}
+
+// WithResource with the resource extractor. Default is DefaultResource.
+func WithResource(f ResourceFunc) Option {
+	return func(o *options) {
+		if f != nil {
+			o.resource = f
+		}
+	}
+}
+
+// WithAuditLogger with the logger that records every decision,
+// allowed requests are logged at info level and denied ones at warn level.
+func WithAuditLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.audit = logger
+	}
+}
+
+// JWTSubject returns the "sub" claim put into the context by the jwt auth middleware.
+func JWTSubject(ctx context.Context) (string, bool) {
+	claims, ok := jwtauth.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return "", false
+	}
+	return sub, true
+}
+
+// DefaultResource returns the request path for HTTP, and the operation otherwise.
+func DefaultResource(ctx context.Context, _ interface{}) string {
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return ""
+	}
+	if ht, ok := tr.(*thttp.Transport); ok && ht.Request() != nil {
+		return ht.Request().URL.Path
+	}
+	return tr.Operation()
+}
+
+// Server is a server authorization middleware, it should be placed after the auth middleware.
+func Server(authorizer Authorizer, opts ...Option) middleware.Middleware {
+	o := &options{
+		subject:  JWTSubject,
+		resource: DefaultResource,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			subject, ok := o.subject(ctx)
+			if !ok {
+				return nil, ErrMissingSubject
+			}
+			operation := tr.Operation()
+			resource := o.resource(ctx, req)
+			err := authorizer.Allow(ctx, subject, operation, resource)
+			if err != nil {
+				if _, ok := err.(*errors.Error); !ok {
+					err = ErrForbidden.WithCause(err)
+				}
+			}
+			if o.audit != nil {
+				o.log(ctx, subject, operation, resource, err)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func (o *options) log(ctx context.Context, subject, operation, resource string, err error) {
+	level, keyvals := log.LevelInfo, []interface{}{
+		"kind", "authz",
+		"subject", subject,
+		"operation", operation,
+		"resource", resource,
+		"allowed", err == nil,
+	}
+	if err != nil {
+		level = log.LevelWarn
+		keyvals = append(keyvals, "reason", errors.Reason(err))
+	}
+	log.NewHelper(log.WithContext(ctx, o.audit)).Log(level, keyvals...)
+}
@@ -0,0 +1,103 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	kerrors "github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/log"
+	jwtauth "github.com/cnsync/kratos/middleware/auth/jwt"
+	"github.com/cnsync/kratos/transport"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *testTransport) Operation() string { return tr.operation }
+
+type enforcer map[string]bool
+
+func (e enforcer) Enforce(rvals ...interface{}) (bool, error) {
+	if rvals[0] == "error" {
+		return false, errors.New("engine error")
+	}
+	return e[rvals[0].(string)+":"+rvals[1].(string)+":"+rvals[2].(string)], nil
+}
+
+func newContext(subject string) context.Context {
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: "/test.Greeter/Hello"})
+	if subject == "" {
+		return ctx
+	}
+	return jwtauth.NewContext(ctx, jwt.RegisteredClaims{Subject: subject})
+}
+
+func TestServer(t *testing.T) {
+	var buf bytes.Buffer
+	authorizer := NewEnforcerAuthorizer(enforcer{"alice:/test.Greeter/Hello:/test.Greeter/Hello": true})
+	m := Server(authorizer, WithAuditLogger(log.NewStdLogger(&buf)))
+	next := func(context.Context, interface{}) (interface{}, error) { return "reply", nil }
+
+	tests := []struct {
+		name    string
+		subject string
+		code    int
+	}{
+		{"allowed", "alice", 200},
+		{"denied", "bob", 403},
+		{"missing subject", "", 401},
+		{"engine error", "error", 500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := m(next)(newContext(test.subject), nil)
+			if code := kerrors.Code(err); code != test.code {
+				t.Errorf("expected code %d, got %d: %v", test.code, code, err)
+			}
+		})
+	}
+	if !strings.Contains(buf.String(), "subject=alice") || !strings.Contains(buf.String(), "allowed=false") {
+		t.Errorf("unexpected audit log: %s", buf.String())
+	}
+
+	if _, err := m(next)(context.Background(), nil); !kerrors.IsForbidden(err) {
+		t.Errorf("expected forbidden without transport, got %v", err)
+	}
+}
+
+func TestNewPolicyAuthorizer(t *testing.T) {
+	var input *Input
+	a := NewPolicyAuthorizer(func(_ context.Context, in *Input) (bool, error) {
+		input = in
+		return in.Subject == "alice", nil
+	})
+	m := Server(a, WithResource(func(context.Context, interface{}) string { return "greeter" }))
+	next := func(context.Context, interface{}) (interface{}, error) { return "reply", nil }
+	if _, err := m(next)(newContext("alice"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if input.Resource != "greeter" || input.Operation != "/test.Greeter/Hello" {
+		t.Errorf("unexpected input: %+v", input)
+	}
+	if _, err := m(next)(newContext("bob"), nil); !kerrors.IsForbidden(err) {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+}
+
+func TestAuthorizerFunc(t *testing.T) {
+	a := AuthorizerFunc(func(context.Context, string, string, string) error {
+		return errors.New("denied")
+	})
+	m := Server(a, WithSubject(func(context.Context) (string, bool) { return "anyone", true }))
+	_, err := m(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(newContext(""), nil)
+	if !kerrors.IsForbidden(err) {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package authz
+
+import (
+	"context"
+)
+
+// Enforcer is a Casbin-style policy engine, *casbin.Enforcer and
+// *casbin.SyncedEnforcer satisfy it.
+type Enforcer interface {
+	Enforce(rvals ...interface{}) (bool, error)
+}
+
+// NewEnforcerAuthorizer returns an Authorizer that enforces the request
+// (subject, resource, operation), matching the (sub, obj, act) definition of a Casbin model.
+func NewEnforcerAuthorizer(e Enforcer) Authorizer {
+	return AuthorizerFunc(func(_ context.Context, subject, operation, resource string) error {
+		ok, err := e.Enforce(subject, resource, operation)
+		if err != nil {
+			return ErrEngine.WithCause(err)
+		}
+		if !ok {
+			return ErrForbidden
+		}
+		return nil
+	})
+}
+
+// Input is the input document evaluated by a Policy.
+type Input struct {
+	Subject   string `json:"subject"`
+	Operation string `json:"operation"`
+	Resource  string `json:"resource"`
+}
+
+// Policy is an OPA-style policy evaluator, it reports whether the input is allowed,
+// e.g. by evaluating a prepared "data.authz.allow" rego query with the input.
+type Policy func(ctx context.Context, input *Input) (bool, error)
+
+// NewPolicyAuthorizer returns an Authorizer that evaluates the policy.
+func NewPolicyAuthorizer(p Policy) Authorizer {
+	return AuthorizerFunc(func(ctx context.Context, subject, operation, resource string) error {
+		ok, err := p(ctx, &Input{Subject: subject, Operation: operation, Resource: resource})
+		if err != nil {
+			return ErrEngine.WithCause(err)
+		}
+		if !ok {
+			return ErrForbidden
+		}
+		return nil
+	})
+}
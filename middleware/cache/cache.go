@@ -0,0 +1,383 @@
+// Package cache caches the replies of idempotent HTTP operations.
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/cnsync/kratos/internal/httputil"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+	thttp "github.com/cnsync/kratos/transport/http"
+)
+
+// Reply headers set by the middleware.
+const (
+	// StatusHeader tells whether the reply is served from the cache, HIT or MISS.
+	StatusHeader = "X-Cache"
+	// AgeHeader is the age of the cached reply in seconds.
+	AgeHeader = "Age"
+)
+
+// Results recorded by the counter.
+const (
+	ResultHit    = "hit"
+	ResultMiss   = "miss"
+	ResultBypass = "bypass"
+)
+
+// Store stores the encoded replies.
+// The methods map to the Redis commands GET and SET PX, so a Redis client could be adapted
+// to share the cache between instances.
+type Store interface {
+	// Get returns the value of the key, false if the key does not exist or has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set sets the value of the key.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Option is cache option.
+type Option func(*options)
+
+// WithTTL with how long the replies without a max-age directive are cached.
+// Default is 0, which only caches the replies carrying Cache-Control max-age or s-maxage.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithVary with the request headers the replies always vary on, e.g. Accept-Language,
+// in addition to the Vary header set by the handlers.
+func WithVary(headers ...string) Option {
+	return func(o *options) {
+		for _, h := range headers {
+			o.vary = append(o.vary, textproto.CanonicalMIMEHeaderKey(h))
+		}
+	}
+}
+
+// WithCredentials caches the replies of the requests carrying the Authorization or Cookie header,
+// which vary on both headers so a reply is only served to the same credentials.
+// Default is to bypass the cache for such requests.
+func WithCredentials() Option {
+	return func(o *options) {
+		o.credentials = true
+		o.vary = append(o.vary, "Authorization", "Cookie")
+	}
+}
+
+// WithCounter with the counter of the cache lookups, recorded with the operation and result
+// (hit, miss or bypass) attributes, the hit ratio is hit / (hit + miss).
+func WithCounter(c metric.Int64Counter) Option {
+	return func(o *options) {
+		o.counter = c
+	}
+}
+
+type options struct {
+	ttl         time.Duration
+	vary        []string
+	credentials bool
+	counter     metric.Int64Counter
+	nowFunc     func() time.Time
+}
+
+// cached is a reply with the headers set by the handler.
+type cached struct {
+	reply  interface{}
+	header http.Header
+	// request is the request headers of the handler call, not stored.
+	request http.Header
+}
+
+// Server is a server middleware that caches the replies of HTTP GET and HEAD operations in the store,
+// keyed by the operation, the path, the canonical query and the varying request headers.
+// The reply headers set by the handler, except Set-Cookie, are stored with the reply and replayed on a hit.
+// The request Cache-Control no-store skips the cache, no-cache and max-age=0 skip the lookup;
+// the requests carrying credentials skip the cache unless WithCredentials is set;
+// the replies with Cache-Control no-store, private or Vary: * are not cached.
+// Concurrent misses of the same key share one handler call, protecting the backends from stampedes;
+// a request whose values differ from the shared call for the headers in the reply Vary calls the handler itself.
+// Only proto message replies are cached, which are what the generated services return.
+func Server(store Store, opts ...Option) middleware.Middleware {
+	o := &options{nowFunc: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var group flight
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ht, ok := tr.(thttp.Transporter)
+			if !ok || ht.Request() == nil {
+				return handler(ctx, req)
+			}
+			r := ht.Request()
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				return handler(ctx, req)
+			}
+			cc := httputil.ParseCacheControl(r.Header.Get("Cache-Control"))
+			if _, ok := cc["no-store"]; ok || (!o.credentials && hasCredentials(r.Header)) {
+				o.record(ctx, tr.Operation(), ResultBypass)
+				return handler(ctx, req)
+			}
+			base := tr.Operation() + " " + r.URL.Path + "?" + canonicalQuery(r.URL.Query())
+			vary, stored := o.storedVary(ctx, store, base)
+			_, noCache := cc["no-cache"]
+			if stored && !noCache && cc["max-age"] != "0" {
+				if e, age, ok := o.lookup(ctx, store, base+"#"+variant(vary, r.Header)); ok {
+					o.record(ctx, tr.Operation(), ResultHit)
+					replay(tr.ReplyHeader(), e.header)
+					tr.ReplyHeader().Set(StatusHeader, "HIT")
+					tr.ReplyHeader().Set(AgeHeader, strconv.Itoa(int(age/time.Second)))
+					return e.reply, nil
+				}
+			}
+			o.record(ctx, tr.Operation(), ResultMiss)
+			tr.ReplyHeader().Set(StatusHeader, "MISS")
+			call := func(ctx context.Context) (*cached, error) {
+				before := snapshot(tr.ReplyHeader())
+				reply, err := handler(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				e := &cached{reply: reply, header: handlerHeader(tr.ReplyHeader(), before), request: r.Header}
+				o.save(context.WithoutCancel(ctx), store, base, r.Header, tr.ReplyHeader(), e)
+				return e, nil
+			}
+			// the first request of the key calls the handler, the others wait for its reply;
+			// the handler is detached from the cancellation of the first request so it does not
+			// fail the waiting requests, which stop waiting on their own cancellation
+			e, shared, err := group.do(ctx, base+"#"+variant(vary, r.Header), func() (*cached, error) {
+				return call(context.WithoutCancel(ctx))
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !shared {
+				return e.reply, nil
+			}
+			if !sameVariant(e, r.Header) {
+				// the reply varies on headers missing from the key, whose values differ
+				// from the request of the shared call, so it is not the reply of this request
+				if e, err = call(ctx); err != nil {
+					return nil, err
+				}
+				return e.reply, nil
+			}
+			replay(tr.ReplyHeader(), e.header)
+			tr.ReplyHeader().Set(StatusHeader, "MISS")
+			if m, ok := e.reply.(proto.Message); ok {
+				return proto.Clone(m), nil
+			}
+			return e.reply, nil
+		}
+	}
+}
+
+// storedVary returns the varying request headers stored under the base key,
+// or the headers of WithVary if there is none.
+func (o *options) storedVary(ctx context.Context, store Store, base string) ([]string, bool) {
+	vary, ok, err := store.Get(ctx, base)
+	if err != nil || !ok {
+		return o.vary, false
+	}
+	return splitVary(string(vary)), true
+}
+
+// lookup returns the cached reply of the variant key and its age.
+func (o *options) lookup(ctx context.Context, store Store, key string) (*cached, time.Duration, bool) {
+	data, ok, err := store.Get(ctx, key)
+	if err != nil || !ok || len(data) < 12 {
+		return nil, 0, false
+	}
+	// the value is the creation time, the length of the headers, the headers and the reply
+	created := time.Unix(0, int64(binary.BigEndian.Uint64(data))) //nolint:gosec
+	n := int(binary.BigEndian.Uint32(data[8:]))
+	if len(data) < 12+n {
+		return nil, 0, false
+	}
+	values, err := url.ParseQuery(string(data[12 : 12+n]))
+	if err != nil {
+		return nil, 0, false
+	}
+	var a anypb.Any
+	if err = proto.Unmarshal(data[12+n:], &a); err != nil {
+		return nil, 0, false
+	}
+	reply, err := a.UnmarshalNew()
+	if err != nil {
+		return nil, 0, false
+	}
+	return &cached{reply: reply, header: http.Header(values)}, o.nowFunc().Sub(created), true
+}
+
+// save stores the reply if it is cacheable.
+// The varying request headers are stored under the base key, and the reply under the variant key.
+func (o *options) save(ctx context.Context, store Store, base string, header http.Header, replyHeader transport.Header, e *cached) {
+	m, ok := e.reply.(proto.Message)
+	if !ok {
+		return
+	}
+	cc := httputil.ParseCacheControl(replyHeader.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return
+	}
+	if _, ok := cc["private"]; ok {
+		return
+	}
+	ttl := o.ttl
+	if v, ok := cc["s-maxage"]; ok {
+		ttl = httputil.DeltaSeconds(v)
+	} else if v, ok := cc["max-age"]; ok {
+		ttl = httputil.DeltaSeconds(v)
+	}
+	if ttl <= 0 {
+		return
+	}
+	vary := append([]string{}, o.vary...)
+	for _, v := range splitVary(replyHeader.Get("Vary")) {
+		if v == "*" {
+			return
+		}
+		vary = append(vary, v)
+	}
+	sort.Strings(vary)
+	a, err := anypb.New(m)
+	if err != nil {
+		return
+	}
+	data, err := proto.Marshal(a)
+	if err != nil {
+		return
+	}
+	encoded := url.Values(e.header).Encode()
+	value := make([]byte, 12, 12+len(encoded)+len(data))
+	binary.BigEndian.PutUint64(value, uint64(o.nowFunc().UnixNano())) //nolint:gosec
+	binary.BigEndian.PutUint32(value[8:], uint32(len(encoded)))       //nolint:gosec
+	value = append(value, encoded...)
+	value = append(value, data...)
+	if err = store.Set(ctx, base, []byte(strings.Join(vary, ",")), ttl); err != nil {
+		return
+	}
+	_ = store.Set(ctx, base+"#"+variant(vary, header), value, ttl)
+}
+
+// record records the result of the cache lookup.
+func (o *options) record(ctx context.Context, operation, result string) {
+	if o.counter == nil {
+		return
+	}
+	o.counter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("operation", operation),
+		attribute.String("result", result),
+	))
+}
+
+// hasCredentials reports whether the request carries the Authorization or Cookie header.
+func hasCredentials(header http.Header) bool {
+	return header.Get("Authorization") != "" || header.Get("Cookie") != ""
+}
+
+// snapshot copies the reply headers.
+func snapshot(replyHeader transport.Header) http.Header {
+	header := make(http.Header)
+	for _, k := range replyHeader.Keys() {
+		header[textproto.CanonicalMIMEHeaderKey(k)] = append([]string(nil), replyHeader.Values(k)...)
+	}
+	return header
+}
+
+// handlerHeader copies the reply headers the handler added or changed since the snapshot before it ran,
+// so the headers of the outer middleware, e.g. X-Request-Id, are not replayed to other requests.
+// Set-Cookie is never copied, the cookies belong to the session of the request that filled the cache.
+func handlerHeader(replyHeader transport.Header, before http.Header) http.Header {
+	header := make(http.Header)
+	for _, k := range replyHeader.Keys() {
+		k = textproto.CanonicalMIMEHeaderKey(k)
+		if k == StatusHeader || k == AgeHeader || k == "Set-Cookie" {
+			continue
+		}
+		vs := replyHeader.Values(k)
+		if prev, ok := before[k]; ok && slices.Equal(prev, vs) {
+			continue
+		}
+		header[k] = vs
+	}
+	return header
+}
+
+// replay sets the stored headers to the reply headers.
+func replay(replyHeader transport.Header, header http.Header) {
+	for k, vs := range header {
+		for i, v := range vs {
+			if i == 0 {
+				replyHeader.Set(k, v)
+			} else {
+				replyHeader.Add(k, v)
+			}
+		}
+	}
+}
+
+// canonicalQuery returns the query with the keys and values sorted.
+func canonicalQuery(query url.Values) string {
+	for _, vs := range query {
+		sort.Strings(vs)
+	}
+	return query.Encode()
+}
+
+// sameVariant reports whether the request has the same values as the request of the
+// handler call for the headers the reply varies on.
+func sameVariant(e *cached, header http.Header) bool {
+	vary := splitVary(strings.Join(e.header.Values("Vary"), ","))
+	for _, v := range vary {
+		if v == "*" {
+			return false
+		}
+	}
+	return variant(vary, e.request) == variant(vary, header)
+}
+
+// variant returns the values of the varying request headers.
+func variant(vary []string, header http.Header) string {
+	if len(vary) == 0 {
+		return ""
+	}
+	values := make(url.Values, len(vary))
+	for _, h := range vary {
+		values[h] = header.Values(h)
+	}
+	return values.Encode()
+}
+
+// splitVary splits the Vary header into the canonical header names.
+func splitVary(vary string) []string {
+	var names []string
+	for _, v := range strings.Split(vary, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			names = append(names, textproto.CanonicalMIMEHeaderKey(v))
+		}
+	}
+	return names
+}
@@ -0,0 +1,445 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/cnsync/kratos/internal/lru"
+	"github.com/cnsync/kratos/middleware/requestid"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type transportMock struct {
+	request     *http.Request
+	replyHeader headerCarrier
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return "/helloworld.Greeter/GetHello"
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return headerCarrier(tr.request.Header)
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+func (tr *transportMock) Request() *http.Request {
+	return tr.request
+}
+
+func (tr *transportMock) PathTemplate() string {
+	return "/hello"
+}
+
+func newContext(method, target string, header http.Header) (context.Context, *transportMock) {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	tr := &transportMock{request: r, replyHeader: headerCarrier{}}
+	return transport.NewServerContext(context.Background(), tr), tr
+}
+
+// counting returns a handler counting its calls, which replies the call number with the reply headers.
+func counting(calls *int32, replyHeader http.Header) func(context.Context, interface{}) (interface{}, error) {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		n := atomic.AddInt32(calls, 1)
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			for k, v := range replyHeader {
+				tr.ReplyHeader().Set(k, v[0])
+			}
+		}
+		return wrapperspb.Int32(n), nil
+	}
+}
+
+func call(t *testing.T, h func(context.Context, interface{}) (interface{}, error), method, target string, header http.Header) (int32, *transportMock) {
+	t.Helper()
+	ctx, tr := newContext(method, target, header)
+	reply, err := h(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply.(*wrapperspb.Int32Value).GetValue(), tr
+}
+
+func TestServer(t *testing.T) {
+	var calls int32
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute))(counting(&calls, nil))
+
+	if n, tr := call(t, h, http.MethodGet, "/hello?b=2&a=1", nil); n != 1 || tr.replyHeader.Get(StatusHeader) != "MISS" {
+		t.Errorf("expect miss, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+	// the query is canonicalized
+	n, tr := call(t, h, http.MethodGet, "/hello?a=1&b=2", nil)
+	if n != 1 || tr.replyHeader.Get(StatusHeader) != "HIT" || tr.replyHeader.Get(AgeHeader) != "0" {
+		t.Errorf("expect hit, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+	if n, _ = call(t, h, http.MethodGet, "/hello?a=2", nil); n != 2 {
+		t.Errorf("expect a different key, got %d", n)
+	}
+	// non idempotent methods are not cached
+	if n, _ = call(t, h, http.MethodPost, "/hello?a=1&b=2", nil); n != 3 {
+		t.Errorf("expect post not to be cached, got %d", n)
+	}
+}
+
+func TestServer_RequestCacheControl(t *testing.T) {
+	var calls int32
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute))(counting(&calls, nil))
+	call(t, h, http.MethodGet, "/hello", nil)
+
+	// no-cache skips the lookup and refreshes the cache
+	if n, _ := call(t, h, http.MethodGet, "/hello", http.Header{"Cache-Control": {"no-cache"}}); n != 2 {
+		t.Errorf("expect no-cache to call the handler, got %d", n)
+	}
+	if n, _ := call(t, h, http.MethodGet, "/hello", nil); n != 2 {
+		t.Errorf("expect the refreshed reply, got %d", n)
+	}
+	// no-store skips the cache
+	n, tr := call(t, h, http.MethodGet, "/hello", http.Header{"Cache-Control": {"no-store"}})
+	if n != 3 || tr.replyHeader.Get(StatusHeader) != "" {
+		t.Errorf("expect no-store to bypass the cache, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+	if n, _ = call(t, h, http.MethodGet, "/hello", nil); n != 2 {
+		t.Errorf("expect no-store not to refresh the cache, got %d", n)
+	}
+}
+
+func TestServer_ReplyCacheControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		cached bool
+	}{
+		{name: "no ttl", header: nil, cached: false},
+		{name: "max-age", header: http.Header{"Cache-Control": {"public, max-age=60"}}, cached: true},
+		{name: "s-maxage", header: http.Header{"Cache-Control": {"max-age=0, s-maxage=60"}}, cached: true},
+		{name: "no-store", header: http.Header{"Cache-Control": {"no-store, max-age=60"}}, cached: false},
+		{name: "private", header: http.Header{"Cache-Control": {"private, max-age=60"}}, cached: false},
+		{name: "vary all", header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}, cached: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int32
+			h := Server(NewMemoryStore(100))(counting(&calls, test.header))
+			call(t, h, http.MethodGet, "/hello", nil)
+			n, _ := call(t, h, http.MethodGet, "/hello", nil)
+			if cached := n == 1; cached != test.cached {
+				t.Errorf("expect cached %v, got %v", test.cached, cached)
+			}
+		})
+	}
+}
+
+func TestServer_Vary(t *testing.T) {
+	var calls int32
+	h := Server(NewMemoryStore(100), WithVary("accept-language"))(counting(&calls, http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Vary":          {"Accept"},
+	}))
+	en := http.Header{"Accept-Language": {"en"}, "Accept": {"application/json"}}
+	zh := http.Header{"Accept-Language": {"zh"}, "Accept": {"application/json"}}
+	pb := http.Header{"Accept-Language": {"en"}, "Accept": {"application/x-protobuf"}}
+	call(t, h, http.MethodGet, "/hello", en)
+	call(t, h, http.MethodGet, "/hello", zh)
+	call(t, h, http.MethodGet, "/hello", pb)
+	if n, _ := call(t, h, http.MethodGet, "/hello", en); n != 1 {
+		t.Errorf("expect en variant 1, got %d", n)
+	}
+	if n, _ := call(t, h, http.MethodGet, "/hello", zh); n != 2 {
+		t.Errorf("expect zh variant 2, got %d", n)
+	}
+	if n, _ := call(t, h, http.MethodGet, "/hello", pb); n != 3 {
+		t.Errorf("expect protobuf variant 3, got %d", n)
+	}
+}
+
+func TestServer_Singleflight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute))(func(context.Context, interface{}) (interface{}, error) {
+		<-release
+		return wrapperspb.Int32(atomic.AddInt32(&calls, 1)), nil
+	})
+	const n = 10
+	var (
+		wg      sync.WaitGroup
+		replies = make([]proto.Message, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, _ := newContext(http.MethodGet, "/hello", nil)
+			reply, err := h(ctx, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			replies[i] = reply.(proto.Message)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("expect 1 handler call, got %d", calls)
+	}
+	for _, r := range replies {
+		if r.(*wrapperspb.Int32Value).GetValue() != 1 {
+			t.Errorf("expect shared reply 1, got %v", r)
+		}
+	}
+}
+
+func TestServer_SingleflightVary(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	h := Server(NewMemoryStore(100))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-release
+		atomic.AddInt32(&calls, 1)
+		tr, _ := transport.FromServerContext(ctx)
+		tr.ReplyHeader().Set("Cache-Control", "max-age=60")
+		tr.ReplyHeader().Set("Vary", "Accept-Language")
+		tr.ReplyHeader().Set("Content-Language", tr.RequestHeader().Get("Accept-Language"))
+		return wrapperspb.String(tr.RequestHeader().Get("Accept-Language")), nil
+	})
+	languages := []string{"en", "zh"}
+	var (
+		wg      sync.WaitGroup
+		replies = make([]string, len(languages))
+		headers = make([]string, len(languages))
+	)
+	for i, lang := range languages {
+		wg.Add(1)
+		go func(i int, lang string) {
+			defer wg.Done()
+			ctx, tr := newContext(http.MethodGet, "/hello", http.Header{"Accept-Language": {lang}})
+			reply, err := h(ctx, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			replies[i] = reply.(*wrapperspb.StringValue).GetValue()
+			headers[i] = tr.replyHeader.Get("Content-Language")
+		}(i, lang)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 2 {
+		t.Errorf("expect 2 handler calls, got %d", calls)
+	}
+	for i, lang := range languages {
+		if replies[i] != lang || headers[i] != lang {
+			t.Errorf("expect %s variant, got %s %s", lang, replies[i], headers[i])
+		}
+	}
+}
+
+func TestWithCounter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	counter, err := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("cache").Int64Counter("cache_lookups_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute), WithCounter(counter))(counting(&calls, nil))
+	call(t, h, http.MethodGet, "/hello", nil)
+	call(t, h, http.MethodGet, "/hello", nil)
+	call(t, h, http.MethodGet, "/hello", nil)
+
+	var rm metricdata.ResourceMetrics
+	if err = reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]int64{}
+	for _, dp := range rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64]).DataPoints {
+		v, _ := dp.Attributes.Value("result")
+		results[v.AsString()] = dp.Value
+	}
+	if results[ResultHit] != 2 || results[ResultMiss] != 1 {
+		t.Errorf("expect 2 hits and 1 miss, got %v", results)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	s := &memoryStore{cache: lru.New(1, func() time.Time { return now })}
+	ctx := context.Background()
+	_ = s.Set(ctx, "a", []byte("1"), time.Minute)
+	if v, ok, _ := s.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("expect a=1, got %s %v", v, ok)
+	}
+	// the least recently used key is evicted when the store is full
+	_ = s.Set(ctx, "b", []byte("2"), time.Minute)
+	if _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Error("expect a to be evicted")
+	}
+	now = now.Add(time.Minute)
+	if _, ok, _ := s.Get(ctx, "b"); ok {
+		t.Error("expect b to expire")
+	}
+}
+
+func TestServer_Path(t *testing.T) {
+	var calls int32
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute))(counting(&calls, nil))
+	call(t, h, http.MethodGet, "/users/1", nil)
+	// the same operation with different path variables is a different key
+	if n, tr := call(t, h, http.MethodGet, "/users/2", nil); n != 2 || tr.replyHeader.Get(StatusHeader) != "MISS" {
+		t.Errorf("expect miss, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+	if n, _ := call(t, h, http.MethodGet, "/users/1", nil); n != 1 {
+		t.Errorf("expect the reply of /users/1, got %d", n)
+	}
+}
+
+func TestServer_Credentials(t *testing.T) {
+	var calls int32
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute))(counting(&calls, nil))
+	alice := http.Header{"Authorization": {"Bearer alice"}}
+	call(t, h, http.MethodGet, "/hello", alice)
+	n, tr := call(t, h, http.MethodGet, "/hello", alice)
+	if n != 2 || tr.replyHeader.Get(StatusHeader) != "" {
+		t.Errorf("expect the credentials to bypass the cache, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+
+	calls = 0
+	h = Server(NewMemoryStore(100), WithTTL(time.Minute), WithCredentials())(counting(&calls, nil))
+	bob := http.Header{"Cookie": {"session=bob"}}
+	call(t, h, http.MethodGet, "/hello", alice)
+	if n, _ = call(t, h, http.MethodGet, "/hello", bob); n != 2 {
+		t.Errorf("expect a different key for other credentials, got %d", n)
+	}
+	if n, _ = call(t, h, http.MethodGet, "/hello", alice); n != 1 {
+		t.Errorf("expect the reply of the same credentials, got %d", n)
+	}
+}
+
+func TestServer_ReplayHeader(t *testing.T) {
+	var calls int32
+	h := Server(NewMemoryStore(100))(counting(&calls, http.Header{
+		"Cache-Control": {"max-age=60"},
+		"X-Total-Count": {"42"},
+	}))
+	call(t, h, http.MethodGet, "/hello", nil)
+	n, tr := call(t, h, http.MethodGet, "/hello", nil)
+	if n != 1 || tr.replyHeader.Get(StatusHeader) != "HIT" {
+		t.Fatalf("expect hit, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+	if tr.replyHeader.Get("X-Total-Count") != "42" || tr.replyHeader.Get("Cache-Control") != "max-age=60" {
+		t.Errorf("expect the handler headers to be replayed, got %v", tr.replyHeader)
+	}
+}
+
+func TestServer_OuterHeader(t *testing.T) {
+	var calls int32
+	h := requestid.Server()(Server(NewMemoryStore(100))(counting(&calls, http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Set-Cookie":    {"session=anonymous"},
+	})))
+	call(t, h, http.MethodGet, "/hello", http.Header{requestid.DefaultHeader: {"first"}})
+	n, tr := call(t, h, http.MethodGet, "/hello", http.Header{requestid.DefaultHeader: {"second"}})
+	if n != 1 || tr.replyHeader.Get(StatusHeader) != "HIT" {
+		t.Fatalf("expect hit, got %d %s", n, tr.replyHeader.Get(StatusHeader))
+	}
+	if id := tr.replyHeader.Get(requestid.DefaultHeader); id != "second" {
+		t.Errorf("expect the request id of the request, got %s", id)
+	}
+	if cookie := tr.replyHeader.Get("Set-Cookie"); cookie != "" {
+		t.Errorf("expect no replayed cookie, got %s", cookie)
+	}
+}
+
+func TestServer_SingleflightCancel(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	h := Server(NewMemoryStore(100), WithTTL(time.Minute))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			tr.ReplyHeader().Set("X-Total-Count", "42")
+		}
+		return wrapperspb.Int32(atomic.AddInt32(&calls, 1)), nil
+	})
+	leaderCtx, _ := newContext(http.MethodGet, "/hello", nil)
+	leaderCtx, cancel := context.WithCancel(leaderCtx)
+	go func() { _, _ = h(leaderCtx, nil) }()
+	time.Sleep(20 * time.Millisecond)
+	followerCtx, followerTr := newContext(http.MethodGet, "/hello", nil)
+	follower := make(chan interface{}, 1)
+	go func() {
+		reply, err := h(followerCtx, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		follower <- reply
+	}()
+	time.Sleep(20 * time.Millisecond)
+	// the cancellation of the first request does not fail the waiting one
+	cancel()
+	close(release)
+	if reply := <-follower; reply.(*wrapperspb.Int32Value).GetValue() != 1 {
+		t.Errorf("expect reply 1, got %v", reply)
+	}
+	if followerTr.replyHeader.Get("X-Total-Count") != "42" {
+		t.Errorf("expect the handler headers on the follower, got %v", followerTr.replyHeader)
+	}
+
+	// a waiting request stops on its own cancellation
+	release = make(chan struct{})
+	defer close(release)
+	leaderCtx, _ = newContext(http.MethodGet, "/world", nil)
+	go func() { _, _ = h(leaderCtx, nil) }()
+	time.Sleep(20 * time.Millisecond)
+	ctx, _ := newContext(http.MethodGet, "/world", nil)
+	ctx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := h(ctx, nil); err != context.DeadlineExceeded {
+		t.Errorf("expect %v, got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var errPanicked = errors.New("cache: the handler panicked")
+
+// inflight is an in-flight handler call.
+type inflight struct {
+	done chan struct{}
+	val  *cached
+	err  error
+}
+
+// flight deduplicates the concurrent handler calls of the same key.
+// Unlike singleflight, the first caller runs the function on its own goroutine and
+// the others stop waiting on their own cancellation, so a function writing to the
+// transport of the first caller never outlives its request.
+type flight struct {
+	mu    sync.Mutex
+	calls map[string]*inflight
+}
+
+// do runs fn once for the concurrent callers of the key, shared reports whether
+// the result comes from the call of another caller.
+func (f *flight) do(ctx context.Context, key string, fn func() (*cached, error)) (val *cached, shared bool, err error) {
+	f.mu.Lock()
+	if c, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		case <-c.done:
+			return c.val, true, c.err
+		}
+	}
+	if f.calls == nil {
+		f.calls = make(map[string]*inflight)
+	}
+	c := &inflight{done: make(chan struct{}), err: errPanicked}
+	f.calls[key] = c
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, key)
+		f.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = fn()
+	return c.val, false, c.err
+}
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/cnsync/kratos/internal/lru"
+)
+
+// memoryStore is an in-memory Store that evicts the least recently used keys.
+type memoryStore struct {
+	cache *lru.Cache
+}
+
+// NewMemoryStore returns an in-memory Store that holds at most size keys,
+// the least recently used keys are evicted when it is full.
+func NewMemoryStore(size int) Store {
+	return &memoryStore{cache: lru.New(size, time.Now)}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := s.cache.Get(key)
+	return value, ok, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.cache.Set(key, value, ttl)
+	return nil
+}
@@ -35,8 +35,17 @@ func WithCircuitBreaker(genBreakerFunc func() circuitbreaker.CircuitBreaker) Opt
 	}
 }
 
+// WithRules with the breaker thresholds of the operations, which can be reloaded from config
+// at runtime. Operations that match no rule use the breakers of the group.
+func WithRules(r *Rules) Option {
+	return func(o *options) {
+		o.rules = r
+	}
+}
+
 type options struct {
 	group *group.Group
+	rules *Rules
 }
 
 // Client circuitbreaker middleware will return errBreakerTriggered when the circuit
@@ -53,7 +62,10 @@ func Client(opts ...Option) middleware.Middleware {
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			info, _ := transport.FromClientContext(ctx)
-			breaker := opt.group.Get(info.Operation()).(circuitbreaker.CircuitBreaker)
+			breaker, ok := opt.breaker(info.Operation())
+			if !ok {
+				breaker = opt.group.Get(info.Operation()).(circuitbreaker.CircuitBreaker)
+			}
 			if err := breaker.Allow(); err != nil {
 				// rejected
 				// NOTE: when client reject requests locally,
@@ -72,3 +84,11 @@ func Client(opts ...Option) middleware.Middleware {
 		}
 	}
 }
+
+// breaker returns the breaker of the operation from the rules.
+func (o *options) breaker(operation string) (circuitbreaker.CircuitBreaker, bool) {
+	if o.rules == nil {
+		return nil, false
+	}
+	return o.rules.breaker(operation)
+}
@@ -77,3 +77,50 @@ func TestServer(_ *testing.T) {
 
 	_, _ = Client(func(_ *options) {})(nextInvalid)(ctx, nil)
 }
+
+func TestRules(t *testing.T) {
+	r := NewRules(map[string]*Threshold{
+		"/helloworld.Greeter/*": {Success: 0.9, Request: 10},
+	})
+	b1, ok := r.breaker("/helloworld.Greeter/SayHello")
+	if !ok {
+		t.Fatal("expected a breaker of the matched operation")
+	}
+	if b2, _ := r.breaker("/helloworld.Greeter/SayHello"); b2 != b1 {
+		t.Error("expected the same breaker of the operation")
+	}
+	if b3, _ := r.breaker("/helloworld.Greeter/Search"); b3 == b1 {
+		t.Error("expected a breaker per operation")
+	}
+	if _, ok = r.breaker("/other.Service/Get"); ok {
+		t.Error("expected no breaker of the unmatched operation")
+	}
+	// breakers are recreated after the thresholds are updated
+	r.Update(map[string]*Threshold{"*": {Success: 0.5}})
+	if b4, ok := r.breaker("/helloworld.Greeter/SayHello"); !ok || b4 == b1 {
+		t.Error("expected a new breaker after update")
+	}
+	if _, ok = r.breaker("/other.Service/Get"); !ok {
+		t.Error("expected the default rule to match")
+	}
+}
+
+func TestClientWithRules(t *testing.T) {
+	r := NewRules(map[string]*Threshold{"/rules": {}})
+	mark := &circuitBreakerMock{err: errors.New("circuitbreaker error")}
+	ctx := transport.NewClientContext(context.Background(), &transportMock{operation: "/rules"})
+	// the breaker of the rules is used before the group
+	_, err := Client(WithRules(r), WithGroup(group.NewGroup(func() interface{} { return mark })))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+	if err != nil {
+		t.Errorf("expected the rules breaker to allow the request, got %v", err)
+	}
+	ctx = transport.NewClientContext(context.Background(), &transportMock{operation: "/group"})
+	_, err = Client(WithRules(r), WithGroup(group.NewGroup(func() interface{} { return mark })))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+	if !kratoserrors.IsServiceUnavailable(err) {
+		t.Errorf("expected the group breaker to reject the request, got %v", err)
+	}
+}
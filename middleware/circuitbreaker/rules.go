@@ -0,0 +1,106 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+
+	"github.com/cnsync/kratos/config"
+	"github.com/cnsync/kratos/internal/matcher"
+	"github.com/cnsync/kratos/log"
+)
+
+// Threshold is the thresholds of the SRE circuit breaker, zero values use the breaker defaults.
+type Threshold struct {
+	// Success is the success ratio below which requests start to be dropped.
+	Success float64 `json:"success"`
+	// Request is the minimum number of requests in the window before the breaker trips.
+	Request int64 `json:"request"`
+	// Window is the statistical window.
+	Window config.Duration `json:"window"`
+	// Bucket is the number of buckets in the window.
+	Bucket int `json:"bucket"`
+}
+
+// Rules holds the breaker thresholds keyed by operation selector, it can be updated at runtime.
+// A selector is a full operation, e.g. /helloworld.Greeter/SayHello, or a prefix ending with *,
+// e.g. /helloworld.Greeter/*; "*" matches all operations. Each operation has its own breaker.
+type Rules struct {
+	state atomic.Pointer[ruleState]
+}
+
+type ruleState struct {
+	rules    *matcher.Rules[*Threshold]
+	breakers sync.Map
+}
+
+// NewRules creates breaker rules with the given thresholds.
+func NewRules(thresholds map[string]*Threshold) *Rules {
+	r := &Rules{}
+	r.Update(thresholds)
+	return r
+}
+
+// LoadRules creates breaker rules from the config value of key and keeps them updated
+// when the value changes.
+//
+//	circuitbreaker:
+//	  "*":
+//	    success: 0.6
+//	  /helloworld.Greeter/SayHello:
+//	    success: 0.8
+//	    request: 100
+//	    window: 5s
+func LoadRules(c config.Config, key string) (*Rules, error) {
+	thresholds := make(map[string]*Threshold)
+	if err := c.Value(key).Scan(&thresholds); err != nil {
+		return nil, err
+	}
+	r := NewRules(thresholds)
+	if err := c.Watch(key, func(_ string, v config.Value) {
+		thresholds := make(map[string]*Threshold)
+		if err := v.Scan(&thresholds); err != nil {
+			log.Errorf("failed to reload circuit breaker rules: %v", err)
+			return
+		}
+		r.Update(thresholds)
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces all thresholds, the breakers are recreated with the new thresholds.
+func (r *Rules) Update(thresholds map[string]*Threshold) {
+	r.state.Store(&ruleState{rules: matcher.NewRules(thresholds)})
+}
+
+// breaker returns the breaker of the operation, false if no rule matches the operation.
+func (r *Rules) breaker(operation string) (circuitbreaker.CircuitBreaker, bool) {
+	state := r.state.Load()
+	if v, ok := state.breakers.Load(operation); ok {
+		return v.(circuitbreaker.CircuitBreaker), true
+	}
+	t, _, ok := state.rules.Match(operation)
+	if !ok || t == nil {
+		return nil, false
+	}
+	var opts []sre.Option
+	if t.Success > 0 {
+		opts = append(opts, sre.WithSuccess(t.Success))
+	}
+	if t.Request > 0 {
+		opts = append(opts, sre.WithRequest(t.Request))
+	}
+	if t.Window > 0 {
+		opts = append(opts, sre.WithWindow(time.Duration(t.Window)))
+	}
+	if t.Bucket > 0 {
+		opts = append(opts, sre.WithBucket(t.Bucket))
+	}
+	v, _ := state.breakers.LoadOrStore(operation, sre.NewBreaker(opts...))
+	return v.(circuitbreaker.CircuitBreaker), true
+}
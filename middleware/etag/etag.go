@@ -0,0 +1,216 @@
+package etag
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+const (
+	// Reason is the error reason of a version conflict.
+	Reason = "VERSION_CONFLICT"
+	// MetadataKey is the error metadata key carrying the current version.
+	MetadataKey = "version"
+)
+
+// ErrPreconditionFailed is returned when the If-Match version does not match the current version.
+var ErrPreconditionFailed = errors.New(412, "PRECONDITION_FAILED", "resource version does not match")
+
+// Conflict returns a version conflict error that handlers should return
+// when the stored version differs from the requested one.
+func Conflict(current string) *errors.Error {
+	return errors.Conflict(Reason, "resource version conflict").WithMetadata(map[string]string{MetadataKey: current})
+}
+
+// ConflictFunc reports whether err is a version conflict and returns the current version.
+type ConflictFunc func(err error) (current string, ok bool)
+
+// Option is etag option.
+type Option func(*options)
+
+type options struct {
+	field    string
+	conflict ConflictFunc
+}
+
+// WithField set the dot-separated path of the version field in request and reply messages,
+// default is "version".
+func WithField(path string) Option {
+	return func(o *options) {
+		o.field = path
+	}
+}
+
+// WithConflictFunc set the function used to detect version conflicts returned by handlers,
+// default matches errors created by Conflict.
+func WithConflictFunc(fn ConflictFunc) Option {
+	return func(o *options) {
+		o.conflict = fn
+	}
+}
+
+// Server is a server middleware that maps the If-Match header to the version field of
+// the request message, converts version conflicts into 412 Precondition Failed and
+// sets the ETag reply header from the version field of the reply message.
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{
+		field:    "version",
+		conflict: defaultConflict,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	path := strings.Split(o.field, ".")
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			if version := parseIfMatch(tr.RequestHeader().Get("If-Match")); version != "" {
+				if msg, ok := req.(proto.Message); ok {
+					if err := setField(msg.ProtoReflect(), path, version); err != nil {
+						return nil, errors.BadRequest("INVALID_VERSION", err.Error())
+					}
+				}
+			}
+			reply, err := handler(ctx, req)
+			if err != nil {
+				if current, ok := o.conflict(err); ok {
+					if current != "" {
+						tr.ReplyHeader().Set("ETag", quote(current))
+					}
+					return nil, ErrPreconditionFailed.WithCause(err).WithMetadata(map[string]string{MetadataKey: current})
+				}
+				return nil, err
+			}
+			if msg, ok := reply.(proto.Message); ok {
+				if version, ok := getField(msg.ProtoReflect(), path); ok && version != "" {
+					tr.ReplyHeader().Set("ETag", quote(version))
+				}
+			}
+			return reply, nil
+		}
+	}
+}
+
+func defaultConflict(err error) (string, bool) {
+	se := errors.FromError(err)
+	if se.Code != 409 || se.Reason != Reason {
+		return "", false
+	}
+	return se.Metadata[MetadataKey], true
+}
+
+// parseIfMatch returns the first entity tag of the If-Match header without quotes
+// and weak prefix, "*" matches any version and is ignored.
+func parseIfMatch(v string) string {
+	v = strings.TrimSpace(v)
+	if i := strings.IndexByte(v, ','); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	v = strings.TrimPrefix(v, "W/")
+	if v == "*" {
+		return ""
+	}
+	return strings.Trim(v, `"`)
+}
+
+func quote(v string) string {
+	return `"` + v + `"`
+}
+
+// lookup walks the message along path and returns the parent message and the last field.
+func lookup(m protoreflect.Message, path []string, mutable bool) (protoreflect.Message, protoreflect.FieldDescriptor, bool) {
+	for i, name := range path {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = m.Descriptor().Fields().ByJSONName(name)
+		}
+		if fd == nil || fd.IsList() || fd.IsMap() {
+			return nil, nil, false
+		}
+		if i == len(path)-1 {
+			return m, fd, true
+		}
+		if fd.Kind() != protoreflect.MessageKind {
+			return nil, nil, false
+		}
+		if mutable {
+			m = m.Mutable(fd).Message()
+		} else {
+			if !m.Has(fd) {
+				return nil, nil, false
+			}
+			m = m.Get(fd).Message()
+		}
+	}
+	return nil, nil, false
+}
+
+func setField(m protoreflect.Message, path []string, version string) error {
+	parent, fd, ok := lookup(m, path, true)
+	if !ok {
+		return nil
+	}
+	var v protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(version)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(version, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		v = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(version, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		v = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		v = protoreflect.ValueOfUint64(n)
+	default:
+		return fmt.Errorf("unsupported version field kind: %s", fd.Kind())
+	}
+	parent.Set(fd, v)
+	return nil
+}
+
+func getField(m protoreflect.Message, path []string) (string, bool) {
+	parent, fd, ok := lookup(m, path, false)
+	if !ok || !parent.Has(fd) {
+		return "", false
+	}
+	v := parent.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return v.String(), true
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(v.Int(), 10), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(v.Uint(), 10), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,127 @@
+package etag
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/testdata/complex"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type testTransport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return "/test" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *testTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func newContext(ifMatch string) (context.Context, *testTransport) {
+	tr := &testTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	if ifMatch != "" {
+		tr.reqHeader.Set("If-Match", ifMatch)
+	}
+	return transport.NewServerContext(context.Background(), tr), tr
+}
+
+func TestServer(t *testing.T) {
+	ctx, tr := newContext(`W/"7"`)
+	next := func(_ context.Context, req interface{}) (interface{}, error) {
+		in := req.(*complex.Complex)
+		if in.Id != 7 {
+			t.Errorf("expected version 7, got %d", in.Id)
+		}
+		return &complex.Complex{Id: 8}, nil
+	}
+	if _, err := Server(WithField("id"))(next)(ctx, &complex.Complex{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.replyHeader.Get("ETag"); got != `"8"` {
+		t.Errorf("expected ETag \"8\", got %s", got)
+	}
+}
+
+func TestServerNestedField(t *testing.T) {
+	ctx, tr := newContext(`"v1"`)
+	next := func(_ context.Context, req interface{}) (interface{}, error) {
+		in := req.(*complex.Complex)
+		if in.GetSimple().GetComponent() != "v1" {
+			t.Errorf("expected version v1, got %s", in.GetSimple().GetComponent())
+		}
+		return in, nil
+	}
+	if _, err := Server(WithField("very_simple.component"))(next)(ctx, &complex.Complex{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.replyHeader.Get("ETag"); got != `"v1"` {
+		t.Errorf("expected ETag \"v1\", got %s", got)
+	}
+}
+
+func TestServerConflict(t *testing.T) {
+	ctx, tr := newContext(`"1"`)
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return nil, Conflict("2")
+	}
+	_, err := Server(WithField("id"))(next)(ctx, &complex.Complex{})
+	se := errors.FromError(err)
+	if se.Code != 412 {
+		t.Fatalf("expected code 412, got %d", se.Code)
+	}
+	if se.Metadata[MetadataKey] != "2" {
+		t.Errorf("expected current version 2, got %s", se.Metadata[MetadataKey])
+	}
+	if got := tr.replyHeader.Get("ETag"); got != `"2"` {
+		t.Errorf("expected ETag \"2\", got %s", got)
+	}
+}
+
+func TestServerInvalidVersion(t *testing.T) {
+	ctx, _ := newContext(`"abc"`)
+	next := func(_ context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+	if _, err := Server(WithField("id"))(next)(ctx, &complex.Complex{}); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := map[string]string{
+		``:             "",
+		`*`:            "",
+		`"1"`:          "1",
+		`W/"abc"`:      "abc",
+		`"1", "2"`:     "1",
+		` "spaced" `:   "spaced",
+		`W/"x", W/"y"`: "x",
+	}
+	for in, want := range tests {
+		if got := parseIfMatch(in); got != want {
+			t.Errorf("parseIfMatch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Package fieldmask prunes proto replies to the fields selected by the
+// google.protobuf.FieldMask carried in the request, e.g. a read_mask.
+package fieldmask
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// Reason is the error reason returned when the field mask has unknown paths.
+const Reason = "INVALID_FIELD_MASK"
+
+var fieldMaskName = (&fieldmaskpb.FieldMask{}).ProtoReflect().Descriptor().FullName()
+
+// Option is field mask option.
+type Option func(*options)
+
+type options struct {
+	field protoreflect.Name
+}
+
+// WithField sets the name of the request field holding the mask, e.g. read_mask.
+// By default, the first field of type google.protobuf.FieldMask is used.
+func WithField(name string) Option {
+	return func(o *options) {
+		o.field = protoreflect.Name(name)
+	}
+}
+
+// Server is a server middleware that prunes the proto reply to the paths of
+// the field mask in the request. Requests without a mask, or with an empty
+// mask, get the full reply. The mask is validated before the handler runs
+// when the reply type of the operation is found in the proto registry, and
+// a pruned copy is returned, so the reply owned by the handler is untouched.
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			mask := o.mask(req)
+			if len(mask.GetPaths()) == 0 {
+				return handler(ctx, req)
+			}
+			t := newTree(mask)
+			validated := false
+			if md := output(ctx); md != nil {
+				if err := t.validate(md, ""); err != nil {
+					return nil, err
+				}
+				validated = true
+			}
+			reply, err := handler(ctx, req)
+			if err != nil {
+				return reply, err
+			}
+			m, ok := reply.(proto.Message)
+			if !ok {
+				return reply, nil
+			}
+			msg := m.ProtoReflect()
+			if !validated {
+				if err := t.validate(msg.Descriptor(), ""); err != nil {
+					return nil, err
+				}
+			}
+			if !msg.IsValid() {
+				return reply, nil
+			}
+			m = proto.Clone(m)
+			t.prune(m.ProtoReflect())
+			return m, nil
+		}
+	}
+}
+
+// output returns the reply descriptor of the operation, e.g. /helloworld.Greeter/SayHello,
+// or nil if the method is not registered.
+func output(ctx context.Context) protoreflect.MessageDescriptor {
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return nil
+	}
+	name := strings.ReplaceAll(strings.TrimPrefix(tr.Operation(), "/"), "/", ".")
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil
+	}
+	if md, ok := d.(protoreflect.MethodDescriptor); ok {
+		return md.Output()
+	}
+	return nil
+}
+
+// mask returns the field mask in the request.
+func (o *options) mask(req interface{}) *fieldmaskpb.FieldMask {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	msg := m.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() == nil || fd.Message().FullName() != fieldMaskName || fd.IsList() {
+			continue
+		}
+		if o.field != "" && fd.Name() != o.field {
+			continue
+		}
+		if !msg.Has(fd) {
+			return nil
+		}
+		mask, _ := msg.Get(fd).Message().Interface().(*fieldmaskpb.FieldMask)
+		return mask
+	}
+	return nil
+}
+
+// tree is the field mask paths as a tree, a leaf selects the whole field.
+type tree map[protoreflect.Name]tree
+
+// Prune clears the fields of the message not selected by the mask in place.
+// Paths in nested repeated and map messages apply to each element.
+// It returns a bad request error if a path does not exist in the message.
+func Prune(m proto.Message, mask *fieldmaskpb.FieldMask) error {
+	t := newTree(mask)
+	msg := m.ProtoReflect()
+	if err := t.validate(msg.Descriptor(), ""); err != nil {
+		return err
+	}
+	t.prune(msg)
+	return nil
+}
+
+// newTree builds the tree of the mask paths.
+func newTree(mask *fieldmaskpb.FieldMask) tree {
+	t := tree{}
+	for _, path := range mask.GetPaths() {
+		node := t
+		for _, name := range strings.Split(path, ".") {
+			child, ok := node[protoreflect.Name(name)]
+			if !ok {
+				child = tree{}
+				node[protoreflect.Name(name)] = child
+			}
+			node = child
+		}
+	}
+	return t
+}
+
+// validate checks that every path of the tree exists in the message descriptor.
+func (t tree) validate(md protoreflect.MessageDescriptor, prefix string) error {
+	for name, child := range t {
+		fd := md.Fields().ByName(name)
+		if fd == nil {
+			return errors.BadRequest(Reason, "unknown field mask path: "+prefix+string(name))
+		}
+		if len(child) == 0 {
+			continue
+		}
+		sub := fd.Message()
+		if fd.IsMap() {
+			sub = fd.MapValue().Message()
+		}
+		if sub == nil {
+			return errors.BadRequest(Reason, "field mask path is not a message: "+prefix+string(name))
+		}
+		if err := child.validate(sub, prefix+string(name)+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune clears the fields of the message not in the tree.
+func (t tree) prune(msg protoreflect.Message) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		child, ok := t[fd.Name()]
+		switch {
+		case !ok:
+			msg.Clear(fd)
+		case len(child) == 0:
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				child.prune(mv.Message())
+				return true
+			})
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				child.prune(list.Get(i).Message())
+			}
+		default:
+			child.prune(v.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,93 @@
+package fieldmask
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/testdata/complex"
+)
+
+func newReply() *complex.Complex {
+	return &complex.Complex{
+		Id:      1,
+		NoOne:   "one",
+		Simple:  &complex.Simple{Component: "c"},
+		Simples: []string{"a"},
+		Map:     map[string]string{"k": "v"},
+	}
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  *complex.Complex
+	}{
+		{"no mask", nil, newReply()},
+		{"top level", []string{"id", "map"}, &complex.Complex{Id: 1, Map: map[string]string{"k": "v"}}},
+		{"nested", []string{"simple.component"}, &complex.Complex{Simple: &complex.Simple{Component: "c"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &complex.Complex{}
+			if test.paths != nil {
+				req.Field = &fieldmaskpb.FieldMask{Paths: test.paths}
+			}
+			next := func(context.Context, interface{}) (interface{}, error) { return newReply(), nil }
+			reply, err := Server()(next)(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := reply.(*complex.Complex)
+			if got.Id != test.want.Id || got.NoOne != test.want.NoOne || len(got.Simples) != len(test.want.Simples) ||
+				len(got.Map) != len(test.want.Map) || got.GetSimple().GetComponent() != test.want.GetSimple().GetComponent() {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestServerInvalidPath(t *testing.T) {
+	req := &complex.Complex{Field: &fieldmaskpb.FieldMask{Paths: []string{"simple.unknown"}}}
+	next := func(context.Context, interface{}) (interface{}, error) { return newReply(), nil }
+	_, err := Server()(next)(context.Background(), req)
+	if !errors.IsBadRequest(err) || errors.Reason(err) != Reason {
+		t.Errorf("expected bad request, got %v", err)
+	}
+	req.Field.Paths = []string{"id.value"}
+	_, err = Server()(next)(context.Background(), req)
+	if errors.Reason(err) != Reason {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestWithField(t *testing.T) {
+	req := &complex.Complex{Field: &fieldmaskpb.FieldMask{Paths: []string{"id"}}}
+	next := func(context.Context, interface{}) (interface{}, error) { return newReply(), nil }
+	reply, err := Server(WithField("read_mask"))(next)(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.(*complex.Complex).NoOne != "one" {
+		t.Errorf("expected reply not pruned by other fields")
+	}
+}
+
+func TestServerKeepsReply(t *testing.T) {
+	req := &complex.Complex{Field: &fieldmaskpb.FieldMask{Paths: []string{"id"}}}
+	shared := newReply()
+	next := func(context.Context, interface{}) (interface{}, error) { return shared, nil }
+	reply, err := Server()(next)(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.(*complex.Complex).NoOne != "" {
+		t.Errorf("expected reply pruned, got %v", reply)
+	}
+	if shared.NoOne != "one" {
+		t.Errorf("expected handler reply untouched, got %v", shared)
+	}
+}
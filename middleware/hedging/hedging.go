@@ -0,0 +1,201 @@
+package hedging
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/selector"
+	"github.com/cnsync/kratos/transport"
+	thttp "github.com/cnsync/kratos/transport/http"
+)
+
+const (
+	// window is the number of recent latencies kept for each operation.
+	window = 128
+	// minSamples is the number of latencies required before the percentile is used.
+	minSamples = 20
+)
+
+// Option is hedging option.
+type Option func(*options)
+
+// WithDelay with the delay before a backup request is issued,
+// it is also used until enough latencies are observed for the percentile. Default is 100ms.
+func WithDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.delay = delay
+	}
+}
+
+// WithPercentile with the percentile of the recent latencies of the operation used as the delay,
+// e.g. 0.95 issues a backup request when the call is slower than 95% of the recent calls.
+// Zero disables the percentile and always uses the fixed delay.
+func WithPercentile(percentile float64) Option {
+	return func(o *options) {
+		o.percentile = percentile
+	}
+}
+
+// WithMaxAttempts with the max number of attempts of a call, including the original request. Default is 2.
+func WithMaxAttempts(attempts int) Option {
+	return func(o *options) {
+		o.attempts = attempts
+	}
+}
+
+// WithOperations with the idempotent operations that could be hedged,
+// by default only the GET, HEAD and OPTIONS requests of the HTTP client are hedged.
+func WithOperations(operations ...string) Option {
+	return func(o *options) {
+		for _, operation := range operations {
+			o.operations[operation] = struct{}{}
+		}
+	}
+}
+
+type options struct {
+	delay      time.Duration
+	percentile float64
+	attempts   int
+	operations map[string]struct{}
+}
+
+// idempotent reports whether the call could be hedged.
+func (o *options) idempotent(tr transport.Transporter) bool {
+	if len(o.operations) > 0 {
+		_, ok := o.operations[tr.Operation()]
+		return ok
+	}
+	if ht, ok := tr.(thttp.Transporter); ok && ht.Request() != nil {
+		switch ht.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return true
+		}
+	}
+	return false
+}
+
+// Client is a client middleware that issues a backup request of an idempotent call
+// if it has not completed after the delay, the first successful reply wins and the others are canceled.
+// Backup requests prefer the nodes that are not picked by the previous attempts of the call.
+//
+// Hedging should be the last client middleware, the middlewares after it run once per attempt
+// concurrently and must not modify the request header.
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		delay:      100 * time.Millisecond,
+		attempts:   2,
+		operations: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var latencies sync.Map
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok || o.attempts < 2 || !o.idempotent(tr) {
+				return handler(ctx, req)
+			}
+			v, _ := latencies.LoadOrStore(tr.Operation(), &stats{})
+			s := v.(*stats)
+			delay := o.delay
+			if o.percentile > 0 {
+				if d, ok := s.percentile(o.percentile); ok {
+					delay = d
+				}
+			}
+			return hedge(ctx, req, handler, delay, o.attempts, s)
+		}
+	}
+}
+
+type result struct {
+	reply   interface{}
+	err     error
+	peer    *selector.Peer
+	latency time.Duration
+}
+
+// hedge calls the handler and issues a backup request after each delay until the attempts are exhausted.
+func hedge(ctx context.Context, req interface{}, handler middleware.Handler, delay time.Duration, attempts int, s *stats) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	actx := selector.NewPickedContext(transport.WithConcurrentAttempts(ctx))
+	results := make(chan result, attempts)
+	launch := func() {
+		p := new(selector.Peer)
+		go func() {
+			start := time.Now()
+			reply, err := handler(selector.NewPeerContext(actx, p), req)
+			results <- result{reply: reply, err: err, peer: p, latency: time.Since(start)}
+		}()
+	}
+	launch()
+	launched, inflight := 1, 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			launch()
+			launched++
+			inflight++
+			if launched < attempts {
+				timer.Reset(delay)
+			}
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				s.observe(r.latency)
+				if p, ok := selector.FromPeerContext(ctx); ok {
+					p.Node = r.peer.Node
+				}
+				return r.reply, nil
+			}
+			if inflight == 0 {
+				return nil, r.err
+			}
+		}
+	}
+}
+
+// stats keeps the recent latencies of an operation.
+type stats struct {
+	mu        sync.Mutex
+	latencies [window]time.Duration
+	next      int
+	count     int
+}
+
+func (s *stats) observe(latency time.Duration) {
+	s.mu.Lock()
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % window
+	if s.count < window {
+		s.count++
+	}
+	s.mu.Unlock()
+}
+
+// percentile returns the percentile of the recent latencies, false if there are not enough samples.
+func (s *stats) percentile(p float64) (time.Duration, bool) {
+	s.mu.Lock()
+	if s.count < minSamples {
+		s.mu.Unlock()
+		return 0, false
+	}
+	latencies := make([]time.Duration, s.count)
+	copy(latencies, s.latencies[:s.count])
+	s.mu.Unlock()
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	i := int(p * float64(len(latencies)))
+	if i >= len(latencies) {
+		i = len(latencies) - 1
+	}
+	return latencies[i], true
+}
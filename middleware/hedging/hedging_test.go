@@ -0,0 +1,191 @@
+package hedging
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/selector"
+	"github.com/cnsync/kratos/transport"
+	thttp "github.com/cnsync/kratos/transport/http"
+)
+
+type transportMock struct {
+	operation string
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindGRPC
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return nil
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return nil
+}
+
+func TestHedging(t *testing.T) {
+	var calls int32
+	canceled := make(chan struct{})
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if !transport.ConcurrentAttempts(ctx) {
+			t.Error("expected concurrent attempts")
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		}
+		return "backup", nil
+	}
+	ctx := transport.NewClientContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	m := Client(WithDelay(10*time.Millisecond), WithOperations("/helloworld.Greeter/SayHello"))
+	reply, err := m(handler)(ctx, nil)
+	if err != nil || reply != "backup" {
+		t.Fatalf("unexpected reply %v, %v", reply, err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the original request to be canceled")
+	}
+}
+
+func TestHedgingFastReply(t *testing.T) {
+	var calls int32
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "reply", nil
+	}
+	ctx := transport.NewClientContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	m := Client(WithDelay(50*time.Millisecond), WithOperations("/helloworld.Greeter/SayHello"))
+	if _, err := m(handler)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected 1 call, got %d", n)
+	}
+}
+
+func TestHedgingError(t *testing.T) {
+	errFailed := errors.New("failed")
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, errFailed
+	}
+	ctx := transport.NewClientContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	m := Client(WithDelay(time.Hour), WithOperations("/helloworld.Greeter/SayHello"))
+	if _, err := m(handler)(ctx, nil); !errors.Is(err, errFailed) {
+		t.Errorf("expected %v, got %v", errFailed, err)
+	}
+}
+
+func TestHedgingNotIdempotent(t *testing.T) {
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if transport.ConcurrentAttempts(ctx) {
+			t.Error("unexpected concurrent attempts")
+		}
+		return "reply", nil
+	}
+	ctx := transport.NewClientContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/CreateHello"})
+	m := Client(WithOperations("/helloworld.Greeter/SayHello"))
+	if _, err := m(handler)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 未配置操作时仅对冲 HTTP 的安全方法
+	if _, err := m(handler)(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHedgingPeer(t *testing.T) {
+	node := selector.NewNode("grpc", "127.0.0.1:9000", nil)
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		p, _ := selector.FromPeerContext(ctx)
+		p.Node = node
+		return "reply", nil
+	}
+	var p selector.Peer
+	ctx := selector.NewPeerContext(context.Background(), &p)
+	ctx = transport.NewClientContext(ctx, &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	m := Client(WithOperations("/helloworld.Greeter/SayHello"))
+	if _, err := m(handler)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if p.Node != node {
+		t.Errorf("expected peer %v, got %v", node, p.Node)
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := &stats{}
+	if _, ok := s.percentile(0.9); ok {
+		t.Error("expected not enough samples")
+	}
+	for i := 1; i <= 100; i++ {
+		s.observe(time.Duration(i) * time.Millisecond)
+	}
+	if d, ok := s.percentile(0.9); !ok || d != 91*time.Millisecond {
+		t.Errorf("unexpected percentile %v", d)
+	}
+	for i := 0; i < window; i++ {
+		s.observe(time.Second)
+	}
+	if d, _ := s.percentile(0.5); d != time.Second {
+		t.Errorf("expected old samples to be evicted, got %v", d)
+	}
+}
+
+type helloReply struct {
+	Message string `json:"message"`
+}
+
+func TestHedgingHTTPClient(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Attempt", "backup")
+		_, _ = w.Write([]byte(`{"message":"backup"}`))
+	}))
+	defer srv.Close()
+
+	client, err := thttp.NewClient(context.Background(),
+		thttp.WithEndpoint(srv.Listener.Addr().String()),
+		thttp.WithMiddleware(Client(WithDelay(10*time.Millisecond))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var (
+		reply  helloReply
+		header http.Header
+	)
+	if err := client.Invoke(context.Background(), http.MethodGet, "/hello", nil, &reply, thttp.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "backup" || header.Get("X-Attempt") != "backup" {
+		t.Errorf("unexpected reply %v, header %v", reply, header)
+	}
+}
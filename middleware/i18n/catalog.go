@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"strings"
+	"sync"
+)
+
+var _ Translator = (*Catalog)(nil)
+
+// Catalog is an in-memory Translator keyed by locale and error reason.
+// Messages may reference the error metadata as {key}, e.g. "user {id} not found".
+// Lookups fall back from a regional locale to its language, e.g. zh-CN to zh.
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+// NewCatalog returns an empty catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{messages: make(map[string]map[string]string)}
+}
+
+// Add adds the messages of error reasons in the locale.
+func (c *Catalog) Add(locale string, messages map[string]string) *Catalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	locale = strings.ToLower(locale)
+	m, ok := c.messages[locale]
+	if !ok {
+		m = make(map[string]string, len(messages))
+		c.messages[locale] = m
+	}
+	for reason, msg := range messages {
+		m[reason] = msg
+	}
+	return c
+}
+
+// Translate returns the message of the error reason in the locale.
+func (c *Catalog) Translate(locale, reason string, metadata map[string]string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locale = strings.ToLower(locale)
+	for {
+		if msg, ok := c.messages[locale][reason]; ok {
+			return expand(msg, metadata), true
+		}
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			return "", false
+		}
+		locale = locale[:i]
+	}
+}
+
+// expand replaces the {key} placeholders with the metadata values.
+func expand(msg string, metadata map[string]string) string {
+	if len(metadata) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	kvs := make([]string, 0, len(metadata)*2)
+	for k, v := range metadata {
+		kvs = append(kvs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(kvs...).Replace(msg)
+}
@@ -0,0 +1,24 @@
+package i18n
+
+import (
+	"net/http"
+
+	khttp "github.com/cnsync/kratos/transport/http"
+)
+
+// ErrorEncoder wraps the HTTP error encoder to localize the error messages by the
+// locale of the request, including the errors returned before the middleware runs,
+// e.g. binding errors. The locale in the request context set by Server takes precedence.
+func ErrorEncoder(t Translator, next khttp.EncodeErrorFunc, opts ...Option) khttp.EncodeErrorFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		locale, ok := FromContext(r.Context())
+		if !ok {
+			locale = o.match(r.Header.Get(o.header))
+		}
+		if locale != "" {
+			err = Localize(t, locale, err)
+		}
+		next(w, r, err)
+	}
+}
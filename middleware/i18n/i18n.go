@@ -0,0 +1,178 @@
+// Package i18n extracts the locale of requests and localizes the messages of errors.
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// Translator translates the messages of errors.
+type Translator interface {
+	// Translate returns the message of the error reason in the locale,
+	// ok is false if there is no translation.
+	Translate(locale, reason string, metadata map[string]string) (msg string, ok bool)
+}
+
+type localeKey struct{}
+
+// NewContext returns a new context with the locale.
+func NewContext(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the locale in the context.
+func FromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// Option is i18n option.
+type Option func(*options)
+
+// WithHeader with the request header or metadata key holding the locale, default is Accept-Language.
+func WithHeader(key string) Option {
+	return func(o *options) {
+		o.header = key
+	}
+}
+
+// WithSupported with the supported locales, the request locale is matched against
+// them by language range, e.g. zh-CN matches zh. Any locale is accepted if none is given.
+func WithSupported(locales ...string) Option {
+	return func(o *options) {
+		o.supported = locales
+	}
+}
+
+// WithDefault with the locale used when the request has no acceptable locale.
+func WithDefault(locale string) Option {
+	return func(o *options) {
+		o.fallback = locale
+	}
+}
+
+// WithTranslator with the translator localizing the messages of the returned errors.
+func WithTranslator(t Translator) Option {
+	return func(o *options) {
+		o.translator = t
+	}
+}
+
+type options struct {
+	header     string
+	supported  []string
+	fallback   string
+	translator Translator
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{header: "Accept-Language"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Server is a server middleware that stores the locale of the request in the
+// context, and localizes the message of the returned error if a translator is given.
+func Server(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			locale := o.fallback
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				if l := o.match(tr.RequestHeader().Get(o.header)); l != "" {
+					locale = l
+				}
+			}
+			if locale != "" {
+				ctx = NewContext(ctx, locale)
+			}
+			reply, err := handler(ctx, req)
+			if err != nil && o.translator != nil && locale != "" {
+				err = Localize(o.translator, locale, err)
+			}
+			return reply, err
+		}
+	}
+}
+
+// Localize returns a copy of the error with the message translated to the locale,
+// or the error itself if there is no translation.
+func Localize(t Translator, locale string, err error) error {
+	se := errors.FromError(err)
+	if se == nil {
+		return err
+	}
+	msg, ok := t.Translate(locale, se.Reason, se.Metadata)
+	if !ok || msg == se.Message {
+		return err
+	}
+	le := errors.Clone(se)
+	le.Message = msg
+	return le
+}
+
+// match returns the best locale of the Accept-Language value, or the default
+// locale if none is acceptable.
+func (o *options) match(accept string) string {
+	for _, tag := range parseAcceptLanguage(accept) {
+		if len(o.supported) == 0 {
+			if tag != "*" {
+				return tag
+			}
+			continue
+		}
+		for _, s := range o.supported {
+			if tag == "*" || matchRange(tag, s) {
+				return s
+			}
+		}
+	}
+	return o.fallback
+}
+
+// matchRange reports whether the language range matches the locale,
+// e.g. zh matches zh-CN and zh-CN matches zh.
+func matchRange(tag, locale string) bool {
+	tag, locale = strings.ToLower(tag), strings.ToLower(locale)
+	return tag == locale || strings.HasPrefix(locale, tag+"-") || strings.HasPrefix(tag, locale+"-")
+}
+
+// parseAcceptLanguage returns the language tags of the Accept-Language value
+// ordered by quality, tags with zero quality are dropped.
+func parseAcceptLanguage(accept string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(accept, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	res := make([]string, len(tags))
+	for i, t := range tags {
+		res[i] = t.tag
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type testTransport struct {
+	transport.Transporter
+	header headerCarrier
+}
+
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+
+func newContext(accept string) context.Context {
+	h := headerCarrier{}
+	h.Set("Accept-Language", accept)
+	return transport.NewServerContext(context.Background(), &testTransport{header: h})
+}
+
+func newCatalog() *Catalog {
+	return NewCatalog().
+		Add("zh", map[string]string{"USER_NOT_FOUND": "用户 {id} 不存在"}).
+		Add("fr-CA", map[string]string{"USER_NOT_FOUND": "utilisateur {id} introuvable"})
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		accept string
+		locale string
+		msg    string
+	}{
+		{"zh-CN,zh;q=0.9,en;q=0.8", "zh-CN", "用户 1 不存在"},
+		{"en;q=0.5, fr-CA", "fr-CA", "utilisateur 1 introuvable"},
+		{"de", "de", "user not found"},
+		{"", "", "user not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			var locale string
+			next := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				locale, _ = FromContext(ctx)
+				return nil, errors.NotFound("USER_NOT_FOUND", "user not found").WithMetadata(map[string]string{"id": "1"})
+			}
+			_, err := Server(WithTranslator(newCatalog()))(next)(newContext(test.accept), nil)
+			if locale != test.locale {
+				t.Errorf("expected locale %q, got %q", test.locale, locale)
+			}
+			if se := errors.FromError(err); se.Message != test.msg || se.Code != 404 {
+				t.Errorf("expected %q, got %v", test.msg, err)
+			}
+		})
+	}
+}
+
+func TestServerSupported(t *testing.T) {
+	o := newOptions([]Option{WithSupported("en-US", "zh-CN"), WithDefault("en-US")})
+	tests := map[string]string{
+		"zh":             "zh-CN",
+		"zh-TW":          "en-US",
+		"fr, en;q=0.1":   "en-US",
+		"fr":             "en-US",
+		"*":              "en-US",
+		"zh-CN;q=0, en ": "en-US",
+	}
+	for accept, want := range tests {
+		if got := o.match(accept); got != want {
+			t.Errorf("match(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestErrorEncoder(t *testing.T) {
+	var got error
+	enc := ErrorEncoder(newCatalog(), func(_ http.ResponseWriter, _ *http.Request, err error) { got = err })
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "zh")
+	enc(httptest.NewRecorder(), r, errors.NotFound("USER_NOT_FOUND", "user not found"))
+	if errors.FromError(got).Message != "用户 {id} 不存在" {
+		t.Errorf("unexpected error %v", got)
+	}
+
+	// the locale in the context takes precedence
+	r = r.WithContext(NewContext(r.Context(), "fr-CA"))
+	enc(httptest.NewRecorder(), r, errors.NotFound("USER_NOT_FOUND", "user not found"))
+	if errors.FromError(got).Message != "utilisateur {id} introuvable" {
+		t.Errorf("unexpected error %v", got)
+	}
+}
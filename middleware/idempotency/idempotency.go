@@ -0,0 +1,223 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/middleware/auth/jwt"
+	"github.com/cnsync/kratos/transport"
+)
+
+const (
+	// DefaultHeader is the request header that carries the idempotency key.
+	DefaultHeader = "Idempotency-Key"
+	// ReplayedHeader is the reply header set when the cached response is returned.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+var (
+	// ErrInProgress is returned when a request with the same idempotency key is still being processed.
+	ErrInProgress = errors.Conflict("IDEMPOTENCY_KEY_IN_USE", "a request with the same idempotency key is in progress")
+	// ErrMismatch is returned when the idempotency key is reused with a different request payload.
+	ErrMismatch = errors.New(422, "IDEMPOTENCY_KEY_REUSED", "the idempotency key is reused with a different request")
+	// ErrStore is returned when the store fails.
+	ErrStore = errors.InternalServer("IDEMPOTENCY_STORE", "idempotency store failed")
+)
+
+// Store stores the responses of the idempotency keys.
+// The methods map to the Redis commands GET, SET NX PX, SET PX and DEL,
+// so a Redis client could be adapted to share the responses between instances.
+type Store interface {
+	// Get returns the value of the key, false if the key does not exist or has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// SetNX sets the value of the key only if the key does not exist, and reports whether it is set.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Set sets the value of the key.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete deletes the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Option is idempotency option.
+type Option func(*options)
+
+// WithHeader with the request header that carries the idempotency key. Default is Idempotency-Key.
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithTTL with how long the response is cached for retries. Default is 24h.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithLockTTL with how long the key is locked while the request is in progress. Default is 1m.
+// The lock expires if the instance crashes before the response is cached, so the clients could retry;
+// it should be longer than the timeout of the operation.
+func WithLockTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.lockTTL = ttl
+	}
+}
+
+// WithCaller with the function returning the identity of the caller, which scopes the idempotency keys
+// so a caller never gets the response of another caller reusing the same key.
+// By default it is the subject of the jwt claims, or the Authorization header, e.g. an API key.
+func WithCaller(f func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.caller = f
+	}
+}
+
+type options struct {
+	header  string
+	ttl     time.Duration
+	lockTTL time.Duration
+	caller  func(ctx context.Context) string
+}
+
+// Server is a server middleware that deduplicates the requests carrying the same idempotency key.
+// The first successful response of the operation is cached in the store and returned for the retries
+// within the TTL, while a retry arriving before the first request completes gets ErrInProgress.
+// A retry with the same key but a different request payload gets ErrMismatch.
+// The keys are scoped by the operation and the caller, see WithCaller.
+// Requests without the key and failed requests are not cached, so the clients could retry after errors.
+// Only proto message replies are cached, which are what the generated services return.
+func Server(store Store, opts ...Option) middleware.Middleware {
+	o := &options{
+		header:  DefaultHeader,
+		ttl:     24 * time.Hour,
+		lockTTL: time.Minute,
+		caller:  defaultCaller,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			id := tr.RequestHeader().Get(o.header)
+			if id == "" {
+				return handler(ctx, req)
+			}
+			// the caller is hashed so the credentials are not stored in the keys
+			caller := sha256.Sum256([]byte(o.caller(ctx)))
+			key := tr.Operation() + ":" + hex.EncodeToString(caller[:]) + ":" + id
+			sum, err := fingerprint(req)
+			if err != nil {
+				return nil, ErrStore.WithCause(err)
+			}
+			// the fingerprint alone marks the request is in progress
+			set, err := store.SetNX(ctx, key, sum, o.lockTTL)
+			if err != nil {
+				return nil, ErrStore.WithCause(err)
+			}
+			if !set {
+				return replay(ctx, store, key, sum, tr)
+			}
+			// the store is updated even if the request is canceled, otherwise the key stays locked
+			sctx := context.WithoutCancel(ctx)
+			reply, err := handler(ctx, req)
+			if err != nil {
+				_ = store.Delete(sctx, key)
+				return reply, err
+			}
+			m, ok := reply.(proto.Message)
+			if !ok {
+				_ = store.Delete(sctx, key)
+				return reply, nil
+			}
+			// release the key if the response could not be cached, the handler has succeeded anyway
+			data, err := marshal(m)
+			if err == nil {
+				err = store.Set(sctx, key, append(sum, data...), o.ttl)
+			}
+			if err != nil {
+				_ = store.Delete(sctx, key)
+			}
+			return reply, nil
+		}
+	}
+}
+
+// defaultCaller returns the subject of the jwt claims, or the Authorization header of the request.
+func defaultCaller(ctx context.Context) string {
+	if claims, ok := jwt.FromContext(ctx); ok && claims != nil {
+		if sub, err := claims.GetSubject(); err == nil && sub != "" {
+			return "sub:" + sub
+		}
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if auth := tr.RequestHeader().Get("Authorization"); auth != "" {
+			return "authorization:" + auth
+		}
+	}
+	return ""
+}
+
+// replay returns the cached response of the key.
+func replay(ctx context.Context, store Store, key string, sum []byte, tr transport.Transporter) (interface{}, error) {
+	data, ok, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, ErrStore.WithCause(err)
+	}
+	if !ok {
+		return nil, ErrInProgress
+	}
+	if len(data) < len(sum) || !bytes.Equal(data[:len(sum)], sum) {
+		return nil, ErrMismatch
+	}
+	if len(data) == len(sum) {
+		return nil, ErrInProgress
+	}
+	var a anypb.Any
+	if err := proto.Unmarshal(data[len(sum):], &a); err != nil {
+		return nil, ErrStore.WithCause(err)
+	}
+	reply, err := a.UnmarshalNew()
+	if err != nil {
+		return nil, ErrStore.WithCause(err)
+	}
+	if header := tr.ReplyHeader(); header != nil {
+		header.Set(ReplayedHeader, "true")
+	}
+	return reply, nil
+}
+
+// fingerprint returns the SHA-256 digest of the request payload, which is stored in front of the response.
+// Proto messages are encoded deterministically, other requests have the digest of an empty payload.
+func fingerprint(req interface{}) ([]byte, error) {
+	var data []byte
+	if m, ok := req.(proto.Message); ok {
+		var err error
+		if data, err = (proto.MarshalOptions{Deterministic: true}).Marshal(m); err != nil {
+			return nil, err
+		}
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// marshal encodes the reply with its type.
+func marshal(reply proto.Message) ([]byte, error) {
+	a, err := anypb.New(reply)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(a)
+}
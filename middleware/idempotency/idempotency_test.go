@@ -0,0 +1,247 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	kerrors "github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware/auth/jwt"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type transportMock struct {
+	operation   string
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+func newContext(key string) (context.Context, *transportMock) {
+	tr := &transportMock{
+		operation:   "/helloworld.Greeter/CreateHello",
+		reqHeader:   headerCarrier{},
+		replyHeader: headerCarrier{},
+	}
+	if key != "" {
+		tr.reqHeader.Set(DefaultHeader, key)
+	}
+	return transport.NewServerContext(context.Background(), tr), tr
+}
+
+func TestServer(t *testing.T) {
+	var calls int
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.String("created"), nil
+	}
+	m := Server(NewMemoryStore(100))(handler)
+
+	ctx, _ := newContext("key-1")
+	if _, err := m(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, tr := newContext("key-1")
+	reply, err := m(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(reply.(proto.Message), wrapperspb.String("created")) {
+		t.Errorf("unexpected reply %v", reply)
+	}
+	if tr.replyHeader.Get(ReplayedHeader) != "true" {
+		t.Errorf("expected %s header", ReplayedHeader)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	// 不同的键或没有键时正常处理
+	ctx, _ = newContext("key-2")
+	_, _ = m(ctx, nil)
+	ctx, _ = newContext("")
+	_, _ = m(ctx, nil)
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestServerCaller(t *testing.T) {
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		claims, _ := jwt.FromContext(ctx)
+		sub, _ := claims.GetSubject()
+		return wrapperspb.String(sub), nil
+	}
+	m := Server(NewMemoryStore(100))(handler)
+	for _, sub := range []string{"alice", "bob"} {
+		ctx, tr := newContext("key-1")
+		reply, err := m(jwt.NewContext(ctx, jwtv5.RegisteredClaims{Subject: sub}), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := reply.(*wrapperspb.StringValue).GetValue(); got != sub || tr.replyHeader.Get(ReplayedHeader) != "" {
+			t.Errorf("expected the reply of %s, got %s", sub, got)
+		}
+	}
+
+	// 没有 jwt 时使用 Authorization 区分调用方
+	m = Server(NewMemoryStore(100))(func(context.Context, interface{}) (interface{}, error) {
+		return wrapperspb.String("created"), nil
+	})
+	ctx, tr := newContext("key-1")
+	tr.reqHeader.Set("Authorization", "Bearer a")
+	_, _ = m(ctx, nil)
+	ctx, tr = newContext("key-1")
+	tr.reqHeader.Set("Authorization", "Bearer b")
+	if _, _ = m(ctx, nil); tr.replyHeader.Get(ReplayedHeader) != "" {
+		t.Error("expected another caller not to get the replayed response")
+	}
+}
+
+func TestServerInProgress(t *testing.T) {
+	store := NewMemoryStore(100)
+	var m func(context.Context, interface{}) (interface{}, error)
+	m = Server(store)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		// 第一个请求完成前的重试
+		rctx, _ := newContext("key-1")
+		if _, err := m(rctx, nil); !errors.Is(err, ErrInProgress) || !kerrors.IsConflict(err) {
+			t.Errorf("expected %v, got %v", ErrInProgress, err)
+		}
+		return wrapperspb.String("created"), nil
+	})
+	ctx, _ := newContext("key-1")
+	if _, err := m(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	var calls int
+	errFailed := kerrors.InternalServer("FAILED", "failed")
+	m := Server(NewMemoryStore(100))(func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errFailed
+		}
+		return wrapperspb.String("created"), nil
+	})
+	ctx, _ := newContext("key-1")
+	if _, err := m(ctx, nil); !errors.Is(err, errFailed) {
+		t.Fatalf("expected %v, got %v", errFailed, err)
+	}
+	// 失败的请求不缓存，可以重试
+	ctx, _ = newContext("key-1")
+	if _, err := m(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestServerMismatch(t *testing.T) {
+	var calls int
+	m := Server(NewMemoryStore(100))(func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.String("created"), nil
+	})
+	ctx, _ := newContext("key-1")
+	if _, err := m(ctx, wrapperspb.String("a")); err != nil {
+		t.Fatal(err)
+	}
+	// 相同的键携带不同的请求
+	ctx, _ = newContext("key-1")
+	if _, err := m(ctx, wrapperspb.String("b")); !errors.Is(err, ErrMismatch) || kerrors.Code(err) != 422 {
+		t.Errorf("expected %v, got %v", ErrMismatch, err)
+	}
+	ctx, _ = newContext("key-1")
+	if _, err := m(ctx, wrapperspb.String("a")); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+type recordStore struct {
+	Store
+	lockTTL   time.Duration
+	deleteErr error
+}
+
+func (s *recordStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.lockTTL = ttl
+	return s.Store.SetNX(ctx, key, value, ttl)
+}
+
+func (s *recordStore) Delete(ctx context.Context, key string) error {
+	s.deleteErr = ctx.Err()
+	return s.Store.Delete(ctx, key)
+}
+
+func TestServerLockCanceled(t *testing.T) {
+	store := &recordStore{Store: NewMemoryStore(100)}
+	ctx, cancel := context.WithCancel(context.Background())
+	_, tr := newContext("key-1")
+	ctx = transport.NewServerContext(ctx, tr)
+	m := Server(store, WithLockTTL(time.Second))(func(context.Context, interface{}) (interface{}, error) {
+		cancel()
+		return nil, context.Canceled
+	})
+	if _, err := m(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if store.lockTTL != time.Second {
+		t.Errorf("expected lock ttl 1s, got %v", store.lockTTL)
+	}
+	// 请求取消后仍然释放键
+	if store.deleteErr != nil {
+		t.Errorf("expected the key to be deleted with a live context, got %v", store.deleteErr)
+	}
+	if _, ok, _ := store.Get(context.Background(), "/helloworld.Greeter/CreateHello:key-1"); ok {
+		t.Error("expected the key to be released")
+	}
+}
@@ -0,0 +1,39 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/cnsync/kratos/internal/lru"
+)
+
+// memoryStore is an in-memory Store that evicts the least recently used keys.
+type memoryStore struct {
+	cache *lru.Cache
+}
+
+// NewMemoryStore returns an in-memory Store that holds at most size keys,
+// the least recently used keys are evicted when it is full.
+// It could only deduplicate the requests served by the same instance.
+func NewMemoryStore(size int) Store {
+	return &memoryStore{cache: lru.New(size, time.Now)}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := s.cache.Get(key)
+	return value, ok, nil
+}
+
+func (s *memoryStore) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.cache.SetNX(key, value, ttl), nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.cache.Set(key, value, ttl)
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.cache.Delete(key)
+	return nil
+}
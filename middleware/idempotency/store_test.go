@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/internal/lru"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := &memoryStore{cache: lru.New(2, func() time.Time { return now })}
+
+	if ok, _ := s.SetNX(ctx, "a", []byte("1"), time.Minute); !ok {
+		t.Fatal("expected a to be set")
+	}
+	if ok, _ := s.SetNX(ctx, "a", []byte("2"), time.Minute); ok {
+		t.Fatal("expected a to exist")
+	}
+	if v, ok, _ := s.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Fatalf("unexpected value %q", v)
+	}
+
+	// 超出容量时淘汰最久未使用的键
+	_ = s.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _, _ = s.Get(ctx, "a")
+	_ = s.Set(ctx, "c", []byte("3"), time.Minute)
+	if _, ok, _ := s.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok, _ := s.Get(ctx, "a"); !ok {
+		t.Error("expected a to exist")
+	}
+
+	// 过期的键不存在
+	now = now.Add(time.Minute)
+	if _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Error("expected a to expire")
+	}
+	if ok, _ := s.SetNX(ctx, "c", nil, time.Minute); !ok {
+		t.Error("expected expired c to be set")
+	}
+	_ = s.Delete(ctx, "c")
+	if _, ok, _ := s.Get(ctx, "c"); ok {
+		t.Error("expected c to be deleted")
+	}
+}
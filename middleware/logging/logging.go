@@ -3,14 +3,21 @@ package logging
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/codes"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
 	"github.com/cnsync/kratos/errors"
 	"github.com/cnsync/kratos/log"
 	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/selector"
 	"github.com/cnsync/kratos/transport"
+	"github.com/cnsync/kratos/transport/http"
 	"github.com/cnsync/kratos/transport/http/status"
 )
 
@@ -19,8 +26,70 @@ type Redacter interface {
 	Redact() string
 }
 
-// Server is an server logging middleware.
-func Server(logger log.Logger) middleware.Middleware {
+// Option is logging option.
+type Option func(*options)
+
+// WithHeaders with the request headers to log, all the request headers are logged if no key is given.
+// The values of the redacted headers are masked.
+func WithHeaders(keys ...string) Option {
+	return func(o *options) {
+		o.headers = true
+		o.headerKeys = keys
+	}
+}
+
+// WithRedactHeaders with the sensitive headers to mask, replacing the defaults
+// Authorization, Cookie and Set-Cookie.
+func WithRedactHeaders(keys ...string) Option {
+	return func(o *options) {
+		o.redactHeaders = make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			o.redactHeaders[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// WithRedactFields with the sensitive fields to mask in the args of proto message requests,
+// the fields are matched by the proto field name at any depth, e.g. password.
+func WithRedactFields(fields ...string) Option {
+	return func(o *options) {
+		for _, f := range fields {
+			o.redactFields[f] = struct{}{}
+		}
+	}
+}
+
+// WithSlowThreshold with the latency threshold of the slow requests,
+// which are logged at warn level with "slow" set to true. Zero disables it, which is the default.
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+	}
+}
+
+type options struct {
+	headers       bool
+	headerKeys    []string
+	redactHeaders map[string]struct{}
+	redactFields  map[string]struct{}
+	slowThreshold time.Duration
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		redactHeaders: map[string]struct{}{"authorization": {}, "cookie": {}, "set-cookie": {}},
+		redactFields:  make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Server is an server logging middleware that produces one access log per request.
+// The global logger is used if the logger is nil.
+func Server(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			var (
@@ -28,6 +97,8 @@ func Server(logger log.Logger) middleware.Middleware {
 				reason    string
 				kind      string
 				operation string
+				peer      string
+				header    transport.Header
 			)
 
 			// default code
@@ -37,30 +108,41 @@ func Server(logger log.Logger) middleware.Middleware {
 			if info, ok := transport.FromServerContext(ctx); ok {
 				kind = info.Kind().String()
 				operation = info.Operation()
+				header = info.RequestHeader()
+				peer = serverPeer(ctx, info)
 			}
 			reply, err = handler(ctx, req)
 			if se := errors.FromError(err); se != nil {
 				code = se.Code
 				reason = se.Reason
 			}
+			latency := time.Since(startTime)
 			level, stack := extractError(err)
-			log.NewHelper(log.WithContext(ctx, logger)).Log(level,
+			keyvals := []interface{}{
 				"kind", "server",
 				"component", kind,
 				"operation", operation,
-				"args", extractArgs(req),
+				"args", o.extractArgs(req),
 				"code", code,
 				"reason", reason,
 				"stack", stack,
-				"latency", time.Since(startTime).Seconds(),
-			)
+				"latency", latency.Seconds(),
+			}
+			level, keyvals = o.extra(ctx, level, keyvals, peer, header, latency)
+			l := logger
+			if l == nil {
+				l = log.GetLogger()
+			}
+			log.NewHelper(log.WithContext(ctx, l)).Log(level, keyvals...)
 			return
 		}
 	}
 }
 
-// Client is a client logging middleware.
-func Client(logger log.Logger) middleware.Middleware {
+// Client is a client logging middleware that produces one access log per request.
+// The global logger is used if the logger is nil.
+func Client(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
 			var (
@@ -68,6 +150,8 @@ func Client(logger log.Logger) middleware.Middleware {
 				reason    string
 				kind      string
 				operation string
+				peer      string
+				header    transport.Header
 			)
 
 			// default code
@@ -77,28 +161,91 @@ func Client(logger log.Logger) middleware.Middleware {
 			if info, ok := transport.FromClientContext(ctx); ok {
 				kind = info.Kind().String()
 				operation = info.Operation()
+				header = info.RequestHeader()
 			}
 			reply, err = handler(ctx, req)
 			if se := errors.FromError(err); se != nil {
 				code = se.Code
 				reason = se.Reason
 			}
+			if p, ok := selector.FromPeerContext(ctx); ok && p.Node != nil {
+				peer = p.Node.Address()
+			}
+			latency := time.Since(startTime)
 			level, stack := extractError(err)
-			log.NewHelper(log.WithContext(ctx, logger)).Log(level,
+			keyvals := []interface{}{
 				"kind", "client",
 				"component", kind,
 				"operation", operation,
-				"args", extractArgs(req),
+				"args", o.extractArgs(req),
 				"code", code,
 				"reason", reason,
 				"stack", stack,
-				"latency", time.Since(startTime).Seconds(),
-			)
+				"latency", latency.Seconds(),
+			}
+			level, keyvals = o.extra(ctx, level, keyvals, peer, header, latency)
+			l := logger
+			if l == nil {
+				l = log.GetLogger()
+			}
+			log.NewHelper(log.WithContext(ctx, l)).Log(level, keyvals...)
 			return
 		}
 	}
 }
 
+// extra appends the peer, trace id, headers and slow request fields.
+func (o *options) extra(ctx context.Context, level log.Level, keyvals []interface{}, peer string, header transport.Header, latency time.Duration) (log.Level, []interface{}) {
+	if peer != "" {
+		keyvals = append(keyvals, "peer", peer)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		keyvals = append(keyvals, "trace_id", span.TraceID().String())
+	}
+	if o.headers && header != nil {
+		keyvals = append(keyvals, "header", o.extractHeader(header))
+	}
+	if o.slowThreshold > 0 && latency >= o.slowThreshold {
+		keyvals = append(keyvals, "slow", true)
+		if level < log.LevelWarn {
+			level = log.LevelWarn
+		}
+	}
+	return level, keyvals
+}
+
+// extractHeader returns the logged request headers with the sensitive values masked.
+func (o *options) extractHeader(header transport.Header) map[string]string {
+	keys := o.headerKeys
+	if len(keys) == 0 {
+		keys = header.Keys()
+	}
+	values := make(map[string]string, len(keys))
+	for _, k := range keys {
+		v := header.Get(k)
+		if v == "" {
+			continue
+		}
+		if _, ok := o.redactHeaders[strings.ToLower(k)]; ok {
+			v = redacted
+		}
+		values[k] = v
+	}
+	return values
+}
+
+// extractArgs returns the string of the req with the sensitive fields masked.
+func (o *options) extractArgs(req interface{}) string {
+	if m, ok := req.(proto.Message); ok && len(o.redactFields) > 0 {
+		if _, ok := req.(Redacter); !ok {
+			m = proto.Clone(m)
+			redactMessage(m.ProtoReflect(), o.redactFields)
+			return extractArgs(m)
+		}
+	}
+	return extractArgs(req)
+}
+
 // extractArgs returns the string of the req
 func extractArgs(req interface{}) string {
 	if redacter, ok := req.(Redacter); ok {
@@ -117,3 +264,46 @@ func extractError(err error) (log.Level, string) {
 	}
 	return log.LevelInfo, ""
 }
+
+// redacted is the mask of the sensitive values.
+const redacted = "***"
+
+// redactMessage masks the fields of the message and its nested messages by name.
+func redactMessage(m protoreflect.Message, fields map[string]struct{}) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if _, ok := fields[string(fd.Name())]; ok {
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				m.Set(fd, protoreflect.ValueOfString(redacted))
+			} else {
+				m.Clear(fd)
+			}
+			return true
+		}
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message(), fields)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				redactMessage(mv.Message(), fields)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			redactMessage(v.Message(), fields)
+		}
+		return true
+	})
+}
+
+// serverPeer returns the remote address of the server request.
+func serverPeer(ctx context.Context, info transport.Transporter) string {
+	if ht, ok := info.(http.Transporter); ok && ht.Request() != nil {
+		return ht.Request().RemoteAddr
+	}
+	if p, ok := grpcpeer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
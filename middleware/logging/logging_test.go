@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cnsync/kratos/internal/testdata/complex"
 	"github.com/cnsync/kratos/log"
 	"github.com/cnsync/kratos/middleware"
 	"github.com/cnsync/kratos/transport"
@@ -46,7 +49,7 @@ func TestHTTP(t *testing.T) {
 
 	tests := []struct {
 		name string
-		kind func(logger log.Logger) middleware.Middleware
+		kind func(logger log.Logger, opts ...Option) middleware.Middleware
 		err  error
 		ctx  context.Context
 	}{
@@ -233,3 +236,98 @@ func TestClient_CallerPath(t *testing.T) {
 		t.Fatalf("middleware should have the same caller as log.Helper. middleware: %s, helper: %s", a[0][1], a[1][1])
 	}
 }
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string        { return hc[key] }
+func (hc headerCarrier) Set(key string, value string) { hc[key] = value }
+func (hc headerCarrier) Add(key string, value string) { hc[key] = value }
+func (hc headerCarrier) Values(key string) []string   { return []string{hc[key]} }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type headerTransport struct {
+	Transport
+	header headerCarrier
+}
+
+func (tr *headerTransport) RequestHeader() transport.Header {
+	return tr.header
+}
+
+func TestRedact(t *testing.T) {
+	var a extractKeyValues
+	tr := &headerTransport{
+		Transport: Transport{kind: transport.KindHTTP, operation: "/package.service/method"},
+		header:    headerCarrier{"Authorization": "Bearer token", "X-Request-Id": "1"},
+	}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	req := &complex.Complex{NoOne: "secret", Simple: &complex.Simple{Component: "secret"}, Simples: []string{"a"}, Age: 18}
+	m := Server(&a, WithHeaders(), WithRedactFields("no_one", "component", "age"))
+	if _, err := m(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	kv := toMap(a[0])
+	if args := kv["args"].(string); strings.Contains(args, "secret") || strings.Contains(args, "18") || !strings.Contains(args, redacted) {
+		t.Errorf("expected args redacted, got %s", args)
+	}
+	if req.NoOne != "secret" {
+		t.Error("expected the request unchanged")
+	}
+	header := kv["header"].(map[string]string)
+	if header["Authorization"] != redacted || header["X-Request-Id"] != "1" {
+		t.Errorf("unexpected header %v", header)
+	}
+}
+
+func TestSlowThreshold(t *testing.T) {
+	var a levelKeyValues
+	ctx := transport.NewServerContext(context.Background(), &Transport{kind: transport.KindGRPC, operation: "/package.service/method"})
+	m := Server(&a, WithSlowThreshold(time.Millisecond))
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) {
+		time.Sleep(2 * time.Millisecond)
+		return nil, nil
+	})(ctx, nil)
+	_, _ = Server(&a, WithSlowThreshold(time.Hour))(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(ctx, nil)
+	if a.levels[0] != log.LevelWarn || toMap(a.keyvals[0])["slow"] != true {
+		t.Errorf("expected slow request logged at warn, got %v %v", a.levels[0], a.keyvals[0])
+	}
+	if a.levels[1] != log.LevelInfo || toMap(a.keyvals[1])["slow"] != nil {
+		t.Errorf("unexpected log %v %v", a.levels[1], a.keyvals[1])
+	}
+}
+
+func TestGlobalLogger(t *testing.T) {
+	var a extractKeyValues
+	global := log.GetLogger()
+	log.SetLogger(&a)
+	defer log.SetLogger(global)
+	_, _ = Client(nil)(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(context.Background(), nil)
+	if len(a) != 1 {
+		t.Errorf("expected the global logger to be used")
+	}
+}
+
+type levelKeyValues struct {
+	levels  []log.Level
+	keyvals [][]any
+}
+
+func (l *levelKeyValues) Log(level log.Level, kv ...any) error {
+	l.levels = append(l.levels, level)
+	l.keyvals = append(l.keyvals, kv)
+	return nil
+}
+
+func toMap(kv []any) map[string]any {
+	m := make(map[string]any, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		m[kv[i].(string)] = kv[i+1]
+	}
+	return m
+}
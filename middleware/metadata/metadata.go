@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	"github.com/cnsync/kratos/metadata"
@@ -13,14 +14,22 @@ import (
 type Option func(*options)
 
 type options struct {
-	prefix []string
-	md     metadata.Metadata
+	prefix   []string
+	md       metadata.Metadata
+	policies map[string]metadata.Propagation
+	maxSize  int
+}
+
+// policy returns the propagation policy registered for the key.
+func (o *options) policy(key string) (metadata.Propagation, bool) {
+	p, ok := o.policies[strings.ToLower(key)]
+	return p, ok
 }
 
 func (o *options) hasPrefix(key string) bool {
 	k := strings.ToLower(key)
 	for _, prefix := range o.prefix {
-		if strings.HasPrefix(k, prefix) {
+		if strings.HasPrefix(k, strings.ToLower(prefix)) {
 			return true
 		}
 	}
@@ -41,6 +50,56 @@ func WithPropagatedPrefix(prefix ...string) Option {
 	}
 }
 
+// WithPropagation sets the propagation policy of the given keys, which takes
+// precedence over the propagated prefix. Keys with a policy are accepted by the
+// server even without a matching prefix, unless the policy is PropagateLocal.
+func WithPropagation(p metadata.Propagation, keys ...string) Option {
+	return func(o *options) {
+		if o.policies == nil {
+			o.policies = make(map[string]metadata.Propagation, len(keys))
+		}
+		for _, k := range keys {
+			o.policies[strings.ToLower(k)] = p
+		}
+	}
+}
+
+// WithMaxSize limits the total size in bytes of the keys and values received
+// by the server or sent by the client. Entries beyond the limit are dropped.
+// Zero means no limit.
+func WithMaxSize(size int) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+// sizeLimiter tracks the metadata size against the max size.
+type sizeLimiter struct {
+	max  int
+	size int
+}
+
+// allow reports whether the entry fits in the remaining size, and accounts it if so.
+func (l *sizeLimiter) allow(key, value string) bool {
+	n := len(key) + len(value)
+	if l.max > 0 && l.size+n > l.max {
+		return false
+	}
+	l.size += n
+	return true
+}
+
+// sortedKeys returns the keys of the metadata in order, so that the entries
+// dropped by the size limit are deterministic.
+func sortedKeys(md metadata.Metadata) []string {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Server is middleware server-side metadata.
 func Server(opts ...Option) middleware.Middleware {
 	options := &options{
@@ -58,9 +117,19 @@ func Server(opts ...Option) middleware.Middleware {
 
 			md := options.md.Clone()
 			header := tr.RequestHeader()
-			for _, k := range header.Keys() {
-				if options.hasPrefix(k) {
-					for _, v := range header.Values(k) {
+			keys := header.Keys()
+			sort.Strings(keys)
+			limiter := &sizeLimiter{max: options.maxSize}
+			for _, k := range keys {
+				if p, ok := options.policy(k); ok {
+					if p == metadata.PropagateLocal {
+						continue
+					}
+				} else if !options.hasPrefix(k) {
+					continue
+				}
+				for _, v := range header.Values(k) {
+					if limiter.allow(k, v) {
 						md.Add(k, v)
 					}
 				}
@@ -87,26 +156,41 @@ func Client(opts ...Option) middleware.Middleware {
 			}
 
 			header := tr.RequestHeader()
-			// x-md-local-
-			for k, vList := range options.md {
-				for _, v := range vList {
-					header.Add(k, v)
+			limiter := &sizeLimiter{max: options.maxSize}
+			send := func(k string, vList []string) {
+				if p, ok := options.policy(k); ok && p == metadata.PropagateLocal {
+					return
 				}
-			}
-			if md, ok := metadata.FromClientContext(ctx); ok {
-				for k, vList := range md {
-					for _, v := range vList {
+				for _, v := range vList {
+					if limiter.allow(k, v) {
 						header.Add(k, v)
 					}
 				}
 			}
-			// x-md-global-
+			// x-md-local-
+			for _, k := range sortedKeys(options.md) {
+				send(k, options.md[k])
+			}
+			clientMD, _ := metadata.FromClientContext(ctx)
+			for _, k := range sortedKeys(clientMD) {
+				send(k, clientMD[k])
+			}
+			// x-md-global-, the metadata set by the client overrides the propagated one
 			if md, ok := metadata.FromServerContext(ctx); ok {
-				for k, vList := range md {
-					if options.hasPrefix(k) {
-						for _, v := range vList {
-							header.Add(k, v)
+				for _, k := range sortedKeys(md) {
+					if _, ok := options.md[k]; ok {
+						continue
+					}
+					if _, ok := clientMD[k]; ok {
+						continue
+					}
+					// one-hop metadata received from the caller stops here
+					if p, ok := options.policy(k); ok {
+						if p == metadata.PropagateGlobal {
+							send(k, md[k])
 						}
+					} else if options.hasPrefix(k) {
+						send(k, md[k])
 					}
 				}
 			}
@@ -167,6 +167,7 @@ func TestOptions_hasPrefix(t *testing.T) {
 		{"exists key lower", &options{prefix: []string{"prefix"}}, "prefix_true", true},
 		{"not exists key upper", &options{prefix: []string{"prefix"}}, "false_PREFIX", false},
 		{"not exists key lower", &options{prefix: []string{"prefix"}}, "false_prefix", false},
+		{"exists key upper prefix", &options{prefix: []string{"PREFIX"}}, "prefix_true", true},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -177,3 +178,104 @@ func TestOptions_hasPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestClientOverride(t *testing.T) {
+	hc := headerCarrier{}
+	hs := func(_ context.Context, in interface{}) (interface{}, error) {
+		return in, nil
+	}
+	serverMD := metadata.New()
+	serverMD.Set(globalKey, globalValue)
+	ctx := metadata.NewServerContext(context.Background(), serverMD)
+	ctx = metadata.AppendToClientContext(ctx, globalKey, "client-value")
+	ctx = transport.NewClientContext(ctx, &testTransport{hc})
+	if _, err := Client(WithPropagatedPrefix("X-MD-GLOBAL-"))(hs)(ctx, "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if v := hc.Values(globalKey); !reflect.DeepEqual(v, []string{"client-value"}) {
+		t.Errorf("want [client-value] got %v", v)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	hs := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		md, _ := metadata.FromServerContext(ctx)
+		return md, nil
+	}
+	opts := []Option{
+		WithPropagation(metadata.PropagateOneHop, "x-tenant"),
+		WithPropagation(metadata.PropagateGlobal, "x-region"),
+		WithPropagation(metadata.PropagateLocal, "x-md-global-secret"),
+	}
+	// server accepts keys with a policy, and drops local-only keys
+	header := headerCarrier{}
+	header.Set("X-Tenant", "t1")
+	header.Set("X-Region", "cn")
+	header.Set("X-Md-Global-Secret", "s")
+	header.Set("X-Md-Global-Trace", "abc")
+	reply, err := Server(opts...)(hs)(transport.NewServerContext(context.Background(), &testTransport{header}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := reply.(metadata.Metadata)
+	if md.Get("x-tenant") != "t1" || md.Get("x-region") != "cn" || md.Get("x-md-global-trace") != "abc" {
+		t.Errorf("unexpected server metadata: %v", md)
+	}
+	if v := md.Get("x-md-global-secret"); v != "" {
+		t.Errorf("expected local-only key to be dropped, got %v", v)
+	}
+
+	// client forwards global keys only, and never sends local-only keys
+	ctx := metadata.NewServerContext(context.Background(), md)
+	ctx = metadata.AppendToClientContext(ctx, "x-md-global-secret", "s", "x-tenant", "t2")
+	hc := headerCarrier{}
+	ctx = transport.NewClientContext(ctx, &testTransport{hc})
+	if _, err = Client(opts...)(hs)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"X-Tenant": "t2", "X-Region": "cn", "X-Md-Global-Trace": "abc", "X-Md-Global-Secret": ""}
+	for k, v := range want {
+		if got := hc.Get(k); got != v {
+			t.Errorf("%s: want %q got %q", k, v, got)
+		}
+	}
+
+	// one-hop keys received from the caller are not forwarded
+	hc = headerCarrier{}
+	ctx = transport.NewClientContext(metadata.NewServerContext(context.Background(), md), &testTransport{hc})
+	if _, err = Client(opts...)(hs)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := hc.Get("X-Tenant"); v != "" {
+		t.Errorf("expected one-hop key not to be forwarded, got %v", v)
+	}
+}
+
+func TestWithMaxSize(t *testing.T) {
+	hc := headerCarrier{}
+	hs := func(_ context.Context, in interface{}) (interface{}, error) {
+		return in, nil
+	}
+	ctx := metadata.AppendToClientContext(context.Background(), "x-md-a", "1234", "x-md-b", "5678")
+	ctx = transport.NewClientContext(ctx, &testTransport{hc})
+	if _, err := Client(WithMaxSize(len("x-md-a1234")))(hs)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if hc.Get("x-md-a") != "1234" || hc.Get("x-md-b") != "" {
+		t.Errorf("unexpected header: %v", hc)
+	}
+
+	header := headerCarrier{}
+	header.Set("X-Md-A", "1234")
+	header.Set("X-Md-B", "5678")
+	reply, err := Server(WithMaxSize(len("X-Md-A1234")))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		md, _ := metadata.FromServerContext(ctx)
+		return md, nil
+	})(transport.NewServerContext(context.Background(), &testTransport{header}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := reply.(metadata.Metadata); len(md) != 1 || md.Get("x-md-a") != "1234" {
+		t.Errorf("unexpected metadata: %v", md)
+	}
+}
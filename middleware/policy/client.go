@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	cbmw "github.com/cnsync/kratos/middleware/circuitbreaker"
+	"github.com/cnsync/kratos/middleware/hedging"
+	"github.com/cnsync/kratos/transport"
+)
+
+// Option is client policy option.
+type Option func(*options)
+
+// WithService with the service name used to look up the policy,
+// by default it is derived from the client endpoint, e.g. discovery:///helloworld.
+func WithService(service string) Option {
+	return func(o *options) {
+		o.service = service
+	}
+}
+
+type options struct {
+	service string
+}
+
+// Client is a client middleware that applies the timeout, retry, hedging and circuit breaker
+// policy of the downstream service from the store. Policy changes take effect on the next call.
+//
+// Hedging runs the handler concurrently, so the policy middleware should be the last client middleware.
+func Client(store *Store, opts ...Option) middleware.Middleware {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var operation string
+			service := o.service
+			if info, ok := transport.FromClientContext(ctx); ok {
+				operation = info.Operation()
+				if service == "" {
+					service = serviceName(info.Endpoint())
+				}
+			}
+			p := store.Get(service)
+			if p == nil {
+				return handler(ctx, req)
+			}
+			if p.Hedging != nil {
+				handler = store.hedger(service, p.Hedging)(handler)
+			}
+			if p.Breaker == nil {
+				return invoke(ctx, req, p, handler)
+			}
+			breaker := store.breaker(service+operation, p.Breaker)
+			if err := breaker.Allow(); err != nil {
+				breaker.MarkFailed()
+				return nil, cbmw.ErrNotAllowed
+			}
+			reply, err := invoke(ctx, req, p, handler)
+			if err != nil && (errors.IsInternalServer(err) || errors.IsServiceUnavailable(err) || errors.IsGatewayTimeout(err)) {
+				breaker.MarkFailed()
+			} else {
+				breaker.MarkSuccess()
+			}
+			return reply, err
+		}
+	}
+}
+
+// invoke calls the handler with the timeout and retry policy.
+func invoke(ctx context.Context, req interface{}, p *Policy, handler middleware.Handler) (reply interface{}, err error) {
+	attempts := 1
+	var backoff time.Duration
+	if p.Retry != nil && p.Retry.Attempts > 1 {
+		attempts = p.Retry.Attempts
+		backoff = time.Duration(p.Retry.Backoff)
+	}
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			// honor the retry delay hinted by the server if it is longer than the backoff
+			wait := backoff
+			if delay, ok := errors.RetryDelay(err); ok && delay > wait {
+				wait = delay
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, err
+			case <-timer.C:
+			}
+			backoff *= 2
+			if maxBackoff := time.Duration(p.Retry.MaxBackoff); maxBackoff > 0 && backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		reply, err = attempt(ctx, req, p.Timeout, handler)
+		if err == nil || attempts == 1 || !retryable(p.Retry, err) {
+			return reply, err
+		}
+	}
+	return reply, err
+}
+
+// attempt calls the handler with the timeout.
+func attempt(ctx context.Context, req interface{}, timeout Duration, handler middleware.Handler) (interface{}, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout))
+		defer cancel()
+	}
+	return handler(ctx, req)
+}
+
+// retryable reports whether the error should be retried.
+// Without configured codes, errors are retried if they are errors.IsRetryable.
+func retryable(r *Retry, err error) bool {
+	if len(r.Codes) == 0 {
+		return errors.IsRetryable(err)
+	}
+	code := errors.Code(err)
+	for _, c := range r.Codes {
+		if int(c) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// breaker returns the circuit breaker of the key, created with the thresholds.
+func (s *Store) breaker(key string, b *Breaker) circuitbreaker.CircuitBreaker {
+	breakers := s.breakers.Load()
+	if v, ok := breakers.Load(key); ok {
+		return v.(circuitbreaker.CircuitBreaker)
+	}
+	var opts []sre.Option
+	if b.Success > 0 {
+		opts = append(opts, sre.WithSuccess(b.Success))
+	}
+	if b.Request > 0 {
+		opts = append(opts, sre.WithRequest(b.Request))
+	}
+	if b.Window > 0 {
+		opts = append(opts, sre.WithWindow(time.Duration(b.Window)))
+	}
+	if b.Bucket > 0 {
+		opts = append(opts, sre.WithBucket(b.Bucket))
+	}
+	v, _ := breakers.LoadOrStore(key, sre.NewBreaker(opts...))
+	return v.(circuitbreaker.CircuitBreaker)
+}
+
+// hedger returns the hedging middleware of the service, created with the policy.
+// The middleware keeps the recent latencies used by the percentile delay.
+func (s *Store) hedger(service string, h *Hedging) middleware.Middleware {
+	hedgers := s.hedgers.Load()
+	if v, ok := hedgers.Load(service); ok {
+		return v.(middleware.Middleware)
+	}
+	opts := []hedging.Option{hedging.WithPercentile(h.Percentile), hedging.WithOperations(h.Operations...)}
+	if h.Attempts > 0 {
+		opts = append(opts, hedging.WithMaxAttempts(h.Attempts))
+	}
+	if h.Delay > 0 {
+		opts = append(opts, hedging.WithDelay(time.Duration(h.Delay)))
+	}
+	v, _ := hedgers.LoadOrStore(service, hedging.Client(opts...))
+	return v.(middleware.Middleware)
+}
+
+// serviceName returns the service name of the endpoint, e.g. discovery:///helloworld → helloworld.
+func serviceName(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return endpoint
+	}
+	if u.Host != "" && u.Path == "" {
+		return u.Host
+	}
+	return strings.TrimPrefix(u.Path, "/")
+}
@@ -0,0 +1,203 @@
+package policy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	cbmw "github.com/cnsync/kratos/middleware/circuitbreaker"
+	"github.com/cnsync/kratos/transport"
+	khttp "github.com/cnsync/kratos/transport/http"
+)
+
+type transportMock struct {
+	endpoint  string
+	operation string
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindGRPC
+}
+
+func (tr *transportMock) Endpoint() string {
+	return tr.endpoint
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return nil
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return nil
+}
+
+func TestClientRetry(t *testing.T) {
+	store := NewStore(map[string]*Policy{
+		"helloworld": {
+			Timeout: Duration(time.Second),
+			Retry:   &Retry{Attempts: 3, Backoff: Duration(time.Millisecond)},
+		},
+	})
+	ctx := transport.NewClientContext(context.Background(), &transportMock{endpoint: "discovery:///helloworld", operation: "/hello"})
+	calls := 0
+	reply, err := Client(store)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		calls++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected deadline")
+		}
+		if calls < 3 {
+			return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		}
+		return "ok", nil
+	})(ctx, nil)
+	if err != nil || reply != "ok" || calls != 3 {
+		t.Errorf("unexpected result: %v %v %d", reply, err, calls)
+	}
+
+	// 不可重试的错误
+	calls = 0
+	_, err = Client(store)(func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.BadRequest("BAD", "bad request")
+	})(ctx, nil)
+	if !errors.IsBadRequest(err) || calls != 1 {
+		t.Errorf("unexpected result: %v %d", err, calls)
+	}
+}
+
+func TestClientRetryCodes(t *testing.T) {
+	store := NewStore(map[string]*Policy{
+		DefaultKey: {Retry: &Retry{Attempts: 2, Codes: []int32{429}}},
+	})
+	calls := 0
+	_, err := Client(store, WithService("other"))(func(context.Context, interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.New(429, "TOO_MANY_REQUESTS", "")
+	})(context.Background(), nil)
+	if errors.Code(err) != 429 || calls != 2 {
+		t.Errorf("unexpected result: %v %d", err, calls)
+	}
+}
+
+func TestClientRetryInfo(t *testing.T) {
+	store := NewStore(map[string]*Policy{
+		DefaultKey: {Retry: &Retry{Attempts: 2, Backoff: Duration(time.Millisecond)}},
+	})
+	var last time.Time
+	var wait time.Duration
+	_, err := Client(store, WithService("other"))(func(context.Context, interface{}) (interface{}, error) {
+		if !last.IsZero() {
+			wait = time.Since(last)
+		}
+		last = time.Now()
+		return nil, errors.New(429, "RATE_LIMITED", "").WithRetryInfo(50 * time.Millisecond)
+	})(context.Background(), nil)
+	if errors.Code(err) != 429 || wait < 50*time.Millisecond {
+		t.Errorf("unexpected result: %v %v", err, wait)
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	store := NewStore(map[string]*Policy{
+		"helloworld": {Breaker: &Breaker{Success: 0.9, Request: 5, Window: Duration(time.Minute)}},
+	})
+	ctx := transport.NewClientContext(context.Background(), &transportMock{endpoint: "discovery:///helloworld", operation: "/hello"})
+	h := Client(store)(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.ServiceUnavailable("UNAVAILABLE", "unavailable")
+	})
+	rejected := false
+	for i := 0; i < 100; i++ {
+		if _, err := h(ctx, nil); errors.Reason(err) == errors.Reason(cbmw.ErrNotAllowed) {
+			rejected = true
+			break
+		}
+	}
+	if !rejected {
+		t.Error("expected breaker to reject requests")
+	}
+	// 更新策略后熔断器重新创建
+	store.Update(map[string]*Policy{"helloworld": {}})
+	if _, err := h(ctx, nil); !errors.IsServiceUnavailable(err) || errors.Reason(err) == errors.Reason(cbmw.ErrNotAllowed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	tests := map[string]string{
+		"discovery:///helloworld": "helloworld",
+		"http://127.0.0.1:8000":   "127.0.0.1:8000",
+		"127.0.0.1:8000":          "127.0.0.1:8000",
+		"localhost:8000":          "localhost:8000",
+		"helloworld":              "helloworld",
+	}
+	for endpoint, want := range tests {
+		if got := serviceName(endpoint); got != want {
+			t.Errorf("%s: expected %s, got %s", endpoint, want, got)
+		}
+	}
+}
+
+func TestClientHedging(t *testing.T) {
+	store := NewStore(map[string]*Policy{
+		"helloworld": {Hedging: &Hedging{Delay: Duration(10 * time.Millisecond), Operations: []string{"/hello"}}},
+	})
+	ctx := transport.NewClientContext(context.Background(), &transportMock{endpoint: "discovery:///helloworld", operation: "/hello"})
+	var calls int32
+	reply, err := Client(store)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// the original request is slow, the backup request wins
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return "backup", nil
+	})(ctx, nil)
+	if err != nil || reply != "backup" || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("unexpected result: %v %v %d", reply, err, calls)
+	}
+}
+
+func TestFactory(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer srv.Close()
+
+	store := NewStore(map[string]*Policy{
+		"helloworld": {Retry: &Retry{Attempts: 2, Codes: []int32{503}}},
+	})
+	var seen bool
+	f := NewFactory(store, WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			seen = true
+			return handler(ctx, req)
+		}
+	}))
+	client, err := f.HTTPClient(context.Background(), "helloworld", khttp.WithEndpoint(strings.TrimPrefix(srv.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var reply map[string]string
+	if err = client.Invoke(context.Background(), http.MethodGet, "/hello", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply["message"] != "ok" || atomic.LoadInt32(&calls) != 2 || !seen {
+		t.Errorf("unexpected result: %v %d %v", reply, calls, seen)
+	}
+}
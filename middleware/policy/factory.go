@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/registry"
+	kgrpc "github.com/cnsync/kratos/transport/grpc"
+	"github.com/cnsync/kratos/transport/http"
+)
+
+// FactoryOption is client factory option.
+type FactoryOption func(*Factory)
+
+// WithDiscovery with the service discovery used to resolve the services.
+func WithDiscovery(d registry.Discovery) FactoryOption {
+	return func(f *Factory) {
+		f.discovery = d
+	}
+}
+
+// WithMiddleware with the client middleware applied before the policy middleware.
+func WithMiddleware(m ...middleware.Middleware) FactoryOption {
+	return func(f *Factory) {
+		f.middleware = m
+	}
+}
+
+// Factory creates the clients of the downstream services with their policies applied,
+// so the callers do not have to wire the policy middleware themselves.
+type Factory struct {
+	store      *Store
+	discovery  registry.Discovery
+	middleware []middleware.Middleware
+}
+
+// NewFactory creates a client factory with the policy store.
+func NewFactory(store *Store, opts ...FactoryOption) *Factory {
+	f := &Factory{store: store}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// HTTPClient creates an HTTP client of the service, the endpoint is discovery:///<service>
+// by default. The options are applied after the factory ones, the middleware should be set
+// with WithMiddleware of the factory instead of the client option, which would drop the policy.
+func (f *Factory) HTTPClient(ctx context.Context, service string, opts ...http.ClientOption) (*http.Client, error) {
+	o := []http.ClientOption{
+		http.WithEndpoint("discovery:///" + service),
+		http.WithMiddleware(f.chain(service)...),
+	}
+	if f.discovery != nil {
+		o = append(o, http.WithDiscovery(f.discovery))
+	}
+	return http.NewClient(ctx, append(o, opts...)...)
+}
+
+// GRPCConn creates a gRPC connection of the service, see HTTPClient for the options.
+func (f *Factory) GRPCConn(ctx context.Context, service string, opts ...kgrpc.ClientOption) (*grpc.ClientConn, error) {
+	return kgrpc.Dial(ctx, append(f.grpcOptions(service), opts...)...)
+}
+
+// GRPCInsecureConn creates an insecure gRPC connection of the service, see HTTPClient for the options.
+func (f *Factory) GRPCInsecureConn(ctx context.Context, service string, opts ...kgrpc.ClientOption) (*grpc.ClientConn, error) {
+	return kgrpc.DialInsecure(ctx, append(f.grpcOptions(service), opts...)...)
+}
+
+func (f *Factory) grpcOptions(service string) []kgrpc.ClientOption {
+	o := []kgrpc.ClientOption{
+		kgrpc.WithEndpoint("discovery:///" + service),
+		kgrpc.WithMiddleware(f.chain(service)...),
+	}
+	if f.discovery != nil {
+		o = append(o, kgrpc.WithDiscovery(f.discovery))
+	}
+	return o
+}
+
+// chain returns the client middleware of the service, the policy middleware is the last one.
+func (f *Factory) chain(service string) []middleware.Middleware {
+	ms := make([]middleware.Middleware, 0, len(f.middleware)+1)
+	ms = append(ms, f.middleware...)
+	return append(ms, Client(f.store, WithService(service)))
+}
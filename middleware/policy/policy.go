@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnsync/kratos/config"
+	"github.com/cnsync/kratos/log"
+)
+
+// DefaultKey is the key of the policy applied to services without their own policy.
+const DefaultKey = "*"
+
+// Duration is a time.Duration that unmarshals from a duration string like "1.5s"
+// or from a number of nanoseconds.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case float64:
+		*d = Duration(value)
+	case string:
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(dur)
+	default:
+		return fmt.Errorf("policy: invalid duration %s", b)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Retry is the retry policy of a downstream service.
+type Retry struct {
+	// Attempts is the maximum number of attempts, including the first one.
+	Attempts int `json:"attempts"`
+	// Backoff is the delay before the first retry, doubled on every retry.
+	Backoff Duration `json:"backoff"`
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff Duration `json:"max_backoff"`
+	// Codes are the error codes to retry on, defaults to 503 and 504.
+	Codes []int32 `json:"codes"`
+}
+
+// Breaker is the circuit breaker thresholds of a downstream service.
+type Breaker struct {
+	// Success is the success ratio below which requests start to be dropped.
+	Success float64 `json:"success"`
+	// Request is the minimum number of requests in the window before the breaker trips.
+	Request int64 `json:"request"`
+	// Window is the statistical window.
+	Window Duration `json:"window"`
+	// Bucket is the number of buckets in the window.
+	Bucket int `json:"bucket"`
+}
+
+// Hedging is the hedging policy of a downstream service, see the hedging middleware.
+type Hedging struct {
+	// Attempts is the maximum number of attempts, including the original request, defaults to 2.
+	Attempts int `json:"attempts"`
+	// Delay is the delay before a backup request is issued, defaults to 100ms.
+	Delay Duration `json:"delay"`
+	// Percentile is the percentile of the recent latencies used as the delay, e.g. 0.95.
+	Percentile float64 `json:"percentile"`
+	// Operations are the idempotent operations to hedge,
+	// defaults to the GET, HEAD and OPTIONS requests of the HTTP client.
+	Operations []string `json:"operations"`
+}
+
+// Policy is the traffic policy of a downstream service.
+type Policy struct {
+	// Timeout is the deadline of each attempt.
+	Timeout Duration `json:"timeout"`
+	Retry   *Retry   `json:"retry"`
+	Hedging *Hedging `json:"hedging"`
+	Breaker *Breaker `json:"breaker"`
+}
+
+// Store holds the policies keyed by service name, it can be updated at runtime.
+type Store struct {
+	policies atomic.Pointer[map[string]*Policy]
+	breakers atomic.Pointer[sync.Map]
+	hedgers  atomic.Pointer[sync.Map]
+}
+
+// NewStore creates a policy store with the given policies.
+func NewStore(policies map[string]*Policy) *Store {
+	s := &Store{}
+	s.Update(policies)
+	return s
+}
+
+// Load creates a policy store from the config value of key and keeps it updated
+// when the value changes.
+//
+//	client:
+//	  policies:
+//	    "*":
+//	      timeout: 1s
+//	    helloworld:
+//	      timeout: 500ms
+//	      retry:
+//	        attempts: 3
+//	        backoff: 10ms
+//	      hedging:
+//	        delay: 50ms
+//	      breaker:
+//	        success: 0.6
+//	        request: 100
+func Load(c config.Config, key string) (*Store, error) {
+	policies := make(map[string]*Policy)
+	if err := c.Value(key).Scan(&policies); err != nil {
+		return nil, err
+	}
+	s := NewStore(policies)
+	if err := c.Watch(key, func(_ string, v config.Value) {
+		policies := make(map[string]*Policy)
+		if err := v.Scan(&policies); err != nil {
+			log.Errorf("failed to reload client policies: %v", err)
+			return
+		}
+		s.Update(policies)
+	}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update replaces all policies, circuit breakers and hedgers are recreated with the new thresholds.
+func (s *Store) Update(policies map[string]*Policy) {
+	s.policies.Store(&policies)
+	s.breakers.Store(new(sync.Map))
+	s.hedgers.Store(new(sync.Map))
+}
+
+// Get returns the policy of the service, or the default policy if not found.
+func (s *Store) Get(service string) *Policy {
+	policies := *s.policies.Load()
+	if p, ok := policies[service]; ok {
+		return p
+	}
+	return policies[DefaultKey]
+}
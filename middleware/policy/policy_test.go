@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/config"
+)
+
+type testSource struct {
+	data string
+	next chan string
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return []*config.KeyValue{{Key: "policy", Value: []byte(s.data), Format: "json"}}, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return &testWatcher{next: s.next, exit: make(chan struct{})}, nil
+}
+
+type testWatcher struct {
+	next chan string
+	exit chan struct{}
+}
+
+func (w *testWatcher) Next() ([]*config.KeyValue, error) {
+	select {
+	case data := <-w.next:
+		return []*config.KeyValue{{Key: "policy", Value: []byte(data), Format: "json"}}, nil
+	case <-w.exit:
+		return nil, context.Canceled
+	}
+}
+
+func (w *testWatcher) Stop() error {
+	close(w.exit)
+	return nil
+}
+
+func TestDuration(t *testing.T) {
+	var p Policy
+	if err := json.Unmarshal([]byte(`{"timeout":"1.5s","retry":{"backoff":1000}}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(p.Timeout) != 1500*time.Millisecond {
+		t.Errorf("expected 1.5s, got %v", time.Duration(p.Timeout))
+	}
+	if time.Duration(p.Retry.Backoff) != time.Microsecond {
+		t.Errorf("expected 1µs, got %v", time.Duration(p.Retry.Backoff))
+	}
+	if err := json.Unmarshal([]byte(`{"timeout":"1x"}`), &p); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+	if err := json.Unmarshal([]byte(`{"timeout":true}`), &p); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+	b, _ := json.Marshal(p.Timeout)
+	if string(b) != `"1.5s"` {
+		t.Errorf("expected \"1.5s\", got %s", b)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(map[string]*Policy{
+		DefaultKey:   {Timeout: Duration(time.Second)},
+		"helloworld": {Timeout: Duration(time.Millisecond)},
+	})
+	if p := s.Get("helloworld"); time.Duration(p.Timeout) != time.Millisecond {
+		t.Errorf("expected 1ms, got %v", time.Duration(p.Timeout))
+	}
+	if p := s.Get("other"); time.Duration(p.Timeout) != time.Second {
+		t.Errorf("expected 1s, got %v", time.Duration(p.Timeout))
+	}
+	if p := NewStore(nil).Get("other"); p != nil {
+		t.Errorf("expected nil policy, got %v", p)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	source := &testSource{
+		data: `{"client":{"policies":{"helloworld":{"timeout":"1s"}}}}`,
+		next: make(chan string),
+	}
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	s, err := Load(c, "client.policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := s.Get("helloworld"); p == nil || time.Duration(p.Timeout) != time.Second {
+		t.Fatalf("unexpected policy: %v", p)
+	}
+	source.next <- `{"client":{"policies":{"helloworld":{"timeout":"2s"}}}}`
+	deadline := time.Now().Add(time.Second)
+	for time.Duration(s.Get("helloworld").Timeout) != 2*time.Second {
+		if time.Now().After(deadline) {
+			t.Fatal("expected policy to be reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cnsync/kratos/errors"
 	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
 )
 
 // ErrLimitExceed is service unavailable due to rate limit exceeded.
@@ -24,8 +25,17 @@ func WithLimiter(limiter ratelimit.Limiter) Option {
 	}
 }
 
+// WithRules with the limiter thresholds of the operations, which can be reloaded from config
+// at runtime. Operations that match no rule use the limiter of WithLimiter.
+func WithRules(r *Rules) Option {
+	return func(o *options) {
+		o.rules = r
+	}
+}
+
 type options struct {
 	limiter ratelimit.Limiter
+	rules   *Rules
 }
 
 // Server ratelimiter middleware
@@ -38,7 +48,7 @@ func Server(opts ...Option) middleware.Middleware {
 	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
-			done, e := options.limiter.Allow()
+			done, e := options.limiterOf(ctx).Allow()
 			if e != nil {
 				// rejected
 				return nil, ErrLimitExceed
@@ -50,3 +60,16 @@ func Server(opts ...Option) middleware.Middleware {
 		}
 	}
 }
+
+// limiterOf returns the limiter of the operation in the server context.
+func (o *options) limiterOf(ctx context.Context) ratelimit.Limiter {
+	if o.rules == nil {
+		return o.limiter
+	}
+	if info, ok := transport.FromServerContext(ctx); ok {
+		if l, ok := o.rules.limiter(info.Operation()); ok {
+			return l
+		}
+	}
+	return o.limiter
+}
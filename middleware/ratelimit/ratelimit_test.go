@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/go-kratos/aegis/ratelimit"
+
+	"github.com/cnsync/kratos/transport"
 )
 
 type (
@@ -62,3 +64,51 @@ func TestServer(t *testing.T) {
 		t.Error("The ratelimit must not run the done function and should be denied.")
 	}
 }
+
+type transportMock struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func TestRules(t *testing.T) {
+	r := NewRules(map[string]*Limit{
+		"/helloworld.Greeter/*": {CPUThreshold: 900},
+	})
+	l1, ok := r.limiter("/helloworld.Greeter/SayHello")
+	if !ok {
+		t.Fatal("expected a limiter of the matched operation")
+	}
+	if l2, _ := r.limiter("/helloworld.Greeter/SayHello"); l2 != l1 {
+		t.Error("expected the same limiter of the operation")
+	}
+	if _, ok = r.limiter("/other.Service/Get"); ok {
+		t.Error("expected no limiter of the unmatched operation")
+	}
+	// limiters are recreated after the thresholds are updated
+	r.Update(map[string]*Limit{"*": {}})
+	if l3, ok := r.limiter("/helloworld.Greeter/SayHello"); !ok || l3 == l1 {
+		t.Error("expected a new limiter after update")
+	}
+}
+
+func TestServerWithRules(t *testing.T) {
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return "Hello valid", nil
+	}
+	rlrm := &ratelimitReachedMock{}
+	m := Server(WithLimiter(rlrm), WithRules(NewRules(map[string]*Limit{"/rules": {}})))
+
+	// the limiter of the rules is used before the default limiter
+	ctx := transport.NewServerContext(context.Background(), &transportMock{operation: "/rules"})
+	if _, err := m(next)(ctx, nil); err != nil {
+		t.Errorf("expected the rules limiter to allow the request, got %v", err)
+	}
+	ctx = transport.NewServerContext(context.Background(), &transportMock{operation: "/default"})
+	if _, err := m(next)(ctx, nil); !errors.Is(err, ErrLimitExceed) {
+		t.Errorf("expected the default limiter to reject the request, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/aegis/ratelimit/bbr"
+
+	"github.com/cnsync/kratos/config"
+	"github.com/cnsync/kratos/internal/matcher"
+	"github.com/cnsync/kratos/log"
+)
+
+// Limit is the thresholds of the BBR limiter, zero values use the limiter defaults.
+type Limit struct {
+	// Window is the statistical window.
+	Window config.Duration `json:"window"`
+	// Bucket is the number of buckets in the window.
+	Bucket int `json:"bucket"`
+	// CPUThreshold is the CPU usage, in thousandths, above which requests start to be limited.
+	CPUThreshold int64 `json:"cpu_threshold"`
+	// CPUQuota is the CPU quota used when it could not be read from the cgroup.
+	CPUQuota float64 `json:"cpu_quota"`
+}
+
+// Rules holds the limiter thresholds keyed by operation selector, it can be updated at runtime.
+// A selector is a full operation, e.g. /helloworld.Greeter/SayHello, or a prefix ending with *,
+// e.g. /helloworld.Greeter/*; "*" matches all operations. Each operation has its own limiter.
+type Rules struct {
+	state atomic.Pointer[ruleState]
+}
+
+type ruleState struct {
+	rules    *matcher.Rules[*Limit]
+	limiters sync.Map
+}
+
+// NewRules creates limiter rules with the given thresholds.
+func NewRules(limits map[string]*Limit) *Rules {
+	r := &Rules{}
+	r.Update(limits)
+	return r
+}
+
+// LoadRules creates limiter rules from the config value of key and keeps them updated
+// when the value changes.
+//
+//	ratelimit:
+//	  "*":
+//	    cpu_threshold: 800
+//	  /helloworld.Greeter/SayHello:
+//	    cpu_threshold: 600
+//	    window: 5s
+func LoadRules(c config.Config, key string) (*Rules, error) {
+	limits := make(map[string]*Limit)
+	if err := c.Value(key).Scan(&limits); err != nil {
+		return nil, err
+	}
+	r := NewRules(limits)
+	if err := c.Watch(key, func(_ string, v config.Value) {
+		limits := make(map[string]*Limit)
+		if err := v.Scan(&limits); err != nil {
+			log.Errorf("failed to reload rate limit rules: %v", err)
+			return
+		}
+		r.Update(limits)
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces all thresholds, the limiters are recreated with the new thresholds.
+func (r *Rules) Update(limits map[string]*Limit) {
+	r.state.Store(&ruleState{rules: matcher.NewRules(limits)})
+}
+
+// limiter returns the limiter of the operation, false if no rule matches the operation.
+func (r *Rules) limiter(operation string) (ratelimit.Limiter, bool) {
+	state := r.state.Load()
+	if v, ok := state.limiters.Load(operation); ok {
+		return v.(ratelimit.Limiter), true
+	}
+	l, _, ok := state.rules.Match(operation)
+	if !ok || l == nil {
+		return nil, false
+	}
+	var opts []bbr.Option
+	if l.Window > 0 {
+		opts = append(opts, bbr.WithWindow(time.Duration(l.Window)))
+	}
+	if l.Bucket > 0 {
+		opts = append(opts, bbr.WithBucket(l.Bucket))
+	}
+	if l.CPUThreshold > 0 {
+		opts = append(opts, bbr.WithCPUThreshold(l.CPUThreshold))
+	}
+	if l.CPUQuota > 0 {
+		opts = append(opts, bbr.WithCPUQuota(l.CPUQuota))
+	}
+	v, _ := state.limiters.LoadOrStore(operation, bbr.NewLimiter(opts...))
+	return v.(ratelimit.Limiter), true
+}
@@ -0,0 +1,187 @@
+// Package record records sampled requests and replies to a sink in a replayable format,
+// the records can be sent again to a dev instance with "kratos replay", which drops the redacted
+// headers and takes the dev credentials from its --header flag.
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/cnsync/kratos/config"
+	"github.com/cnsync/kratos/encoding"
+	jsoncodec "github.com/cnsync/kratos/encoding/json"
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/log"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+	thttp "github.com/cnsync/kratos/transport/http"
+)
+
+// Record is a recorded request and its reply, encoded as one JSON line.
+type Record struct {
+	Time      time.Time           `json:"time"`
+	Kind      string              `json:"kind"`
+	Operation string              `json:"operation"`
+	Method    string              `json:"method,omitempty"`
+	Path      string              `json:"path,omitempty"`
+	Header    map[string][]string `json:"header,omitempty"`
+	Request   json.RawMessage     `json:"request,omitempty"`
+	Reply     json.RawMessage     `json:"reply,omitempty"`
+	Code      int                 `json:"code"`
+	Reason    string              `json:"reason,omitempty"`
+	Latency   string              `json:"latency"`
+}
+
+// Sink stores the records, it is called on the request path so it should not block for long.
+type Sink interface {
+	Write(ctx context.Context, r *Record) error
+}
+
+// Option is record option.
+type Option func(*options)
+
+type options struct {
+	enabled  func() bool
+	rate     float64
+	patterns []string
+	headers  map[string]struct{}
+}
+
+// WithEnabled with the switch of the recording, checked on every request. Default is always enabled.
+func WithEnabled(f func() bool) Option {
+	return func(o *options) {
+		if f != nil {
+			o.enabled = f
+		}
+	}
+}
+
+// WithSampleRate with the fraction of the requests to record, between 0 and 1. Default is 1.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		if rate >= 0 && rate <= 1 {
+			o.rate = rate
+		}
+	}
+}
+
+// WithRedactPatterns with the patterns of the message fields to redact,
+// see config.WithRedactPatterns for the syntax. Default is config.DefaultRedactPatterns.
+func WithRedactPatterns(patterns ...string) Option {
+	return func(o *options) {
+		o.patterns = patterns
+	}
+}
+
+// WithRedactHeaders with the headers to redact.
+// Default is Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+func WithRedactHeaders(names ...string) Option {
+	return func(o *options) {
+		o.headers = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			o.headers[http.CanonicalHeaderKey(name)] = struct{}{}
+		}
+	}
+}
+
+// ConfigFlag returns a switch reading the bool value of the key, so the recording
+// can be turned on and off by the config. A missing or invalid value disables it.
+func ConfigFlag(c config.Config, key string) func() bool {
+	return func() bool {
+		v, err := c.Value(key).Bool()
+		return err == nil && v
+	}
+}
+
+// Server is a server middleware that records the sampled requests and replies.
+func Server(sink Sink, opts ...Option) middleware.Middleware {
+	o := &options{
+		enabled:  func() bool { return true },
+		rate:     1,
+		patterns: config.DefaultRedactPatterns,
+	}
+	WithRedactHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie")(o)
+	for _, opt := range opts {
+		opt(o)
+	}
+	codec := encoding.GetCodec(jsoncodec.Name)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if !o.enabled() || (o.rate < 1 && rand.Float64() >= o.rate) {
+				return handler(ctx, req)
+			}
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			r := &Record{
+				Time:      start,
+				Kind:      tr.Kind().String(),
+				Operation: tr.Operation(),
+				Header:    o.header(tr.RequestHeader()),
+				Request:   o.message(codec, req),
+				Code:      http.StatusOK,
+				Latency:   time.Since(start).String(),
+			}
+			if ht, ok := tr.(*thttp.Transport); ok && ht.Request() != nil {
+				r.Method = ht.Request().Method
+				r.Path = ht.Request().URL.RequestURI()
+			}
+			if err != nil {
+				se := errors.FromError(err)
+				r.Code, r.Reason = int(se.Code), se.Reason
+			} else {
+				r.Reply = o.message(codec, reply)
+			}
+			if werr := sink.Write(context.WithoutCancel(ctx), r); werr != nil {
+				log.Errorf("failed to write the record of %s: %v", r.Operation, werr)
+			}
+			return reply, err
+		}
+	}
+}
+
+// header copies the header, the values of the redacted headers are replaced.
+func (o *options) header(h transport.Header) map[string][]string {
+	header := make(map[string][]string)
+	for _, key := range h.Keys() {
+		name := http.CanonicalHeaderKey(key)
+		if _, ok := o.headers[name]; ok {
+			header[name] = []string{config.RedactedValue}
+			continue
+		}
+		header[name] = h.Values(key)
+	}
+	return header
+}
+
+// message encodes the message to JSON with the sensitive fields redacted.
+func (o *options) message(codec encoding.Codec, v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	if len(o.patterns) == 0 || !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return data
+	}
+	var fields map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err = d.Decode(&fields); err != nil {
+		return data
+	}
+	redacted, err := json.Marshal(config.Redact(fields, config.WithRedactPatterns(o.patterns...)))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
@@ -0,0 +1,191 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string      { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key, value string)      { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key, value string)      { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	transport.Transporter
+	header headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Operation() string               { return "/test.Greeter/Hello" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+
+type memorySink struct {
+	mu      sync.Mutex
+	records []*Record
+}
+
+func (s *memorySink) Write(_ context.Context, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+type request struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+func newContext() context.Context {
+	header := headerCarrier{}
+	header.Set("Authorization", "Bearer token")
+	header.Set("X-Trace", "1")
+	return transport.NewServerContext(context.Background(), &testTransport{header: header})
+}
+
+func TestServer(t *testing.T) {
+	sink := &memorySink{}
+	m := Server(sink)
+	reply, err := m(func(context.Context, interface{}) (interface{}, error) {
+		return map[string]string{"message": "hello"}, nil
+	})(newContext(), &request{Name: "kratos", Password: "secret"})
+	if err != nil || reply == nil {
+		t.Fatalf("unexpected reply: %v %v", reply, err)
+	}
+	_, err = m(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "user not found")
+	})(newContext(), &request{Name: "nobody"})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sink.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(sink.records))
+	}
+	r := sink.records[0]
+	if r.Operation != "/test.Greeter/Hello" || r.Kind != "grpc" || r.Code != 200 {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	if strings.Contains(string(r.Request), "secret") || !strings.Contains(string(r.Request), "kratos") {
+		t.Errorf("expected the password to be redacted: %s", r.Request)
+	}
+	if r.Header["Authorization"][0] == "Bearer token" || r.Header["X-Trace"][0] != "1" {
+		t.Errorf("unexpected header: %v", r.Header)
+	}
+	if string(r.Reply) != `{"message":"hello"}` {
+		t.Errorf("unexpected reply: %s", r.Reply)
+	}
+	if r = sink.records[1]; r.Code != 404 || r.Reason != "USER_NOT_FOUND" || r.Reply != nil {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
+
+func TestServer_Disabled(t *testing.T) {
+	sink := &memorySink{}
+	enabled := false
+	m := Server(sink, WithEnabled(func() bool { return enabled }))
+	next := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	_, _ = m(next)(newContext(), "req")
+	if len(sink.records) != 0 {
+		t.Fatal("expected no record when disabled")
+	}
+	enabled = true
+	_, _ = m(next)(newContext(), "req")
+	if len(sink.records) != 1 {
+		t.Fatal("expected a record when enabled")
+	}
+	_, _ = Server(sink, WithSampleRate(0))(next)(newContext(), "req")
+	if len(sink.records) != 1 {
+		t.Fatal("expected no record with zero sample rate")
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"a", "b"} {
+		if err = sink.Write(context.Background(), &Record{Operation: op}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = sink.Close()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ops []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err = json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		ops = append(ops, r.Operation)
+	}
+	if strings.Join(ops, ",") != "a,b" {
+		t.Errorf("unexpected records: %v", ops)
+	}
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryStore) PutObject(_ context.Context, key string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func TestObjectSink(t *testing.T) {
+	store := &memoryStore{objects: make(map[string][]byte)}
+	sink := NewObjectSink(store, "records/", 2)
+	for i := 0; i < 3; i++ {
+		_ = sink.Write(context.Background(), &Record{Operation: "op"})
+	}
+	_ = sink.Close()
+	if len(store.objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(store.objects))
+	}
+	lines := 0
+	for key, data := range store.objects {
+		if !strings.HasPrefix(key, "records/") || !strings.HasSuffix(key, ".jsonl") {
+			t.Errorf("unexpected key: %s", key)
+		}
+		lines += bytes.Count(data, []byte("\n"))
+	}
+	if lines != 3 {
+		t.Errorf("expected 3 records, got %d", lines)
+	}
+}
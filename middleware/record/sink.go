@@ -0,0 +1,117 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cnsync/kratos/log"
+)
+
+// FileSink appends the records to a file as JSON lines.
+type FileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileSink opens the file for appending, creating it if it does not exist.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f}, nil
+}
+
+// Write appends the record to the file.
+func (s *FileSink) Write(_ context.Context, r *Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// ObjectStore stores objects, the method maps to the S3 PutObject API,
+// so an S3 or OSS client could be adapted to keep the records.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body io.Reader) error
+}
+
+// ObjectSink buffers the records and uploads every batch as a JSON lines object.
+type ObjectSink struct {
+	store  ObjectStore
+	prefix string
+	batch  int
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	count int
+	seq   int
+	wg    sync.WaitGroup
+}
+
+// NewObjectSink returns an ObjectSink that uploads the objects named
+// "<prefix><timestamp>-<seq>.jsonl" every batch records.
+func NewObjectSink(store ObjectStore, prefix string, batch int) *ObjectSink {
+	if batch <= 0 {
+		batch = 100
+	}
+	return &ObjectSink{store: store, prefix: prefix, batch: batch}
+}
+
+// Write buffers the record, the full batch is uploaded in background.
+func (s *ObjectSink) Write(_ context.Context, r *Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Write(data)
+	s.buf.WriteByte('\n')
+	if s.count++; s.count >= s.batch {
+		s.flush()
+	}
+	return nil
+}
+
+// Close uploads the buffered records and waits for the uploads.
+func (s *ObjectSink) Close() error {
+	s.mu.Lock()
+	if s.count > 0 {
+		s.flush()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// flush uploads the buffered records, it must be called with the lock held.
+func (s *ObjectSink) flush() {
+	body := bytes.Clone(s.buf.Bytes())
+	key := fmt.Sprintf("%s%s-%d.jsonl", s.prefix, time.Now().UTC().Format("20060102T150405Z"), s.seq)
+	s.buf.Reset()
+	s.count = 0
+	s.seq++
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.store.PutObject(context.Background(), key, bytes.NewReader(body)); err != nil {
+			log.Errorf("failed to upload the records %s: %v", key, err)
+		}
+	}()
+}
@@ -0,0 +1,99 @@
+// Package redact strips sensitive fields from proto replies before they are
+// encoded, so PII handling does not depend on every handler remembering it.
+package redact
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/cnsync/kratos/middleware"
+)
+
+// Option is redact option.
+type Option func(*options)
+
+type options struct {
+	fields map[protoreflect.Name]struct{}
+}
+
+// WithFields with additional sensitive fields, matched by the proto field
+// name at any depth, e.g. password.
+func WithFields(fields ...string) Option {
+	return func(o *options) {
+		for _, f := range fields {
+			o.fields[protoreflect.Name(f)] = struct{}{}
+		}
+	}
+}
+
+// Server is a server middleware that clears the sensitive fields of a copy of
+// proto replies. A field is sensitive if it is annotated with [debug_redact = true]
+// or its name is given by WithFields.
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{fields: make(map[protoreflect.Name]struct{})}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			if m, ok := reply.(proto.Message); ok && err == nil && m.ProtoReflect().IsValid() {
+				// the reply may be shared by the handler, e.g. a cached message, so a copy is redacted
+				m = proto.Clone(m)
+				o.redact(m.ProtoReflect())
+				return m, nil
+			}
+			return reply, err
+		}
+	}
+}
+
+// Redact clears the sensitive fields of the message in place, a field is
+// sensitive if it is annotated with [debug_redact = true] or its name is one
+// of fields.
+func Redact(m proto.Message, fields ...string) {
+	o := &options{fields: make(map[protoreflect.Name]struct{}, len(fields))}
+	WithFields(fields...)(o)
+	o.redact(m.ProtoReflect())
+}
+
+// sensitive reports whether the field is sensitive.
+func (o *options) sensitive(fd protoreflect.FieldDescriptor) bool {
+	if _, ok := o.fields[fd.Name()]; ok {
+		return true
+	}
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDebugRedact()
+}
+
+// redact clears the sensitive fields of the message and its nested messages.
+func (o *options) redact(msg protoreflect.Message) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if o.sensitive(fd) {
+			msg.Clear(fd)
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					o.redact(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				list := v.List()
+				for i := 0; i < list.Len(); i++ {
+					o.redact(list.Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			o.redact(v.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,67 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cnsync/kratos/internal/testdata/complex"
+)
+
+func TestServer(t *testing.T) {
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return &complex.Complex{
+			Id:     1,
+			NoOne:  "secret",
+			Simple: &complex.Simple{Component: "secret"},
+		}, nil
+	}
+	reply, err := Server(WithFields("no_one", "component"))(next)(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reply.(*complex.Complex)
+	if got.Id != 1 {
+		t.Errorf("expected id kept, got %d", got.Id)
+	}
+	if got.NoOne != "" || got.GetSimple().GetComponent() != "" {
+		t.Errorf("expected sensitive fields cleared, got %v", got)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	wantErr := errors.New("failed")
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return &complex.Complex{NoOne: "secret"}, wantErr
+	}
+	reply, err := Server(WithFields("no_one"))(next)(context.Background(), nil)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if reply.(*complex.Complex).NoOne != "secret" {
+		t.Errorf("expected reply untouched on error")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	m := &complex.Complex{Id: 1, NoOne: "secret"}
+	Redact(m, "no_one")
+	if m.NoOne != "" || m.Id != 1 {
+		t.Errorf("unexpected result %v", m)
+	}
+}
+
+func TestServerKeepsReply(t *testing.T) {
+	shared := &complex.Complex{Id: 1, NoOne: "secret"}
+	next := func(context.Context, interface{}) (interface{}, error) { return shared, nil }
+	reply, err := Server(WithFields("no_one"))(next)(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.(*complex.Complex).NoOne != "" {
+		t.Errorf("expected reply redacted, got %v", reply)
+	}
+	if shared.NoOne != "secret" {
+		t.Errorf("expected handler reply untouched, got %v", shared)
+	}
+}
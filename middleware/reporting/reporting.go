@@ -0,0 +1,144 @@
+// Package reporting reports server errors to alerting backends such as Sentry.
+package reporting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// Tag keys set by the middleware.
+const (
+	TagKind          = "kind"
+	TagOperation     = "operation"
+	TagCode          = "code"
+	TagReason        = "reason"
+	TagTraceID       = "trace_id"
+	TagRequestDigest = "request_digest"
+)
+
+// Reporter captures errors into an alerting backend.
+type Reporter interface {
+	// CaptureError reports the error with the tags, it should not block the request for long.
+	CaptureError(ctx context.Context, err error, tags map[string]string)
+}
+
+// ReporterFunc is a function that implements Reporter.
+type ReporterFunc func(ctx context.Context, err error, tags map[string]string)
+
+// CaptureError calls f(ctx, err, tags).
+func (f ReporterFunc) CaptureError(ctx context.Context, err error, tags map[string]string) {
+	f(ctx, err, tags)
+}
+
+// Nop is a reporter that discards all errors.
+var Nop Reporter = ReporterFunc(func(context.Context, error, map[string]string) {})
+
+// Option is reporting option.
+type Option func(*options)
+
+// WithFilter with the function deciding which errors are reported,
+// by default the errors with a 5xx code, including the internal and unknown errors.
+func WithFilter(f func(err error) bool) Option {
+	return func(o *options) {
+		o.filter = f
+	}
+}
+
+// WithTags with the static tags added to every report, e.g. the service version.
+func WithTags(tags map[string]string) Option {
+	return func(o *options) {
+		for k, v := range tags {
+			o.tags[k] = v
+		}
+	}
+}
+
+type options struct {
+	filter func(err error) bool
+	tags   map[string]string
+}
+
+// Server is a server middleware that reports the errors of handlers with the
+// operation, trace id and a digest of the request, the request itself is not
+// reported so no PII leaves the service. A nil reporter disables reporting.
+func Server(reporter Reporter, opts ...Option) middleware.Middleware {
+	o := &options{
+		filter: serverError,
+		tags:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if reporter == nil {
+		reporter = Nop
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			if err != nil && o.filter(err) {
+				reporter.CaptureError(ctx, err, o.buildTags(ctx, req, err))
+			}
+			return reply, err
+		}
+	}
+}
+
+// buildTags returns the tags of the error report.
+func (o *options) buildTags(ctx context.Context, req interface{}, err error) map[string]string {
+	tags := make(map[string]string, len(o.tags)+6)
+	for k, v := range o.tags {
+		tags[k] = v
+	}
+	if info, ok := transport.FromServerContext(ctx); ok {
+		tags[TagKind] = info.Kind().String()
+		tags[TagOperation] = info.Operation()
+	}
+	se := errors.FromError(err)
+	tags[TagCode] = strconv.Itoa(int(se.Code))
+	tags[TagReason] = se.Reason
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		tags[TagTraceID] = span.TraceID().String()
+	}
+	if digest := Digest(req); digest != "" {
+		tags[TagRequestDigest] = digest
+	}
+	return tags
+}
+
+// serverError reports whether the error is a server error.
+func serverError(err error) bool {
+	return errors.Code(err) >= 500
+}
+
+// Digest returns a short sha256 digest of the request, which groups the
+// reports of the same request without including its content.
+// Proto messages are encoded deterministically, others as JSON.
+func Digest(req interface{}) string {
+	if req == nil {
+		return ""
+	}
+	var (
+		data []byte
+		err  error
+	)
+	if m, ok := req.(proto.Message); ok {
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	} else {
+		data, err = json.Marshal(req)
+	}
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,130 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/testdata/complex"
+	"github.com/cnsync/kratos/transport"
+)
+
+type Transport struct {
+	transport.Transporter
+	operation string
+}
+
+func (tr *Transport) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		report bool
+	}{
+		{"ok", nil, false},
+		{"bad request", errors.BadRequest("BAD", "bad"), false},
+		{"internal", errors.InternalServer("DB", "db failed"), true},
+		{"unknown", context.DeadlineExceeded, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tags map[string]string
+			reporter := ReporterFunc(func(_ context.Context, _ error, t map[string]string) {
+				tags = t
+			})
+			ctx := transport.NewServerContext(context.Background(), &Transport{operation: "/test.Service/Get"})
+			next := func(context.Context, interface{}) (interface{}, error) { return nil, test.err }
+			_, err := Server(reporter, WithTags(map[string]string{"version": "v1"}))(next)(ctx, &complex.Complex{Id: 1})
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected %v, got %v", test.err, err)
+			}
+			if (tags != nil) != test.report {
+				t.Fatalf("expected report %v, got %v", test.report, tags)
+			}
+			if !test.report {
+				return
+			}
+			if tags[TagOperation] != "/test.Service/Get" || tags[TagKind] != "http" || tags["version"] != "v1" {
+				t.Errorf("unexpected tags %v", tags)
+			}
+			if tags[TagCode] != "500" || tags[TagRequestDigest] == "" {
+				t.Errorf("unexpected tags %v", tags)
+			}
+		})
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	reported := false
+	reporter := ReporterFunc(func(context.Context, error, map[string]string) { reported = true })
+	next := func(context.Context, interface{}) (interface{}, error) { return nil, errors.BadRequest("BAD", "bad") }
+	_, _ = Server(reporter, WithFilter(func(error) bool { return true }))(next)(context.Background(), nil)
+	if !reported {
+		t.Error("expected error reported")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	a := Digest(&complex.Complex{Id: 1, Map: map[string]string{"a": "1", "b": "2"}})
+	b := Digest(&complex.Complex{Id: 1, Map: map[string]string{"b": "2", "a": "1"}})
+	if a == "" || a != b {
+		t.Errorf("expected stable digest, got %s and %s", a, b)
+	}
+	if a == Digest(&complex.Complex{Id: 2}) {
+		t.Error("expected different digest")
+	}
+	if Digest(nil) != "" {
+		t.Error("expected empty digest of nil request")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	ch := make(chan *WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		ch <- &p
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(srv.URL, WithTimeout(time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	w.CaptureError(ctx, errors.InternalServer("DB", "db failed"), map[string]string{TagOperation: "op"})
+	// the report is posted even if the request context is done
+	cancel()
+	select {
+	case p := <-ch:
+		if p.Code != 500 || p.Reason != "DB" || p.Message != "db failed" || p.Tags[TagOperation] != "op" {
+			t.Errorf("unexpected payload %+v", p)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("webhook not called")
+	}
+}
+
+func TestWebhookBusy(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-block }))
+	defer srv.Close()
+	defer close(block)
+
+	w := NewWebhook(srv.URL, WithConcurrency(1))
+	w.CaptureError(context.Background(), errors.InternalServer("A", "a"), nil)
+	w.CaptureError(context.Background(), errors.InternalServer("B", "b"), nil)
+	if len(w.sem) != 1 {
+		t.Errorf("expected one report in flight, got %d", len(w.sem))
+	}
+}
@@ -0,0 +1,120 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/log"
+)
+
+var _ Reporter = (*Webhook)(nil)
+
+// WebhookOption is webhook reporter option.
+type WebhookOption func(*Webhook)
+
+// WithClient with the http client used to post the reports.
+func WithClient(c *http.Client) WebhookOption {
+	return func(w *Webhook) {
+		w.client = c
+	}
+}
+
+// WithTimeout with the timeout of posting a report, default is 5s.
+func WithTimeout(d time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.timeout = d
+	}
+}
+
+// WithConcurrency with the maximum number of in flight reports, default is 16.
+// Reports are dropped while the limit is reached so a slow webhook never blocks requests.
+func WithConcurrency(n int) WebhookOption {
+	return func(w *Webhook) {
+		if n > 0 {
+			w.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// Webhook is a reporter posting the errors as JSON to an HTTP endpoint.
+type Webhook struct {
+	url     string
+	client  *http.Client
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+// WebhookPayload is the JSON body posted to the webhook.
+type WebhookPayload struct {
+	Message string            `json:"message"`
+	Code    int32             `json:"code"`
+	Reason  string            `json:"reason"`
+	Tags    map[string]string `json:"tags"`
+	Time    time.Time         `json:"time"`
+}
+
+// NewWebhook returns a reporter posting the errors to the url.
+func NewWebhook(url string, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		url:     url,
+		client:  http.DefaultClient,
+		timeout: 5 * time.Second,
+		sem:     make(chan struct{}, 16),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// CaptureError posts the error to the webhook asynchronously.
+func (w *Webhook) CaptureError(ctx context.Context, err error, tags map[string]string) {
+	se := errors.FromError(err)
+	payload := &WebhookPayload{
+		Message: se.Message,
+		Code:    se.Code,
+		Reason:  se.Reason,
+		Tags:    tags,
+		Time:    time.Now(),
+	}
+	select {
+	case w.sem <- struct{}{}:
+	default:
+		log.Context(ctx).Warnf("reporting: webhook is busy, dropped report of %v", err)
+		return
+	}
+	go func() {
+		defer func() { <-w.sem }()
+		if err := w.post(context.WithoutCancel(ctx), payload); err != nil {
+			log.Context(ctx).Errorf("reporting: failed to post report to webhook: %v", err)
+		}
+	}()
+}
+
+// post sends the payload to the webhook.
+func (w *Webhook) post(ctx context.Context, payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Newf(resp.StatusCode, "WEBHOOK_ERROR", "unexpected status %s", resp.Status)
+	}
+	return nil
+}
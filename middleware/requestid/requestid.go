@@ -0,0 +1,147 @@
+// Package requestid ensures every request carries a request id and propagates it downstream.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/cnsync/kratos/log"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// DefaultHeader is the header that carries the request id.
+const DefaultHeader = "X-Request-Id"
+
+// maxLength is the max length of the request ids accepted from the callers.
+const maxLength = 128
+
+type requestIDKey struct{}
+
+// NewContext returns a new context that carries the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request id in the context.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Option is request id option.
+type Option func(*Options)
+
+// Options is the options of the request id middlewares and filters.
+type Options struct {
+	// Header is the header that carries the request id.
+	Header string
+	// Generator generates the request id when the request does not carry a valid one.
+	Generator func() string
+}
+
+// WithHeader with the header that carries the request id. Default is X-Request-Id.
+func WithHeader(header string) Option {
+	return func(o *Options) {
+		if header != "" {
+			o.Header = header
+		}
+	}
+}
+
+// WithGenerator with the function generating the request ids. Default is Generate.
+func WithGenerator(g func() string) Option {
+	return func(o *Options) {
+		if g != nil {
+			o.Generator = g
+		}
+	}
+}
+
+// NewOptions returns the options applied with opts.
+func NewOptions(opts ...Option) *Options {
+	o := &Options{
+		Header:    DefaultHeader,
+		Generator: Generate,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Ensure returns id if it is a valid request id, or generates a new one.
+func (o *Options) Ensure(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return o.Generator()
+}
+
+// Generate returns a new UUIDv7 request id, which is ordered by time.
+func Generate() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// Valid reports whether id could be accepted from the callers, which must be not empty,
+// at most 128 bytes, and only contain the printable ASCII characters, so it could not inject the logs.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Server is a server middleware that ensures the request carries a request id,
+// attaches it to the context and echoes it in the reply header.
+// It reuses the request id attached by the HTTP RequestID filter if any.
+func Server(opts ...Option) middleware.Middleware {
+	o := NewOptions(opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			id, ok := FromContext(ctx)
+			if tr, has := transport.FromServerContext(ctx); has {
+				if !ok {
+					id = o.Ensure(tr.RequestHeader().Get(o.Header))
+				}
+				tr.ReplyHeader().Set(o.Header, id)
+			} else if !ok {
+				id = o.Generator()
+			}
+			return handler(NewContext(ctx, id), req)
+		}
+	}
+}
+
+// Client is a client middleware that forwards the request id in the context to the downstream services.
+func Client(opts ...Option) middleware.Middleware {
+	o := NewOptions(opts...)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if id, ok := FromContext(ctx); ok {
+				if tr, has := transport.FromClientContext(ctx); has {
+					tr.RequestHeader().Set(o.Header, id)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Valuer returns a log valuer of the request id in the context.
+func Valuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		id, _ := FromContext(ctx)
+		return id
+	}
+}
@@ -0,0 +1,157 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type transportMock struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindGRPC
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return "/helloworld.Greeter/SayHello"
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return tr.replyHeader
+}
+
+func newTransport() *transportMock {
+	return &transportMock{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "keep", incoming: "abc-123", keep: true},
+		{name: "generate", incoming: "", keep: false},
+		{name: "invalid", incoming: "abc\n123", keep: false},
+		{name: "too long", incoming: strings.Repeat("a", 129), keep: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := newTransport()
+			tr.reqHeader.Set(DefaultHeader, test.incoming)
+			var got string
+			h := Server()(func(ctx context.Context, _ interface{}) (interface{}, error) {
+				got, _ = FromContext(ctx)
+				return nil, nil
+			})
+			if _, err := h(transport.NewServerContext(context.Background(), tr), nil); err != nil {
+				t.Fatal(err)
+			}
+			if test.keep && got != test.incoming {
+				t.Errorf("expect %q, got %q", test.incoming, got)
+			}
+			if !test.keep {
+				if id, err := uuid.Parse(got); err != nil || id.Version() != 7 {
+					t.Errorf("expect a generated UUIDv7, got %q", got)
+				}
+			}
+			if tr.replyHeader.Get(DefaultHeader) != got {
+				t.Errorf("expect reply header %q, got %q", got, tr.replyHeader.Get(DefaultHeader))
+			}
+		})
+	}
+}
+
+func TestServer_FromContext(t *testing.T) {
+	// the request id attached by the HTTP filter is reused
+	tr := newTransport()
+	tr.reqHeader.Set(DefaultHeader, "from-header")
+	ctx := NewContext(transport.NewServerContext(context.Background(), tr), "from-filter")
+	var got string
+	h := Server()(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		got, _ = FromContext(ctx)
+		return nil, nil
+	})
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got != "from-filter" || tr.replyHeader.Get(DefaultHeader) != "from-filter" {
+		t.Errorf("expect from-filter, got %q", got)
+	}
+}
+
+func TestClient(t *testing.T) {
+	tr := newTransport()
+	ctx := transport.NewClientContext(NewContext(context.Background(), "abc"), tr)
+	h := Client(WithHeader("X-Trace-Request"))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := tr.reqHeader.Get("X-Trace-Request"); v != "abc" {
+		t.Errorf("expect abc, got %q", v)
+	}
+	// nothing is forwarded without a request id
+	tr = newTransport()
+	if _, err := h(transport.NewClientContext(context.Background(), tr), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.reqHeader) != 0 {
+		t.Errorf("expect no header, got %v", tr.reqHeader)
+	}
+}
+
+func TestValuer(t *testing.T) {
+	v := Valuer()
+	if got := v(context.Background()); got != "" {
+		t.Errorf("expect empty, got %v", got)
+	}
+	if got := v(NewContext(context.Background(), "abc")); got != "abc" {
+		t.Errorf("expect abc, got %v", got)
+	}
+}
+
+func TestWithGenerator(t *testing.T) {
+	o := NewOptions(WithGenerator(func() string { return "fixed" }), WithGenerator(nil), WithHeader(""))
+	if o.Header != DefaultHeader {
+		t.Errorf("expect %s, got %s", DefaultHeader, o.Header)
+	}
+	if id := o.Ensure(""); id != "fixed" {
+		t.Errorf("expect fixed, got %s", id)
+	}
+}
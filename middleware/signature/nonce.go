@@ -0,0 +1,53 @@
+package signature
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryNonceStore is an in-memory NonceStore that evicts the oldest nonces.
+type memoryNonceStore struct {
+	mu      sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	evicts  *list.List
+	nowFunc func() time.Time
+}
+
+type nonceEntry struct {
+	nonce   string
+	expires time.Time
+}
+
+// NewMemoryNonceStore returns an in-memory NonceStore that holds at most size nonces,
+// the oldest nonces are evicted when it is full.
+func NewMemoryNonceStore(size int) NonceStore {
+	return &memoryNonceStore{
+		size:    size,
+		items:   make(map[string]*list.Element),
+		evicts:  list.New(),
+		nowFunc: time.Now,
+	}
+}
+
+func (s *memoryNonceStore) SetNX(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.nowFunc()
+	if el, ok := s.items[nonce]; ok {
+		if now.Before(el.Value.(*nonceEntry).expires) {
+			return false, nil
+		}
+		s.evicts.Remove(el)
+		delete(s.items, nonce)
+	}
+	s.items[nonce] = s.evicts.PushFront(&nonceEntry{nonce: nonce, expires: now.Add(ttl)})
+	for s.size > 0 && s.evicts.Len() > s.size {
+		el := s.evicts.Back()
+		s.evicts.Remove(el)
+		delete(s.items, el.Value.(*nonceEntry).nonce)
+	}
+	return true, nil
+}
@@ -0,0 +1,244 @@
+// Package signature signs and verifies requests with HMAC for partner API authentication.
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+	thttp "github.com/cnsync/kratos/transport/http"
+)
+
+// Headers carrying the signature.
+const (
+	HeaderKeyID     = "X-Signature-Key"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderNonce     = "X-Signature-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+const reason = "INVALID_SIGNATURE"
+
+var (
+	// ErrMissingSignature is returned when the request is not signed.
+	ErrMissingSignature = errors.Unauthorized(reason, "request signature is missing")
+	// ErrUnknownKey is returned when the signing key could not be found.
+	ErrUnknownKey = errors.Unauthorized(reason, "signing key is unknown")
+	// ErrInvalidSignature is returned when the signature does not match the request.
+	ErrInvalidSignature = errors.Unauthorized(reason, "request signature is invalid")
+	// ErrExpiredSignature is returned when the timestamp is out of the allowed clock skew.
+	ErrExpiredSignature = errors.Unauthorized(reason, "request signature has expired")
+	// ErrReplayedRequest is returned when the nonce has been used.
+	ErrReplayedRequest = errors.Unauthorized(reason, "request has been replayed")
+	// ErrNonceStore is returned when the nonce store fails.
+	ErrNonceStore = errors.InternalServer("SIGNATURE_NONCE_STORE", "signature nonce store failed")
+	// ErrWrongContext is returned when there is no transport in the context.
+	ErrWrongContext = errors.Unauthorized(reason, "wrong context for middleware")
+)
+
+// KeyFunc returns the secret of the key id, or an error if the key is unknown.
+type KeyFunc func(ctx context.Context, keyID string) ([]byte, error)
+
+// NonceStore remembers the nonces of the verified requests to reject replays.
+// The method maps to the Redis command SET NX PX, so a Redis client could be adapted
+// to share the nonces between instances.
+type NonceStore interface {
+	// SetNX sets the nonce only if it does not exist, and reports whether it is set.
+	SetNX(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// Option is signature option.
+type Option func(*options)
+
+// WithHash with the hash function of the HMAC. Default is sha256.
+func WithHash(h func() hash.Hash) Option {
+	return func(o *options) {
+		o.hash = h
+	}
+}
+
+// WithSkew with the allowed clock skew between the client and the server. Default is 5m.
+func WithSkew(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.skew = d
+		}
+	}
+}
+
+// WithNonceStore with the store remembering the used nonces.
+// Default is an in-memory store holding 100000 nonces, which only rejects the replays
+// served by the same instance.
+func WithNonceStore(s NonceStore) Option {
+	return func(o *options) {
+		o.nonces = s
+	}
+}
+
+type options struct {
+	hash    func() hash.Hash
+	skew    time.Duration
+	nonces  NonceStore
+	nowFunc func() time.Time
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		hash:    sha256.New,
+		skew:    5 * time.Minute,
+		nowFunc: time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Client is a client middleware that signs the requests with the secret of the key id.
+func Client(keyID string, secret []byte, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			nonce, err := newNonce()
+			if err != nil {
+				return nil, err
+			}
+			timestamp := strconv.FormatInt(o.nowFunc().Unix(), 10)
+			method, path := target(tr)
+			s, err := StringToSign(method, path, timestamp, nonce, req)
+			if err != nil {
+				return nil, err
+			}
+			header := tr.RequestHeader()
+			header.Set(HeaderKeyID, keyID)
+			header.Set(HeaderTimestamp, timestamp)
+			header.Set(HeaderNonce, nonce)
+			header.Set(HeaderSignature, sign(o.hash, secret, s))
+			return handler(ctx, req)
+		}
+	}
+}
+
+// Server is a server middleware that verifies the request signatures.
+// A request is accepted only if its signature matches the secret of the key id,
+// its timestamp is within the allowed clock skew, and its nonce has not been used.
+func Server(keys KeyFunc, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	if o.nonces == nil {
+		o.nonces = NewMemoryNonceStore(100000)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			header := tr.RequestHeader()
+			keyID := header.Get(HeaderKeyID)
+			timestamp := header.Get(HeaderTimestamp)
+			nonce := header.Get(HeaderNonce)
+			signature := header.Get(HeaderSignature)
+			if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+				return nil, ErrMissingSignature
+			}
+			ts, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return nil, ErrInvalidSignature
+			}
+			if d := o.nowFunc().Sub(time.Unix(ts, 0)); d > o.skew || d < -o.skew {
+				return nil, ErrExpiredSignature
+			}
+			secret, err := keys(ctx, keyID)
+			if err != nil || len(secret) == 0 {
+				return nil, ErrUnknownKey
+			}
+			method, path := target(tr)
+			s, err := StringToSign(method, path, timestamp, nonce, req)
+			if err != nil {
+				return nil, ErrInvalidSignature
+			}
+			if !hmac.Equal([]byte(sign(o.hash, secret, s)), []byte(signature)) {
+				return nil, ErrInvalidSignature
+			}
+			// only remember the nonces of valid signatures, so forged requests could not burn them
+			set, err := o.nonces.SetNX(ctx, keyID+":"+nonce, 2*o.skew)
+			if err != nil {
+				return nil, ErrNonceStore.WithCause(err)
+			}
+			if !set {
+				return nil, ErrReplayedRequest
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StringToSign returns the canonical string of the request to be signed:
+// the method, path, timestamp, nonce and the hex sha256 of the request body joined by newlines.
+// The body is the deterministic protobuf encoding of proto messages, or the JSON encoding otherwise,
+// so it does not depend on the codec of the transport.
+func StringToSign(method, path, timestamp, nonce string, req interface{}) (string, error) {
+	body, err := bodyHash(req)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{strings.ToUpper(method), path, timestamp, nonce, body}, "\n"), nil
+}
+
+// target returns the method and path of the request. gRPC requests are POST requests to the operation.
+func target(tr transport.Transporter) (string, string) {
+	if ht, ok := tr.(thttp.Transporter); ok && ht.Request() != nil {
+		return ht.Request().Method, ht.Request().URL.EscapedPath()
+	}
+	return http.MethodPost, tr.Operation()
+}
+
+func bodyHash(req interface{}) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch m := req.(type) {
+	case nil:
+	case proto.Message:
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	default:
+		data, err = json.Marshal(req)
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func sign(h func() hash.Hash, secret []byte, s string) string {
+	mac := hmac.New(h, secret)
+	_, _ = mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
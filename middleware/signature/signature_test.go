@@ -0,0 +1,215 @@
+package signature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type transportMock struct {
+	operation string
+	reqHeader headerCarrier
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindGRPC
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return tr.reqHeader
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return headerCarrier{}
+}
+
+var secrets = map[string][]byte{"partner": []byte("secret")}
+
+func keys(_ context.Context, keyID string) ([]byte, error) {
+	if s, ok := secrets[keyID]; ok {
+		return s, nil
+	}
+	return nil, errors.New("unknown key")
+}
+
+func ok(context.Context, interface{}) (interface{}, error) {
+	return "ok", nil
+}
+
+// signed signs the request with the client middleware and returns the signed headers.
+func signed(t *testing.T, keyID string, secret []byte, req interface{}, opts ...Option) headerCarrier {
+	t.Helper()
+	tr := &transportMock{operation: "/helloworld.Greeter/SayHello", reqHeader: headerCarrier{}}
+	ctx := transport.NewClientContext(context.Background(), tr)
+	if _, err := Client(keyID, secret, opts...)(ok)(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	return tr.reqHeader
+}
+
+func verify(server middleware.Middleware, header headerCarrier, req interface{}) error {
+	tr := &transportMock{operation: "/helloworld.Greeter/SayHello", reqHeader: header}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	_, err := server(ok)(ctx, req)
+	return err
+}
+
+func TestSignature(t *testing.T) {
+	server := Server(keys)
+	req := wrapperspb.String("kratos")
+	header := signed(t, "partner", secrets["partner"], req)
+	for _, k := range []string{HeaderKeyID, HeaderTimestamp, HeaderNonce, HeaderSignature} {
+		if header.Get(k) == "" {
+			t.Fatalf("expect header %s to be set", k)
+		}
+	}
+	if err := verify(server, header, req); err != nil {
+		t.Fatalf("expect valid signature, got %v", err)
+	}
+	// replaying the same request is rejected
+	if err := verify(server, header, req); !errors.Is(err, ErrReplayedRequest) {
+		t.Errorf("expect %v, got %v", ErrReplayedRequest, err)
+	}
+}
+
+func TestSignature_Invalid(t *testing.T) {
+	req := wrapperspb.String("kratos")
+	tests := []struct {
+		name   string
+		header func() headerCarrier
+		req    interface{}
+		err    error
+	}{
+		{
+			name:   "missing",
+			header: func() headerCarrier { return headerCarrier{} },
+			req:    req,
+			err:    ErrMissingSignature,
+		},
+		{
+			name:   "unknown key",
+			header: func() headerCarrier { return signed(t, "unknown", []byte("secret"), req) },
+			req:    req,
+			err:    ErrUnknownKey,
+		},
+		{
+			name:   "wrong secret",
+			header: func() headerCarrier { return signed(t, "partner", []byte("wrong"), req) },
+			req:    req,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "tampered body",
+			header: func() headerCarrier { return signed(t, "partner", secrets["partner"], req) },
+			req:    wrapperspb.String("tampered"),
+			err:    ErrInvalidSignature,
+		},
+		{
+			name: "tampered timestamp",
+			header: func() headerCarrier {
+				h := signed(t, "partner", secrets["partner"], req)
+				h.Set(HeaderTimestamp, strconv.FormatInt(time.Now().Unix()+1, 10))
+				return h
+			},
+			req: req,
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "expired",
+			header: func() headerCarrier {
+				return signed(t, "partner", secrets["partner"], req, func(o *options) {
+					o.nowFunc = func() time.Time { return time.Now().Add(-time.Hour) }
+				})
+			},
+			req: req,
+			err: ErrExpiredSignature,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := verify(Server(keys), test.header(), test.req); !errors.Is(err, test.err) {
+				t.Errorf("expect %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestSignature_WrongContext(t *testing.T) {
+	if _, err := Client("partner", secrets["partner"])(ok)(context.Background(), nil); !errors.Is(err, ErrWrongContext) {
+		t.Errorf("expect %v, got %v", ErrWrongContext, err)
+	}
+	if _, err := Server(keys)(ok)(context.Background(), nil); !errors.Is(err, ErrWrongContext) {
+		t.Errorf("expect %v, got %v", ErrWrongContext, err)
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	s, err := StringToSign("post", "/v1/hello", "1700000000", "abc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of the empty body
+	expected := "POST\n/v1/hello\n1700000000\nabc\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if s != expected {
+		t.Errorf("expect %q, got %q", expected, s)
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	s := NewMemoryNonceStore(1).(*memoryNonceStore)
+	now := time.Now()
+	s.nowFunc = func() time.Time { return now }
+	ctx := context.Background()
+	if set, _ := s.SetNX(ctx, "a", time.Minute); !set {
+		t.Error("expect nonce a to be set")
+	}
+	if set, _ := s.SetNX(ctx, "a", time.Minute); set {
+		t.Error("expect nonce a to be used")
+	}
+	// the nonce could be reused after it expires
+	now = now.Add(time.Minute)
+	if set, _ := s.SetNX(ctx, "a", time.Minute); !set {
+		t.Error("expect expired nonce a to be set")
+	}
+	// the oldest nonce is evicted when the store is full
+	if set, _ := s.SetNX(ctx, "b", time.Minute); !set {
+		t.Error("expect nonce b to be set")
+	}
+	if len(s.items) != 1 {
+		t.Errorf("expect 1 nonce, got %d", len(s.items))
+	}
+}
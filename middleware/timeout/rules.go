@@ -0,0 +1,62 @@
+package timeout
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/cnsync/kratos/config"
+	"github.com/cnsync/kratos/internal/matcher"
+	"github.com/cnsync/kratos/log"
+)
+
+// Rules holds the timeouts keyed by operation selector, it can be updated at runtime.
+// A selector is a full operation, e.g. /helloworld.Greeter/SayHello, or a prefix ending with *,
+// e.g. /helloworld.Greeter/*; "*" matches all operations. A zero timeout disables the timeout
+// of the matched operations.
+type Rules struct {
+	rules atomic.Pointer[matcher.Rules[config.Duration]]
+}
+
+// NewRules creates timeout rules with the given timeouts.
+func NewRules(timeouts map[string]config.Duration) *Rules {
+	r := &Rules{}
+	r.Update(timeouts)
+	return r
+}
+
+// LoadRules creates timeout rules from the config value of key and keeps them updated
+// when the value changes.
+//
+//	timeout:
+//	  "*": 1s
+//	  /helloworld.Greeter/*: 500ms
+//	  /helloworld.Greeter/Export: 30s
+func LoadRules(c config.Config, key string) (*Rules, error) {
+	timeouts := make(map[string]config.Duration)
+	if err := c.Value(key).Scan(&timeouts); err != nil {
+		return nil, err
+	}
+	r := NewRules(timeouts)
+	if err := c.Watch(key, func(_ string, v config.Value) {
+		timeouts := make(map[string]config.Duration)
+		if err := v.Scan(&timeouts); err != nil {
+			log.Errorf("failed to reload timeout rules: %v", err)
+			return
+		}
+		r.Update(timeouts)
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces all timeouts.
+func (r *Rules) Update(timeouts map[string]config.Duration) {
+	r.rules.Store(matcher.NewRules(timeouts))
+}
+
+// Timeout returns the timeout of the operation, false if no rule matches the operation.
+func (r *Rules) Timeout(operation string) (time.Duration, bool) {
+	d, _, ok := r.rules.Load().Match(operation)
+	return time.Duration(d), ok
+}
@@ -0,0 +1,101 @@
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/matcher"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// reason holds the error reason.
+const reason string = "DEADLINE_EXCEEDED"
+
+// ErrDeadlineExceeded is returned when the handler does not complete before the timeout,
+// it is mapped to the gRPC DeadlineExceeded code.
+var ErrDeadlineExceeded = errors.GatewayTimeout(reason, "request timeout")
+
+// Option is timeout option.
+type Option func(*options)
+
+// WithDefault with the timeout of the operations that match no selector.
+// Zero means no timeout is set by the middleware, which is the default.
+func WithDefault(timeout time.Duration) Option {
+	return func(o *options) {
+		o.defaults = timeout
+	}
+}
+
+// WithTimeout with the timeout of the operations that match the selector,
+// the selector is a full operation, e.g. /helloworld.Greeter/SayHello,
+// or a prefix ending with *, e.g. /helloworld.Greeter/*. The full operation takes precedence over prefixes.
+func WithTimeout(selector string, timeout time.Duration) Option {
+	return func(o *options) {
+		o.matcher.Add(selector, withTimeout(timeout))
+	}
+}
+
+// WithRules with the timeouts of the operations, which can be reloaded from config at runtime.
+// The rules take precedence over WithTimeout and WithDefault.
+func WithRules(r *Rules) Option {
+	return func(o *options) {
+		o.rules = r
+	}
+}
+
+type options struct {
+	defaults time.Duration
+	matcher  matcher.Matcher
+	rules    *Rules
+}
+
+// Server is a server middleware that enforces the timeout of each operation.
+// The timeout could only shorten the deadline of the context, so the transport Timeout option
+// should not be shorter than the longest operation timeout.
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{matcher: matcher.New()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var ms []middleware.Middleware
+			if info, ok := transport.FromServerContext(ctx); ok {
+				if o.rules != nil {
+					if timeout, ok := o.rules.Timeout(info.Operation()); ok {
+						if timeout <= 0 {
+							return handler(ctx, req)
+						}
+						return withTimeout(timeout)(handler)(ctx, req)
+					}
+				}
+				ms = o.matcher.Match(info.Operation())
+			}
+			if len(ms) > 0 {
+				return middleware.Chain(ms...)(handler)(ctx, req)
+			}
+			if o.defaults > 0 {
+				return withTimeout(o.defaults)(handler)(ctx, req)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// withTimeout returns a middleware that sets the deadline of the context, the handler is expected to
+// return once the context is done, and its error is reported as ErrDeadlineExceeded.
+func withTimeout(timeout time.Duration) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			reply, err := handler(ctx, req)
+			if err != nil && ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrDeadlineExceeded.WithCause(err)
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/config"
+	kerrors "github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/transport"
+)
+
+type transportMock struct {
+	operation string
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindGRPC
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return nil
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	m := Server(
+		WithDefault(20*time.Millisecond),
+		WithTimeout("/helloworld.Greeter/*", 50*time.Millisecond),
+		WithTimeout("/helloworld.Greeter/SlowOp", time.Second),
+	)
+	tests := []struct {
+		operation string
+		timeout   time.Duration
+	}{
+		{"/helloworld.Greeter/SlowOp", time.Second},
+		{"/helloworld.Greeter/SayHello", 50 * time.Millisecond},
+		{"/helloworld.Other/SayHello", 20 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.operation, func(t *testing.T) {
+			var deadline time.Time
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				deadline, _ = ctx.Deadline()
+				return "reply", nil
+			}
+			ctx := transport.NewServerContext(context.Background(), &transportMock{operation: test.operation})
+			start := time.Now()
+			if _, err := m(handler)(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			if d := deadline.Sub(start); d > test.timeout+10*time.Millisecond || d < test.timeout-10*time.Millisecond {
+				t.Errorf("expected timeout %v, got %v", test.timeout, d)
+			}
+		})
+	}
+}
+
+func TestServerDeadlineExceeded(t *testing.T) {
+	m := Server(WithTimeout("/helloworld.Greeter/SayHello", 10*time.Millisecond))
+	ctx := transport.NewServerContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+
+	// 处理函数因上下文超时返回的错误
+	_, err := m(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})(ctx, nil)
+	if !errors.Is(err, ErrDeadlineExceeded) || !errors.Is(err, context.DeadlineExceeded) || !kerrors.IsGatewayTimeout(err) {
+		t.Errorf("expected %v, got %v", ErrDeadlineExceeded, err)
+	}
+	if s := kerrors.FromError(err).GRPCStatus(); s.Code().String() != "DeadlineExceeded" {
+		t.Errorf("expected DeadlineExceeded, got %v", s.Code())
+	}
+
+	// 处理函数在超时后成功返回时使用其响应
+	reply, err := m(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return "reply", nil
+	})(ctx, nil)
+	if err != nil || reply != "reply" {
+		t.Errorf("unexpected reply %v, %v", reply, err)
+	}
+}
+
+func TestServerNoTimeout(t *testing.T) {
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("unexpected deadline")
+		}
+		return "reply", nil
+	}
+	ctx := transport.NewServerContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	if reply, err := Server()(handler)(ctx, nil); err != nil || reply != "reply" {
+		t.Errorf("unexpected reply %v, %v", reply, err)
+	}
+}
+
+func TestServerPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("expected panic boom, got %v", r)
+		}
+	}()
+	_, _ = Server(WithDefault(time.Second))(func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})(context.Background(), nil)
+}
+
+type testSource struct {
+	data string
+	next chan string
+}
+
+func (s *testSource) Load() ([]*config.KeyValue, error) {
+	return []*config.KeyValue{{Key: "timeout", Value: []byte(s.data), Format: "json"}}, nil
+}
+
+func (s *testSource) Watch() (config.Watcher, error) {
+	return &testWatcher{next: s.next, exit: make(chan struct{})}, nil
+}
+
+type testWatcher struct {
+	next chan string
+	exit chan struct{}
+}
+
+func (w *testWatcher) Next() ([]*config.KeyValue, error) {
+	select {
+	case data := <-w.next:
+		return []*config.KeyValue{{Key: "timeout", Value: []byte(data), Format: "json"}}, nil
+	case <-w.exit:
+		return nil, context.Canceled
+	}
+}
+
+func (w *testWatcher) Stop() error {
+	close(w.exit)
+	return nil
+}
+
+func TestLoadRules(t *testing.T) {
+	source := &testSource{
+		data: `{"server":{"timeout":{"*":"1s","/helloworld.Greeter/*":"500ms","/helloworld.Greeter/Export":0}}}`,
+		next: make(chan string),
+	}
+	c := config.New(config.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	r, err := LoadRules(c, "server.timeout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		operation string
+		timeout   time.Duration
+	}{
+		{"/helloworld.Greeter/Export", 0},
+		{"/helloworld.Greeter/SayHello", 500 * time.Millisecond},
+		{"/helloworld.Other/SayHello", time.Second},
+	}
+	for _, tt := range tests {
+		if timeout, ok := r.Timeout(tt.operation); !ok || timeout != tt.timeout {
+			t.Errorf("%s: expected %v, got %v", tt.operation, tt.timeout, timeout)
+		}
+	}
+
+	source.next <- `{"server":{"timeout":{"/helloworld.Greeter/*":"2s"}}}`
+	deadline := time.Now().Add(time.Second)
+	for {
+		if timeout, _ := r.Timeout("/helloworld.Greeter/SayHello"); timeout == 2*time.Second {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected rules to be reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerWithRules(t *testing.T) {
+	r := NewRules(map[string]config.Duration{
+		"/helloworld.Greeter/*":      config.Duration(10 * time.Millisecond),
+		"/helloworld.Greeter/Export": 0,
+	})
+	m := Server(WithDefault(time.Second), WithRules(r))
+	slow := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return "ok", nil
+		}
+	}
+	// the rules take precedence over the default timeout
+	ctx := transport.NewServerContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/SayHello"})
+	if _, err := m(slow)(ctx, nil); !kerrors.IsGatewayTimeout(err) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	// a zero timeout disables the timeout
+	ctx = transport.NewServerContext(context.Background(), &transportMock{operation: "/helloworld.Greeter/Export"})
+	if _, err := m(slow)(ctx, nil); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package tx
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// reason holds the error reason.
+const reason string = "TX_COMMIT_FAILED"
+
+// ErrCommit is returned when the transaction failed to commit after the handler succeeded.
+var ErrCommit = errors.InternalServer(reason, "transaction commit failed")
+
+// Tx is a transaction carried in the request context.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Driver begins transactions for the middleware, e.g. an adapter of database/sql or an ORM.
+type Driver interface {
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// DriverFunc is an adapter to allow the use of ordinary functions as Driver.
+type DriverFunc func(ctx context.Context) (Tx, error)
+
+// Begin calls f(ctx).
+func (f DriverFunc) Begin(ctx context.Context) (Tx, error) {
+	return f(ctx)
+}
+
+// SQL returns a Driver that begins transactions of database/sql,
+// the data layer could get the *sql.Tx by FromContext.
+func SQL(db *sql.DB, opts *sql.TxOptions) Driver {
+	return DriverFunc(func(ctx context.Context) (Tx, error) {
+		return db.BeginTx(ctx, opts)
+	})
+}
+
+// TransactionManager begins, commits and rolls back the transactions bound to the context,
+// it fits the data layers that keep the transaction in the context themselves, e.g. an ORM wrapper.
+type TransactionManager interface {
+	// Begin begins a transaction and returns the context carrying it.
+	Begin(ctx context.Context) (context.Context, error)
+	// Commit commits the transaction carried by the context returned from Begin.
+	Commit(ctx context.Context) error
+	// Rollback rolls back the transaction carried by the context returned from Begin.
+	Rollback(ctx context.Context) error
+}
+
+// managedTx is a Tx bound to the context returned from TransactionManager.Begin.
+type managedTx struct {
+	ctx context.Context
+	m   TransactionManager
+}
+
+func (tx managedTx) Commit() error   { return tx.m.Commit(tx.ctx) }
+func (tx managedTx) Rollback() error { return tx.m.Rollback(tx.ctx) }
+
+type txKey struct{}
+
+// NewContext returns a new Context that carries the transaction.
+func NewContext(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// FromContext returns the transaction in ctx if it exists.
+func FromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// Option is transaction option.
+type Option func(*options)
+
+// WithOperations with the operations that run in a transaction, an operation ending with "*"
+// matches the operations with the prefix, e.g. "/api.Order/*". By default all operations run in a transaction.
+func WithOperations(operations ...string) Option {
+	return func(o *options) {
+		for _, operation := range operations {
+			o.operations[operation] = struct{}{}
+		}
+	}
+}
+
+type options struct {
+	operations map[string]struct{}
+}
+
+// Server is a server middleware that begins a transaction before the handler,
+// commits it if the handler succeeds and rolls it back if the handler fails or panics.
+// If a transaction already exists in the context, the handler joins it.
+func Server(driver Driver, opts ...Option) middleware.Middleware {
+	return newMiddleware(func(ctx context.Context) (context.Context, Tx, error) {
+		tx, err := driver.Begin(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewContext(ctx, tx), tx, nil
+	}, opts...)
+}
+
+// Managed is a server middleware like Server, but the transactions are managed by the TransactionManager,
+// the handler runs with the context returned from Begin, which also carries the transaction for FromContext.
+func Managed(m TransactionManager, opts ...Option) middleware.Middleware {
+	return newMiddleware(func(ctx context.Context) (context.Context, Tx, error) {
+		txCtx, err := m.Begin(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		tx := managedTx{ctx: txCtx, m: m}
+		return NewContext(txCtx, tx), tx, nil
+	}, opts...)
+}
+
+// newMiddleware returns the transaction middleware with the function beginning the transactions.
+func newMiddleware(begin func(context.Context) (context.Context, Tx, error), opts ...Option) middleware.Middleware {
+	o := &options{operations: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if _, ok := FromContext(ctx); ok || !o.match(ctx) {
+				return handler(ctx, req)
+			}
+			txCtx, tx, err := begin(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				if rerr := recover(); rerr != nil {
+					_ = tx.Rollback()
+					panic(rerr)
+				}
+			}()
+			if reply, err = handler(txCtx, req); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+			if err = tx.Commit(); err != nil {
+				return nil, ErrCommit.WithCause(err)
+			}
+			return reply, nil
+		}
+	}
+}
+
+// match reports whether the operation of the request runs in a transaction.
+func (o *options) match(ctx context.Context) bool {
+	if len(o.operations) == 0 {
+		return true
+	}
+	info, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return false
+	}
+	operation := info.Operation()
+	if _, ok = o.operations[operation]; ok {
+		return true
+	}
+	for pattern := range o.operations {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(operation, prefix) {
+			return true
+		}
+	}
+	return false
+}
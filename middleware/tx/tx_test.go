@@ -0,0 +1,192 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kerrors "github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/transport"
+)
+
+type transportMock struct {
+	operation string
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return nil
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return nil
+}
+
+type mockTx struct {
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *mockTx) Commit() error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *mockTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	errHandler := errors.New("handler error")
+	tests := []struct {
+		name       string
+		err        error
+		commitErr  error
+		committed  bool
+		rolledBack bool
+	}{
+		{"commit", nil, nil, true, false},
+		{"rollback", errHandler, nil, false, true},
+		{"commit error", nil, errors.New("commit error"), true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := &mockTx{commitErr: test.commitErr}
+			driver := DriverFunc(func(context.Context) (Tx, error) { return tx, nil })
+			_, err := Server(driver)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+				if got, ok := FromContext(ctx); !ok || got != tx {
+					t.Error("expected transaction in context")
+				}
+				return "ok", test.err
+			})(context.Background(), nil)
+			if tx.committed != test.committed || tx.rolledBack != test.rolledBack {
+				t.Errorf("unexpected transaction state: %+v", tx)
+			}
+			switch {
+			case test.err != nil && !errors.Is(err, test.err):
+				t.Errorf("expected %v, got %v", test.err, err)
+			case test.commitErr != nil && kerrors.Reason(err) != reason:
+				t.Errorf("expected commit error, got %v", err)
+			case test.err == nil && test.commitErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestServerPanic(t *testing.T) {
+	tx := &mockTx{}
+	driver := DriverFunc(func(context.Context) (Tx, error) { return tx, nil })
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+		if !tx.rolledBack || tx.committed {
+			t.Errorf("unexpected transaction state: %+v", tx)
+		}
+	}()
+	_, _ = Server(driver)(func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})(context.Background(), nil)
+}
+
+func TestServerOperations(t *testing.T) {
+	begins := 0
+	driver := DriverFunc(func(context.Context) (Tx, error) {
+		begins++
+		return &mockTx{}, nil
+	})
+	m := Server(driver, WithOperations("/api.Order/Create"))
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+
+	for _, operation := range []string{"/api.Order/Create", "/api.Order/Get"} {
+		ctx := transport.NewServerContext(context.Background(), &transportMock{operation: operation})
+		_, _ = m(handler)(ctx, nil)
+	}
+	if begins != 1 {
+		t.Errorf("expected 1 transaction, got %d", begins)
+	}
+
+	// 已存在事务时加入该事务
+	begins = 0
+	ctx := transport.NewServerContext(context.Background(), &transportMock{operation: "/api.Order/Create"})
+	_, _ = m(handler)(NewContext(ctx, &mockTx{}), nil)
+	if begins != 0 {
+		t.Errorf("expected to join the existing transaction, got %d begins", begins)
+	}
+}
+
+type managerKey struct{}
+
+type mockManager struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (m *mockManager) Begin(ctx context.Context) (context.Context, error) {
+	return context.WithValue(ctx, managerKey{}, "tx"), nil
+}
+
+func (m *mockManager) Commit(ctx context.Context) error {
+	m.committed = ctx.Value(managerKey{}) == "tx"
+	return nil
+}
+
+func (m *mockManager) Rollback(ctx context.Context) error {
+	m.rolledBack = ctx.Value(managerKey{}) == "tx"
+	return nil
+}
+
+func TestManaged(t *testing.T) {
+	m := &mockManager{}
+	_, err := Managed(m)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if ctx.Value(managerKey{}) != "tx" {
+			t.Error("expected the context returned from Begin")
+		}
+		if _, ok := FromContext(ctx); !ok {
+			t.Error("expected transaction in context")
+		}
+		return "ok", nil
+	})(context.Background(), nil)
+	if err != nil || !m.committed || m.rolledBack {
+		t.Errorf("unexpected result: %v %+v", err, m)
+	}
+
+	m = &mockManager{}
+	_, _ = Managed(m)(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.New("handler error")
+	})(context.Background(), nil)
+	if m.committed || !m.rolledBack {
+		t.Errorf("expected rollback, got %+v", m)
+	}
+}
+
+func TestServerOperationPrefix(t *testing.T) {
+	begins := 0
+	driver := DriverFunc(func(context.Context) (Tx, error) {
+		begins++
+		return &mockTx{}, nil
+	})
+	m := Server(driver, WithOperations("/api.Order/*"))
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	for _, operation := range []string{"/api.Order/Create", "/api.Order/Cancel", "/api.User/Get"} {
+		ctx := transport.NewServerContext(context.Background(), &transportMock{operation: operation})
+		_, _ = m(handler)(ctx, nil)
+	}
+	if begins != 2 {
+		t.Errorf("expected 2 transactions, got %d", begins)
+	}
+}
@@ -0,0 +1,59 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cnsync/kratos/log"
+)
+
+// LogSink returns a sink that writes the usage summaries to the logger.
+func LogSink(logger log.Logger) Sink {
+	return SinkFunc(func(_ context.Context, summaries []*Summary) error {
+		for _, s := range summaries {
+			_ = logger.Log(log.LevelInfo,
+				"kind", "usage",
+				"operation", s.Operation,
+				"calls", s.Calls,
+				"callers", s.Callers,
+				"codes", s.Codes,
+				"start", s.Start,
+				"end", s.End,
+			)
+		}
+		return nil
+	})
+}
+
+// HTTPSink returns a sink that posts the usage summaries as JSON to the url,
+// if client is nil, http.DefaultClient is used.
+func HTTPSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return SinkFunc(func(ctx context.Context, summaries []*Summary) error {
+		body, err := json.Marshal(summaries)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(io.Discard, res.Body)
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("usage: export failed with status %d", res.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,50 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cnsync/kratos/log"
+)
+
+func TestLogSink(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := LogSink(log.NewStdLogger(buf)).Export(context.Background(), []*Summary{{Operation: "/api.User/Get", Calls: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "operation=/api.User/Get calls=3") {
+		t.Errorf("unexpected log: %s", buf.String())
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var got []*Summary
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	err := HTTPSink(srv.URL, nil).Export(context.Background(), []*Summary{{Operation: "/api.User/Get", Calls: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Operation != "/api.User/Get" || got[0].Calls != 3 {
+		t.Errorf("unexpected summaries: %v", got)
+	}
+
+	fail := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer fail.Close()
+	if err = HTTPSink(fail.URL, nil).Export(context.Background(), nil); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,207 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/metadata"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/middleware/auth/jwt"
+	"github.com/cnsync/kratos/transport"
+)
+
+const (
+	defaultInterval   = time.Minute
+	defaultMaxCallers = 10000
+	// callerKey is the metadata key of the caller identity.
+	callerKey = "x-md-global-caller"
+)
+
+// Summary is the usage summary of an operation within an export interval.
+type Summary struct {
+	Operation string        `json:"operation"`
+	Calls     int64         `json:"calls"`
+	Callers   int           `json:"callers"`
+	Codes     map[int]int64 `json:"codes"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+}
+
+// Sink exports the usage summaries.
+type Sink interface {
+	Export(ctx context.Context, summaries []*Summary) error
+}
+
+// SinkFunc is an adapter to allow the use of ordinary functions as Sink.
+type SinkFunc func(ctx context.Context, summaries []*Summary) error
+
+// Export calls f(ctx, summaries).
+func (f SinkFunc) Export(ctx context.Context, summaries []*Summary) error {
+	return f(ctx, summaries)
+}
+
+// Option is usage collector option.
+type Option func(*Collector)
+
+// WithInterval with the export interval, default is one minute.
+func WithInterval(d time.Duration) Option {
+	return func(c *Collector) {
+		c.interval = d
+	}
+}
+
+// WithCaller with the function extracting the caller identity from the request context,
+// by default it is the subject of the jwt claims or the x-md-global-caller metadata.
+func WithCaller(f func(ctx context.Context) string) Option {
+	return func(c *Collector) {
+		c.caller = f
+	}
+}
+
+// WithMaxCallers with the maximum number of unique callers tracked per operation in an interval,
+// callers beyond the limit are not counted. Default is 10000.
+func WithMaxCallers(n int) Option {
+	return func(c *Collector) {
+		c.maxCallers = n
+	}
+}
+
+// stat is the usage statistics of an operation.
+type stat struct {
+	calls   int64
+	callers map[string]struct{}
+	codes   map[int]int64
+}
+
+// Collector aggregates the per-operation usage in memory and periodically exports it to the sink.
+// It implements transport.Server, so it can be registered to the app to run the export loop.
+type Collector struct {
+	sink       Sink
+	interval   time.Duration
+	caller     func(ctx context.Context) string
+	maxCallers int
+
+	mu    sync.Mutex
+	start time.Time
+	stats map[string]*stat
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewCollector new a usage collector exporting to the sink.
+func NewCollector(sink Sink, opts ...Option) *Collector {
+	c := &Collector{
+		sink:       sink,
+		interval:   defaultInterval,
+		caller:     defaultCaller,
+		maxCallers: defaultMaxCallers,
+		start:      time.Now(),
+		stats:      make(map[string]*stat),
+		stop:       make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Server is a server middleware that records the usage of the operations.
+func (c *Collector) Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			if info, ok := transport.FromServerContext(ctx); ok {
+				c.record(info.Operation(), c.caller(ctx), errors.Code(err))
+			}
+			return reply, err
+		}
+	}
+}
+
+// record records a call of the operation.
+func (c *Collector) record(operation, caller string, code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stats[operation]
+	if !ok {
+		s = &stat{callers: make(map[string]struct{}), codes: make(map[int]int64)}
+		c.stats[operation] = s
+	}
+	s.calls++
+	s.codes[code]++
+	if caller != "" && len(s.callers) < c.maxCallers {
+		s.callers[caller] = struct{}{}
+	}
+}
+
+// Snapshot returns the usage summaries since the last snapshot and resets the statistics.
+func (c *Collector) Snapshot() []*Summary {
+	c.mu.Lock()
+	stats, start, end := c.stats, c.start, time.Now()
+	c.stats, c.start = make(map[string]*stat), end
+	c.mu.Unlock()
+
+	summaries := make([]*Summary, 0, len(stats))
+	for operation, s := range stats {
+		summaries = append(summaries, &Summary{
+			Operation: operation,
+			Calls:     s.calls,
+			Callers:   len(s.callers),
+			Codes:     s.codes,
+			Start:     start,
+			End:       end,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Operation < summaries[j].Operation
+	})
+	return summaries
+}
+
+// Flush exports the usage summaries since the last export.
+func (c *Collector) Flush(ctx context.Context) error {
+	summaries := c.Snapshot()
+	if len(summaries) == 0 {
+		return nil
+	}
+	return c.sink.Export(ctx, summaries)
+}
+
+// Start runs the export loop until the collector is stopped.
+func (c *Collector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.stop:
+			return nil
+		case <-ticker.C:
+			_ = c.Flush(ctx)
+		}
+	}
+}
+
+// Stop stops the export loop and exports the remaining usage summaries.
+func (c *Collector) Stop(ctx context.Context) error {
+	c.once.Do(func() { close(c.stop) })
+	return c.Flush(ctx)
+}
+
+// defaultCaller returns the subject of the jwt claims or the x-md-global-caller metadata.
+func defaultCaller(ctx context.Context) string {
+	if claims, ok := jwt.FromContext(ctx); ok && claims != nil {
+		if sub, err := claims.GetSubject(); err == nil && sub != "" {
+			return sub
+		}
+	}
+	if md, ok := metadata.FromServerContext(ctx); ok {
+		return md.Get(callerKey)
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package usage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/metadata"
+	"github.com/cnsync/kratos/middleware/auth/jwt"
+	"github.com/cnsync/kratos/transport"
+)
+
+type transportMock struct {
+	operation string
+}
+
+func (tr *transportMock) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+func (tr *transportMock) Endpoint() string {
+	return ""
+}
+
+func (tr *transportMock) Operation() string {
+	return tr.operation
+}
+
+func (tr *transportMock) RequestHeader() transport.Header {
+	return nil
+}
+
+func (tr *transportMock) ReplyHeader() transport.Header {
+	return nil
+}
+
+func TestCollector(t *testing.T) {
+	var exported []*Summary
+	c := NewCollector(SinkFunc(func(_ context.Context, summaries []*Summary) error {
+		exported = append(exported, summaries...)
+		return nil
+	}))
+	call := func(operation, caller string, err error) {
+		ctx := transport.NewServerContext(context.Background(), &transportMock{operation: operation})
+		ctx = metadata.NewServerContext(ctx, metadata.New(map[string][]string{callerKey: {caller}}))
+		_, _ = c.Server()(func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		})(ctx, nil)
+	}
+	call("/api.User/Get", "alice", nil)
+	call("/api.User/Get", "alice", nil)
+	call("/api.User/Get", "bob", errors.NotFound("NOT_FOUND", ""))
+	call("/api.User/Create", "bob", errors.BadRequest("INVALID", ""))
+
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 2 {
+		t.Fatalf("expected 2 summaries, got %d", len(exported))
+	}
+	s := exported[1]
+	if s.Operation != "/api.User/Get" || s.Calls != 3 || s.Callers != 2 || !reflect.DeepEqual(s.Codes, map[int]int64{200: 2, 404: 1}) {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if s := exported[0]; s.Operation != "/api.User/Create" || s.Calls != 1 || s.Codes[400] != 1 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	// 导出后重新开始统计
+	if summaries := c.Snapshot(); len(summaries) != 0 {
+		t.Errorf("expected no summaries, got %v", summaries)
+	}
+}
+
+func TestCollectorStart(t *testing.T) {
+	exported := make(chan []*Summary, 1)
+	c := NewCollector(SinkFunc(func(_ context.Context, summaries []*Summary) error {
+		exported <- summaries
+		return nil
+	}), WithInterval(10*time.Millisecond), WithCaller(func(context.Context) string { return "alice" }))
+	done := make(chan error)
+	go func() { done <- c.Start(context.Background()) }()
+
+	ctx := transport.NewServerContext(context.Background(), &transportMock{operation: "/api.User/Get"})
+	_, _ = c.Server()(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(ctx, nil)
+	select {
+	case summaries := <-exported:
+		if len(summaries) != 1 || summaries[0].Callers != 1 {
+			t.Errorf("unexpected summaries: %v", summaries)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected summaries to be exported")
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxCallers(t *testing.T) {
+	c := NewCollector(nil, WithMaxCallers(1))
+	c.record("/api.User/Get", "alice", 200)
+	c.record("/api.User/Get", "bob", 200)
+	if s := c.Snapshot(); s[0].Calls != 2 || s[0].Callers != 1 {
+		t.Errorf("unexpected summary: %+v", s[0])
+	}
+}
+
+func TestDefaultCaller(t *testing.T) {
+	ctx := jwt.NewContext(context.Background(), jwtv5.RegisteredClaims{Subject: "alice"})
+	if caller := defaultCaller(ctx); caller != "alice" {
+		t.Errorf("expected alice, got %s", caller)
+	}
+	if caller := defaultCaller(context.Background()); caller != "" {
+		t.Errorf("expected empty caller, got %s", caller)
+	}
+}
@@ -28,8 +28,10 @@ type options struct {
 	logger           log.Logger
 	registrar        registry.Registrar
 	registrarTimeout time.Duration
-	stopTimeout      time.Duration
-	servers          []transport.Server
+	// 注销超时时间，小于等于 0 时使用 registrarTimeout
+	deregisterTimeout time.Duration
+	stopTimeout       time.Duration
+	servers           []transport.Server
 
 	// 启动前和停止后的函数
 	beforeStart []func(context.Context) error
@@ -93,6 +95,11 @@ func RegistrarTimeout(t time.Duration) Option {
 	return func(o *options) { o.registrarTimeout = t }
 }
 
+// DeregisterTimeout 用于设置停止时注销服务实例的超时时间，未设置时使用注册器超时时间。
+func DeregisterTimeout(t time.Duration) Option {
+	return func(o *options) { o.deregisterTimeout = t }
+}
+
 // StopTimeout 用于设置应用程序停止超时时间。
 func StopTimeout(t time.Duration) Option {
 	return func(o *options) { o.stopTimeout = t }
@@ -190,6 +190,16 @@ func TestRegistrarTimeout(t *testing.T) {
 	}
 }
 
+// TestDeregisterTimeout 测试注销超时设置方法
+func TestDeregisterTimeout(t *testing.T) {
+	o := &options{}
+	v := time.Duration(456)
+	DeregisterTimeout(v)(o)
+	if !reflect.DeepEqual(v, o.deregisterTimeout) {
+		t.Fatal("o.deregisterTimeout is not equal to v")
+	}
+}
+
 // TestStopTimeout 测试停止超时设置方法
 func TestStopTimeout(t *testing.T) {
 	// 创建一个 options 实例
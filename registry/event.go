@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+)
+
+// EventType 是服务实例变更事件的类型。
+type EventType int
+
+const (
+	// EventAdded 表示新增了服务实例。
+	EventAdded EventType = iota + 1
+	// EventUpdated 表示服务实例的元数据、端点或版本发生了变化。
+	EventUpdated
+	// EventRemoved 表示服务实例被移除。
+	EventRemoved
+)
+
+// String 返回事件类型的名称。
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "ADDED"
+	case EventUpdated:
+		return "UPDATED"
+	case EventRemoved:
+		return "REMOVED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Event 是单个服务实例的变更事件。
+type Event struct {
+	Type     EventType
+	Instance *ServiceInstance
+}
+
+// EventWatcher 是以增量事件通知服务实例变更的监视器，
+// 大规模部署中只传递发生变化的实例，避免每次变更都传递完整的实例列表。
+type EventWatcher interface {
+	// NextEvents 返回自上次调用以来的变更事件，第一次调用时所有实例以 EventAdded 事件返回。
+	// 返回的事件可能为空，表示底层监视器返回的实例列表没有变化。
+	NextEvents() ([]*Event, error)
+	// Stop 关闭监视器。
+	Stop() error
+}
+
+// NewEventWatcher 返回增量事件监视器。
+// 如果 w 本身实现了 EventWatcher 则直接返回，否则通过比较前后两次的实例列表生成增量事件，
+// 用于适配只支持返回完整实例列表的注册中心。
+func NewEventWatcher(w Watcher) EventWatcher {
+	if ew, ok := w.(EventWatcher); ok {
+		return ew
+	}
+	return &diffWatcher{w: w, set: NewInstanceSet()}
+}
+
+// diffWatcher 通过比较实例列表将 Watcher 转换为 EventWatcher。
+type diffWatcher struct {
+	w   Watcher
+	set *InstanceSet
+}
+
+// NextEvents 获取下一个实例列表，并返回与上一个实例列表相比的变更事件。
+func (d *diffWatcher) NextEvents() ([]*Event, error) {
+	ins, err := d.w.Next()
+	if err != nil {
+		return nil, err
+	}
+	events := d.set.Diff(ins)
+	d.set.Apply(events)
+	return events, nil
+}
+
+// Stop 关闭底层监视器。
+func (d *diffWatcher) Stop() error {
+	return d.w.Stop()
+}
+
+// InstanceSet 是由增量事件维护的服务实例集合，非并发安全。
+type InstanceSet struct {
+	instances map[string]*ServiceInstance
+}
+
+// NewInstanceSet 创建一个空的服务实例集合。
+func NewInstanceSet() *InstanceSet {
+	return &InstanceSet{instances: make(map[string]*ServiceInstance)}
+}
+
+// Apply 将变更事件应用到集合中，返回集合是否发生了变化。
+func (s *InstanceSet) Apply(events []*Event) bool {
+	changed := false
+	for _, e := range events {
+		if e == nil || e.Instance == nil {
+			continue
+		}
+		key := instanceKey(e.Instance)
+		old, ok := s.instances[key]
+		switch e.Type {
+		case EventAdded, EventUpdated:
+			if ok && old.Equal(e.Instance) {
+				continue
+			}
+			s.instances[key] = e.Instance
+			changed = true
+		case EventRemoved:
+			if ok {
+				delete(s.instances, key)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Diff 返回将集合变为 ins 所需的变更事件，不修改集合本身。
+func (s *InstanceSet) Diff(ins []*ServiceInstance) []*Event {
+	var events []*Event
+	seen := make(map[string]struct{}, len(ins))
+	for _, in := range ins {
+		if in == nil {
+			continue
+		}
+		key := instanceKey(in)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		old, ok := s.instances[key]
+		switch {
+		case !ok:
+			events = append(events, &Event{Type: EventAdded, Instance: in})
+		case !old.Equal(in):
+			events = append(events, &Event{Type: EventUpdated, Instance: in})
+		}
+	}
+	for _, key := range s.keys() {
+		if _, ok := seen[key]; !ok {
+			events = append(events, &Event{Type: EventRemoved, Instance: s.instances[key]})
+		}
+	}
+	return events
+}
+
+// List 返回集合中的所有服务实例，按实例 ID 排序。
+func (s *InstanceSet) List() []*ServiceInstance {
+	keys := s.keys()
+	ins := make([]*ServiceInstance, 0, len(keys))
+	for _, key := range keys {
+		ins = append(ins, s.instances[key])
+	}
+	return ins
+}
+
+// Len 返回集合中服务实例的数量。
+func (s *InstanceSet) Len() int {
+	return len(s.instances)
+}
+
+// keys 返回排序后的实例键。
+func (s *InstanceSet) keys() []string {
+	keys := make([]string, 0, len(s.instances))
+	for key := range s.instances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// instanceKey 返回标识服务实例的键，没有实例 ID 时使用端点地址。
+func instanceKey(in *ServiceInstance) string {
+	if in.ID != "" {
+		return in.ID
+	}
+	endpoints := append([]string(nil), in.Endpoints...)
+	sort.Strings(endpoints)
+	return in.Name + "/" + strings.Join(endpoints, ",")
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+)
+
+type snapshotWatcher struct {
+	snapshots [][]*ServiceInstance
+}
+
+func (w *snapshotWatcher) Next() ([]*ServiceInstance, error) {
+	if len(w.snapshots) == 0 {
+		return nil, errors.New("done")
+	}
+	ins := w.snapshots[0]
+	w.snapshots = w.snapshots[1:]
+	return ins, nil
+}
+
+func (w *snapshotWatcher) Stop() error { return nil }
+
+func TestEventWatcher(t *testing.T) {
+	a := &ServiceInstance{ID: "a", Name: "svc", Endpoints: []string{"grpc://127.0.0.1:9000"}}
+	b := &ServiceInstance{ID: "b", Name: "svc", Endpoints: []string{"grpc://127.0.0.1:9001"}}
+	b2 := &ServiceInstance{ID: "b", Name: "svc", Endpoints: []string{"grpc://127.0.0.1:9001"}, Metadata: map[string]string{"weight": "10"}}
+	w := NewEventWatcher(&snapshotWatcher{snapshots: [][]*ServiceInstance{
+		{a, b},
+		{a, b},
+		{b2},
+	}})
+
+	// 第一次返回所有实例的新增事件
+	events, err := w.NextEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, events, EventAdded, EventAdded)
+
+	// 实例列表没有变化时不返回事件
+	events, err = w.NextEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, events)
+
+	// 元数据变化返回更新事件，消失的实例返回移除事件
+	events, err = w.NextEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, events, EventUpdated, EventRemoved)
+	if events[1].Instance.ID != "a" {
+		t.Errorf("expected a removed, got %s", events[1].Instance.ID)
+	}
+
+	if _, err = w.NextEvents(); err == nil {
+		t.Error("expected error from underlying watcher")
+	}
+}
+
+func TestInstanceSet(t *testing.T) {
+	set := NewInstanceSet()
+	a := &ServiceInstance{ID: "a"}
+	b := &ServiceInstance{ID: "b"}
+	if !set.Apply([]*Event{{Type: EventAdded, Instance: b}, {Type: EventAdded, Instance: a}}) {
+		t.Error("expected set changed")
+	}
+	if set.Apply([]*Event{{Type: EventUpdated, Instance: &ServiceInstance{ID: "a"}}}) {
+		t.Error("expected set not changed by equal instance")
+	}
+	list := set.List()
+	if len(list) != 2 || list[0] != a || list[1] != b {
+		t.Errorf("unexpected list %v", list)
+	}
+	if !set.Apply([]*Event{{Type: EventRemoved, Instance: a}}) || set.Len() != 1 {
+		t.Errorf("expected a removed, got %v", set.List())
+	}
+	if set.Apply([]*Event{{Type: EventRemoved, Instance: a}}) {
+		t.Error("expected removing missing instance not change set")
+	}
+}
+
+func assertEvents(t *testing.T, events []*Event, types ...EventType) {
+	t.Helper()
+	if len(events) != len(types) {
+		t.Fatalf("expected %d events, got %d", len(types), len(events))
+	}
+	for i, e := range events {
+		if e.Type != types[i] {
+			t.Errorf("event %d: expected %s, got %s", i, types[i], e.Type)
+		}
+	}
+}
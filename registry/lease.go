@@ -0,0 +1,27 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost 表示租约已经失效，服务实例需要重新注册。
+var ErrLeaseLost = errors.New("registry: lease lost")
+
+// Lease 是服务实例注册的租约。
+type Lease interface {
+	// TTL 返回租约的有效期，小于等于 0 表示租约不需要续约。
+	TTL() time.Duration
+	// Heartbeat 续约，租约已经失效时返回 ErrLeaseLost，其他错误视为暂时的失败。
+	Heartbeat(ctx context.Context) error
+}
+
+// RegistrarTTL 是支持租约的服务注册器。
+// kratos.App 使用 RegisterLease 注册服务实例，按 TTL 的三分之一附近的随机间隔续约，并在租约失效后重新注册，
+// 注册中心的驱动只需要实现单次的续约，不必各自实现保活逻辑。
+type RegistrarTTL interface {
+	Registrar
+	// RegisterLease 注册服务实例并返回租约。
+	RegisterLease(ctx context.Context, service *ServiceInstance) (Lease, error)
+}
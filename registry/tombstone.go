@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Tombstone 将注册状态记录到本地文件的服务注册器。
+// 进程被 SIGKILL 等方式异常终止时来不及注销，注册中心中会残留旧的实例，
+// 重启后再次注册前，Tombstone 会先注销文件中记录的旧实例，再写入新的实例。
+// 正常注销成功后删除状态文件。
+type Tombstone struct {
+	r    Registrar
+	path string
+	mu   sync.Mutex
+}
+
+// tombstoneTTL 是被包装的注册器支持租约时使用的 Tombstone。
+type tombstoneTTL struct {
+	*Tombstone
+	ttl RegistrarTTL
+}
+
+// NewTombstone 返回将注册状态记录到 path 的服务注册器。
+// r 实现了 RegistrarTTL 时，返回的注册器同样实现 RegistrarTTL。
+func NewTombstone(r Registrar, path string) Registrar {
+	t := &Tombstone{r: r, path: path}
+	if ttl, ok := r.(RegistrarTTL); ok {
+		return &tombstoneTTL{Tombstone: t, ttl: ttl}
+	}
+	return t
+}
+
+// Register 注销残留的旧实例后注册服务实例，并记录注册状态。
+func (t *Tombstone) Register(ctx context.Context, service *ServiceInstance) error {
+	return t.register(ctx, service, func() error {
+		return t.r.Register(ctx, service)
+	})
+}
+
+// Deregister 注销服务实例，成功后删除状态文件。
+func (t *Tombstone) Deregister(ctx context.Context, service *ServiceInstance) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.r.Deregister(ctx, service); err != nil {
+		return err
+	}
+	if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// RegisterLease 注销残留的旧实例后注册服务实例并返回租约，并记录注册状态。
+func (t *tombstoneTTL) RegisterLease(ctx context.Context, service *ServiceInstance) (lease Lease, err error) {
+	err = t.register(ctx, service, func() error {
+		lease, err = t.ttl.RegisterLease(ctx, service)
+		return err
+	})
+	return lease, err
+}
+
+// register 清理旧实例后执行注册，注册成功后写入状态文件。
+func (t *Tombstone) register(ctx context.Context, service *ServiceInstance, fn func() error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if stale, err := t.load(); err == nil && stale.ID != service.ID {
+		// 旧实例可能已经随租约过期被注册中心移除，注销失败不影响本次注册
+		_ = t.r.Deregister(ctx, stale)
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return t.store(service)
+}
+
+// load 读取状态文件中记录的服务实例。
+func (t *Tombstone) load() (*ServiceInstance, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, err
+	}
+	var service ServiceInstance
+	if err = json.Unmarshal(data, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// store 以原子替换的方式写入状态文件，避免写入过程中被终止留下不完整的内容。
+func (t *Tombstone) store(service *ServiceInstance) error {
+	data, err := json.Marshal(service)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), t.path)
+}
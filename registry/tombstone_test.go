@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type recordRegistrar struct {
+	registered   []string
+	deregistered []string
+}
+
+func (r *recordRegistrar) Register(_ context.Context, service *ServiceInstance) error {
+	r.registered = append(r.registered, service.ID)
+	return nil
+}
+
+func (r *recordRegistrar) Deregister(_ context.Context, service *ServiceInstance) error {
+	r.deregistered = append(r.deregistered, service.ID)
+	return nil
+}
+
+type recordRegistrarTTL struct {
+	recordRegistrar
+}
+
+type testLease struct{}
+
+func (testLease) TTL() time.Duration                { return time.Second }
+func (testLease) Heartbeat(_ context.Context) error { return nil }
+
+func (r *recordRegistrarTTL) RegisterLease(ctx context.Context, service *ServiceInstance) (Lease, error) {
+	return testLease{}, r.Register(ctx, service)
+}
+
+// TestTombstone 测试异常退出后重启时注销残留的旧实例
+func TestTombstone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instance.json")
+	ctx := context.Background()
+
+	// 第一次启动后被强制终止，没有注销
+	r := &recordRegistrar{}
+	if err := NewTombstone(r, path).Register(ctx, &ServiceInstance{ID: "old", Name: "svc"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected tombstone file: %v", err)
+	}
+
+	// 重启后注册新实例，旧实例被注销
+	r = &recordRegistrar{}
+	ts := NewTombstone(r, path)
+	service := &ServiceInstance{ID: "new", Name: "svc"}
+	if err := ts.Register(ctx, service); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.deregistered) != 1 || r.deregistered[0] != "old" {
+		t.Errorf("expected stale instance to be deregistered, got %v", r.deregistered)
+	}
+	if len(r.registered) != 1 || r.registered[0] != "new" {
+		t.Errorf("expected new instance to be registered, got %v", r.registered)
+	}
+
+	// 正常注销后删除状态文件
+	if err := ts.Deregister(ctx, service); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected tombstone file to be removed, got %v", err)
+	}
+}
+
+// TestTombstone_TTL 测试包装支持租约的注册器
+func TestTombstone_TTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instance.json")
+	r := &recordRegistrarTTL{}
+	ts, ok := NewTombstone(r, path).(RegistrarTTL)
+	if !ok {
+		t.Fatal("expected RegistrarTTL")
+	}
+	lease, err := ts.RegisterLease(context.Background(), &ServiceInstance{ID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease == nil || lease.TTL() != time.Second {
+		t.Errorf("unexpected lease: %v", lease)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("expected tombstone file: %v", err)
+	}
+	if _, ok = NewTombstone(&recordRegistrar{}, path).(RegistrarTTL); ok {
+		t.Error("expected plain Registrar")
+	}
+}
@@ -2,7 +2,9 @@ package selector
 
 import (
 	"context"
+	"maps"
 	"sync/atomic"
+	"time"
 )
 
 var (
@@ -18,6 +20,8 @@ type Default struct {
 	NodeBuilder WeightedNodeBuilder
 	// Balancer 是一个负载均衡器。
 	Balancer Balancer
+	// Events 接收选择器的事件，为 nil 时不产生事件。
+	Events EventSink
 
 	// nodes 是一个原子值，用于存储节点列表。
 	nodes atomic.Value
@@ -25,6 +29,24 @@ type Default struct {
 
 // Select 选择一个节点。
 func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected Node, done DoneFunc, err error) {
+	if d.Events == nil {
+		return d.selectNode(ctx, opts...)
+	}
+	start := time.Now()
+	node, nodeDone, err := d.selectNode(ctx, opts...)
+	d.Events.OnPick(ctx, node, time.Since(start), err)
+	if err != nil {
+		return nil, nil, err
+	}
+	picked := time.Now()
+	return node, func(ctx context.Context, di DoneInfo) {
+		nodeDone(ctx, di)
+		d.Events.OnDone(ctx, node, time.Since(picked), di)
+	}, nil
+}
+
+// selectNode 过滤候选节点并使用负载均衡器选择一个节点。
+func (d *Default) selectNode(ctx context.Context, opts ...SelectOption) (selected Node, done DoneFunc, err error) {
 	var (
 		// options 是一个选择选项。
 		options SelectOptions
@@ -69,12 +91,20 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 	if len(candidates) == 0 {
 		return nil, nil, ErrNoAvailable
 	}
+	// 同一次调用的多次尝试优先选择尚未被选择过的节点。
+	pk, _ := ctx.Value(pickedKey{}).(*picked)
+	if pk != nil {
+		candidates = pk.exclude(candidates)
+	}
 	// 使用负载均衡器选择一个加权节点。
 	wn, done, err := d.Balancer.Pick(ctx, candidates)
 	if err != nil {
 		// 如果选择失败，返回错误。
 		return nil, nil, err
 	}
+	if pk != nil {
+		pk.add(wn.Address())
+	}
 	// 从上下文中获取对等节点信息。
 	p, ok := FromPeerContext(ctx)
 	if ok {
@@ -87,15 +117,29 @@ func (d *Default) Select(ctx context.Context, opts ...SelectOption) (selected No
 
 // Apply 更新节点信息。
 func (d *Default) Apply(nodes []Node) {
+	// 未发生变化的节点沿用原有的加权节点，保留其统计数据与慢启动的进度。
+	previous := make(map[string]WeightedNode)
+	if old, ok := d.nodes.Load().([]WeightedNode); ok {
+		for _, wn := range old {
+			previous[wn.Address()] = wn
+		}
+	}
 	// 创建一个加权节点列表，用于存储更新后的节点。
 	weightedNodes := make([]WeightedNode, 0, len(nodes))
 	// 遍历节点列表，构建加权节点。
 	for _, n := range nodes {
+		if wn, ok := previous[n.Address()]; ok && nodeEqual(wn.Raw(), n) {
+			weightedNodes = append(weightedNodes, wn)
+			continue
+		}
 		// 使用加权节点构建器构建加权节点。
 		weightedNodes = append(weightedNodes, d.NodeBuilder.Build(n))
 	}
 	// 将更新后的加权节点列表存储到原子值中。
 	d.nodes.Store(weightedNodes)
+	if d.Events != nil {
+		d.Events.OnApply(nodes)
+	}
 }
 
 // DefaultBuilder 是 Default 选择器的构建器。
@@ -104,6 +148,8 @@ type DefaultBuilder struct {
 	Node WeightedNodeBuilder
 	// Balancer 是一个负载均衡器构建器。
 	Balancer BalancerBuilder
+	// Events 接收构建的选择器的事件，可选。
+	Events EventSink
 }
 
 // Build 创建 Default 选择器。
@@ -112,5 +158,18 @@ func (db *DefaultBuilder) Build() Selector {
 	return &Default{
 		NodeBuilder: db.Node,
 		Balancer:    db.Balancer.Build(),
+		Events:      db.Events,
+	}
+}
+
+// nodeEqual 判断两个节点的属性是否相同。
+func nodeEqual(a, b Node) bool {
+	if a.Scheme() != b.Scheme() || a.Address() != b.Address() || a.ServiceName() != b.ServiceName() || a.Version() != b.Version() {
+		return false
+	}
+	aw, bw := a.InitialWeight(), b.InitialWeight()
+	if (aw == nil) != (bw == nil) || (aw != nil && *aw != *bw) {
+		return false
 	}
+	return maps.Equal(a.Metadata(), b.Metadata())
 }
@@ -0,0 +1,17 @@
+package selector
+
+import (
+	"context"
+	"time"
+)
+
+// EventSink 接收选择器的事件，应用可以据此导出选择耗时、各节点的 QPS 与错误率等指标，
+// 而无需修改负载均衡器的实现。实现需要是并发安全的，并且不应阻塞。
+type EventSink interface {
+	// OnPick 在选择节点后调用，latency 为选择耗时，选择失败时 node 为 nil，err 为失败原因。
+	OnPick(ctx context.Context, node Node, latency time.Duration, err error)
+	// OnDone 在调用完成时调用，latency 为从选择节点到调用完成的耗时。
+	OnDone(ctx context.Context, node Node, latency time.Duration, di DoneInfo)
+	// OnApply 在节点列表更新时调用。
+	OnApply(nodes []Node)
+}
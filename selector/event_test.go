@@ -0,0 +1,69 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/registry"
+)
+
+// mockEventSink 记录选择器的事件
+type mockEventSink struct {
+	mu     sync.Mutex
+	picks  []error
+	dones  []error
+	applys int
+}
+
+func (s *mockEventSink) OnPick(_ context.Context, _ Node, _ time.Duration, err error) {
+	s.mu.Lock()
+	s.picks = append(s.picks, err)
+	s.mu.Unlock()
+}
+
+func (s *mockEventSink) OnDone(_ context.Context, _ Node, _ time.Duration, di DoneInfo) {
+	s.mu.Lock()
+	s.dones = append(s.dones, di.Err)
+	s.mu.Unlock()
+}
+
+func (s *mockEventSink) OnApply([]Node) {
+	s.mu.Lock()
+	s.applys++
+	s.mu.Unlock()
+}
+
+func TestEventSink(t *testing.T) {
+	sink := &mockEventSink{}
+	builder := DefaultBuilder{
+		Node:     &mockWeightedNodeBuilder{},
+		Balancer: &mockBalancerBuilder{},
+		Events:   sink,
+	}
+	selector := builder.Build()
+
+	// 没有节点时选择失败
+	if _, _, err := selector.Select(context.Background()); !errors.Is(err, ErrNoAvailable) {
+		t.Fatalf("expect %v, got %v", ErrNoAvailable, err)
+	}
+	selector.Apply([]Node{NewNode("http", "127.0.0.1:8080", &registry.ServiceInstance{ID: "127.0.0.1:8080"})})
+	_, done, err := selector.Select(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFailed := errors.New("failed")
+	done(context.Background(), DoneInfo{Err: errFailed})
+
+	if sink.applys != 1 {
+		t.Errorf("expect 1 apply, got %d", sink.applys)
+	}
+	if len(sink.picks) != 2 || !errors.Is(sink.picks[0], ErrNoAvailable) || sink.picks[1] != nil {
+		t.Errorf("unexpected picks %v", sink.picks)
+	}
+	if len(sink.dones) != 1 || sink.dones[0] != errFailed {
+		t.Errorf("unexpected dones %v", sink.dones)
+	}
+}
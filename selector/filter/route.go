@@ -0,0 +1,205 @@
+package filter
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/cnsync/kratos/config"
+	"github.com/cnsync/kratos/log"
+	"github.com/cnsync/kratos/metadata"
+	"github.com/cnsync/kratos/selector"
+	"github.com/cnsync/kratos/transport"
+)
+
+// DefaultService 是没有单独配置路由规则的服务使用的键。
+const DefaultService = "*"
+
+// Match 是路由规则的匹配条件，所有条件都满足时匹配，没有条件时匹配所有请求。
+type Match struct {
+	// Headers 是请求头的取值。
+	Headers map[string]string `json:"headers"`
+	// Metadata 是客户端上下文中元数据的取值。
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Destination 是流量的目标子集。
+type Destination struct {
+	// Version 是节点的版本，为空时不限制版本。
+	Version string `json:"version"`
+	// Metadata 是节点元数据的取值，用于按版本以外的标签划分子集。
+	Metadata map[string]string `json:"metadata"`
+	// Weight 是分配到该子集的流量权重。
+	Weight int `json:"weight"`
+}
+
+// Route 是一条路由规则，匹配的请求按权重分配到目标子集。
+type Route struct {
+	Match        Match          `json:"match"`
+	Destinations []*Destination `json:"destinations"`
+}
+
+// Router 按服务名称保存路由规则，可以在运行时更新。
+type Router struct {
+	routes atomic.Pointer[map[string][]*Route]
+}
+
+// NewRouter 使用指定的路由规则创建路由器。
+func NewRouter(routes map[string][]*Route) *Router {
+	r := &Router{}
+	r.Update(routes)
+	return r
+}
+
+// LoadRouter 从配置中 key 对应的值创建路由器，并在配置变化时更新路由规则。
+//
+//	client:
+//	  routes:
+//	    helloworld:
+//	      - match:
+//	          headers:
+//	            x-canary: "true"
+//	        destinations:
+//	          - version: v2
+//	      - destinations:
+//	          - version: v1
+//	            weight: 95
+//	          - version: v2
+//	            weight: 5
+func LoadRouter(c config.Config, key string) (*Router, error) {
+	routes := make(map[string][]*Route)
+	if err := c.Value(key).Scan(&routes); err != nil {
+		return nil, err
+	}
+	r := NewRouter(routes)
+	if err := c.Watch(key, func(_ string, v config.Value) {
+		routes := make(map[string][]*Route)
+		if err := v.Scan(&routes); err != nil {
+			log.Errorf("failed to reload selector routes: %v", err)
+			return
+		}
+		r.Update(routes)
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update 替换所有的路由规则。
+func (r *Router) Update(routes map[string][]*Route) {
+	r.routes.Store(&routes)
+}
+
+// NodeFilter 返回按路由规则过滤节点的过滤器。
+// 服务名称取自节点，依次匹配服务的路由规则，第一条匹配的规则按权重随机选择一个目标子集；
+// 选中的子集没有节点时使用规则中其他子集的节点，都没有节点或者没有匹配的规则时不过滤节点。
+func (r *Router) NodeFilter() selector.NodeFilter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		if len(nodes) == 0 {
+			return nodes
+		}
+		routes := *r.routes.Load()
+		rs, ok := routes[nodes[0].ServiceName()]
+		if !ok {
+			rs = routes[DefaultService]
+		}
+		for _, route := range rs {
+			if !route.Match.match(ctx) {
+				continue
+			}
+			if d := pick(route.Destinations); d != nil {
+				if subset := d.filter(nodes); len(subset) > 0 {
+					return subset
+				}
+			}
+			var subset []selector.Node
+			for _, d := range route.Destinations {
+				subset = append(subset, d.filter(nodes)...)
+			}
+			if len(subset) > 0 {
+				return subset
+			}
+			return nodes
+		}
+		return nodes
+	}
+}
+
+// match 判断请求是否满足匹配条件。
+func (m *Match) match(ctx context.Context) bool {
+	if len(m.Headers) > 0 {
+		tr, ok := transport.FromClientContext(ctx)
+		if !ok {
+			return false
+		}
+		for k, v := range m.Headers {
+			if tr.RequestHeader().Get(k) != v {
+				return false
+			}
+		}
+	}
+	if len(m.Metadata) > 0 {
+		md, ok := metadata.FromClientContext(ctx)
+		if !ok {
+			return false
+		}
+		for k, v := range m.Metadata {
+			if md.Get(k) != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// filter 返回属于目标子集的节点。
+func (d *Destination) filter(nodes []selector.Node) []selector.Node {
+	subset := make([]selector.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if d.contains(n) {
+			subset = append(subset, n)
+		}
+	}
+	return subset
+}
+
+// contains 判断节点是否属于目标子集。
+func (d *Destination) contains(n selector.Node) bool {
+	if d.Version != "" && n.Version() != d.Version {
+		return false
+	}
+	md := n.Metadata()
+	for k, v := range d.Metadata {
+		if md[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// pick 按权重随机选择一个目标子集，只有一个子集时忽略权重，所有权重都不大于 0 时返回 nil。
+func pick(ds []*Destination) *Destination {
+	if len(ds) == 1 {
+		return ds[0]
+	}
+	total := 0
+	for _, d := range ds {
+		if d.Weight > 0 {
+			total += d.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	n := rand.Intn(total) //nolint:gosec
+	for _, d := range ds {
+		if d.Weight <= 0 {
+			continue
+		}
+		if n < d.Weight {
+			return d
+		}
+		n -= d.Weight
+	}
+	return nil
+}
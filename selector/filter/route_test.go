@@ -0,0 +1,117 @@
+package filter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/cnsync/kratos/metadata"
+	"github.com/cnsync/kratos/registry"
+	"github.com/cnsync/kratos/selector"
+	"github.com/cnsync/kratos/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type mockTransport struct {
+	header headerCarrier
+}
+
+func (tr *mockTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *mockTransport) Endpoint() string                { return "" }
+func (tr *mockTransport) Operation() string               { return "" }
+func (tr *mockTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *mockTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func newNodes() []selector.Node {
+	var nodes []selector.Node
+	for _, ins := range []*registry.ServiceInstance{
+		{ID: "1", Name: "helloworld", Version: "v1", Endpoints: []string{"http://127.0.0.1:9090"}},
+		{ID: "2", Name: "helloworld", Version: "v1", Endpoints: []string{"http://127.0.0.2:9090"}},
+		{ID: "3", Name: "helloworld", Version: "v2", Endpoints: []string{"http://127.0.0.3:9090"}, Metadata: map[string]string{"zone": "b"}},
+	} {
+		nodes = append(nodes, selector.NewNode("http", ins.Endpoints[0][len("http://"):], ins))
+	}
+	return nodes
+}
+
+func TestRouter_Weight(t *testing.T) {
+	r := NewRouter(map[string][]*Route{
+		"helloworld": {{Destinations: []*Destination{{Version: "v1", Weight: 95}, {Version: "v2", Weight: 5}}}},
+	})
+	f := r.NodeFilter()
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		nodes := f(context.Background(), newNodes())
+		if len(nodes) == 0 {
+			t.Fatal("expect nodes")
+		}
+		counts[nodes[0].Version()]++
+	}
+	// 按权重分配流量，允许一定的随机误差
+	if counts["v2"] < 300 || counts["v2"] > 700 {
+		t.Errorf("expect about 500 requests to v2, got %d", counts["v2"])
+	}
+}
+
+func TestRouter_Match(t *testing.T) {
+	r := NewRouter(map[string][]*Route{
+		DefaultService: {
+			{Match: Match{Headers: map[string]string{"x-canary": "true"}}, Destinations: []*Destination{{Version: "v2"}}},
+			{Match: Match{Metadata: map[string]string{"x-md-zone": "b"}}, Destinations: []*Destination{{Metadata: map[string]string{"zone": "b"}}}},
+			{Destinations: []*Destination{{Version: "v1"}}},
+		},
+	})
+	f := r.NodeFilter()
+
+	// 请求头匹配
+	ctx := transport.NewClientContext(context.Background(), &mockTransport{header: headerCarrier{"X-Canary": []string{"true"}}})
+	if nodes := f(ctx, newNodes()); len(nodes) != 1 || nodes[0].Version() != "v2" {
+		t.Errorf("expect canary node, got %v", nodes)
+	}
+	// 元数据匹配
+	ctx = metadata.NewClientContext(context.Background(), metadata.New(map[string][]string{"x-md-zone": {"b"}}))
+	if nodes := f(ctx, newNodes()); len(nodes) != 1 || nodes[0].Metadata()["zone"] != "b" {
+		t.Errorf("expect zone b node, got %v", nodes)
+	}
+	// 默认规则
+	if nodes := f(context.Background(), newNodes()); len(nodes) != 2 || nodes[0].Version() != "v1" {
+		t.Errorf("expect v1 nodes, got %v", nodes)
+	}
+}
+
+func TestRouter_Fallback(t *testing.T) {
+	r := NewRouter(map[string][]*Route{
+		"helloworld": {{Destinations: []*Destination{{Version: "v3", Weight: 100}, {Version: "v2", Weight: 0}}}},
+	})
+	// 选中的子集没有节点时使用其他子集的节点
+	if nodes := r.NodeFilter()(context.Background(), newNodes()); len(nodes) != 1 || nodes[0].Version() != "v2" {
+		t.Errorf("expect v2 node, got %v", nodes)
+	}
+	// 更新规则后生效，所有子集都没有节点时不过滤
+	r.Update(map[string][]*Route{"helloworld": {{Destinations: []*Destination{{Version: "v3"}}}}})
+	if nodes := r.NodeFilter()(context.Background(), newNodes()); len(nodes) != 3 {
+		t.Errorf("expect all nodes, got %v", nodes)
+	}
+	// 没有规则的服务不过滤
+	r.Update(nil)
+	if nodes := r.NodeFilter()(context.Background(), newNodes()); len(nodes) != 3 {
+		t.Errorf("expect all nodes, got %v", nodes)
+	}
+}
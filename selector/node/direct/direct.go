@@ -27,15 +27,21 @@ type Node struct {
 
 	// 最后一次选择的时间戳
 	lastPick int64
+	// 节点的创建时间与慢启动窗口
+	created   time.Time
+	slowStart time.Duration
 }
 
 // Builder 是直接节点构建器
-type Builder struct{}
+type Builder struct {
+	// SlowStart 是新节点的慢启动窗口，窗口内节点的权重线性提升，为零时不启用
+	SlowStart time.Duration
+}
 
 // Build 创建一个新的节点
-func (*Builder) Build(n selector.Node) selector.WeightedNode {
+func (b *Builder) Build(n selector.Node) selector.WeightedNode {
 	// 返回一个新的 Node 实例，初始 lastPick 时间戳为 0
-	return &Node{Node: n, lastPick: 0}
+	return &Node{Node: n, lastPick: 0, created: time.Now(), slowStart: b.SlowStart}
 }
 
 // Pick 选择一个节点并返回一个完成时调用的回调函数
@@ -50,12 +56,12 @@ func (n *Node) Pick() selector.DoneFunc {
 
 // Weight 获取节点的有效权重
 func (n *Node) Weight() float64 {
-	// 如果节点有初始权重，则返回初始权重
+	// 如果节点有初始权重，则使用初始权重，否则使用默认权重
+	weight := float64(defaultWeight)
 	if n.InitialWeight() != nil {
-		return float64(*n.InitialWeight())
+		weight = float64(*n.InitialWeight())
 	}
-	// 如果没有初始权重，则返回默认权重
-	return defaultWeight
+	return selector.SlowStartWeight(weight, time.Since(n.created), n.slowStart)
 }
 
 // PickElapsed 获取自上次选择以来的时间
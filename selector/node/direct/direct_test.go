@@ -96,3 +96,22 @@ func TestDirectDefaultWeight(t *testing.T) {
 		t.Errorf("time.Millisecond*5 >= wn.PickElapsed()(%s)", wn.PickElapsed())
 	}
 }
+
+// TestDirectSlowStart 测试慢启动期间节点的权重线性提升
+func TestDirectSlowStart(t *testing.T) {
+	b := &Builder{SlowStart: time.Minute}
+	wn := b.Build(selector.NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{
+		Metadata: map[string]string{"weight": "10"},
+	})).(*Node)
+	if w := wn.Weight(); w != 1 {
+		t.Errorf("expect weight 1 at the beginning, got %v", w)
+	}
+	wn.created = time.Now().Add(-30 * time.Second)
+	if w := wn.Weight(); w < 4.9 || w > 5.1 {
+		t.Errorf("expect weight about 5 in the middle of the window, got %v", w)
+	}
+	wn.created = time.Now().Add(-time.Minute)
+	if w := wn.Weight(); w != 10 {
+		t.Errorf("expect weight 10 after the window, got %v", w)
+	}
+}
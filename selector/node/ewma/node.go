@@ -42,6 +42,13 @@ type Node struct {
 
 	errHandler   func(err error) (isErr bool) // 错误处理函数
 	cachedWeight *atomic.Value                // 用于缓存权重的原子变量
+
+	tau          int64  // 延迟移动平均的时间常数
+	successDecay int64  // 成功率移动平均的时间常数
+	penalty      uint64 // 没有统计信息时的延迟惩罚值
+
+	created   time.Time     // 节点的创建时间
+	slowStart time.Duration // 慢启动窗口
 }
 
 type nodeWeight struct {
@@ -49,9 +56,76 @@ type nodeWeight struct {
 	updateAt int64   // 更新时间戳
 }
 
+// Option 是 ewma 构建器的选项。
+type Option func(b *Builder)
+
+// WithTau 设置延迟移动平均的时间常数，延迟统计在 tau*ln(2) 后衰减一半，默认为 600ms。
+// 延迟长尾明显的服务可以调大 tau 以平滑偶发的慢请求。非正数的值会被忽略。
+func WithTau(tau time.Duration) Option {
+	return func(b *Builder) {
+		if tau > 0 {
+			b.Tau = tau
+		}
+	}
+}
+
+// WithPenalty 设置节点没有延迟统计时的延迟惩罚值，默认为 100us。非正数的值会被忽略。
+func WithPenalty(penalty time.Duration) Option {
+	return func(b *Builder) {
+		if penalty > 0 {
+			b.Penalty = penalty
+		}
+	}
+}
+
+// WithSuccessDecay 设置成功率移动平均的时间常数，默认与延迟的时间常数相同。
+// 调大后节点的成功率在出错后恢复得更慢。非正数的值会被忽略。
+func WithSuccessDecay(decay time.Duration) Option {
+	return func(b *Builder) {
+		if decay > 0 {
+			b.SuccessDecay = decay
+		}
+	}
+}
+
+// WithSlowStart 设置新节点的慢启动窗口，窗口内节点的权重从 10% 线性提升到计算出的权重，
+// 避免刚发布、缓存尚未预热的节点立即承受全部流量。默认不启用，非正数的值会被忽略。
+func WithSlowStart(window time.Duration) Option {
+	return func(b *Builder) {
+		if window > 0 {
+			b.SlowStart = window
+		}
+	}
+}
+
+// WithErrHandler 设置自定义的错误处理函数，返回 true 的错误会降低节点的成功率。
+func WithErrHandler(h func(err error) (isErr bool)) Option {
+	return func(b *Builder) {
+		b.ErrHandler = h
+	}
+}
+
+// NewBuilder 使用选项创建加权节点构建器。
+func NewBuilder(opts ...Option) *Builder {
+	b := &Builder{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
 // Builder 是用于构建加权节点的构建器
 type Builder struct {
 	ErrHandler func(err error) (isErr bool) // 自定义错误处理函数
+
+	// Tau 是延迟移动平均的时间常数，为零时使用默认值
+	Tau time.Duration
+	// Penalty 是没有统计信息时的延迟惩罚值，为零时使用默认值
+	Penalty time.Duration
+	// SuccessDecay 是成功率移动平均的时间常数，为零时与 Tau 相同
+	SuccessDecay time.Duration
+	// SlowStart 是新节点的慢启动窗口，为零时不启用
+	SlowStart time.Duration
 }
 
 // Build 方法根据给定的节点创建一个新的加权节点实例
@@ -70,6 +144,20 @@ func (b *Builder) Build(n selector.Node) selector.WeightedNode {
 		errHandler: b.ErrHandler,
 		// 创建一个新的 atomic.Value 实例用于缓存权重
 		cachedWeight: &atomic.Value{},
+		tau:          tau,
+		penalty:      penalty,
+		created:      time.Now(),
+		slowStart:    b.SlowStart,
+	}
+	if b.Tau > 0 {
+		s.tau = int64(b.Tau)
+	}
+	if b.Penalty > 0 {
+		s.penalty = uint64(b.Penalty)
+	}
+	s.successDecay = s.tau
+	if b.SuccessDecay > 0 {
+		s.successDecay = int64(b.SuccessDecay)
 	}
 	// 返回新创建的加权节点实例
 	return s
@@ -88,7 +176,7 @@ func (n *Node) load() (load uint64) {
 
 	if avgLag == 0 {
 		// 如果节点刚开始运行且没有数据，使用惩罚值作为负载
-		load = penalty * uint64(atomic.LoadInt64(&n.inflight))
+		load = n.penalty * uint64(atomic.LoadInt64(&n.inflight))
 		return
 	}
 	if predict > avgLag {
@@ -160,7 +248,9 @@ func (n *Node) Pick() selector.DoneFunc {
 		if td < 0 {
 			td = 0
 		}
-		w := math.Exp(float64(-td) / float64(tau))
+		w := math.Exp(float64(-td) / float64(n.tau))
+		// 成功率使用独立的时间常数衰减
+		sw := math.Exp(float64(-td) / float64(n.successDecay))
 
 		lag := now - start
 		if lag < 0 {
@@ -169,6 +259,7 @@ func (n *Node) Pick() selector.DoneFunc {
 		oldLag := atomic.LoadInt64(&n.lag)
 		if oldLag == 0 {
 			w = 0.0
+			sw = 0.0
 		}
 		lag = int64(float64(oldLag)*w + float64(lag)*(1.0-w))
 		atomic.StoreInt64(&n.lag, lag)
@@ -185,7 +276,7 @@ func (n *Node) Pick() selector.DoneFunc {
 			}
 		}
 		oldSuc := atomic.LoadUint64(&n.success)
-		success = uint64(float64(oldSuc)*w + float64(success)*(1.0-w))
+		success = uint64(float64(oldSuc)*sw + float64(success)*(1.0-sw))
 		atomic.StoreUint64(&n.success, success)
 	}
 }
@@ -213,8 +304,8 @@ func (n *Node) Weight() (weight float64) {
 		// 如果权重是有效的，则直接使用缓存中的权重
 		weight = w.value
 	}
-	// 返回计算得到的权重
-	return
+	// 返回计算得到的权重，慢启动期间按比例降低
+	return selector.SlowStartWeight(weight, time.Since(n.created), n.slowStart)
 }
 
 // PickElapsed 获取自上次选取节点以来经过的时间
@@ -206,3 +206,33 @@ func BenchmarkPickAndWeight(b *testing.B) {
 		}
 	})
 }
+
+// TestBuilderOptions 测试 ewma 构建器的调优选项
+func TestBuilderOptions(t *testing.T) {
+	b := NewBuilder(WithPenalty(time.Microsecond*200), WithTau(-1), WithSuccessDecay(time.Second))
+	wn := b.Build(selector.NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{ID: "127.0.0.1:9090"}))
+	// 没有统计信息时权重与惩罚值成反比
+	if !reflect.DeepEqual(float64(50), wn.Weight()) {
+		t.Errorf("expect %v, got %v", 50, wn.Weight())
+	}
+	n := wn.(*Node)
+	if n.tau != tau {
+		t.Errorf("expect default tau %v, got %v", tau, n.tau)
+	}
+	if n.successDecay != int64(time.Second) {
+		t.Errorf("expect success decay %v, got %v", time.Second, n.successDecay)
+	}
+}
+
+// TestBuilderSlowStart 测试慢启动期间节点的权重按比例降低
+func TestBuilderSlowStart(t *testing.T) {
+	b := NewBuilder(WithPenalty(time.Microsecond*200), WithSlowStart(time.Minute))
+	wn := b.Build(selector.NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{ID: "127.0.0.1:9090"}))
+	if w := wn.Weight(); w != 5 {
+		t.Errorf("expect %v, got %v", 5, w)
+	}
+	wn.(*Node).created = time.Now().Add(-time.Minute)
+	if w := wn.Weight(); w != 50 {
+		t.Errorf("expect %v, got %v", 50, w)
+	}
+}
@@ -12,6 +12,7 @@ import (
 )
 
 const (
+	// forcePick 是默认的强制选择间隔
 	forcePick = time.Second * 3
 	// Name 是 p2c(Pick of 2 choices) 均衡器的名称
 	Name = "p2c"
@@ -23,7 +24,41 @@ var _ selector.Balancer = (*Balancer)(nil)
 type Option func(o *options)
 
 // options 是 p2c 构建器的选项。
-type options struct{}
+type options struct {
+	forcePick time.Duration
+	node      []ewma.Option
+}
+
+// WithForcePick 设置强制选择的间隔，权重较低的节点超过该间隔未被选择时会被强制选择一次，
+// 以更新其成功率和延迟统计，默认为 3s。非正数的值会被忽略。
+func WithForcePick(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.forcePick = d
+		}
+	}
+}
+
+// WithTau 设置 ewma 节点延迟移动平均的时间常数，默认为 600ms。非正数的值会被忽略。
+func WithTau(tau time.Duration) Option {
+	return func(o *options) {
+		o.node = append(o.node, ewma.WithTau(tau))
+	}
+}
+
+// WithPenalty 设置 ewma 节点没有延迟统计时的延迟惩罚值，默认为 100us。非正数的值会被忽略。
+func WithPenalty(penalty time.Duration) Option {
+	return func(o *options) {
+		o.node = append(o.node, ewma.WithPenalty(penalty))
+	}
+}
+
+// WithSuccessDecay 设置 ewma 节点成功率移动平均的时间常数，默认与延迟的时间常数相同。非正数的值会被忽略。
+func WithSuccessDecay(decay time.Duration) Option {
+	return func(o *options) {
+		o.node = append(o.node, ewma.WithSuccessDecay(decay))
+	}
+}
 
 // New 创建一个 p2c 选择器。
 func New(opts ...Option) selector.Selector {
@@ -32,9 +67,10 @@ func New(opts ...Option) selector.Selector {
 
 // Balancer 是 p2c 选择器。
 type Balancer struct {
-	mu     sync.Mutex
-	r      *rand.Rand
-	picked int64
+	mu        sync.Mutex
+	r         *rand.Rand
+	picked    int64
+	forcePick time.Duration
 }
 
 // prePick 方法从给定的节点列表中随机选择两个不同的节点
@@ -77,7 +113,7 @@ func (s *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 
 	// 如果失败的节点在 forceGap 期间从未被选择过一次，则强制选择一次
 	// 利用强制机会触发成功率和延迟的更新
-	if upc.PickElapsed() > forcePick && atomic.CompareAndSwapInt64(&s.picked, 0, 1) {
+	if upc.PickElapsed() > s.forcePick && atomic.CompareAndSwapInt64(&s.picked, 0, 1) {
 		pc = upc
 		atomic.StoreInt64(&s.picked, 0)
 	}
@@ -87,20 +123,27 @@ func (s *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 
 // NewBuilder 返回一个带有 p2c 均衡器的选择器构建器。
 func NewBuilder(opts ...Option) selector.Builder {
-	var option options
+	option := options{forcePick: forcePick}
 	for _, opt := range opts {
 		opt(&option)
 	}
 	return &selector.DefaultBuilder{
-		Balancer: &Builder{},
-		Node:     &ewma.Builder{},
+		Balancer: &Builder{ForcePick: option.forcePick},
+		Node:     ewma.NewBuilder(option.node...),
 	}
 }
 
 // Builder 是 p2c 构建器。
-type Builder struct{}
+type Builder struct {
+	// ForcePick 是强制选择的间隔，为零时使用默认值
+	ForcePick time.Duration
+}
 
 // Build 创建 Balancer。
 func (b *Builder) Build() selector.Balancer {
-	return &Balancer{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	d := b.ForcePick
+	if d <= 0 {
+		d = forcePick
+	}
+	return &Balancer{r: rand.New(rand.NewSource(time.Now().UnixNano())), forcePick: d}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/cnsync/kratos/registry"
 	"github.com/cnsync/kratos/selector"
 	"github.com/cnsync/kratos/selector/filter"
+	"github.com/cnsync/kratos/selector/node/ewma"
 )
 
 // TestWrr3 测试加权轮询算法的实现
@@ -179,3 +180,27 @@ func TestOne(t *testing.T) {
 		t.Errorf("expect %v, got %v", "127.0.0.0:8080", n.Address())
 	}
 }
+
+// TestOptions 测试 p2c 构建器的调优选项
+func TestOptions(t *testing.T) {
+	b := NewBuilder(WithForcePick(time.Second), WithTau(time.Second), WithPenalty(time.Millisecond), WithSuccessDecay(time.Minute)).(*selector.DefaultBuilder)
+	if d := b.Balancer.(*Builder).ForcePick; d != time.Second {
+		t.Errorf("expect force pick %v, got %v", time.Second, d)
+	}
+	if d := b.Balancer.Build().(*Balancer).forcePick; d != time.Second {
+		t.Errorf("expect force pick %v, got %v", time.Second, d)
+	}
+	nb := b.Node.(*ewma.Builder)
+	if nb.Tau != time.Second || nb.Penalty != time.Millisecond || nb.SuccessDecay != time.Minute {
+		t.Errorf("unexpected ewma builder %+v", nb)
+	}
+
+	// 非正数的值被忽略，使用默认值
+	b = NewBuilder(WithForcePick(-1), WithTau(0)).(*selector.DefaultBuilder)
+	if d := b.Balancer.Build().(*Balancer).forcePick; d != forcePick {
+		t.Errorf("expect force pick %v, got %v", forcePick, d)
+	}
+	if nb = b.Node.(*ewma.Builder); nb.Tau != 0 {
+		t.Errorf("expect default tau, got %v", nb.Tau)
+	}
+}
@@ -0,0 +1,48 @@
+package selector
+
+import (
+	"context"
+	"sync"
+)
+
+// pickedKey 是一个用于在上下文中存储已选择节点的键。
+type pickedKey struct{}
+
+// picked 记录同一次调用中已被选择过的节点地址。
+type picked struct {
+	mu    sync.Mutex
+	addrs map[string]struct{}
+}
+
+// NewPickedContext 返回一个记录已选择节点的新上下文。
+// 同一次调用的多次尝试（例如对冲请求）共享该上下文时，选择器会优先选择尚未被选择过的节点，
+// 所有候选节点都已被选择过时不做排除。
+func NewPickedContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pickedKey{}, &picked{addrs: make(map[string]struct{})})
+}
+
+// exclude 返回尚未被选择过的候选节点，全部被选择过时返回原候选节点。
+func (p *picked) exclude(candidates []WeightedNode) []WeightedNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.addrs) == 0 {
+		return candidates
+	}
+	nodes := make([]WeightedNode, 0, len(candidates))
+	for _, n := range candidates {
+		if _, ok := p.addrs[n.Address()]; !ok {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return candidates
+	}
+	return nodes
+}
+
+// add 记录被选择的节点。
+func (p *picked) add(addr string) {
+	p.mu.Lock()
+	p.addrs[addr] = struct{}{}
+	p.mu.Unlock()
+}
@@ -0,0 +1,41 @@
+package selector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cnsync/kratos/registry"
+)
+
+// TestPickedContext 测试同一次调用的多次选择优先选择未被选择过的节点
+func TestPickedContext(t *testing.T) {
+	builder := DefaultBuilder{
+		Node:     &mockWeightedNodeBuilder{},
+		Balancer: &mockBalancerBuilder{},
+	}
+	selector := builder.Build()
+	var nodes []Node
+	for i := 0; i < 3; i++ {
+		addr := fmt.Sprintf("127.0.0.1:%d", 8080+i)
+		nodes = append(nodes, NewNode("http", addr, &registry.ServiceInstance{ID: addr, Name: "helloworld"}))
+	}
+	selector.Apply(nodes)
+
+	ctx := NewPickedContext(context.Background())
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		n, _, err := selector.Select(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[n.Address()] {
+			t.Fatalf("node %s picked twice", n.Address())
+		}
+		seen[n.Address()] = true
+	}
+	// 所有节点都被选择过后不再排除
+	if _, _, err := selector.Select(ctx); err != nil {
+		t.Errorf("expect %v, got %v", nil, err)
+	}
+}
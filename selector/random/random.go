@@ -3,6 +3,7 @@ package random
 import (
 	"context"
 	"math/rand"
+	"sync"
 
 	"github.com/cnsync/kratos/selector"
 	"github.com/cnsync/kratos/selector/node/direct"
@@ -19,10 +20,33 @@ var _ selector.Balancer = (*Balancer)(nil) // Name 是均衡器的名称
 type Option func(o *options)
 
 // options 是随机构建器的选项。
-type options struct{}
+type options struct {
+	weighted bool
+	source   rand.Source
+}
+
+// WithWeighted 设置是否按照节点的权重随机选择，默认为 false，即等概率选择。
+// 权重之和不大于 0 时仍然等概率选择。
+func WithWeighted(weighted bool) Option {
+	return func(o *options) {
+		o.weighted = weighted
+	}
+}
 
-// Balancer 是一个随机均衡器。
-type Balancer struct{}
+// WithSource 设置随机数的来源，例如使用固定种子的 rand.NewSource 得到可复现的选择结果，
+// 同一个构建器创建的均衡器共享该来源。默认使用全局的随机数生成器。
+func WithSource(src rand.Source) Option {
+	return func(o *options) {
+		o.source = src
+	}
+}
+
+// Balancer 是一个随机均衡器，不记录节点的状态，适用于节点很少的场景。
+type Balancer struct {
+	mu       *sync.Mutex
+	r        *rand.Rand
+	weighted bool
+}
 
 // New 随机选择一个选择器。
 func New(opts ...Option) selector.Selector {
@@ -35,7 +59,7 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 		return nil, nil, selector.ErrNoAvailable
 	}
 	// 生成一个随机索引
-	cur := rand.Intn(len(nodes))
+	cur := p.index(nodes)
 	// 选择随机索引对应的节点
 	selected := nodes[cur]
 	// 调用节点的 Pick 方法获取完成函数
@@ -44,22 +68,85 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 	return selected, d, nil
 }
 
+// index 返回随机选中的节点下标，按权重选择时落在 [0, 总权重) 中的随机数所在区间即选中的节点。
+func (p *Balancer) index(nodes []selector.WeightedNode) int {
+	var total float64
+	if p.weighted {
+		for _, node := range nodes {
+			if w := node.Weight(); w > 0 {
+				total += w
+			}
+		}
+	}
+	if total <= 0 {
+		return p.intn(len(nodes))
+	}
+	n := p.float64() * total
+	for i, node := range nodes {
+		w := node.Weight()
+		if w <= 0 {
+			continue
+		}
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	// 浮点误差导致没有落在任何区间时选择最后一个有权重的节点
+	for i := len(nodes) - 1; i > 0; i-- {
+		if nodes[i].Weight() > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// intn 返回 [0, n) 中的随机整数。
+func (p *Balancer) intn(n int) int {
+	if p.r == nil {
+		return rand.Intn(n)
+	}
+	// rand.Rand 不是并发安全的
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.r.Intn(n)
+}
+
+// float64 返回 [0, 1) 中的随机浮点数。
+func (p *Balancer) float64() float64 {
+	if p.r == nil {
+		return rand.Float64()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.r.Float64()
+}
+
 // NewBuilder 返回一个带有随机均衡器的选择器构建器。
 func NewBuilder(opts ...Option) selector.Builder {
 	var option options
 	for _, opt := range opts {
 		opt(&option)
 	}
+	b := &Builder{weighted: option.weighted}
+	if option.source != nil {
+		b.mu = &sync.Mutex{}
+		b.r = rand.New(option.source)
+	}
 	return &selector.DefaultBuilder{
-		Balancer: &Builder{},
+		Balancer: b,
 		Node:     &direct.Builder{},
 	}
 }
 
 // Builder 是随机构建器。
-type Builder struct{}
+type Builder struct {
+	mu       *sync.Mutex
+	r        *rand.Rand
+	weighted bool
+}
 
 // Build 创建 Balancer。
 func (b *Builder) Build() selector.Balancer {
-	return &Balancer{}
+	return &Balancer{mu: b.mu, r: b.r, weighted: b.weighted}
 }
@@ -2,11 +2,15 @@ package random
 
 import (
 	"context"
+	"math/rand"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/cnsync/kratos/registry"
 	"github.com/cnsync/kratos/selector"
 	"github.com/cnsync/kratos/selector/filter"
+	"github.com/cnsync/kratos/selector/node/direct"
 )
 
 // TestWrr 测试加权轮询算法的实现
@@ -99,3 +103,85 @@ func TestEmpty(t *testing.T) {
 		t.Errorf("expect nil, got %v", err)
 	}
 }
+
+// newNodes 创建权重分别为 weights 的节点列表
+func newNodes(weights ...string) []selector.Node {
+	nodes := make([]selector.Node, 0, len(weights))
+	for i, weight := range weights {
+		addr := "127.0.0.1:" + strconv.Itoa(8080+i)
+		nodes = append(nodes, selector.NewNode("http", addr, &registry.ServiceInstance{
+			ID:       addr,
+			Version:  "v2.0.0",
+			Metadata: map[string]string{"weight": weight},
+		}))
+	}
+	return nodes
+}
+
+// TestWeighted 测试按照节点的权重随机选择
+func TestWeighted(t *testing.T) {
+	random := New(WithWeighted(true), WithSource(rand.NewSource(1)))
+	random.Apply(newNodes("10", "30"))
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		n, done, err := random.Select(context.Background())
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		done(context.Background(), selector.DoneInfo{})
+		counts[n.Address()]++
+	}
+	// 期望的比例为 1:3
+	if c := counts["127.0.0.1:8080"]; c < 800 || c > 1200 {
+		t.Errorf("expect about 1000, got %v", c)
+	}
+	if c := counts["127.0.0.1:8081"]; c < 2800 || c > 3200 {
+		t.Errorf("expect about 3000, got %v", c)
+	}
+}
+
+// TestWeighted_Zero 测试权重为 0 的节点不会被选中，权重全部为 0 时等概率选择
+func TestWeighted_Zero(t *testing.T) {
+	b := &Balancer{weighted: true}
+	nodes := make([]selector.WeightedNode, 0, 2)
+	for _, n := range newNodes("0", "10") {
+		nodes = append(nodes, (&direct.Builder{}).Build(n))
+	}
+	for i := 0; i < 100; i++ {
+		n, _, err := b.Pick(context.Background(), nodes)
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if n.Address() != "127.0.0.1:8081" {
+			t.Fatalf("expect 127.0.0.1:8081, got %v", n.Address())
+		}
+	}
+	nodes = nodes[:1]
+	if _, _, err := b.Pick(context.Background(), nodes); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+}
+
+// TestWithSource 测试相同种子的随机数来源得到相同的选择结果
+func TestWithSource(t *testing.T) {
+	pick := func(opts ...Option) []string {
+		random := New(opts...)
+		random.Apply(newNodes("10", "20", "30"))
+		var addrs []string
+		for i := 0; i < 20; i++ {
+			n, _, err := random.Select(context.Background())
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			addrs = append(addrs, n.Address())
+		}
+		return addrs
+	}
+	for _, weighted := range []bool{false, true} {
+		a := pick(WithWeighted(weighted), WithSource(rand.NewSource(42)))
+		b := pick(WithWeighted(weighted), WithSource(rand.NewSource(42)))
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("expect the same picks, got %v and %v", a, b)
+		}
+	}
+}
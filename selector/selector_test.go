@@ -381,3 +381,41 @@ func TestGlobalSelector(t *testing.T) {
 		t.Errorf("expect %v, got %v", nil, gBuilder)
 	}
 }
+
+// TestDefaultApplyReuse 测试未变化的节点沿用原有的加权节点
+func TestDefaultApplyReuse(t *testing.T) {
+	d := (&DefaultBuilder{Node: &mockWeightedNodeBuilder{}, Balancer: &mockBalancerBuilder{}}).Build().(*Default)
+	a := NewNode("http", "127.0.0.1:8080", &registry.ServiceInstance{Version: "v1", Metadata: map[string]string{"weight": "10"}})
+	b := NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{Version: "v1"})
+	d.Apply([]Node{a, b})
+	before := d.nodes.Load().([]WeightedNode)
+
+	changed := NewNode("http", "127.0.0.1:9090", &registry.ServiceInstance{Version: "v2"})
+	same := NewNode("http", "127.0.0.1:8080", &registry.ServiceInstance{Version: "v1", Metadata: map[string]string{"weight": "10"}})
+	d.Apply([]Node{same, changed})
+	after := d.nodes.Load().([]WeightedNode)
+	if after[0] != before[0] {
+		t.Error("expect the unchanged node to be reused")
+	}
+	if after[1] == before[1] || after[1].Version() != "v2" {
+		t.Error("expect the changed node to be rebuilt")
+	}
+}
+
+// TestSlowStartWeight 测试慢启动的权重
+func TestSlowStartWeight(t *testing.T) {
+	tests := []struct {
+		elapsed, window time.Duration
+		want            float64
+	}{
+		{0, 0, 100},
+		{0, time.Minute, 10},
+		{30 * time.Second, time.Minute, 50},
+		{2 * time.Minute, time.Minute, 100},
+	}
+	for _, test := range tests {
+		if got := SlowStartWeight(100, test.elapsed, test.window); got != test.want {
+			t.Errorf("SlowStartWeight(100, %v, %v) = %v, want %v", test.elapsed, test.window, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package selector
+
+import "time"
+
+// minSlowStartFactor 是慢启动开始时权重的最小比例，避免新节点完全收不到流量。
+const minSlowStartFactor = 0.1
+
+// SlowStartWeight 返回慢启动期间的节点权重。
+// 节点加入后的 window 时间内，权重从 weight 的 10% 线性提升到 weight，window 小于等于 0 时不调整。
+func SlowStartWeight(weight float64, elapsed, window time.Duration) float64 {
+	if window <= 0 || elapsed >= window {
+		return weight
+	}
+	return weight * max(float64(elapsed)/float64(window), minSlowStartFactor)
+}
@@ -6,11 +6,14 @@ import (
 
 	"github.com/cnsync/kratos/selector"
 	"github.com/cnsync/kratos/selector/node/direct"
+	"github.com/cnsync/kratos/selector/node/ewma"
 )
 
 const (
 	// Name 是 wrr(Weighted Round Robin) 均衡器的名称
 	Name = "wrr"
+	// defaultWeight 是未设置初始权重的节点的权重，与 direct 节点一致
+	defaultWeight = 100
 )
 
 var _ selector.Balancer = (*Balancer)(nil) // Name 是均衡器的名称
@@ -19,12 +22,28 @@ var _ selector.Balancer = (*Balancer)(nil) // Name 是均衡器的名称
 type Option func(o *options)
 
 // options 是 wrr 构建器的选项。
-type options struct{}
+type options struct {
+	alpha float64
+}
+
+// WithRuntimeWeight 设置运行时权重的混合系数 alpha，取值范围为 (0, 1]。
+// 设置后使用 ewma 节点统计运行时权重，节点的有效权重为
+// 初始权重 * ((1 - alpha) + alpha * 运行时权重 / 候选节点中最大的运行时权重)，
+// 使静态加权的节点在延迟升高或出错时仍能减少分配到的流量。默认为 0，即只使用初始权重。
+func WithRuntimeWeight(alpha float64) Option {
+	return func(o *options) {
+		if alpha > 1 {
+			alpha = 1
+		}
+		o.alpha = alpha
+	}
+}
 
 // Balancer 是一个 wrr 均衡器。
 type Balancer struct {
 	mu            sync.Mutex
 	currentWeight map[string]float64
+	alpha         float64
 }
 
 // New 随机选择一个选择器。
@@ -45,16 +64,18 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 	// 初始化选中的权重为 0
 	var selectWeight float64
 
+	weights := p.weights(nodes)
+
 	// 使用互斥锁保证线程安全
 	p.mu.Lock()
 	// 遍历节点列表
-	for _, node := range nodes {
+	for i, node := range nodes {
 		// 累加总权重
-		totalWeight += node.Weight()
+		totalWeight += weights[i]
 		// 获取当前节点的当前权重
 		cwt := p.currentWeight[node.Address()]
 		// 当前权重加上有效权重
-		cwt += node.Weight()
+		cwt += weights[i]
 		// 更新当前节点的当前权重
 		p.currentWeight[node.Address()] = cwt
 		// 如果当前节点的权重大于选中节点的权重，则更新选中节点
@@ -74,6 +95,37 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 	return selected, d, nil
 }
 
+// weights 返回节点的有效权重，未设置混合系数时即节点的权重。
+func (p *Balancer) weights(nodes []selector.WeightedNode) []float64 {
+	weights := make([]float64, len(nodes))
+	if p.alpha <= 0 {
+		for i, node := range nodes {
+			weights[i] = node.Weight()
+		}
+		return weights
+	}
+	// 运行时权重的量纲与初始权重不同，按候选节点中的最大值归一化
+	var maxWeight float64
+	for i, node := range nodes {
+		weights[i] = node.Weight()
+		if weights[i] > maxWeight {
+			maxWeight = weights[i]
+		}
+	}
+	for i, node := range nodes {
+		ratio := 1.0
+		if maxWeight > 0 {
+			ratio = weights[i] / maxWeight
+		}
+		initial := float64(defaultWeight)
+		if w := node.InitialWeight(); w != nil {
+			initial = float64(*w)
+		}
+		weights[i] = initial * ((1 - p.alpha) + p.alpha*ratio)
+	}
+	return weights
+}
+
 // NewBuilder 函数根据给定的选项创建一个新的选择器构建器实例
 func NewBuilder(opts ...Option) selector.Builder {
 	// 初始化一个新的 options 实例 option
@@ -83,6 +135,13 @@ func NewBuilder(opts ...Option) selector.Builder {
 		// 将每个选项应用到 option 实例上
 		opt(&option)
 	}
+	// 混合运行时权重时使用 ewma 节点统计运行时权重
+	if option.alpha > 0 {
+		return &selector.DefaultBuilder{
+			Balancer: &Builder{alpha: option.alpha},
+			Node:     &ewma.Builder{},
+		}
+	}
 	// 返回一个新的 DefaultBuilder 实例，其中包含了 wrr 均衡器构建器和直接节点构建器
 	return &selector.DefaultBuilder{
 		// 设置 Balancer 为 Builder 实例
@@ -93,9 +152,11 @@ func NewBuilder(opts ...Option) selector.Builder {
 }
 
 // Builder 是 wrr 构建器。
-type Builder struct{}
+type Builder struct {
+	alpha float64
+}
 
 // Build 创建 Balancer。
 func (b *Builder) Build() selector.Balancer {
-	return &Balancer{currentWeight: make(map[string]float64)}
+	return &Balancer{currentWeight: make(map[string]float64), alpha: b.alpha}
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cnsync/kratos/registry"
 	"github.com/cnsync/kratos/selector"
 	"github.com/cnsync/kratos/selector/filter"
+	"github.com/cnsync/kratos/selector/node/ewma"
 )
 
 // TestWrr 测试加权轮询算法的实现
@@ -90,3 +92,64 @@ func TestEmpty(t *testing.T) {
 		t.Errorf("expect no error, got %v", err)
 	}
 }
+
+// runtimeNode 是运行时权重固定的测试节点
+type runtimeNode struct {
+	selector.Node
+	weight float64
+}
+
+func (n *runtimeNode) Raw() selector.Node         { return n.Node }
+func (n *runtimeNode) Weight() float64            { return n.weight }
+func (n *runtimeNode) Pick() selector.DoneFunc    { return func(context.Context, selector.DoneInfo) {} }
+func (n *runtimeNode) PickElapsed() time.Duration { return 0 }
+
+// TestRuntimeWeight 测试混合运行时权重后，运行时权重下降的节点分配到的流量减少
+func TestRuntimeWeight(t *testing.T) {
+	newNode := func(addr string, initial string, weight float64) selector.WeightedNode {
+		return &runtimeNode{
+			Node: selector.NewNode("http", addr, &registry.ServiceInstance{
+				ID:       addr,
+				Metadata: map[string]string{"weight": initial},
+			}),
+			weight: weight,
+		}
+	}
+	// 初始权重相同，第二个节点的运行时权重只有第一个节点的一半
+	nodes := []selector.WeightedNode{
+		newNode("127.0.0.1:8080", "10", 2000),
+		newNode("127.0.0.1:9090", "10", 1000),
+	}
+	tests := []struct {
+		alpha          float64
+		count1, count2 int
+	}{
+		// 未混合时直接使用节点的权重
+		{0, 67, 33},
+		{0.5, 57, 43},
+		{1, 67, 33},
+	}
+	for _, test := range tests {
+		b := (&Builder{alpha: test.alpha}).Build()
+		var count1, count2 int
+		for i := 0; i < 100; i++ {
+			n, _, err := b.Pick(context.Background(), nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.Address() == "127.0.0.1:8080" {
+				count1++
+			} else {
+				count2++
+			}
+		}
+		if count1 < test.count1-1 || count1 > test.count1+1 || count1+count2 != 100 {
+			t.Errorf("alpha %v: expect %d/%d, got %d/%d", test.alpha, test.count1, test.count2, count1, count2)
+		}
+	}
+
+	// 使用 ewma 节点统计运行时权重
+	if _, ok := NewBuilder(WithRuntimeWeight(0.5)).(*selector.DefaultBuilder).Node.(*ewma.Builder); !ok {
+		t.Error("expect ewma node builder")
+	}
+}
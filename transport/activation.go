@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFDsStart 是 systemd 传递的第一个文件描述符。
+const listenFDsStart = 3
+
+var (
+	activationOnce      sync.Once
+	activationListeners []net.Listener
+	activationNames     []string
+	activationErr       error
+)
+
+// ActivationListeners 返回 systemd 套接字激活（LISTEN_PID、LISTEN_FDS）传递给当前进程的监听器，
+// 可以通过 HTTP 和 gRPC 服务器的 Listener 选项使用。没有套接字激活时返回空列表。
+// 监听器只创建一次，多次调用返回相同的监听器，相关环境变量在读取后被清除，不会传递给子进程。
+func ActivationListeners() ([]net.Listener, error) {
+	activationOnce.Do(func() {
+		activationListeners, activationNames, activationErr = loadActivation(
+			os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES"), listenFDsStart)
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	})
+	return activationListeners, activationErr
+}
+
+// ActivationListener 返回 systemd 套接字激活传递的指定名称的监听器，名称由 socket 单元的 FileDescriptorName 设置。
+func ActivationListener(name string) (net.Listener, error) {
+	listeners, err := ActivationListeners()
+	if err != nil {
+		return nil, err
+	}
+	for i, n := range activationNames {
+		if n == name && i < len(listeners) {
+			return listeners[i], nil
+		}
+	}
+	return nil, fmt.Errorf("transport: activation listener %q not found", name)
+}
+
+// loadActivation 从文件描述符 start 开始创建 n 个监听器，pid 与当前进程不一致时忽略。
+func loadActivation(pid, fds, names string, start int) ([]net.Listener, []string, error) {
+	if pid == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil, nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, nil, fmt.Errorf("transport: invalid LISTEN_FDS %q", fds)
+	}
+	var fdNames []string
+	if names != "" {
+		fdNames = strings.Split(names, ":")
+	}
+	listeners := make([]net.Listener, 0, n)
+	for fd := start; fd < start+n; fd++ {
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i := fd - start; i < len(fdNames) {
+			name = fdNames[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		lis, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, nil, fmt.Errorf("transport: invalid activation fd %d: %w", fd, err)
+		}
+		listeners = append(listeners, lis)
+	}
+	return listeners, fdNames, nil
+}
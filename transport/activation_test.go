@@ -0,0 +1,55 @@
+//go:build !windows
+
+package transport
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+// TestLoadActivation 测试从继承的文件描述符创建监听器
+func TestLoadActivation(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	f, err := lis.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// loadActivation 接管并关闭传入的文件描述符，传入副本使其只有一个所有者
+	fd, err := syscall.Dup(int(f.Fd()))
+	_ = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strconv.Itoa(os.Getpid())
+
+	listeners, names, err := loadActivation(pid, "1", "web", fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listeners) != 1 || len(names) != 1 || names[0] != "web" {
+		t.Fatalf("unexpected listeners %v names %v", listeners, names)
+	}
+	defer listeners[0].Close()
+	if listeners[0].Addr().String() != lis.Addr().String() {
+		t.Errorf("expected %s got %s", lis.Addr(), listeners[0].Addr())
+	}
+
+	// 其他进程的套接字激活被忽略
+	if listeners, _, err = loadActivation("1", "1", "", 3); err != nil || len(listeners) != 0 {
+		t.Errorf("unexpected listeners %v: %v", listeners, err)
+	}
+	if _, _, err = loadActivation(pid, "x", "", 3); err == nil {
+		t.Error("expected error for invalid LISTEN_FDS")
+	}
+	// 没有套接字激活时返回空列表
+	if listeners, err = ActivationListeners(); err != nil || len(listeners) != 0 {
+		t.Errorf("unexpected listeners %v: %v", listeners, err)
+	}
+}
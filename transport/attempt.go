@@ -0,0 +1,17 @@
+package transport
+
+import "context"
+
+type concurrentAttemptsKey struct{}
+
+// WithConcurrentAttempts 返回一个新的上下文，标记同一次调用的处理函数可能被并发执行多次（例如对冲请求）。
+// 客户端传输层会为每次尝试使用独立的请求与响应对象，并只采纳第一个成功的结果。
+func WithConcurrentAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, concurrentAttemptsKey{}, struct{}{})
+}
+
+// ConcurrentAttempts 报告上下文中的调用是否可能被并发执行多次。
+func ConcurrentAttempts(ctx context.Context) bool {
+	_, ok := ctx.Value(concurrentAttemptsKey{}).(struct{})
+	return ok
+}
@@ -0,0 +1,188 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/tls"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/cnsync/kratos/log"
+)
+
+// CertificateProvider 是动态证书提供者接口，HTTP 与 gRPC 的客户端和服务器在每次握手时通过它获取证书，
+// 证书更新后新建立的连接会自动使用新证书，无需重启服务
+type CertificateProvider interface {
+	// GetCertificate 返回服务端证书，签名与 tls.Config.GetCertificate 一致
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	// GetClientCertificate 返回客户端证书，签名与 tls.Config.GetClientCertificate 一致
+	GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+}
+
+var _ CertificateProvider = (*FileCertificate)(nil)
+
+// FileCertificate 是基于文件的证书提供者，它监听证书与私钥文件所在目录的变化并重新加载证书，
+// 兼容 cert-manager 等工具通过符号链接替换 Kubernetes Secret 文件的场景
+type FileCertificate struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+
+	mu        sync.Mutex
+	listeners []func(*tls.Certificate)
+}
+
+// NewFileCertificate 加载证书与私钥文件并开始监听文件变化
+func NewFileCertificate(certFile, keyFile string) (*FileCertificate, error) {
+	c := &FileCertificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+		done:     make(chan struct{}),
+	}
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	dirs := map[string]struct{}{filepath.Dir(certFile): {}, filepath.Dir(keyFile): {}}
+	for dir := range dirs {
+		if err = w.Add(dir); err != nil {
+			_ = w.Close()
+			return nil, err
+		}
+	}
+	c.watcher = w
+	go c.watch()
+	return c, nil
+}
+
+// GetCertificate 返回当前的证书，用作服务端证书
+func (c *FileCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// GetClientCertificate 返回当前的证书，用作客户端证书
+func (c *FileCertificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Certificate 返回当前的证书
+func (c *FileCertificate) Certificate() *tls.Certificate {
+	return c.cert.Load()
+}
+
+// OnReload 注册证书更新后的回调函数，回调在监听协程中同步执行
+func (c *FileCertificate) OnReload(fn func(*tls.Certificate)) {
+	c.mu.Lock()
+	c.listeners = append(c.listeners, fn)
+	c.mu.Unlock()
+}
+
+// Close 停止监听证书文件的变化
+func (c *FileCertificate) Close() error {
+	select {
+	case <-c.done:
+		return nil
+	default:
+		close(c.done)
+	}
+	return c.watcher.Close()
+}
+
+// watch 监听证书文件的变化并重新加载证书
+func (c *FileCertificate) watch() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			changed, err := c.reload()
+			if err != nil {
+				// 证书与私钥可能尚未全部写入，等待下一次事件
+				log.Warnf("[TLS] failed to reload certificate: %v", err)
+				continue
+			}
+			if changed {
+				log.Infof("[TLS] certificate reloaded: %s", c.certFile)
+				c.notify()
+			}
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("[TLS] certificate watcher error: %v", err)
+		}
+	}
+}
+
+// notify 通知所有回调函数证书已更新
+func (c *FileCertificate) notify() {
+	c.mu.Lock()
+	listeners := make([]func(*tls.Certificate), len(c.listeners))
+	copy(listeners, c.listeners)
+	c.mu.Unlock()
+	cert := c.cert.Load()
+	for _, fn := range listeners {
+		fn(cert)
+	}
+}
+
+// reload 重新加载证书，返回证书是否发生变化
+func (c *FileCertificate) reload() (bool, error) {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, err
+	}
+	if old := c.cert.Load(); old != nil && EqualCertificate(old, &cert) {
+		return false, nil
+	}
+	c.cert.Store(&cert)
+	return true, nil
+}
+
+// EqualCertificate 判断两个证书链是否相同
+func EqualCertificate(a, b *tls.Certificate) bool {
+	if len(a.Certificate) != len(b.Certificate) {
+		return false
+	}
+	for i := range a.Certificate {
+		if !bytes.Equal(a.Certificate[i], b.Certificate[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ServerTLSConfig 返回一个使用证书提供者获取服务端证书的 TLS 配置，conf 为空时使用默认配置
+func ServerTLSConfig(conf *tls.Config, p CertificateProvider) *tls.Config {
+	if conf == nil {
+		conf = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	conf = conf.Clone()
+	conf.Certificates = nil
+	conf.GetCertificate = p.GetCertificate
+	return conf
+}
+
+// ClientTLSConfig 返回一个使用证书提供者获取客户端证书的 TLS 配置，conf 为空时使用默认配置
+func ClientTLSConfig(conf *tls.Config, p CertificateProvider) *tls.Config {
+	if conf == nil {
+		conf = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	conf = conf.Clone()
+	conf.Certificates = nil
+	conf.GetClientCertificate = p.GetClientCertificate
+	return conf
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyPair(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "kratos"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 先写私钥再写证书，保证证书变化时私钥已经就绪
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, 1)
+
+	c, err := NewFileCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	old, _ := c.GetCertificate(nil)
+	if client, _ := c.GetClientCertificate(nil); client != old {
+		t.Error("expect server and client certificate to be the same")
+	}
+	reloaded := make(chan *tls.Certificate, 1)
+	c.OnReload(func(cert *tls.Certificate) {
+		select {
+		case reloaded <- cert:
+		default:
+		}
+	})
+
+	writeKeyPair(t, certFile, keyFile, 2)
+	select {
+	case cert := <-reloaded:
+		if EqualCertificate(old, cert) {
+			t.Error("expect certificate to be reloaded")
+		}
+		if cur, _ := c.GetCertificate(nil); cur != cert {
+			t.Error("expect current certificate to be the reloaded one")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect reload callback")
+	}
+	if err = c.Close(); err != nil {
+		t.Error(err)
+	}
+	// 重复关闭不应返回错误
+	if err = c.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileCertificate_Error(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileCertificate(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")); err == nil {
+		t.Error("expect error for missing certificate")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, 1)
+	c, err := NewFileCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	base := &tls.Config{MinVersion: tls.VersionTLS13, Certificates: []tls.Certificate{{}}}
+	conf := ServerTLSConfig(base, c)
+	if conf == base || len(base.Certificates) != 1 {
+		t.Error("expect base config not to be modified")
+	}
+	if conf.MinVersion != tls.VersionTLS13 || conf.Certificates != nil || conf.GetCertificate == nil {
+		t.Errorf("unexpected server config: %+v", conf)
+	}
+	conf = ClientTLSConfig(nil, c)
+	if conf.MinVersion != tls.VersionTLS12 || conf.GetClientCertificate == nil {
+		t.Errorf("unexpected client config: %+v", conf)
+	}
+}
@@ -32,8 +32,8 @@ func init() {
 		},
 		base.Config{HealthCheck: true},
 	)
-	// 注册负载均衡器
-	balancer.Register(b)
+	// 注册负载均衡器，子连接可以按最大存活时间或在证书更新后被替换
+	balancer.Register(&recycleBuilder{Builder: b})
 }
 
 // balancerBuilder 结构体，实现了 base.PickerBuilder 接口
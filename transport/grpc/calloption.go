@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/cnsync/kratos/middleware"
+)
+
+// CallOption 是 kratos 定义的 gRPC 调用选项，与 grpc.CallOption 一样传递给生成的客户端方法，
+// 由 Dial 创建的连接上的拦截器处理，用于定制单次调用而不需要创建新的连接。
+type CallOption interface {
+	grpc.CallOption
+
+	// apply 在调用开始前设置调用信息。
+	apply(*callInfo)
+}
+
+// callInfo 包含 gRPC 调用的信息，与 HTTP 客户端的 callInfo 对应。
+type callInfo struct {
+	operation  string                  // 操作名称，默认为调用的方法
+	middleware []middleware.Middleware // 在客户端中间件之后执行的调用中间件
+	header     *metadata.MD            // 请求的元数据，调用完成后替换为回复头
+}
+
+// newCallInfo 从调用选项中提取 kratos 定义的调用选项。
+func newCallInfo(method string, opts []grpc.CallOption) callInfo {
+	c := callInfo{operation: method}
+	for _, o := range opts {
+		if co, ok := o.(CallOption); ok {
+			co.apply(&c)
+		}
+	}
+	return c
+}
+
+// Operation 是一个设置操作名称的调用选项，中间件与节点过滤器看到的操作名称会被替换，调用的方法不变。
+func Operation(operation string) CallOption {
+	return OperationCallOption{Operation: operation}
+}
+
+// OperationCallOption 是设置操作名称的调用选项。
+type OperationCallOption struct {
+	grpc.EmptyCallOption
+	Operation string // 操作名称
+}
+
+// apply 设置操作名称到 callInfo 中。
+func (o OperationCallOption) apply(c *callInfo) {
+	c.operation = o.Operation
+}
+
+// CallMiddleware 是一个为单次调用添加中间件的调用选项，中间件在 WithMiddleware 配置的中间件之后执行。
+// 流式调用同样适用，中间件在收发每个消息时执行。
+func CallMiddleware(m ...middleware.Middleware) CallOption {
+	return MiddlewareCallOption{Middleware: m}
+}
+
+// MiddlewareCallOption 是为单次调用添加中间件的调用选项。
+type MiddlewareCallOption struct {
+	grpc.EmptyCallOption
+	Middleware []middleware.Middleware // 调用中间件
+}
+
+// apply 添加调用中间件到 callInfo 中。
+func (o MiddlewareCallOption) apply(c *callInfo) {
+	c.middleware = append(c.middleware, o.Middleware...)
+}
+
+// Header 返回一个调用选项，header 中的元数据作为请求头发送，调用完成后 header 被替换为服务端返回的回复头。
+func Header(header *metadata.MD) CallOption {
+	return HeaderCallOption{header: header}
+}
+
+// HeaderCallOption 是设置请求头并获取回复头的调用选项。
+type HeaderCallOption struct {
+	grpc.EmptyCallOption
+	header *metadata.MD // 请求头与回复头
+}
+
+// apply 设置 callInfo 中的 header。
+func (o HeaderCallOption) apply(c *callInfo) {
+	c.header = o.header
+}
+
+// chain 返回客户端中间件与调用中间件组成的中间件列表。
+func (c callInfo) chain(ms []middleware.Middleware) []middleware.Middleware {
+	if len(c.middleware) == 0 {
+		return ms
+	}
+	return append(ms[:len(ms):len(ms)], c.middleware...)
+}
+
+// requestHeader 返回调用的请求头。
+func (c callInfo) requestHeader() headerCarrier {
+	if c.header == nil {
+		return headerCarrier{}
+	}
+	return headerCarrier(c.header.Copy())
+}
@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
+
+	pb "github.com/cnsync/kratos/internal/testdata/helloworld"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// TestCallOption 测试单次调用的中间件、操作名称与请求头
+func TestCallOption(t *testing.T) {
+	srv := NewServer(Middleware(func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			// 将请求头中的 x-token 作为回复头返回
+			md, _ := grpcmd.FromIncomingContext(ctx)
+			_ = grpc.SetHeader(ctx, grpcmd.Pairs("x-token", md.Get("x-token")[0]))
+			return handler(ctx, req)
+		}
+	}))
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	var calls []string
+	record := func(name string) middleware.Middleware {
+		return func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				tr, _ := transport.FromClientContext(ctx)
+				calls = append(calls, name+":"+tr.Operation())
+				return handler(ctx, req)
+			}
+		}
+	}
+	conn, err := DialInsecure(context.Background(), WithEndpoint(u.Host), WithMiddleware(record("client")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	header := grpcmd.Pairs("x-token", "secret")
+	_, err = pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"},
+		Operation("greeter.hello"), CallMiddleware(record("call")), Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调用中间件在客户端中间件之后执行，并且看到替换后的操作名称
+	if len(calls) != 2 || calls[0] != "client:greeter.hello" || calls[1] != "call:greeter.hello" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+	if v := header.Get("x-token"); len(v) != 1 || v[0] != "secret" {
+		t.Errorf("unexpected reply header: %v", header)
+	}
+
+	// 不使用调用选项时只执行客户端中间件
+	calls = nil
+	if _, err = pb.NewGreeterClient(conn).SayHello(grpcmd.AppendToOutgoingContext(context.Background(), "x-token", "1"), &pb.HelloRequest{Name: "kratos"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0] != "client:/helloworld.Greeter/SayHello" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
@@ -3,15 +3,20 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	grpcinsecure "google.golang.org/grpc/credentials/insecure"
 	grpcmd "google.golang.org/grpc/metadata"
 
+	"github.com/cnsync/kratos/encoding"
 	"github.com/cnsync/kratos/internal/matcher"
+	replyutil "github.com/cnsync/kratos/internal/reply"
 	"github.com/cnsync/kratos/log"
 	"github.com/cnsync/kratos/middleware"
 	"github.com/cnsync/kratos/registry"
@@ -77,6 +82,30 @@ func WithTLSConfig(c *tls.Config) ClientOption {
 	}
 }
 
+// WithTLSCertificate 设置动态证书提供者，每次握手时从提供者获取客户端证书，
+// 已建立的连接不会重新握手，可以配合 WithMaxConnectionAge 定期重建连接
+func WithTLSCertificate(p transport.CertificateProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.certProvider = p
+	}
+}
+
+// WithCredentials 设置客户端的传输凭证，优先级高于 WithTLSConfig，
+// 可以配合 NewReloadableCredentials 在证书轮换后自动重建连接
+func WithCredentials(creds credentials.TransportCredentials) ClientOption {
+	return func(o *clientOptions) {
+		o.creds = creds
+	}
+}
+
+// WithMaxConnectionAge 设置连接的最大存活时间，到期后负载均衡器会建立新的连接，
+// 旧连接不再接收新的请求，进行中的请求完成后才会断开，不支持与 WithXDS 一起使用
+func WithMaxConnectionAge(age time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.maxConnectionAge = age
+	}
+}
+
 // WithUnaryInterceptor 设置客户端单次 RPC 的拦截器
 func WithUnaryInterceptor(in ...grpc.UnaryClientInterceptor) ClientOption {
 	return func(o *clientOptions) {
@@ -98,6 +127,14 @@ func WithOptions(opts ...grpc.DialOption) ClientOption {
 	}
 }
 
+// WithCodec 设置连接使用的 kratos 编解码器，例如 msgpack 或 json，请求以对应的 content-subtype 发送，
+// 服务端需要在 init 函数中通过 RegisterCodec 注册同一个编解码器。客户端不需要注册编解码器。
+func WithCodec(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.codec = name
+	}
+}
+
 // WithNodeFilter 设置节点选择过滤器
 func WithNodeFilter(filters ...selector.NodeFilter) ClientOption {
 	return func(o *clientOptions) {
@@ -114,6 +151,30 @@ func WithHealthCheck(healthCheck bool) ClientOption {
 	}
 }
 
+// WithBlock 设置 Dial 阻塞直到连接就绪，连接失败或者超时时返回错误，
+// 默认情况下 Dial 立即返回，在第一次调用时才建立连接
+func WithBlock() ClientOption {
+	return func(o *clientOptions) {
+		o.block = true
+	}
+}
+
+// WithConnectTimeout 设置阻塞建立连接的超时时间，只在 WithBlock 时生效，
+// 默认为 0，表示只受 Dial 的 ctx 限制
+func WithConnectTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.connectTimeout = timeout
+	}
+}
+
+// WithOnStateChange 设置连接状态变化的回调函数，可以在回调中记录日志或者指标，
+// 回调函数在单独的 goroutine 中按顺序调用，连接关闭后不再调用
+func WithOnStateChange(fn func(from, to connectivity.State)) ClientOption {
+	return func(o *clientOptions) {
+		o.onStateChange = fn
+	}
+}
+
 // WithLogger 设置日志记录器
 // Deprecated: 请使用全局日志记录器
 func WithLogger(log.Logger) ClientOption {
@@ -132,6 +193,9 @@ type clientOptions struct {
 	endpoint               string
 	subsetSize             int
 	tlsConf                *tls.Config
+	certProvider           transport.CertificateProvider
+	creds                  credentials.TransportCredentials
+	maxConnectionAge       time.Duration
 	timeout                time.Duration
 	discovery              registry.Discovery
 	middleware             []middleware.Middleware
@@ -143,6 +207,11 @@ type clientOptions struct {
 	filters                []selector.NodeFilter
 	healthCheckConfig      string
 	printDiscoveryDebugLog bool
+	block                  bool
+	connectTimeout         time.Duration
+	onStateChange          func(from, to connectivity.State)
+	xds                    *xdsOptions
+	codec                  string
 }
 
 // Dial 返回一个 gRPC 连接
@@ -188,16 +257,35 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		sints = append(sints, options.streamInts...)
 	}
 
+	endpoint, useXDS, err := xdsEndpoint(&options)
+	if err != nil {
+		return nil, err
+	}
+
 	// 配置 gRPC 连接选项
 	grpcOpts := []grpc.DialOption{
-		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]%s}`,
-			options.balancerName, options.healthCheckConfig)),
 		grpc.WithChainUnaryInterceptor(ints...),
 		grpc.WithChainStreamInterceptor(sints...),
 	}
 
-	// 如果启用了服务发现，则添加解析器选项
-	if options.discovery != nil {
+	// 使用 xDS 时负载均衡策略由 xDS 下发的服务配置决定
+	if !useXDS {
+		lbConfig := "{}"
+		if options.maxConnectionAge > 0 {
+			lbConfig = fmt.Sprintf(`{"maxConnectionAge":%q}`, options.maxConnectionAge.String())
+		}
+		grpcOpts = append(grpcOpts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":%s}]%s}`,
+			options.balancerName, lbConfig, options.healthCheckConfig)))
+	} else if options.maxConnectionAge > 0 {
+		// xDS 下发的负载均衡策略不会替换子连接，应由服务端的 MaxConnectionAge 回收连接
+		return nil, errors.New("grpc: WithMaxConnectionAge is not supported with WithXDS, use the server MaxConnectionAge instead")
+	}
+
+	switch {
+	case useXDS && options.xds.resolver != nil:
+		grpcOpts = append(grpcOpts, grpc.WithResolvers(options.xds.resolver))
+	case !useXDS && options.discovery != nil:
+		// 如果启用了服务发现，则添加解析器选项
 		grpcOpts = append(grpcOpts,
 			grpc.WithResolvers(
 				discovery.NewBuilder(
@@ -209,14 +297,32 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 				)))
 	}
 
-	// 如果是非安全连接，使用不安全的凭证
+	// 选择传输凭证：自定义凭证优先于 TLS 配置，TLS 配置优先于不安全连接
+	var creds credentials.TransportCredentials
 	if insecure {
-		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(grpcinsecure.NewCredentials()))
+		creds = grpcinsecure.NewCredentials()
+	}
+	if options.certProvider != nil {
+		options.tlsConf = transport.ClientTLSConfig(options.tlsConf, options.certProvider)
 	}
-
-	// 如果设置了 TLS 配置，使用加密连接
 	if options.tlsConf != nil {
-		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(options.tlsConf)))
+		creds = credentials.NewTLS(options.tlsConf)
+	}
+	if options.creds != nil {
+		creds = options.creds
+	}
+	if creds != nil {
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(creds))
+	}
+
+	// 使用指定的编解码器编码请求与响应的消息
+	if options.codec != "" {
+		c := encoding.GetCodec(options.codec)
+		if c == nil {
+			return nil, fmt.Errorf("grpc: codec %q is not registered in kratos encoding", options.codec)
+		}
+		// 只对该连接的调用生效，不修改 gRPC 全局的编解码器注册表
+		grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(grpc.ForceCodecV2(codecBridge{codec: c})))
 	}
 
 	// 添加用户自定义的 gRPC 连接选项
@@ -225,16 +331,62 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	}
 
 	// 使用配置选项建立 gRPC 连接
-	return grpc.DialContext(ctx, options.endpoint, grpcOpts...)
+	conn, err := grpc.DialContext(ctx, endpoint, grpcOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.onStateChange != nil {
+		go watchState(conn, options.onStateChange)
+	}
+	if options.block {
+		if err = waitReady(ctx, conn, options.connectTimeout); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// waitReady 主动建立连接并等待连接就绪
+func waitReady(ctx context.Context, conn *grpc.ClientConn, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("grpc: failed to connect to %s, last state %s: %w", conn.Target(), state, ctx.Err())
+		}
+	}
+}
+
+// watchState 监听连接状态的变化并调用回调函数，连接关闭后退出
+func watchState(conn *grpc.ClientConn, fn func(from, to connectivity.State)) {
+	from := conn.GetState()
+	for from != connectivity.Shutdown {
+		if !conn.WaitForStateChange(context.Background(), from) {
+			return
+		}
+		to := conn.GetState()
+		fn(from, to)
+		from = to
+	}
 }
 
 func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, filters []selector.NodeFilter) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		c := newCallInfo(method, opts)
 		// 为每个 RPC 请求创建新的上下文
 		ctx = transport.NewClientContext(ctx, &Transport{
 			endpoint:    cc.Target(),
-			operation:   method,
-			reqHeader:   headerCarrier{},
+			operation:   c.operation,
+			reqHeader:   c.requestHeader(),
 			nodeFilters: filters,
 		})
 
@@ -245,6 +397,10 @@ func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, f
 			defer cancel()
 		}
 
+		var (
+			mu        sync.Mutex
+			committed bool
+		)
 		// 处理 RPC 调用
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
 			if tr, ok := transport.FromClientContext(ctx); ok {
@@ -257,11 +413,35 @@ func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, f
 				// 将请求头添加到 gRPC 上下文中
 				ctx = grpcmd.AppendToOutgoingContext(ctx, keyvals...)
 			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			// 读取 trailer 中的错误元数据，合并到返回的错误中
+			var header, trailer grpcmd.MD
+			callOpts := append(opts[:len(opts):len(opts)], grpc.Header(&header), grpc.Trailer(&trailer))
+			if !transport.ConcurrentAttempts(ctx) {
+				err := invoker(ctx, method, req, reply, cc, callOpts...)
+				if c.header != nil {
+					*c.header = header
+				}
+				return reply, mergeErrorTrailer(err, trailer)
+			}
+			// 并发的多次尝试各自解码到独立的响应对象，只采纳第一个成功的结果
+			r := replyutil.New(reply)
+			if err := invoker(ctx, method, req, r, cc, callOpts...); err != nil {
+				return nil, mergeErrorTrailer(err, trailer)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !committed {
+				replyutil.Copy(reply, r)
+				if c.header != nil {
+					*c.header = header
+				}
+				committed = true
+			}
+			return reply, nil
 		}
 
-		// 应用中间件链
-		if len(ms) > 0 {
+		// 应用中间件链，调用中间件在客户端中间件之后执行
+		if ms := c.chain(ms); len(ms) > 0 {
 			h = middleware.Chain(ms...)(h)
 		}
 
@@ -327,13 +507,23 @@ func (w *wrappedClientStream) RecvMsg(m interface{}) error {
 // streamClientInterceptor 为流式 RPC 设置拦截器，并应用中间件
 func streamClientInterceptor(ms []middleware.Middleware, filters []selector.NodeFilter) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		c := newCallInfo(method, opts)
 		// 为每个流式 RPC 请求创建新的上下文
 		ctx = transport.NewClientContext(ctx, &Transport{
 			endpoint:    cc.Target(),
-			operation:   method,
+			operation:   c.operation,
 			reqHeader:   headerCarrier{},
 			nodeFilters: filters,
 		})
+		if c.header != nil {
+			// 发送调用选项设置的请求头，服务端返回的回复头在流结束后写回
+			for k, vs := range *c.header {
+				for _, v := range vs {
+					ctx = grpcmd.AppendToOutgoingContext(ctx, k, v)
+				}
+			}
+			opts = append(opts[:len(opts):len(opts)], grpc.Header(c.header))
+		}
 
 		var p selector.Peer
 		ctx = selector.NewPeerContext(ctx, &p)
@@ -350,7 +540,7 @@ func streamClientInterceptor(ms []middleware.Middleware, filters []selector.Node
 
 		m := matcher.New()
 		// 如果有中间件，应用它们
-		if len(ms) > 0 {
+		if ms := c.chain(ms); len(ms) > 0 {
 			m.Use(ms...)
 			middleware.Chain(ms...)(h)
 		}
@@ -3,11 +3,14 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"reflect"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/cnsync/kratos/middleware"
 	"github.com/cnsync/kratos/registry"
@@ -70,6 +73,24 @@ func TestWithTLSConfig(t *testing.T) {
 	}
 }
 
+func TestWithCredentials(t *testing.T) {
+	o := &clientOptions{}
+	v := insecure.NewCredentials()
+	WithCredentials(v)(o)
+	if !reflect.DeepEqual(v, o.creds) {
+		t.Errorf("expect %v but got %v", v, o.creds)
+	}
+}
+
+func TestWithMaxConnectionAge(t *testing.T) {
+	o := &clientOptions{}
+	v := time.Minute
+	WithMaxConnectionAge(v)(o)
+	if !reflect.DeepEqual(v, o.maxConnectionAge) {
+		t.Errorf("expect %v but got %v", v, o.maxConnectionAge)
+	}
+}
+
 func EmptyMiddleware() middleware.Middleware {
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
@@ -153,3 +174,66 @@ func TestDialConn(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestWithBlock(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	states := make(chan connectivity.State, 10)
+	conn, err := DialInsecure(
+		context.Background(),
+		WithEndpoint(lis.Addr().String()),
+		WithBlock(),
+		WithConnectTimeout(5*time.Second),
+		WithOnStateChange(func(_, to connectivity.State) { states <- to }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 阻塞建立连接时返回的连接已经就绪
+	if state := conn.GetState(); state != connectivity.Ready {
+		t.Errorf("expect %v but got %v", connectivity.Ready, state)
+	}
+	_ = conn.Close()
+	// 连接关闭后回调最后一次收到 Shutdown 状态
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if state == connectivity.Shutdown {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expect shutdown state")
+		}
+	}
+}
+
+func TestWithBlock_Timeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	// 连接失败时在超时后返回错误
+	start := time.Now()
+	_, err = DialInsecure(
+		context.Background(),
+		WithEndpoint(addr),
+		WithBlock(),
+		WithConnectTimeout(200*time.Millisecond),
+	)
+	if err == nil {
+		t.Fatal("expect error but got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expect to return after the connect timeout, got %v", elapsed)
+	}
+}
@@ -2,17 +2,66 @@ package grpc
 
 import (
 	"fmt"
+	"strings"
 
 	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/mem"
 	"google.golang.org/protobuf/proto"
 
 	enc "github.com/cnsync/kratos/encoding"
 	"github.com/cnsync/kratos/encoding/json"
+	kproto "github.com/cnsync/kratos/encoding/proto"
 )
 
 func init() {
 	// 注册自定义的编解码器
 	encoding.RegisterCodec(codec{})
+	// 包装 gRPC 默认的 proto 编解码器，使 vtprotobuf 生成的消息使用快速路径
+	if base := encoding.GetCodecV2(kproto.Name); base != nil {
+		encoding.RegisterCodecV2(vtCodec{base: base})
+	}
+}
+
+// vtMessage 是 vtprotobuf 生成的序列化与反序列化方法
+type vtMessage interface {
+	MarshalVT() ([]byte, error)
+	UnmarshalVT([]byte) error
+}
+
+// vtCodec 是 gRPC 的 proto 编解码器，实现了 vtprotobuf 方法的消息交给已注册的 proto 编解码器处理，
+// 由它决定是否使用 vtprotobuf（参见 proto.WithStandard），其他消息仍然使用 gRPC 默认的编解码器
+type vtCodec struct {
+	base encoding.CodecV2
+}
+
+// Marshal 方法将消息编码为 gRPC 的缓冲区
+func (c vtCodec) Marshal(v any) (mem.BufferSlice, error) {
+	if _, ok := v.(vtMessage); ok {
+		if pc := enc.GetCodec(kproto.Name); pc != nil {
+			data, err := pc.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return mem.BufferSlice{mem.SliceBuffer(data)}, nil
+		}
+	}
+	return c.base.Marshal(v)
+}
+
+// Unmarshal 方法将 gRPC 的缓冲区解码为消息
+func (c vtCodec) Unmarshal(data mem.BufferSlice, v any) error {
+	if _, ok := v.(vtMessage); ok {
+		if pc := enc.GetCodec(kproto.Name); pc != nil {
+			// 缓冲区在返回后会被释放，Materialize 复制数据，保证消息不会引用已释放的内存
+			return pc.Unmarshal(data.Materialize(), v)
+		}
+	}
+	return c.base.Unmarshal(data, v)
+}
+
+// Name 方法返回编解码器的名称
+func (c vtCodec) Name() string {
+	return c.base.Name()
 }
 
 // codec 是一个使用 protobuf 的 Codec 实现。它是 gRPC 的默认编解码器。
@@ -47,3 +96,49 @@ func (codec) Name() string {
 	// 返回 JSON 编解码器的名称
 	return json.Name
 }
+
+// RegisterCodec 将 kratos 注册的编解码器注册到 gRPC，gRPC 服务器按照请求的 content-subtype
+// （例如 application/grpc+msgpack）选择编解码器，使服务可以在 gRPC 帧上使用 msgpack 等格式的消息，
+// 编解码行为与 HTTP 传输一致。编解码器不存在时返回错误。
+// 已经注册到 gRPC 的编解码器（例如 proto 以及本包注册的 json）保持不变。
+//
+// 与 encoding.RegisterCodec 一样，RegisterCodec 只应在 init 函数中调用，它不是并发安全的。
+func RegisterCodec(name string) error {
+	c := enc.GetCodec(name)
+	if c == nil {
+		return fmt.Errorf("grpc: codec %q is not registered in kratos encoding", name)
+	}
+	if !codecRegistered(strings.ToLower(c.Name())) {
+		encoding.RegisterCodecV2(codecBridge{codec: c})
+	}
+	return nil
+}
+
+// codecRegistered 报告 gRPC 是否注册了内容子类型对应的编解码器
+func codecRegistered(contentSubtype string) bool {
+	return encoding.GetCodecV2(contentSubtype) != nil || encoding.GetCodec(contentSubtype) != nil
+}
+
+// codecBridge 将 kratos 的编解码器适配为 gRPC 的编解码器
+type codecBridge struct {
+	codec enc.Codec
+}
+
+// Marshal 方法使用 kratos 的编解码器编码消息
+func (c codecBridge) Marshal(v any) (mem.BufferSlice, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return mem.BufferSlice{mem.SliceBuffer(data)}, nil
+}
+
+// Unmarshal 方法使用 kratos 的编解码器解码消息，缓冲区在返回后会被释放，因此先复制数据
+func (c codecBridge) Unmarshal(data mem.BufferSlice, v any) error {
+	return c.codec.Unmarshal(data.Materialize(), v)
+}
+
+// Name 方法返回编解码器的名称，即 gRPC 的 content-subtype
+func (c codecBridge) Name() string {
+	return c.codec.Name()
+}
@@ -1,10 +1,21 @@
 package grpc
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	grpcmd "google.golang.org/grpc/metadata"
+
+	kproto "github.com/cnsync/kratos/encoding/proto"
+	pb "github.com/cnsync/kratos/internal/testdata/helloworld"
+	"github.com/cnsync/kratos/middleware"
 )
 
 func TestCodec(t *testing.T) {
@@ -26,3 +37,118 @@ func TestCodec(t *testing.T) {
 		t.Errorf("grpc codec want %v, got %v", in, out)
 	}
 }
+
+// vtString 模拟 vtprotobuf 生成的消息，记录 vtprotobuf 方法是否被调用
+type vtString struct {
+	*wrapperspb.StringValue
+	marshaled   bool
+	unmarshaled bool
+}
+
+func (m *vtString) MarshalVT() ([]byte, error) {
+	m.marshaled = true
+	return proto.Marshal(m.StringValue)
+}
+
+func (m *vtString) UnmarshalVT(data []byte) error {
+	m.unmarshaled = true
+	return proto.Unmarshal(data, m.StringValue)
+}
+
+func TestVTCodec(t *testing.T) {
+	c := encoding.GetCodecV2(kproto.Name)
+	if _, ok := c.(vtCodec); !ok {
+		t.Fatalf("expect vtCodec to be registered, got %T", c)
+	}
+	in := &vtString{StringValue: wrapperspb.String("kratos")}
+	data, err := c.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &vtString{StringValue: &wrapperspb.StringValue{}}
+	if err = c.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if !in.marshaled || !out.unmarshaled {
+		t.Error("expect vtprotobuf methods to be used")
+	}
+	if out.GetValue() != "kratos" {
+		t.Errorf("expect kratos, got %s", out.GetValue())
+	}
+
+	// 普通消息仍然使用 gRPC 默认的编解码器
+	data, err = c.Marshal(wrapperspb.String("kratos"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := &wrapperspb.StringValue{}
+	if err = c.Unmarshal(data, plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.GetValue() != "kratos" {
+		t.Errorf("expect kratos, got %s", plain.GetValue())
+	}
+
+	// 强制使用标准 protobuf 库时不调用 vtprotobuf 方法
+	kproto.Register(kproto.WithStandard())
+	defer kproto.Register()
+	in = &vtString{StringValue: wrapperspb.String("kratos")}
+	if _, err = c.Marshal(in); err != nil {
+		t.Fatal(err)
+	}
+	if in.marshaled {
+		t.Error("expect MarshalVT not to be used")
+	}
+}
+
+// TestCustomCodec 测试使用 kratos 的编解码器在 gRPC 上传输消息
+func TestCustomCodec(t *testing.T) {
+	if err := RegisterCodec("unknown"); err == nil {
+		t.Error("expect error for unknown codec")
+	}
+
+	var contentType string
+	srv := NewServer(Codecs("json"), Middleware(func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			md, _ := grpcmd.FromIncomingContext(ctx)
+			if v := md.Get("content-type"); len(v) > 0 {
+				contentType = v[0]
+			}
+			return handler(ctx, req)
+		}
+	}))
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(u.Host), WithCodec("json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reply, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "Hello kratos" {
+		t.Errorf("unexpected reply: %v", reply)
+	}
+	if contentType != "application/grpc+json" {
+		t.Errorf("expect application/grpc+json, got %q", contentType)
+	}
+
+	// 未注册到 gRPC 的编解码器使服务器返回错误
+	if _, err = NewServer(Codecs("yaml")).Endpoint(); err == nil {
+		t.Error("expect error for the codec not registered in grpc")
+	}
+	if _, err = DialInsecure(context.Background(), WithEndpoint(u.Host), WithCodec("unknown")); err == nil {
+		t.Error("expect error for unknown codec")
+	}
+}
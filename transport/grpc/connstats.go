@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/stats"
+)
+
+var _ stats.Handler = (*connStatsHandler)(nil)
+
+// connStatsHandler 统计服务器的活跃连接数
+type connStatsHandler struct {
+	active  atomic.Int64
+	counter metric.Int64UpDownCounter
+}
+
+// TagConn 实现 stats.Handler 接口
+func (h *connStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn 在连接建立和断开时更新活跃连接数
+func (h *connStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	var delta int64
+	switch s.(type) {
+	case *stats.ConnBegin:
+		delta = 1
+	case *stats.ConnEnd:
+		delta = -1
+	default:
+		return
+	}
+	h.active.Add(delta)
+	if h.counter != nil {
+		h.counter.Add(ctx, delta)
+	}
+}
+
+// TagRPC 实现 stats.Handler 接口
+func (h *connStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC 实现 stats.Handler 接口
+func (h *connStatsHandler) HandleRPC(context.Context, stats.RPCStats) {}
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/cnsync/kratos/transport"
+)
+
+var _ credentials.TransportCredentials = (*ReloadableCredentials)(nil)
+
+// ReloadableOption 是可重载凭证的配置选项类型
+type ReloadableOption func(*ReloadableCredentials)
+
+// ReloadGrace 设置证书更新后替换连接前的等待时间，默认为 0，即立即替换
+func ReloadGrace(d time.Duration) ReloadableOption {
+	return func(c *ReloadableCredentials) {
+		c.grace = d
+	}
+}
+
+// ReloadableCredentials 是一个客户端传输凭证，它监听证书文件的变化并重新加载客户端证书。
+// 通过 WithCredentials 传给 Dial 后，证书更新时负载均衡器会使用新证书建立新的连接，
+// 并优雅地关闭使用旧证书建立的连接：旧连接不再接收新的请求，进行中的请求完成后才会断开，
+// 避免长连接持续使用已过期的证书。
+type ReloadableCredentials struct {
+	credentials.TransportCredentials
+	file  *transport.FileCertificate
+	grace time.Duration
+
+	mu        sync.Mutex
+	balancers map[*recycleBalancer]struct{}
+}
+
+// NewReloadableCredentials 使用 TLS 配置与证书文件创建一个可重载的客户端传输凭证
+func NewReloadableCredentials(conf *tls.Config, certFile, keyFile string, opts ...ReloadableOption) (*ReloadableCredentials, error) {
+	c := &ReloadableCredentials{
+		balancers: make(map[*recycleBalancer]struct{}),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	file, err := transport.NewFileCertificate(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	file.OnReload(func(*tls.Certificate) {
+		if c.grace > 0 {
+			time.AfterFunc(c.grace, c.recycle)
+			return
+		}
+		c.recycle()
+	})
+	c.file = file
+	c.TransportCredentials = credentials.NewTLS(transport.ClientTLSConfig(conf, file))
+	return c, nil
+}
+
+// Clone 返回凭证本身，克隆的凭证与原凭证共享证书和连接状态
+func (c *ReloadableCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+// Certificate 返回当前使用的客户端证书
+func (c *ReloadableCredentials) Certificate() *tls.Certificate {
+	return c.file.Certificate()
+}
+
+// Close 停止监听证书文件的变化
+func (c *ReloadableCredentials) Close() error {
+	return c.file.Close()
+}
+
+// subscribe 记录使用该凭证的负载均衡器，证书更新后替换其子连接
+func (c *ReloadableCredentials) subscribe(b *recycleBalancer) {
+	c.mu.Lock()
+	c.balancers[b] = struct{}{}
+	c.mu.Unlock()
+}
+
+// unsubscribe 移除关闭的负载均衡器
+func (c *ReloadableCredentials) unsubscribe(b *recycleBalancer) {
+	c.mu.Lock()
+	delete(c.balancers, b)
+	c.mu.Unlock()
+}
+
+// recycle 替换所有负载均衡器的子连接
+func (c *ReloadableCredentials) recycle() {
+	c.mu.Lock()
+	balancers := make([]*recycleBalancer, 0, len(c.balancers))
+	for b := range c.balancers {
+		balancers = append(balancers, b)
+	}
+	c.mu.Unlock()
+	for _, b := range balancers {
+		b.recycle()
+	}
+}
@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/cnsync/kratos/transport"
+)
+
+func writeKeyPair(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "kratos"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 先写私钥再写证书，保证证书变化时私钥已经就绪
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadableCredentials(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, 1)
+
+	c, err := NewReloadableCredentials(nil, certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Info().SecurityProtocol != "tls" {
+		t.Errorf("expect tls but got %s", c.Info().SecurityProtocol)
+	}
+	if c.Clone() != c {
+		t.Error("expect clone to share state")
+	}
+	old := c.Certificate()
+
+	inner := &stateBalancer{}
+	b := (&recycleBuilder{Builder: stateBuilder{b: inner}}).Build(nil, balancer.BuildOptions{DialCreds: c})
+	defer b.Close()
+	addrs := []resolver.Address{{Addr: "127.0.0.1:9000"}}
+	if err = b.UpdateClientConnState(balancer.ClientConnState{ResolverState: resolver.State{Addresses: addrs}}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeKeyPair(t, certFile, keyFile, 2)
+	deadline := time.Now().Add(5 * time.Second)
+	for generation(inner.last().ResolverState.Addresses[0]) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect subconns to be replaced after reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if transport.EqualCertificate(old, c.Certificate()) {
+		t.Error("expect certificate to be reloaded")
+	}
+}
+
+func TestReloadableCredentials_Error(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewReloadableCredentials(nil, filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")); err == nil {
+		t.Error("expect error for missing certificate")
+	}
+}
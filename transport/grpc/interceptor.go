@@ -2,34 +2,33 @@ package grpc
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 
 	"google.golang.org/grpc"
 	grpcmd "google.golang.org/grpc/metadata"
 
+	"github.com/cnsync/kratos/errors"
 	ic "github.com/cnsync/kratos/internal/context"
 	"github.com/cnsync/kratos/internal/matcher"
 	"github.com/cnsync/kratos/middleware"
 	"github.com/cnsync/kratos/transport"
 )
 
-// unaryServerInterceptor 是一个 gRPC 的单次 RPC 拦截器
+// unaryServerInterceptor 是一个 gRPC 的单次 RPC 拦截器。
+// 请求的元数据在中间件第一次读取请求头时才复制，回复头在第一次写入时才创建，
+// 启用 TransportPool 时 Transport 在调用返回后放回池中复用。
 func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		// 合并用户的上下文和基本上下文
 		ctx, cancel := ic.Merge(ctx, s.baseCtx)
 		defer cancel()
 
-		// 获取请求的元数据
-		md, _ := grpcmd.FromIncomingContext(ctx)
-
-		// 创建一个用于传输的 Transport 对象，包含请求和响应的元数据
-		replyHeader := grpcmd.MD{}
-		tr := &Transport{
-			operation:   info.FullMethod,
-			reqHeader:   headerCarrier(md),
-			replyHeader: headerCarrier(replyHeader),
-		}
+		// 创建一个用于传输的 Transport 对象，请求头与回复头延迟创建
+		tr := s.acquireTransport()
+		defer s.releaseTransport(tr)
+		tr.operation = s.operation(info.FullMethod)
+		tr.incoming = ctx
 
 		// 如果有端点信息，设置它
 		if s.endpoint != nil {
@@ -41,14 +40,13 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 		// 如果有超时限制，设置超时
 		if s.timeout > 0 {
+			var cancel context.CancelFunc
 			ctx, cancel = context.WithTimeout(ctx, s.timeout)
 			defer cancel()
 		}
 
-		// 定义请求处理函数
-		h := func(ctx context.Context, req interface{}) (interface{}, error) {
-			return handler(ctx, req)
-		}
+		// 请求处理函数与 grpc.UnaryHandler 的类型相同，无需再包装一层
+		h := middleware.Handler(handler)
 
 		// 如果有中间件匹配当前操作，链式调用中间件
 		if next := s.middleware.Match(tr.Operation()); len(next) > 0 {
@@ -59,14 +57,49 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 		reply, err := h(ctx, req)
 
 		// 如果有回复头信息，设置它
-		if len(replyHeader) > 0 {
-			_ = grpc.SetHeader(ctx, replyHeader)
+		if tr.hasReplyHeader() {
+			_ = grpc.SetHeader(ctx, grpcmd.MD(tr.replyHeader))
+		}
+		// 将错误的元数据写入 trailer
+		if err != nil {
+			_ = grpc.SetTrailer(ctx, errorTrailer(err))
+			err = s.detailedError(err)
 		}
 		return reply, err
 	}
 }
 
-// wrappedStream 用于包装 gRPC 流式请求的上下文
+// detailedError 启用详细错误时将 *errors.Error 转换为携带根因链的 gRPC 状态错误
+func (s *Server) detailedError(err error) error {
+	if !s.detailedErrors {
+		return err
+	}
+	if se := new(errors.Error); stderrors.As(err, &se) {
+		return se.DetailedGRPCStatus().Err()
+	}
+	return err
+}
+
+// acquireTransport 获取单次 RPC 使用的 Transport
+func (s *Server) acquireTransport() *Transport {
+	if s.transportPool {
+		if tr, ok := s.trPool.Get().(*Transport); ok {
+			return tr
+		}
+	}
+	return &Transport{}
+}
+
+// releaseTransport 在调用返回后回收 Transport
+func (s *Server) releaseTransport(tr *Transport) {
+	if !s.transportPool {
+		return
+	}
+	*tr = Transport{}
+	s.trPool.Put(tr)
+}
+
+// wrappedStream 用于包装 gRPC 流式请求的上下文，并在收发每条消息时调用流式中间件
 type wrappedStream struct {
 	grpc.ServerStream
 	ctx        context.Context
@@ -87,64 +120,63 @@ func (w *wrappedStream) Context() context.Context {
 	return w.ctx
 }
 
-// streamServerInterceptor 是一个 gRPC 的流式 RPC 拦截器
+// streamServerInterceptor 是一个 gRPC 的流式 RPC 拦截器。
+// 匹配的流式中间件在每个流上调用一次并包裹流处理函数，此时 req 为包装后的 grpc.ServerStream，
+// 中间件返回的上下文会作为流的上下文传递给处理函数，中间件返回错误时不会调用处理函数；
+// 此外，处理函数每次调用 RecvMsg 与 SendMsg 时也会调用匹配的中间件，此时 req 为收发的消息。
 func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		// 合并用户的上下文和基本上下文
 		ctx, cancel := ic.Merge(ss.Context(), s.baseCtx)
 		defer cancel()
 
-		// 获取请求的元数据
-		md, _ := grpcmd.FromIncomingContext(ctx)
-
-		// 创建一个用于传输的 Transport 对象，包含请求和响应的元数据
-		replyHeader := grpcmd.MD{}
-		ctx = transport.NewServerContext(ctx, &Transport{
-			endpoint:    s.endpoint.String(),
-			operation:   info.FullMethod,
-			reqHeader:   headerCarrier(md),
-			replyHeader: headerCarrier(replyHeader),
-		})
-
-		// 定义流式请求的处理函数
-		h := func(_ context.Context, _ interface{}) (interface{}, error) {
-			return handler(srv, ss), nil
+		// 创建一个用于传输的 Transport 对象，请求头与回复头延迟创建
+		tr := &Transport{
+			operation: s.operation(info.FullMethod),
+			incoming:  ctx,
 		}
-
-		// 如果有中间件匹配当前流操作，链式调用中间件
-		if next := s.streamMiddleware.Match(info.FullMethod); len(next) > 0 {
-			middleware.Chain(next...)(h)
+		if s.endpoint != nil {
+			tr.endpoint = s.endpoint.String()
 		}
+		ctx = transport.NewServerContext(ctx, tr)
 
-		// 将流的上下文存入 context 中
-		ctx = context.WithValue(ctx, stream{
-			ServerStream:     ss,
-			streamMiddleware: s.streamMiddleware,
-		}, ss)
+		// 将原始的流存入上下文中，可以通过 GetStream 获取
+		ctx = context.WithValue(ctx, streamKey{}, ss)
 
-		// 创建包装后的流实例
-		ws := NewWrappedStream(ctx, ss, s.streamMiddleware)
+		// 定义流式请求的处理函数，使用中间件传递的上下文创建包装后的流
+		h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, NewWrappedStream(ctx, ss, s.streamMiddleware))
+		}
+
+		// 如果有中间件匹配当前流操作，使用中间件包裹处理函数
+		next := s.streamMiddleware.Match(tr.Operation())
+		if len(next) > 0 {
+			h = middleware.Chain(next...)(h)
+		}
 
 		// 调用处理函数并返回结果
-		err := handler(srv, ws)
+		_, err := h(ctx, NewWrappedStream(ctx, ss, s.streamMiddleware))
 
 		// 如果有回复头信息，设置它
-		if len(replyHeader) > 0 {
-			_ = grpc.SetHeader(ctx, replyHeader)
+		if tr.hasReplyHeader() {
+			_ = grpc.SetHeader(ctx, grpcmd.MD(tr.replyHeader))
+		}
+		// 将错误的元数据写入 trailer
+		if err != nil {
+			ss.SetTrailer(errorTrailer(err))
+			err = s.detailedError(err)
 		}
 		return err
 	}
 }
 
-// stream 类型用于存储流的中间件信息
-type stream struct {
-	grpc.ServerStream
-	streamMiddleware matcher.Matcher
-}
+// streamKey 是流在上下文中的键
+type streamKey struct{}
 
-// GetStream 从上下文中获取流实例
+// GetStream 从上下文中获取原始的流实例，通过它收发的消息不会调用流式中间件
 func GetStream(ctx context.Context) grpc.ServerStream {
-	return ctx.Value(stream{}).(grpc.ServerStream)
+	ss, _ := ctx.Value(streamKey{}).(grpc.ServerStream)
+	return ss
 }
 
 // SendMsg 通过包装的流发送消息，支持中间件链式处理
@@ -0,0 +1,226 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+var (
+	_ balancer.Builder      = (*recycleBuilder)(nil)
+	_ balancer.ConfigParser = (*recycleBuilder)(nil)
+	_ balancer.Balancer     = (*recycleBalancer)(nil)
+	_ balancer.ExitIdler    = (*recycleBalancer)(nil)
+)
+
+// generationKey 是地址属性中连接代数的键，代数变化后负载均衡器会为该地址建立新的子连接，
+// 并优雅地关闭旧的子连接：旧连接不再接收新的请求，进行中的请求完成后才会断开。
+type generationKey struct{}
+
+// recycleConfig 是负载均衡器的配置
+type recycleConfig struct {
+	serviceconfig.LoadBalancingConfig
+	maxConnectionAge time.Duration
+}
+
+// recycleBuilder 包装负载均衡器构建器，使子连接到达最大存活时间或证书更新后被替换
+type recycleBuilder struct {
+	balancer.Builder
+}
+
+// ParseConfig 解析负载均衡器的配置，例如 {"maxConnectionAge":"30m"}
+func (b *recycleBuilder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	var raw struct {
+		MaxConnectionAge string `json:"maxConnectionAge"`
+	}
+	if len(js) > 0 {
+		if err := json.Unmarshal(js, &raw); err != nil {
+			return nil, fmt.Errorf("grpc: invalid %s balancer config: %w", b.Name(), err)
+		}
+	}
+	c := &recycleConfig{}
+	if raw.MaxConnectionAge != "" {
+		age, err := time.ParseDuration(raw.MaxConnectionAge)
+		if err != nil {
+			return nil, fmt.Errorf("grpc: invalid %s balancer config: %w", b.Name(), err)
+		}
+		c.maxConnectionAge = age
+	}
+	return c, nil
+}
+
+// Build 创建负载均衡器，连接使用 ReloadableCredentials 时在证书更新后替换所有子连接
+func (b *recycleBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	r := &recycleBalancer{
+		subConns: make(map[string]*recycleAddr),
+	}
+	r.Balancer = b.Builder.Build(&recycleClientConn{ClientConn: cc, b: r}, opts)
+	if c, ok := opts.DialCreds.(*ReloadableCredentials); ok {
+		r.creds = c
+		c.subscribe(r)
+	}
+	return r
+}
+
+// recycleAddr 记录一个地址的连接代数
+type recycleAddr struct {
+	generation uint64
+	timer      *time.Timer
+}
+
+// recycleBalancer 在被包装的负载均衡器前为地址附加连接代数，
+// 所有对被包装的负载均衡器的调用都持有锁，使定时器触发的替换与 gRPC 的调用互斥。
+type recycleBalancer struct {
+	balancer.Balancer
+	creds *ReloadableCredentials
+
+	mu       sync.Mutex
+	state    *balancer.ClientConnState
+	maxAge   time.Duration
+	subConns map[string]*recycleAddr
+	closed   bool
+}
+
+// UpdateClientConnState 记录解析器的状态，并附加连接代数后交给被包装的负载均衡器
+func (r *recycleBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := s.BalancerConfig.(*recycleConfig); ok && c.maxConnectionAge != r.maxAge {
+		r.maxAge = c.maxConnectionAge
+		for addr, ra := range r.subConns {
+			r.schedule(addr, ra)
+		}
+	}
+	r.state = &s
+	seen := make(map[string]struct{}, len(s.ResolverState.Addresses))
+	for _, a := range s.ResolverState.Addresses {
+		seen[a.Addr] = struct{}{}
+		if _, ok := r.subConns[a.Addr]; !ok {
+			ra := &recycleAddr{}
+			r.subConns[a.Addr] = ra
+			r.schedule(a.Addr, ra)
+		}
+	}
+	for addr, ra := range r.subConns {
+		if _, ok := seen[addr]; !ok {
+			if ra.timer != nil {
+				ra.timer.Stop()
+			}
+			delete(r.subConns, addr)
+		}
+	}
+	return r.Balancer.UpdateClientConnState(r.tagged())
+}
+
+// ResolverError 将解析器的错误交给被包装的负载均衡器
+func (r *recycleBalancer) ResolverError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Balancer.ResolverError(err)
+}
+
+// UpdateSubConnState 将子连接的状态交给被包装的负载均衡器
+func (r *recycleBalancer) UpdateSubConnState(sc balancer.SubConn, s balancer.SubConnState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Balancer.UpdateSubConnState(sc, s)
+}
+
+// ExitIdle 使被包装的负载均衡器退出空闲状态
+func (r *recycleBalancer) ExitIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.Balancer.(balancer.ExitIdler); ok {
+		e.ExitIdle()
+	}
+}
+
+// Close 关闭负载均衡器并停止所有定时器
+func (r *recycleBalancer) Close() {
+	if r.creds != nil {
+		r.creds.unsubscribe(r)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, ra := range r.subConns {
+		if ra.timer != nil {
+			ra.timer.Stop()
+		}
+	}
+	r.Balancer.Close()
+}
+
+// recycle 替换指定地址的子连接，没有指定地址时替换所有子连接
+func (r *recycleBalancer) recycle(addrs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.state == nil {
+		return
+	}
+	if len(addrs) == 0 {
+		for addr := range r.subConns {
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, addr := range addrs {
+		if ra, ok := r.subConns[addr]; ok {
+			ra.generation++
+			r.schedule(addr, ra)
+		}
+	}
+	_ = r.Balancer.UpdateClientConnState(r.tagged())
+}
+
+// schedule 在最大存活时间后替换地址的子连接，增加 ±10% 的随机抖动，避免所有连接同时重建，调用方需要持有锁
+func (r *recycleBalancer) schedule(addr string, ra *recycleAddr) {
+	if ra.timer != nil {
+		ra.timer.Stop()
+		ra.timer = nil
+	}
+	if r.maxAge <= 0 {
+		return
+	}
+	jitter := time.Duration(rand.Int63n(int64(r.maxAge)/5+1)) - r.maxAge/10 //nolint:gosec
+	ra.timer = time.AfterFunc(r.maxAge+jitter, func() {
+		r.recycle(addr)
+	})
+}
+
+// tagged 返回附加了连接代数的解析器状态，调用方需要持有锁
+func (r *recycleBalancer) tagged() balancer.ClientConnState {
+	s := *r.state
+	addrs := make([]resolver.Address, 0, len(s.ResolverState.Addresses))
+	for _, a := range s.ResolverState.Addresses {
+		if ra, ok := r.subConns[a.Addr]; ok && ra.generation > 0 {
+			a.Attributes = a.Attributes.WithValue(generationKey{}, ra.generation)
+		}
+		addrs = append(addrs, a)
+	}
+	s.ResolverState.Addresses = addrs
+	return s
+}
+
+// recycleClientConn 包装 balancer.ClientConn，使子连接的状态回调与其他调用一样持有负载均衡器的锁
+type recycleClientConn struct {
+	balancer.ClientConn
+	b *recycleBalancer
+}
+
+// NewSubConn 创建子连接，子连接的状态在持有锁时交给被包装的负载均衡器
+func (cc *recycleClientConn) NewSubConn(addrs []resolver.Address, opts balancer.NewSubConnOptions) (balancer.SubConn, error) {
+	if listener := opts.StateListener; listener != nil {
+		opts.StateListener = func(s balancer.SubConnState) {
+			cc.b.mu.Lock()
+			defer cc.b.mu.Unlock()
+			listener(s)
+		}
+	}
+	return cc.ClientConn.NewSubConn(addrs, opts)
+}
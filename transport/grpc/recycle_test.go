@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+)
+
+// stateBalancer 记录收到的解析器状态
+type stateBalancer struct {
+	balancer.Balancer
+	mu     sync.Mutex
+	states []balancer.ClientConnState
+}
+
+func (b *stateBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.states = append(b.states, s)
+	return nil
+}
+
+func (b *stateBalancer) Close() {}
+
+func (b *stateBalancer) last() balancer.ClientConnState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.states[len(b.states)-1]
+}
+
+type stateBuilder struct {
+	b *stateBalancer
+}
+
+func (sb stateBuilder) Build(balancer.ClientConn, balancer.BuildOptions) balancer.Balancer {
+	return sb.b
+}
+
+func (stateBuilder) Name() string {
+	return "state"
+}
+
+// generation 返回地址的连接代数
+func generation(a resolver.Address) uint64 {
+	g, _ := a.Attributes.Value(generationKey{}).(uint64)
+	return g
+}
+
+func TestRecycleBuilder_ParseConfig(t *testing.T) {
+	b := &recycleBuilder{Builder: stateBuilder{}}
+	c, err := b.ParseConfig([]byte(`{"maxConnectionAge":"30m"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age := c.(*recycleConfig).maxConnectionAge; age != 30*time.Minute {
+		t.Errorf("expect 30m, got %s", age)
+	}
+	if c, err = b.ParseConfig([]byte(`{}`)); err != nil || c.(*recycleConfig).maxConnectionAge != 0 {
+		t.Errorf("expect no max age, got %v %v", c, err)
+	}
+	if _, err = b.ParseConfig([]byte(`{"maxConnectionAge":"x"}`)); err == nil {
+		t.Error("expect error for invalid duration")
+	}
+}
+
+func TestRecycleBalancer(t *testing.T) {
+	inner := &stateBalancer{}
+	b := (&recycleBuilder{Builder: stateBuilder{b: inner}}).Build(nil, balancer.BuildOptions{})
+	defer b.Close()
+	r := b.(*recycleBalancer)
+	addrs := []resolver.Address{{Addr: "127.0.0.1:9000"}, {Addr: "127.0.0.1:9001"}}
+	if err := b.UpdateClientConnState(balancer.ClientConnState{ResolverState: resolver.State{Addresses: addrs}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range inner.last().ResolverState.Addresses {
+		if generation(a) != 0 {
+			t.Errorf("expect the first generation, got %d", generation(a))
+		}
+	}
+
+	// 替换一个地址的子连接，其他地址不变
+	r.recycle("127.0.0.1:9000")
+	got := inner.last().ResolverState.Addresses
+	if generation(got[0]) != 1 || generation(got[1]) != 0 {
+		t.Errorf("expect only the first address to be replaced, got %d %d", generation(got[0]), generation(got[1]))
+	}
+	if addrs[0].Attributes != nil {
+		t.Error("expect the resolver addresses not to be modified")
+	}
+
+	// 替换所有子连接
+	r.recycle()
+	got = inner.last().ResolverState.Addresses
+	if generation(got[0]) != 2 || generation(got[1]) != 1 {
+		t.Errorf("expect all addresses to be replaced, got %d %d", generation(got[0]), generation(got[1]))
+	}
+
+	// 移除的地址不再替换
+	if err := b.UpdateClientConnState(balancer.ClientConnState{ResolverState: resolver.State{Addresses: addrs[1:]}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.subConns["127.0.0.1:9000"]; ok {
+		t.Error("expect the removed address to be forgotten")
+	}
+}
+
+func TestRecycleBalancer_MaxConnectionAge(t *testing.T) {
+	inner := &stateBalancer{}
+	b := (&recycleBuilder{Builder: stateBuilder{b: inner}}).Build(nil, balancer.BuildOptions{})
+	defer b.Close()
+	err := b.UpdateClientConnState(balancer.ClientConnState{
+		ResolverState:  resolver.State{Addresses: []resolver.Address{{Addr: "127.0.0.1:9000"}}},
+		BalancerConfig: &recycleConfig{maxConnectionAge: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for generation(inner.last().ResolverState.Addresses[0]) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect subconns to be replaced after max connection age")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// subConnClientConn 记录创建子连接的选项
+type subConnClientConn struct {
+	balancer.ClientConn
+	opts balancer.NewSubConnOptions
+}
+
+func (cc *subConnClientConn) NewSubConn(_ []resolver.Address, opts balancer.NewSubConnOptions) (balancer.SubConn, error) {
+	cc.opts = opts
+	return nil, nil
+}
+
+func TestRecycleClientConn(t *testing.T) {
+	r := &recycleBalancer{}
+	cc := &subConnClientConn{}
+	var locked bool
+	_, _ = (&recycleClientConn{ClientConn: cc, b: r}).NewSubConn(nil, balancer.NewSubConnOptions{
+		StateListener: func(balancer.SubConnState) {
+			locked = !r.mu.TryLock()
+		},
+	})
+	cc.opts.StateListener(balancer.SubConnState{})
+	if !locked {
+		t.Error("expect the state listener to hold the balancer lock")
+	}
+}
@@ -2,6 +2,7 @@ package direct
 
 import (
 	"strings"
+	"time"
 
 	"google.golang.org/grpc/resolver"
 )
@@ -13,12 +14,51 @@ func init() {
 	resolver.Register(NewBuilder())
 }
 
+// Option 是构建器的选项。
+type Option func(o *directBuilder)
+
+// WithHealthCheck 设置健康探测的间隔，探测失败的地址会从可用地址中移除，恢复后重新加入。
+// 默认为 0，即不探测。
+func WithHealthCheck(interval time.Duration) Option {
+	return func(b *directBuilder) {
+		b.interval = interval
+	}
+}
+
+// WithProbe 设置健康探测函数，默认为 TCPProbe。
+func WithProbe(probe ProbeFunc) Option {
+	return func(b *directBuilder) {
+		b.probe = probe
+	}
+}
+
+// WithProbeTimeout 设置单次健康探测的超时时间，默认为 1s。
+func WithProbeTimeout(timeout time.Duration) Option {
+	return func(b *directBuilder) {
+		b.timeout = timeout
+	}
+}
+
 // directBuilder 结构体，用于创建直接解析器
-type directBuilder struct{}
+type directBuilder struct {
+	interval time.Duration
+	timeout  time.Duration
+	probe    ProbeFunc
+}
 
-// NewBuilder 创建一个新的 directBuilder 实例
-func NewBuilder() resolver.Builder {
-	return &directBuilder{}
+// NewBuilder 创建一个新的 directBuilder 实例。
+// 默认注册的构建器不做健康探测，需要探测时可以通过 grpc.WithResolvers 使用带选项的构建器，例如：
+//
+//	grpc.WithResolvers(direct.NewBuilder(direct.WithHealthCheck(5 * time.Second)))
+func NewBuilder(opts ...Option) resolver.Builder {
+	b := &directBuilder{
+		timeout: time.Second,
+		probe:   TCPProbe,
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
 }
 
 // Build 根据目标地址和客户端连接创建一个解析器实例
@@ -26,6 +66,9 @@ func (d *directBuilder) Build(target resolver.Target, cc resolver.ClientConn, _
 	// 解析目标地址中的路径部分，获取逗号分隔的地址列表
 	addrs := make([]resolver.Address, 0)
 	for _, addr := range strings.Split(strings.TrimPrefix(target.URL.Path, "/"), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
 		// 将每个地址添加到解析器的地址列表中
 		addrs = append(addrs, resolver.Address{Addr: addr})
 	}
@@ -37,8 +80,12 @@ func (d *directBuilder) Build(target resolver.Target, cc resolver.ClientConn, _
 		// 如果更新状态失败，返回错误
 		return nil, err
 	}
-	// 创建并返回一个新的 directResolver 实例
-	return newDirectResolver(), nil
+	r := newDirectResolver(cc, addrs, d.timeout, d.probe)
+	if d.interval > 0 && len(addrs) > 0 {
+		go r.watch(d.interval)
+	}
+	// 返回 directResolver 实例
+	return r, nil
 }
 
 // Scheme 返回解析器的方案名称
@@ -1,19 +1,156 @@
 package direct
 
-import "google.golang.org/grpc/resolver"
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+)
+
+// ProbeFunc 探测地址是否健康，返回 nil 表示健康。
+type ProbeFunc func(ctx context.Context, addr string) error
+
+// TCPProbe 通过建立 TCP 连接探测地址是否健康。
+func TCPProbe(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// HealthProbe 通过 gRPC 健康检查协议（基于 HTTP/2）探测地址上的服务是否处于 SERVING 状态，
+// service 为空时检查整个服务器。未指定连接选项时使用非安全连接。
+func HealthProbe(service string, opts ...grpc.DialOption) ProbeFunc {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	return func(ctx context.Context, addr string) error {
+		conn, err := grpc.NewClient("passthrough:///"+addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if res.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("direct: %s is %s", addr, res.GetStatus())
+		}
+		return nil
+	}
+}
 
 // directResolver 结构体，用于实现 gRPC 的解析器接口
-type directResolver struct{}
+type directResolver struct {
+	cc      resolver.ClientConn
+	addrs   []resolver.Address
+	timeout time.Duration
+	probe   ProbeFunc
+
+	mu    sync.Mutex
+	alive []resolver.Address // 最近一次更新的可用地址
+
+	now    chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
 
 // newDirectResolver 函数，创建一个新的 directResolver 实例
-func newDirectResolver() resolver.Resolver {
-	return &directResolver{}
+func newDirectResolver(cc resolver.ClientConn, addrs []resolver.Address, timeout time.Duration, probe ProbeFunc) *directResolver {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &directResolver{
+		cc:      cc,
+		addrs:   addrs,
+		timeout: timeout,
+		probe:   probe,
+		alive:   addrs,
+		now:     make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// watch 周期性地探测所有地址，并在可用地址变化时更新客户端连接的状态。
+func (r *directResolver) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.check()
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.now:
+		}
+	}
+}
+
+// check 并发探测所有地址，全部不可用时保留所有地址，由连接自身的重连机制处理。
+func (r *directResolver) check() {
+	healthy := make([]bool, len(r.addrs))
+	var wg sync.WaitGroup
+	for i, addr := range r.addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.ctx, r.timeout)
+			defer cancel()
+			healthy[i] = r.probe(ctx, addr) == nil
+		}(i, addr.Addr)
+	}
+	wg.Wait()
+	if r.ctx.Err() != nil {
+		return
+	}
+	alive := make([]resolver.Address, 0, len(r.addrs))
+	for i, addr := range r.addrs {
+		if healthy[i] {
+			alive = append(alive, addr)
+		}
+	}
+	if len(alive) == 0 {
+		alive = r.addrs
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if equal(r.alive, alive) {
+		return
+	}
+	r.alive = alive
+	_ = r.cc.UpdateState(resolver.State{Addresses: alive})
 }
 
 // Close 方法，关闭解析器
 func (r *directResolver) Close() {
+	r.cancel()
 }
 
 // ResolveNow 方法，立即解析目标地址
 func (r *directResolver) ResolveNow(_ resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+// equal 判断两个地址列表是否相同。
+func equal(a, b []resolver.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Addr != b[i].Addr {
+			return false
+		}
+	}
+	return true
 }
@@ -1 +1,124 @@
 package direct
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// stateConn 记录解析器更新的状态
+type stateConn struct {
+	mu     sync.Mutex
+	states []resolver.State
+}
+
+func (c *stateConn) UpdateState(s resolver.State) error {
+	c.mu.Lock()
+	c.states = append(c.states, s)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *stateConn) last() []resolver.Address {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.states[len(c.states)-1].Addresses
+}
+
+func (c *stateConn) ReportError(error)               {}
+func (c *stateConn) NewAddress(_ []resolver.Address) {}
+func (c *stateConn) NewServiceConfig(_ string)       {}
+func (c *stateConn) ParseServiceConfig(_ string) *serviceconfig.ParseResult {
+	return nil
+}
+
+// TestHealthCheck 测试不可用的地址被移除，恢复后重新加入
+func TestHealthCheck(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		dead = map[string]bool{"127.0.0.1:2": true}
+	)
+	probe := func(_ context.Context, addr string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if dead[addr] {
+			return context.DeadlineExceeded
+		}
+		return nil
+	}
+	cc := &stateConn{}
+	b := NewBuilder(WithHealthCheck(10*time.Millisecond), WithProbe(probe))
+	r, err := b.Build(resolver.Target{URL: url.URL{Path: "/127.0.0.1:1,127.0.0.1:2"}}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	wait := func(want ...string) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			addrs := cc.last()
+			if len(addrs) == len(want) {
+				ok := true
+				for i := range want {
+					ok = ok && addrs[i].Addr == want[i]
+				}
+				if ok {
+					return
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expect %v, got %v", want, cc.last())
+	}
+	wait("127.0.0.1:1")
+
+	mu.Lock()
+	dead = map[string]bool{}
+	mu.Unlock()
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	wait("127.0.0.1:1", "127.0.0.1:2")
+
+	// 全部不可用时保留所有地址
+	mu.Lock()
+	dead = map[string]bool{"127.0.0.1:1": true, "127.0.0.1:2": true}
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	wait("127.0.0.1:1", "127.0.0.1:2")
+}
+
+func TestProbe(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	addr := lis.Addr().String()
+	if err := TCPProbe(ctx, addr); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+	if err := HealthProbe("")(ctx, addr); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if err := HealthProbe("")(ctx, addr); err == nil {
+		t.Error("expect not serving error")
+	}
+}
@@ -30,14 +30,17 @@ type discoveryResolver struct {
 }
 
 // watch 方法，用于监视服务实例的变化
+// 服务实例以增量事件的形式应用到实例集合中，只有集合发生变化时才更新客户端连接的状态
 func (r *discoveryResolver) watch() {
+	w := registry.NewEventWatcher(r.w)
+	set := registry.NewInstanceSet()
 	for {
 		select {
 		case <-r.ctx.Done():
 			return
 		default:
 		}
-		ins, err := r.w.Next()
+		events, err := w.NextEvents()
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				return
@@ -46,7 +49,10 @@ func (r *discoveryResolver) watch() {
 			time.Sleep(time.Second)
 			continue
 		}
-		r.update(ins)
+		if !set.Apply(events) {
+			continue
+		}
+		r.update(set.List())
 	}
 }
 
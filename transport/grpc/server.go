@@ -3,15 +3,21 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/metric"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/admin"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
 	apimd "github.com/cnsync/kratos/api/metadata"
@@ -28,6 +34,13 @@ var (
 	_ transport.EndpointProvider = (*Server)(nil)
 )
 
+const (
+	// DefaultMaxConnectionAge 是服务器默认的连接最大存活时间，使长连接能够重新均衡到新的实例上
+	DefaultMaxConnectionAge = 30 * time.Minute
+	// DefaultMaxConnectionAgeGrace 是连接到达最大存活时间后默认的等待时间
+	DefaultMaxConnectionAgeGrace = 10 * time.Second
+)
+
 // ServerOption 是 gRPC 服务器配置的选项类型
 type ServerOption func(o *Server)
 
@@ -52,6 +65,68 @@ func Endpoint(endpoint *url.URL) ServerOption {
 	}
 }
 
+// InstanceMetadata 设置注册到服务发现中的实例元数据，如 zone，多次设置时合并。
+// 应用的 kratos.Metadata 选项中的同名键优先。
+func InstanceMetadata(md map[string]string) ServerOption {
+	return func(s *Server) {
+		if s.instanceMetadata == nil {
+			s.instanceMetadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			s.instanceMetadata[k] = v
+		}
+	}
+}
+
+// Weight 设置注册到服务发现中的实例权重，即元数据中的 "weight"，负载均衡器据此分配流量。
+func Weight(weight int) ServerOption {
+	return InstanceMetadata(map[string]string{"weight": strconv.Itoa(weight)})
+}
+
+// AdvertiseScheme 设置注册到服务发现中的端点协议，如 "grpcs"。
+// 当 TLS 由边车代理或负载均衡器终止时，本地监听器不使用 TLS，但客户端仍需以安全连接访问。
+// 默认根据是否设置了 TLSConfig 选择 "grpc" 或 "grpcs"。
+func AdvertiseScheme(scheme string) ServerOption {
+	return func(s *Server) {
+		s.advertiseScheme = scheme
+	}
+}
+
+// AdvertiseAddress 设置注册到服务发现中的端点地址，例如 NAT 之后的公网地址。
+// 地址可以是 host 或 host:port，未指定端口时使用监听的端口。
+func AdvertiseAddress(addr string) ServerOption {
+	return func(s *Server) {
+		s.hostOpts.Advertise = addr
+	}
+}
+
+// AdvertiseInterface 设置自动提取端点地址时使用的网络接口，例如 "eth1"，用于多网卡的主机。
+func AdvertiseInterface(name string) ServerOption {
+	return func(s *Server) {
+		s.hostOpts.Interface = name
+	}
+}
+
+// AdvertiseSubnet 设置自动提取的端点地址必须属于的子网，例如 "10.0.0.0/8"。
+// 子网格式错误时启动服务器返回错误。
+func AdvertiseSubnet(cidr string) ServerOption {
+	return func(s *Server) {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.hostOpts.Subnet = subnet
+	}
+}
+
+// PreferIPv6 设置自动提取端点地址时是否优先选择 IPv6 地址，默认优先选择 IPv4 地址。
+func PreferIPv6(prefer bool) ServerOption {
+	return func(s *Server) {
+		s.hostOpts.PreferIPv6 = prefer
+	}
+}
+
 // Timeout 设置服务器的超时时间
 func Timeout(timeout time.Duration) ServerOption {
 	return func(s *Server) {
@@ -72,7 +147,10 @@ func Middleware(m ...middleware.Middleware) ServerOption {
 	}
 }
 
-// StreamMiddleware 设置服务器的流式中间件
+// StreamMiddleware 设置服务器的流式中间件。
+// 中间件在每个流上调用一次并包裹流处理函数，此时 req 为 grpc.ServerStream，中间件向上下文添加的值
+// 可以通过 stream.Context() 获取；处理函数每次调用 RecvMsg 与 SendMsg 时也会调用中间件，此时 req 为收发的消息，
+// 中间件可以通过 req 的类型区分这两种调用。
 func StreamMiddleware(m ...middleware.Middleware) ServerOption {
 	return func(s *Server) {
 		s.streamMiddleware.Use(m...)
@@ -86,6 +164,14 @@ func CustomHealth() ServerOption {
 	}
 }
 
+// DetailedErrors 启用详细错误，*errors.Error 的结构化根因链会通过 gRPC 状态的详细信息传递给客户端，
+// 与 HTTP 服务器使用 DetailedErrorEncoder 时一致。根因链可能包含内部实现细节，默认不传递。
+func DetailedErrors() ServerOption {
+	return func(s *Server) {
+		s.detailedErrors = true
+	}
+}
+
 // TLSConfig 设置 TLS 配置
 func TLSConfig(c *tls.Config) ServerOption {
 	return func(s *Server) {
@@ -93,6 +179,14 @@ func TLSConfig(c *tls.Config) ServerOption {
 	}
 }
 
+// TLSCertificate 设置动态证书提供者，每次握手时从提供者获取服务端证书，
+// 配合 transport.NewFileCertificate 可以在证书文件轮换后无需重启即使用新证书
+func TLSCertificate(p transport.CertificateProvider) ServerOption {
+	return func(s *Server) {
+		s.certProvider = p
+	}
+}
+
 // Listener 设置服务器的监听器
 func Listener(lis net.Listener) ServerOption {
 	return func(s *Server) {
@@ -114,6 +208,19 @@ func StreamInterceptor(in ...grpc.StreamServerInterceptor) ServerOption {
 	}
 }
 
+// Codecs 声明服务器需要支持的编解码器，服务器按照请求的 content-subtype 选择编解码器，
+// 默认的 proto 编解码器仍然可用。gRPC 的编解码器注册表是全局的，编解码器需要在 init 函数中
+// 通过 RegisterCodec 注册，未注册的编解码器会使启动服务器返回错误。
+func Codecs(names ...string) ServerOption {
+	return func(s *Server) {
+		for _, name := range names {
+			if !codecRegistered(strings.ToLower(name)) {
+				s.err = fmt.Errorf("grpc: codec %q is not registered, call RegisterCodec in init", name)
+			}
+		}
+	}
+}
+
 // Options 设置 gRPC 连接的其他选项
 func Options(opts ...grpc.ServerOption) ServerOption {
 	return func(s *Server) {
@@ -121,11 +228,52 @@ func Options(opts ...grpc.ServerOption) ServerOption {
 	}
 }
 
+// OperationNormalizer 设置操作名称的规范化函数，规范化后的操作名称用于中间件匹配、指标和限流等
+func OperationNormalizer(fn func(operation string) string) ServerOption {
+	return func(s *Server) {
+		s.normalizer = fn
+	}
+}
+
+// MaxConnectionAge 设置连接的最大存活时间，到期后服务器会通知客户端断开连接并重新建立，
+// 使长连接在服务发布或扩容后能够重新均衡到新的实例上。gRPC 会在该时间上增加 ±10% 的随机抖动，
+// 默认为 DefaultMaxConnectionAge，设置为 0 时不限制连接的存活时间
+func MaxConnectionAge(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.maxConnectionAge = d
+	}
+}
+
+// MaxConnectionAgeGrace 设置连接到达最大存活时间后，等待进行中的请求完成的时间，
+// 默认为 DefaultMaxConnectionAgeGrace
+func MaxConnectionAgeGrace(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.maxConnectionAgeGrace = d
+	}
+}
+
+// ConnectionsCounter 设置活跃连接数的指标，连接建立时加一，断开时减一
+func ConnectionsCounter(c metric.Int64UpDownCounter) ServerOption {
+	return func(s *Server) {
+		s.connStats.counter = c
+	}
+}
+
+// TransportPool 设置是否通过 sync.Pool 复用单次 RPC 的 Transport，以减少高 QPS 下的内存分配。
+// 启用后，Transport 及其请求头与回复头在处理函数返回后不能再被使用，
+// 因此不能与在处理函数返回后仍继续执行处理逻辑的中间件一起使用
+func TransportPool(pool bool) ServerOption {
+	return func(s *Server) {
+		s.transportPool = pool
+	}
+}
+
 // Server 是一个 gRPC 服务器包装器
 type Server struct {
 	*grpc.Server
 	baseCtx          context.Context
 	tlsConf          *tls.Config
+	certProvider     transport.CertificateProvider
 	lis              net.Listener
 	err              error
 	network          string
@@ -139,8 +287,20 @@ type Server struct {
 	grpcOpts         []grpc.ServerOption
 	health           *health.Server
 	customHealth     bool
+	detailedErrors   bool
 	metadata         *apimd.Server
 	adminClean       func()
+	normalizer       func(string) string
+	connStats        *connStatsHandler
+
+	maxConnectionAge      time.Duration
+	maxConnectionAgeGrace time.Duration
+	advertiseScheme       string
+	instanceMetadata      map[string]string
+	hostOpts              host.ExtractOptions
+
+	transportPool bool      // 是否复用单次 RPC 的 Transport
+	trPool        sync.Pool // 复用的 Transport
 }
 
 // NewServer 创建一个 gRPC 服务器，并应用给定的选项
@@ -153,11 +313,18 @@ func NewServer(opts ...ServerOption) *Server {
 		health:           health.NewServer(),
 		middleware:       matcher.New(),
 		streamMiddleware: matcher.New(),
+		connStats:        &connStatsHandler{},
+
+		maxConnectionAge:      DefaultMaxConnectionAge,
+		maxConnectionAgeGrace: DefaultMaxConnectionAgeGrace,
 	}
 	// 应用给定的选项
 	for _, o := range opts {
 		o(srv)
 	}
+	if srv.certProvider != nil {
+		srv.tlsConf = transport.ServerTLSConfig(srv.tlsConf, srv.certProvider)
+	}
 
 	// 配置默认的 RPC 拦截器
 	unaryInts := []grpc.UnaryServerInterceptor{
@@ -179,6 +346,15 @@ func NewServer(opts ...ServerOption) *Server {
 	grpcOpts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(unaryInts...),
 		grpc.ChainStreamInterceptor(streamInts...),
+		grpc.StatsHandler(srv.connStats),
+	}
+
+	// 如果设置了连接的最大存活时间，定期回收连接
+	if srv.maxConnectionAge > 0 {
+		grpcOpts = append(grpcOpts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionAge:      srv.maxConnectionAge,
+			MaxConnectionAgeGrace: srv.maxConnectionAgeGrace,
+		}))
 	}
 
 	// 如果启用了 TLS，添加 TLS 认证
@@ -217,6 +393,11 @@ func (s *Server) Use(selector string, m ...middleware.Middleware) {
 	s.middleware.Add(selector, m...)
 }
 
+// InstanceMetadata 返回注册到服务发现中的实例元数据。
+func (s *Server) InstanceMetadata() map[string]string {
+	return s.instanceMetadata
+}
+
 // Endpoint 返回真实的服务端点地址
 // 示例：
 //
@@ -250,8 +431,25 @@ func (s *Server) Stop(_ context.Context) error {
 	return nil
 }
 
+// ActiveConnections 返回当前活跃的连接数
+func (s *Server) ActiveConnections() int64 {
+	return s.connStats.active.Load()
+}
+
+// operation 返回规范化后的操作名称
+func (s *Server) operation(fullMethod string) string {
+	if s.normalizer != nil {
+		return s.normalizer(fullMethod)
+	}
+	return fullMethod
+}
+
 // listenAndEndpoint 启动监听并设置服务端点
 func (s *Server) listenAndEndpoint() error {
+	// 配置错误时不监听端口，避免启动失败后监听器无法关闭
+	if s.err != nil {
+		return s.err
+	}
 	if s.lis == nil {
 		// 如果没有提供监听器，默认使用网络和地址创建
 		lis, err := net.Listen(s.network, s.address)
@@ -261,14 +459,22 @@ func (s *Server) listenAndEndpoint() error {
 		}
 		s.lis = lis
 	}
+	// Unix 域套接字使用 unix:///path 形式的端点
+	if addr, ok := s.lis.Addr().(*net.UnixAddr); ok && s.endpoint == nil {
+		s.endpoint = endpoint.NewUnixEndpoint(addr.Name)
+	}
 	if s.endpoint == nil {
 		// 如果没有提供服务端点，自动提取服务地址
-		addr, err := host.Extract(s.address, s.lis)
+		addr, err := host.ExtractWithOptions(s.address, s.lis, s.hostOpts)
 		if err != nil {
 			s.err = err
 			return err
 		}
-		s.endpoint = endpoint.NewEndpoint(endpoint.Scheme("grpc", s.tlsConf != nil), addr)
+		scheme := endpoint.Scheme("grpc", s.tlsConf != nil)
+		if s.advertiseScheme != "" {
+			scheme = s.advertiseScheme
+		}
+		s.endpoint = endpoint.NewEndpoint(scheme, addr)
 	}
 	return s.err
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -13,8 +14,10 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 
 	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/endpoint"
 	"github.com/cnsync/kratos/internal/matcher"
 	pb "github.com/cnsync/kratos/internal/testdata/helloworld"
 	"github.com/cnsync/kratos/middleware"
@@ -144,6 +147,9 @@ func testClient(t *testing.T, srv *Server) {
 	if !reflect.DeepEqual(reply.Message, "Hello kratos") {
 		t.Errorf("expect %s, got %s", "Hello kratos", reply.Message)
 	}
+	if n := srv.ActiveConnections(); n != 1 {
+		t.Errorf("expect 1 active connection, got %d", n)
+	}
 
 	streamCli, err := client.SayHelloStream(context.Background())
 	if err != nil {
@@ -210,6 +216,89 @@ func TestTLSConfig(t *testing.T) {
 	}
 }
 
+type stubCertificate struct{ cert *tls.Certificate }
+
+func (s *stubCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.cert, nil
+}
+
+func (s *stubCertificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.cert, nil
+}
+
+func TestTLSCertificate(t *testing.T) {
+	p := &stubCertificate{cert: &tls.Certificate{}}
+	srv := NewServer(TLSCertificate(p), TLSConfig(&tls.Config{MinVersion: tls.VersionTLS13}))
+	if srv.tlsConf == nil || srv.tlsConf.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expect tls config with min version tls1.3, got %v", srv.tlsConf)
+	}
+	// 握手时应当从提供者获取证书
+	cert, err := srv.tlsConf.GetCertificate(nil)
+	if err != nil || cert != p.cert {
+		t.Errorf("expect %v, got %v", p.cert, cert)
+	}
+}
+
+func TestMaxConnectionAge(t *testing.T) {
+	o := &Server{}
+	v := time.Duration(123)
+	MaxConnectionAge(v)(o)
+	if !reflect.DeepEqual(v, o.maxConnectionAge) {
+		t.Errorf("expect %s, got %s", v, o.maxConnectionAge)
+	}
+	MaxConnectionAgeGrace(v)(o)
+	if !reflect.DeepEqual(v, o.maxConnectionAgeGrace) {
+		t.Errorf("expect %s, got %s", v, o.maxConnectionAgeGrace)
+	}
+	if s := NewServer(); s.maxConnectionAgeGrace != DefaultMaxConnectionAgeGrace {
+		t.Errorf("expect %s, got %s", DefaultMaxConnectionAgeGrace, s.maxConnectionAgeGrace)
+	}
+	if s := NewServer(); s.maxConnectionAge != DefaultMaxConnectionAge {
+		t.Errorf("expect %s, got %s", DefaultMaxConnectionAge, s.maxConnectionAge)
+	}
+	if s := NewServer(MaxConnectionAge(0)); s.maxConnectionAge != 0 {
+		t.Errorf("expect 0, got %s", s.maxConnectionAge)
+	}
+}
+
+func TestConnStatsHandler(t *testing.T) {
+	h := &connStatsHandler{}
+	ctx := context.Background()
+	h.HandleConn(ctx, &stats.ConnBegin{})
+	h.HandleConn(ctx, &stats.ConnBegin{})
+	h.HandleConn(ctx, &stats.ConnEnd{})
+	if n := h.active.Load(); n != 1 {
+		t.Errorf("expect 1, got %d", n)
+	}
+}
+
+func TestAdvertiseScheme(t *testing.T) {
+	tests := []struct {
+		opts []ServerOption
+		want string
+	}{
+		{nil, "grpc"},
+		{[]ServerOption{TLSConfig(&tls.Config{})}, "grpcs"},
+		{[]ServerOption{AdvertiseScheme("grpcs")}, "grpcs"},
+	}
+	for _, test := range tests {
+		srv := NewServer(append(test.opts, Address("127.0.0.1:0"))...)
+		e, err := srv.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Scheme != test.want {
+			t.Errorf("expect %s, got %s", test.want, e.Scheme)
+		}
+		// 安全连接的客户端能够从注册的端点中解析出地址
+		host, err := endpoint.ParseEndpoint([]string{e.String()}, endpoint.Scheme("grpc", test.want == "grpcs"))
+		if err != nil || host != e.Host {
+			t.Errorf("expect %s, got %s", e.Host, host)
+		}
+		_ = srv.lis.Close()
+	}
+}
+
 func TestUnaryInterceptor(t *testing.T) {
 	o := &Server{}
 	v := []grpc.UnaryServerInterceptor{
@@ -281,6 +370,65 @@ func TestServer_unaryServerInterceptor(t *testing.T) {
 	}
 }
 
+// TestServer_unaryServerInterceptorHeader 测试延迟创建的请求头与回复头以及 Transport 的复用
+func TestServer_unaryServerInterceptorHeader(t *testing.T) {
+	srv := &Server{
+		baseCtx:       context.Background(),
+		middleware:    matcher.New(),
+		transportPool: true,
+	}
+	var trs []transport.Transporter
+	srv.middleware.Use(func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, _ := transport.FromServerContext(ctx)
+			trs = append(trs, tr)
+			tr.ReplyHeader().Set("x-reply", tr.RequestHeader().Get("x-request"))
+			return handler(ctx, req)
+		}
+	})
+	for i := 0; i < 2; i++ {
+		value := fmt.Sprintf("value-%d", i)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request", value))
+		_, err := srv.unaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test"}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			tr, _ := transport.FromServerContext(ctx)
+			if got := tr.ReplyHeader().Get("x-reply"); got != value {
+				t.Errorf("expect %v, got %v", value, got)
+			}
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 调用返回后 Transport 被重置
+	if tr := trs[0].(*Transport); tr.operation != "" || tr.reqHeader != nil || tr.replyHeader != nil {
+		t.Errorf("expect transport to be reset, got %+v", tr)
+	}
+}
+
+func BenchmarkServer_unaryServerInterceptor(b *testing.B) {
+	for _, pool := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pool=%v", pool), func(b *testing.B) {
+			srv := &Server{
+				baseCtx:       context.Background(),
+				timeout:       time.Second,
+				middleware:    matcher.New(),
+				transportPool: pool,
+			}
+			srv.middleware.Use(EmptyMiddleware())
+			interceptor := srv.unaryServerInterceptor()
+			info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-md-global-key", "value"))
+			handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = interceptor(ctx, nil, info, handler)
+			}
+		})
+	}
+}
+
 type mockServerStream struct {
 	ctx      context.Context
 	sentMsg  interface{}
@@ -357,6 +505,91 @@ func TestServer_streamServerInterceptor(t *testing.T) {
 	}
 }
 
+type streamCtxKey struct{}
+
+func TestServer_streamServerInterceptorOrder(t *testing.T) {
+	srv := &Server{
+		baseCtx:          context.Background(),
+		middleware:       matcher.New(),
+		streamMiddleware: matcher.New(),
+	}
+	var calls []string
+	record := func(name string) middleware.Middleware {
+		return func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				// 流级别调用时 req 为流，消息级别调用时 req 为消息
+				kind := "msg"
+				if _, ok := req.(grpc.ServerStream); ok {
+					kind = "stream"
+					ctx = context.WithValue(ctx, streamCtxKey{}, name)
+				}
+				calls = append(calls, name+":"+kind+":before")
+				reply, err := handler(ctx, req)
+				calls = append(calls, name+":"+kind+":after")
+				return reply, err
+			}
+		}
+	}
+	srv.streamMiddleware.Use(record("a"), record("b"))
+
+	mockStream := &mockServerStream{ctx: context.Background()}
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		// 中间件向上下文添加的值可以通过流的上下文获取
+		if v := stream.Context().Value(streamCtxKey{}); v != "b" {
+			t.Errorf("expect %v, got %v", "b", v)
+		}
+		if GetStream(stream.Context()) != mockStream {
+			t.Error("expect the original stream in context")
+		}
+		calls = append(calls, "handler")
+		return stream.SendMsg(&testResp{Data: "hi"})
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/helloworld.Greeter/SayHelloStream"}
+	if err := srv.streamServerInterceptor()(nil, mockStream, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"a:stream:before", "b:stream:before",
+		"handler",
+		"a:msg:before", "b:msg:before", "b:msg:after", "a:msg:after",
+		"b:stream:after", "a:stream:after",
+	}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Errorf("expect %v, got %v", expected, calls)
+	}
+}
+
+func TestServer_streamServerInterceptorError(t *testing.T) {
+	srv := &Server{
+		baseCtx:          context.Background(),
+		middleware:       matcher.New(),
+		streamMiddleware: matcher.New(),
+	}
+	srv.streamMiddleware.Use(func(middleware.Handler) middleware.Handler {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, errors.Unauthorized("UNAUTHORIZED", "denied")
+		}
+	})
+	called := false
+	handler := func(interface{}, grpc.ServerStream) error {
+		called = true
+		return nil
+	}
+	mockStream := &mockServerStream{ctx: context.Background()}
+	info := &grpc.StreamServerInfo{FullMethod: "/helloworld.Greeter/SayHelloStream"}
+	err := srv.streamServerInterceptor()(nil, mockStream, info, handler)
+	// 中间件返回错误时不调用处理函数
+	if !errors.IsUnauthorized(err) {
+		t.Errorf("expect unauthorized error, got %v", err)
+	}
+	if called {
+		t.Error("expect handler not to be called")
+	}
+	if GetStream(context.Background()) != nil {
+		t.Error("expect no stream in context")
+	}
+}
+
 func TestListener(t *testing.T) {
 	lis, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -371,3 +604,56 @@ func TestListener(t *testing.T) {
 		t.Errorf("expect not empty")
 	}
 }
+
+func TestAdvertiseAddress(t *testing.T) {
+	srv := NewServer(Address(":0"), AdvertiseAddress("203.0.113.1:8443"))
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Host != "203.0.113.1:8443" {
+		t.Errorf("expected %s got %s", "203.0.113.1:8443", e.Host)
+	}
+	_ = srv.lis.Close()
+
+	// 子网格式错误
+	srv = NewServer(Address(":0"), AdvertiseSubnet("10.0.0.0"))
+	if _, err = srv.Endpoint(); err == nil {
+		t.Error("expected error for invalid subnet")
+	}
+	if srv.lis != nil {
+		_ = srv.lis.Close()
+		t.Error("expected no listener for invalid subnet")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	srv := NewServer(Network("unix"), Address(sock))
+	pb.RegisterGreeterServer(srv, &server{})
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.String() != "unix://"+sock {
+		t.Errorf("expected %s got %s", "unix://"+sock, e)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(e.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reply, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "Hello kratos" {
+		t.Errorf("unexpected reply %s", reply.Message)
+	}
+}
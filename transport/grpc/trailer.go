@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	grpcmd "google.golang.org/grpc/metadata"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+const (
+	// errorCodeKey 是错误的 HTTP 状态码在 gRPC trailer 中的键。
+	errorCodeKey = "x-kratos-error-code"
+	// errorReasonKey 是错误原因在 gRPC trailer 中的键。
+	errorReasonKey = "x-kratos-error-reason"
+	// errorCausesKey 是错误根因链摘要在 gRPC trailer 中的键，每个根因为一个 "原因:状态码" 形式的值。
+	errorCausesKey = "x-kratos-error-causes"
+	// errorMetadataPrefix 是错误元数据在 gRPC trailer 中的键前缀。
+	errorMetadataPrefix = "x-kratos-error-md-"
+)
+
+// errorTrailer 将错误的原因、元数据和根因链摘要转换为 gRPC trailer，
+// 使其他语言的客户端无需解析错误详情即可读取结构化的错误信息。
+// 元数据的键会转换为合法的 gRPC 元数据键，包含非 ASCII 可打印字符的值使用 -bin 后缀的二进制键传递。
+func errorTrailer(err error) grpcmd.MD {
+	se := errors.FromError(err)
+	if se == nil {
+		return nil
+	}
+	md := grpcmd.MD{}
+	md.Set(errorCodeKey, strconv.Itoa(int(se.Code)))
+	if se.Reason != "" {
+		md.Set(errorReasonKey, se.Reason)
+	}
+	for k, v := range se.Metadata {
+		key := errorMetadataPrefix + metadataKey(k)
+		if !printable(v) {
+			key += "-bin"
+		}
+		md.Append(key, v)
+	}
+	for _, c := range se.Causes() {
+		md.Append(errorCausesKey, c.Reason+":"+strconv.Itoa(int(c.Code)))
+	}
+	return md
+}
+
+// mergeErrorTrailer 将 gRPC trailer 中的错误信息合并到错误中，错误本身携带的信息优先。
+// trailer 没有补充任何信息时原样返回错误。
+func mergeErrorTrailer(err error, md grpcmd.MD) error {
+	if err == nil || len(md.Get(errorCodeKey)) == 0 {
+		return err
+	}
+	se := errors.Clone(errors.FromError(err))
+	changed := false
+	if v := md.Get(errorReasonKey); len(v) > 0 && (se.Reason == "" || se.Reason == errors.UnknownReason) {
+		se.Reason = v[0]
+		changed = true
+	}
+	// gRPC 状态码无法区分的 HTTP 状态码（例如 400 与 422）以 trailer 为准
+	if code, perr := strconv.Atoi(md.Get(errorCodeKey)[0]); perr == nil && int32(code) != se.Code {
+		se.Code = int32(code)
+		changed = true
+	}
+	existing := make(map[string]struct{}, len(se.Metadata))
+	for k := range se.Metadata {
+		existing[metadataKey(k)] = struct{}{}
+	}
+	for k, vList := range md {
+		if !strings.HasPrefix(k, errorMetadataPrefix) || len(vList) == 0 {
+			continue
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(k, errorMetadataPrefix), "-bin")
+		if _, ok := existing[key]; ok {
+			continue
+		}
+		se.Metadata[key] = vList[0]
+		changed = true
+	}
+	if !changed {
+		return err
+	}
+	return se
+}
+
+// metadataKey 将元数据的键转换为合法的 gRPC 元数据键，非法字符替换为 "-"。
+func metadataKey(k string) string {
+	k = strings.ToLower(k)
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.TrimSuffix(k, "-bin"))
+}
+
+// printable 判断值是否只包含 ASCII 可打印字符，可以作为非二进制的 gRPC 元数据值传递。
+func printable(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/cnsync/kratos/errors"
+	pb "github.com/cnsync/kratos/internal/testdata/helloworld"
+	"github.com/cnsync/kratos/middleware"
+)
+
+// TestErrorTrailer 测试错误信息与 trailer 的相互转换
+func TestErrorTrailer(t *testing.T) {
+	err := errors.New(422, "INVALID_NAME", "invalid name").
+		WithMetadata(map[string]string{"Field": "name", "note": "名称非法"}).
+		WithCause(errors.New(503, "DB", "db unavailable"))
+	md := errorTrailer(err)
+	want := map[string]string{
+		errorCodeKey:                     "422",
+		errorReasonKey:                   "INVALID_NAME",
+		errorMetadataPrefix + "field":    "name",
+		errorMetadataPrefix + "note-bin": "名称非法",
+		errorCausesKey:                   "DB:503",
+	}
+	for k, v := range want {
+		if got := md.Get(k); len(got) != 1 || got[0] != v {
+			t.Errorf("%s: want %q, got %v", k, v, got)
+		}
+	}
+
+	// trailer 补充了 gRPC 状态码无法区分的 HTTP 状态码和缺失的信息
+	se := errors.FromError(mergeErrorTrailer(status.Error(3, "invalid name"), md))
+	if se.Code != 422 || se.Reason != "INVALID_NAME" || se.Metadata["field"] != "name" || se.Metadata["note"] != "名称非法" {
+		t.Errorf("unexpected error: %v", se)
+	}
+	// 错误本身携带的信息优先
+	se = errors.FromError(mergeErrorTrailer(err.GRPCStatus().Err(), md))
+	if se.Metadata["Field"] != "name" || len(se.Metadata) != 2 {
+		t.Errorf("unexpected metadata: %v", se.Metadata)
+	}
+	// trailer 中没有错误信息时原样返回
+	origin := status.Error(3, "invalid")
+	if got := mergeErrorTrailer(origin, grpcmd.MD{}); got != origin {
+		t.Errorf("expected original error, got %v", got)
+	}
+}
+
+// TestErrorTrailerServer 测试服务端将错误信息写入 trailer，客户端从 trailer 中读取
+func TestErrorTrailerServer(t *testing.T) {
+	srv := NewServer(Middleware(func(middleware.Handler) middleware.Handler {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, errors.New(422, "INVALID_NAME", "invalid name").WithMetadata(map[string]string{"field": "name"})
+		}
+	}))
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	// 其他语言的客户端可以直接从 trailer 中读取错误信息
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var trailer grpcmd.MD
+	_, err = pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"}, grpc.Trailer(&trailer))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if v := trailer.Get(errorReasonKey); len(v) != 1 || v[0] != "INVALID_NAME" {
+		t.Errorf("unexpected trailer: %v", trailer)
+	}
+	if v := trailer.Get(errorMetadataPrefix + "field"); len(v) != 1 || v[0] != "name" {
+		t.Errorf("unexpected trailer: %v", trailer)
+	}
+
+	kconn, err := DialInsecure(context.Background(), WithEndpoint(u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kconn.Close()
+	_, err = pb.NewGreeterClient(kconn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+	if se := errors.FromError(err); se.Code != 422 || se.Reason != "INVALID_NAME" {
+		t.Errorf("unexpected error: %v", se)
+	}
+}
+
+// TestDetailedErrors 测试仅在启用详细错误时通过 gRPC 状态传递根因链
+func TestDetailedErrors(t *testing.T) {
+	for _, detailed := range []bool{false, true} {
+		opts := []ServerOption{Middleware(func(middleware.Handler) middleware.Handler {
+			return func(context.Context, interface{}) (interface{}, error) {
+				return nil, errors.New(500, "REPO", "query failed").WithCause(errors.New(503, "DB", "db unavailable"))
+			}
+		})}
+		if detailed {
+			opts = append(opts, DetailedErrors())
+		}
+		srv := NewServer(opts...)
+		pb.RegisterGreeterServer(srv, &server{})
+		u, err := srv.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			_ = srv.Start(context.Background())
+		}()
+		time.Sleep(100 * time.Millisecond)
+
+		conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+		se := errors.FromError(err)
+		if se.Reason != "REPO" {
+			t.Errorf("unexpected error: %v", se)
+		}
+		causes := se.Causes()
+		if detailed && (len(causes) != 1 || causes[0].Reason != "DB" || causes[0].Code != 503) {
+			t.Errorf("expected causes, got %v", causes)
+		}
+		if !detailed && len(causes) != 0 {
+			t.Errorf("expected no causes, got %v", causes)
+		}
+		_ = conn.Close()
+		_ = srv.Stop(context.Background())
+	}
+}
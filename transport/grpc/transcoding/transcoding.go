@@ -0,0 +1,245 @@
+// Package transcoding 根据服务描述符中的 google.api.http 规则，在运行时将 HTTP/JSON 请求转码为 gRPC 调用，
+// 无需代码生成即可在 kratos HTTP 服务器中内嵌网关。
+package transcoding
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"regexp"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/cnsync/kratos/transport/http"
+)
+
+// Option 是转码的选项。
+type Option func(*options)
+
+// options 是转码的配置。
+type options struct {
+	files    *protoregistry.Files
+	services []protoreflect.FullName
+	headers  []string
+}
+
+// WithFiles 设置查找服务描述符的注册表，默认为 protoregistry.GlobalFiles。
+func WithFiles(files *protoregistry.Files) Option {
+	return func(o *options) {
+		o.files = files
+	}
+}
+
+// WithServices 设置需要转码的服务的全名，默认为注册表中所有声明了 google.api.http 规则的服务。
+func WithServices(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.services = append(o.services, protoreflect.FullName(name))
+		}
+	}
+}
+
+// WithHeaders 设置需要作为 gRPC 元数据转发的请求头。
+func WithHeaders(keys ...string) Option {
+	return func(o *options) {
+		o.headers = append(o.headers, keys...)
+	}
+}
+
+// Register 为服务中声明了 google.api.http 规则的一元方法在 HTTP 服务器上注册路由，
+// 请求按规则绑定请求体、查询参数与路径变量后，通过 cc 以 gRPC 调用转发，响应按规则编码为 HTTP 响应。
+// 流式方法与没有 google.api.http 规则的方法会被忽略。
+func Register(s *http.Server, cc grpc.ClientConnInterface, opts ...Option) error {
+	o := &options{files: protoregistry.GlobalFiles}
+	for _, opt := range opts {
+		opt(o)
+	}
+	services, err := o.lookupServices()
+	if err != nil {
+		return err
+	}
+	r := s.Route("/")
+	for _, sd := range services {
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			if md.IsStreamingClient() || md.IsStreamingServer() {
+				continue
+			}
+			rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			for _, b := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				route, err := newRoute(md, b, cc, o.headers)
+				if err != nil {
+					return err
+				}
+				r.Handle(route.method, route.path, route.handle)
+			}
+		}
+	}
+	return nil
+}
+
+// lookupServices 返回需要转码的服务描述符。
+func (o *options) lookupServices() ([]protoreflect.ServiceDescriptor, error) {
+	var services []protoreflect.ServiceDescriptor
+	if len(o.services) > 0 {
+		for _, name := range o.services {
+			d, err := o.files.FindDescriptorByName(name)
+			if err != nil {
+				return nil, fmt.Errorf("transcoding: service %s not found: %w", name, err)
+			}
+			sd, ok := d.(protoreflect.ServiceDescriptor)
+			if !ok {
+				return nil, fmt.Errorf("transcoding: %s is not a service", name)
+			}
+			services = append(services, sd)
+		}
+		return services, nil
+	}
+	o.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			services = append(services, fd.Services().Get(i))
+		}
+		return true
+	})
+	return services, nil
+}
+
+// route 是一条 google.api.http 规则对应的 HTTP 路由。
+type route struct {
+	method    string
+	path      string
+	operation string
+	md        protoreflect.MethodDescriptor
+	cc        grpc.ClientConnInterface
+	headers   []string
+	// body 为 nil 时不绑定请求体，为 "*" 规则时 bodyAll 为 true
+	body         protoreflect.FieldDescriptor
+	bodyAll      bool
+	responseBody protoreflect.FieldDescriptor
+}
+
+// newRoute 根据 google.api.http 规则创建路由。
+func newRoute(md protoreflect.MethodDescriptor, rule *annotations.HttpRule, cc grpc.ClientConnInterface, headers []string) (*route, error) {
+	operation := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	r := &route{operation: operation, md: md, cc: cc, headers: headers}
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		r.method, r.path = nethttp.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		r.method, r.path = nethttp.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		r.method, r.path = nethttp.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		r.method, r.path = nethttp.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		r.method, r.path = nethttp.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		r.method, r.path = pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	}
+	if r.method == "" {
+		r.method = nethttp.MethodPost
+	}
+	if r.path == "" {
+		r.path = operation
+	}
+	r.path = muxPath(r.path)
+	switch body := rule.GetBody(); body {
+	case "":
+	case "*":
+		r.bodyAll = true
+	default:
+		fd := md.Input().Fields().ByName(protoreflect.Name(body))
+		if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("transcoding: body %q of %s is not a message field", body, operation)
+		}
+		r.body = fd
+	}
+	if name := rule.GetResponseBody(); name != "" {
+		r.responseBody = md.Output().Fields().ByName(protoreflect.Name(name))
+		if r.responseBody == nil {
+			return nil, fmt.Errorf("transcoding: response body %q of %s not found", name, operation)
+		}
+	}
+	return r, nil
+}
+
+// handle 将 HTTP 请求转码为 gRPC 调用。
+func (r *route) handle(ctx http.Context) error {
+	in := dynamicpb.NewMessage(r.md.Input())
+	switch {
+	case r.bodyAll:
+		if err := ctx.Bind(in); err != nil {
+			return err
+		}
+	case r.body != nil:
+		if err := ctx.Bind(in.Mutable(r.body).Message().Interface()); err != nil {
+			return err
+		}
+	}
+	if err := ctx.BindQuery(in); err != nil {
+		return err
+	}
+	if err := ctx.BindVars(in); err != nil {
+		return err
+	}
+	http.SetOperation(ctx, r.operation)
+	h := ctx.Middleware(func(c context.Context, req interface{}) (interface{}, error) {
+		out := dynamicpb.NewMessage(r.md.Output())
+		if md := r.metadata(ctx.Request()); md.Len() > 0 {
+			c = grpcmd.NewOutgoingContext(c, md)
+		}
+		if err := r.cc.Invoke(c, r.operation, req, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	})
+	out, err := h(ctx, in)
+	if err != nil {
+		return err
+	}
+	reply := out.(*dynamicpb.Message)
+	if r.responseBody != nil {
+		v := reply.Get(r.responseBody)
+		if r.responseBody.Message() != nil && !r.responseBody.IsList() && !r.responseBody.IsMap() {
+			return ctx.Result(200, v.Message().Interface())
+		}
+		return ctx.Result(200, v.Interface())
+	}
+	return ctx.Result(200, reply)
+}
+
+// metadata 返回需要转发的请求头。
+func (r *route) metadata(req *nethttp.Request) grpcmd.MD {
+	md := grpcmd.MD{}
+	for _, key := range r.headers {
+		if v := req.Header.Values(key); len(v) > 0 {
+			md.Append(key, v...)
+		}
+	}
+	return md
+}
+
+var pathVarPattern = regexp.MustCompile(`{\s*([a-zA-Z0-9_.]+)\s*(?:=([^{}]*))?}`)
+
+// muxPath 将 google.api.http 的路径模板转换为路由使用的路径模板，
+// 例如 /v1/{name=messages/*} 转换为 /v1/{name:messages/.*}，与 protoc-gen-go-http 一致。
+func muxPath(path string) string {
+	return pathVarPattern.ReplaceAllStringFunc(path, func(s string) string {
+		m := pathVarPattern.FindStringSubmatch(s)
+		if m[2] == "" {
+			return "{" + m[1] + "}"
+		}
+		return "{" + m[1] + ":" + strings.ReplaceAll(m[2], "*", ".*") + "}"
+	})
+}
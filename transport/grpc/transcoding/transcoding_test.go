@@ -0,0 +1,114 @@
+package transcoding
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcmd "google.golang.org/grpc/metadata"
+
+	apimd "github.com/cnsync/kratos/api/metadata"
+	"github.com/cnsync/kratos/transport/http"
+)
+
+// newTestServer 启动注册了元数据服务的 gRPC 服务器，并返回在 HTTP 服务器上转码该服务的测试服务器
+func newTestServer(t *testing.T, opts ...Option) (*httptest.Server, chan grpcmd.MD) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mds := make(chan grpcmd.MD, 8)
+	gs := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+		md, _ := grpcmd.FromIncomingContext(ctx)
+		mds <- md
+		return h(ctx, req)
+	}))
+	apimd.RegisterMetadataServer(gs, apimd.NewServer(gs))
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	cc, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+
+	hs := http.NewServer()
+	if err = Register(hs, cc, append([]Option{WithServices("kratos.api.Metadata")}, opts...)...); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(hs)
+	t.Cleanup(srv.Close)
+	return srv, mds
+}
+
+func TestRegister(t *testing.T) {
+	srv, mds := newTestServer(t, WithHeaders("X-Tenant"))
+
+	req, _ := nethttp.NewRequest(nethttp.MethodGet, srv.URL+"/services", nil)
+	req.Header.Set("X-Tenant", "a")
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Services []string `json:"services"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Services) != 1 || list.Services[0] != "kratos.api.Metadata" {
+		t.Errorf("unexpected services %v", list.Services)
+	}
+	if md := <-mds; len(md.Get("x-tenant")) == 0 || md.Get("x-tenant")[0] != "a" {
+		t.Errorf("expected header forwarded, got %v", md)
+	}
+
+	// 路径变量绑定到请求消息
+	resp, err = nethttp.Get(srv.URL + "/services/kratos.api.Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// gRPC 错误转换为对应的 HTTP 状态码
+	resp, err = nethttp.Get(srv.URL + "/services/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestRegisterUnknownService(t *testing.T) {
+	if err := Register(http.NewServer(), nil, WithServices("unknown.Service")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMuxPath(t *testing.T) {
+	tests := map[string]string{
+		"/v1/messages":                        "/v1/messages",
+		"/v1/{name}":                          "/v1/{name}",
+		"/v1/{name=messages/*}":               "/v1/{name:messages/.*}",
+		"/v1/{parent.id}/items/{ item_id }":   "/v1/{parent.id}/items/{item_id}",
+		"/v1/{name=shelves/*/books/*}:cancel": "/v1/{name:shelves/.*/books/.*}:cancel",
+	}
+	for in, want := range tests {
+		if got := muxPath(in); got != want {
+			t.Errorf("muxPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package grpc
 
 import (
+	"context"
+	"sync"
+
 	"google.golang.org/grpc/metadata"
 
 	"github.com/cnsync/kratos/selector"
@@ -10,12 +13,17 @@ import (
 var _ transport.Transporter = (*Transport)(nil)
 
 // Transport 是一个 gRPC 传输器。
+// 服务端的请求头与回复头在第一次读取时才创建，没有中间件读取时不会复制请求的元数据。
 type Transport struct {
 	endpoint    string                // 端点地址
 	operation   string                // 操作名称
 	reqHeader   headerCarrier         // 请求头
 	replyHeader headerCarrier         // 回复头
 	nodeFilters []selector.NodeFilter // 节点过滤器
+
+	incoming  context.Context // 读取请求元数据的上下文，为空时直接使用 reqHeader
+	reqOnce   sync.Once
+	replyOnce sync.Once
 }
 
 // Kind 返回传输器的类型。
@@ -35,14 +43,40 @@ func (tr *Transport) Operation() string {
 
 // RequestHeader 返回请求头。
 func (tr *Transport) RequestHeader() transport.Header {
-	return tr.reqHeader
+	return tr.requestHeader()
 }
 
 // ReplyHeader 返回回复头。
 func (tr *Transport) ReplyHeader() transport.Header {
+	return tr.replyHeaderMD()
+}
+
+// requestHeader 返回请求头，服务端在第一次调用时从上下文中读取请求的元数据。
+func (tr *Transport) requestHeader() headerCarrier {
+	if tr.incoming != nil {
+		tr.reqOnce.Do(func() {
+			md, _ := metadata.FromIncomingContext(tr.incoming)
+			tr.reqHeader = headerCarrier(md)
+		})
+	}
+	return tr.reqHeader
+}
+
+// replyHeaderMD 返回回复头，在第一次调用时创建。
+func (tr *Transport) replyHeaderMD() headerCarrier {
+	tr.replyOnce.Do(func() {
+		if tr.replyHeader == nil {
+			tr.replyHeader = headerCarrier{}
+		}
+	})
 	return tr.replyHeader
 }
 
+// hasReplyHeader 判断是否设置了回复头，不会创建回复头。
+func (tr *Transport) hasReplyHeader() bool {
+	return len(tr.replyHeader) > 0
+}
+
 // NodeFilters 返回客户端选择过滤器。
 func (tr *Transport) NodeFilters() []selector.NodeFilter {
 	return tr.nodeFilters
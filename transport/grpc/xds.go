@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+
+	"github.com/cnsync/kratos/log"
+)
+
+const (
+	// xdsScheme 是 xDS 解析器的协议名。
+	xdsScheme = "xds"
+	// XDSBootstrapEnv 是 gRPC 读取 xDS 引导文件路径的环境变量。
+	XDSBootstrapEnv = "GRPC_XDS_BOOTSTRAP"
+	// XDSBootstrapConfigEnv 是 gRPC 读取 xDS 引导配置内容的环境变量。
+	XDSBootstrapConfigEnv = "GRPC_XDS_BOOTSTRAP_CONFIG"
+)
+
+// ErrXDSUnavailable 表示 xDS 不可用并且禁用了回退。
+var ErrXDSUnavailable = errors.New("grpc: xds resolver is not registered or the bootstrap config is missing")
+
+// XDSOption 是 xDS 的配置选项。
+type XDSOption func(*xdsOptions)
+
+type xdsOptions struct {
+	target   string
+	resolver resolver.Builder
+	fallback bool
+}
+
+// XDSTarget 设置 xDS 的服务名称，默认使用 WithEndpoint 设置的端点中的服务名称。
+func XDSTarget(name string) XDSOption {
+	return func(o *xdsOptions) {
+		o.target = name
+	}
+}
+
+// XDSResolver 设置 xDS 解析器，例如使用自定义引导配置创建的解析器，
+// 设置后不再依赖全局注册的 xds 解析器与引导配置的环境变量。
+func XDSResolver(b resolver.Builder) XDSOption {
+	return func(o *xdsOptions) {
+		o.resolver = b
+	}
+}
+
+// XDSFallback 设置 xDS 不可用时是否回退到服务发现或直连，默认启用。
+func XDSFallback(fallback bool) XDSOption {
+	return func(o *xdsOptions) {
+		o.fallback = fallback
+	}
+}
+
+// WithXDS 使用 xDS 进行服务解析与负载均衡，适用于 Istio、Traffic Director 等服务网格，
+// 客户端的中间件与拦截器照常生效，节点过滤与负载均衡由 xDS 下发的配置决定。
+//
+// 需要导入 google.golang.org/grpc/xds 注册 xds 解析器，并通过 GRPC_XDS_BOOTSTRAP
+// 或 GRPC_XDS_BOOTSTRAP_CONFIG 环境变量提供引导配置，或者使用 XDSResolver 指定解析器。
+// xDS 不可用时默认回退到 WithDiscovery 设置的服务发现。
+func WithXDS(opts ...XDSOption) ClientOption {
+	return func(o *clientOptions) {
+		x := &xdsOptions{fallback: true}
+		for _, opt := range opts {
+			opt(x)
+		}
+		o.xds = x
+	}
+}
+
+// available 判断 xDS 是否可用。
+func (o *xdsOptions) available() bool {
+	if o.resolver != nil {
+		return true
+	}
+	if resolver.Get(xdsScheme) == nil {
+		return false
+	}
+	return os.Getenv(XDSBootstrapEnv) != "" || os.Getenv(XDSBootstrapConfigEnv) != ""
+}
+
+// endpoint 返回 xDS 的目标地址，例如 discovery:///helloworld 转换为 xds:///helloworld。
+func (o *xdsOptions) endpoint(endpoint string) string {
+	name := o.target
+	if name == "" {
+		name = endpoint
+		if u, err := url.Parse(endpoint); err == nil && strings.Contains(endpoint, "://") {
+			name = strings.TrimPrefix(u.Path, "/")
+		}
+	}
+	return xdsScheme + ":///" + name
+}
+
+// xdsEndpoint 返回使用 xDS 时的目标地址，xDS 不可用时按照回退的配置返回原地址或错误。
+func xdsEndpoint(o *clientOptions) (string, bool, error) {
+	if o.xds == nil {
+		return o.endpoint, false, nil
+	}
+	if o.xds.available() {
+		return o.xds.endpoint(o.endpoint), true, nil
+	}
+	if !o.xds.fallback {
+		return "", false, ErrXDSUnavailable
+	}
+	log.Warnf("[gRPC] xds is unavailable, fall back to the endpoint: %s", o.endpoint)
+	return o.endpoint, false, nil
+}
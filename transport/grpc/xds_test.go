@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// TestXDSEndpoint 测试 xDS 目标地址的转换
+func TestXDSEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		opts     []XDSOption
+		want     string
+	}{
+		{"discovery:///helloworld", nil, "xds:///helloworld"},
+		{"helloworld", nil, "xds:///helloworld"},
+		{"discovery:///helloworld", []XDSOption{XDSTarget("greeter.mesh:9000")}, "xds:///greeter.mesh:9000"},
+	}
+	for _, test := range tests {
+		o := &clientOptions{endpoint: test.endpoint}
+		WithXDS(test.opts...)(o)
+		if got := o.xds.endpoint(o.endpoint); got != test.want {
+			t.Errorf("expect %v but got %v", test.want, got)
+		}
+	}
+}
+
+// TestWithXDS_Fallback 测试 xDS 不可用时回退到原地址
+func TestWithXDS_Fallback(t *testing.T) {
+	if resolver.Get(xdsScheme) != nil {
+		t.Skip("xds resolver is registered")
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(lis.Addr().String()), WithXDS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.Target() != lis.Addr().String() {
+		t.Errorf("expect %v but got %v", lis.Addr().String(), conn.Target())
+	}
+
+	_, err = DialInsecure(context.Background(), WithEndpoint(lis.Addr().String()), WithXDS(XDSFallback(false)))
+	if !errors.Is(err, ErrXDSUnavailable) {
+		t.Errorf("expect %v but got %v", ErrXDSUnavailable, err)
+	}
+}
+
+// TestWithXDS_Resolver 测试使用指定的 xDS 解析器
+func TestWithXDS_Resolver(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	r := manual.NewBuilderWithScheme(xdsScheme)
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: lis.Addr().String()}}})
+	conn, err := DialInsecure(
+		context.Background(),
+		WithEndpoint("discovery:///helloworld"),
+		WithXDS(XDSResolver(r)),
+		WithBlock(),
+		WithConnectTimeout(5*time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.Target() != "xds:///helloworld" {
+		t.Errorf("expect %v but got %v", "xds:///helloworld", conn.Target())
+	}
+}
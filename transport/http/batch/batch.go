@@ -0,0 +1,251 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultMaxRequests = 50
+	defaultConcurrency = 8
+)
+
+// nestedKey 标记子请求的上下文，用于拒绝嵌套的批量请求。
+type nestedKey struct{}
+
+// Option 是批量请求处理器的配置选项。
+type Option func(*options)
+
+type options struct {
+	maxRequests int
+	concurrency int
+	maxBodySize int64
+}
+
+// MaxRequests 配置单个批量请求中允许的最大子请求数量，默认为 50。
+func MaxRequests(n int) Option {
+	return func(o *options) {
+		o.maxRequests = n
+	}
+}
+
+// Concurrency 配置子请求的最大并发执行数量，默认为 8。
+func Concurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// MaxBodySize 配置批量请求体的最大字节数，小于等于 0 表示不限制。
+func MaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// Request 描述批量请求中的一个子请求。
+type Request struct {
+	// ID 由调用方指定，原样回填到对应的 Response 中，便于关联结果。
+	ID     string            `json:"id,omitempty"`
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response 描述一个子请求的执行结果。
+// 单个子请求失败不会影响其他子请求，失败信息通过 Status 和 Error 体现。
+type Response struct {
+	ID     string            `json:"id,omitempty"`
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// Batch 是批量请求的请求体。
+type Batch struct {
+	Requests []*Request `json:"requests"`
+}
+
+// Result 是批量请求的响应体，Responses 与 Batch.Requests 按下标一一对应。
+type Result struct {
+	Responses []*Response `json:"responses"`
+}
+
+// NewHandler 创建一个批量请求处理器，它将每个子请求交给 h 执行，
+// 通常 h 为服务器自身（*http.Server 实现了 http.Handler），使子请求经过完整的过滤器与中间件链。
+// 子请求会继承批量请求的请求头与上下文，子请求自身的 Header 优先。
+// 子请求不能再发起批量请求，避免递归放大请求数量。
+func NewHandler(h http.Handler, opts ...Option) http.Handler {
+	o := options{
+		maxRequests: defaultMaxRequests,
+		concurrency: defaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = 1
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "batch: method not allowed")
+			return
+		}
+		if r.Context().Value(nestedKey{}) != nil {
+			writeError(w, http.StatusBadRequest, "batch: nested batch request")
+			return
+		}
+		body := r.Body
+		if o.maxBodySize > 0 {
+			body = http.MaxBytesReader(w, body, o.maxBodySize)
+		}
+		var batch Batch
+		if err := json.NewDecoder(body).Decode(&batch); err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("batch: request body exceeds %d bytes", mbe.Limit))
+				return
+			}
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("batch: invalid request body: %v", err))
+			return
+		}
+		if o.maxRequests > 0 && len(batch.Requests) > o.maxRequests {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("batch: too many requests: %d > %d", len(batch.Requests), o.maxRequests))
+			return
+		}
+		result := Result{Responses: make([]*Response, len(batch.Requests))}
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, o.concurrency)
+		)
+		for i, sub := range batch.Requests {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, sub *Request) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				result.Responses[i] = do(h, r, sub)
+			}(i, sub)
+		}
+		wg.Wait()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
+
+// do 执行单个子请求，并将 panic 和非法参数转换为失败的 Response。
+func do(h http.Handler, parent *http.Request, sub *Request) (res *Response) {
+	if sub == nil {
+		return &Response{Status: http.StatusBadRequest, Error: "batch: empty request"}
+	}
+	res = &Response{ID: sub.ID}
+	defer func() {
+		if err := recover(); err != nil {
+			res.Status = http.StatusInternalServerError
+			res.Header = nil
+			res.Body = nil
+			res.Error = fmt.Sprintf("batch: panic: %v", err)
+		}
+	}()
+	if sub.Method == "" {
+		sub.Method = http.MethodGet
+	}
+	if !strings.HasPrefix(sub.Path, "/") {
+		res.Status = http.StatusBadRequest
+		res.Error = fmt.Sprintf("batch: invalid path: %q", sub.Path)
+		return res
+	}
+	if u, err := url.Parse(sub.Path); err == nil && path.Clean(u.Path) == path.Clean(parent.URL.Path) {
+		res.Status = http.StatusBadRequest
+		res.Error = "batch: nested batch request"
+		return res
+	}
+	ctx := context.WithValue(parent.Context(), nestedKey{}, struct{}{})
+	req, err := http.NewRequestWithContext(ctx, sub.Method, sub.Path, bytes.NewReader(sub.Body))
+	if err != nil {
+		res.Status = http.StatusBadRequest
+		res.Error = err.Error()
+		return res
+	}
+	req.Header = parent.Header.Clone()
+	req.Header.Del("Content-Length")
+	for k, v := range sub.Header {
+		req.Header.Set(k, v)
+	}
+	if len(sub.Body) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.RemoteAddr = parent.RemoteAddr
+	req.Host = parent.Host
+
+	rec := newRecorder()
+	h.ServeHTTP(rec, req)
+
+	res.Status = rec.code
+	res.Header = make(map[string]string, len(rec.header))
+	for k := range rec.header {
+		res.Header[k] = rec.header.Get(k)
+	}
+	if data := rec.body.Bytes(); len(data) > 0 {
+		if json.Valid(data) {
+			res.Body = data
+		} else {
+			res.Body, _ = json.Marshal(string(data))
+		}
+	}
+	if res.Status >= http.StatusBadRequest {
+		res.Error = http.StatusText(res.Status)
+	}
+	return res
+}
+
+// recorder 是一个内存中的 http.ResponseWriter，用于收集子请求的响应。
+type recorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	code        int
+	wroteHeader bool
+}
+
+// newRecorder 创建一个默认状态码为 200 的 recorder。
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header), code: http.StatusOK}
+}
+
+// Header 返回响应头。
+func (r *recorder) Header() http.Header { return r.header }
+
+// WriteHeader 记录状态码，仅第一次调用生效。
+func (r *recorder) WriteHeader(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.code = code
+}
+
+// Write 将响应数据写入缓冲区。
+func (r *recorder) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(data)
+}
+
+// writeError 以 JSON 格式写入批量请求本身的错误。
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "message": msg})
+}
@@ -0,0 +1,154 @@
+package batch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"method": r.Method, "auth": r.Header.Get("Authorization")})
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("plain"))
+	})
+	mux.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	return mux
+}
+
+func doBatch(t *testing.T, h http.Handler, body string) (*httptest.ResponseRecorder, *Result) {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set("Authorization", "token")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec, nil
+	}
+	var res Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return rec, &res
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(testHandler())
+	_, res := doBatch(t, h, `{"requests":[
+		{"id":"1","method":"GET","path":"/hello"},
+		{"id":"2","method":"POST","path":"/echo","body":{"a":1}},
+		{"id":"3","path":"/text"},
+		{"id":"4","path":"/notfound"},
+		{"id":"5","path":"/panic"},
+		{"id":"6","path":"relative"}
+	]}`)
+	if res == nil || len(res.Responses) != 6 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	tests := []struct {
+		status int
+		body   string
+		err    bool
+	}{
+		{http.StatusOK, `{"auth":"token","method":"GET"}`, false},
+		{http.StatusOK, `{"a":1}`, false},
+		{http.StatusOK, `"plain"`, false},
+		{http.StatusNotFound, "", true},
+		{http.StatusInternalServerError, "", true},
+		{http.StatusBadRequest, "", true},
+	}
+	for i, test := range tests {
+		r := res.Responses[i]
+		if r.ID == "" {
+			t.Errorf("[%d] expected id", i)
+		}
+		if r.Status != test.status {
+			t.Errorf("[%d] expected status %d, got %d", i, test.status, r.Status)
+		}
+		if test.body != "" && strings.TrimSpace(string(r.Body)) != test.body {
+			t.Errorf("[%d] expected body %s, got %s", i, test.body, r.Body)
+		}
+		if (r.Error != "") != test.err {
+			t.Errorf("[%d] unexpected error: %q", i, r.Error)
+		}
+	}
+}
+
+func TestHandlerLimits(t *testing.T) {
+	h := NewHandler(testHandler(), MaxRequests(1))
+	if rec, _ := doBatch(t, h, `{"requests":[{"path":"/hello"},{"path":"/hello"}]}`); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %d, got %d", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+	if rec, _ := doBatch(t, h, `{`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/batch", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+	// 超过请求体大小限制
+	h = NewHandler(testHandler(), MaxBodySize(16))
+	if rec, _ := doBatch(t, h, `{"requests":[{"path":"/hello"}]}`); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %d, got %d", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+}
+
+func TestHandlerNested(t *testing.T) {
+	mux := http.NewServeMux()
+	h := NewHandler(mux)
+	mux.Handle("/batch", h)
+	mux.Handle("/batch/", h)
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	})
+	_, res := doBatch(t, h, `{"requests":[
+		{"method":"POST","path":"/batch","body":{"requests":[{"path":"/hello"}]}},
+		{"method":"POST","path":"/batch/","body":{"requests":[{"path":"/hello"}]}},
+		{"path":"/hello"}
+	]}`)
+	if res == nil || len(res.Responses) != 3 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for i, status := range []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusOK} {
+		if res.Responses[i].Status != status {
+			t.Errorf("[%d] expected status %d, got %d", i, status, res.Responses[i].Status)
+		}
+	}
+}
+
+func TestHandlerConcurrency(t *testing.T) {
+	var running, peak int32
+	slow := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+	h := NewHandler(slow, Concurrency(2))
+	_, res := doBatch(t, h, `{"requests":[{"path":"/"},{"path":"/"},{"path":"/"},{"path":"/"},{"path":"/"}]}`)
+	if res == nil || len(res.Responses) != 5 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if p := atomic.LoadInt32(&peak); p > 2 {
+		t.Errorf("expected concurrency <= 2, got %d", p)
+	}
+}
@@ -1,14 +1,17 @@
 package binding
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 
 	"github.com/cnsync/kratos/encoding/form"
 	"google.golang.org/protobuf/proto"
 )
 
-var reg = regexp.MustCompile(`{[\\.\w]+}`) // 定义一个正则表达式，用于匹配路径模板中的占位符
+// 定义一个正则表达式，用于匹配路径模板中的占位符，支持 {name} 以及生成代码中的 {name:shelves/.*} 形式
+var reg = regexp.MustCompile(`{([\\.\w]+)(:[^{}]*)?}`)
 
 // EncodeURL 将 proto 消息编码为 URL 路径。
 // pathTemplate 是路径模板，msg 是要编码的 proto 消息，needQuery 表示是否需要将剩余的查询参数附加到 URL 中。
@@ -26,12 +29,13 @@ func EncodeURL(pathTemplate string, msg interface{}, needQuery bool) string {
 
 	// 使用正则表达式替换路径模板中的占位符，将占位符替换为对应的查询参数值
 	path := reg.ReplaceAllStringFunc(pathTemplate, func(in string) string {
-		// 从占位符中提取出键名
-		key := in[1 : len(in)-1]
+		// 从占位符中提取出键名，带有匹配模式的占位符允许值中包含 "/"
+		sub := reg.FindStringSubmatch(in)
+		key := sub[1]
 		// 将键添加到路径参数映射中
 		pathParams[key] = struct{}{}
 		// 从查询参数中获取对应键的值，并替换占位符
-		return queryParams.Get(key)
+		return escapePath(queryParams.Get(key), sub[2] != "")
 	})
 
 	// 如果不需要查询参数
@@ -63,3 +67,18 @@ func EncodeURL(pathTemplate string, msg interface{}, needQuery bool) string {
 	// 返回最终的路径
 	return path
 }
+
+// escapePath 转义路径参数中会破坏 URL 结构的字符，如空格、"?"、"#" 与 "%"。
+// keepSlash 为 false 时同时转义 "/"，避免参数值被拆分为多个路径段。
+func escapePath(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '?' || c == '#' || c == '%' || (c == '/' && !keepSlash) {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -117,6 +117,18 @@ func TestEncodeURL(t *testing.T) {
 			needQuery:    false,
 			want:         "http://helloworld.Greeter/helloworld/{}/[]/[kratos]",
 		},
+		{
+			pathTemplate: "/v1/{name:shelves/.*}/books",
+			request:      &binding.HelloRequest{Name: "shelves/1", Sub: &binding.Sub{Name: "kratos"}},
+			needQuery:    true,
+			want:         "/v1/shelves/1/books?sub.naming=kratos",
+		},
+		{
+			pathTemplate: "/v1/{name}/sub/{sub.naming}",
+			request:      &binding.HelloRequest{Name: "a b?c", Sub: &binding.Sub{Name: "x/y#z"}},
+			needQuery:    false,
+			want:         "/v1/a%20b%3Fc/sub/x%2Fy%23z",
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,424 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cnsync/kratos/internal/httputil"
+)
+
+// CacheStatusHeader 是响应缓存设置的响应头，表示响应的来源。
+const CacheStatusHeader = "X-Cache"
+
+// 响应缓存的状态。
+const (
+	// CacheHit 表示响应来自新鲜的缓存。
+	CacheHit = "HIT"
+	// CacheMiss 表示响应来自服务端。
+	CacheMiss = "MISS"
+	// CacheStale 表示在 stale-while-revalidate 窗口内返回了过期的缓存，并在后台重新验证。
+	CacheStale = "STALE"
+	// CacheRevalidated 表示过期的缓存经服务端验证（304）后继续使用。
+	CacheRevalidated = "REVALIDATED"
+)
+
+// revalidateTimeout 是后台重新验证请求的超时时间。
+const revalidateTimeout = 30 * time.Second
+
+// CacheStore 是响应缓存的存储。
+// 方法对应 Redis 的 GET、SET PX 与 DEL 命令，可以适配 Redis 客户端在实例之间共享缓存，
+// 因此缓存遵循 RFC 9111 共享缓存的语义，不保存 private 的响应与携带凭证的请求的响应。
+type CacheStore interface {
+	// Get 返回键的值，键不存在或已过期时返回 false。
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set 设置键的值。
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除键。
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheOption 是响应缓存的选项。
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	key         func(*http.Request) string
+	maxBodySize int64
+	retention   time.Duration
+}
+
+// CacheKey 设置缓存键的生成函数，默认使用请求的完整 URL。
+func CacheKey(f func(*http.Request) string) CacheOption {
+	return func(o *cacheOptions) {
+		if f != nil {
+			o.key = f
+		}
+	}
+}
+
+// CacheMaxBodySize 设置可缓存的响应体的最大字节数，默认为 1MB，更大的响应直接透传。
+func CacheMaxBodySize(n int64) CacheOption {
+	return func(o *cacheOptions) {
+		if n > 0 {
+			o.maxBodySize = n
+		}
+	}
+}
+
+// CacheRetention 设置带有校验器（ETag、Last-Modified）的响应过期后继续保留的时间，
+// 保留期间可以通过条件请求重新验证，默认为 1 小时。
+func CacheRetention(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		if d >= 0 {
+			o.retention = d
+		}
+	}
+}
+
+// cacheRoundTripper 是按照 RFC 9111 实现的共享缓存。
+type cacheRoundTripper struct {
+	next     http.RoundTripper
+	store    CacheStore
+	opts     cacheOptions
+	inflight sync.Map // 正在后台重新验证的缓存键
+	now      func() time.Time
+}
+
+// NewCacheRoundTripper 返回缓存 GET 响应的 RoundTripper。
+// 缓存遵循 RFC 9111 共享缓存的语义：按 Cache-Control s-maxage、max-age 或 Expires 计算新鲜度，
+// 过期后使用 ETag 与 Last-Modified 发起条件请求重新验证，支持 stale-while-revalidate、
+// 请求的 no-store、no-cache 与 max-age 指令，按 Vary 区分变体，并在不安全的方法成功后使缓存失效。
+// 响应带有 private 时不保存；请求携带 Authorization 时，只有响应带有 public、s-maxage 或
+// must-revalidate 才保存，避免一个用户的响应返回给其他用户。
+func NewCacheRoundTripper(next http.RoundTripper, store CacheStore, opts ...CacheOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	o := cacheOptions{
+		key:         func(r *http.Request) string { return r.URL.String() },
+		maxBodySize: 1 << 20,
+		retention:   time.Hour,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &cacheRoundTripper{next: next, store: store, opts: o, now: time.Now}
+}
+
+// cacheEntry 是缓存的响应。
+type cacheEntry struct {
+	StatusCode   int               `json:"status"`
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	Vary         map[string]string `json:"vary,omitempty"`
+	RequestTime  time.Time         `json:"request_time"`
+	ResponseTime time.Time         `json:"response_time"`
+}
+
+// RoundTrip 实现 http.RoundTripper 接口。
+func (rt *cacheRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := rt.opts.key(req)
+	if req.Method != http.MethodGet {
+		res, err := rt.next.RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && res.StatusCode < http.StatusBadRequest {
+			_ = rt.store.Delete(req.Context(), key)
+		}
+		return res, err
+	}
+	cc := httputil.ParseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return rt.next.RoundTrip(req)
+	}
+	// 调用方自行发起的条件请求需要看到服务端的 304 响应
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return rt.next.RoundTrip(req)
+	}
+	entry := rt.load(req.Context(), key, req)
+	if entry == nil {
+		return rt.fetch(req, key, nil)
+	}
+	_, noCache := cc["no-cache"]
+	if !noCache {
+		age, lifetime := entry.age(rt.now()), entry.lifetime()
+		if v, ok := cc["max-age"]; ok {
+			lifetime = min(lifetime, httputil.DeltaSeconds(v))
+		}
+		if age < lifetime {
+			return entry.response(req, age, CacheHit), nil
+		}
+		if age < lifetime+entry.staleWhileRevalidate() {
+			res := entry.response(req, age, CacheStale)
+			rt.revalidate(req, key, entry)
+			return res, nil
+		}
+	}
+	return rt.fetch(req, key, entry)
+}
+
+// fetch 向服务端发起请求，有缓存时发起条件请求，并保存可缓存的响应。
+func (rt *cacheRoundTripper) fetch(req *http.Request, key string, entry *cacheEntry) (*http.Response, error) {
+	r := req
+	if entry != nil && entry.hasValidators() {
+		r = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+			r.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+	requestTime := rt.now()
+	res, err := rt.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := rt.now()
+	if res.StatusCode == http.StatusNotModified && r != req {
+		_ = httputil.DrainAndClose(res.Body)
+		entry.update(res.Header, requestTime, responseTime)
+		rt.save(req.Context(), key, entry)
+		return entry.response(req, entry.age(responseTime), CacheRevalidated), nil
+	}
+	return rt.storeResponse(req, key, res, requestTime, responseTime)
+}
+
+// storeResponse 保存可缓存的响应，响应体超过限制时直接透传。
+func (rt *cacheRoundTripper) storeResponse(req *http.Request, key string, res *http.Response, requestTime, responseTime time.Time) (*http.Response, error) {
+	if !storable(req, res) {
+		res.Header.Set(CacheStatusHeader, CacheMiss)
+		return res, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, rt.opts.maxBodySize+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > rt.opts.maxBodySize {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		res.Header.Set(CacheStatusHeader, CacheMiss)
+		return res, nil
+	}
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	entry := &cacheEntry{
+		StatusCode:   res.StatusCode,
+		Header:       res.Header.Clone(),
+		Body:         body,
+		Vary:         varyValues(req, res.Header),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	}
+	rt.save(req.Context(), key, entry)
+	res.Header.Set(CacheStatusHeader, CacheMiss)
+	return res, nil
+}
+
+// revalidate 在后台重新验证过期的缓存，同一个键同时只有一个重新验证的请求。
+func (rt *cacheRoundTripper) revalidate(req *http.Request, key string, entry *cacheEntry) {
+	if _, loaded := rt.inflight.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer rt.inflight.Delete(key)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), revalidateTimeout)
+		defer cancel()
+		res, err := rt.fetch(req.Clone(ctx), key, entry)
+		if err == nil {
+			_ = httputil.DrainAndClose(res.Body)
+		}
+	}()
+}
+
+// load 读取缓存，变体不匹配时视为未命中。
+func (rt *cacheRoundTripper) load(ctx context.Context, key string, req *http.Request) *cacheEntry {
+	data, ok, err := rt.store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	for name, value := range entry.Vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return nil
+		}
+	}
+	return &entry
+}
+
+// save 保存缓存，不可能再被使用的响应不保存。
+func (rt *cacheRoundTripper) save(ctx context.Context, key string, entry *cacheEntry) {
+	ttl := entry.lifetime() + entry.staleWhileRevalidate()
+	if entry.hasValidators() {
+		ttl += rt.opts.retention
+	}
+	if ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = rt.store.Set(ctx, key, data, ttl)
+}
+
+// lifetime 返回响应的新鲜期，共享缓存优先使用 s-maxage。
+func (e *cacheEntry) lifetime() time.Duration {
+	cc := httputil.ParseCacheControl(e.Header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if v, ok := cc["s-maxage"]; ok {
+		return httputil.DeltaSeconds(v)
+	}
+	if v, ok := cc["max-age"]; ok {
+		return httputil.DeltaSeconds(v)
+	}
+	if v := e.Header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		return max(expires.Sub(e.date()), 0)
+	}
+	return 0
+}
+
+// staleWhileRevalidate 返回 stale-while-revalidate 允许使用过期响应的时长。
+func (e *cacheEntry) staleWhileRevalidate() time.Duration {
+	cc := httputil.ParseCacheControl(e.Header.Get("Cache-Control"))
+	for _, directive := range []string{"must-revalidate", "proxy-revalidate", "s-maxage"} {
+		if _, ok := cc[directive]; ok {
+			return 0
+		}
+	}
+	return httputil.DeltaSeconds(cc["stale-while-revalidate"])
+}
+
+// age 按照 RFC 9111 4.2.3 计算响应的当前年龄。
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparent := max(e.ResponseTime.Sub(e.date()), 0)
+	corrected := httputil.DeltaSeconds(e.Header.Get("Age")) + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparent, corrected) + now.Sub(e.ResponseTime)
+}
+
+// date 返回响应的 Date 头，缺失时使用收到响应的时间。
+func (e *cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+// hasValidators 判断响应是否带有可用于条件请求的校验器。
+func (e *cacheEntry) hasValidators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+// update 使用 304 响应的头更新缓存，RFC 9111 4.3.4。
+func (e *cacheEntry) update(header http.Header, requestTime, responseTime time.Time) {
+	for name, values := range header {
+		switch name {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding", "Content-Range":
+			continue
+		}
+		e.Header[name] = values
+	}
+	e.RequestTime, e.ResponseTime = requestTime, responseTime
+}
+
+// response 使用缓存构造响应。
+func (e *cacheEntry) response(req *http.Request, age time.Duration, status string) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(age/time.Second)))
+	header.Set(CacheStatusHeader, status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// storable 判断响应是否可以被共享缓存保存，只保存默认可缓存的状态码并且带有显式新鲜期或校验器的响应，
+// 不保存 private 的响应，携带 Authorization 的请求的响应需要显式允许共享缓存保存，RFC 9111 3.5。
+func storable(req *http.Request, res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusNotFound,
+		http.StatusMethodNotAllowed, http.StatusGone, http.StatusRequestURITooLong,
+		http.StatusNotImplemented:
+	default:
+		return false
+	}
+	cc := httputil.ParseCacheControl(res.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["private"]; ok {
+		return false
+	}
+	if req.Header.Get("Authorization") != "" && !sharedAuthorized(cc) {
+		return false
+	}
+	if strings.TrimSpace(res.Header.Get("Vary")) == "*" {
+		return false
+	}
+	_, maxAge := cc["max-age"]
+	_, sMaxAge := cc["s-maxage"]
+	return maxAge || sMaxAge || res.Header.Get("Expires") != "" ||
+		res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+// sharedAuthorized 判断响应是否允许共享缓存保存携带 Authorization 的请求的响应。
+func sharedAuthorized(cc map[string]string) bool {
+	for _, directive := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := cc[directive]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// varyValues 记录 Vary 列出的请求头的值。
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	var values map[string]string
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			name = http.CanonicalHeaderKey(name)
+			values[name] = strings.Join(req.Header.Values(name), ",")
+		}
+	}
+	return values
+}
+
+// isSafeMethod 判断请求方法是否为安全的方法。
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
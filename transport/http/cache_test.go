@@ -0,0 +1,264 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mapStore 是测试使用的缓存存储
+type mapStore struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{items: make(map[string][]byte)}
+}
+
+func (s *mapStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.items[key]
+	return v, ok, nil
+}
+
+func (s *mapStore) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = value
+	return nil
+}
+
+func (s *mapStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+// cacheGet 发起 GET 请求并返回缓存状态与响应体
+func cacheGet(t *testing.T, rt http.RoundTripper, url string, header http.Header) (string, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return res.Header.Get(CacheStatusHeader), string(body)
+}
+
+// TestCacheRoundTripper_Fresh 测试新鲜的响应直接从缓存返回
+func TestCacheRoundTripper_Fresh(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Method == http.MethodPost {
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	rt := NewCacheRoundTripper(nil, newMapStore())
+	if status, body := cacheGet(t, rt, srv.URL, nil); status != CacheMiss || body != "hello" {
+		t.Fatalf("unexpected response: %s %s", status, body)
+	}
+	if status, body := cacheGet(t, rt, srv.URL, nil); status != CacheHit || body != "hello" {
+		t.Fatalf("unexpected response: %s %s", status, body)
+	}
+	// 请求的 no-cache 指令跳过新鲜的缓存
+	if status, _ := cacheGet(t, rt, srv.URL, http.Header{"Cache-Control": {"no-cache"}}); status != CacheMiss {
+		t.Fatalf("expected MISS, got %s", status)
+	}
+	// 不安全的方法使缓存失效
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("x"))
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if status, _ := cacheGet(t, rt, srv.URL, nil); status != CacheMiss {
+		t.Fatalf("expected MISS after invalidation, got %s", status)
+	}
+	if n := atomic.LoadInt32(&hits); n != 4 {
+		t.Errorf("expected 4 requests, got %d", n)
+	}
+}
+
+// TestCacheRoundTripper_Revalidate 测试过期的响应通过条件请求重新验证
+func TestCacheRoundTripper_Revalidate(t *testing.T) {
+	var conditional int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&conditional, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	rt := NewCacheRoundTripper(nil, newMapStore())
+	cacheGet(t, rt, srv.URL, nil)
+	if status, body := cacheGet(t, rt, srv.URL, nil); status != CacheRevalidated || body != "hello" {
+		t.Fatalf("unexpected response: %s %s", status, body)
+	}
+	if atomic.LoadInt32(&conditional) != 1 {
+		t.Error("expected a conditional request")
+	}
+}
+
+// TestCacheRoundTripper_StaleWhileRevalidate 测试在窗口内返回过期的响应并在后台重新验证
+func TestCacheRoundTripper_StaleWhileRevalidate(t *testing.T) {
+	var version int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		if atomic.AddInt32(&version, 1) == 1 {
+			_, _ = w.Write([]byte("v1"))
+			return
+		}
+		_, _ = w.Write([]byte("v2"))
+	}))
+	defer srv.Close()
+
+	var offset atomic.Int64
+	rt := NewCacheRoundTripper(nil, newMapStore()).(*cacheRoundTripper)
+	rt.now = func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }
+	cacheGet(t, rt, srv.URL, nil)
+	offset.Store(int64(30 * time.Second))
+	if status, body := cacheGet(t, rt, srv.URL, nil); status != CacheStale || body != "v1" {
+		t.Fatalf("unexpected response: %s %s", status, body)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, body := cacheGet(t, rt, srv.URL, nil); body == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the cache to be revalidated in background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 超出窗口后同步请求
+	offset.Store(int64(2 * time.Minute))
+	if status, _ := cacheGet(t, rt, srv.URL, nil); status != CacheMiss {
+		t.Fatalf("expected MISS, got %s", status)
+	}
+}
+
+// TestCacheRoundTripper_NotStorable 测试不可缓存的响应与 Vary 变体
+func TestCacheRoundTripper_NotStorable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer srv.Close()
+
+	rt := NewCacheRoundTripper(nil, newMapStore())
+	for _, path := range []string{"/no-store", "/none"} {
+		cacheGet(t, rt, srv.URL+path, nil)
+		if status, _ := cacheGet(t, rt, srv.URL+path, nil); status != CacheMiss {
+			t.Errorf("%s: expected MISS, got %s", path, status)
+		}
+	}
+	en := http.Header{"Accept-Language": {"en"}}
+	cacheGet(t, rt, srv.URL+"/vary", en)
+	if status, _ := cacheGet(t, rt, srv.URL+"/vary", en); status != CacheHit {
+		t.Errorf("expected HIT, got %s", status)
+	}
+	if status, body := cacheGet(t, rt, srv.URL+"/vary", http.Header{"Accept-Language": {"zh"}}); status != CacheMiss || body != "zh" {
+		t.Errorf("unexpected response: %s %s", status, body)
+	}
+}
+
+// TestCacheRoundTripper_Shared 测试共享缓存不保存 private 的响应与携带凭证的请求的响应
+func TestCacheRoundTripper_Shared(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/s-maxage":
+			w.Header().Set("Cache-Control", "max-age=0, s-maxage=60")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	rt := NewCacheRoundTripper(nil, newMapStore())
+	cacheGet(t, rt, srv.URL+"/private", nil)
+	if status, _ := cacheGet(t, rt, srv.URL+"/private", nil); status != CacheMiss {
+		t.Errorf("/private: expected MISS, got %s", status)
+	}
+	alice := http.Header{"Authorization": {"Bearer alice"}}
+	bob := http.Header{"Authorization": {"Bearer bob"}}
+	cacheGet(t, rt, srv.URL+"/authorized", alice)
+	if status, body := cacheGet(t, rt, srv.URL+"/authorized", bob); status != CacheMiss || body != "Bearer bob" {
+		t.Errorf("/authorized: unexpected response: %s %s", status, body)
+	}
+	for _, path := range []string{"/public", "/s-maxage"} {
+		cacheGet(t, rt, srv.URL+path, alice)
+		if status, _ := cacheGet(t, rt, srv.URL+path, bob); status != CacheHit {
+			t.Errorf("%s: expected HIT, got %s", path, status)
+		}
+	}
+}
+
+// TestWithResponseCache 测试客户端的响应缓存
+func TestWithResponseCache(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(`{"name":"kratos"}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(context.Background(),
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithResponseCache(newMapStore()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	for i := 0; i < 2; i++ {
+		var reply struct {
+			Name string `json:"name"`
+		}
+		if err = client.Invoke(context.Background(), http.MethodGet, "/config", nil, &reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Name != "kratos" {
+			t.Fatalf("unexpected reply: %+v", reply)
+		}
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+}
@@ -6,13 +6,19 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/cnsync/kratos/encoding"
+	"github.com/cnsync/kratos/encoding/form"
 	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/endpoint"
 	"github.com/cnsync/kratos/internal/host"
 	"github.com/cnsync/kratos/internal/httputil"
+	replyutil "github.com/cnsync/kratos/internal/reply"
 	"github.com/cnsync/kratos/middleware"
 	"github.com/cnsync/kratos/registry"
 	"github.com/cnsync/kratos/selector"
@@ -41,20 +47,24 @@ type ClientOption func(*clientOptions)
 
 // clientOptions 用于存储 HTTP 客户端的配置选项。
 type clientOptions struct {
-	ctx          context.Context         // 上下文对象，用于控制超时等
-	tlsConf      *tls.Config             // TLS 配置，用于启用 HTTPS
-	timeout      time.Duration           // 请求超时时间
-	endpoint     string                  // 目标服务的地址
-	userAgent    string                  // 用户代理字符串
-	encoder      EncodeRequestFunc       // 请求编码器
-	decoder      DecodeResponseFunc      // 响应解码器
-	errorDecoder DecodeErrorFunc         // 错误解码器
-	transport    http.RoundTripper       // HTTP 请求的传输器
-	nodeFilters  []selector.NodeFilter   // 节点选择器过滤器
-	discovery    registry.Discovery      // 服务发现接口
-	middleware   []middleware.Middleware // 中间件列表
-	block        bool                    // 是否阻塞
-	subsetSize   int                     // 客户端发现的子集大小
+	ctx          context.Context               // 上下文对象，用于控制超时等
+	tlsConf      *tls.Config                   // TLS 配置，用于启用 HTTPS
+	certProvider transport.CertificateProvider // 动态证书提供者
+	timeout      time.Duration                 // 请求超时时间
+	endpoint     string                        // 目标服务的地址
+	userAgent    string                        // 用户代理字符串
+	encoder      EncodeRequestFunc             // 请求编码器
+	decoder      DecodeResponseFunc            // 响应解码器
+	errorDecoder DecodeErrorFunc               // 错误解码器
+	transport    http.RoundTripper             // HTTP 请求的传输器
+	nodeFilters  []selector.NodeFilter         // 节点选择器过滤器
+	discovery    registry.Discovery            // 服务发现接口
+	middleware   []middleware.Middleware       // 中间件列表
+	block        bool                          // 是否阻塞
+	subsetSize   int                           // 客户端发现的子集大小
+	dnsRefresh   time.Duration                 // dns:/// 目标地址重新解析的间隔
+	cacheStore   CacheStore                    // 响应缓存的存储
+	cacheOpts    []CacheOption                 // 响应缓存的选项
 }
 
 // WithSubset 设置客户端发现的子集大小。零值表示禁用子集过滤。
@@ -71,6 +81,15 @@ func WithTransport(trans http.RoundTripper) ClientOption {
 	}
 }
 
+// WithResponseCache 启用客户端的响应缓存，按照 HTTP 缓存语义缓存 GET 响应，参见 NewCacheRoundTripper。
+// 默认的缓存键使用目标地址而不是选中的节点地址，同一服务的各个节点共享缓存。
+func WithResponseCache(store CacheStore, opts ...CacheOption) ClientOption {
+	return func(o *clientOptions) {
+		o.cacheStore = store
+		o.cacheOpts = opts
+	}
+}
+
 // WithTimeout 设置客户端请求超时时间。
 func WithTimeout(d time.Duration) ClientOption {
 	return func(o *clientOptions) {
@@ -127,6 +146,13 @@ func WithDiscovery(d registry.Discovery) ClientOption {
 	}
 }
 
+// WithDNSRefresh 设置 dns:/// 目标地址重新解析 DNS 记录的间隔，默认为 30s。
+func WithDNSRefresh(interval time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.dnsRefresh = interval
+	}
+}
+
 // WithNodeFilter 设置节点选择过滤器。
 func WithNodeFilter(filters ...selector.NodeFilter) ClientOption {
 	return func(o *clientOptions) {
@@ -148,6 +174,13 @@ func WithTLSConfig(c *tls.Config) ClientOption {
 	}
 }
 
+// WithTLSCertificate 设置动态证书提供者，每次握手时从提供者获取客户端证书，用于证书轮换的 mTLS 场景。
+func WithTLSCertificate(p transport.CertificateProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.certProvider = p
+	}
+}
+
 // Client 是 HTTP 客户端的结构体，封装了 HTTP 请求的配置和操作。
 type Client struct {
 	opts     clientOptions     // 客户端配置选项
@@ -168,11 +201,15 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 		errorDecoder: DefaultErrorDecoder,     // 默认错误解码器
 		transport:    http.DefaultTransport,   // 默认 HTTP 传输器
 		subsetSize:   25,                      // 默认子集大小为 25
+		dnsRefresh:   30 * time.Second,        // 默认每 30 秒重新解析 DNS 记录
 	}
 	// 处理传入的客户端配置选项
 	for _, o := range opts {
 		o(&options)
 	}
+	if options.certProvider != nil {
+		options.tlsConf = transport.ClientTLSConfig(options.tlsConf, options.certProvider)
+	}
 	// 如果配置了 TLS 配置，则更新传输器的 TLS 设置
 	if options.tlsConf != nil {
 		if tr, ok := options.transport.(*http.Transport); ok {
@@ -185,11 +222,22 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	// unix:///path 目标地址通过 Unix 域套接字连接
+	if target.Scheme == endpoint.UnixScheme {
+		if target, err = unixTarget(&options, insecure); err != nil {
+			return nil, err
+		}
+	}
 	// 使用全局选择器构建一个服务选择器
 	selector := selector.GlobalSelector().Build()
 	var r *resolver
-	// 如果配置了服务发现，则创建解析器
-	if options.discovery != nil {
+	// dns:/// 目标地址通过定期解析 DNS 记录发现服务实例
+	if target.Scheme == "dns" {
+		if r, err = newResolver(ctx, newDNSDiscovery(options.dnsRefresh, insecure), target, selector, options.block, insecure, options.subsetSize); err != nil {
+			return nil, fmt.Errorf("[http client] new dns resolver failed!err: %v", options.endpoint)
+		}
+	} else if options.discovery != nil {
+		// 如果配置了服务发现，则创建解析器
 		if target.Scheme == "discovery" {
 			if r, err = newResolver(ctx, options.discovery, target, selector, options.block, insecure, options.subsetSize); err != nil {
 				return nil, fmt.Errorf("[http client] new resolver failed!err: %v", options.endpoint)
@@ -198,6 +246,12 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 			return nil, fmt.Errorf("[http client] invalid endpoint format: %v", options.endpoint)
 		}
 	}
+	if options.cacheStore != nil {
+		key := CacheKey(func(r *http.Request) string {
+			return target.Scheme + "://" + target.Authority + r.URL.RequestURI()
+		})
+		options.transport = NewCacheRoundTripper(options.transport, options.cacheStore, append([]CacheOption{key}, options.cacheOpts...)...)
+	}
 	// 返回配置好的客户端实例
 	return &Client{
 		opts:     options,
@@ -217,6 +271,7 @@ func (client *Client) Invoke(ctx context.Context, method, path string, args inte
 	var (
 		contentType string
 		body        io.Reader
+		getBody     func() (io.ReadCloser, error)
 	)
 	c := defaultCallInfo(path)
 	// 处理传入的调用选项
@@ -226,7 +281,14 @@ func (client *Client) Invoke(ctx context.Context, method, path string, args inte
 		}
 	}
 	// 如果有请求参数，则进行编码
-	if args != nil {
+	if boundary, ok := isMultipart(c.contentType); ok && args != nil {
+		// 多部分表单不经过请求编码器，请求体在发送时才以流的方式写入，
+		// 避免中间件未发送请求时写入请求体的协程与打开的文件泄漏
+		var err error
+		if contentType, getBody, err = multipartBody(boundary, args); err != nil {
+			return err
+		}
+	} else if args != nil {
 		data, err := client.opts.encoder(ctx, c.contentType, args)
 		if err != nil {
 			return err
@@ -241,6 +303,9 @@ func (client *Client) Invoke(ctx context.Context, method, path string, args inte
 	if err != nil {
 		return err
 	}
+	if getBody != nil {
+		req.GetBody = getBody
+	}
 	// 设置请求头
 	if c.headerCarrier != nil {
 		req.Header = *c.headerCarrier
@@ -248,7 +313,7 @@ func (client *Client) Invoke(ctx context.Context, method, path string, args inte
 
 	// 设置请求的 Content-Type
 	if contentType != "" {
-		req.Header.Set("Content-Type", c.contentType)
+		req.Header.Set("Content-Type", contentType)
 	}
 	// 设置客户端的用户代理
 	if client.opts.userAgent != "" {
@@ -268,8 +333,24 @@ func (client *Client) Invoke(ctx context.Context, method, path string, args inte
 
 // invoke 实际执行 HTTP 请求并处理响应。
 func (client *Client) invoke(ctx context.Context, req *http.Request, args interface{}, reply interface{}, c callInfo, opts ...CallOption) error {
+	var (
+		mu        sync.Mutex
+		committed bool
+		attempts  int
+	)
 	// 定义处理请求的函数
 	h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if transport.ConcurrentAttempts(ctx) {
+			return client.attempt(ctx, req, reply, c, &mu, &committed, opts...)
+		}
+		// 延迟生成的请求体在第一次尝试时获取，重试时请求体已经被上一次尝试读取，需要重新获取
+		if attempts++; req.GetBody != nil && (attempts > 1 || req.Body == nil) {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
 		res, err := client.do(req.WithContext(ctx)) // 发送请求
 		if res != nil {
 			cs := csAttempt{res: res}
@@ -281,7 +362,7 @@ func (client *Client) invoke(ctx context.Context, req *http.Request, args interf
 		if err != nil {
 			return nil, err
 		}
-		defer res.Body.Close()
+		defer httputil.DrainAndClose(res.Body)
 		// 解码响应数据
 		if err := client.opts.decoder(ctx, res, reply); err != nil {
 			return nil, err
@@ -300,6 +381,51 @@ func (client *Client) invoke(ctx context.Context, req *http.Request, args interf
 	return err
 }
 
+// attempt 执行并发的一次尝试：每次尝试使用独立的请求、传输信息与响应对象，
+// 只有第一个成功的尝试会写入响应对象、响应头并执行调用后的操作。
+func (client *Client) attempt(ctx context.Context, req *http.Request, reply interface{}, c callInfo, mu *sync.Mutex, committed *bool, opts ...CallOption) (interface{}, error) {
+	tr, _ := transport.FromClientContext(ctx)
+	ht, _ := tr.(*Transport)
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	var at Transport
+	if ht != nil {
+		at = *ht
+	}
+	at.reqHeader = headerCarrier(r.Header)
+	at.request = r
+	r = r.WithContext(transport.NewClientContext(ctx, &at))
+	res, err := client.do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer httputil.DrainAndClose(res.Body)
+	v := replyutil.New(reply)
+	if err := client.opts.decoder(ctx, res, v); err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !*committed {
+		*committed = true
+		replyutil.Copy(reply, v)
+		if ht != nil {
+			ht.replyHeader = at.replyHeader
+		}
+		cs := csAttempt{res: res}
+		for _, o := range opts {
+			o.after(&c, &cs)
+		}
+	}
+	return reply, nil
+}
+
 // Do 发送 HTTP 请求并解码响应数据。
 func (client *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, error) {
 	c := defaultCallInfo(req.URL.Path)
@@ -369,8 +495,12 @@ func (client *Client) Close() error {
 }
 
 // DefaultRequestEncoder 是默认的请求编码器，将请求数据编码为字节数组。
-func DefaultRequestEncoder(_ context.Context, contentType string, in interface{}) ([]byte, error) {
+// 表单内容类型使用 FormRequestEncoder 编码，其余内容类型按编解码器编码。
+func DefaultRequestEncoder(ctx context.Context, contentType string, in interface{}) ([]byte, error) {
 	name := httputil.ContentSubtype(contentType)
+	if name == form.Name {
+		return FormRequestEncoder(ctx, contentType, in)
+	}
 	body, err := encoding.GetCodec(name).Marshal(in)
 	if err != nil {
 		return nil, err
@@ -380,7 +510,7 @@ func DefaultRequestEncoder(_ context.Context, contentType string, in interface{}
 
 // DefaultResponseDecoder 是默认的响应解码器，将响应数据解码到指定结构。
 func DefaultResponseDecoder(_ context.Context, res *http.Response, v interface{}) error {
-	defer res.Body.Close()
+	defer httputil.DrainAndClose(res.Body)
 	data, err := io.ReadAll(res.Body)
 	if err != nil {
 		return err
@@ -388,21 +518,63 @@ func DefaultResponseDecoder(_ context.Context, res *http.Response, v interface{}
 	return CodecForResponse(res).Unmarshal(data, v)
 }
 
+// maxErrorBodyBytes 是解码错误响应时最多读取的字节数。
+const maxErrorBodyBytes = 1 << 20
+
+// AbortedConnReuse 返回客户端因响应体过大或读取失败而放弃连接复用的次数，可用于上报监控指标。
+func AbortedConnReuse() int64 {
+	return httputil.AbortedReuse()
+}
+
 // DefaultErrorDecoder 是默认的错误解码器，检查响应状态码并解码错误信息。
 func DefaultErrorDecoder(_ context.Context, res *http.Response) error {
 	if res.StatusCode >= 200 && res.StatusCode <= 299 {
 		return nil
 	}
-	defer res.Body.Close()
-	data, err := io.ReadAll(res.Body)
-	if err == nil {
+	defer httputil.DrainAndClose(res.Body)
+	// 限制错误响应体的读取大小，超出部分在关闭前丢弃
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyBytes))
+	if err == nil && isProblemResponse(res) {
+		var e *errors.Error
+		if e, err = decodeProblem(data, res.StatusCode); err == nil {
+			return decodeRetryAfter(res, e)
+		}
+	} else if err == nil {
 		e := new(errors.Error)
-		if err = CodecForResponse(res).Unmarshal(data, e); err == nil {
+		codec := CodecForResponse(res)
+		if err = codec.Unmarshal(data, e); err == nil {
 			e.Code = int32(res.StatusCode)
-			return e
+			// 解析 DetailedErrorEncoder 输出的详细信息
+			if codec.Name() == "json" {
+				e = decodeErrorDetails(data, e)
+			}
+			return decodeRetryAfter(res, e)
 		}
 	}
-	return errors.Newf(res.StatusCode, errors.UnknownReason, "").WithCause(err)
+	return decodeRetryAfter(res, errors.Newf(res.StatusCode, errors.UnknownReason, "").WithCause(err))
+}
+
+// unixTarget 为 unix:///path 目标地址配置通过 Unix 域套接字拨号的传输器，请求的主机名固定为 localhost。
+func unixTarget(options *clientOptions, insecure bool) (*Target, error) {
+	u, err := url.Parse(options.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Path
+	if path == "" {
+		path = u.Opaque
+	}
+	tr, ok := options.transport.(*http.Transport)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("[http client] invalid unix endpoint: %v", options.endpoint)
+	}
+	tr = tr.Clone()
+	tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+	options.transport = tr
+	return &Target{Scheme: endpoint.Scheme("http", !insecure), Authority: "localhost"}, nil
 }
 
 // CodecForResponse 获取适用于响应的编码器。
@@ -349,6 +349,22 @@ func TestDefaultErrorDecoder(t *testing.T) {
 	}
 }
 
+// TestDefaultErrorDecoderLargeBody 测试错误响应体过大时放弃连接复用
+func TestDefaultErrorDecoderLargeBody(t *testing.T) {
+	before := AbortedConnReuse()
+	resp := &http.Response{
+		Header:     make(http.Header),
+		StatusCode: 500,
+		Body:       io.NopCloser(bytes.NewReader(make([]byte, 4<<20))),
+	}
+	if err := DefaultErrorDecoder(context.TODO(), resp); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	if got := AbortedConnReuse() - before; got != 1 {
+		t.Errorf("expected 1 aborted reuse, got %d", got)
+	}
+}
+
 func TestCodecForResponse(t *testing.T) {
 	// 创建一个 HTTP 响应，设置响应头的 Content-Type 为 application/xml
 	resp := &http.Response{Header: make(http.Header)}
@@ -435,3 +451,38 @@ func TestNewClient(t *testing.T) {
 		t.Error("err should be equal to encoder error")
 	}
 }
+
+// TestClient_RetryBody 测试中间件重试时请求体被重新发送
+func TestClient_RetryBody(t *testing.T) {
+	var bodies []string
+	// 直接读取请求体的传输器，不会像 http.Transport 一样自行重置请求体
+	rt := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(bytes.NewReader(data))}
+		if len(bodies) == 1 {
+			res.StatusCode = http.StatusServiceUnavailable
+		}
+		return res, nil
+	})
+	retry := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, err := handler(ctx, req); err == nil {
+				return nil, errors.New("expected the first attempt to fail")
+			}
+			return handler(ctx, req)
+		}
+	}
+	client, err := NewClient(context.Background(), WithEndpoint("127.0.0.1:8000"), WithTransport(rt), WithMiddleware(retry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var reply map[string]string
+	if err = client.Invoke(context.Background(), http.MethodPost, "/echo", map[string]string{"name": "kratos"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || reply["name"] != "kratos" {
+		t.Errorf("expected the body to be sent twice, got %q %v", bodies, reply)
+	}
+}
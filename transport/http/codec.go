@@ -2,10 +2,13 @@ package http
 
 import (
 	"bytes"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -60,7 +63,9 @@ func DefaultRequestQuery(r *http.Request, v interface{}) error {
 func DefaultRequestDecoder(r *http.Request, v interface{}) error {
 	codec, ok := CodecForRequest(r, "Content-Type")
 	if !ok {
-		return errors.BadRequest("CODEC", fmt.Sprintf("unregister Content-Type: %s", r.Header.Get("Content-Type")))
+		// 丢弃未读取的请求体，使长连接可以继续复用
+		httputil.Drain(r.Body)
+		return unsupportedMediaType(r.Header.Get("Content-Type"))
 	}
 	data, err := io.ReadAll(r.Body)
 
@@ -68,6 +73,10 @@ func DefaultRequestDecoder(r *http.Request, v interface{}) error {
 	r.Body = io.NopCloser(bytes.NewBuffer(data))
 
 	if err != nil {
+		var mbe *http.MaxBytesError
+		if stderrors.As(err, &mbe) {
+			return errors.New(http.StatusRequestEntityTooLarge, "REQUEST_ENTITY_TOO_LARGE", fmt.Sprintf("request body exceeds %d bytes", mbe.Limit))
+		}
 		return errors.BadRequest("CODEC", err.Error())
 	}
 	if len(data) == 0 {
@@ -91,10 +100,15 @@ func DefaultResponseEncoder(w http.ResponseWriter, r *http.Request, v interface{
 	}
 	codec, _ := CodecForRequest(r, "Accept")
 	data, err := codec.Marshal(v)
+	if err != nil && codec.Name() != "json" {
+		// 协商的编码格式无法编码该对象（如 xml 不支持 map）时使用默认的编码格式
+		codec = encoding.GetCodec("json")
+		data, err = codec.Marshal(v)
+	}
 	if err != nil {
 		return err
 	}
-	w.Header().Set("Content-Type", httputil.ContentType(codec.Name()))
+	w.Header().Set("Content-Type", responseContentType(r, codec))
 	_, err = w.Write(data)
 	if err != nil {
 		return err
@@ -104,25 +118,102 @@ func DefaultResponseEncoder(w http.ResponseWriter, r *http.Request, v interface{
 
 // DefaultErrorEncoder 编码错误到 HTTP 响应。
 func DefaultErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
-	se := errors.FromError(err)
-	codec, _ := CodecForRequest(r, "Accept")
+	se := fromError(err)
+	codec, ok := CodecForRequest(r, "Accept")
+	// 客户端明确要求 application/problem+json 且没有更优先的编码格式时，输出 RFC 7807 格式
+	if (!ok || codec.Name() == "json") && acceptsProblem(r) {
+		writeProblem(w, r, se)
+		return
+	}
 	body, err := codec.Marshal(se)
+	if err != nil && codec.Name() != "json" {
+		codec = encoding.GetCodec("json")
+		body, err = codec.Marshal(se)
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", httputil.ContentType(codec.Name()))
+	setRetryAfter(w.Header(), se)
 	w.WriteHeader(int(se.Code))
 	_, _ = w.Write(body)
 }
 
+// fromError 将错误转换为 *errors.Error，错误原因注册了状态码映射时使用注册的 HTTP 状态码。
+func fromError(err error) *errors.Error {
+	se := errors.FromError(err)
+	if code := errors.HTTPStatus(se); code != int(se.Code) {
+		se = errors.Clone(se)
+		se.Code = int32(code)
+	}
+	return se
+}
+
 // CodecForRequest 通过 HTTP 请求获取编码解码器。
+// 头部可以包含以逗号分隔的多个媒体类型，按照 q 参数的优先级选择第一个已注册的编码格式，
+// 厂商类型（如 application/vnd.myapp.v2+json）使用后缀对应的编码格式。
+// 对于 Accept 头部，只有已注册的编码格式的优先级不低于通配符（*/* 与 application/*）时才会选中，
+// 并且只有厂商类型使用后缀对应的编码格式，浏览器默认的 Accept（如 text/html,application/xml;q=0.9,*/*）
+// 因此仍然使用默认的 json 编码格式。
 func CodecForRequest(r *http.Request, name string) (encoding.Codec, bool) {
-	for _, accept := range r.Header[name] {
-		codec := encoding.GetCodec(httputil.ContentSubtype(accept))
-		if codec != nil {
-			return codec, true
+	codec, _, ok := negotiate(r, name)
+	return codec, ok
+}
+
+// negotiate 按照 q 参数的优先级选择请求头中第一个已注册的编码格式，并返回选中的内容子类型。
+func negotiate(r *http.Request, name string) (encoding.Codec, string, bool) {
+	var (
+		best    encoding.Codec
+		subtype string
+		bestQ   float64
+		anyQ    float64
+	)
+	accept := strings.EqualFold(name, "Accept")
+	for _, header := range r.Header[name] {
+		for _, v := range strings.Split(header, ",") {
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+			q := mediaQuality(v)
+			st := httputil.ContentSubtype(v)
+			if st == "*" && q > anyQ {
+				anyQ = q
+			}
+			if accept && strings.Contains(st, "+") && !strings.HasPrefix(st, "vnd.") {
+				// 非厂商类型的后缀（如 xhtml+xml）并不表示客户端接受该编码格式
+				continue
+			}
+			codec := encoding.GetCodec(st)
+			if codec == nil {
+				continue
+			}
+			if q > bestQ {
+				best, subtype, bestQ = codec, st, q
+			}
+		}
+	}
+	// 客户端更偏好任意媒体类型时使用默认的编码格式
+	if best != nil && (!accept || bestQ >= anyQ) {
+		return best, subtype, true
+	}
+	return encoding.GetCodec("json"), "json", false
+}
+
+// responseContentType 返回响应的内容类型，客户端接受厂商类型时原样返回该类型，否则使用编码格式的名称。
+func responseContentType(r *http.Request, codec encoding.Codec) string {
+	if _, subtype, ok := negotiate(r, "Accept"); ok && strings.HasPrefix(subtype, "vnd.") && encoding.GetCodec(subtype) == codec {
+		return httputil.ContentType(subtype)
+	}
+	return httputil.ContentType(codec.Name())
+}
+
+// mediaQuality 返回媒体类型的 q 参数，未指定时为 1。
+func mediaQuality(mediaType string) float64 {
+	if v := httputil.MediaTypeParam(mediaType, "q"); v != "" {
+		if q, err := strconv.ParseFloat(v, 64); err == nil {
+			return q
 		}
 	}
-	return encoding.GetCodec("json"), false
+	return 1
 }
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"google.golang.org/grpc/codes"
+
 	"github.com/cnsync/kratos/errors"
 )
 
@@ -145,6 +148,22 @@ func TestDefaultErrorEncoder(t *testing.T) {
 	}
 }
 
+// TestDefaultErrorEncoderCodeMapping 测试默认错误编码器使用错误原因注册的 HTTP 状态码
+func TestDefaultErrorEncoderCodeMapping(t *testing.T) {
+	errors.RegisterCodeMapping("ACCOUNT_LOCKED", 423, codes.FailedPrecondition)
+	w := &mockResponseWriter{header: make(http.Header)}
+	r, _ := http.NewRequest(http.MethodPost, "", nil)
+	r.Header.Set("Content-Type", "application/json")
+
+	DefaultErrorEncoder(w, r, errors.BadRequest("ACCOUNT_LOCKED", "account locked"))
+	if w.StatusCode != 423 {
+		t.Errorf("expected %v, got %v", 423, w.StatusCode)
+	}
+	if !bytes.Contains(w.Data, []byte(`"code":423`)) {
+		t.Errorf("expected the body code to be 423, got %s", w.Data)
+	}
+}
+
 // TestDefaultResponseEncoderEncodeNil 测试默认响应编码器在编码空值时的行为
 func TestDefaultResponseEncoderEncodeNil(t *testing.T) {
 	var (
@@ -208,3 +227,60 @@ func TestCodecForRequest(t *testing.T) {
 		t.Errorf("expected %v, got %v", "json", c.Name())
 	}
 }
+
+// TestCodecForRequestMediaType 测试带有参数的媒体类型与厂商类型
+func TestCodecForRequestMediaType(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "", nil)
+	r.Header.Set("Content-Type", "application/vnd.myapp.v2+xml; charset=utf-8")
+	// 厂商类型使用后缀对应的编解码器
+	if c, ok := CodecForRequest(r, "Content-Type"); !ok || c.Name() != "xml" {
+		t.Errorf("expected xml codec, got %v %v", c.Name(), ok)
+	}
+
+	// 响应的内容类型原样返回客户端接受的厂商类型
+	w := httptest.NewRecorder()
+	r.Header.Set("Accept", "application/vnd.myapp.v2+json")
+	if err := DefaultResponseEncoder(w, r, map[string]string{"a": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/vnd.myapp.v2+json" {
+		t.Errorf("expected %v, got %v", "application/vnd.myapp.v2+json", got)
+	}
+}
+
+// TestCodecForRequestAccept 测试 Accept 头部的内容协商
+func TestCodecForRequestAccept(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		// 浏览器默认的 Accept 使用默认的 json 编码格式
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*", "json", false},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.9", "xml", true},
+		{"application/xhtml+xml", "json", false},
+		{"application/xml", "xml", true},
+		{"application/xml, */*;q=0.5", "xml", true},
+		{"application/json;q=0.5, application/xml", "xml", true},
+		{"application/vnd.myapp.v2+json", "json", true},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", test.accept)
+		c, ok := CodecForRequest(r, "Accept")
+		if c.Name() != test.want || ok != test.ok {
+			t.Errorf("%s: expected %v %v, got %v %v", test.accept, test.want, test.ok, c.Name(), ok)
+		}
+	}
+
+	// 协商的编码格式无法编码时使用默认的编码格式
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	if err := DefaultResponseEncoder(w, r, map[string]string{"a": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" || w.Body.String() != `{"a":"1"}` {
+		t.Errorf("expected json reply, got %v %s", got, w.Body.String())
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+// UnsupportedMediaTypeReason 是请求的媒体类型不受支持时返回的错误原因。
+const UnsupportedMediaTypeReason = "UNSUPPORTED_MEDIA_TYPE"
+
+// RequireContentType 配置服务器只接受指定媒体类型的请求体，例如 application/json，
+// 支持 application/* 形式的通配符。带有请求体的请求的 Content-Type 不在其中时，
+// 在解码之前返回 415 错误；没有请求体的请求不受限制。
+func RequireContentType(types ...string) ServerOption {
+	return func(s *Server) {
+		s.contentTypes = make(map[string]struct{}, len(types))
+		for _, t := range types {
+			if mt, _, err := mime.ParseMediaType(t); err == nil {
+				s.contentTypes[mt] = struct{}{}
+			}
+		}
+	}
+}
+
+// requireContentType 是检查请求媒体类型的过滤器。
+func (s *Server) requireContentType(w http.ResponseWriter, req *http.Request, next http.Handler) error {
+	if req.ContentLength != 0 && !s.acceptContentType(req.Header.Get("Content-Type")) {
+		return unsupportedMediaType(req.Header.Get("Content-Type"))
+	}
+	next.ServeHTTP(w, req)
+	return nil
+}
+
+// acceptContentType 判断请求的媒体类型是否在允许的范围内。
+func (s *Server) acceptContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if _, ok := s.contentTypes[mt]; ok {
+		return true
+	}
+	if i := strings.IndexByte(mt, '/'); i > 0 {
+		_, ok := s.contentTypes[mt[:i]+"/*"]
+		return ok
+	}
+	return false
+}
+
+// unsupportedMediaType 返回请求的媒体类型不受支持的 415 错误。
+func unsupportedMediaType(contentType string) error {
+	return errors.New(http.StatusUnsupportedMediaType, UnsupportedMediaTypeReason, fmt.Sprintf("unsupported Content-Type: %s", contentType))
+}
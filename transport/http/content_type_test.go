@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+func TestRequireContentType(t *testing.T) {
+	srv := NewServer(RequireContentType("application/json", "text/*"))
+	srv.router.HandleFunc("/users", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		method      string
+		body        string
+		contentType string
+		code        int
+	}{
+		{http.MethodPost, `{"name":"kratos"}`, "application/json; charset=utf-8", http.StatusOK},
+		{http.MethodPost, "kratos", "text/plain", http.StatusOK},
+		{http.MethodPost, "<name>kratos</name>", "application/xml", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "name=kratos", "", http.StatusUnsupportedMediaType},
+		// 没有请求体的请求不受限制
+		{http.MethodGet, "", "application/xml", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/users", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s %q: expect %d, got %d", tt.method, tt.contentType, tt.code, w.Code)
+		}
+	}
+}
+
+func TestDefaultRequestDecoderUnsupportedMediaType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name: kratos"))
+	req.Header.Set("Content-Type", "application/unknown")
+	err := DefaultRequestDecoder(req, &struct{}{})
+	if errors.Code(err) != http.StatusUnsupportedMediaType || errors.Reason(err) != UnsupportedMediaTypeReason {
+		t.Errorf("expect 415, got %v", err)
+	}
+}
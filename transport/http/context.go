@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 
 	"github.com/cnsync/kratos/middleware"
@@ -20,6 +21,10 @@ import (
 type Context interface {
 	context.Context                                   // 嵌入 Go 的 context.Context，允许获取请求的上下文信息
 	Vars() url.Values                                 // 获取URL中的路径参数
+	Param(string) string                              // 获取指定名称的路径参数
+	ParamInt64(string) (int64, error)                 // 获取指定名称的路径参数并转换为 int64
+	ParamUUID(string) (uuid.UUID, error)              // 获取指定名称的路径参数并转换为 UUID
+	ParamBool(string) (bool, error)                   // 获取指定名称的路径参数并转换为 bool
 	Query() url.Values                                // 获取URL中的查询参数
 	Form() url.Values                                 // 获取请求中的表单数据
 	Header() http.Header                              // 获取请求头
@@ -73,6 +78,8 @@ type wrapper struct {
 	req    *http.Request       // HTTP 请求
 	res    http.ResponseWriter // HTTP 响应
 	w      responseWriter      // 包装后的响应写入器
+	vars   url.Values          // 缓存的路径变量
+	parsed bool                // 路径变量是否已解析
 }
 
 // Header 返回请求头部信息
@@ -80,14 +87,21 @@ func (c *wrapper) Header() http.Header {
 	return c.req.Header
 }
 
-// Vars 返回URL中的路径变量（使用 gorilla/mux 路由时，路径参数将被解析并返回）
+// Vars 返回URL中的路径变量（使用 gorilla/mux 路由时，路径参数将被解析并返回）。
+// 路径变量在每个请求中只解析一次，多次调用返回同一个 url.Values。
 func (c *wrapper) Vars() url.Values {
+	if c.parsed {
+		return c.vars
+	}
 	raws := mux.Vars(c.req)
-	vars := make(url.Values, len(raws))
+	if c.vars == nil {
+		c.vars = make(url.Values, len(raws))
+	}
 	for k, v := range raws {
-		vars[k] = []string{v}
+		c.vars[k] = []string{v}
 	}
-	return vars
+	c.parsed = true
+	return c.vars
 }
 
 // Form 返回解析后的表单数据
@@ -117,10 +131,16 @@ func (c *wrapper) Response() http.ResponseWriter {
 // Middleware 处理请求的中间件
 func (c *wrapper) Middleware(h middleware.Handler) middleware.Handler {
 	// 如果请求中包含 server 上下文，则使用该上下文的操作匹配中间件，否则使用请求的路径匹配中间件
+	operation := c.req.URL.Path
 	if tr, ok := transport.FromServerContext(c.req.Context()); ok {
-		return middleware.Chain(c.router.srv.middleware.Match(tr.Operation())...)(h)
+		operation = tr.Operation()
 	}
-	return middleware.Chain(c.router.srv.middleware.Match(c.req.URL.Path)...)(h)
+	ms := c.router.srv.middleware.Match(operation)
+	// 路由组的中间件在服务中间件之后执行
+	if len(c.router.middleware) > 0 {
+		ms = append(ms[:len(ms):len(ms)], c.router.middleware...)
+	}
+	return middleware.Chain(ms...)(h)
 }
 
 // Bind 将请求体绑定到给定的结构体
@@ -206,6 +226,11 @@ func (c *wrapper) Reset(res http.ResponseWriter, req *http.Request) {
 	c.w.reset(res)
 	c.res = res
 	c.req = req
+	if c.parsed {
+		// 复用 Context 时不复用已返回给调用方的路径变量
+		c.vars = nil
+		c.parsed = false
+	}
 }
 
 // Deadline 返回请求的截止时间
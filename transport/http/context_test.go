@@ -249,3 +249,52 @@ func TestContextCtx(t *testing.T) {
 		t.Errorf("expected %v, got %v", nil, v)
 	}
 }
+
+// TestContextPool 测试复用 Context 时每个请求的路径变量互不影响
+func TestContextPool(t *testing.T) {
+	srv := NewServer(ContextPool(true))
+	var contexts []Context
+	srv.Route("/").GET("/users/{id}", func(ctx Context) error {
+		contexts = append(contexts, ctx)
+		vars := ctx.Vars()
+		if v := ctx.Vars(); reflect.ValueOf(v).Pointer() != reflect.ValueOf(vars).Pointer() {
+			t.Error("expected Vars to be cached within a request")
+		}
+		return ctx.String(http.StatusOK, vars.Get("id"))
+	})
+	for _, id := range []string{"1", "2", "3"} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+		if body := w.Body.String(); body != id {
+			t.Errorf("expected %q, got %q", id, body)
+		}
+	}
+	// 处理函数返回后 Context 被回收
+	if c := contexts[len(contexts)-1]; c.Request() != nil {
+		t.Errorf("expected released context, got %v", c.Request())
+	}
+}
+
+func benchmarkRoute(b *testing.B, opts ...ServerOption) {
+	srv := NewServer(opts...)
+	srv.Route("/").GET("/users/{id}/posts/{post}", func(ctx Context) error {
+		_ = ctx.Vars().Get("id")
+		_ = ctx.Vars().Get("post")
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/1/posts/2", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		srv.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRoute(b *testing.B) {
+	benchmarkRoute(b)
+}
+
+func BenchmarkRouteContextPool(b *testing.B) {
+	benchmarkRoute(b, ContextPool(true))
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cnsync/kratos/registry"
+)
+
+// dnsDiscovery 通过 DNS 记录发现服务实例，用于 dns:/// 目标地址，例如：
+//
+//	dns:///helloworld.default.svc.cluster.local:8000 解析 A/AAAA 记录，未指定端口时使用 80 或 443
+//	dns:///_http._tcp.helloworld.default.svc.cluster.local 解析 SRV 记录，使用记录中的端口与权重
+//
+// 使 Kubernetes 无头服务等场景无需注册中心即可在客户端进行负载均衡。
+type dnsDiscovery struct {
+	lookupIP  func(ctx context.Context, host string) ([]net.IPAddr, error)
+	lookupSRV func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	refresh   time.Duration
+	insecure  bool
+}
+
+// newDNSDiscovery 创建使用系统 DNS 解析器的服务发现。
+func newDNSDiscovery(refresh time.Duration, insecure bool) *dnsDiscovery {
+	return &dnsDiscovery{
+		lookupIP:  net.DefaultResolver.LookupIPAddr,
+		lookupSRV: net.DefaultResolver.LookupSRV,
+		refresh:   refresh,
+		insecure:  insecure,
+	}
+}
+
+var _ registry.Discovery = (*dnsDiscovery)(nil)
+
+// GetService 解析 DNS 记录并返回服务实例。
+func (d *dnsDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	scheme := "https"
+	if d.insecure {
+		scheme = "http"
+	}
+	var (
+		instances []*registry.ServiceInstance
+		weights   = make(map[string]uint16)
+	)
+	addrs, err := d.lookup(ctx, name, weights)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		ins := &registry.ServiceInstance{
+			ID:        addr,
+			Name:      name,
+			Endpoints: []string{scheme + "://" + addr},
+		}
+		if w := weights[addr]; w > 0 {
+			ins.Metadata = map[string]string{"weight": strconv.Itoa(int(w))}
+		}
+		instances = append(instances, ins)
+	}
+	return instances, nil
+}
+
+// lookup 解析服务名称对应的地址，SRV 记录的权重写入 weights。
+func (d *dnsDiscovery) lookup(ctx context.Context, name string, weights map[string]uint16) ([]string, error) {
+	host, port, err := net.SplitHostPort(name)
+	if err != nil {
+		if strings.HasPrefix(name, "_") {
+			_, srvs, err := d.lookupSRV(ctx, "", "", name)
+			if err != nil {
+				return nil, err
+			}
+			addrs := make([]string, 0, len(srvs))
+			for _, srv := range srvs {
+				addr := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+				weights[addr] = srv.Weight
+				addrs = append(addrs, addr)
+			}
+			return addrs, nil
+		}
+		host, port = name, "443"
+		if d.insecure {
+			port = "80"
+		}
+	}
+	ips, err := d.lookupIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+	}
+	return addrs, nil
+}
+
+// Watch 返回一个定期重新解析 DNS 记录的观察者。
+func (d *dnsDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &dnsWatcher{d: d, name: name, ctx: ctx, cancel: cancel}, nil
+}
+
+// dnsWatcher 定期重新解析 DNS 记录，仅在地址变化时返回服务实例。
+type dnsWatcher struct {
+	d       *dnsDiscovery
+	name    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	last    []string
+}
+
+// Next 返回变化后的服务实例，首次调用时立即解析。
+func (w *dnsWatcher) Next() ([]*registry.ServiceInstance, error) {
+	for {
+		if w.started {
+			timer := time.NewTimer(w.d.refresh)
+			select {
+			case <-w.ctx.Done():
+				timer.Stop()
+				return nil, w.ctx.Err()
+			case <-timer.C:
+			}
+		}
+		w.started = true
+		instances, err := w.d.GetService(w.ctx, w.name)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			return nil, err
+		}
+		ids := make([]string, 0, len(instances))
+		for _, ins := range instances {
+			ids = append(ids, ins.ID)
+		}
+		if len(instances) > 0 && !equalStrings(ids, w.last) {
+			w.last = ids
+			return instances, nil
+		}
+	}
+}
+
+// Stop 停止观察者。
+func (w *dnsWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+// equalStrings 判断两个字符串切片是否相同。
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
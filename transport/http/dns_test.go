@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDNSDiscovery 测试解析 A/AAAA 与 SRV 记录
+func TestDNSDiscovery(t *testing.T) {
+	d := &dnsDiscovery{
+		lookupIP: func(_ context.Context, host string) ([]net.IPAddr, error) {
+			if host != "svc.local" {
+				return nil, errors.New("not found")
+			}
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.2")}, {IP: net.ParseIP("::1")}}, nil
+		},
+		lookupSRV: func(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+			return name, []*net.SRV{{Target: "a.svc.local.", Port: 9000, Weight: 10}}, nil
+		},
+		insecure: true,
+	}
+	ins, err := d.GetService(context.Background(), "svc.local:8000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) != 2 || ins[0].Endpoints[0] != "http://10.0.0.2:8000" || ins[1].Endpoints[0] != "http://[::1]:8000" {
+		t.Errorf("unexpected instances: %v", ins)
+	}
+	// 未指定端口时使用默认端口
+	if ins, _ = d.GetService(context.Background(), "svc.local"); len(ins) != 2 || ins[0].ID != "10.0.0.2:80" {
+		t.Errorf("unexpected instances: %v", ins)
+	}
+	// SRV 记录
+	ins, err = d.GetService(context.Background(), "_http._tcp.svc.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) != 1 || ins[0].Endpoints[0] != "http://a.svc.local:9000" || ins[0].Metadata["weight"] != "10" {
+		t.Errorf("unexpected instances: %v", ins)
+	}
+}
+
+// TestDNSWatcher 测试定期重新解析，仅在地址变化时返回
+func TestDNSWatcher(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+		ips   = []string{"10.0.0.1"}
+	)
+	d := &dnsDiscovery{
+		lookupIP: func(context.Context, string) ([]net.IPAddr, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			res := make([]net.IPAddr, 0, len(ips))
+			for _, ip := range ips {
+				res = append(res, net.IPAddr{IP: net.ParseIP(ip)})
+			}
+			return res, nil
+		},
+		refresh: 10 * time.Millisecond,
+	}
+	w, err := d.Watch(context.Background(), "svc.local:8000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins, err := w.Next()
+	if err != nil || len(ins) != 1 {
+		t.Fatalf("unexpected result: %v %v", ins, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		ips = []string{"10.0.0.1", "10.0.0.2"}
+		mu.Unlock()
+	}()
+	ins, err = w.Next()
+	if err != nil || len(ins) != 2 {
+		t.Fatalf("unexpected result: %v %v", ins, err)
+	}
+	mu.Lock()
+	if calls < 3 {
+		t.Errorf("expected periodic lookups, got %d", calls)
+	}
+	mu.Unlock()
+
+	_ = w.Stop()
+	if _, err = w.Next(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,324 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// RouterEngine 是 HTTP 服务器的路由引擎接口。服务器默认使用 gorilla/mux，
+// 可以通过 Engine 选项替换为其他路由库的适配器，kratos 的路由、过滤器与中间件保持不变。
+type RouterEngine interface {
+	http.Handler
+	// Handle 注册匹配请求方法与路径模板的路由，method 为空时匹配所有方法。
+	// 路径模板使用 gorilla/mux 的语法，参数为 {name} 或 {name:regexp}，由适配器转换为路由库的语法。
+	Handle(method, pattern string, h http.Handler)
+	// HandlePrefix 注册匹配路径前缀的路由。
+	HandlePrefix(prefix string, h http.Handler)
+	// Walk 遍历已注册的路由。
+	Walk(fn WalkRouteFunc) error
+	// Vars 返回请求匹配的路径参数。
+	Vars(req *http.Request) map[string]string
+	// Template 返回请求匹配的路径模板，没有匹配的路由时返回空字符串。
+	Template(req *http.Request) string
+}
+
+// Engine 配置服务器的路由引擎，替换默认的 gorilla/mux。
+// PathPrefix、StrictSlash、NotFoundHandler、MethodNotAllowedHandler 选项以及路由名称、URL 与 HandleHeader
+// 依赖 gorilla/mux，使用其他路由引擎时不生效，应直接在路由库中进行相应的配置。
+// 除了标准库的 ServeMux，contrib/router 提供 chi 与 httprouter 的适配器。
+// 路由引擎实现 Err() error 方法时，注册路由失败的错误在获取端点或启动服务器时返回。
+func Engine(e RouterEngine) ServerOption {
+	return func(o *Server) {
+		o.engine = e
+	}
+}
+
+// handle 注册匹配路径模板与请求方法的路由，methods 为空时匹配所有方法。
+// 使用 gorilla/mux 时返回注册的路由，使用其他路由引擎时返回 nil。
+func (s *Server) handle(pattern string, h http.Handler, methods ...string) *mux.Route {
+	if s.engine == nil {
+		route := s.router.Handle(pattern, h)
+		if len(methods) > 0 {
+			route.Methods(methods...)
+		}
+		return route
+	}
+	h = s.engineHandler(h)
+	if len(methods) == 0 {
+		s.engine.Handle("", pattern, h)
+	}
+	for _, method := range methods {
+		s.engine.Handle(method, pattern, h)
+	}
+	s.engineErr()
+	return nil
+}
+
+// handlePrefix 注册匹配路径前缀与请求方法的路由，methods 为空时匹配所有方法。
+func (s *Server) handlePrefix(prefix string, h http.Handler, methods ...string) {
+	if s.engine == nil {
+		route := s.router.PathPrefix(prefix)
+		if len(methods) > 0 {
+			route.Methods(methods...)
+		}
+		route.Handler(h)
+		return
+	}
+	if len(methods) > 0 {
+		h = allowMethods(h, methods)
+	}
+	s.engine.HandlePrefix(prefix, s.engineHandler(h))
+	s.engineErr()
+}
+
+// engineErr 记录路由引擎注册路由失败的错误，只保留第一个错误。
+func (s *Server) engineErr() {
+	if e, ok := s.engine.(interface{ Err() error }); ok && s.err == nil {
+		s.err = e.Err()
+	}
+}
+
+// engineHandler 为路由引擎的处理器设置路径参数并应用服务器的过滤器。
+// 路径参数通过 mux.SetURLVars 设置，使请求参数的绑定与 Context.Vars 与路由引擎无关。
+func (s *Server) engineHandler(h http.Handler) http.Handler {
+	next := s.filter()(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if vars := s.engine.Vars(req); len(vars) > 0 {
+			req = mux.SetURLVars(req, vars)
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// handleVersion 使用路由引擎注册只匹配指定 API 版本的路由。
+// 路由引擎不支持自定义匹配条件，因此相同的路由只注册一次，由 versionRoutes 按请求的版本分发。
+func (s *Server) handleVersion(method, pattern string, versions []string, h http.Handler) {
+	key := method + " " + pattern
+	routes, ok := s.versionRoutes[key]
+	if !ok {
+		if s.versionRoutes == nil {
+			s.versionRoutes = make(map[string]*versionRoutes)
+		}
+		routes = &versionRoutes{vs: s.versionStrategy(), handlers: make(map[string]http.Handler)}
+		s.versionRoutes[key] = routes
+		s.handle(pattern, routes, method)
+	}
+	for _, v := range versions {
+		routes.handlers[v] = h
+	}
+}
+
+// versionRoutes 按请求的 API 版本分发相同路由的处理器。
+type versionRoutes struct {
+	vs       VersionStrategy
+	handlers map[string]http.Handler
+}
+
+// ServeHTTP 调用请求版本的处理器，没有对应的版本时返回 404。
+func (v *versionRoutes) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h, ok := v.handlers[v.vs.Extract(req)]; ok {
+		h.ServeHTTP(w, req)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// allowMethods 返回只处理指定请求方法的处理器，其他方法返回 405。
+func allowMethods(h http.Handler, methods []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, m := range methods {
+			if req.Method == m {
+				h.ServeHTTP(w, req)
+				return
+			}
+		}
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	})
+}
+
+var _ RouterEngine = (*ServeMux)(nil)
+
+// ServeMux 是基于标准库 http.ServeMux 的路由引擎，不依赖第三方路由库。
+// 位于模板末尾的 {name:.*} 转换为 {name...}；标准库不支持正则表达式，其他带有正则表达式的参数无法注册，
+// 避免参数的格式约束被静默地忽略。标准库的参数必须是完整的路径段，例如 /{name}.{ext} 也无法注册。
+// 注册失败的路由不会生效，错误通过 Err 返回。
+type ServeMux struct {
+	mux       *http.ServeMux
+	routes    []RouteInfo
+	templates map[string]string   // 标准库的模式到路径模板的映射
+	params    map[string][]string // 标准库的模式到参数名称的映射
+	err       error               // 第一个注册失败的错误
+}
+
+// NewServeMux 创建一个基于标准库 http.ServeMux 的路由引擎。
+func NewServeMux() *ServeMux {
+	return &ServeMux{
+		mux:       http.NewServeMux(),
+		templates: make(map[string]string),
+		params:    make(map[string][]string),
+	}
+}
+
+// ServeHTTP 分发请求。
+func (m *ServeMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	m.mux.ServeHTTP(w, req)
+}
+
+// Handle 注册匹配请求方法与路径模板的路由。
+func (m *ServeMux) Handle(method, pattern string, h http.Handler) {
+	std, names, err := serveMuxPattern(pattern)
+	if err != nil {
+		m.setErr(err)
+		return
+	}
+	if method != "" {
+		std = method + " " + std
+	}
+	if !m.register(std, h) {
+		return
+	}
+	if method != "" {
+		m.routes = append(m.routes, RouteInfo{Method: method, Path: pattern})
+	}
+	m.templates[std] = pattern
+	m.params[std] = names
+}
+
+// HandlePrefix 注册匹配路径前缀的路由。
+func (m *ServeMux) HandlePrefix(prefix string, h http.Handler) {
+	std := strings.TrimSuffix(prefix, "/") + "/"
+	if m.register(std, h) {
+		m.templates[std] = prefix
+	}
+}
+
+// Err 返回第一个注册失败的错误，例如模式不合法、带有正则表达式或与已注册的模式冲突。
+func (m *ServeMux) Err() error {
+	return m.err
+}
+
+// register 使用标准库注册模式，将标准库的 panic 转换为错误，返回是否注册成功。
+func (m *ServeMux) register(std string, h http.Handler) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.setErr(fmt.Errorf("http: %v", r))
+		}
+	}()
+	m.mux.Handle(std, h)
+	return true
+}
+
+func (m *ServeMux) setErr(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// Walk 遍历已注册的路由，只包含指定了请求方法的路由。
+func (m *ServeMux) Walk(fn WalkRouteFunc) error {
+	for _, r := range m.routes {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Vars 返回请求匹配的路径参数。
+func (m *ServeMux) Vars(req *http.Request) map[string]string {
+	names := m.params[req.Pattern]
+	if len(names) == 0 {
+		return nil
+	}
+	vars := make(map[string]string, len(names))
+	for _, name := range names {
+		vars[name] = req.PathValue(name)
+	}
+	return vars
+}
+
+// Template 返回请求匹配的路径模板。
+func (m *ServeMux) Template(req *http.Request) string {
+	return m.templates[req.Pattern]
+}
+
+// serveMuxPattern 将 gorilla/mux 的路径模板转换为标准库的模式，并返回参数名称。
+// 标准库的参数必须是完整的路径段，并且不支持正则表达式，无法转换时返回错误。
+func serveMuxPattern(pattern string) (string, []string, error) {
+	var (
+		b     strings.Builder
+		names []string
+		last  int
+	)
+	for _, p := range PathParams(pattern) {
+		param := pattern[p.Start:p.End]
+		if p.Start == 0 || pattern[p.Start-1] != '/' || (p.End < len(pattern) && pattern[p.End] != '/') {
+			return "", nil, fmt.Errorf("http: pattern %q: parameter %s must be a full path segment for ServeMux", pattern, param)
+		}
+		b.WriteString(pattern[last:p.Start])
+		switch {
+		case p.Regexp == "":
+			b.WriteString("{" + p.Name + "}")
+		case p.Wildcard(pattern):
+			b.WriteString("{" + p.Name + "...}")
+		default:
+			return "", nil, fmt.Errorf("http: pattern %q: parameter %s has a regexp that ServeMux cannot enforce", pattern, param)
+		}
+		names = append(names, p.Name)
+		last = p.End
+	}
+	b.WriteString(pattern[last:])
+	return b.String(), names, nil
+}
+
+// PathParam 是路径模板中的参数 {name} 或 {name:regexp}。
+type PathParam struct {
+	Name   string // 参数名称
+	Regexp string // 参数的正则表达式，没有时为空
+	Start  int    // 参数在路径模板中的起始位置
+	End    int    // 参数在路径模板中的结束位置，不包含
+}
+
+// Wildcard 判断参数是否是位于路径模板末尾的 {name:.*}，即匹配剩余的所有路径段。
+func (p PathParam) Wildcard(pattern string) bool {
+	return p.Regexp == ".*" && p.End == len(pattern) && p.Start > 0 && pattern[p.Start-1] == '/'
+}
+
+// PathParams 返回 gorilla/mux 路径模板中的参数，供 RouterEngine 的适配器将路径模板转换为路由库的语法。
+func PathParams(pattern string) []PathParam {
+	var params []PathParam
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			continue
+		}
+		end := closingBrace(pattern, i)
+		if end < 0 {
+			break
+		}
+		name, re, _ := strings.Cut(pattern[i+1:end], ":")
+		params = append(params, PathParam{Name: name, Regexp: re, Start: i, End: end + 1})
+		i = end
+	}
+	return params
+}
+
+// closingBrace 返回与 start 处的左花括号匹配的右花括号的位置，正则表达式中可能包含花括号。
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
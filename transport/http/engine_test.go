@@ -0,0 +1,163 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestServeMuxPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		std     string
+		names   []string
+	}{
+		{"/users", "/users", nil},
+		{"/users/{id}", "/users/{id}", []string{"id"}},
+		{"/users/{id}/books/{book}", "/users/{id}/books/{book}", []string{"id", "book"}},
+		{"/v1/{name:.*}", "/v1/{name...}", []string{"name"}},
+	}
+	for _, test := range tests {
+		std, names, err := serveMuxPattern(test.pattern)
+		if err != nil || std != test.std || !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%s: expect %s %v, got %s %v %v", test.pattern, test.std, test.names, std, names, err)
+		}
+	}
+	// 参数不是完整的路径段或带有标准库无法校验的正则表达式
+	for _, pattern := range []string{
+		"/files/{name}.{ext}", "/v1/users:{id}", "/v1/{id}:get",
+		"/users/{id:[0-9]+}", "/v1/{code:[a-z]{2}}", "/v1/{name:messages/.*}", "/v1/{name:.*}/books",
+	} {
+		if _, _, err := serveMuxPattern(pattern); err == nil {
+			t.Errorf("%s: expect error", pattern)
+		}
+	}
+}
+
+func TestEngine_InvalidPattern(t *testing.T) {
+	srv := NewServer(Engine(NewServeMux()))
+	srv.HandleFunc("/files/{name}.{ext}", func(http.ResponseWriter, *http.Request) {})
+	if _, err := srv.Endpoint(); err == nil {
+		t.Error("expect error for the invalid pattern")
+	}
+
+	// 正则表达式的约束无法校验时返回错误而不是忽略约束
+	srv = NewServer(Engine(NewServeMux()))
+	srv.HandleFunc("/users/{id:[0-9]+}", func(http.ResponseWriter, *http.Request) {})
+	if _, err := srv.Endpoint(); err == nil {
+		t.Error("expect error for the regexp constraint")
+	}
+
+	// 与已注册的模式冲突时返回错误而不是 panic
+	srv = NewServer(Engine(NewServeMux()))
+	srv.HandleFunc("/users/{id}", func(http.ResponseWriter, *http.Request) {})
+	srv.HandleFunc("/users/{name}", func(http.ResponseWriter, *http.Request) {})
+	if _, err := srv.Endpoint(); err == nil {
+		t.Error("expect error for the conflicting pattern")
+	}
+}
+
+func TestEngine(t *testing.T) {
+	srv := NewServer(Engine(NewServeMux()))
+	r := srv.Route("/v1")
+	r.GET("/users/{id}", func(ctx Context) error {
+		id, err := ctx.ParamInt64("id")
+		if err != nil {
+			return err
+		}
+		return ctx.String(200, operationFromContext(ctx)+" "+ctx.Vars().Get("id")+" "+strconv.FormatInt(id, 10))
+	})
+	r.POST("/messages/{name:.*}", func(ctx Context) error {
+		return ctx.String(200, ctx.Vars().Get("name"))
+	})
+	srv.HandlePrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("static"))
+	}))
+
+	// 路径参数与操作名称与 gorilla/mux 一致
+	code, body, _ := doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/v1/users/7", nil))
+	if code != 200 || body != "/v1/users/{id} 7 7" {
+		t.Errorf("unexpected response: %d %s", code, body)
+	}
+	code, body, _ = doVersion(t, srv, httptest.NewRequest(http.MethodPost, "/v1/messages/messages/1/2", nil))
+	if code != 200 || body != "messages/1/2" {
+		t.Errorf("unexpected response: %d %s", code, body)
+	}
+	code, body, _ = doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	if code != 200 || body != "static" {
+		t.Errorf("unexpected response: %d %s", code, body)
+	}
+	// 请求方法不匹配时由标准库返回 405
+	if code, _, _ = doVersion(t, srv, httptest.NewRequest(http.MethodDelete, "/v1/users/7", nil)); code != http.StatusMethodNotAllowed {
+		t.Errorf("expect 405, got %d", code)
+	}
+	// 路径参数格式错误时返回 400
+	if code, _, _ = doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/v1/users/abc", nil)); code != http.StatusBadRequest {
+		t.Errorf("expect 400, got %d", code)
+	}
+
+	var routes []RouteInfo
+	if err := srv.WalkRoute(func(r RouteInfo) error {
+		routes = append(routes, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []RouteInfo{{Path: "/v1/users/{id}", Method: http.MethodGet}, {Path: "/v1/messages/{name:.*}", Method: http.MethodPost}}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expect %v, got %v", expected, routes)
+	}
+	if _, err := srv.URL("user", nil); err == nil {
+		t.Error("expect error for route names")
+	}
+}
+
+func TestEngine_VersionHeader(t *testing.T) {
+	srv := NewServer(Engine(NewServeMux()), Versioning(VersionHeader("Api-Version")))
+	r := srv.Route("/")
+	r.Version("v1").GET("/users", versionHandler)
+	r.Version("v2").GET("/users", versionHandler)
+
+	// 相同的路由按请求的版本分发
+	for _, v := range []string{"v1", "v2"} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Api-Version", v)
+		code, body, _ := doVersion(t, srv, req)
+		if code != 200 || body != v+" /"+v+"/users" {
+			t.Errorf("unexpected response: %d %s", code, body)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Api-Version", "v3")
+	if code, _, _ := doVersion(t, srv, req); code != http.StatusNotFound {
+		t.Errorf("expect 404, got %d", code)
+	}
+}
+
+func TestEngine_HandleHeader(t *testing.T) {
+	srv := NewServer(Engine(NewServeMux()))
+	srv.HandleHeader("X-Key", "v", func(http.ResponseWriter, *http.Request) {})
+	if _, err := srv.Endpoint(); err == nil {
+		t.Error("expect error for HandleHeader")
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	pattern := "/v1/{id}/{code:[a-z]{2}}/{name:.*}"
+	expected := []PathParam{
+		{Name: "id", Start: 4, End: 8},
+		{Name: "code", Regexp: "[a-z]{2}", Start: 9, End: 24},
+		{Name: "name", Regexp: ".*", Start: 25, End: 34},
+	}
+	params := PathParams(pattern)
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("expect %v, got %v", expected, params)
+	}
+	for i, p := range params {
+		if p.Wildcard(pattern) != (i == 2) {
+			t.Errorf("%s: unexpected wildcard", p.Name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/httputil"
+)
+
+// errorStatus 是错误在 HTTP 响应体中的基本结构。
+type errorStatus struct {
+	Code     int32             `json:"code"`
+	Reason   string            `json:"reason"`
+	Message  string            `json:"message"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// localizedMessage 是本地化错误消息在 HTTP 响应体中的结构。
+type localizedMessage struct {
+	Locale  string `json:"locale"`
+	Message string `json:"message"`
+}
+
+// fieldViolation 是字段校验错误在 HTTP 响应体中的结构。
+type fieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// errorDetails 是携带详细信息的错误在 HTTP 响应体中的结构。
+type errorDetails struct {
+	errorStatus
+	Localized  *localizedMessage `json:"localized,omitempty"`
+	Violations []fieldViolation  `json:"violations,omitempty"`
+	Causes     []errorStatus     `json:"causes,omitempty"`
+}
+
+// DetailedErrorEncoder 是一个错误编码器，在默认的错误响应之外额外输出本地化消息、字段校验错误
+// 以及结构化的根因链，客户端的 DefaultErrorDecoder 会将其还原到 *errors.Error 中。
+// 仅在使用 JSON 编码时输出详细信息，其他编码格式与 DefaultErrorEncoder 一致。
+func DetailedErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
+	codec, _ := CodecForRequest(r, "Accept")
+	if codec.Name() != "json" {
+		DefaultErrorEncoder(w, r, err)
+		return
+	}
+	se := fromError(err)
+	body, err := json.Marshal(newErrorDetails(se))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", httputil.ContentType(codec.Name()))
+	setRetryAfter(w.Header(), se)
+	w.WriteHeader(int(se.Code))
+	_, _ = w.Write(body)
+}
+
+// newErrorDetails 将错误转换为 HTTP 响应体结构。
+func newErrorDetails(se *errors.Error) *errorDetails {
+	d := &errorDetails{errorStatus: newErrorStatus(se)}
+	if l := se.LocalizedMessage(); l != nil {
+		d.Localized = &localizedMessage{Locale: l.Locale, Message: l.Message}
+	}
+	for _, v := range se.FieldViolations() {
+		d.Violations = append(d.Violations, fieldViolation{Field: v.Field, Description: v.Description})
+	}
+	for _, c := range se.Causes() {
+		d.Causes = append(d.Causes, newErrorStatus(c))
+	}
+	return d
+}
+
+func newErrorStatus(se *errors.Error) errorStatus {
+	return errorStatus{Code: se.Code, Reason: se.Reason, Message: se.Message, Metadata: se.Metadata}
+}
+
+// decodeErrorDetails 从 JSON 响应体中解析错误的详细信息，并附加到错误对象上。
+func decodeErrorDetails(data []byte, se *errors.Error) *errors.Error {
+	var d errorDetails
+	if err := json.Unmarshal(data, &d); err != nil {
+		return se
+	}
+	if d.Localized != nil {
+		se = se.WithLocalizedMessage(d.Localized.Locale, d.Localized.Message)
+	}
+	if len(d.Violations) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(d.Violations))
+		for _, v := range d.Violations {
+			violations = append(violations, errors.FieldViolation(v.Field, v.Description))
+		}
+		se = se.WithFieldViolations(violations...)
+	}
+	// 从内到外依次构建根因链
+	var cause error
+	for i := len(d.Causes) - 1; i >= 0; i-- {
+		c := d.Causes[i]
+		ce := errors.New(int(c.Code), c.Reason, c.Message).WithMetadata(c.Metadata)
+		if cause != nil {
+			ce = ce.WithCause(cause)
+		}
+		cause = ce
+	}
+	if cause != nil {
+		se = se.WithCause(cause)
+	}
+	return se
+}
+
+// setRetryAfter 将错误携带的重试信息写入 Retry-After 响应头，等待时间向上取整到秒。
+func setRetryAfter(h http.Header, se *errors.Error) {
+	delay, ok := se.RetryInfo()
+	if !ok {
+		return
+	}
+	seconds := int64((delay + time.Second - 1) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	h.Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+// decodeRetryAfter 解析 Retry-After 响应头，支持秒数与 HTTP 日期两种格式，并附加到错误对象上。
+func decodeRetryAfter(res *http.Response, se *errors.Error) *errors.Error {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return se
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return se
+		}
+		return se.WithRetryInfo(time.Duration(seconds) * time.Second)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return se.WithRetryInfo(delay)
+	}
+	return se
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+// TestDetailedErrorEncoder 测试携带详细信息的错误编码与解码
+func TestDetailedErrorEncoder(t *testing.T) {
+	err := errors.BadRequest("INVALID", "invalid argument").
+		WithLocalizedMessage("en-US", "Name is required").
+		WithFieldViolations(errors.FieldViolation("name", "must not be empty")).
+		WithCause(errors.New(503, "DB", "database unavailable").WithCause(errors.New(504, "TIMEOUT", "timeout")))
+
+	w := httptest.NewRecorder()
+	DetailedErrorEncoder(w, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"localized":{"locale":"en-US","message":"Name is required"}`) {
+		t.Errorf("unexpected body: %s", body)
+	}
+
+	se := errors.FromError(DefaultErrorDecoder(context.Background(), w.Result()))
+	if se.Code != 400 || se.Reason != "INVALID" || se.Message != "invalid argument" {
+		t.Errorf("unexpected error: %v", se)
+	}
+	if l := se.LocalizedMessage(); l == nil || l.Message != "Name is required" {
+		t.Errorf("unexpected localized message: %v", l)
+	}
+	if v := se.FieldViolations(); len(v) != 1 || v[0].Field != "name" || v[0].Description != "must not be empty" {
+		t.Errorf("unexpected field violations: %v", v)
+	}
+	if c := se.Causes(); len(c) != 2 || c[0].Reason != "DB" || c[1].Reason != "TIMEOUT" {
+		t.Errorf("unexpected causes: %v", c)
+	}
+
+	// 非 JSON 编码与默认编码器一致
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	DetailedErrorEncoder(w, req, err)
+	if strings.Contains(w.Body.String(), "localized") {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// TestRetryAfter 测试重试信息通过 Retry-After 响应头传递
+func TestRetryAfter(t *testing.T) {
+	err := errors.New(429, "RATE_LIMITED", "too many requests").WithRetryInfo(1500 * time.Millisecond)
+	w := httptest.NewRecorder()
+	DefaultErrorEncoder(w, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	if v := w.Header().Get("Retry-After"); v != "2" {
+		t.Errorf("expected Retry-After 2, got %q", v)
+	}
+	delay, ok := errors.RetryDelay(DefaultErrorDecoder(context.Background(), w.Result()))
+	if !ok || delay != 2*time.Second {
+		t.Errorf("expected 2s, got %v", delay)
+	}
+
+	// 不携带重试信息时不输出 Retry-After
+	w = httptest.NewRecorder()
+	DetailedErrorEncoder(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.ServiceUnavailable("", ""))
+	if v := w.Header().Get("Retry-After"); v != "" {
+		t.Errorf("unexpected Retry-After %q", v)
+	}
+
+	// HTTP 日期格式
+	res := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	if delay, ok := errors.RetryDelay(DefaultErrorDecoder(context.Background(), res)); !ok || delay <= 59*time.Minute {
+		t.Errorf("unexpected delay %v", delay)
+	}
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagOption 是 ETag 响应编码器的配置选项。
+type ETagOption func(*etagOptions)
+
+// etagOptions 是 ETag 响应编码器的配置。
+type etagOptions struct {
+	weak bool
+}
+
+// WeakETag 配置生成弱 ETag（W/"..."），适用于语义相同但编码结果可能不完全一致的响应。
+func WeakETag() ETagOption {
+	return func(o *etagOptions) {
+		o.weak = true
+	}
+}
+
+// ETagResponseEncoder 包装响应编码器，为 GET 与 HEAD 请求的成功响应添加 ETag，并处理条件请求。
+// 如果处理器已经设置了 ETag 或 Last-Modified 响应头，将在编码之前根据 If-None-Match 与 If-Modified-Since
+// 判断，命中时直接返回 304 而不编码响应；否则根据编码后的响应体计算 ETag，命中时返回 304 且不写入响应体。
+func ETagResponseEncoder(enc EncodeResponseFunc, opts ...ETagOption) EncodeResponseFunc {
+	o := &etagOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(w http.ResponseWriter, r *http.Request, v interface{}) error {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return enc(w, r, v)
+		}
+		if _, ok := v.(Redirector); ok || v == nil {
+			return enc(w, r, v)
+		}
+		// 处理器已设置校验器时无需编码即可判断
+		if notModified(w.Header(), r) {
+			writeNotModified(w)
+			return nil
+		}
+		if w.Header().Get("ETag") != "" {
+			return enc(w, r, v)
+		}
+		bw := &bufferedWriter{ResponseWriter: w, code: http.StatusOK}
+		if err := enc(bw, r, v); err != nil {
+			return err
+		}
+		if bw.code == http.StatusOK {
+			w.Header().Set("ETag", computeETag(bw.buf.Bytes(), o.weak))
+			if notModified(w.Header(), r) {
+				writeNotModified(w)
+				return nil
+			}
+		}
+		w.WriteHeader(bw.code)
+		_, err := w.Write(bw.buf.Bytes())
+		return err
+	}
+}
+
+// bufferedWriter 缓存编码后的响应体与状态码，响应头直接写入原始的 ResponseWriter。
+type bufferedWriter struct {
+	http.ResponseWriter
+	buf  bytes.Buffer
+	code int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// computeETag 根据响应体计算 ETag。
+func computeETag(data []byte, weak bool) string {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// notModified 根据响应头中的 ETag 与 Last-Modified 判断条件请求是否命中。
+// 请求携带 If-None-Match 时忽略 If-Modified-Since。
+func notModified(h http.Header, r *http.Request) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := h.Get("ETag")
+		return etag != "" && etagMatch(inm, etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	lm := h.Get("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagMatch 使用弱比较判断 If-None-Match 中是否包含指定的 ETag。
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified 写入 304 响应，并移除与响应体相关的响应头。
+func writeNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+}
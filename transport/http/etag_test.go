@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETagResponseEncoder(t *testing.T) {
+	enc := ETagResponseEncoder(DefaultResponseEncoder)
+	v := map[string]string{"name": "kratos"}
+
+	// 首次请求返回 ETag
+	w := httptest.NewRecorder()
+	if err := enc(w, httptest.NewRequest(http.MethodGet, "/", nil), v); err != nil {
+		t.Fatal(err)
+	}
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != `{"name":"kratos"}` {
+		t.Fatalf("unexpected response: %d %q %s", w.Code, etag, w.Body.String())
+	}
+
+	// 携带匹配的 If-None-Match 返回 304
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	if err := enc(w, req, v); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	// 非 GET 请求不处理
+	w = httptest.NewRecorder()
+	if err := enc(w, httptest.NewRequest(http.MethodPost, "/", nil), v); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Errorf("unexpected ETag %q", w.Header().Get("ETag"))
+	}
+
+	// 弱 ETag
+	w = httptest.NewRecorder()
+	if err := ETagResponseEncoder(DefaultResponseEncoder, WeakETag())(w, httptest.NewRequest(http.MethodGet, "/", nil), v); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Get("ETag"); got != "W/"+etag {
+		t.Errorf("expected W/%s got %s", etag, got)
+	}
+}
+
+func TestETagResponseEncoderValidators(t *testing.T) {
+	encoded := false
+	enc := ETagResponseEncoder(func(w http.ResponseWriter, r *http.Request, v interface{}) error {
+		encoded = true
+		return DefaultResponseEncoder(w, r, v)
+	})
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		etag    string
+		header  string
+		value   string
+		code    int
+		encoded bool
+	}{
+		{"etag match", `"v1"`, "If-None-Match", `"v1"`, http.StatusNotModified, false},
+		{"etag mismatch", `"v2"`, "If-None-Match", `"v1"`, http.StatusOK, true},
+		{"not modified", "", "If-Modified-Since", modified.Format(http.TimeFormat), http.StatusNotModified, false},
+		{"modified", "", "If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded = false
+			w := httptest.NewRecorder()
+			if test.etag != "" {
+				w.Header().Set("ETag", test.etag)
+			} else {
+				w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(test.header, test.value)
+			if err := enc(w, req, map[string]string{}); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != test.code || encoded != test.encoded {
+				t.Errorf("expected %d %v got %d %v", test.code, test.encoded, w.Code, encoded)
+			}
+		})
+	}
+}
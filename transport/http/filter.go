@@ -1,10 +1,23 @@
 package http
 
-import "net/http"
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/log"
+)
 
 // FilterFunc 是一个函数，它接收一个 http.Handler 并返回另一个 http.Handler。
 type FilterFunc func(http.Handler) http.Handler
 
+// ErrorFilterFunc 是可以返回错误的过滤器，返回的错误由服务器的错误编码器编码为响应，
+// 返回错误时不应再调用 next 或写入响应。
+type ErrorFilterFunc func(w http.ResponseWriter, r *http.Request, next http.Handler) error
+
+// ErrPanic 是过滤器或处理器发生 panic 时编码为响应的错误。
+var ErrPanic = errors.InternalServer("PANIC", "internal server error")
+
 // FilterChain 返回一个 FilterFunc，它指定了 HTTP 路由器的链式处理程序。
 func FilterChain(filters ...FilterFunc) FilterFunc {
 	return func(next http.Handler) http.Handler {
@@ -17,3 +30,42 @@ func FilterChain(filters ...FilterFunc) FilterFunc {
 		return next
 	}
 }
+
+// errorFilter 将 ErrorFilterFunc 转换为 FilterFunc，返回的错误使用服务器的错误编码器编码。
+func (s *Server) errorFilter(f ErrorFilterFunc) FilterFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := f(w, r, next); err != nil {
+				s.ene(w, r, err)
+			}
+		})
+	}
+}
+
+// recoverFilter 返回一个处理器，捕获过滤器链与处理器中的 panic，记录日志与调用栈后使用错误编码器返回 500 响应。
+// http.ErrAbortHandler 会继续向上抛出，由 net/http 中断连接；响应已经开始写入时无法再替换为错误响应。
+func (s *Server) recoverFilter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rerr := recover()
+			if rerr == nil {
+				return
+			}
+			if rerr == http.ErrAbortHandler { //nolint:errorlint
+				panic(rerr)
+			}
+			buf := make([]byte, 64<<10) //nolint:mnd
+			buf = buf[:runtime.Stack(buf, false)]
+			log.Context(r.Context()).Errorw(
+				"kind", "server",
+				"component", "http",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", rerr,
+				"stack", string(buf),
+			)
+			s.ene(w, r, ErrPanic)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
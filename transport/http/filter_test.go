@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+func TestFilterChain(t *testing.T) {
+	var calls []string
+	filter := func(name string) FilterFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := FilterChain(filter("a"), filter("b"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls = append(calls, "handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Join(calls, ",") != "a,b,handler" {
+		t.Errorf("unexpected order: %v", calls)
+	}
+}
+
+func TestRecoverFilter(t *testing.T) {
+	panicFilter := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		})
+	}
+	srv := NewServer(Filter(panicFilter))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	// 过滤器中的 panic 由错误编码器返回 500 响应
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expect 500, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"reason":"PANIC"`) {
+		t.Errorf("expect encoded panic error, got %s", w.Body.String())
+	}
+
+	// 处理器中的 panic 同样会被捕获
+	srv = NewServer()
+	srv.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expect 500, got %d", w.Code)
+	}
+}
+
+func TestRecoverFilter_Abort(t *testing.T) {
+	srv := NewServer()
+	srv.HandleFunc("/abort", func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		// http.ErrAbortHandler 继续向上抛出，由 net/http 中断连接
+		if rerr := recover(); rerr != http.ErrAbortHandler { //nolint:errorlint
+			t.Errorf("expect %v, got %v", http.ErrAbortHandler, rerr)
+		}
+	}()
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
+
+func TestErrorFilter(t *testing.T) {
+	var calls []string
+	filter := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "filter")
+			next.ServeHTTP(w, r)
+		})
+	}
+	auth := func(w http.ResponseWriter, r *http.Request, next http.Handler) error {
+		calls = append(calls, "auth")
+		if r.Header.Get("Authorization") == "" {
+			return errors.Unauthorized("UNAUTHORIZED", "missing token")
+		}
+		next.ServeHTTP(w, r)
+		return nil
+	}
+	srv := NewServer(ErrorFilter(auth), Filter(filter))
+	srv.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	// 返回的错误由错误编码器编码，并且在 Filter 配置的过滤器之后执行
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"reason":"UNAUTHORIZED"`) {
+		t.Errorf("expect encoded unauthorized error, got %d %s", w.Code, w.Body.String())
+	}
+	if strings.Join(calls, ",") != "filter,auth" {
+		t.Errorf("unexpected order: %v", calls)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("expect ok, got %d %s", w.Code, w.Body.String())
+	}
+}
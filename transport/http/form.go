@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cnsync/kratos/encoding/form"
+)
+
+const (
+	// FormContentType 是表单请求体的内容类型。
+	FormContentType = "application/x-www-form-urlencoded"
+	// MultipartContentType 是多部分表单请求体的内容类型。
+	MultipartContentType = "multipart/form-data"
+)
+
+// FilePart 描述多部分表单中的一个文件部分。
+// Open 在每次发送请求时调用一次，重试与对冲请求会重新打开文件，
+// 因此文件内容以流的方式写入请求体，不会整体读入内存。
+type FilePart struct {
+	// Field 是表单字段名。
+	Field string
+	// Filename 是上报给服务端的文件名。
+	Filename string
+	// ContentType 是文件部分的内容类型，为空时使用 application/octet-stream。
+	ContentType string
+	// Open 打开文件内容。
+	Open func() (io.ReadCloser, error)
+}
+
+// FileFromPath 返回读取本地文件 path 的文件部分。
+func FileFromPath(field, path string) *FilePart {
+	return &FilePart{
+		Field:    field,
+		Filename: filepath.Base(path),
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}
+}
+
+// Multipart 是多部分表单请求的参数。
+// Fields 可以是 url.Values、map[string]string、map[string][]string、
+// proto 消息或结构体，普通字段按表单编码规则展开。
+type Multipart struct {
+	Fields interface{}
+	Files  []*FilePart
+}
+
+// FormRequestEncoder 将请求参数编码为 application/x-www-form-urlencoded 请求体。
+func FormRequestEncoder(_ context.Context, _ string, in interface{}) ([]byte, error) {
+	values, err := formValues(in)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(values.Encode()), nil
+}
+
+// formValues 将请求参数转换为表单值。
+func formValues(in interface{}) (url.Values, error) {
+	switch v := in.(type) {
+	case nil:
+		return url.Values{}, nil
+	case url.Values:
+		return v, nil
+	case map[string][]string:
+		return v, nil
+	case map[string]string:
+		values := make(url.Values, len(v))
+		for key, value := range v {
+			values.Set(key, value)
+		}
+		return values, nil
+	}
+	return form.EncodeValues(in)
+}
+
+// isMultipart 判断内容类型是否为 multipart/form-data，并返回其中指定的边界。
+func isMultipart(contentType string) (string, bool) {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mt != MultipartContentType {
+		return "", false
+	}
+	return params["boundary"], true
+}
+
+// multipartBody 根据请求参数构造多部分表单请求体，返回带边界的内容类型
+// 以及每次调用都会生成新请求体的函数。请求体通过管道流式写入。
+func multipartBody(boundary string, in interface{}) (string, func() (io.ReadCloser, error), error) {
+	fields, files := in, []*FilePart(nil)
+	if m, ok := in.(*Multipart); ok {
+		fields, files = m.Fields, m.Files
+	}
+	values, err := formValues(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	if boundary == "" {
+		boundary = multipart.NewWriter(io.Discard).Boundary()
+	}
+	// 提前校验边界，避免错误延迟到写请求体时才出现
+	if err = multipart.NewWriter(io.Discard).SetBoundary(boundary); err != nil {
+		return "", nil, err
+	}
+	getBody := func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeMultipart(pw, boundary, values, files))
+		}()
+		return pr, nil
+	}
+	contentType := mime.FormatMediaType(MultipartContentType, map[string]string{"boundary": boundary})
+	return contentType, getBody, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// writeMultipart 将表单字段与文件依次写入 w。
+func writeMultipart(w io.Writer, boundary string, values url.Values, files []*FilePart) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if err := mw.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range files {
+		if err := writeFilePart(mw, file); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// writeFilePart 写入单个文件部分。
+func writeFilePart(mw *multipart.Writer, file *FilePart) error {
+	if file.Open == nil {
+		return fmt.Errorf("http: multipart file %q has no content", file.Field)
+	}
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(file.Field), quoteEscaper.Replace(file.Filename)))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(part, rc)
+	return err
+}
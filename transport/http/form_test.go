@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cnsync/kratos/middleware"
+)
+
+// TestFormRequestEncoder 测试表单请求编码
+func TestFormRequestEncoder(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{nil, ""},
+		{url.Values{"a": {"1", "2"}}, "a=1&a=2"},
+		{map[string]string{"name": "kratos"}, "name=kratos"},
+		{&struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}{Name: "kratos", Age: 1}, "age=1&name=kratos"},
+	}
+	for _, test := range tests {
+		body, err := DefaultRequestEncoder(context.Background(), FormContentType, test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != test.want {
+			t.Errorf("expected %q, got %q", test.want, body)
+		}
+	}
+}
+
+// TestClient_Multipart 测试多部分表单请求以流的方式发送字段与文件
+func TestClient_Multipart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"name":"`+r.FormValue("name")+`","file":"`+header.Filename+":"+string(data)+`"}`)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(context.Background(), WithEndpoint(strings.TrimPrefix(srv.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	args := &Multipart{
+		Fields: map[string]string{"name": "kratos"},
+		Files: []*FilePart{{
+			Field:    "file",
+			Filename: "a.txt",
+			Open: func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("hello")), nil
+			},
+		}},
+	}
+	var reply struct {
+		Name string `json:"name"`
+		File string `json:"file"`
+	}
+	if err = client.Invoke(context.Background(), http.MethodPost, "/upload", args, &reply, ContentType(MultipartContentType)); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "kratos" || reply.File != "a.txt:hello" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+}
+
+// TestClient_MultipartLazy 测试多部分表单请求体在发送时才生成，中间件未发送请求时不会打开文件，重试时重新打开
+func TestClient_MultipartLazy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	var (
+		opens int
+		skip  bool
+	)
+	client, err := NewClient(context.Background(), WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if skip {
+					return nil, nil
+				}
+				if _, err := handler(ctx, req); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	args := &Multipart{Files: []*FilePart{{
+		Field:    "file",
+		Filename: "a.txt",
+		Open: func() (io.ReadCloser, error) {
+			opens++
+			return io.NopCloser(strings.NewReader("hello")), nil
+		},
+	}}}
+	if err = client.Invoke(context.Background(), http.MethodPost, "/upload", args, &struct{}{}, ContentType(MultipartContentType)); err != nil {
+		t.Fatal(err)
+	}
+	if opens != 2 {
+		t.Errorf("expected the file to be opened twice, got %d", opens)
+	}
+	skip = true
+	if err = client.Invoke(context.Background(), http.MethodPost, "/upload", args, &struct{}{}, ContentType(MultipartContentType)); err != nil {
+		t.Fatal(err)
+	}
+	if opens != 2 {
+		t.Errorf("expected the file not to be opened, got %d", opens)
+	}
+}
+
+// TestMultipartBody 测试多部分表单请求体可以重复生成
+func TestMultipartBody(t *testing.T) {
+	contentType, getBody, err := multipartBody("kratos-boundary", url.Values{"a": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "multipart/form-data; boundary=kratos-boundary" {
+		t.Errorf("unexpected content type: %s", contentType)
+	}
+	for i := 0; i < 2; i++ {
+		body, err := getBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `name="a"`) {
+			t.Errorf("unexpected body: %s", data)
+		}
+	}
+	if _, _, err = multipartBody("", &Multipart{Files: []*FilePart{{Field: "f"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = multipartBody("bad boundary!", nil); err == nil {
+		t.Error("expected invalid boundary error")
+	}
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+var (
+	// ErrServerBusy 是正在处理的请求数达到上限时返回的错误。
+	ErrServerBusy = errors.ServiceUnavailable("SERVER_BUSY", "too many concurrent requests")
+	// ErrTooManyRequests 是客户端的请求速率超过限制时返回的错误。
+	ErrTooManyRequests = errors.New(http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "too many requests")
+)
+
+// MaxConcurrentRequests 配置服务器同时处理的最大请求数，超过时在所有过滤器与解码之前返回 503 错误，
+// 并通过 Retry-After 响应头建议客户端在 1 秒后重试。n 小于等于 0 时不限制。
+func MaxConcurrentRequests(n int) ServerOption {
+	return func(s *Server) {
+		if n > 0 {
+			s.inflight = make(chan struct{}, n)
+		} else {
+			s.inflight = nil
+		}
+	}
+}
+
+// RateLimitOption 是客户端请求速率限制的配置选项。
+type RateLimitOption func(*ipLimiter)
+
+// RateLimitKey 设置区分客户端的函数，默认使用请求的来源 IP。
+// 服务器位于代理之后时，可以使用代理设置的可信请求头中的客户端地址。
+func RateLimitKey(fn func(*http.Request) string) RateLimitOption {
+	return func(l *ipLimiter) {
+		if fn != nil {
+			l.key = fn
+		}
+	}
+}
+
+// RateLimit 配置每个客户端 IP 的请求速率限制，每秒允许 rate 个请求，最多允许 burst 个突发请求。
+// 超过限制时在所有过滤器与解码之前返回 429 错误，并通过 Retry-After 响应头返回建议的等待时间。
+func RateLimit(rate float64, burst int, opts ...RateLimitOption) ServerOption {
+	return func(s *Server) {
+		if rate <= 0 || burst <= 0 {
+			s.limiter = nil
+			return
+		}
+		l := &ipLimiter{
+			rate:    rate,
+			burst:   float64(burst),
+			key:     remoteIP,
+			buckets: make(map[string]*bucket),
+			now:     time.Now,
+		}
+		for _, o := range opts {
+			o(l)
+		}
+		s.limiter = l
+	}
+}
+
+// limitConcurrency 是限制同时处理的请求数的过滤器。
+func (s *Server) limitConcurrency(w http.ResponseWriter, req *http.Request, next http.Handler) error {
+	select {
+	case s.inflight <- struct{}{}:
+		defer func() { <-s.inflight }()
+		next.ServeHTTP(w, req)
+		return nil
+	default:
+		return ErrServerBusy.WithRetryInfo(time.Second)
+	}
+}
+
+// limitRate 是限制每个客户端请求速率的过滤器。
+func (s *Server) limitRate(w http.ResponseWriter, req *http.Request, next http.Handler) error {
+	if wait, ok := s.limiter.allow(s.limiter.key(req)); !ok {
+		return ErrTooManyRequests.WithRetryInfo(wait)
+	}
+	next.ServeHTTP(w, req)
+	return nil
+}
+
+// remoteIP 返回请求的来源 IP。
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// bucket 是一个客户端的令牌桶。
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ipLimiter 为每个客户端维护一个令牌桶。
+type ipLimiter struct {
+	rate  float64
+	burst float64
+	key   func(*http.Request) string
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// allow 从客户端的令牌桶中取出一个令牌，令牌不足时返回需要等待的时间。
+func (l *ipLimiter) allow(key string) (time.Duration, bool) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep 每分钟清理一次已经填满的令牌桶，填满的令牌桶与新建的令牌桶等价，避免客户端过多时占用内存。
+func (l *ipLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+	srv := NewServer(MaxConcurrentRequests(1))
+	srv.router.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		close(entered)
+		<-release
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	// 正在处理的请求数达到上限时返回 503
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expect 503 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := NewServer(RateLimit(1, 2))
+	srv.router.HandleFunc("/users", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	now := time.Now()
+	srv.limiter.now = func() time.Time { return now }
+
+	serve := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	// 允许 burst 个突发请求
+	for i := 0; i < 2; i++ {
+		if w := serve("10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("expect 200, got %d", w.Code)
+		}
+	}
+	w := serve("10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expect 429 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// 不同的客户端 IP 使用独立的令牌桶
+	if w = serve("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("expect 200, got %d", w.Code)
+	}
+	// 令牌随时间恢复
+	now = now.Add(time.Second)
+	if w = serve("10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("expect 200, got %d", w.Code)
+	}
+}
+
+func TestRateLimitSweep(t *testing.T) {
+	now := time.Now()
+	l := &ipLimiter{rate: 1, burst: 1, buckets: make(map[string]*bucket), now: func() time.Time { return now }}
+	l.allow("a")
+	now = now.Add(time.Minute)
+	l.allow("b")
+	// 已经填满的令牌桶被清理
+	if _, ok := l.buckets["a"]; ok || len(l.buckets) != 1 {
+		t.Errorf("unexpected buckets: %v", l.buckets)
+	}
+}
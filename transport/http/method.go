@@ -0,0 +1,146 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// MethodOverrideHeader 是覆盖请求方法的请求头。
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// MethodOverride 配置服务器使用 X-HTTP-Method-Override 请求头覆盖 POST 请求的方法，
+// 供只能发送 GET 与 POST 请求的客户端调用 REST 接口。methods 是允许覆盖为的方法，默认为 PUT、PATCH 与 DELETE。
+// 请求方法在所有过滤器之前覆盖，过滤器、路由与中间件看到的都是覆盖后的方法。
+func MethodOverride(methods ...string) ServerOption {
+	return func(s *Server) {
+		if len(methods) == 0 {
+			methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+		}
+		s.overrideMethods = make(map[string]struct{}, len(methods))
+		for _, m := range methods {
+			s.overrideMethods[strings.ToUpper(m)] = struct{}{}
+		}
+	}
+}
+
+// AutoOptions 配置服务器自动响应没有注册 OPTIONS 路由的 OPTIONS 请求，
+// 返回 204 以及包含路径上所有已注册方法的 Allow 响应头。只支持 gorilla/mux 路由引擎。
+// 自动响应在过滤器之后执行，CORS 等过滤器可以先处理预检请求。
+func AutoOptions(auto bool) ServerOption {
+	return func(s *Server) {
+		s.autoOptions = auto
+	}
+}
+
+// AutoHead 配置服务器使用 GET 路由处理没有注册 HEAD 路由的 HEAD 请求，并丢弃响应体。
+// 只支持 gorilla/mux 路由引擎。
+func AutoHead(auto bool) ServerOption {
+	return func(s *Server) {
+		s.autoHead = auto
+	}
+}
+
+// methodOverride 返回覆盖请求方法的过滤器。
+func (s *Server) methodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			if m := strings.ToUpper(strings.TrimSpace(req.Header.Get(MethodOverrideHeader))); m != "" {
+				if _, ok := s.overrideMethods[m]; ok {
+					req.Method = m
+					req.Header.Del(MethodOverrideHeader)
+				}
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// autoMethods 返回自动处理 OPTIONS 与 HEAD 请求的处理器，只处理路径匹配但是方法不匹配的请求。
+func (s *Server) autoMethods(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodOptions && s.autoOptions && s.methodMismatch(req):
+			if allow := s.allowedMethods(req); len(allow) > 0 {
+				w.Header().Set("Allow", strings.Join(allow, ", "))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		case req.Method == http.MethodHead && s.autoHead && s.methodMismatch(req):
+			get := withMethod(req, http.MethodGet)
+			if s.matchRoute(get) {
+				next.ServeHTTP(headResponseWriter{w}, get)
+				return
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// methodMismatch 判断请求的路径是否匹配已注册的路由，但是请求方法不匹配。
+func (s *Server) methodMismatch(req *http.Request) bool {
+	var match mux.RouteMatch
+	s.router.Match(req, &match)
+	return match.MatchErr == mux.ErrMethodMismatch //nolint:errorlint
+}
+
+// matchRoute 判断请求是否匹配已注册的路由。
+func (s *Server) matchRoute(req *http.Request) bool {
+	var match mux.RouteMatch
+	return s.router.Match(req, &match) && match.MatchErr == nil && match.Route != nil
+}
+
+// allowedMethods 返回请求路径上已注册的所有方法。
+func (s *Server) allowedMethods(req *http.Request) []string {
+	seen := make(map[string]struct{})
+	_ = s.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, _ := route.GetMethods()
+		for _, m := range methods {
+			seen[m] = struct{}{}
+		}
+		return nil
+	})
+	var allow []string
+	for m := range seen {
+		if s.matchRoute(withMethod(req, m)) {
+			allow = append(allow, m)
+		}
+	}
+	if len(allow) == 0 {
+		return nil
+	}
+	has := func(method string) bool {
+		for _, m := range allow {
+			if m == method {
+				return true
+			}
+		}
+		return false
+	}
+	if s.autoHead && has(http.MethodGet) && !has(http.MethodHead) {
+		allow = append(allow, http.MethodHead)
+	}
+	if !has(http.MethodOptions) {
+		allow = append(allow, http.MethodOptions)
+	}
+	sort.Strings(allow)
+	return allow
+}
+
+// withMethod 返回使用指定方法的请求副本。
+func withMethod(req *http.Request, method string) *http.Request {
+	r := req.WithContext(req.Context())
+	r.Method = method
+	return r
+}
+
+// headResponseWriter 丢弃 HEAD 请求的响应体。
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newMethodServer(opts ...ServerOption) *Server {
+	srv := NewServer(opts...)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		_, _ = w.Write([]byte("ok"))
+	}
+	srv.router.HandleFunc("/users/{id}", handler).Methods(http.MethodGet, http.MethodPut)
+	srv.router.HandleFunc("/users/{id}", handler).Methods(http.MethodDelete)
+	srv.router.HandleFunc("/users", handler).Methods(http.MethodPost)
+	return srv
+}
+
+func TestMethodOverride(t *testing.T) {
+	srv := newMethodServer(MethodOverride())
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Header.Set(MethodOverrideHeader, "delete")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	// POST 请求的方法被覆盖为 DELETE
+	if w.Code != http.StatusOK || w.Header().Get("X-Method") != http.MethodDelete {
+		t.Errorf("expect DELETE, got %d %s", w.Code, w.Header().Get("X-Method"))
+	}
+
+	// 不允许覆盖为 GET，也不覆盖 POST 以外的请求
+	for _, tt := range []struct{ method, override string }{
+		{http.MethodPost, http.MethodGet},
+		{http.MethodGet, http.MethodDelete},
+	} {
+		req = httptest.NewRequest(tt.method, "/users", nil)
+		req.Header.Set(MethodOverrideHeader, tt.override)
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if got := w.Header().Get("X-Method"); tt.method == http.MethodPost && got != http.MethodPost {
+			t.Errorf("expect POST, got %s", got)
+		}
+		if tt.method == http.MethodGet && w.Code == http.StatusOK {
+			t.Errorf("expect GET /users not to be overridden, got %d", w.Code)
+		}
+	}
+
+	// 未启用时忽略请求头
+	srv = newMethodServer()
+	req = httptest.NewRequest(http.MethodPost, "/users", nil)
+	req.Header.Set(MethodOverrideHeader, http.MethodDelete)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Header().Get("X-Method") != http.MethodPost {
+		t.Errorf("expect POST, got %s", w.Header().Get("X-Method"))
+	}
+}
+
+func TestAutoOptions(t *testing.T) {
+	srv := newMethodServer(AutoOptions(true), AutoHead(true))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/users/1", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expect 204, got %d", w.Code)
+	}
+	if got, want := w.Header().Get("Allow"), "DELETE, GET, HEAD, OPTIONS, PUT"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+
+	// 没有匹配的路径时不自动响应
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/orders", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expect 404, got %d", w.Code)
+	}
+}
+
+func TestAutoHead(t *testing.T) {
+	srv := newMethodServer(AutoHead(true))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/users/1", nil))
+	// 使用 GET 路由处理并丢弃响应体
+	if w.Code != http.StatusOK || w.Header().Get("X-Method") != http.MethodGet {
+		t.Errorf("expect GET handler, got %d %s", w.Code, w.Header().Get("X-Method"))
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expect empty body, got %q", w.Body.String())
+	}
+
+	// 没有 GET 路由的路径不处理
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/users", nil))
+	if w.Code == http.StatusOK {
+		t.Errorf("expect HEAD /users not to be handled, got %d", w.Code)
+	}
+
+	// 未启用时不处理
+	srv = newMethodServer()
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/users/1", nil))
+	if w.Code == http.StatusOK {
+		t.Errorf("expect HEAD not to be handled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultOpenAPIPath 是 OpenAPI 文档默认的访问路径。
+const defaultOpenAPIPath = "/openapi.json"
+
+// OpenAPIOption 是 OpenAPI 文档服务的配置选项。
+type OpenAPIOption func(*openAPI)
+
+// openAPI 是 OpenAPI 文档服务的配置。
+type openAPI struct {
+	doc    []byte
+	path   string
+	uiPath string
+}
+
+// OpenAPIPath 配置 OpenAPI JSON 文档的访问路径，默认为 "/openapi.json"。
+func OpenAPIPath(path string) OpenAPIOption {
+	return func(o *openAPI) {
+		o.path = path
+	}
+}
+
+// OpenAPIUI 配置 Swagger UI 页面的访问路径，如 "/docs"，默认不提供 UI 页面。
+func OpenAPIUI(path string) OpenAPIOption {
+	return func(o *openAPI) {
+		o.uiPath = path
+	}
+}
+
+// ServeOpenAPI 配置服务器提供 OpenAPI 文档，文档可以是 `kratos proto openapi` 生成的 YAML 或 JSON，
+// 统一以 JSON 格式提供，通常使用 embed 将生成的文档嵌入到服务中，使文档与路由保持同步。
+func ServeOpenAPI(doc []byte, opts ...OpenAPIOption) ServerOption {
+	return func(s *Server) {
+		o := &openAPI{doc: doc, path: defaultOpenAPIPath}
+		for _, opt := range opts {
+			opt(o)
+		}
+		s.openapi = o
+	}
+}
+
+// register 将 OpenAPI 文档与 UI 页面注册到路由中。
+func (o *openAPI) register(s *Server) error {
+	doc, err := openAPIJSON(o.doc)
+	if err != nil {
+		return err
+	}
+	s.handle(o.path, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	}), http.MethodGet, http.MethodHead)
+	if o.uiPath == "" {
+		return nil
+	}
+	var page bytes.Buffer
+	if err = swaggerUI.Execute(&page, o.path); err != nil {
+		return err
+	}
+	s.handle(o.uiPath, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page.Bytes())
+	}), http.MethodGet, http.MethodHead)
+	return nil
+}
+
+// openAPIJSON 将 YAML 或 JSON 格式的 OpenAPI 文档转换为 JSON。
+func openAPIJSON(doc []byte) ([]byte, error) {
+	if json.Valid(doc) {
+		return doc, nil
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(doc, &v); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	return json.Marshal(stringKeys(v))
+}
+
+// stringKeys 将 YAML 解析结果中非字符串的键转换为字符串，以便编码为 JSON。
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = stringKeys(e)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = stringKeys(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// swaggerUI 是加载 OpenAPI 文档的 Swagger UI 页面。
+var swaggerUI = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API Documentation</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.onload = function() {
+  window.ui = SwaggerUIBundle({url: {{.}}, dom_id: "#swagger-ui"});
+};
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeOpenAPI(t *testing.T) {
+	doc := []byte(`openapi: 3.0.3
+info:
+    title: Greeter API
+paths:
+    /helloworld/{name}:
+        get:
+            responses:
+                "200":
+                    description: OK
+`)
+	srv := NewServer(ServeOpenAPI(doc, OpenAPIUI("/docs")))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `"title":"Greeter API"`) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `url: "/openapi.json"`) {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	// 自定义路径，未配置 UI 页面
+	srv = NewServer(ServeOpenAPI([]byte(`{"openapi":"3.1.0"}`), OpenAPIPath("/api/openapi.json")))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Body.String() != `{"openapi":"3.1.0"}` {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	// 无效的文档在获取端点时返回错误
+	srv = NewServer(ServeOpenAPI([]byte("\t: invalid")))
+	if _, err := srv.Endpoint(); err == nil {
+		t.Error("expected error")
+	}
+	if srv.lis != nil {
+		_ = srv.lis.Close()
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+// ParamReason 是路径参数缺失或格式错误时返回的错误原因。
+const ParamReason = "INVALID_PATH_PARAM"
+
+// Param 返回指定名称的路径参数，参数不存在时返回空字符串。
+func (c *wrapper) Param(name string) string {
+	return c.Vars().Get(name)
+}
+
+// ParamInt64 返回转换为 int64 的路径参数，参数缺失或格式错误时返回 BadRequest 错误。
+func (c *wrapper) ParamInt64(name string) (int64, error) {
+	v, err := c.param(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, invalidParam(name, v, "int64")
+	}
+	return n, nil
+}
+
+// ParamUUID 返回转换为 UUID 的路径参数，参数缺失或格式错误时返回 BadRequest 错误。
+func (c *wrapper) ParamUUID(name string) (uuid.UUID, error) {
+	v, err := c.param(name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return uuid.Nil, invalidParam(name, v, "uuid")
+	}
+	return id, nil
+}
+
+// ParamBool 返回转换为 bool 的路径参数，参数缺失或格式错误时返回 BadRequest 错误。
+func (c *wrapper) ParamBool(name string) (bool, error) {
+	v, err := c.param(name)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, invalidParam(name, v, "bool")
+	}
+	return b, nil
+}
+
+// param 返回路径参数，参数不存在时返回 BadRequest 错误。
+func (c *wrapper) param(name string) (string, error) {
+	vs, ok := c.Vars()[name]
+	if !ok || len(vs) == 0 {
+		return "", errors.BadRequest(ParamReason, fmt.Sprintf("missing path parameter %s", name)).
+			WithMetadata(map[string]string{"param": name})
+	}
+	return vs[0], nil
+}
+
+// invalidParam 返回路径参数格式错误的 BadRequest 错误。
+func invalidParam(name, value, typ string) error {
+	return errors.BadRequest(ParamReason, fmt.Sprintf("invalid path parameter %s=%q, expected %s", name, value, typ)).
+		WithMetadata(map[string]string{"param": name})
+}
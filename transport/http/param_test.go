@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+// TestContextParam 测试路径参数的类型转换
+func TestContextParam(t *testing.T) {
+	id := uuid.New()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{
+		"id":     "42",
+		"uid":    id.String(),
+		"active": "true",
+		"bad":    "x",
+	})
+	w := &wrapper{router: testRouter, req: req}
+
+	if got := w.Param("id"); got != "42" {
+		t.Errorf("expected 42, got %s", got)
+	}
+	if n, err := w.ParamInt64("id"); err != nil || n != 42 {
+		t.Errorf("expected 42, got %d %v", n, err)
+	}
+	if u, err := w.ParamUUID("uid"); err != nil || u != id {
+		t.Errorf("expected %s, got %s %v", id, u, err)
+	}
+	if b, err := w.ParamBool("active"); err != nil || !b {
+		t.Errorf("expected true, got %v %v", b, err)
+	}
+
+	// 格式错误与缺失的参数返回 BadRequest 错误
+	_, err := w.ParamInt64("bad")
+	if !errors.IsBadRequest(err) || errors.Reason(err) != ParamReason || errors.FromError(err).Metadata["param"] != "bad" {
+		t.Errorf("expected bad request, got %v", err)
+	}
+	if _, err = w.ParamUUID("bad"); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+	if _, err = w.ParamBool("missing"); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
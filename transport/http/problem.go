@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+// ProblemContentType 是 RFC 7807 定义的问题详情的媒体类型。
+const ProblemContentType = "application/problem+json"
+
+// problemDetails 是 RFC 7807 定义的问题详情，reason 与 metadata 作为扩展成员输出。
+type problemDetails struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int32             `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ProblemDetails 配置服务器使用 ProblemErrorEncoder 编码错误。
+func ProblemDetails() ServerOption {
+	return ErrorEncoder(ProblemErrorEncoder)
+}
+
+// ProblemErrorEncoder 是一个错误编码器，将错误编码为 RFC 7807 定义的 application/problem+json 格式，
+// 便于非 kratos 客户端处理错误。请求的 Accept 头明确要求其他已注册的编码格式时（例如 application/xml），
+// 与 DefaultErrorEncoder 一致。
+func ProblemErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
+	if codec, ok := CodecForRequest(r, "Accept"); ok && codec.Name() != "json" && !acceptsProblem(r) {
+		DefaultErrorEncoder(w, r, err)
+		return
+	}
+	writeProblem(w, r, fromError(err))
+}
+
+// writeProblem 将错误以 application/problem+json 格式写入响应。
+func writeProblem(w http.ResponseWriter, r *http.Request, se *errors.Error) {
+	title := http.StatusText(int(se.Code))
+	if title == "" {
+		title = se.Reason
+	}
+	body, err := json.Marshal(&problemDetails{
+		Type:     "about:blank",
+		Title:    title,
+		Status:   se.Code,
+		Detail:   se.Message,
+		Instance: r.URL.Path,
+		Reason:   se.Reason,
+		Metadata: se.Metadata,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	setRetryAfter(w.Header(), se)
+	w.WriteHeader(int(se.Code))
+	_, _ = w.Write(body)
+}
+
+// acceptsProblem 判断请求的 Accept 头是否明确接受 application/problem+json。
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, v := range strings.Split(accept, ",") {
+			mt, _, _ := strings.Cut(v, ";")
+			if strings.EqualFold(strings.TrimSpace(mt), ProblemContentType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isProblemResponse 判断响应是否为 application/problem+json 格式。
+func isProblemResponse(res *http.Response) bool {
+	mt, _, _ := strings.Cut(res.Header.Get("Content-Type"), ";")
+	return strings.EqualFold(strings.TrimSpace(mt), ProblemContentType)
+}
+
+// decodeProblem 将 application/problem+json 格式的响应体解析为错误。
+func decodeProblem(data []byte, code int) (*errors.Error, error) {
+	var p problemDetails
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return errors.New(code, p.Reason, p.Detail).WithMetadata(p.Metadata), nil
+}
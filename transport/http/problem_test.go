@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/errors"
+)
+
+// TestProblemErrorEncoder 测试 application/problem+json 格式的错误编码与解码
+func TestProblemErrorEncoder(t *testing.T) {
+	err := errors.NotFound("USER_NOT_FOUND", "user not found").
+		WithMetadata(map[string]string{"id": "42"}).
+		WithRetryInfo(time.Second)
+
+	w := httptest.NewRecorder()
+	ProblemErrorEncoder(w, httptest.NewRequest(http.MethodGet, "/users/42", nil), err)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	var p map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p["type"] != "about:blank" || p["title"] != "Not Found" || p["status"] != float64(404) ||
+		p["detail"] != "user not found" || p["instance"] != "/users/42" || p["reason"] != "USER_NOT_FOUND" {
+		t.Errorf("unexpected problem: %v", p)
+	}
+
+	se := errors.FromError(DefaultErrorDecoder(context.Background(), w.Result()))
+	if se.Code != 404 || se.Reason != "USER_NOT_FOUND" || se.Message != "user not found" || se.Metadata["id"] != "42" {
+		t.Errorf("unexpected error: %v", se)
+	}
+	if delay, ok := se.RetryInfo(); !ok || delay != time.Second {
+		t.Errorf("unexpected retry info: %v", delay)
+	}
+
+	// 明确要求其他编码格式时与默认编码器一致
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/proto")
+	ProblemErrorEncoder(w, req, err)
+	if ct := w.Header().Get("Content-Type"); ct != "application/proto" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
+
+// TestDefaultErrorEncoderNegotiation 测试默认错误编码器的内容协商
+func TestDefaultErrorEncoderNegotiation(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"application/problem+json", ProblemContentType},
+		{"application/problem+json, application/json;q=0.5", ProblemContentType},
+		{"text/html, application/proto;q=0.9, application/json;q=0.8", "application/proto"},
+		{"application/json;q=0.5, application/proto", "application/proto"},
+		{"application/proto;q=0, application/json", "application/json"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		DefaultErrorEncoder(w, req, errors.BadRequest("INVALID", "invalid"))
+		if ct := w.Header().Get("Content-Type"); ct != test.want {
+			t.Errorf("%q: expected %q, got %q", test.accept, test.want, ct)
+		}
+	}
+}
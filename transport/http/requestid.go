@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/cnsync/kratos/middleware/requestid"
+)
+
+// RequestID 返回一个过滤器，确保每个请求都带有请求 ID：请求头中没有合法的请求 ID 时生成一个 UUIDv7，
+// 并将其写回请求头、附加到请求上下文中，同时在响应头中返回。
+// 服务端的 requestid.Server 中间件与客户端的 requestid.Client 中间件会复用上下文中的请求 ID。
+func RequestID(opts ...requestid.Option) FilterFunc {
+	o := requestid.NewOptions(opts...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := o.Ensure(r.Header.Get(o.Header))
+			r.Header.Set(o.Header, id)
+			w.Header().Set(o.Header, id)
+			next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cnsync/kratos/middleware/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	var (
+		header string
+		ctxID  string
+	)
+	h := RequestID()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(requestid.DefaultHeader)
+		ctxID, _ = requestid.FromContext(r.Context())
+	}))
+
+	// 请求头中带有请求 ID 时原样使用
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestid.DefaultHeader, "abc")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if header != "abc" || ctxID != "abc" || w.Header().Get(requestid.DefaultHeader) != "abc" {
+		t.Errorf("expect abc, got header %q, context %q, response %q", header, ctxID, w.Header().Get(requestid.DefaultHeader))
+	}
+
+	// 请求头中没有请求 ID 时生成新的请求 ID
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if header == "" || header == "abc" || ctxID != header || w.Header().Get(requestid.DefaultHeader) != header {
+		t.Errorf("expect generated request id, got header %q, context %q, response %q", header, ctxID, w.Header().Get(requestid.DefaultHeader))
+	}
+}
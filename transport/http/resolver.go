@@ -56,11 +56,13 @@ func parseTarget(endpoint string, insecure bool) (*Target, error) {
 type resolver struct {
 	rebalancer selector.Rebalancer // 负载均衡器
 
-	target      *Target          // 目标服务的解析信息
-	watcher     registry.Watcher // 服务发现的观察者
-	selectorKey string           // 选择器的唯一标识符
-	subsetSize  int              // 子集大小，用于筛选服务实例
-	insecure    bool             // 是否使用不安全的 HTTP（http://）
+	target      *Target               // 目标服务的解析信息
+	watcher     registry.Watcher      // 服务发现的观察者
+	events      registry.EventWatcher // 服务实例的增量事件监视器
+	set         *registry.InstanceSet // 由增量事件维护的服务实例集合
+	selectorKey string                // 选择器的唯一标识符
+	subsetSize  int                   // 子集大小，用于筛选服务实例
+	insecure    bool                  // 是否使用不安全的 HTTP（http://）
 }
 
 // newResolver 创建并初始化一个新的 resolver。
@@ -78,6 +80,8 @@ func newResolver(ctx context.Context, discovery registry.Discovery, target *Targ
 	r := &resolver{
 		target:      target,
 		watcher:     watcher,
+		events:      registry.NewEventWatcher(watcher),
+		set:         registry.NewInstanceSet(),
 		rebalancer:  rebalancer,
 		insecure:    insecure,
 		selectorKey: uuid.New().String(),
@@ -89,14 +93,14 @@ func newResolver(ctx context.Context, discovery registry.Discovery, target *Targ
 		done := make(chan error, 1)
 		go func() {
 			for {
-				// 获取下一个服务实例列表
-				services, err := watcher.Next()
+				// 获取下一批服务实例变更事件
+				events, err := r.events.NextEvents()
 				if err != nil {
 					done <- err
 					return
 				}
-				// 更新服务实例
-				if r.update(services) {
+				// 实例集合发生变化时更新服务实例
+				if r.set.Apply(events) && r.update(r.set.List()) {
 					done <- nil
 					return
 				}
@@ -126,7 +130,7 @@ func newResolver(ctx context.Context, discovery registry.Discovery, target *Targ
 	// 启动一个 goroutine 来持续监听服务实例的变化
 	go func() {
 		for {
-			services, err := watcher.Next()
+			events, err := r.events.NextEvents()
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
 					return
@@ -135,8 +139,10 @@ func newResolver(ctx context.Context, discovery registry.Discovery, target *Targ
 				time.Sleep(time.Second)
 				continue
 			}
-			// 更新服务实例
-			r.update(services)
+			// 实例集合发生变化时更新服务实例
+			if r.set.Apply(events) {
+				r.update(r.set.List())
+			}
 		}
 	}()
 	return r, nil
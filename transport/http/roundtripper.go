@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/httputil"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+var _ http.RoundTripper = RoundTripperFunc(nil)
+
+// RoundTripperFunc 是函数形式的 http.RoundTripper。
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip 执行一次 HTTP 请求。
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// responseError 在中间件链中携带非 2xx 的 HTTP 响应，使中间件能够感知错误的同时保留原始响应。
+type responseError struct {
+	resp *http.Response
+}
+
+func (e *responseError) Error() string {
+	return e.resp.Status
+}
+
+// NewRoundTripper 将 kratos 客户端中间件转换为 http.RoundTripper，
+// 使基于 net/http 的 SDK 无需改用 Client.Invoke 也能使用链路追踪、指标和重试等中间件。
+// 非 2xx 的响应会以 *errors.Error 的形式传递给中间件，但最终仍会原样返回给调用方。
+func NewRoundTripper(next http.RoundTripper, m ...middleware.Middleware) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// RoundTripper 不应修改原始请求，复制后再由中间件修改请求头
+		req = req.Clone(req.Context())
+		tr := &Transport{
+			endpoint:     req.URL.Host,
+			operation:    req.URL.Path,
+			reqHeader:    headerCarrier(req.Header),
+			request:      req,
+			pathTemplate: req.URL.Path,
+		}
+		var (
+			attempts int
+			last     *http.Response
+		)
+		h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			r := req.WithContext(ctx)
+			// 中间件重试时需要重新读取请求体，并释放上一次尝试的响应使连接可以复用
+			if attempts++; attempts > 1 {
+				if last != nil {
+					_ = httputil.DrainAndClose(last.Body)
+					last = nil
+				}
+				if r.Body != nil && r.GetBody != nil {
+					body, err := r.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					r.Body = body
+				}
+			}
+			resp, err := next.RoundTrip(r)
+			if err != nil {
+				return nil, err
+			}
+			last = resp
+			tr.replyHeader = headerCarrier(resp.Header)
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return resp, errors.New(resp.StatusCode, errors.UnknownReason, http.StatusText(resp.StatusCode)).
+					WithCause(&responseError{resp: resp})
+			}
+			return resp, nil
+		}
+		if len(m) > 0 {
+			h = middleware.Chain(m...)(h)
+		}
+		reply, err := h(transport.NewClientContext(req.Context(), tr), nil)
+		resp, _ := reply.(*http.Response)
+		if resp == nil {
+			var re *responseError
+			if stderrors.As(err, &re) {
+				resp = re.resp
+			}
+		}
+		// 中间件没有返回的响应需要释放
+		if last != nil && last != resp {
+			_ = httputil.DrainAndClose(last.Body)
+		}
+		if resp == nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+}
+
+// RoundTripperMiddleware 将 http.RoundTripper 包装器转换为 kratos 客户端中间件，
+// 使现有的 net/http 中间件能够用于 Client.Invoke 发起的请求。
+// 包装器对请求头的修改会同步到实际的请求中，包装器收到的响应只包含状态码和响应头。
+func RoundTripperMiddleware(wrap func(http.RoundTripper) http.RoundTripper) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ht, ok := tr.(*Transport)
+			if !ok || ht.request == nil {
+				return handler(ctx, req)
+			}
+			var (
+				reply  interface{}
+				err    error
+				called bool
+			)
+			rt := wrap(RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				for k, v := range r.Header {
+					ht.request.Header[k] = v
+				}
+				called = true
+				reply, err = handler(r.Context(), req)
+				resp := &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header(ht.replyHeader),
+					Body:       http.NoBody,
+					Request:    r,
+				}
+				if err != nil {
+					resp.StatusCode = errors.HTTPStatus(err)
+				}
+				resp.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+				if resp.Header == nil {
+					resp.Header = make(http.Header)
+				}
+				return resp, nil
+			}))
+			resp, rtErr := rt.RoundTrip(ht.request.WithContext(ctx))
+			if rtErr != nil {
+				return nil, rtErr
+			}
+			_ = httputil.DrainAndClose(resp.Body)
+			// 包装器没有继续调用下游时，以包装器返回的状态码作为结果
+			if !called && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+				return nil, errors.New(resp.StatusCode, errors.UnknownReason, http.StatusText(resp.StatusCode))
+			}
+			return reply, err
+		}
+	}
+}
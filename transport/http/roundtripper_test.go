@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kratoserrors "github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
+)
+
+// TestNewRoundTripper 测试将中间件转换为 RoundTripper
+func TestNewRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-reply", r.Header.Get("x-trace"))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var (
+		operation string
+		reply     string
+		code      int32
+	)
+	m := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				t.Fatal("expected client transport")
+			}
+			operation = tr.Operation()
+			tr.RequestHeader().Set("x-trace", "2233")
+			res, err := handler(ctx, req)
+			reply = tr.ReplyHeader().Get("x-reply")
+			code = int32(kratoserrors.Code(err))
+			return res, err
+		}
+	}
+	client := &http.Client{Transport: NewRoundTripper(nil, m)}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ok", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if operation != "/ok" || reply != "2233" || code != 200 {
+		t.Errorf("unexpected result: operation=%s reply=%s code=%d", operation, reply, code)
+	}
+	if req.Header.Get("x-trace") != "" {
+		t.Error("expected original request not to be modified")
+	}
+
+	resp, err = client.Get(srv.URL + "/fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d %d", resp.StatusCode, code)
+	}
+}
+
+// TestNewRoundTripperRetry 测试中间件重试时重新发送请求体
+func TestNewRoundTripperRetry(t *testing.T) {
+	var (
+		bodies    []string
+		responses []*closeBody
+	)
+	next := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		body := &closeBody{Reader: strings.NewReader("unavailable")}
+		responses = append(responses, body)
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: make(http.Header), Body: body}, nil
+	})
+	retry := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, _ = handler(ctx, req)
+			return handler(ctx, req)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/retry", strings.NewReader("data"))
+	resp, err := NewRoundTripper(next, retry).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", resp.StatusCode)
+	}
+	if len(bodies) != 2 || bodies[0] != "data" || bodies[1] != "data" {
+		t.Errorf("unexpected bodies: %v", bodies)
+	}
+	// 被重试丢弃的响应需要关闭，返回的响应由调用方关闭
+	if !responses[0].closed || responses[1].closed {
+		t.Errorf("expected only the retried response to be closed, got %v %v", responses[0].closed, responses[1].closed)
+	}
+}
+
+// closeBody 记录响应体是否被关闭
+type closeBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// TestRoundTripperMiddleware 测试将 RoundTripper 包装器转换为中间件
+func TestRoundTripperMiddleware(t *testing.T) {
+	var status int
+	wrap := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			r.Header.Set("x-auth", "token")
+			resp, err := next.RoundTrip(r)
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			return resp, err
+		})
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/test", nil)
+	ctx := transport.NewClientContext(context.Background(), &Transport{
+		reqHeader: headerCarrier(req.Header),
+		request:   req,
+	})
+	h := RoundTripperMiddleware(wrap)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		tr, _ := transport.FromClientContext(ctx)
+		if v := tr.RequestHeader().Get("x-auth"); v != "token" {
+			t.Errorf("expected header token, got %q", v)
+		}
+		return nil, kratoserrors.BadRequest("BAD", "bad request")
+	})
+	_, err := h(ctx, nil)
+	if !kratoserrors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+	if status != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", status)
+	}
+
+	// 包装器直接返回响应
+	deny := func(http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusForbidden, Body: http.NoBody}, nil
+		})
+	}
+	_, err = RoundTripperMiddleware(deny)(func(context.Context, interface{}) (interface{}, error) {
+		t.Error("expected handler not to be called")
+		return nil, nil
+	})(ctx, nil)
+	if kratoserrors.FromError(err).Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %v", err)
+	}
+}
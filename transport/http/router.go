@@ -3,6 +3,10 @@ package http
 import (
 	"net/http"
 	"path"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cnsync/kratos/middleware"
 )
 
 // WalkRouteFunc 是在遍历路由时，为每个访问的路由调用的函数类型。
@@ -26,6 +30,11 @@ type Router struct {
 	prefix  string       // 路由前缀，所有路由的 URL 会基于这个前缀匹配
 	srv     *Server      // 服务器实例，用于注册路由
 	filters []FilterFunc // 路由的过滤器（中间件），用于在请求处理过程中执行
+	route   *mux.Route   // 最近一次注册的路由，用于 Name 设置路由名称
+	op      *string      // 最近一次注册的路由的操作名称，用于 Operation 设置操作名称
+
+	middleware []middleware.Middleware // 路由组的中间件，在服务中间件之后执行
+	versions   []string                // 路由组处理的 API 版本
 }
 
 // newRouter 用于创建一个新的路由器实例。
@@ -43,9 +52,19 @@ func newRouter(prefix string, srv *Server, filters ...FilterFunc) *Router {
 // 它将创建一个新的路由器，并将当前路由的前缀和中间件过滤器与新组合并。
 func (r *Router) Group(prefix string, filters ...FilterFunc) *Router {
 	var newFilters []FilterFunc
-	newFilters = append(newFilters, r.filters...)                       // 保留当前路由的过滤器
-	newFilters = append(newFilters, filters...)                         // 添加新路由组的过滤器
-	return newRouter(path.Join(r.prefix, prefix), r.srv, newFilters...) // 创建新的路由器组
+	newFilters = append(newFilters, r.filters...)                      // 保留当前路由的过滤器
+	newFilters = append(newFilters, filters...)                        // 添加新路由组的过滤器
+	nr := newRouter(path.Join(r.prefix, prefix), r.srv, newFilters...) // 创建新的路由器组
+	nr.middleware = append(nr.middleware, r.middleware...)             // 继承当前路由的中间件
+	nr.versions = append(nr.versions, r.versions...)                   // 继承当前路由的 API 版本
+	return nr
+}
+
+// Use 为路由组添加 kratos 中间件，对该路由器及之后由其创建的路由组中的路由生效，
+// 在 Server.Use 和 Middleware 选项匹配的服务中间件之后执行。应在服务启动前调用。
+func (r *Router) Use(m ...middleware.Middleware) *Router {
+	r.middleware = append(r.middleware, m...)
+	return r
 }
 
 // Handle 注册一个新的路由，匹配 URL 路径和 HTTP 方法。
@@ -53,17 +72,48 @@ func (r *Router) Group(prefix string, filters ...FilterFunc) *Router {
 func (r *Router) Handle(method, relativePath string, h HandlerFunc, filters ...FilterFunc) {
 	// 将处理函数包裹为 http.Handler，并处理错误
 	next := http.Handler(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		ctx := &wrapper{router: r} // 创建一个 Context 包装器
-		ctx.Reset(res, req)        // 重置上下文
+		ctx := r.srv.acquireContext(r, res, req) // 获取一个 Context 包装器
 		if err := h(ctx); err != nil {
 			r.srv.ene(res, req, err) // 如果处理函数返回错误，调用错误编码器
 		}
+		r.srv.releaseContext(ctx)
 	}))
 	// 应用过滤器链
 	next = FilterChain(filters...)(next)
 	next = FilterChain(r.filters...)(next) // 将路由器的过滤器应用到处理函数
+	r.op = new(string)
+	next = operationHandler(r.op, next)
 	// 注册路由到服务器
-	r.srv.router.Handle(path.Join(r.prefix, relativePath), next).Methods(method)
+	if len(r.versions) > 0 {
+		r.handleVersions(method, relativePath, next)
+		return
+	}
+	r.route = r.srv.handle(path.Join(r.prefix, relativePath), next, method)
+}
+
+// Name 为最近一次注册的路由设置名称，之后可以通过 Server.URL 根据名称生成链接，只支持 gorilla/mux 路由引擎，例如：
+//
+//	r.GET("/users/{id}", getUser)
+//	r.Name("user")
+func (r *Router) Name(name string) *Router {
+	if r.route != nil {
+		r.route.Name(name)
+	}
+	return r
+}
+
+// Operation 为最近一次注册的路由设置稳定的操作名称，路由过滤器、中间件的匹配以及指标等使用该名称，
+// 而不是路径模板，例如：
+//
+//	r.GET("/users/{id}", getUser)
+//	r.Operation("/api.user.v1.User/GetUser")
+//
+// 应在服务启动前调用。
+func (r *Router) Operation(operation string) *Router {
+	if r.op != nil {
+		*r.op = operation
+	}
+	return r
 }
 
 // GET 注册一个新的 GET 请求路由，并将其与处理函数绑定。
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"runtime"
 	"strings"
@@ -15,6 +16,8 @@ import (
 	"time"
 
 	"github.com/cnsync/kratos/internal/host"
+	"github.com/cnsync/kratos/middleware"
+	"github.com/cnsync/kratos/transport"
 )
 
 const appJSONStr = "application/json"
@@ -248,3 +251,114 @@ func TestRouter_ContextDataRace(t *testing.T) {
 	_ = srv.Stop(ctx)
 	t.Log("test end")
 }
+
+// TestRouter_Name 测试命名路由与反向生成 URL
+func TestRouter_Name(t *testing.T) {
+	srv := NewServer(PathPrefix("/api"))
+	r := srv.Route("/v1")
+	r.GET("/users/{id:[0-9]+}", func(ctx Context) error { return nil })
+	r.Name("user").GET("/users/{id}/posts/{post}", func(ctx Context) error { return nil })
+	r.Name("post")
+
+	u, err := srv.URL("user", map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "/api/v1/users/42" {
+		t.Errorf("expected %q, got %q", "/api/v1/users/42", u)
+	}
+	if u, err = srv.URL("post", map[string]string{"id": "1", "post": "2"}); err != nil || u != "/api/v1/users/1/posts/2" {
+		t.Errorf("unexpected url %q: %v", u, err)
+	}
+	// 参数不满足匹配规则
+	if _, err = srv.URL("user", map[string]string{"id": "abc"}); err == nil {
+		t.Error("expected error for mismatched param")
+	}
+	// 缺少参数
+	if _, err = srv.URL("post", map[string]string{"id": "1"}); err == nil {
+		t.Error("expected error for missing param")
+	}
+	if _, err = srv.URL("unknown", nil); err == nil {
+		t.Error("expected error for unknown route")
+	}
+}
+
+// TestRouter_Use 测试路由组的中间件
+func TestRouter_Use(t *testing.T) {
+	var calls []string
+	mw := func(name string) middleware.Middleware {
+		return func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, name)
+				return handler(ctx, req)
+			}
+		}
+	}
+	srv := NewServer(Middleware(mw("server")))
+	handler := func(ctx Context) error {
+		h := ctx.Middleware(func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		_, err := h(ctx, nil)
+		return err
+	}
+	api := srv.Route("/api").Use(mw("api"))
+	api.GET("/users", handler)
+	api.Group("/admin").Use(mw("admin")).GET("/stats", handler)
+	srv.Route("/").GET("/health", handler)
+
+	tests := map[string][]string{
+		"/api/users":       {"server", "api"},
+		"/api/admin/stats": {"server", "api", "admin"},
+		"/health":          {"server"},
+	}
+	for path, want := range tests {
+		calls = nil
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("%s: want %v, got %v", path, want, calls)
+		}
+	}
+}
+
+// TestRouter_Operation 测试为原生 HTTP 路由设置操作名称
+func TestRouter_Operation(t *testing.T) {
+	var calls []string
+	srv := NewServer()
+	srv.Use("/api.user.v1.User/*", func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls = append(calls, "selected")
+			return handler(ctx, req)
+		}
+	})
+	handler := func(ctx Context) error {
+		tr, _ := transport.FromServerContext(ctx)
+		calls = append(calls, tr.Operation())
+		_, err := ctx.Middleware(func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		})(ctx, nil)
+		return err
+	}
+	r := srv.Route("/")
+	r.GET("/users/{id}", handler)
+	r.Operation("/api.user.v1.User/GetUser")
+	r.GET("/health", handler)
+	srv.Handle("/download/{name}", OperationHandler("/api.file.v1.File/Download", http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		tr, _ := transport.FromServerContext(req.Context())
+		calls = append(calls, tr.Operation())
+	})))
+
+	tests := map[string][]string{
+		// 中间件按照设置的操作名称匹配
+		"/users/1":      {"/api.user.v1.User/GetUser", "selected"},
+		"/health":       {"/health"},
+		"/download/a.b": {"/api.file.v1.File/Download"},
+	}
+	for path, want := range tests {
+		calls = nil
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("%s: want %v, got %v", path, want, calls)
+		}
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -49,6 +52,76 @@ func Endpoint(endpoint *url.URL) ServerOption {
 	}
 }
 
+// InstanceMetadata 设置注册到服务发现中的实例元数据，如 zone，多次设置时合并。
+// 应用的 kratos.Metadata 选项中的同名键优先。
+func InstanceMetadata(md map[string]string) ServerOption {
+	return func(s *Server) {
+		if s.instanceMetadata == nil {
+			s.instanceMetadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			s.instanceMetadata[k] = v
+		}
+	}
+}
+
+// Weight 设置注册到服务发现中的实例权重，即元数据中的 "weight"，负载均衡器据此分配流量。
+func Weight(weight int) ServerOption {
+	return InstanceMetadata(map[string]string{"weight": strconv.Itoa(weight)})
+}
+
+// AdvertiseScheme 配置注册到服务发现中的端点协议，如 "https"。
+// 当 TLS 由边车代理或负载均衡器终止时，本地监听器不使用 TLS，但客户端仍需以 https 访问。
+// 默认根据是否配置了 TLSConfig 选择 "http" 或 "https"。
+func AdvertiseScheme(scheme string) ServerOption {
+	return func(s *Server) {
+		s.advertiseScheme = scheme
+	}
+}
+
+// AdvertiseAddress 设置注册到服务发现中的端点地址，例如 NAT 之后的公网地址。
+// 地址可以是 host 或 host:port，未指定端口时使用监听的端口。
+func AdvertiseAddress(addr string) ServerOption {
+	return func(o *Server) {
+		o.hostOpts.Advertise = addr
+	}
+}
+
+// AdvertiseInterface 设置自动提取端点地址时使用的网络接口，例如 "eth1"，用于多网卡的主机。
+func AdvertiseInterface(name string) ServerOption {
+	return func(o *Server) {
+		o.hostOpts.Interface = name
+	}
+}
+
+// AdvertiseSubnet 设置自动提取的端点地址必须属于的子网，例如 "10.0.0.0/8"。
+// 子网格式错误时启动服务器返回错误。
+func AdvertiseSubnet(cidr string) ServerOption {
+	return func(o *Server) {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			o.err = err
+			return
+		}
+		o.hostOpts.Subnet = subnet
+	}
+}
+
+// PreferIPv6 设置自动提取端点地址时是否优先选择 IPv6 地址，默认优先选择 IPv4 地址。
+func PreferIPv6(prefer bool) ServerOption {
+	return func(o *Server) {
+		o.hostOpts.PreferIPv6 = prefer
+	}
+}
+
+// MaxRequestBodySize 配置请求体的最大字节数，超出时 DefaultRequestDecoder 返回 413 错误。
+// 默认不限制请求体大小。
+func MaxRequestBodySize(size int64) ServerOption {
+	return func(s *Server) {
+		s.maxBodySize = size
+	}
+}
+
 // Timeout 配置服务器的超时时间。
 func Timeout(timeout time.Duration) ServerOption {
 	return func(s *Server) {
@@ -76,6 +149,13 @@ func Filter(filters ...FilterFunc) ServerOption {
 	}
 }
 
+// ErrorFilter 配置可以返回错误的过滤器，在 Filter 配置的过滤器之后执行，返回的错误由错误编码器编码。
+func ErrorFilter(filters ...ErrorFilterFunc) ServerOption {
+	return func(o *Server) {
+		o.errorFilters = append(o.errorFilters, filters...)
+	}
+}
+
 // RequestVarsDecoder 配置请求参数解码器。
 func RequestVarsDecoder(dec DecodeRequestFunc) ServerOption {
 	return func(o *Server) {
@@ -118,6 +198,14 @@ func TLSConfig(c *tls.Config) ServerOption {
 	}
 }
 
+// TLSCertificate 配置动态证书提供者，每次握手时从提供者获取服务端证书，
+// 配合 transport.NewFileCertificate 可以在证书文件轮换后无需重启即使用新证书。
+func TLSCertificate(p transport.CertificateProvider) ServerOption {
+	return func(o *Server) {
+		o.certProvider = p
+	}
+}
+
 // StrictSlash 配置 mux 的 StrictSlash。
 // 如果为 true，当访问 "/path" 时，自动重定向到 "/path/"，反之亦然。
 func StrictSlash(strictSlash bool) ServerOption {
@@ -126,6 +214,15 @@ func StrictSlash(strictSlash bool) ServerOption {
 	}
 }
 
+// ContextPool 配置是否通过 sync.Pool 复用路由处理函数的 Context，以减少高 QPS 下的内存分配。
+// 启用后，Context 及由其派生的 context.Context 在处理函数返回后不能再被使用，
+// 因此处理函数不能启动在其返回后仍持有 ctx 的 goroutine，例如异步记录日志或发送事件。
+func ContextPool(pool bool) ServerOption {
+	return func(o *Server) {
+		o.contextPool = pool
+	}
+}
+
 // Listener 配置服务器的监听器。
 func Listener(lis net.Listener) ServerOption {
 	return func(s *Server) {
@@ -155,25 +252,60 @@ func MethodNotAllowedHandler(handler http.Handler) ServerOption {
 	}
 }
 
+// OperationNormalizer 配置操作名称的规范化函数。
+// 当请求没有匹配到路由模板而回退为 URL 路径时，操作名称可能包含 ID 等高基数的值，
+// 可以通过该函数将其归并为模板，避免指标标签和限流键的数量膨胀。
+// 规范化后的结果同时作为 Transport 的 Operation 与 PathTemplate。
+func OperationNormalizer(fn func(operation string) string) ServerOption {
+	return func(s *Server) {
+		s.normalizer = fn
+	}
+}
+
 // Server 是 HTTP 服务器的封装，提供了更灵活的配置和中间件支持。
 type Server struct {
 	*http.Server
-	lis         net.Listener       // 网络监听器
-	tlsConf     *tls.Config        // TLS 配置
-	endpoint    *url.URL           // 服务器的端点 URL
-	err         error              // 错误信息
-	network     string             // 网络类型（TCP、UDP）
-	address     string             // 服务器地址
-	timeout     time.Duration      // 请求超时
-	filters     []FilterFunc       // 过滤器（中间件）
-	middleware  matcher.Matcher    // 中间件匹配器
-	decVars     DecodeRequestFunc  // 请求变量解码器
-	decQuery    DecodeRequestFunc  // 查询参数解码器
-	decBody     DecodeRequestFunc  // 请求体解码器
-	enc         EncodeResponseFunc // 响应编码器
-	ene         EncodeErrorFunc    // 错误编码器
-	strictSlash bool               // 是否启用严格斜杠
-	router      *mux.Router        // 路由器
+	lis           net.Listener                  // 网络监听器
+	tlsConf       *tls.Config                   // TLS 配置
+	certProvider  transport.CertificateProvider // 动态证书提供者
+	endpoint      *url.URL                      // 服务器的端点 URL
+	err           error                         // 错误信息
+	network       string                        // 网络类型（TCP、UDP）
+	address       string                        // 服务器地址
+	timeout       time.Duration                 // 请求超时
+	filters       []FilterFunc                  // 过滤器（中间件）
+	middleware    matcher.Matcher               // 中间件匹配器
+	decVars       DecodeRequestFunc             // 请求变量解码器
+	decQuery      DecodeRequestFunc             // 查询参数解码器
+	decBody       DecodeRequestFunc             // 请求体解码器
+	enc           EncodeResponseFunc            // 响应编码器
+	ene           EncodeErrorFunc               // 错误编码器
+	strictSlash   bool                          // 是否启用严格斜杠
+	router        *mux.Router                   // 路由器
+	engine        RouterEngine                  // 自定义的路由引擎，为空时使用 router
+	versionRoutes map[string]*versionRoutes     // 路由引擎中按 API 版本分发的路由
+	errorFilters  []ErrorFilterFunc             // 可以返回错误的过滤器
+	normalizer    func(string) string           // 操作名称规范化函数
+
+	overrideMethods map[string]struct{} // 允许通过请求头覆盖为的请求方法
+	autoOptions     bool                // 是否自动响应 OPTIONS 请求
+	autoHead        bool                // 是否使用 GET 路由处理 HEAD 请求
+
+	advertiseScheme string              // 注册到服务发现中的端点协议
+	maxBodySize     int64               // 请求体的最大字节数
+	contentTypes    map[string]struct{} // 允许的请求体媒体类型
+	inflight        chan struct{}       // 限制同时处理的请求数的信号量
+	limiter         *ipLimiter          // 每个客户端的请求速率限制
+	openapi         *openAPI            // OpenAPI 文档服务配置
+
+	instanceMetadata map[string]string   // 注册到服务发现中的实例元数据
+	hostOpts         host.ExtractOptions // 端点地址的选择选项
+
+	contextPool bool      // 是否复用路由处理函数的 Context
+	ctxPool     sync.Pool // 复用的 Context
+
+	versioning         *VersionStrategy     // API 版本的提取策略
+	deprecatedVersions map[string]time.Time // 已弃用的 API 版本及其停用时间
 }
 
 // NewServer 创建一个新的 HTTP 服务器，接受配置选项。
@@ -197,13 +329,48 @@ func NewServer(opts ...ServerOption) *Server {
 	for _, o := range opts {
 		o(srv)
 	}
+	if srv.certProvider != nil {
+		srv.tlsConf = transport.ServerTLSConfig(srv.tlsConf, srv.certProvider)
+	}
 	// 启用严格斜杠选项
 	srv.router.StrictSlash(srv.strictSlash)
-	// 添加中间件
-	srv.router.Use(srv.filter())
-	// 创建 HTTP 服务器
+	// 注册 OpenAPI 文档，文档无效时在启动服务器时返回错误
+	if srv.openapi != nil {
+		if err := srv.openapi.register(srv); err != nil {
+			srv.err = err
+		}
+	}
+	// 添加中间件，使用自定义的路由引擎时在注册路由时应用
+	var handler http.Handler = srv.router
+	if srv.engine != nil {
+		handler = srv.engine
+	} else {
+		srv.router.Use(srv.filter())
+	}
+	if srv.engine == nil && (srv.autoOptions || srv.autoHead) {
+		handler = srv.autoMethods(handler)
+	}
+	var filters []FilterFunc
+	// 连接级别的限制在其他过滤器之前执行，避免被拒绝的请求消耗资源
+	if srv.inflight != nil {
+		filters = append(filters, srv.errorFilter(srv.limitConcurrency))
+	}
+	if srv.limiter != nil {
+		filters = append(filters, srv.errorFilter(srv.limitRate))
+	}
+	if len(srv.overrideMethods) > 0 {
+		filters = append(filters, srv.methodOverride)
+	}
+	if len(srv.contentTypes) > 0 {
+		filters = append(filters, srv.errorFilter(srv.requireContentType))
+	}
+	filters = append(filters, srv.filters...)
+	for _, f := range srv.errorFilters {
+		filters = append(filters, srv.errorFilter(f))
+	}
+	// 创建 HTTP 服务器，过滤器链与处理器中的 panic 通过错误编码器返回 500 响应
 	srv.Server = &http.Server{
-		Handler:   FilterChain(srv.filters...)(srv.router),
+		Handler:   srv.recoverFilter(FilterChain(filters...)(handler)),
 		TLSConfig: srv.tlsConf,
 	}
 	return srv
@@ -217,6 +384,9 @@ func (s *Server) Use(selector string, m ...middleware.Middleware) {
 
 // WalkRoute 遍历路由器及其子路由，调用提供的回调函数处理每个路由。
 func (s *Server) WalkRoute(fn WalkRouteFunc) error {
+	if s.engine != nil {
+		return s.engine.Walk(fn)
+	}
 	return s.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
 		methods, err := route.GetMethods()
 		if err != nil {
@@ -248,26 +418,78 @@ func (s *Server) Route(prefix string, filters ...FilterFunc) *Router {
 	return newRouter(prefix, s, filters...)
 }
 
+// URL 根据路由名称和路径参数生成路由的 URL 路径，用于 Location 响应头、超媒体链接等场景。
+// 路由模板中的所有参数都必须提供，并且满足参数的匹配规则。
+func (s *Server) URL(name string, params map[string]string) (string, error) {
+	if s.engine != nil {
+		return "", fmt.Errorf("http: route names require the gorilla/mux router engine")
+	}
+	route := s.router.Get(name)
+	if route == nil {
+		return "", fmt.Errorf("http: route %q not found", name)
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, k, v)
+	}
+	u, err := route.URLPath(pairs...)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
 // Handle 注册一个新路由。
 func (s *Server) Handle(path string, h http.Handler) {
-	s.router.Handle(path, h)
+	s.handle(path, h)
 }
 
 // HandlePrefix 注册一个带有路径前缀的路由。
 func (s *Server) HandlePrefix(prefix string, h http.Handler) {
-	s.router.PathPrefix(prefix).Handler(h)
+	s.handlePrefix(prefix, h)
 }
 
 // HandleFunc 注册一个带有路径匹配的路由，处理器为 http.HandlerFunc。
 func (s *Server) HandleFunc(path string, h http.HandlerFunc) {
-	s.router.HandleFunc(path, h)
+	s.handle(path, h)
 }
 
-// HandleHeader 根据请求头注册路由。
+// HandleHeader 根据请求头注册路由，只支持 gorilla/mux 路由引擎，
+// 使用其他路由引擎时路由不会注册，错误在获取端点或启动服务器时返回。
 func (s *Server) HandleHeader(key, val string, h http.HandlerFunc) {
+	if s.engine != nil {
+		if s.err == nil {
+			s.err = errors.New("http: HandleHeader requires the gorilla/mux router engine")
+		}
+		return
+	}
 	s.router.Headers(key, val).Handler(h)
 }
 
+// acquireContext 获取路由处理函数使用的 Context。
+func (s *Server) acquireContext(r *Router, res http.ResponseWriter, req *http.Request) *wrapper {
+	var c *wrapper
+	if s.contextPool {
+		c, _ = s.ctxPool.Get().(*wrapper)
+	}
+	if c == nil {
+		c = &wrapper{}
+	}
+	c.router = r
+	c.Reset(res, req)
+	return c
+}
+
+// releaseContext 在处理函数返回后回收 Context。
+func (s *Server) releaseContext(c *wrapper) {
+	if !s.contextPool {
+		return
+	}
+	c.Reset(nil, nil)
+	c.router = nil
+	s.ctxPool.Put(c)
+}
+
 // ServeHTTP 处理 HTTP 请求并返回响应。
 func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	s.Handler.ServeHTTP(res, req)
@@ -288,11 +510,24 @@ func (s *Server) filter() mux.MiddlewareFunc {
 			}
 			defer cancel()
 
+			// 限制请求体大小，避免读取过大的请求体耗尽内存
+			if s.maxBodySize > 0 && req.Body != nil {
+				req.Body = http.MaxBytesReader(w, req.Body, s.maxBodySize)
+			}
+
 			// 获取路径模板，可能包含占位符
 			pathTemplate := req.URL.Path
-			if route := mux.CurrentRoute(req); route != nil {
+			if s.engine != nil {
+				if t := s.engine.Template(req); t != "" {
+					pathTemplate = t
+				}
+			} else if route := mux.CurrentRoute(req); route != nil {
 				pathTemplate, _ = route.GetPathTemplate()
 			}
+			// 规范化操作名称，避免高基数的操作名称
+			if s.normalizer != nil {
+				pathTemplate = s.normalizer(pathTemplate)
+			}
 
 			// 创建一个 Transport 对象封装 HTTP 请求和响应
 			tr := &Transport{
@@ -306,6 +541,7 @@ func (s *Server) filter() mux.MiddlewareFunc {
 			if s.endpoint != nil {
 				tr.endpoint = s.endpoint.String()
 			}
+			s.applyVersion(w, req, tr)
 			tr.request = req.WithContext(transport.NewServerContext(ctx, tr))
 			// 调用下一个中间件或处理器
 			next.ServeHTTP(w, tr.request)
@@ -313,6 +549,11 @@ func (s *Server) filter() mux.MiddlewareFunc {
 	}
 }
 
+// InstanceMetadata 返回注册到服务发现中的实例元数据。
+func (s *Server) InstanceMetadata() map[string]string {
+	return s.instanceMetadata
+}
+
 // Endpoint 返回实际的服务器地址和端点。
 func (s *Server) Endpoint() (*url.URL, error) {
 	if err := s.listenAndEndpoint(); err != nil {
@@ -352,6 +593,10 @@ func (s *Server) Stop(ctx context.Context) error {
 
 // listenAndEndpoint 初始化监听器并确定服务器的端点地址。
 func (s *Server) listenAndEndpoint() error {
+	// 配置错误时不监听端口，避免启动失败后监听器无法关闭
+	if s.err != nil {
+		return s.err
+	}
 	if s.lis == nil {
 		lis, err := net.Listen(s.network, s.address)
 		if err != nil {
@@ -360,13 +605,21 @@ func (s *Server) listenAndEndpoint() error {
 		}
 		s.lis = lis
 	}
+	// Unix 域套接字使用 unix:///path 形式的端点
+	if addr, ok := s.lis.Addr().(*net.UnixAddr); ok && s.endpoint == nil {
+		s.endpoint = endpoint.NewUnixEndpoint(addr.Name)
+	}
 	if s.endpoint == nil {
-		addr, err := host.Extract(s.address, s.lis)
+		addr, err := host.ExtractWithOptions(s.address, s.lis, s.hostOpts)
 		if err != nil {
 			s.err = err
 			return err
 		}
-		s.endpoint = endpoint.NewEndpoint(endpoint.Scheme("http", s.tlsConf != nil), addr)
+		scheme := endpoint.Scheme("http", s.tlsConf != nil)
+		if s.advertiseScheme != "" {
+			scheme = s.advertiseScheme
+		}
+		s.endpoint = endpoint.NewEndpoint(scheme, addr)
 	}
 	return s.err
 }
@@ -9,13 +9,17 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	kratoserrors "github.com/cnsync/kratos/errors"
+	"github.com/cnsync/kratos/internal/endpoint"
 	"github.com/cnsync/kratos/internal/host"
+	"github.com/cnsync/kratos/transport"
 )
 
 var h = func(w http.ResponseWriter, r *http.Request) {
@@ -343,6 +347,56 @@ func TestTLSConfig(t *testing.T) {
 	}
 }
 
+type stubCertificate struct{ cert *tls.Certificate }
+
+func (s *stubCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.cert, nil
+}
+
+func (s *stubCertificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.cert, nil
+}
+
+func TestTLSCertificate(t *testing.T) {
+	p := &stubCertificate{cert: &tls.Certificate{}}
+	srv := NewServer(TLSCertificate(p), TLSConfig(&tls.Config{MinVersion: tls.VersionTLS13}))
+	if srv.tlsConf == nil || srv.tlsConf.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected tls config with min version tls1.3, got %v", srv.tlsConf)
+	}
+	// 握手时应当从提供者获取证书
+	cert, err := srv.tlsConf.GetCertificate(nil)
+	if err != nil || cert != p.cert {
+		t.Errorf("expected %v, got %v", p.cert, cert)
+	}
+}
+
+func TestAdvertiseScheme(t *testing.T) {
+	tests := []struct {
+		opts []ServerOption
+		want string
+	}{
+		{nil, "http"},
+		{[]ServerOption{TLSConfig(&tls.Config{})}, "https"},
+		{[]ServerOption{AdvertiseScheme("https")}, "https"},
+	}
+	for _, test := range tests {
+		srv := NewServer(append(test.opts, Address("127.0.0.1:0"))...)
+		e, err := srv.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Scheme != test.want {
+			t.Errorf("expected %s got %s", test.want, e.Scheme)
+		}
+		// 安全连接的客户端能够从注册的端点中解析出地址
+		host, err := endpoint.ParseEndpoint([]string{e.String()}, endpoint.Scheme("http", test.want == "https"))
+		if err != nil || host != e.Host {
+			t.Errorf("expected %s got %s", e.Host, host)
+		}
+		_ = srv.lis.Close()
+	}
+}
+
 func TestStrictSlash(t *testing.T) {
 	o := &Server{}
 	v := true
@@ -382,3 +436,107 @@ func TestMethodNotAllowedHandler(t *testing.T) {
 		t.Errorf("expected %v got %v", mux, srv.router.MethodNotAllowedHandler)
 	}
 }
+
+func TestOperationNormalizer(t *testing.T) {
+	srv := NewServer(OperationNormalizer(func(op string) string {
+		if strings.HasPrefix(op, "/users/") {
+			return "/users/{id}"
+		}
+		return op
+	}))
+	var operation, pathTemplate string
+	srv.HandlePrefix("/users/", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if tr, ok := transport.FromServerContext(r.Context()); ok {
+			operation = tr.Operation()
+			pathTemplate = tr.(Transporter).PathTemplate()
+		}
+	}))
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+	if operation != "/users/{id}" {
+		t.Errorf("expected operation %s got %s", "/users/{id}", operation)
+	}
+	if pathTemplate != "/users/{id}" {
+		t.Errorf("expected path template %s got %s", "/users/{id}", pathTemplate)
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	srv := NewServer(MaxRequestBodySize(16))
+	srv.Route("/").POST("/users", func(ctx Context) error {
+		var in map[string]string
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		return ctx.Result(http.StatusOK, in)
+	})
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"name":"kratos"}`, http.StatusRequestEntityTooLarge},
+		{`{"name":"k"}`, http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("expected %d got %d: %s", test.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAdvertiseAddress(t *testing.T) {
+	srv := NewServer(Address(":0"), AdvertiseAddress("203.0.113.1:8443"))
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Host != "203.0.113.1:8443" {
+		t.Errorf("expected %s got %s", "203.0.113.1:8443", e.Host)
+	}
+	_ = srv.lis.Close()
+
+	// 子网格式错误
+	srv = NewServer(Address(":0"), AdvertiseSubnet("10.0.0.0"))
+	if _, err = srv.Endpoint(); err == nil {
+		t.Error("expected error for invalid subnet")
+	}
+	if srv.lis != nil {
+		_ = srv.lis.Close()
+		t.Error("expected no listener for invalid subnet")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "http.sock")
+	srv := NewServer(Network("unix"), Address(sock))
+	srv.Route("/").GET("/ping", func(ctx Context) error {
+		return ctx.Result(http.StatusOK, &testData{Path: "pong"})
+	})
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.String() != "unix://"+sock {
+		t.Errorf("expected %s got %s", "unix://"+sock, e)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() { _ = srv.Stop(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := NewClient(context.Background(), WithEndpoint(e.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res testData
+	if err = client.Invoke(context.Background(), http.MethodGet, "/ping", nil, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Path != "pong" {
+		t.Errorf("expected pong got %s", res.Path)
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// ShadowHeader 是镜像请求携带的请求头，影子服务可以据此避免产生副作用。
+const ShadowHeader = "X-Kratos-Shadow"
+
+// ShadowOption 是请求镜像过滤器的配置选项。
+type ShadowOption func(*shadowOptions)
+
+// shadowOptions 是请求镜像过滤器的配置。
+type shadowOptions struct {
+	maxBody int64
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+// ShadowMaxBody 设置镜像请求体的大小上限，默认为 1MB，超过上限的请求不会被镜像。
+func ShadowMaxBody(n int64) ShadowOption {
+	return func(o *shadowOptions) {
+		o.maxBody = n
+	}
+}
+
+// ShadowTimeout 设置镜像请求的超时时间，默认为 5s。
+func ShadowTimeout(d time.Duration) ShadowOption {
+	return func(o *shadowOptions) {
+		o.timeout = d
+	}
+}
+
+// ShadowConcurrency 设置同时进行的镜像请求数量上限，默认为 64，达到上限时新的请求不会被镜像。
+func ShadowConcurrency(n int) ShadowOption {
+	return func(o *shadowOptions) {
+		if n > 0 {
+			o.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// Shadow 返回一个按比例将请求异步镜像到影子服务的过滤器，用于在生产流量下安全地验证新版本。
+// match 为需要镜像的请求的匹配条件，为 nil 时匹配所有请求；rate 为匹配的请求中被镜像的比例，取值范围为 [0, 1]。镜像请求使用 target 客户端发送，复制原请求的方法、路径、查询参数、
+// 请求头与请求体，并携带 ShadowHeader 请求头；镜像请求的响应与错误会被丢弃，不影响原请求的处理与响应。
+func Shadow(match func(*http.Request) bool, target *Client, rate float64, opts ...ShadowOption) FilterFunc {
+	o := &shadowOptions{
+		maxBody: 1 << 20,
+		timeout: 5 * time.Second,
+		sem:     make(chan struct{}, 64),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rate > 0 && (rate >= 1 || rand.Float64() < rate) && (match == nil || match(r)) {
+				if body, ok := o.copyBody(r); ok {
+					o.mirror(target, r, body)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// copyBody 读取并复制请求体，原请求的请求体保持可读。请求体超过上限或读取失败时返回 false。
+func (o *shadowOptions) copyBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	if r.ContentLength > o.maxBody {
+		return nil, false
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, o.maxBody+1))
+	if err != nil || int64(len(buf)) > o.maxBody {
+		// 已读取的部分放回请求体，保证原请求的处理不受影响
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(buf))
+	return buf, true
+}
+
+// mirror 异步发送镜像请求，达到并发上限时丢弃。
+func (o *shadowOptions) mirror(target *Client, r *http.Request, body []byte) {
+	select {
+	case o.sem <- struct{}{}:
+	default:
+		return
+	}
+	header := r.Header.Clone()
+	header.Set(ShadowHeader, "true")
+	method, uri := r.Method, r.URL.RequestURI()
+	go func() {
+		defer func() { <-o.sem }()
+		ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+		defer cancel()
+		url := target.target.Scheme + "://" + target.target.Authority + uri
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header = header
+		resp, err := target.Do(req)
+		if err != nil {
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+}
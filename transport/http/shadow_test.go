@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type shadowRecord struct {
+	method string
+	uri    string
+	body   string
+	header string
+}
+
+// newShadowTarget 创建记录镜像请求的影子服务及其客户端
+func newShadowTarget(t *testing.T) (*Client, chan shadowRecord) {
+	ch := make(chan shadowRecord, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		ch <- shadowRecord{r.Method, r.URL.RequestURI(), string(b), r.Header.Get(ShadowHeader)}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewClient(context.Background(), WithEndpoint(strings.TrimPrefix(srv.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, ch
+}
+
+// serveShadow 通过镜像过滤器处理请求，返回原请求处理器读取到的请求体与响应状态码
+func serveShadow(filter FilterFunc, r *http.Request) (string, int) {
+	var body string
+	h := filter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return body, w.Code
+}
+
+func TestShadow(t *testing.T) {
+	client, ch := newShadowTarget(t)
+	filter := Shadow(func(r *http.Request) bool { return r.Method == http.MethodPost }, client, 1)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/users?x=1", strings.NewReader("payload"))
+	body, code := serveShadow(filter, r)
+	if body != "payload" || code != http.StatusAccepted {
+		t.Errorf("primary got %q %d", body, code)
+	}
+	select {
+	case rec := <-ch:
+		if rec.method != http.MethodPost || rec.uri != "/v1/users?x=1" || rec.body != "payload" || rec.header != "true" {
+			t.Errorf("unexpected shadow request %+v", rec)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("request not mirrored")
+	}
+
+	// 不匹配的请求不被镜像
+	serveShadow(filter, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	select {
+	case rec := <-ch:
+		t.Errorf("unexpected shadow request %+v", rec)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestShadowSkip(t *testing.T) {
+	client, ch := newShadowTarget(t)
+
+	// 镜像比例为 0
+	serveShadow(Shadow(nil, client, 0), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a")))
+
+	// 请求体超过上限时不镜像，原请求的请求体保持完整
+	r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("0123456789")))
+	r.ContentLength = -1
+	body, _ := serveShadow(Shadow(nil, client, 1, ShadowMaxBody(4)), r)
+	if body != "0123456789" {
+		t.Errorf("expected full body, got %q", body)
+	}
+	select {
+	case rec := <-ch:
+		t.Errorf("unexpected shadow request %+v", rec)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
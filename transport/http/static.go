@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// defaultStaticCacheControl 是静态文件默认的 Cache-Control 响应头。
+const defaultStaticCacheControl = "public, max-age=3600"
+
+// StaticOption 是静态文件服务的配置选项。
+type StaticOption func(*staticOptions)
+
+// staticOptions 是静态文件服务的配置。
+type staticOptions struct {
+	cacheControl string
+	listing      bool
+}
+
+// StaticCacheControl 配置静态文件的 Cache-Control 响应头，默认为 "public, max-age=3600"。
+// 文件名带有内容哈希的资源可以配置为 "public, max-age=31536000, immutable"。
+func StaticCacheControl(value string) StaticOption {
+	return func(o *staticOptions) {
+		o.cacheControl = value
+	}
+}
+
+// StaticListing 配置是否允许列出目录内容，默认不允许。
+func StaticListing(listing bool) StaticOption {
+	return func(o *staticOptions) {
+		o.listing = listing
+	}
+}
+
+// Static 在指定前缀下提供文件系统中的静态文件，例如 embed.FS。
+// 支持 Range 请求与 If-Modified-Since 条件请求，请求路径中的 ".." 不会访问到文件系统之外的文件。
+func (s *Server) Static(prefix string, fsys fs.FS, opts ...StaticOption) {
+	o := &staticOptions{cacheControl: defaultStaticCacheControl}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	files := http.FileServer(http.FS(fsys))
+	h := http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := staticName(r.URL.Path)
+		if !o.listing {
+			if info, err := fs.Stat(fsys, name); err == nil && info.IsDir() {
+				if _, err = fs.Stat(fsys, path.Join(name, "index.html")); err != nil {
+					http.NotFound(w, r)
+					return
+				}
+			}
+		}
+		if o.cacheControl != "" {
+			w.Header().Set("Cache-Control", o.cacheControl)
+		}
+		files.ServeHTTP(w, r)
+	}))
+	s.handlePrefix(prefix+"/", h, http.MethodGet, http.MethodHead)
+}
+
+// SPA 在指定前缀下提供单页应用，文件系统中不存在的页面路径返回 index 文件，由前端路由处理。
+// 带有扩展名的资源不存在时仍返回 404。index 文件不缓存，其他文件使用 Static 的缓存配置。
+// 前缀为 "/" 时会匹配所有未注册的路径，应在注册其他路由之后调用。
+func (s *Server) SPA(prefix string, fsys fs.FS, index string, opts ...StaticOption) {
+	o := &staticOptions{cacheControl: defaultStaticCacheControl}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	files := http.FileServer(http.FS(fsys))
+	h := http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := staticName(r.URL.Path)
+		info, err := fs.Stat(fsys, name)
+		switch {
+		case err == nil && !info.IsDir() && name != index:
+			if o.cacheControl != "" {
+				w.Header().Set("Cache-Control", o.cacheControl)
+			}
+			files.ServeHTTP(w, r)
+		case err != nil && path.Ext(name) != "":
+			http.NotFound(w, r)
+		default:
+			serveIndex(w, r, fsys, index)
+		}
+	}))
+	s.handlePrefix(prefix+"/", h, http.MethodGet, http.MethodHead)
+}
+
+// staticName 将请求路径转换为文件系统中的文件名。
+func staticName(p string) string {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// serveIndex 返回单页应用的 index 文件，并禁止缓存以便及时获取新版本。
+func serveIndex(w http.ResponseWriter, r *http.Request, fsys fs.FS, index string) {
+	f, err := fsys.Open(index)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, index, info.ModTime(), content)
+}
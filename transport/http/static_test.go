@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+var staticFS = fstest.MapFS{
+	"index.html":      {Data: []byte("<html>index</html>")},
+	"assets/app.js":   {Data: []byte("console.log('kratos')")},
+	"docs/index.html": {Data: []byte("<html>docs</html>")},
+}
+
+func TestStatic(t *testing.T) {
+	srv := NewServer()
+	srv.Static("/static/", staticFS, StaticCacheControl("public, max-age=60"))
+	tests := []struct {
+		path string
+		code int
+		body string
+		rng  string
+	}{
+		{"/static/assets/app.js", http.StatusOK, "console.log('kratos')", ""},
+		{"/static/assets/app.js", http.StatusPartialContent, "console", "bytes=0-6"},
+		{"/static/docs/", http.StatusOK, "<html>docs</html>", ""},
+		{"/static/assets/", http.StatusNotFound, "", ""},
+		{"/static/missing.js", http.StatusNotFound, "", ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.rng != "" {
+			req.Header.Set("Range", test.rng)
+		}
+		srv.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s: expected %d got %d", test.path, test.code, w.Code)
+			continue
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s: expected %q got %q", test.path, test.body, w.Body.String())
+		}
+		if w.Code < 300 && w.Header().Get("Cache-Control") != "public, max-age=60" {
+			t.Errorf("%s: unexpected Cache-Control %q", test.path, w.Header().Get("Cache-Control"))
+		}
+	}
+}
+
+func TestStaticName(t *testing.T) {
+	tests := map[string]string{
+		"":                   ".",
+		"/":                  ".",
+		"assets/app.js":      "assets/app.js",
+		"/../../etc/passwd":  "etc/passwd",
+		"assets/../../x.txt": "x.txt",
+	}
+	for p, want := range tests {
+		if got := staticName(p); got != want {
+			t.Errorf("%s: expected %s got %s", p, want, got)
+		}
+	}
+}
+
+func TestSPA(t *testing.T) {
+	srv := NewServer()
+	srv.SPA("/", staticFS, "index.html")
+	tests := []struct {
+		path         string
+		code         int
+		body         string
+		cacheControl string
+	}{
+		{"/", http.StatusOK, "<html>index</html>", "no-cache"},
+		{"/users/1", http.StatusOK, "<html>index</html>", "no-cache"},
+		{"/index.html", http.StatusOK, "<html>index</html>", "no-cache"},
+		{"/assets/app.js", http.StatusOK, "console.log('kratos')", defaultStaticCacheControl},
+		{"/assets/missing.js", http.StatusNotFound, "", ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s: expected %d got %d", test.path, test.code, w.Code)
+			continue
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s: expected %q got %q", test.path, test.body, w.Body.String())
+		}
+		if test.cacheControl != "" && w.Header().Get("Cache-Control") != test.cacheControl {
+			t.Errorf("%s: expected Cache-Control %q got %q", test.path, test.cacheControl, w.Header().Get("Cache-Control"))
+		}
+	}
+}
@@ -25,6 +25,7 @@ type Transport struct {
 	request      *http.Request       // HTTP 请求对象
 	response     http.ResponseWriter // HTTP 响应对象
 	pathTemplate string              // 请求路径模板
+	version      string              // 请求的 API 版本
 }
 
 // Kind 返回当前 Transport 的协议类型，这里是 HTTP。
@@ -71,6 +72,24 @@ func SetOperation(ctx context.Context, op string) {
 	}
 }
 
+// OperationHandler 返回一个处理器，在调用 h 之前将请求的操作名称设置为 operation，
+// 使通过 Server.Handle、Server.HandleFunc 等注册的原生 HTTP 路由也可以使用稳定的操作名称，例如：
+//
+//	srv.Handle("/download/{name}", http.OperationHandler("/api.file.v1.File/Download", handler))
+func OperationHandler(operation string, h http.Handler) http.Handler {
+	return operationHandler(&operation, h)
+}
+
+// operationHandler 返回一个处理器，在 operation 不为空时设置请求的操作名称。
+func operationHandler(operation *string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if *operation != "" {
+			SetOperation(req.Context(), *operation)
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
 // SetCookie 向 HTTP 响应头添加一个 Set-Cookie 信息。
 // 提供的 Cookie 必须有有效的 Name，若无效则会被忽略。
 func SetCookie(ctx context.Context, cookie *http.Cookie) {
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cnsync/kratos/internal/httputil"
+	"github.com/cnsync/kratos/transport"
+)
+
+// versionSegment 匹配路径中表示 API 版本的片段，例如 v1、v2beta1。
+var versionSegment = regexp.MustCompile(`^v[0-9]+[a-z0-9]*$`)
+
+// versionSource 是 API 版本在请求中的位置。
+type versionSource int
+
+const (
+	versionInPath versionSource = iota
+	versionInHeader
+	versionInQuery
+	versionInMediaType
+)
+
+// VersionStrategy 是从请求中提取 API 版本的策略。
+type VersionStrategy struct {
+	source versionSource
+	key    string
+	def    string
+}
+
+// VersionPath 返回从路径前缀中提取版本的策略，版本为路径中第一个形如 v1、v2beta1 的片段，例如 /api/v2/users。
+func VersionPath() VersionStrategy {
+	return VersionStrategy{source: versionInPath}
+}
+
+// VersionHeader 返回从请求头中提取版本的策略，例如 VersionHeader("X-API-Version")。
+func VersionHeader(key string) VersionStrategy {
+	return VersionStrategy{source: versionInHeader, key: key}
+}
+
+// VersionQuery 返回从查询参数中提取版本的策略，例如 VersionQuery("version")。
+func VersionQuery(key string) VersionStrategy {
+	return VersionStrategy{source: versionInQuery, key: key}
+}
+
+// VersionMediaType 返回从媒体类型中提取版本的策略，用于基于内容协商的 API 版本，
+// 版本取自 Accept 请求头，没有时取自 Content-Type 请求头。支持厂商类型中形如 v2 的片段，
+// 例如 application/vnd.myapp.v2+json，以及 version 参数，例如 application/json; version=2 的版本为 v2。
+func VersionMediaType() VersionStrategy {
+	return VersionStrategy{source: versionInMediaType}
+}
+
+// Default 返回请求未指定版本时使用默认版本的策略。
+func (vs VersionStrategy) Default(version string) VersionStrategy {
+	vs.def = version
+	return vs
+}
+
+// Extract 返回请求的 API 版本，请求未指定版本时返回默认版本。
+func (vs VersionStrategy) Extract(r *http.Request) string {
+	var v string
+	switch vs.source {
+	case versionInHeader:
+		v = r.Header.Get(vs.key)
+	case versionInQuery:
+		v = r.URL.Query().Get(vs.key)
+	case versionInMediaType:
+		if v = headerMediaTypeVersion(r.Header.Values("Accept")); v == "" {
+			v = headerMediaTypeVersion(r.Header.Values("Content-Type"))
+		}
+	default:
+		for _, seg := range strings.Split(r.URL.Path, "/") {
+			if versionSegment.MatchString(seg) {
+				v = seg
+				break
+			}
+		}
+	}
+	if v == "" {
+		return vs.def
+	}
+	return v
+}
+
+// headerMediaTypeVersion 返回请求头中第一个带有版本的媒体类型的版本。
+func headerMediaTypeVersion(values []string) string {
+	for _, value := range values {
+		for _, mediaType := range strings.Split(value, ",") {
+			if v := mediaTypeVersion(mediaType); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// mediaTypeVersion 返回媒体类型的版本，version 参数优先于厂商类型中的版本片段。
+func mediaTypeVersion(mediaType string) string {
+	if v := httputil.MediaTypeParam(mediaType, "version"); v != "" {
+		if !strings.HasPrefix(v, "v") {
+			v = "v" + v
+		}
+		return v
+	}
+	subtype, _, _ := strings.Cut(httputil.ContentSubtype(mediaType), "+")
+	if !strings.HasPrefix(subtype, "vnd.") {
+		return ""
+	}
+	segs := strings.Split(subtype, ".")
+	for i := len(segs) - 1; i > 0; i-- {
+		if versionSegment.MatchString(segs[i]) {
+			return segs[i]
+		}
+	}
+	return ""
+}
+
+// Versioning 配置提取 API 版本的策略。配置后版本可以通过 Transport.Version 获取，
+// 使用请求头或查询参数指定版本时，操作名称会加上版本前缀，例如 /v2/users/{id}，
+// 以便中间件的匹配器与监控指标区分不同的版本。
+func Versioning(strategy VersionStrategy) ServerOption {
+	return func(s *Server) {
+		s.versioning = &strategy
+	}
+}
+
+// DeprecatedVersion 将 API 版本标记为已弃用，该版本的响应会带有 Deprecation 响应头，
+// sunset 非零时还会带有表示停用时间的 Sunset 响应头。未配置 Versioning 时从路径前缀中提取版本。
+func DeprecatedVersion(version string, sunset time.Time) ServerOption {
+	return func(s *Server) {
+		if s.deprecatedVersions == nil {
+			s.deprecatedVersions = make(map[string]time.Time)
+		}
+		s.deprecatedVersions[version] = sunset
+	}
+}
+
+// Version 返回请求的 API 版本，未配置版本策略时返回空字符串。
+func (tr *Transport) Version() string {
+	return tr.version
+}
+
+// VersionFromContext 返回服务端上下文中请求的 API 版本。
+func VersionFromContext(ctx context.Context) string {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if tr, ok := tr.(*Transport); ok {
+			return tr.version
+		}
+	}
+	return ""
+}
+
+// Version 返回只处理指定 API 版本的路由组。
+// 使用路径前缀指定版本时，路由注册在每个版本的路径前缀下，例如 r.Version("v1", "v2").GET("/users", h)
+// 注册 /v1/users 与 /v2/users；使用请求头或查询参数指定版本时，路由只匹配这些版本的请求。
+func (r *Router) Version(versions ...string) *Router {
+	nr := newRouter(r.prefix, r.srv, r.filters...)
+	nr.middleware = append(nr.middleware, r.middleware...)
+	nr.versions = append(nr.versions, versions...)
+	return nr
+}
+
+// handleVersions 按版本策略注册路由。
+func (r *Router) handleVersions(method, relativePath string, next http.Handler) {
+	vs := r.srv.versionStrategy()
+	if vs.source == versionInPath {
+		for _, v := range r.versions {
+			r.route = r.srv.handle(path.Join(r.prefix, v, relativePath), next, method)
+		}
+		return
+	}
+	if r.srv.engine != nil {
+		r.route = nil
+		r.srv.handleVersion(method, path.Join(r.prefix, relativePath), r.versions, next)
+		return
+	}
+	versions := r.versions
+	r.route = r.srv.router.Handle(path.Join(r.prefix, relativePath), next).Methods(method).
+		MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+			v := vs.Extract(req)
+			for _, want := range versions {
+				if v == want {
+					return true
+				}
+			}
+			return false
+		})
+}
+
+// versionStrategy 返回服务器的版本策略，未配置时从路径前缀中提取版本。
+func (s *Server) versionStrategy() VersionStrategy {
+	if s.versioning != nil {
+		return *s.versioning
+	}
+	return VersionPath()
+}
+
+// applyVersion 提取请求的 API 版本，为操作名称加上版本前缀，并为已弃用的版本设置响应头。
+func (s *Server) applyVersion(w http.ResponseWriter, req *http.Request, tr *Transport) {
+	if s.versioning == nil && len(s.deprecatedVersions) == 0 {
+		return
+	}
+	vs := s.versionStrategy()
+	tr.version = vs.Extract(req)
+	if tr.version == "" {
+		return
+	}
+	if vs.source != versionInPath {
+		tr.operation = "/" + tr.version + tr.operation
+	}
+	if sunset, ok := s.deprecatedVersions[tr.version]; ok {
+		w.Header().Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cnsync/kratos/transport"
+)
+
+// versionHandler 返回请求的版本与操作名称
+func versionHandler(ctx Context) error {
+	return ctx.String(200, VersionFromContext(ctx)+" "+operationFromContext(ctx))
+}
+
+// operationFromContext 返回请求的操作名称
+func operationFromContext(ctx Context) string {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		return tr.Operation()
+	}
+	return ""
+}
+
+func doVersion(t *testing.T, srv *Server, r *http.Request) (int, string, http.Header) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	b, _ := io.ReadAll(w.Body)
+	return w.Code, string(b), w.Header()
+}
+
+// TestVersionPath 测试使用路径前缀指定版本
+func TestVersionPath(t *testing.T) {
+	sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := NewServer(DeprecatedVersion("v1", sunset))
+	srv.Route("/api").Version("v1", "v2").GET("/users/{id}", versionHandler)
+
+	code, body, header := doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil))
+	if code != 200 || body != "v1 /api/v1/users/{id}" {
+		t.Errorf("unexpected response %d %q", code, body)
+	}
+	if header.Get("Deprecation") != "true" || header.Get("Sunset") != sunset.Format(http.TimeFormat) {
+		t.Errorf("expected deprecation headers, got %v", header)
+	}
+
+	code, body, header = doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/api/v2/users/1", nil))
+	if code != 200 || body != "v2 /api/v2/users/{id}" || header.Get("Deprecation") != "" {
+		t.Errorf("unexpected response %d %q %v", code, body, header)
+	}
+
+	if code, _, _ = doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/api/v3/users/1", nil)); code == 200 {
+		t.Errorf("expected v3 not routed")
+	}
+}
+
+// TestVersionHeader 测试使用请求头指定版本
+func TestVersionHeader(t *testing.T) {
+	srv := NewServer(Versioning(VersionHeader("X-API-Version").Default("v1")))
+	r := srv.Route("/")
+	r.Version("v2").GET("/users", func(ctx Context) error {
+		return ctx.String(200, "new "+operationFromContext(ctx))
+	})
+	r.Version("v1").GET("/users", versionHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-API-Version", "v2")
+	if code, body, _ := doVersion(t, srv, req); code != 200 || body != "new /v2/users" {
+		t.Errorf("unexpected response %d %q", code, body)
+	}
+	// 未指定版本时使用默认版本
+	if code, body, _ := doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/users", nil)); code != 200 || body != "v1 /v1/users" {
+		t.Errorf("unexpected response %d %q", code, body)
+	}
+}
+
+// TestVersionQuery 测试使用查询参数指定版本
+func TestVersionQuery(t *testing.T) {
+	srv := NewServer(Versioning(VersionQuery("version")))
+	srv.Route("/").Version("v2").GET("/users", versionHandler)
+	if code, body, _ := doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/users?version=v2", nil)); code != 200 || body != "v2 /v2/users" {
+		t.Errorf("unexpected response %d %q", code, body)
+	}
+	if code, _, _ := doVersion(t, srv, httptest.NewRequest(http.MethodGet, "/users?version=v1", nil)); code == 200 {
+		t.Error("expected v1 not routed")
+	}
+}
+
+// TestVersionMediaType 测试使用媒体类型指定版本
+func TestVersionMediaType(t *testing.T) {
+	srv := NewServer(Versioning(VersionMediaType().Default("v1")))
+	r := srv.Route("/")
+	r.Version("v1").GET("/users", versionHandler)
+	r.Version("v2").GET("/users", versionHandler)
+
+	for _, tt := range []struct {
+		header, value, want string
+	}{
+		{"Accept", "application/vnd.myapp.v2+json", "v2 /v2/users"},
+		{"Accept", "application/json; version=2", "v2 /v2/users"},
+		{"Accept", "text/html, application/vnd.myapp.v2+json;q=0.9", "v2 /v2/users"},
+		{"Content-Type", "application/vnd.myapp.v2+json; charset=utf-8", "v2 /v2/users"},
+		// 未指定版本时使用默认版本
+		{"Accept", "application/json", "v1 /v1/users"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(tt.header, tt.value)
+		if code, body, _ := doVersion(t, srv, req); code != 200 || body != tt.want {
+			t.Errorf("%s: unexpected response %d %q", tt.value, code, body)
+		}
+	}
+}
@@ -26,6 +26,12 @@ type EndpointProvider interface {
 	Endpoint() (*url.URL, error)
 }
 
+// InstanceMetadataProvider 是提供注册实例元数据的接口
+type InstanceMetadataProvider interface {
+	// InstanceMetadata 返回注册到服务发现中的实例元数据
+	InstanceMetadata() map[string]string
+}
+
 // Header 是存储头部数据的接口
 type Header interface {
 	// Get 获取指定 key 的值